@@ -27,6 +27,21 @@ import (
 	"net/http"
 )
 
+// ErrorWriteStrategy writes the body and the status code of the JSON error responses of the token, introspection,
+// device authorization and backchannel authentication endpoints. The Content-Type, Cache-Control and Pragma headers
+// are set before the strategy is invoked.
+type ErrorWriteStrategy interface {
+	// WriteError writes err to rw. The HTTP status code of the response should be err.CodeField.
+	WriteError(rw http.ResponseWriter, err *RFC6749Error)
+}
+
+// ErrorHook is invoked before an error is written to a client. Debug details are available using err.Debug(),
+// even if they are not sent to the client.
+type ErrorHook func(err *RFC6749Error)
+
+// DefaultErrorWriteStrategy writes errors as defined in https://tools.ietf.org/html/rfc6749#section-5.2
+type DefaultErrorWriteStrategy struct{}
+
 func (f *Fosite) WriteAccessError(rw http.ResponseWriter, _ AccessRequester, err error) {
 	f.writeJsonError(rw, err)
 }
@@ -36,11 +51,21 @@ func (f *Fosite) writeJsonError(rw http.ResponseWriter, err error) {
 	rw.Header().Set("Cache-Control", "no-store")
 	rw.Header().Set("Pragma", "no-cache")
 
-	rfcerr := ErrorToRFC6749Error(err).WithLegacyFormat(f.UseLegacyErrorFormat).WithExposeDebug(f.SendDebugMessagesToClients)
+	rfcerr := ErrorToRFC6749Error(err)
+	f.runErrorHook(rfcerr)
+	rfcerr = rfcerr.WithLegacyFormat(f.UseLegacyErrorFormat).WithExposeDebug(f.SendDebugMessagesToClients)
+
+	s := f.ErrorWriteStrategy
+	if s == nil {
+		s = &DefaultErrorWriteStrategy{}
+	}
+	s.WriteError(rw, rfcerr)
+}
 
+func (s *DefaultErrorWriteStrategy) WriteError(rw http.ResponseWriter, rfcerr *RFC6749Error) {
 	js, err := json.Marshal(rfcerr)
 	if err != nil {
-		if f.SendDebugMessagesToClients {
+		if rfcerr.exposeDebug {
 			errorMessage := EscapeJSONString(err.Error())
 			http.Error(rw, fmt.Sprintf(`{"error":"server_error","error_description":"%s"}`, errorMessage), http.StatusInternalServerError)
 		} else {
@@ -53,3 +78,9 @@ func (f *Fosite) writeJsonError(rw http.ResponseWriter, err error) {
 	// ignoring the error because the connection is broken when it happens
 	_, _ = rw.Write(js)
 }
+
+func (f *Fosite) runErrorHook(err *RFC6749Error) {
+	if f.ErrorHook != nil {
+		f.ErrorHook(err)
+	}
+}
@@ -116,3 +116,75 @@ func TestWriteAccessError_RFC6749(t *testing.T) {
 		})
 	}
 }
+
+type traceIDErrorWriteStrategy struct{}
+
+func (s *traceIDErrorWriteStrategy) WriteError(rw http.ResponseWriter, err *RFC6749Error) {
+	rw.WriteHeader(err.CodeField)
+	_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+		"error":             err.ErrorField,
+		"error_description": err.GetDescription(),
+		"trace_id":          "some-trace-id",
+	})
+}
+
+func TestWriteAccessErrorWithErrorWriteStrategy(t *testing.T) {
+	f := &Fosite{ErrorWriteStrategy: &traceIDErrorWriteStrategy{}}
+
+	for k, write := range []func(rw http.ResponseWriter){
+		func(rw http.ResponseWriter) { f.WriteAccessError(rw, nil, ErrInvalidGrant.WithHint("Some hint.")) },
+		func(rw http.ResponseWriter) { f.WriteIntrospectionError(rw, ErrInvalidRequest.WithHint("Some hint.")) },
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			write(rw)
+
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			assert.Equal(t, "application/json;charset=UTF-8", rw.Header().Get("Content-Type"))
+			assert.Equal(t, "no-store", rw.Header().Get("Cache-Control"))
+
+			var params map[string]interface{}
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&params))
+			assert.Equal(t, "some-trace-id", params["trace_id"])
+			assert.Contains(t, params["error_description"], "Some hint.")
+			assert.NotEmpty(t, params["error"])
+		})
+	}
+}
+
+func TestWriteAccessErrorDebugSeparation(t *testing.T) {
+	for k, c := range []struct {
+		legacy bool
+		debug  bool
+	}{
+		{legacy: false, debug: false},
+		{legacy: true, debug: false},
+		{legacy: false, debug: true},
+		{legacy: true, debug: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			var hooked *RFC6749Error
+			f := &Fosite{
+				UseLegacyErrorFormat:       c.legacy,
+				SendDebugMessagesToClients: c.debug,
+				ErrorHook:                  func(err *RFC6749Error) { hooked = err },
+			}
+
+			rw := httptest.NewRecorder()
+			f.WriteAccessError(rw, nil, ErrInvalidGrant.WithHint("Some hint.").WithDebug("some-secret-debug").WithURI("https://example.com/errors/invalid_grant"))
+
+			require.NotNil(t, hooked)
+			assert.Equal(t, "some-secret-debug", hooked.Debug())
+
+			var params map[string]interface{}
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&params))
+			assert.Equal(t, "invalid_grant", params["error"])
+			assert.Equal(t, "https://example.com/errors/invalid_grant", params["error_uri"])
+			if c.debug {
+				assert.Contains(t, fmt.Sprintf("%v", params), "some-secret-debug")
+			} else {
+				assert.NotContains(t, fmt.Sprintf("%v", params), "some-secret-debug")
+			}
+		})
+	}
+}
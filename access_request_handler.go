@@ -56,18 +56,33 @@ import (
 //   credentials (or assigned other authentication requirements), the
 //   client MUST authenticate with the authorization server as described
 //   in Section 3.2.1.
-func (f *Fosite) NewAccessRequest(ctx context.Context, r *http.Request, session Session) (AccessRequester, error) {
+func (f *Fosite) NewAccessRequest(ctx context.Context, r *http.Request, session Session) (_ AccessRequester, err error) {
+	ctx, endSpan := f.startSpan(ctx, TraceOperationNewAccessRequest)
 	accessRequest := NewAccessRequest(session)
+	defer func() {
+		if !f.hasRequestHooks() {
+			return
+		}
+
+		event := newAuditEvent(accessRequest, err)
+		if err != nil {
+			f.audit(func(l AuditLogger) { l.TokenFailed(ctx, event) })
+		}
+		f.observe(func(o Observer) { observeFailure(ctx, o, newObserverLabels(ObserverEndpointToken, event)) })
+		endSpan(event)
+	}()
 
 	ctx = context.WithValue(ctx, RequestContextKey, r)
 	ctx = context.WithValue(ctx, AccessRequestContextKey, accessRequest)
 
 	if r.Method != "POST" {
 		return accessRequest, errorsx.WithStack(ErrInvalidRequest.WithHintf("HTTP method is '%s', expected 'POST'.", r.Method))
-	} else if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+	} else if err := f.parseMultipartForm(r); err != nil && err != http.ErrNotMultipart {
 		return accessRequest, errorsx.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithWrap(err).WithDebug(err.Error()))
 	} else if len(r.PostForm) == 0 {
 		return accessRequest, errorsx.WithStack(ErrInvalidRequest.WithHint("The POST body can not be empty."))
+	} else if err := f.validateParameterLengths(r.PostForm); err != nil {
+		return accessRequest, err
 	}
 
 	accessRequest.Form = r.PostForm
@@ -75,8 +90,20 @@ func (f *Fosite) NewAccessRequest(ctx context.Context, r *http.Request, session
 		return accessRequest, errors.New("Session must not be nil")
 	}
 
-	accessRequest.SetRequestedScopes(RemoveEmpty(strings.Split(r.PostForm.Get("scope"), " ")))
+	if ctx, err = f.extractRequestContext(ctx, r, &accessRequest.Request); err != nil {
+		return accessRequest, err
+	}
+
+	accessRequest.SetRequestedScopes(f.parseScope(r.PostForm.Get("scope")))
+	if err := f.validateScopeCount(accessRequest.GetRequestedScopes()); err != nil {
+		return accessRequest, err
+	}
+
 	accessRequest.SetRequestedAudience(GetAudiences(r.PostForm))
+	if err := f.validateAudienceCount(r.PostForm); err != nil {
+		return accessRequest, err
+	}
+
 	accessRequest.GrantTypes = RemoveEmpty(strings.Split(r.PostForm.Get("grant_type"), " "))
 	if len(accessRequest.GrantTypes) < 1 {
 		return accessRequest, errorsx.WithStack(ErrInvalidRequest.WithHint("Request parameter 'grant_type' is missing"))
@@ -85,6 +112,17 @@ func (f *Fosite) NewAccessRequest(ctx context.Context, r *http.Request, session
 	client, clientErr := f.AuthenticateClient(ctx, r, r.PostForm)
 	if clientErr == nil {
 		accessRequest.Client = client
+		if err := f.validateAuthorizationDetails(ctx, &accessRequest.Request); err != nil {
+			return accessRequest, err
+		}
+
+		resources, err := f.validateResources(client, r.PostForm)
+		if err != nil {
+			return accessRequest, err
+		}
+		for _, resource := range resources {
+			accessRequest.AppendRequestedAudience(resource)
+		}
 	}
 
 	var found = false
@@ -103,7 +141,9 @@ func (f *Fosite) NewAccessRequest(ctx context.Context, r *http.Request, session
 		}
 
 		// All good.
-		if err := loader.HandleTokenEndpointRequest(ctx, accessRequest); err == nil {
+		if err := f.withStorageTimeout(ctx, func(ctx context.Context) error {
+			return loader.HandleTokenEndpointRequest(ctx, accessRequest)
+		}); err == nil {
 			found = true
 		} else if errors.Is(err, ErrUnknownRequest) {
 			// This is a duplicate because it should already have been handled by
@@ -463,6 +463,42 @@ func TestNewAccessRequestWithMixedClientAuth(t *testing.T) {
 	}
 }
 
+func TestNewAccessRequestWithMaxScopesAndAudiences(t *testing.T) {
+	f := &Fosite{MaxScopes: 2, MaxAudiences: 2}
+	for k, c := range []struct {
+		form      url.Values
+		expectErr error
+		expectMsg string
+	}{
+		{
+			form:      url.Values{"grant_type": {"foo"}, "scope": {"foo bar baz"}},
+			expectErr: ErrInvalidRequest,
+			expectMsg: "The request asks for 3 scopes, but at most 2 scopes are allowed.",
+		},
+		{
+			form:      url.Values{"grant_type": {"foo"}, "audience": {"https://a.example.com", "https://b.example.com"}, "resource": {"https://c.example.com"}},
+			expectErr: ErrInvalidTarget,
+			expectMsg: "The request asks for 3 audiences, but at most 2 audiences are allowed.",
+		},
+		{
+			form:      url.Values{"grant_type": {"foo"}, "scope": {"foo bar"}, "audience": {"https://a.example.com https://b.example.com"}},
+			expectErr: ErrInvalidRequest,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			r := &http.Request{Header: http.Header{}, PostForm: c.form, Form: c.form, Method: "POST"}
+			_, err := f.NewAccessRequest(context.Background(), r, new(DefaultSession))
+			require.EqualError(t, err, c.expectErr.Error())
+			if c.expectMsg != "" {
+				assert.Contains(t, ErrorToRFC6749Error(err).GetDescription(), c.expectMsg)
+			} else {
+				// Requests within the limits fail later on because no client is authenticated.
+				assert.NotContains(t, ErrorToRFC6749Error(err).GetDescription(), "at most")
+			}
+		})
+	}
+}
+
 func basicAuth(username, password string) string {
 	return "Basic " + base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, password)))
 }
@@ -36,12 +36,28 @@ type AccessResponse struct {
 	Extra       map[string]interface{}
 	AccessToken string
 	TokenType   string
+
+	GrantedScope   Arguments
+	RequestedScope Arguments
 }
 
 func (a *AccessResponse) SetScopes(scopes Arguments) {
+	a.GrantedScope = scopes
 	a.SetExtra("scope", strings.Join(scopes, " "))
 }
 
+func (a *AccessResponse) GetScopes() Arguments {
+	return a.GrantedScope
+}
+
+func (a *AccessResponse) SetRequestedScopes(scopes Arguments) {
+	a.RequestedScope = scopes
+}
+
+func (a *AccessResponse) GetRequestedScopes() Arguments {
+	return a.RequestedScope
+}
+
 func (a *AccessResponse) SetExpiresIn(expiresIn time.Duration) {
 	a.SetExtra("expires_in", int64(expiresIn/time.Second))
 }
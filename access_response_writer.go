@@ -23,23 +23,42 @@ package fosite
 
 import (
 	"context"
+	"strings"
 
 	"github.com/ory/x/errorsx"
+	"github.com/ory/x/stringslice"
 
 	"github.com/pkg/errors"
 )
 
-func (f *Fosite) NewAccessResponse(ctx context.Context, requester AccessRequester) (AccessResponder, error) {
-	var err error
+func (f *Fosite) NewAccessResponse(ctx context.Context, requester AccessRequester) (_ AccessResponder, err error) {
 	var tk TokenEndpointHandler
 
+	ctx, endSpan := f.startSpan(ctx, TraceOperationNewAccessResponse)
 	response := NewAccessResponse()
+	defer func() {
+		if !f.hasRequestHooks() {
+			return
+		}
+
+		event := newAuditEvent(requester, err)
+		if err != nil {
+			f.audit(func(l AuditLogger) { l.TokenFailed(ctx, event) })
+		} else {
+			f.audit(func(l AuditLogger) { l.TokenSucceeded(ctx, event) })
+			f.observe(func(o Observer) { o.TokenIssued(ctx, newObserverLabels(ObserverEndpointToken, event)) })
+		}
+		f.observe(func(o Observer) { observeFailure(ctx, o, newObserverLabels(ObserverEndpointToken, event)) })
+		endSpan(event)
+	}()
 
 	ctx = context.WithValue(ctx, AccessRequestContextKey, requester)
 	ctx = context.WithValue(ctx, AccessResponseContextKey, response)
 
 	for _, tk = range f.TokenEndpointHandlers {
-		if err = tk.PopulateTokenEndpointResponse(ctx, requester, response); err == nil {
+		if err = f.withStorageTimeout(ctx, func(ctx context.Context) error {
+			return tk.PopulateTokenEndpointResponse(ctx, requester, response)
+		}); err == nil {
 			// do nothing
 		} else if errors.Is(err, ErrUnknownRequest) {
 			// do nothing
@@ -52,5 +71,30 @@ func (f *Fosite) NewAccessResponse(ctx context.Context, requester AccessRequeste
 		return nil, errorsx.WithStack(ErrServerError.WithHint("An internal server occurred while trying to complete the request.").WithDebug("Access token or token type not set by TokenEndpointHandlers."))
 	}
 
+	if strings.EqualFold(response.GetTokenType(), BearerAccessToken) {
+		response.SetTokenType(f.bearerTokenType())
+	}
+
+	response.SetRequestedScopes(requester.GetRequestedScopes())
+	if response.GetExtra("scope") == nil && !sameScopes(requester.GetGrantedScopes(), requester.GetRequestedScopes()) {
+		// The scope is required if it differs from the requested one, see https://tools.ietf.org/html/rfc6749#section-5.1
+		response.SetScopes(requester.GetGrantedScopes())
+	}
+	if f.IncludeRequestedScopeInAccessResponse {
+		response.SetExtra("requested_scope", strings.Join(requester.GetRequestedScopes(), " "))
+	}
+
 	return response, nil
 }
+
+func sameScopes(a, b Arguments) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, scope := range a {
+		if !stringslice.Has(b, scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -43,6 +43,7 @@ func TestNewAccessResponse(t *testing.T) {
 	for k, c := range []struct {
 		handlers  TokenEndpointHandlers
 		mock      func()
+		requester AccessRequester
 		expectErr error
 		expect    AccessResponder
 	}{
@@ -83,16 +84,60 @@ func TestNewAccessResponse(t *testing.T) {
 			},
 			handlers: TokenEndpointHandlers{handler},
 			expect: &AccessResponse{
-				Extra:       map[string]interface{}{},
-				AccessToken: "foo",
-				TokenType:   "bar",
+				Extra:          map[string]interface{}{},
+				AccessToken:    "foo",
+				TokenType:      "bar",
+				RequestedScope: Arguments{},
+			},
+		},
+		{
+			mock: func() {
+				handler.EXPECT().PopulateTokenEndpointResponse(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ AccessRequester, resp AccessResponder) {
+					resp.SetAccessToken("foo")
+					resp.SetTokenType("bar")
+				}).Return(nil)
+			},
+			handlers: TokenEndpointHandlers{handler},
+			requester: &AccessRequest{Request: Request{
+				RequestedScope: Arguments{"foo", "bar"},
+				GrantedScope:   Arguments{"foo"},
+			}},
+			expect: &AccessResponse{
+				Extra:          map[string]interface{}{"scope": "foo"},
+				AccessToken:    "foo",
+				TokenType:      "bar",
+				RequestedScope: Arguments{"foo", "bar"},
+				GrantedScope:   Arguments{"foo"},
+			},
+		},
+		{
+			mock: func() {
+				handler.EXPECT().PopulateTokenEndpointResponse(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ AccessRequester, resp AccessResponder) {
+					resp.SetAccessToken("foo")
+					resp.SetTokenType("bar")
+				}).Return(nil)
+			},
+			handlers: TokenEndpointHandlers{handler},
+			requester: &AccessRequest{Request: Request{
+				RequestedScope: Arguments{"foo", "bar"},
+				GrantedScope:   Arguments{"bar", "foo"},
+			}},
+			expect: &AccessResponse{
+				Extra:          map[string]interface{}{},
+				AccessToken:    "foo",
+				TokenType:      "bar",
+				RequestedScope: Arguments{"foo", "bar"},
 			},
 		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			f.TokenEndpointHandlers = c.handlers
 			c.mock()
-			ar, err := f.NewAccessResponse(context.TODO(), nil)
+			requester := c.requester
+			if requester == nil {
+				requester = NewAccessRequest(nil)
+			}
+			ar, err := f.NewAccessResponse(context.TODO(), requester)
 
 			if c.expectErr != nil {
 				assert.EqualError(t, err, c.expectErr.Error())
@@ -103,3 +148,26 @@ func TestNewAccessResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAccessResponseRequestedScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := internal.NewMockTokenEndpointHandler(ctrl)
+	defer ctrl.Finish()
+
+	f := &Fosite{TokenEndpointHandlers: TokenEndpointHandlers{handler}, IncludeRequestedScopeInAccessResponse: true}
+	requester := NewAccessRequest(nil)
+	requester.SetRequestedScopes(Arguments{"foo", "bar"})
+	requester.GrantScope("foo")
+	handler.EXPECT().PopulateTokenEndpointResponse(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ context.Context, requester AccessRequester, resp AccessResponder) {
+		resp.SetAccessToken("foo")
+		resp.SetTokenType("bearer")
+		resp.SetScopes(requester.GetGrantedScopes())
+	}).Return(nil)
+
+	resp, err := f.NewAccessResponse(context.TODO(), requester)
+	require.NoError(t, err)
+	assert.Equal(t, Arguments{"foo"}, resp.GetScopes())
+	assert.Equal(t, Arguments{"foo", "bar"}, resp.GetRequestedScopes())
+	assert.Equal(t, "foo", resp.ToMap()["scope"])
+	assert.Equal(t, "foo bar", resp.ToMap()["requested_scope"])
+}
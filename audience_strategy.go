@@ -71,6 +71,75 @@ func ExactAudienceMatchingStrategy(haystack []string, needle []string) error {
 	return nil
 }
 
+// WildcardAudienceMatchingStrategy matches audiences like the DefaultAudienceMatchingStrategy, but paths have to
+// match exactly unless the whitelisted audience ends with "/*": "https://api.example.com/v1/*" matches
+// "https://api.example.com/v1" and everything below it. Trailing slashes are ignored. Wildcards in the host are not
+// allowed, use NewWildcardAudienceMatchingStrategy to enable them.
+func WildcardAudienceMatchingStrategy(haystack []string, needle []string) error {
+	return NewWildcardAudienceMatchingStrategy(false)(haystack, needle)
+}
+
+// NewWildcardAudienceMatchingStrategy returns a WildcardAudienceMatchingStrategy. If allowHostWildcards is true,
+// whitelisted audiences like "https://*.example.com/*" also match all subdomains of the host. The scheme always has
+// to match exactly.
+func NewWildcardAudienceMatchingStrategy(allowHostWildcards bool) AudienceMatchingStrategy {
+	return func(haystack []string, needle []string) error {
+		for _, n := range needle {
+			nu, err := url.Parse(n)
+			if err != nil {
+				return errorsx.WithStack(ErrInvalidTarget.WithHintf("Unable to parse requested audience '%s'.", n).WithWrap(err).WithDebug(err.Error()))
+			}
+
+			var found bool
+			for _, h := range haystack {
+				hu, err := url.Parse(h)
+				if err != nil {
+					return errorsx.WithStack(ErrInvalidTarget.WithHintf("Unable to parse whitelisted audience '%s'.", h).WithWrap(err).WithDebug(err.Error()))
+				}
+
+				if wildcardAudienceMatches(hu, nu, allowHostWildcards) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return errorsx.WithStack(ErrInvalidTarget.WithHintf("Requested audience '%s' has not been whitelisted by the OAuth 2.0 Client.", n))
+			}
+		}
+
+		return nil
+	}
+}
+
+func wildcardAudienceMatches(allowed, requested *url.URL, allowHostWildcards bool) bool {
+	if allowed.Scheme != requested.Scheme {
+		return false
+	}
+
+	if allowHostWildcards && strings.HasPrefix(allowed.Host, "*.") {
+		if !strings.HasSuffix(requested.Host, allowed.Host[1:]) || len(requested.Host) <= len(allowed.Host)-1 {
+			return false
+		}
+	} else if allowed.Host != requested.Host {
+		return false
+	}
+
+	// Dot segments could escape the allowed path once the audience is resolved by the resource server.
+	for _, segment := range strings.Split(requested.Path, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+
+	allowedPath := strings.TrimRight(allowed.Path, "/")
+	requestedPath := strings.TrimRight(requested.Path, "/")
+	if strings.HasSuffix(allowedPath, "/*") {
+		return strings.HasPrefix(requestedPath+"/", strings.TrimSuffix(allowedPath, "*"))
+	}
+	return requestedPath == allowedPath
+}
+
 // GetAudiences allows audiences to be provided as repeated "audience" form parameter,
 // or as a space-delimited "audience" form parameter if it is not repeated.
 // RFC 8693 in section 2.1 specifies that multiple audience values should be multiple
@@ -88,13 +157,49 @@ func GetAudiences(form url.Values) []string {
 	}
 }
 
+// GetResources returns the "resource" form parameters as defined in https://tools.ietf.org/html/rfc8707#section-2.
+// Each resource must be an absolute URI without a fragment component.
+func GetResources(form url.Values) ([]string, error) {
+	resources := RemoveEmpty(form["resource"])
+	for _, resource := range resources {
+		u, err := url.Parse(resource)
+		if err != nil {
+			return nil, errorsx.WithStack(ErrInvalidTarget.WithHintf("Unable to parse requested resource '%s'.", resource).WithWrap(err).WithDebug(err.Error()))
+		} else if !u.IsAbs() || u.Fragment != "" || strings.Contains(resource, "#") {
+			return nil, errorsx.WithStack(ErrInvalidTarget.WithHintf("Requested resource '%s' must be an absolute URI without a fragment component.", resource))
+		}
+	}
+	return resources, nil
+}
+
+func (f *Fosite) validateResources(client Client, form url.Values) ([]string, error) {
+	resources, err := GetResources(form)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.AudienceMatchingStrategy(client.GetAudience(), resources); err != nil {
+		return nil, errorsx.WithStack(ErrInvalidTarget.WithHint("The OAuth 2.0 Client is not allowed to request one of the resources.").WithWrap(err).WithDebug(err.Error()))
+	}
+	return resources, nil
+}
+
 func (f *Fosite) validateAuthorizeAudience(r *http.Request, request *AuthorizeRequest) error {
+	if err := f.validateAudienceCount(request.Form); err != nil {
+		return err
+	}
+
 	audience := GetAudiences(request.Form)
 
 	if err := f.AudienceMatchingStrategy(request.Client.GetAudience(), audience); err != nil {
 		return err
 	}
 
-	request.SetRequestedAudience(Arguments(audience))
+	resources, err := f.validateResources(request.Client, request.Form)
+	if err != nil {
+		return err
+	}
+
+	request.SetRequestedAudience(append(Arguments(audience), resources...))
 	return nil
 }
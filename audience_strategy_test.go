@@ -2,8 +2,10 @@ package fosite
 
 import (
 	"fmt"
+	"net/url"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -252,3 +254,70 @@ func TestExactAudienceMatchingStrategy(t *testing.T) {
 		})
 	}
 }
+
+func TestWildcardAudienceMatchingStrategy(t *testing.T) {
+	for k, tc := range []struct {
+		h             []string
+		n             []string
+		hostWildcards bool
+		err           bool
+	}{
+		{h: []string{"https://api.example.com/*"}, n: []string{}},
+		{h: []string{"https://api.example.com/*"}, n: []string{"https://api.example.com/v1/users"}},
+		{h: []string{"https://api.example.com/*"}, n: []string{"https://api.example.com"}},
+		{h: []string{"https://api.example.com/v1/*"}, n: []string{"https://api.example.com/v1"}},
+		{h: []string{"https://api.example.com/v1/*/"}, n: []string{"https://api.example.com/v1/users/"}},
+		{h: []string{"https://api.example.com/v1/*"}, n: []string{"https://api.example.com/v12"}, err: true},
+		{h: []string{"https://api.example.com/v1/*"}, n: []string{"https://api.example.com/v2/users"}, err: true},
+		{h: []string{"https://api.example.com/v1/*"}, n: []string{"https://api.example.com/v1/../admin"}, err: true},
+		{h: []string{"https://api.example.com/v1/*"}, n: []string{"https://api.example.com/v1/%2e%2e/admin"}, err: true},
+		{h: []string{"https://api.example.com/v1/*"}, n: []string{"https://api.example.com/v1/./users"}, err: true},
+		{h: []string{"https://api.example.com/v1"}, n: []string{"https://api.example.com/v1/"}},
+		{h: []string{"https://api.example.com/v1"}, n: []string{"https://api.example.com/v1/users"}, err: true},
+		{h: []string{"https://api.example.com/*"}, n: []string{"http://api.example.com/v1"}, err: true},
+		{h: []string{"https://api.example.com/*"}, n: []string{"https://api.example.com.evil.com/v1"}, err: true},
+		{h: []string{"https://*.example.com/*"}, n: []string{"https://tenant.example.com/v1"}, err: true},
+		{h: []string{"https://*.example.com/*"}, n: []string{"https://tenant.example.com/v1"}, hostWildcards: true},
+		{h: []string{"https://*.example.com/*"}, n: []string{"https://a.b.example.com/v1"}, hostWildcards: true},
+		{h: []string{"https://*.example.com/*"}, n: []string{"https://example.com/v1"}, hostWildcards: true, err: true},
+		{h: []string{"https://*.example.com/*"}, n: []string{"https://evilexample.com/v1"}, hostWildcards: true, err: true},
+		{h: []string{"https://api.example.com/*"}, n: []string{"https://api.example.com/v1", "https://other.example.com"}, err: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := NewWildcardAudienceMatchingStrategy(tc.hostWildcards)(tc.h, tc.n)
+			if tc.err {
+				require.Error(t, err)
+				assert.EqualError(t, err, ErrInvalidTarget.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			if !tc.hostWildcards {
+				assert.Equal(t, err == nil, WildcardAudienceMatchingStrategy(tc.h, tc.n) == nil)
+			}
+		})
+	}
+}
+
+func TestGetResources(t *testing.T) {
+	for k, tc := range []struct {
+		resources []string
+		err       bool
+	}{
+		{resources: nil},
+		{resources: []string{"https://api.example.com", "urn:example:resource"}},
+		{resources: []string{"api.example.com"}, err: true},
+		{resources: []string{"/foo"}, err: true},
+		{resources: []string{"https://api.example.com/#foo"}, err: true},
+		{resources: []string{"https://api.example.com/#"}, err: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			resources, err := GetResources(url.Values{"resource": tc.resources})
+			if tc.err {
+				require.EqualError(t, err, ErrInvalidTarget.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, RemoveEmpty(tc.resources), resources)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+)
+
+// AuditLogger receives the outcome of authorize, token, introspection and revocation requests, for example to build a
+// security audit trail. The callbacks are invoked synchronously while the request is handled, so implementations
+// which write to slow sinks should hand the event off, for example to a buffered channel, instead of blocking.
+// The callbacks can not alter the response; a panicking callback is recovered and ignored.
+type AuditLogger interface {
+	// AuthorizeSucceeded is invoked when an authorize response was created.
+	AuthorizeSucceeded(ctx context.Context, event *AuditEvent)
+
+	// AuthorizeFailed is invoked when an authorize request or response could not be created.
+	AuthorizeFailed(ctx context.Context, event *AuditEvent)
+
+	// TokenSucceeded is invoked when an access response was created.
+	TokenSucceeded(ctx context.Context, event *AuditEvent)
+
+	// TokenFailed is invoked when an access request or response could not be created.
+	TokenFailed(ctx context.Context, event *AuditEvent)
+
+	// Introspected is invoked when a token introspection request was handled, regardless of its outcome.
+	Introspected(ctx context.Context, event *AuditEvent)
+
+	// Revoked is invoked when a token revocation request was handled, regardless of its outcome.
+	Revoked(ctx context.Context, event *AuditEvent)
+}
+
+// AuditEvent describes the outcome of a request handled by fosite.
+type AuditEvent struct {
+	// ClientID is the ID of the client which made the request. For introspection requests it is the ID of the client
+	// the introspected token was issued to.
+	ClientID string
+
+	// GrantTypes are the grant types of token requests.
+	GrantTypes Arguments
+
+	// ResponseTypes are the response types of authorize requests.
+	ResponseTypes Arguments
+
+	// RequestedScopes and GrantedScopes are the scopes requested and granted so far.
+	RequestedScopes Arguments
+	GrantedScopes   Arguments
+
+	// TokenType is the type of the introspected token, or the token type hint of revocation requests.
+	TokenType TokenType
+
+	// Active indicates whether the introspected token is active.
+	Active bool
+
+	// ErrorCode is the OAuth 2.0 error code of failed requests, for example "invalid_grant".
+	ErrorCode string
+
+	// Error is the error of failed requests. It carries its debug details, regardless of SendDebugMessagesToClients.
+	Error error
+}
+
+// NoopAuditLogger is an AuditLogger which discards all events.
+type NoopAuditLogger struct{}
+
+func (*NoopAuditLogger) AuthorizeSucceeded(context.Context, *AuditEvent) {}
+func (*NoopAuditLogger) AuthorizeFailed(context.Context, *AuditEvent)    {}
+func (*NoopAuditLogger) TokenSucceeded(context.Context, *AuditEvent)     {}
+func (*NoopAuditLogger) TokenFailed(context.Context, *AuditEvent)        {}
+func (*NoopAuditLogger) Introspected(context.Context, *AuditEvent)       {}
+func (*NoopAuditLogger) Revoked(context.Context, *AuditEvent)            {}
+
+func newAuditEvent(requester Requester, err error) *AuditEvent {
+	event := &AuditEvent{Error: err}
+	if err != nil {
+		event.ErrorCode = ErrorToRFC6749Error(err).ErrorField
+	}
+
+	if requester == nil {
+		return event
+	}
+
+	if c := requester.GetClient(); c != nil {
+		event.ClientID = c.GetID()
+	}
+	event.RequestedScopes = requester.GetRequestedScopes()
+	event.GrantedScopes = requester.GetGrantedScopes()
+
+	switch r := requester.(type) {
+	case AccessRequester:
+		event.GrantTypes = r.GetGrantTypes()
+	case AuthorizeRequester:
+		event.ResponseTypes = r.GetResponseTypes()
+	}
+
+	return event
+}
+
+func (f *Fosite) audit(fn func(l AuditLogger)) {
+	if f.AuditLogger == nil {
+		return
+	}
+
+	recoverHook(func() { fn(f.AuditLogger) })
+}
+
+// hasRequestHooks reports whether an AuditLogger, Observer or Tracer is set, all of which are passed the audit event
+// of a request.
+func (f *Fosite) hasRequestHooks() bool {
+	return f.AuditLogger != nil || f.Observer != nil || f.Tracer != nil
+}
+
+// recoverHook invokes fn and ignores a panic, because neither the audit trail nor measurements may alter the response.
+func recoverHook(fn func()) {
+	defer func() {
+		_ = recover()
+	}()
+	fn()
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/ory/x/errorsx"
+)
+
+// AuthorizationDetail is an entry of the authorization_details parameter as defined in
+// https://tools.ietf.org/html/rfc9396#section-2
+type AuthorizationDetail struct {
+	Type       string   `json:"type"`
+	Locations  []string `json:"locations,omitempty"`
+	Actions    []string `json:"actions,omitempty"`
+	DataTypes  []string `json:"datatypes,omitempty"`
+	Identifier string   `json:"identifier,omitempty"`
+	Privileges []string `json:"privileges,omitempty"`
+
+	// Extra contains the fields which are specific to the authorization details type.
+	Extra map[string]interface{} `json:"-"`
+}
+
+var authorizationDetailCommonFields = []string{"type", "locations", "actions", "datatypes", "identifier", "privileges"}
+
+func (d AuthorizationDetail) MarshalJSON() ([]byte, error) {
+	type common AuthorizationDetail
+	out, err := json.Marshal(common(d))
+	if err != nil || len(d.Extra) == 0 {
+		return out, err
+	}
+
+	merged := map[string]interface{}{}
+	for k, v := range d.Extra {
+		merged[k] = v
+	}
+	if err := json.Unmarshal(out, &merged); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
+}
+
+func (d *AuthorizationDetail) UnmarshalJSON(data []byte) error {
+	type common AuthorizationDetail
+	var c common
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	var extra map[string]interface{}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	for _, k := range authorizationDetailCommonFields {
+		delete(extra, k)
+	}
+	if len(extra) > 0 {
+		c.Extra = extra
+	}
+
+	*d = AuthorizationDetail(c)
+	return nil
+}
+
+// AuthorizationDetailsRequester is implemented by requests which carry authorization details.
+type AuthorizationDetailsRequester interface {
+	// GetAuthorizationDetails returns the authorization details of the request.
+	GetAuthorizationDetails() []AuthorizationDetail
+
+	// SetAuthorizationDetails sets the authorization details of the request.
+	SetAuthorizationDetails(details []AuthorizationDetail)
+}
+
+// AuthorizationDetailsClient represents a client which may request authorization details.
+type AuthorizationDetailsClient interface {
+	// GetAuthorizationDetailsTypes returns the authorization details types the client is allowed to request.
+	GetAuthorizationDetailsTypes() []string
+}
+
+// AuthorizationDetailsValidator validates the authorization details requested by a client.
+type AuthorizationDetailsValidator func(ctx context.Context, client Client, details []AuthorizationDetail) error
+
+// DefaultAuthorizationDetailsValidator only allows authorization details of a type which is registered for the client.
+func DefaultAuthorizationDetailsValidator(_ context.Context, client Client, details []AuthorizationDetail) error {
+	var types []string
+	if c, ok := client.(AuthorizationDetailsClient); ok {
+		types = c.GetAuthorizationDetailsTypes()
+	}
+
+	for _, detail := range details {
+		var found bool
+		for _, t := range types {
+			if detail.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errorsx.WithStack(ErrInvalidAuthorizationDetails.WithHintf("The OAuth 2.0 Client is not allowed to request authorization details of type '%s'.", detail.Type))
+		}
+	}
+
+	return nil
+}
+
+// GetAuthorizationDetails parses the authorization_details parameter of the form.
+func GetAuthorizationDetails(form url.Values) ([]AuthorizationDetail, error) {
+	raw := form.Get("authorization_details")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var details []AuthorizationDetail
+	if err := json.Unmarshal([]byte(raw), &details); err != nil {
+		return nil, errorsx.WithStack(ErrInvalidAuthorizationDetails.WithHint("Unable to parse the 'authorization_details' parameter, it must be a JSON array of objects.").WithWrap(err).WithDebug(err.Error()))
+	}
+
+	for _, detail := range details {
+		if detail.Type == "" {
+			return nil, errorsx.WithStack(ErrInvalidAuthorizationDetails.WithHint("Each entry of the 'authorization_details' parameter must have a 'type'."))
+		}
+	}
+
+	return details, nil
+}
+
+// GetAuthorizationDetailsValidator returns AuthorizationDetailsValidator if set. Defaults to
+// DefaultAuthorizationDetailsValidator.
+func (f *Fosite) GetAuthorizationDetailsValidator() AuthorizationDetailsValidator {
+	if f.AuthorizationDetailsValidator == nil {
+		return DefaultAuthorizationDetailsValidator
+	}
+	return f.AuthorizationDetailsValidator
+}
+
+func (f *Fosite) validateAuthorizationDetails(ctx context.Context, request *Request) error {
+	details, err := GetAuthorizationDetails(request.Form)
+	if err != nil {
+		return err
+	} else if len(details) == 0 {
+		return nil
+	}
+
+	if err := f.GetAuthorizationDetailsValidator()(ctx, request.Client, details); err != nil {
+		return err
+	}
+
+	request.SetAuthorizationDetails(details)
+	return nil
+}
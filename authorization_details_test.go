@@ -0,0 +1,78 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+)
+
+func TestGetAuthorizationDetails(t *testing.T) {
+	for k, c := range []struct {
+		raw       string
+		expectErr error
+		expect    []AuthorizationDetail
+	}{
+		{raw: ""},
+		{raw: "foo", expectErr: ErrInvalidAuthorizationDetails},
+		{raw: `{"type":"payment"}`, expectErr: ErrInvalidAuthorizationDetails},
+		{raw: `[{"actions":["read"]}]`, expectErr: ErrInvalidAuthorizationDetails},
+		{
+			raw: `[{"type":"payment","actions":["initiate"],"instructedAmount":{"currency":"EUR","amount":"123.50"}},{"type":"account","locations":["https://example.com/accounts"]}]`,
+			expect: []AuthorizationDetail{
+				{Type: "payment", Actions: []string{"initiate"}, Extra: map[string]interface{}{"instructedAmount": map[string]interface{}{"currency": "EUR", "amount": "123.50"}}},
+				{Type: "account", Locations: []string{"https://example.com/accounts"}},
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			details, err := GetAuthorizationDetails(url.Values{"authorization_details": {c.raw}})
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expect, details)
+
+			if len(details) > 0 {
+				out, err := json.Marshal(details)
+				require.NoError(t, err)
+				assert.JSONEq(t, c.raw, string(out))
+			}
+		})
+	}
+}
+
+func TestDefaultAuthorizationDetailsValidator(t *testing.T) {
+	details := []AuthorizationDetail{{Type: "payment"}}
+	assert.NoError(t, DefaultAuthorizationDetailsValidator(context.Background(), &DefaultClient{AuthorizationDetailsTypes: []string{"account", "payment"}}, details))
+	assert.EqualError(t, DefaultAuthorizationDetailsValidator(context.Background(), &DefaultClient{AuthorizationDetailsTypes: []string{"account"}}, details), ErrInvalidAuthorizationDetails.Error())
+	assert.EqualError(t, DefaultAuthorizationDetailsValidator(context.Background(), &DefaultClient{}, details), ErrInvalidAuthorizationDetails.Error())
+}
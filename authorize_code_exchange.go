@@ -0,0 +1,66 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AuthorizeCodeExchange records the response of a successful authorize code exchange, which makes it possible to
+// answer a retry of the same token request, for example after a network failure, with the tokens issued before.
+type AuthorizeCodeExchange struct {
+	// RequestHash identifies the parameters of the token request, see AuthorizeCodeExchangeRequestHash.
+	RequestHash string `json:"requestHash"`
+
+	// Response contains the parameters of the token response.
+	Response map[string]interface{} `json:"response"`
+
+	// ExpiresAt is the end of the grace period in which retries are answered.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// IsRetryOf returns true if the token request has the same parameters as the recorded one and the grace period has not
+// ended yet.
+func (e *AuthorizeCodeExchange) IsRetryOf(requester Requester) bool {
+	if e == nil || !time.Now().UTC().Before(e.ExpiresAt) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(e.RequestHash), []byte(AuthorizeCodeExchangeRequestHash(requester))) == 1
+}
+
+// AuthorizeCodeExchangeRequestHash hashes the authenticated client and the code, redirect_uri and code_verifier
+// parameters of an authorize code token request.
+func AuthorizeCodeExchangeRequestHash(requester Requester) string {
+	form := requester.GetRequestForm()
+	values, _ := json.Marshal([]string{
+		requester.GetClient().GetID(),
+		form.Get("code"),
+		form.Get("redirect_uri"),
+		form.Get("code_verifier"),
+	})
+	hash := sha256.Sum256(values)
+	return hex.EncodeToString(hash[:])
+}
@@ -22,65 +22,48 @@
 package fosite
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 )
 
-func (f *Fosite) WriteAuthorizeError(rw http.ResponseWriter, ar AuthorizeRequester, err error) {
+func (f *Fosite) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, err error) {
 	rw.Header().Set("Cache-Control", "no-store")
 	rw.Header().Set("Pragma", "no-cache")
 
-	if f.ResponseModeHandler().ResponseModes().Has(ar.GetResponseMode()) {
-		f.ResponseModeHandler().WriteAuthorizeError(rw, ar, err)
+	rfcerr := ErrorToRFC6749Error(err)
+	f.runErrorHook(rfcerr)
+	rfcerr = rfcerr.WithLegacyFormat(f.UseLegacyErrorFormat).WithExposeDebug(f.SendDebugMessagesToClients)
+	if !ar.IsRedirectURIValid() {
+		f.writeAuthorizeErrorJSON(rw, rfcerr)
 		return
 	}
 
-	rfcerr := ErrorToRFC6749Error(err).WithLegacyFormat(f.UseLegacyErrorFormat).WithExposeDebug(f.SendDebugMessagesToClients)
-	if !ar.IsRedirectURIValid() {
-		rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
-
-		js, err := json.Marshal(rfcerr)
-		if err != nil {
-			if f.SendDebugMessagesToClients {
-				errorMessage := EscapeJSONString(err.Error())
-				http.Error(rw, fmt.Sprintf(`{"error":"server_error","error_description":"%s"}`, errorMessage), http.StatusInternalServerError)
-			} else {
-				http.Error(rw, `{"error":"server_error"}`, http.StatusInternalServerError)
-			}
-			return
-		}
-
-		rw.WriteHeader(rfcerr.CodeField)
-		_, _ = rw.Write(js)
+	h := f.GetResponseModeHandler(ar.GetResponseMode())
+	if h == nil {
+		f.writeAuthorizeErrorJSON(rw, rfcerr)
 		return
 	}
 
-	redirectURI := ar.GetRedirectURI()
-
-	// The endpoint URI MUST NOT include a fragment component.
-	redirectURI.Fragment = ""
+	h.WriteAuthorizeError(ctx, rw, ar, rfcerr)
+}
 
-	errors := rfcerr.ToValues()
-	errors.Set("state", ar.GetState())
+func (f *Fosite) writeAuthorizeErrorJSON(rw http.ResponseWriter, err error) {
+	rfcerr := ErrorToRFC6749Error(err).WithLegacyFormat(f.UseLegacyErrorFormat).WithExposeDebug(f.SendDebugMessagesToClients)
+	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
 
-	var redirectURIString string
-	if ar.GetResponseMode() == ResponseModeFormPost {
-		rw.Header().Set("Content-Type", "text/html;charset=UTF-8")
-		WriteAuthorizeFormPostResponse(redirectURI.String(), errors, GetPostFormHTMLTemplate(*f), rw)
-		return
-	} else if ar.GetResponseMode() == ResponseModeFragment {
-		redirectURIString = redirectURI.String() + "#" + errors.Encode()
-	} else {
-		for key, values := range redirectURI.Query() {
-			for _, value := range values {
-				errors.Add(key, value)
-			}
+	js, err := json.Marshal(rfcerr)
+	if err != nil {
+		if f.SendDebugMessagesToClients {
+			errorMessage := EscapeJSONString(err.Error())
+			http.Error(rw, fmt.Sprintf(`{"error":"server_error","error_description":"%s"}`, errorMessage), http.StatusInternalServerError)
+		} else {
+			http.Error(rw, `{"error":"server_error"}`, http.StatusInternalServerError)
 		}
-		redirectURI.RawQuery = errors.Encode()
-		redirectURIString = redirectURI.String()
+		return
 	}
 
-	rw.Header().Set("Location", redirectURIString)
-	rw.WriteHeader(http.StatusFound)
+	rw.WriteHeader(rfcerr.CodeField)
+	_, _ = rw.Write(js)
 }
@@ -22,6 +22,7 @@
 package fosite_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -72,7 +73,6 @@ func TestWriteAuthorizeError(t *testing.T) {
 			err: ErrInvalidGrant,
 			mock: func(rw *MockResponseWriter, req *MockAuthorizeRequester) {
 				req.EXPECT().IsRedirectURIValid().Return(false)
-				req.EXPECT().GetResponseMode().Return(ResponseModeDefault)
 				rw.EXPECT().Header().Times(3).Return(header)
 				rw.EXPECT().WriteHeader(http.StatusBadRequest)
 				rw.EXPECT().Write(gomock.Any())
@@ -428,7 +428,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 				req.EXPECT().GetRedirectURI().Return(copyUrl(purls[1]))
 				req.EXPECT().GetState().Return("foostate")
 				req.EXPECT().GetResponseTypes().AnyTimes().Return(Arguments([]string{"token"}))
-				req.EXPECT().GetResponseMode().Return(ResponseModeFormPost).Times(2)
+				req.EXPECT().GetResponseMode().Return(ResponseModeFormPost)
 				rw.EXPECT().Header().Times(3).Return(header)
 				rw.EXPECT().Write(gomock.Any()).AnyTimes()
 			},
@@ -451,7 +451,7 @@ func TestWriteAuthorizeError(t *testing.T) {
 			req := NewMockAuthorizeRequester(ctrl)
 
 			c.mock(rw, req)
-			oauth2.WriteAuthorizeError(rw, req, c.err)
+			oauth2.WriteAuthorizeError(context.Background(), rw, req, c.err)
 			c.checkHeader(t, k)
 			header = http.Header{}
 		})
@@ -29,8 +29,6 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/ory/x/errorsx"
-
 	"github.com/asaskevich/govalidator"
 )
 
@@ -50,7 +48,9 @@ var FormPostDefaultTemplate = template.Must(template.New("form_post").Parse(`<ht
 </html>`))
 
 // MatchRedirectURIWithClientRedirectURIs if the given uri is a registered redirect uri. Does not perform
-// uri validation.
+// uri validation. Matches using LoopbackIPFlexibleRedirectURIMatchingStrategy, unless the client implements
+// RedirectURIMatchingStrategyClient. Use Fosite.MatchRedirectURIWithClientRedirectURIs to match using the
+// configured RedirectURIMatchingStrategy instead.
 //
 // Considered specifications
 // * https://tools.ietf.org/html/rfc6749#section-3.1.2.3
@@ -80,21 +80,13 @@ var FormPostDefaultTemplate = template.Must(template.New("form_post").Parse(`<ht
 //     with the redirect URI passed to the token's endpoint, such an
 //     attack is detected (see Section 5.2.4.5).
 func MatchRedirectURIWithClientRedirectURIs(rawurl string, client Client) (*url.URL, error) {
-	if rawurl == "" && len(client.GetRedirectURIs()) == 1 {
-		if redirectURIFromClient, err := url.Parse(client.GetRedirectURIs()[0]); err == nil && IsValidRedirectURI(redirectURIFromClient) {
-			// If no redirect_uri was given and the client has exactly one valid redirect_uri registered, use that instead
-			return redirectURIFromClient, nil
-		}
-	} else if redirectTo, ok := isMatchingRedirectURI(rawurl, client.GetRedirectURIs()); rawurl != "" && ok {
-		// If a redirect_uri was given and the clients knows it (simple string comparison!)
-		// return it.
-		if parsed, err := url.Parse(redirectTo); err == nil && IsValidRedirectURI(parsed) {
-			// If no redirect_uri was given and the client has exactly one valid redirect_uri registered, use that instead
-			return parsed, nil
-		}
-	}
+	return matchRedirectURIWithClientRedirectURIs(rawurl, client, LoopbackIPFlexibleRedirectURIMatchingStrategy)
+}
 
-	return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("The 'redirect_uri' parameter does not match any of the OAuth 2.0 Client's pre-registered redirect urls."))
+// MatchRedirectURIWithClientRedirectURIs is like the function of the same name, but matches using the configured
+// RedirectURIMatchingStrategy.
+func (f *Fosite) MatchRedirectURIWithClientRedirectURIs(rawurl string, client Client) (*url.URL, error) {
+	return matchRedirectURIWithClientRedirectURIs(rawurl, client, f.RedirectURIMatchingStrategy)
 }
 
 // Match a requested  redirect URI against a pool of registered client URIs
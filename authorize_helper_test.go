@@ -308,6 +308,21 @@ func TestWriteAuthorizeFormPostResponse(t *testing.T) {
 	}
 }
 
+func TestWriteAuthorizeFormPostResponseEscapesValues(t *testing.T) {
+	var responseBuffer bytes.Buffer
+	fosite.WriteAuthorizeFormPostResponse(
+		`https://localhost:8080/cb?foo="><script>alert(1)</script>`,
+		url.Values{"state": {`"/><script>alert(1)</script>`}, `"><script>`: {"bar"}},
+		fosite.FormPostDefaultTemplate,
+		&responseBuffer,
+	)
+	assert.NotContains(t, responseBuffer.String(), "<script>")
+
+	var javascriptBuffer bytes.Buffer
+	fosite.WriteAuthorizeFormPostResponse("javascript:alert(1)", url.Values{}, fosite.FormPostDefaultTemplate, &javascriptBuffer)
+	assert.NotContains(t, javascriptBuffer.String(), "javascript:alert")
+}
+
 func TestIsRedirectURISecureStrict(t *testing.T) {
 	for d, c := range []struct {
 		u   string
@@ -32,6 +32,12 @@ const (
 	ResponseModeFormPost = ResponseModeType("form_post")
 	ResponseModeQuery    = ResponseModeType("query")
 	ResponseModeFragment = ResponseModeType("fragment")
+
+	// JWT Secured Authorization Response Modes, see https://openid.net/specs/oauth-v2-jarm.html#section-2.3
+	ResponseModeJWT         = ResponseModeType("jwt")
+	ResponseModeQueryJWT    = ResponseModeType("query.jwt")
+	ResponseModeFragmentJWT = ResponseModeType("fragment.jwt")
+	ResponseModeFormPostJWT = ResponseModeType("form_post.jwt")
 )
 
 // AuthorizeRequest is an implementation of AuthorizeRequester
@@ -42,6 +48,13 @@ type AuthorizeRequest struct {
 	HandledResponseTypes Arguments        `json:"handledResponseTypes" gorethink:"handledResponseTypes"`
 	ResponseMode         ResponseModeType `json:"ResponseModes" gorethink:"ResponseModes"`
 	DefaultResponseMode  ResponseModeType `json:"DefaultResponseMode" gorethink:"DefaultResponseMode"`
+	LoginHint            string           `json:"loginHint,omitempty" gorethink:"loginHint"`
+	UILocales            Arguments        `json:"uiLocales,omitempty" gorethink:"uiLocales"`
+	ClaimsLocales        Arguments        `json:"claimsLocales,omitempty" gorethink:"claimsLocales"`
+	Display              DisplayType      `json:"display,omitempty" gorethink:"display"`
+
+	// redirectURIMatchingStrategy is the strategy the redirect URI was validated with.
+	redirectURIMatchingStrategy RedirectURIMatchingStrategy
 
 	Request
 }
@@ -67,7 +80,7 @@ func (d *AuthorizeRequest) IsRedirectURIValid() bool {
 		return false
 	}
 
-	redirectURI, err := MatchRedirectURIWithClientRedirectURIs(raw, d.GetClient())
+	redirectURI, err := matchRedirectURIWithClientRedirectURIs(raw, d.GetClient(), d.redirectURIMatchingStrategy)
 	if err != nil {
 		return false
 	}
@@ -82,6 +95,10 @@ func (d *AuthorizeRequest) GetState() string {
 	return d.State
 }
 
+func (d *AuthorizeRequest) GetLoginHint() string {
+	return d.LoginHint
+}
+
 func (d *AuthorizeRequest) GetRedirectURI() *url.URL {
 	return d.RedirectURI
 }
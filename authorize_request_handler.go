@@ -23,10 +23,14 @@ package fosite
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/ory/fosite/token/jwt"
 	"github.com/ory/x/errorsx"
@@ -45,15 +49,16 @@ func wrapSigningKeyFailure(outer *RFC6749Error, inner error) *RFC6749Error {
 	return outer
 }
 
-func (f *Fosite) authorizeRequestParametersFromOpenIDConnectRequest(request *AuthorizeRequest) error {
-	var scope Arguments = RemoveEmpty(strings.Split(request.Form.Get("scope"), " "))
+func (f *Fosite) authorizeRequestParametersFromOpenIDConnectRequest(ctx context.Context, request *AuthorizeRequest) error {
+	var scope Arguments = f.parseScope(request.Form.Get("scope"))
 
 	// Even if a scope parameter is present in the Request Object value, a scope parameter MUST always be passed using
 	// the OAuth 2.0 request syntax containing the openid scope value to indicate to the underlying OAuth 2.0 logic that this is an OpenID Connect request.
 	// Source: http://openid.net/specs/openid-connect-core-1_0.html#CodeFlowAuth
-	if !scope.Has("openid") {
-		return nil
-	}
+	//
+	// Requests without the openid scope are JWT-Secured Authorization Requests as defined in
+	// https://tools.ietf.org/html/rfc9101, where the scope is taken from the Request Object only.
+	isOpenIDRequest := scope.Has("openid")
 
 	if len(request.Form.Get("request")+request.Form.Get("request_uri")) == 0 {
 		return nil
@@ -75,34 +80,27 @@ func (f *Fosite) authorizeRequestParametersFromOpenIDConnectRequest(request *Aut
 
 	assertion := request.Form.Get("request")
 	if location := request.Form.Get("request_uri"); len(location) > 0 {
-		if !stringslice.Has(oidcClient.GetRequestURIs(), location) {
-			return errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Request URI '%s' is not whitelisted by the OAuth 2.0 Client.", location))
-		}
-
-		hc := f.HTTPClient
-		if hc == nil {
-			hc = http.DefaultClient
-		}
-
-		response, err := hc.Get(location)
+		body, err := f.fetchRequestObject(ctx, oidcClient, location)
 		if err != nil {
-			return errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Unable to fetch OpenID Connect request parameters from 'request_uri' because: %s.", err.Error()).WithWrap(err).WithDebug(err.Error()))
-		}
-		defer response.Body.Close()
-
-		if response.StatusCode != http.StatusOK {
-			return errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Unable to fetch OpenID Connect request parameters from 'request_uri' because status code '%d' was expected, but got '%d'.", http.StatusOK, response.StatusCode))
+			return err
 		}
+		assertion = body
+	}
 
-		body, err := ioutil.ReadAll(response.Body)
+	// Encrypted Request Objects are nested JWTs, see https://tools.ietf.org/html/rfc9101#section-6.1
+	if strings.Count(assertion, ".") == 4 {
+		decrypted, err := f.decryptRequestObject(assertion)
 		if err != nil {
-			return errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Unable to fetch OpenID Connect request parameters from 'request_uri' because body parsing failed with: %s.", err).WithWrap(err).WithDebug(err.Error()))
+			return err
 		}
-
-		assertion = string(body)
+		assertion = decrypted
 	}
 
-	token, err := jwt.ParseWithClaims(assertion, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
+	token, err := jwt.ParseWithClaimsAndClockSkew(assertion, jwt.MapClaims{}, f.ClockSkew, func(t *jwt.Token) (interface{}, error) {
+		if !f.isJWTSigningAlgorithmAllowed(t) {
+			return nil, errorsx.WithStack(ErrInvalidRequestObject.WithHintf("The request object uses signing algorithm '%s' which is not allowed by the authorization server.", t.Header["alg"]))
+		}
+
 		// request_object_signing_alg - OPTIONAL.
 		//  JWS [JWS] alg algorithm [JWA] that MUST be used for signing Request Objects sent to the OP. All Request Objects from this Client MUST be rejected,
 		// 	if not signed with this algorithm. Request Objects are described in Section 6.1 of OpenID Connect Core 1.0 [OpenID.Core]. This algorithm MUST
@@ -113,9 +111,12 @@ func (f *Fosite) authorizeRequestParametersFromOpenIDConnectRequest(request *Aut
 		}
 
 		if t.Method == jwt.SigningMethodNone {
+			// Unsigned request objects are only accepted if the client explicitly registered the none algorithm.
+			if oidcClient.GetRequestObjectSigningAlgorithm() != "none" {
+				return nil, errorsx.WithStack(ErrInvalidRequestObject.WithHint("The request object is not signed, but the OAuth 2.0 Client did not explicitly allow signing algorithm 'none'."))
+			}
 			return jwt.UnsafeAllowNoneSignatureType, nil
 		}
-
 		switch t.Method {
 		case jose.RS256, jose.RS384, jose.RS512:
 			key, err := f.findClientPublicJWK(oidcClient, t, true)
@@ -152,16 +153,42 @@ func (f *Fosite) authorizeRequestParametersFromOpenIDConnectRequest(request *Aut
 			return errorsx.WithStack(ErrInvalidRequestObject.WithHint("Unable to verify the request object's signature.").WithWrap(err).WithDebug(err.Error()))
 		}
 		return err
-	} else if err := token.Claims.Valid(); err != nil {
+	} else if err := token.Claims.ValidWithClockSkew(f.ClockSkew); err != nil {
 		return errorsx.WithStack(ErrInvalidRequestObject.WithHint("Unable to verify the request object because its claims could not be validated, check if the expiry time is set correctly.").WithWrap(err).WithDebug(err.Error()))
 	}
 
 	claims := token.Claims
+
+	// The client_id and iss claims, if present, must identify the client which sent the request,
+	// see https://tools.ietf.org/html/rfc9101#section-5
+	for _, claim := range []string{"client_id", "iss"} {
+		if v, ok := claims[claim]; ok && fmt.Sprintf("%s", v) != request.Client.GetID() {
+			return errorsx.WithStack(ErrInvalidRequestObject.WithHintf("The request object claim '%s' does not match the OAuth 2.0 Client ID.", claim))
+		}
+	}
+
+	// The aud claim, if present, must identify this authorization server.
+	if _, ok := claims["aud"]; ok && f.Issuer != "" && !claims.VerifyAudience(f.Issuer, true) {
+		return errorsx.WithStack(ErrInvalidRequestObject.WithHint("The request object claim 'aud' does not contain the issuer of this authorization server."))
+	}
+
+	for k, v := range claims {
+		value := requestObjectParameter(v)
+		if k == "scope" && isOpenIDRequest {
+			continue
+		}
+
+		// Parameters may be repeated outside of the request object, but they must not conflict with it.
+		if existing, ok := request.Form[k]; ok && (len(existing) != 1 || existing[0] != value) {
+			return errorsx.WithStack(ErrInvalidRequestObject.WithHintf("The request parameter '%s' conflicts with the value in the request object.", k))
+		}
+	}
+
 	for k, v := range claims {
-		request.Form.Set(k, fmt.Sprintf("%s", v))
+		request.Form.Set(k, requestObjectParameter(v))
 	}
 
-	claimScope := RemoveEmpty(strings.Split(request.Form.Get("scope"), " "))
+	claimScope := f.parseScope(request.Form.Get("scope"))
 	for _, s := range scope {
 		if !stringslice.Has(claimScope, s) {
 			claimScope = append(claimScope, s)
@@ -173,23 +200,175 @@ func (f *Fosite) authorizeRequestParametersFromOpenIDConnectRequest(request *Aut
 	return nil
 }
 
+// requestObjectParameter converts a request object claim to a form value. JSON objects such as the OpenID Connect
+// claims parameter are encoded as JSON, as they would be when sent as a query parameter.
+func requestObjectParameter(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		if raw, err := json.Marshal(v); err == nil {
+			return string(raw)
+		}
+	}
+	return fmt.Sprintf("%s", v)
+}
+
+func (f *Fosite) fetchRequestObject(ctx context.Context, oidcClient OpenIDConnectClient, location string) (string, error) {
+	if !stringslice.Has(oidcClient.GetRequestURIs(), location) {
+		return "", errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Request URI '%s' is not whitelisted by the OAuth 2.0 Client.", location))
+	}
+
+	if u, err := url.Parse(location); err != nil || u.Scheme != "https" {
+		return "", errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Request URI '%s' must use HTTPS.", location))
+	} else if !f.isRequestURIAllowed(location) {
+		return "", errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Request URI '%s' is not allowed by the authorization server.", location))
+	}
+
+	hc := f.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	// Redirects must not lead the authorization server to hosts it would not have fetched from in the first place.
+	client := *hc
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" {
+			return errors.Errorf("redirect to '%s' does not use HTTPS", req.URL.String())
+		}
+		if !f.isRequestURIAllowed(req.URL.String()) {
+			return errors.Errorf("redirect to '%s' is not allowed by the authorization server", req.URL.String())
+		}
+		if hc.CheckRedirect != nil {
+			return hc.CheckRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.GetRequestURIFetchTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Unable to fetch OpenID Connect request parameters from 'request_uri' because: %s.", err.Error()).WithWrap(err).WithDebug(err.Error()))
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return "", errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Unable to fetch OpenID Connect request parameters from 'request_uri' because: %s.", err.Error()).WithWrap(err).WithDebug(err.Error()))
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Unable to fetch OpenID Connect request parameters from 'request_uri' because status code '%d' was expected, but got '%d'.", http.StatusOK, response.StatusCode))
+	}
+
+	maxSize := f.GetRequestURIMaxResponseSize()
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, maxSize+1))
+	if err != nil {
+		return "", errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Unable to fetch OpenID Connect request parameters from 'request_uri' because body parsing failed with: %s.", err).WithWrap(err).WithDebug(err.Error()))
+	} else if int64(len(body)) > maxSize {
+		return "", errorsx.WithStack(ErrInvalidRequestURI.WithHintf("Unable to fetch OpenID Connect request parameters from 'request_uri' because the response exceeds the maximum size of %d bytes.", maxSize))
+	}
+
+	return string(body), nil
+}
+
+// isRequestURIAllowed checks the location of a request object against RequestURIAllowlist and RequestURIAllowedHosts.
+func (f *Fosite) isRequestURIAllowed(location string) bool {
+	u, err := url.Parse(location)
+	if err != nil || u.User != nil {
+		return false
+	}
+
+	if len(f.RequestURIAllowlist) > 0 {
+		var allowed bool
+		for _, prefix := range f.RequestURIAllowlist {
+			if isRequestURIPrefixOf(prefix, u) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	if len(f.RequestURIAllowedHosts) > 0 {
+		for _, host := range f.RequestURIAllowedHosts {
+			if strings.EqualFold(u.Hostname(), host) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// isRequestURIPrefixOf returns true if the location has the scheme and host of the prefix and its path is below the
+// path of the prefix. Paths containing dot segments never match, as they could escape the prefix.
+func isRequestURIPrefixOf(prefix string, location *url.URL) bool {
+	p, err := url.Parse(prefix)
+	if err != nil || !strings.EqualFold(p.Scheme, location.Scheme) || !strings.EqualFold(p.Host, location.Host) {
+		return false
+	}
+
+	for _, segment := range strings.Split(location.Path, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+
+	if p.Path == "" || strings.HasSuffix(p.Path, "/") {
+		return strings.HasPrefix(location.Path, p.Path)
+	}
+	return location.Path == p.Path || strings.HasPrefix(location.Path, p.Path+"/")
+}
+
+func (f *Fosite) decryptRequestObject(assertion string) (string, error) {
+	if f.RequestObjectDecryptionKey == nil {
+		return "", errorsx.WithStack(ErrInvalidRequestObject.WithHint("The request object is encrypted, but the authorization server has no decryption key configured."))
+	}
+
+	encrypted, err := jose.ParseEncrypted(assertion)
+	if err != nil {
+		return "", errorsx.WithStack(ErrInvalidRequestObject.WithHint("Unable to parse the encrypted request object.").WithWrap(err).WithDebug(err.Error()))
+	}
+
+	decrypted, err := encrypted.Decrypt(f.RequestObjectDecryptionKey)
+	if err != nil {
+		return "", errorsx.WithStack(ErrInvalidRequestObject.WithHint("Unable to decrypt the request object.").WithWrap(err).WithDebug(err.Error()))
+	}
+
+	return string(decrypted), nil
+}
+
 func (f *Fosite) validateAuthorizeRedirectURI(_ *http.Request, request *AuthorizeRequest) error {
 	// Fetch redirect URI from request
 	rawRedirURI := request.Form.Get("redirect_uri")
 
 	// Validate redirect uri
-	redirectURI, err := MatchRedirectURIWithClientRedirectURIs(rawRedirURI, request.Client)
+	request.redirectURIMatchingStrategy = f.RedirectURIMatchingStrategy
+	redirectURI, err := matchRedirectURIWithClientRedirectURIs(rawRedirURI, request.Client, request.redirectURIMatchingStrategy)
 	if err != nil {
 		return err
 	} else if !IsValidRedirectURI(redirectURI) {
 		return errorsx.WithStack(ErrInvalidRequest.WithHintf("The redirect URI '%s' contains an illegal character (for example #) or is otherwise invalid.", redirectURI))
+	} else if f.RedirectURISecurityPolicy != nil && !f.RedirectURISecurityPolicy.IsSecure(redirectURI) {
+		return errorsx.WithStack(ErrInvalidRequest.WithHintf("The redirect URI '%s' must use HTTPS, or HTTP for a loopback host.", redirectURI))
 	}
 	request.RedirectURI = redirectURI
 	return nil
 }
 
 func (f *Fosite) validateAuthorizeScope(_ *http.Request, request *AuthorizeRequest) error {
-	scope := RemoveEmpty(strings.Split(request.Form.Get("scope"), " "))
+	scope := f.parseScope(request.Form.Get("scope"))
+	if err := f.validateScopeCount(scope); err != nil {
+		return err
+	}
+
 	for _, permission := range scope {
 		if !f.ScopeStrategy(request.Client.GetScopes(), permission) {
 			return errorsx.WithStack(ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope '%s'.", permission))
@@ -220,7 +399,7 @@ func (f *Fosite) validateResponseTypes(r *http.Request, request *AuthorizeReques
 	}
 
 	if !found {
-		return errorsx.WithStack(ErrUnsupportedResponseType.WithHintf("The client is not allowed to request response_type '%s'.", r.Form.Get("response_type")))
+		return errorsx.WithStack(ErrUnauthorizedClient.WithHintf("The OAuth 2.0 Client is not allowed to request response_type '%s'.", r.Form.Get("response_type")))
 	}
 
 	request.ResponseTypes = responseTypes
@@ -228,24 +407,12 @@ func (f *Fosite) validateResponseTypes(r *http.Request, request *AuthorizeReques
 }
 
 func (f *Fosite) ParseResponseMode(r *http.Request, request *AuthorizeRequest) error {
-	switch responseMode := r.Form.Get("response_mode"); responseMode {
-	case string(ResponseModeDefault):
-		request.ResponseMode = ResponseModeDefault
-	case string(ResponseModeFragment):
-		request.ResponseMode = ResponseModeFragment
-	case string(ResponseModeQuery):
-		request.ResponseMode = ResponseModeQuery
-	case string(ResponseModeFormPost):
-		request.ResponseMode = ResponseModeFormPost
-	default:
-		rm := ResponseModeType(responseMode)
-		if f.ResponseModeHandler().ResponseModes().Has(rm) {
-			request.ResponseMode = ResponseModeType(rm)
-			break
-		}
+	responseMode := ResponseModeType(r.Form.Get("response_mode"))
+	if f.GetResponseModeHandler(responseMode) == nil {
 		return errorsx.WithStack(ErrUnsupportedResponseMode.WithHintf("Request with unsupported response_mode \"%s\".", responseMode))
 	}
 
+	request.ResponseMode = responseMode
 	return nil
 }
 
@@ -274,63 +441,122 @@ func (f *Fosite) validateResponseMode(r *http.Request, request *AuthorizeRequest
 	return nil
 }
 
-func (f *Fosite) NewAuthorizeRequest(ctx context.Context, r *http.Request) (AuthorizeRequester, error) {
+func (f *Fosite) NewAuthorizeRequest(ctx context.Context, r *http.Request) (_ AuthorizeRequester, err error) {
+	ctx, endSpan := f.startSpan(ctx, TraceOperationNewAuthorizeRequest)
 	request := NewAuthorizeRequest()
+	start := time.Now()
+	defer func() {
+		if !f.hasRequestHooks() {
+			return
+		}
+
+		event := newAuditEvent(request, err)
+		if err != nil {
+			f.audit(func(l AuditLogger) { l.AuthorizeFailed(ctx, event) })
+		}
+		f.observe(func(o Observer) {
+			labels := newObserverLabels(ObserverEndpointAuthorize, event)
+			o.AuthorizeRequestObserved(ctx, labels, time.Since(start))
+			observeFailure(ctx, o, labels)
+		})
+		endSpan(event)
+	}()
 
 	ctx = context.WithValue(ctx, RequestContextKey, r)
 	ctx = context.WithValue(ctx, AuthorizeRequestContextKey, request)
 
-	if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+	if err := f.parseMultipartForm(r); err != nil && err != http.ErrNotMultipart {
 		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithWrap(err).WithDebug(err.Error()))
+	} else if err := f.validateParameterLengths(r.Form); err != nil {
+		return request, err
 	}
 	request.Form = r.Form
 
 	// Save state to the request to be returned in error conditions (https://github.com/ory/hydra/issues/1642)
 	request.State = request.Form.Get("state")
 
-	client, err := f.Store.GetClient(ctx, request.GetRequestForm().Get("client_id"))
-	if err != nil {
+	if ctx, err = f.extractRequestContext(ctx, r, &request.Request); err != nil {
+		return request, err
+	}
+
+	client, err := f.getClient(ctx, request.GetRequestForm().Get("client_id"))
+	if errors.Is(err, ErrTemporarilyUnavailable) {
+		return request, err
+	} else if err != nil {
 		return request, errorsx.WithStack(ErrInvalidClient.WithHint("The requested OAuth 2.0 Client does not exist.").WithWrap(err).WithDebug(err.Error()))
 	}
 	request.Client = client
 
+	// Pushed authorization requests have been validated when they were pushed, see
+	// https://tools.ietf.org/html/rfc9126#section-4
+	if ok, err := f.authorizeRequestFromPAR(ctx, request); err != nil {
+		return request, err
+	} else if ok {
+		return request, nil
+	} else if f.RequirePushedAuthorizationRequests {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("The authorization server requires the use of Pushed Authorization Requests."))
+	} else if pc, ok := client.(PushedAuthorizeClient); ok && pc.GetRequirePushedAuthorizationRequests() {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("The OAuth 2.0 Client requires the use of Pushed Authorization Requests."))
+	}
+
+	if err := f.validateAuthorizeRequest(ctx, r, request); err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+// validateAuthorizeRequest validates the parameters of an authorize request whose state and client are populated.
+func (f *Fosite) validateAuthorizeRequest(ctx context.Context, r *http.Request, request *AuthorizeRequest) error {
 	// Now that the base fields (state and client) are populated, we extract all the information
 	// from the request object or request object uri, if one is set.
 	//
 	// All other parse methods should come afterwards so that we ensure that the data is taken
 	// from the request_object if set.
-	if err := f.authorizeRequestParametersFromOpenIDConnectRequest(request); err != nil {
-		return request, err
+	if err := f.authorizeRequestParametersFromOpenIDConnectRequest(ctx, request); err != nil {
+		return err
 	}
 
 	// The request context is now fully available and we can start processing the individual
 	// fields.
 	if err := f.ParseResponseMode(r, request); err != nil {
-		return request, err
+		return err
 	}
 
 	if err := f.validateAuthorizeRedirectURI(r, request); err != nil {
-		return request, err
+		return err
 	}
 
 	if err := f.validateAuthorizeScope(r, request); err != nil {
-		return request, err
+		return err
 	}
 
 	if err := f.validateAuthorizeAudience(r, request); err != nil {
-		return request, err
+		return err
+	}
+
+	if err := f.validateAuthorizationDetails(ctx, &request.Request); err != nil {
+		return err
+	}
+
+	if err := f.validateLoginHint(ctx, request); err != nil {
+		return err
+	}
+
+	if err := f.parseAuthorizeUIParameters(request); err != nil {
+		return err
 	}
 
 	if len(request.Form.Get("registration")) > 0 {
-		return request, errorsx.WithStack(ErrRegistrationNotSupported)
+		return errorsx.WithStack(ErrRegistrationNotSupported)
 	}
 
 	if err := f.validateResponseTypes(r, request); err != nil {
-		return request, err
+		return err
 	}
 
 	if err := f.validateResponseMode(r, request); err != nil {
-		return request, err
+		return err
 	}
 
 	// A fallback handler to set the default response mode in cases where we can not reach the Authorize Handlers
@@ -344,6 +570,14 @@ func (f *Fosite) NewAuthorizeRequest(ctx context.Context, r *http.Request) (Auth
 		}
 	}
 
+	if sc, ok := request.GetClient().(StateClient); ok && sc.GetRequireState() {
+		if len(request.State) == 0 {
+			return errorsx.WithStack(ErrInvalidState.WithHint("The OAuth 2.0 Client requires the 'state' parameter to protect its authorization requests against CSRF."))
+		} else if len(request.State) < f.MinStateLength {
+			return errorsx.WithStack(ErrInvalidState.WithHintf("The OAuth 2.0 Client requires the 'state' parameter to be at least %d characters long.", f.MinStateLength))
+		}
+	}
+
 	// rfc6819 4.4.1.8.  Threat: CSRF Attack against redirect-uri
 	// The "state" parameter should be used to link the authorization
 	// request with the redirect URI used to deliver the access token (Section 5.3.5).
@@ -352,8 +586,8 @@ func (f *Fosite) NewAuthorizeRequest(ctx context.Context, r *http.Request) (Auth
 	// The "state" parameter should not	be guessable
 	if len(request.State) < f.GetMinParameterEntropy() {
 		// We're assuming that using less then, by default, 8 characters for the state can not be considered "unguessable"
-		return request, errorsx.WithStack(ErrInvalidState.WithHintf("Request parameter 'state' must be at least be %d characters long to ensure sufficient entropy.", f.GetMinParameterEntropy()))
+		return errorsx.WithStack(ErrInvalidState.WithHintf("Request parameter 'state' must be at least be %d characters long to ensure sufficient entropy.", f.GetMinParameterEntropy()))
 	}
 
-	return request, nil
+	return nil
 }
@@ -22,6 +22,7 @@
 package fosite
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"encoding/json"
@@ -29,7 +30,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -63,6 +66,16 @@ func mustGenerateNoneAssertion(t *testing.T, claims jwt.MapClaims) string {
 	return tokenString
 }
 
+func mustEncryptRequestObject(t *testing.T, requestObject string, key *rsa.PublicKey) string {
+	encrypter, err := jose.NewEncrypter(jose.A128GCM, jose.Recipient{Algorithm: jose.RSA_OAEP, Key: key}, (&jose.EncrypterOptions{}).WithContentType("JWT"))
+	require.NoError(t, err)
+	encrypted, err := encrypter.Encrypt([]byte(requestObject))
+	require.NoError(t, err)
+	serialized, err := encrypted.CompactSerialize()
+	require.NoError(t, err)
+	return serialized
+}
+
 func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 	key, err := rsa.GenerateKey(rand.Reader, 1024)
 	if err != nil {
@@ -80,12 +93,15 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 
 	validRequestObject := mustGenerateAssertion(t, jwt.MapClaims{"scope": "foo", "foo": "bar", "baz": "baz", "response_type": "token", "response_mode": "post_form"}, key, "kid-foo")
 	validRequestObjectWithoutKid := mustGenerateAssertion(t, jwt.MapClaims{"scope": "foo", "foo": "bar", "baz": "baz"}, key, "")
+	validRequestObjectWithClientID := mustGenerateAssertion(t, jwt.MapClaims{"client_id": "foo", "iss": "foo"}, key, "kid-foo")
+	encryptedRequestObject := mustEncryptRequestObject(t, validRequestObjectWithoutKid, &key.PublicKey)
+	validRequestObjectWithClaims := mustGenerateAssertion(t, jwt.MapClaims{"claims": map[string]interface{}{"id_token": map[string]interface{}{"acr": map[string]interface{}{"essential": true}}}}, key, "kid-foo")
 	validNoneRequestObject := mustGenerateNoneAssertion(t, jwt.MapClaims{"scope": "foo", "foo": "bar", "baz": "baz", "state": "some-state"})
 
 	var reqH http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
 		rw.Write([]byte(validRequestObject))
 	}
-	reqTS := httptest.NewServer(reqH)
+	reqTS := httptest.NewTLSServer(reqH)
 	defer reqTS.Close()
 
 	var hJWK http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
@@ -94,7 +110,7 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 	reqJWK := httptest.NewServer(hJWK)
 	defer reqJWK.Close()
 
-	f := &Fosite{JWKSFetcherStrategy: NewDefaultJWKSFetcherStrategy()}
+	f := &Fosite{JWKSFetcherStrategy: NewDefaultJWKSFetcherStrategy(), RequestObjectDecryptionKey: key, HTTPClient: reqTS.Client()}
 	for k, tc := range []struct {
 		client Client
 		form   url.Values
@@ -117,9 +133,9 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 			expectForm: url.Values{"scope": {"openid"}},
 		},
 		{
-			d:          "should pass because request context given but not openid",
+			d:          "should fail because request context given but not an OpenIDConnect compliant client",
 			form:       url.Values{"request": {"foo"}},
-			expectErr:  nil,
+			expectErr:  ErrRequestNotSupported,
 			expectForm: url.Values{"request": {"foo"}},
 		},
 		{
@@ -165,12 +181,56 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 			expectForm:      url.Values{"scope": {"openid"}},
 		},
 		{
-			d:      "should pass and set request parameters properly",
-			form:   url.Values{"scope": {"openid"}, "response_type": {"code"}, "response_mode": {"none"}, "request": {validRequestObject}},
-			client: &DefaultOpenIDConnectClient{JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
-			// The values from form are overwritten by the request object.
+			d:          "should fail because the request parameters conflict with the request object",
+			form:       url.Values{"scope": {"openid"}, "response_type": {"code"}, "response_mode": {"none"}, "request": {validRequestObject}},
+			client:     &DefaultOpenIDConnectClient{JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
+			expectErr:  ErrInvalidRequestObject,
+			expectForm: url.Values{"scope": {"openid"}},
+		},
+		{
+			d:          "should fail because a request parameter is duplicated",
+			form:       url.Values{"scope": {"openid"}, "response_type": {"token", "code"}, "request": {validRequestObject}},
+			client:     &DefaultOpenIDConnectClient{JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
+			expectErr:  ErrInvalidRequestObject,
+			expectForm: url.Values{"scope": {"openid"}},
+		},
+		{
+			d:          "should pass and encode the claims parameter as JSON",
+			form:       url.Values{"scope": {"openid"}, "request": {validRequestObjectWithClaims}},
+			client:     &DefaultOpenIDConnectClient{JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
+			expectForm: url.Values{"scope": {"openid"}, "request": {validRequestObjectWithClaims}, "claims": {`{"id_token":{"acr":{"essential":true}}}`}},
+		},
+		{
+			d:          "should pass and set request parameters properly",
+			form:       url.Values{"scope": {"openid"}, "response_type": {"token"}, "request": {validRequestObject}},
+			client:     &DefaultOpenIDConnectClient{JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
 			expectForm: url.Values{"response_type": {"token"}, "response_mode": {"post_form"}, "scope": {"foo openid"}, "request": {validRequestObject}, "foo": {"bar"}, "baz": {"baz"}},
 		},
+		{
+			d:          "should pass without openid scope and take the scope from the request object",
+			form:       url.Values{"request": {validRequestObject}},
+			client:     &DefaultOpenIDConnectClient{JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
+			expectForm: url.Values{"response_type": {"token"}, "response_mode": {"post_form"}, "scope": {"foo"}, "request": {validRequestObject}, "foo": {"bar"}, "baz": {"baz"}},
+		},
+		{
+			d:          "should fail because the client_id of the request object does not match",
+			form:       url.Values{"scope": {"openid"}, "request": {mustGenerateAssertion(t, jwt.MapClaims{"client_id": "bar"}, key, "kid-foo")}},
+			client:     &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo"}, JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
+			expectErr:  ErrInvalidRequestObject,
+			expectForm: url.Values{"scope": {"openid"}},
+		},
+		{
+			d:          "should pass with matching client_id and iss",
+			form:       url.Values{"scope": {"openid"}, "client_id": {"foo"}, "request": {validRequestObjectWithClientID}},
+			client:     &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo"}, JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
+			expectForm: url.Values{"scope": {"openid"}, "client_id": {"foo"}, "iss": {"foo"}, "request": {validRequestObjectWithClientID}},
+		},
+		{
+			d:          "should pass with an encrypted request object",
+			form:       url.Values{"scope": {"openid"}, "request": {encryptedRequestObject}},
+			client:     &DefaultOpenIDConnectClient{JSONWebKeys: jwks, RequestObjectSigningAlgorithm: "RS256"},
+			expectForm: url.Values{"scope": {"foo openid"}, "request": {encryptedRequestObject}, "foo": {"bar"}, "baz": {"baz"}},
+		},
 		{
 			d:          "should pass even if kid is unset",
 			form:       url.Values{"scope": {"openid"}, "request": {validRequestObjectWithoutKid}},
@@ -197,10 +257,11 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 			expectForm: url.Values{"state": {"some-state"}, "scope": {"foo openid"}, "request": {validNoneRequestObject}, "foo": {"bar"}, "baz": {"baz"}},
 		},
 		{
-			d:          "should pass when request object uses algorithm none and the client did not explicitly allow any algorithm",
+			d:          "should fail when request object uses algorithm none and the client did not explicitly allow it",
 			form:       url.Values{"scope": {"openid"}, "request": {validNoneRequestObject}},
 			client:     &DefaultOpenIDConnectClient{JSONWebKeysURI: reqJWK.URL},
-			expectForm: url.Values{"state": {"some-state"}, "scope": {"foo openid"}, "request": {validNoneRequestObject}, "foo": {"bar"}, "baz": {"baz"}},
+			expectErr:  ErrInvalidRequestObject,
+			expectForm: url.Values{"scope": {"openid"}},
 		},
 	} {
 		t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
@@ -211,7 +272,7 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 				},
 			}
 
-			err := f.authorizeRequestParametersFromOpenIDConnectRequest(req)
+			err := f.authorizeRequestParametersFromOpenIDConnectRequest(context.Background(), req)
 			if tc.expectErr != nil {
 				require.EqualError(t, err, tc.expectErr.Error(), "%+v", err)
 				if tc.expectErrReason != "" {
@@ -234,3 +295,149 @@ func TestAuthorizeRequestParametersFromOpenIDConnectRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorizeRequestParametersFromOpenIDConnectRequestAudience(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	client := &DefaultOpenIDConnectClient{
+		DefaultClient:                 &DefaultClient{ID: "foo"},
+		JSONWebKeys:                   &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{KeyID: "kid-foo", Use: "sig", Key: &key.PublicKey}}},
+		RequestObjectSigningAlgorithm: "RS256",
+	}
+
+	f := &Fosite{JWKSFetcherStrategy: NewDefaultJWKSFetcherStrategy(), Issuer: "https://auth.example.com"}
+	for k, tc := range []struct {
+		claims    jwt.MapClaims
+		expectErr error
+	}{
+		{claims: jwt.MapClaims{"iss": "foo"}},
+		{claims: jwt.MapClaims{"iss": "foo", "aud": "https://auth.example.com"}},
+		{claims: jwt.MapClaims{"iss": "foo", "aud": []string{"https://auth.example.com", "https://other.example.com"}}},
+		{claims: jwt.MapClaims{"iss": "foo", "aud": "https://other.example.com"}, expectErr: ErrInvalidRequestObject},
+		{claims: jwt.MapClaims{"iss": "bar", "aud": "https://auth.example.com"}, expectErr: ErrInvalidRequestObject},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			req := &AuthorizeRequest{
+				Request: Request{
+					Client: client,
+					Form:   url.Values{"scope": {"openid"}, "request": {mustGenerateAssertion(t, tc.claims, key, "kid-foo")}},
+				},
+			}
+
+			err := f.authorizeRequestParametersFromOpenIDConnectRequest(context.Background(), req)
+			if tc.expectErr != nil {
+				require.EqualError(t, err, tc.expectErr.Error(), "%+v", err)
+				return
+			}
+			require.NoError(t, err, "%+v", err)
+		})
+	}
+}
+
+func TestFetchRequestObject(t *testing.T) {
+	var h http.HandlerFunc = func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(time.Second)
+		}
+		_, _ = rw.Write([]byte("request-object"))
+	}
+	ts := httptest.NewTLSServer(h)
+	defer ts.Close()
+
+	client := &DefaultOpenIDConnectClient{RequestURIs: []string{ts.URL + "/foo", ts.URL + "/slow"}}
+
+	body, err := (&Fosite{HTTPClient: ts.Client()}).fetchRequestObject(context.Background(), client, ts.URL+"/foo")
+	require.NoError(t, err)
+	assert.Equal(t, "request-object", body)
+
+	_, err = (&Fosite{HTTPClient: ts.Client(), RequestURIAllowlist: []string{"https://allowed.example.com/"}}).fetchRequestObject(context.Background(), client, ts.URL+"/foo")
+	require.EqualError(t, err, ErrInvalidRequestURI.Error())
+
+	_, err = (&Fosite{HTTPClient: ts.Client(), RequestURIAllowlist: []string{ts.URL + "/"}}).fetchRequestObject(context.Background(), client, ts.URL+"/foo")
+	require.NoError(t, err)
+
+	_, err = (&Fosite{HTTPClient: ts.Client(), RequestURIAllowlist: []string{ts.URL + "/bar"}}).fetchRequestObject(context.Background(), client, ts.URL+"/foo")
+	require.EqualError(t, err, ErrInvalidRequestURI.Error())
+
+	_, err = (&Fosite{HTTPClient: ts.Client(), RequestURIFetchTimeout: time.Millisecond * 50}).fetchRequestObject(context.Background(), client, ts.URL+"/slow")
+	require.EqualError(t, err, ErrInvalidRequestURI.Error())
+
+	_, err = (&Fosite{HTTPClient: ts.Client(), RequestURIAllowedHosts: []string{"allowed.example.com"}}).fetchRequestObject(context.Background(), client, ts.URL+"/foo")
+	require.EqualError(t, err, ErrInvalidRequestURI.Error())
+
+	_, err = (&Fosite{HTTPClient: ts.Client(), RequestURIAllowedHosts: []string{"127.0.0.1"}}).fetchRequestObject(context.Background(), client, ts.URL+"/foo")
+	require.NoError(t, err)
+
+	_, err = (&Fosite{HTTPClient: ts.Client(), RequestURIMaxResponseSize: 4}).fetchRequestObject(context.Background(), client, ts.URL+"/foo")
+	require.EqualError(t, err, ErrInvalidRequestURI.Error())
+
+	_, err = (&Fosite{HTTPClient: ts.Client(), RequestURIMaxResponseSize: int64(len("request-object"))}).fetchRequestObject(context.Background(), client, ts.URL+"/foo")
+	require.NoError(t, err)
+
+	var fetched bool
+	plain := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		fetched = true
+		_, _ = rw.Write([]byte("request-object"))
+	}))
+	defer plain.Close()
+
+	_, err = (&Fosite{}).fetchRequestObject(context.Background(), &DefaultOpenIDConnectClient{RequestURIs: []string{plain.URL + "/foo"}}, plain.URL+"/foo")
+	require.EqualError(t, err, ErrInvalidRequestURI.Error())
+	assert.False(t, fetched)
+}
+
+func TestIsRequestURIAllowed(t *testing.T) {
+	f := &Fosite{RequestURIAllowlist: []string{"https://allowed.example.com", "https://other.example.com/objects/"}}
+	for location, expected := range map[string]bool{
+		"https://allowed.example.com/foo":                true,
+		"https://ALLOWED.example.com/foo":                true,
+		"https://allowed.example.com.evil.com/foo":       false,
+		"https://allowed.example.com@evil.com/foo":       false,
+		"https://allowed.example.com:8443/foo":           false,
+		"http://allowed.example.com/foo":                 false,
+		"https://other.example.com/objects/foo":          true,
+		"https://other.example.com/objects-evil/foo":     false,
+		"https://other.example.com/objects/../admin/foo": false,
+		"https://other.example.com/foo":                  false,
+		"://invalid":                                     false,
+	} {
+		assert.Equal(t, expected, f.isRequestURIAllowed(location), location)
+	}
+}
+
+func TestFetchRequestObjectRedirects(t *testing.T) {
+	target := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = rw.Write([]byte("request-object"))
+	}))
+	defer target.Close()
+
+	plain := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = rw.Write([]byte("request-object"))
+	}))
+	defer plain.Close()
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/https":
+			http.Redirect(rw, r, target.URL+"/object", http.StatusFound)
+		case "/http":
+			http.Redirect(rw, r, plain.URL+"/object", http.StatusFound)
+		case "/host":
+			http.Redirect(rw, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1)+"/object", http.StatusFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := &DefaultOpenIDConnectClient{RequestURIs: []string{ts.URL + "/https", ts.URL + "/http", ts.URL + "/host"}}
+	f := &Fosite{HTTPClient: ts.Client(), RequestURIAllowedHosts: []string{"127.0.0.1"}}
+
+	body, err := f.fetchRequestObject(context.Background(), client, ts.URL+"/https")
+	require.NoError(t, err)
+	assert.Equal(t, "request-object", body)
+
+	_, err = f.fetchRequestObject(context.Background(), client, ts.URL+"/http")
+	require.EqualError(t, err, ErrInvalidRequestURI.Error())
+
+	_, err = f.fetchRequestObject(context.Background(), client, ts.URL+"/host")
+	require.EqualError(t, err, ErrInvalidRequestURI.Error())
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestNewAuthorizeRequestMinStateLength(t *testing.T) {
+	store := storage.NewMemoryStore()
+	for id, requireState := range map[string]bool{"requires-state": true, "optional-state": false} {
+		store.Clients[id] = &DefaultOpenIDConnectClient{
+			DefaultClient: &DefaultClient{
+				ID:            id,
+				RedirectURIs:  []string{"https://foo.example.com/cb"},
+				ResponseTypes: []string{"code"},
+				Scopes:        []string{"foo"},
+			},
+			RequireState: requireState,
+		}
+	}
+
+	for k, c := range []struct {
+		d              string
+		client         string
+		state          string
+		minEntropy     int
+		minStateLength int
+		expectErr      error
+		expectHint     string
+	}{
+		{
+			d:              "should fail because the state is shorter than required by the client",
+			client:         "requires-state",
+			state:          "1234567890",
+			minStateLength: 16,
+			expectErr:      ErrInvalidState,
+		},
+		{
+			d:          "should fail with the client's requirement if the state is missing",
+			client:     "requires-state",
+			expectErr:  ErrInvalidState,
+			expectHint: "requires the 'state' parameter to protect",
+		},
+		{
+			d:              "should fail with the client's requirement if the state is also below the minimum entropy",
+			client:         "requires-state",
+			state:          "1234",
+			minStateLength: 16,
+			expectErr:      ErrInvalidState,
+			expectHint:     "at least 16 characters long",
+		},
+		{
+			d:              "should pass because the state is long enough",
+			client:         "requires-state",
+			state:          "1234567890123456",
+			minStateLength: 16,
+		},
+		{
+			d:              "should pass because the client does not require the state",
+			client:         "optional-state",
+			state:          "1234567890",
+			minStateLength: 16,
+		},
+		{
+			d:          "should fail because the client requires a state even if the entropy check is disabled",
+			client:     "requires-state",
+			minEntropy: -1,
+			expectErr:  ErrInvalidState,
+		},
+		{
+			d:          "should pass without a state because neither the client nor the entropy check require it",
+			client:     "optional-state",
+			minEntropy: -1,
+		},
+	} {
+		t.Run(c.d, func(t *testing.T) {
+			f := &Fosite{Store: store, ScopeStrategy: HierarchicScopeStrategy, AudienceMatchingStrategy: DefaultAudienceMatchingStrategy, MinParameterEntropy: c.minEntropy, MinStateLength: c.minStateLength}
+			query := url.Values{
+				"client_id":     {c.client},
+				"redirect_uri":  {"https://foo.example.com/cb"},
+				"response_type": {"code"},
+				"scope":         {"foo"},
+				"state":         {c.state},
+			}
+
+			_, err := f.NewAuthorizeRequest(context.Background(), &http.Request{Method: "GET", URL: &url.URL{RawQuery: query.Encode()}})
+			if c.expectErr != nil {
+				require.Error(t, err, "%d", k)
+				assert.True(t, errors.Is(err, c.expectErr), "%+v", err)
+				if c.expectHint != "" {
+					assert.Contains(t, ErrorToRFC6749Error(err).HintField, c.expectHint)
+				}
+				return
+			}
+			require.NoError(t, err, "%+v", err)
+		})
+	}
+}
@@ -154,6 +154,22 @@ func TestNewAuthorizeRequest(t *testing.T) {
 				store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{}}, nil)
 			},
 		},
+		/* fails because response type not registered */
+		{
+			desc: "should fail because client is not registered for response type token",
+			conf: &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy, AudienceMatchingStrategy: DefaultAudienceMatchingStrategy},
+			query: url.Values{
+				"redirect_uri":  {"https://foo.bar/cb"},
+				"client_id":     {"1234"},
+				"response_type": {"token"},
+				"state":         {"strong-state"},
+				"scope":         {"foo"},
+			},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"foo"}, ResponseTypes: []string{"code"}}, nil)
+			},
+			expectedError: ErrUnauthorizedClient,
+		},
 		/* fails because scope not given */
 		{
 			desc: "should fail because client does not have scope baz",
@@ -190,6 +206,42 @@ func TestNewAuthorizeRequest(t *testing.T) {
 			},
 			expectedError: ErrInvalidRequest,
 		},
+		/* fails because too many scopes */
+		{
+			desc: "should fail because more scopes than allowed are requested",
+			conf: &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy, AudienceMatchingStrategy: DefaultAudienceMatchingStrategy, MaxScopes: 2},
+			query: url.Values{
+				"redirect_uri":  {"https://foo.bar/cb"},
+				"client_id":     {"1234"},
+				"response_type": {"code"},
+				"state":         {"strong-state"},
+				"scope":         {"foo bar baz"},
+			},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"foo", "bar", "baz"}}, nil)
+			},
+			expectedError: ErrInvalidRequest,
+		},
+		/* fails because too many audiences */
+		{
+			desc: "should fail because more audiences than allowed are requested",
+			conf: &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy, AudienceMatchingStrategy: DefaultAudienceMatchingStrategy, MaxAudiences: 1},
+			query: url.Values{
+				"redirect_uri":  {"https://foo.bar/cb"},
+				"client_id":     {"1234"},
+				"response_type": {"code"},
+				"state":         {"strong-state"},
+				"scope":         {"foo"},
+				"audience":      {"https://cloud.ory.sh/api https://www.ory.sh/api"},
+			},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), "1234").Return(&DefaultClient{
+					RedirectURIs: []string{"https://foo.bar/cb"}, Scopes: []string{"foo"},
+					Audience: []string{"https://cloud.ory.sh/api", "https://www.ory.sh/api"},
+				}, nil)
+			},
+			expectedError: ErrInvalidTarget,
+		},
 		/* success case */
 		{
 			desc: "should pass",
@@ -25,31 +25,57 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/ory/x/errorsx"
 )
 
-func (f *Fosite) NewAuthorizeResponse(ctx context.Context, ar AuthorizeRequester, session Session) (AuthorizeResponder, error) {
+func (f *Fosite) NewAuthorizeResponse(ctx context.Context, ar AuthorizeRequester, session Session) (_ AuthorizeResponder, err error) {
+	ctx, endSpan := f.startSpan(ctx, TraceOperationNewAuthorizeResponse)
 	var resp = &AuthorizeResponse{
 		Header:     http.Header{},
 		Parameters: url.Values{},
 	}
+	defer func() {
+		if !f.hasRequestHooks() {
+			return
+		}
+
+		event := newAuditEvent(ar, err)
+		if err != nil {
+			f.audit(func(l AuditLogger) { l.AuthorizeFailed(ctx, event) })
+		} else {
+			f.audit(func(l AuditLogger) { l.AuthorizeSucceeded(ctx, event) })
+		}
+		f.observe(func(o Observer) { observeFailure(ctx, o, newObserverLabels(ObserverEndpointAuthorize, event)) })
+		endSpan(event)
+	}()
 
 	ctx = context.WithValue(ctx, AuthorizeRequestContextKey, ar)
 	ctx = context.WithValue(ctx, AuthorizeResponseContextKey, resp)
 
 	ar.SetSession(session)
+	if err := f.grantConsent(ctx, ar, session); err != nil {
+		return nil, err
+	}
+
 	for _, h := range f.AuthorizeEndpointHandlers {
-		if err := h.HandleAuthorizeEndpointRequest(ctx, ar, resp); err != nil {
+		if err := f.withStorageTimeout(ctx, func(ctx context.Context) error {
+			return h.HandleAuthorizeEndpointRequest(ctx, ar, resp)
+		}); err != nil {
 			return nil, err
 		}
 	}
 
+	if tokenType := resp.GetParameters().Get("token_type"); strings.EqualFold(tokenType, BearerAccessToken) {
+		resp.GetParameters().Set("token_type", f.bearerTokenType())
+	}
+
 	if !ar.DidHandleAllResponseTypes() {
 		return nil, errorsx.WithStack(ErrUnsupportedResponseType)
 	}
 
-	if ar.GetDefaultResponseMode() == ResponseModeFragment && ar.GetResponseMode() == ResponseModeQuery {
+	if rm := ar.GetResponseMode(); ar.GetDefaultResponseMode() == ResponseModeFragment && (rm == ResponseModeQuery || rm == ResponseModeQueryJWT) {
 		return nil, ErrUnsupportedResponseMode.WithHintf("Insecure response_mode '%s' for the response_type '%s'.", ar.GetResponseMode(), ar.GetResponseTypes())
 	}
 
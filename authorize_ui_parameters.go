@@ -0,0 +1,86 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"strings"
+
+	"github.com/ory/x/errorsx"
+)
+
+// DisplayType specifies how the authorization server displays the authentication and consent user interface pages,
+// see https://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+type DisplayType string
+
+const (
+	DisplayDefault = DisplayType("")
+	DisplayPage    = DisplayType("page")
+	DisplayPopup   = DisplayType("popup")
+	DisplayTouch   = DisplayType("touch")
+	DisplayWAP     = DisplayType("wap")
+)
+
+// IsValid returns true if the display type is one of the values defined by OpenID Connect.
+func (d DisplayType) IsValid() bool {
+	switch d {
+	case DisplayPage, DisplayPopup, DisplayTouch, DisplayWAP:
+		return true
+	}
+	return false
+}
+
+// AuthorizeUIRequester is implemented by requests which carry the ui_locales, claims_locales and display parameters.
+type AuthorizeUIRequester interface {
+	// GetUILocales returns the preferred languages of the user interface, in order of preference.
+	GetUILocales() Arguments
+
+	// GetClaimsLocales returns the preferred languages of the returned claims, in order of preference.
+	GetClaimsLocales() Arguments
+
+	// GetDisplay returns how the user interface should be displayed.
+	GetDisplay() DisplayType
+}
+
+func (d *AuthorizeRequest) GetUILocales() Arguments {
+	return d.UILocales
+}
+
+func (d *AuthorizeRequest) GetClaimsLocales() Arguments {
+	return d.ClaimsLocales
+}
+
+func (d *AuthorizeRequest) GetDisplay() DisplayType {
+	return d.Display
+}
+
+// parseAuthorizeUIParameters stores the ui_locales, claims_locales and display parameters on the request. Unknown
+// display values are rejected unless AllowUnknownDisplayValues is set.
+func (f *Fosite) parseAuthorizeUIParameters(request *AuthorizeRequest) error {
+	request.UILocales = RemoveEmpty(strings.Split(request.Form.Get("ui_locales"), " "))
+	request.ClaimsLocales = RemoveEmpty(strings.Split(request.Form.Get("claims_locales"), " "))
+	request.Display = DisplayType(request.Form.Get("display"))
+
+	if request.Display != DisplayDefault && !request.Display.IsValid() && !f.AllowUnknownDisplayValues {
+		return errorsx.WithStack(ErrInvalidRequest.WithHintf("Request parameter 'display' must be one of 'page', 'popup', 'touch' or 'wap' but got '%s'.", request.Display))
+	}
+	return nil
+}
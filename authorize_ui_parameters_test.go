@@ -0,0 +1,96 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestAuthorizeUIParameters(t *testing.T) {
+	for k, c := range []struct {
+		query         url.Values
+		lenient       bool
+		expectErr     error
+		uiLocales     Arguments
+		claimsLocales Arguments
+		display       DisplayType
+	}{
+		{query: url.Values{}},
+		{
+			query:         url.Values{"ui_locales": {"de-CH  fr-CH en"}, "claims_locales": {"de"}, "display": {"popup"}},
+			uiLocales:     Arguments{"de-CH", "fr-CH", "en"},
+			claimsLocales: Arguments{"de"},
+			display:       DisplayPopup,
+		},
+		{query: url.Values{"display": {"page"}}, display: DisplayPage},
+		{query: url.Values{"display": {"touch"}}, display: DisplayTouch},
+		{query: url.Values{"display": {"wap"}}, display: DisplayWAP},
+		{query: url.Values{"display": {"fullscreen"}}, expectErr: ErrInvalidRequest},
+		{query: url.Values{"display": {"fullscreen"}}, lenient: true, display: DisplayType("fullscreen")},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			f := &Fosite{Store: storage.NewExampleStore(), ScopeStrategy: ExactScopeStrategy, AudienceMatchingStrategy: DefaultAudienceMatchingStrategy, AllowUnknownDisplayValues: c.lenient}
+			query := url.Values{
+				"client_id":     {"my-client"},
+				"redirect_uri":  {"http://localhost:3846/callback"},
+				"response_type": {"code"},
+				"state":         {"strong-state"},
+			}
+			for key, value := range c.query {
+				query[key] = value
+			}
+
+			ar, err := f.NewAuthorizeRequest(context.Background(), httptest.NewRequest(http.MethodGet, "/auth?"+query.Encode(), nil))
+			if c.expectErr != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, c.expectErr))
+				return
+			}
+			require.NoError(t, err)
+
+			// The parameters must survive the round-trip through the storage while the end-user logs in.
+			raw, err := json.Marshal(ar)
+			require.NoError(t, err)
+			restored := NewAuthorizeRequest()
+			require.NoError(t, json.Unmarshal(raw, restored))
+
+			for _, r := range []*AuthorizeRequest{ar.(*AuthorizeRequest), restored} {
+				assert.Equal(t, c.uiLocales, r.GetUILocales())
+				assert.Equal(t, c.claimsLocales, r.GetClaimsLocales())
+				assert.Equal(t, c.display, r.GetDisplay())
+			}
+		})
+	}
+}
@@ -22,10 +22,13 @@
 package fosite
 
 import (
+	"context"
 	"net/http"
+
+	"github.com/ory/x/errorsx"
 )
 
-func (f *Fosite) WriteAuthorizeResponse(rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
+func (f *Fosite) WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
 	// Set custom headers, e.g. "X-MySuperCoolCustomHeader" or "X-DONT-CACHE-ME"...
 	wh := rw.Header()
 	rh := resp.GetHeader()
@@ -36,36 +39,13 @@ func (f *Fosite) WriteAuthorizeResponse(rw http.ResponseWriter, ar AuthorizeRequ
 	wh.Set("Cache-Control", "no-store")
 	wh.Set("Pragma", "no-cache")
 
-	redir := ar.GetRedirectURI()
-	switch rm := ar.GetResponseMode(); rm {
-	case ResponseModeFormPost:
-		//form_post
-		rw.Header().Add("Content-Type", "text/html;charset=UTF-8")
-		WriteAuthorizeFormPostResponse(redir.String(), resp.GetParameters(), GetPostFormHTMLTemplate(*f), rw)
-		return
-	case ResponseModeQuery, ResponseModeDefault:
-		// Explicit grants
-		q := redir.Query()
-		rq := resp.GetParameters()
-		for k := range rq {
-			q.Set(k, rq.Get(k))
-		}
-		redir.RawQuery = q.Encode()
-		sendRedirect(redir.String(), rw)
+	h := f.GetResponseModeHandler(ar.GetResponseMode())
+	if h == nil {
+		f.writeAuthorizeErrorJSON(rw, errorsx.WithStack(ErrUnsupportedResponseMode.WithHintf("Request with unsupported response_mode \"%s\".", ar.GetResponseMode())))
 		return
-	case ResponseModeFragment:
-		// Implicit grants
-		// The endpoint URI MUST NOT include a fragment component.
-		redir.Fragment = ""
-		URLSetFragment(redir, resp.GetParameters())
-		sendRedirect(redir.String(), rw)
-		return
-	default:
-		if f.ResponseModeHandler().ResponseModes().Has(rm) {
-			f.ResponseModeHandler().WriteAuthorizeResponse(rw, ar, resp)
-			return
-		}
 	}
+
+	h.WriteAuthorizeResponse(ctx, rw, ar, resp)
 }
 
 // https://tools.ietf.org/html/rfc6749#section-4.1.1
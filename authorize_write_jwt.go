@@ -0,0 +1,137 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite/token/jwt"
+)
+
+// GetJWTSecuredAuthorizeResponseModeLifespan returns JWTSecuredAuthorizeResponseModeLifespan if set. Defaults to ten
+// minutes.
+func (f *Fosite) GetJWTSecuredAuthorizeResponseModeLifespan() time.Duration {
+	if f.JWTSecuredAuthorizeResponseModeLifespan == 0 {
+		return time.Minute * 10
+	}
+	return f.JWTSecuredAuthorizeResponseModeLifespan
+}
+
+// IsJWTSecuredResponseMode returns true if the response mode is one of the JWT Secured Authorization Response Modes.
+func IsJWTSecuredResponseMode(rm ResponseModeType) bool {
+	switch rm {
+	case ResponseModeJWT, ResponseModeQueryJWT, ResponseModeFragmentJWT, ResponseModeFormPostJWT:
+		return true
+	}
+	return false
+}
+
+// jwtSecuredBaseResponseMode returns the response mode used to transport the JWT secured authorization response. The
+// "jwt" response mode uses the default response mode of the response type as defined in
+// https://openid.net/specs/oauth-v2-jarm.html#section-2.3.4
+func jwtSecuredBaseResponseMode(ar AuthorizeRequester, rm ResponseModeType) ResponseModeType {
+	switch rm {
+	case ResponseModeQueryJWT:
+		return ResponseModeQuery
+	case ResponseModeFragmentJWT:
+		return ResponseModeFragment
+	case ResponseModeFormPostJWT:
+		return ResponseModeFormPost
+	}
+
+	if ar.GetResponseTypes().ExactOne("code") {
+		return ResponseModeQuery
+	}
+	return ResponseModeFragment
+}
+
+// EncodeJWTSecuredAuthorizeResponseParameters wraps the authorization response parameters in a JWT signed with the
+// algorithm registered for the client, as defined in https://openid.net/specs/oauth-v2-jarm.html#section-2.1
+func (f *Fosite) EncodeJWTSecuredAuthorizeResponseParameters(ctx context.Context, ar AuthorizeRequester, parameters url.Values) (url.Values, error) {
+	alg := "RS256"
+	if client, ok := ar.GetClient().(JARMClient); ok {
+		alg = client.GetAuthorizationSignedResponseAlg()
+	}
+
+	signer, ok := f.JWTSecuredAuthorizeResponseModeSigners[alg]
+	if !ok {
+		return nil, errorsx.WithStack(ErrServerError.WithDebugf("No signer is configured for the JWT secured authorization response algorithm \"%s\".", alg))
+	}
+
+	claims := jwt.MapClaims{
+		"iss": f.JWTSecuredAuthorizeResponseModeIssuer,
+		"aud": ar.GetClient().GetID(),
+		"exp": time.Now().UTC().Add(f.GetJWTSecuredAuthorizeResponseModeLifespan()).Unix(),
+	}
+	for k := range parameters {
+		claims[k] = parameters.Get(k)
+	}
+
+	token, _, err := signer.Generate(ctx, claims, &jwt.Headers{})
+	if err != nil {
+		return nil, errorsx.WithStack(ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	return url.Values{"response": {token}}, nil
+}
+
+// jwtSecuredResponseModeHandler handles the JWT Secured Authorization Response Modes. The response parameters are
+// wrapped in a JWT, which is then transported using the handler of the underlying response mode.
+type jwtSecuredResponseModeHandler struct {
+	f *Fosite
+}
+
+func (h *jwtSecuredResponseModeHandler) ResponseModes() ResponseModeTypes {
+	return ResponseModeTypes{ResponseModeJWT, ResponseModeQueryJWT, ResponseModeFragmentJWT, ResponseModeFormPostJWT}
+}
+
+func (h *jwtSecuredResponseModeHandler) WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
+	h.write(ctx, rw, ar, resp, resp.GetParameters())
+}
+
+func (h *jwtSecuredResponseModeHandler) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, err error) {
+	// The endpoint URI MUST NOT include a fragment component.
+	ar.GetRedirectURI().Fragment = ""
+
+	h.write(ctx, rw, ar, NewAuthorizeResponse(), authorizeErrorParameters(ar, err))
+}
+
+func (h *jwtSecuredResponseModeHandler) write(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder, parameters url.Values) {
+	parameters, err := h.f.EncodeJWTSecuredAuthorizeResponseParameters(ctx, ar, parameters)
+	if err != nil {
+		h.f.writeAuthorizeErrorJSON(rw, err)
+		return
+	}
+
+	base := h.f.GetResponseModeHandler(jwtSecuredBaseResponseMode(ar, ar.GetResponseMode()))
+	if base == nil {
+		h.f.writeAuthorizeErrorJSON(rw, errorsx.WithStack(ErrServerError.WithDebug("No handler is registered for the response mode transporting the JWT secured authorization response.")))
+		return
+	}
+
+	base.WriteAuthorizeResponse(ctx, rw, ar, &parametersResponder{AuthorizeResponder: resp, parameters: parameters})
+}
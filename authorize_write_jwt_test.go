@@ -0,0 +1,140 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	josejwt "gopkg.in/square/go-jose.v2/jwt"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/token/jwt"
+)
+
+func TestWriteJWTSecuredAuthorizeResponse(t *testing.T) {
+	key := internal.MustRSAKey()
+	f := &Fosite{
+		JWTSecuredAuthorizeResponseModeSigners: map[string]jwt.JWTStrategy{"RS256": &jwt.RS256JWTStrategy{PrivateKey: key}},
+		JWTSecuredAuthorizeResponseModeIssuer:  "https://issuer.example.com",
+	}
+
+	newRequest := func(responseMode ResponseModeType, responseTypes Arguments, alg string) *AuthorizeRequest {
+		ar := NewAuthorizeRequest()
+		ar.RedirectURI, _ = url.Parse("https://client.example.com/cb?foo=bar")
+		ar.ResponseMode = responseMode
+		ar.ResponseTypes = responseTypes
+		ar.State = "some-state"
+		ar.Client = &DefaultResponseModeClient{
+			DefaultClient:                  &DefaultClient{ID: "foo", RedirectURIs: []string{"https://client.example.com/cb?foo=bar"}},
+			AuthorizationSignedResponseAlg: alg,
+		}
+		return ar
+	}
+
+	formPostResponse := regexp.MustCompile(`name="response" value="([^"]+)"`)
+	decode := func(t *testing.T, rw *httptest.ResponseRecorder, responseMode ResponseModeType) map[string]interface{} {
+		var token string
+		switch responseMode {
+		case ResponseModeFormPost:
+			match := formPostResponse.FindStringSubmatch(rw.Body.String())
+			require.Len(t, match, 2, rw.Body.String())
+			token = match[1]
+		case ResponseModeFragment:
+			location, err := url.Parse(rw.Header().Get("Location"))
+			require.NoError(t, err)
+			fragment, err := url.ParseQuery(location.Fragment)
+			require.NoError(t, err)
+			token = fragment.Get("response")
+		default:
+			location, err := url.Parse(rw.Header().Get("Location"))
+			require.NoError(t, err)
+			assert.Equal(t, "bar", location.Query().Get("foo"))
+			token = location.Query().Get("response")
+		}
+
+		parsed, err := josejwt.ParseSigned(token)
+		require.NoError(t, err)
+		claims := map[string]interface{}{}
+		require.NoError(t, parsed.Claims(&key.PublicKey, &claims))
+		assert.Equal(t, "https://issuer.example.com", claims["iss"])
+		assert.Equal(t, "foo", claims["aud"])
+		assert.NotEmpty(t, claims["exp"])
+		return claims
+	}
+
+	for k, c := range []struct {
+		responseMode  ResponseModeType
+		responseTypes Arguments
+		expectMode    ResponseModeType
+	}{
+		{responseMode: ResponseModeQueryJWT, responseTypes: Arguments{"code"}, expectMode: ResponseModeQuery},
+		{responseMode: ResponseModeFragmentJWT, responseTypes: Arguments{"code"}, expectMode: ResponseModeFragment},
+		{responseMode: ResponseModeFormPostJWT, responseTypes: Arguments{"code"}, expectMode: ResponseModeFormPost},
+		{responseMode: ResponseModeJWT, responseTypes: Arguments{"code"}, expectMode: ResponseModeQuery},
+		{responseMode: ResponseModeJWT, responseTypes: Arguments{"token"}, expectMode: ResponseModeFragment},
+	} {
+		t.Run(fmt.Sprintf("case=%d/response_mode=%s", k, c.responseMode), func(t *testing.T) {
+			ar := newRequest(c.responseMode, c.responseTypes, "")
+
+			resp := NewAuthorizeResponse()
+			resp.AddParameter("code", "some-code")
+			resp.AddParameter("state", "some-state")
+
+			rw := httptest.NewRecorder()
+			f.WriteAuthorizeResponse(context.Background(), rw, ar, resp)
+			claims := decode(t, rw, c.expectMode)
+			assert.Equal(t, "some-code", claims["code"])
+			assert.Equal(t, "some-state", claims["state"])
+
+			rw = httptest.NewRecorder()
+			f.WriteAuthorizeError(context.Background(), rw, newRequest(c.responseMode, c.responseTypes, ""), errors.WithStack(ErrInvalidRequest))
+			claims = decode(t, rw, c.expectMode)
+			assert.Equal(t, "invalid_request", claims["error"])
+			assert.Equal(t, "some-state", claims["state"])
+		})
+	}
+
+	t.Run("case=fails without signer for the client algorithm", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		f.WriteAuthorizeResponse(context.Background(), rw, newRequest(ResponseModeQueryJWT, Arguments{"code"}, "ES256"), NewAuthorizeResponse())
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		assert.Empty(t, rw.Header().Get("Location"))
+	})
+
+	t.Run("case=rejects JWT response modes without signers", func(t *testing.T) {
+		r := &http.Request{Form: url.Values{"response_mode": {"query.jwt"}}}
+		ar := NewAuthorizeRequest()
+		assert.EqualError(t, (&Fosite{}).ParseResponseMode(r, ar), ErrUnsupportedResponseMode.Error())
+		require.NoError(t, f.ParseResponseMode(r, ar))
+		assert.Equal(t, ResponseModeQueryJWT, ar.GetResponseMode())
+	})
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/ory/x/errorsx"
+)
+
+// NewBackchannelAuthenticationRequest parses and validates a backchannel authentication request as defined in
+// https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#auth_request. The client
+// authenticates in the same manner as when making requests to the token endpoint.
+//
+// The request must contain exactly one of the "login_hint", "login_hint_token" and "id_token_hint" parameters.
+// Identifying the end-user based on the hint is left to the caller.
+func (f *Fosite) NewBackchannelAuthenticationRequest(ctx context.Context, r *http.Request) (BackchannelAuthenticationRequester, error) {
+	request := NewBackchannelAuthenticationRequest()
+
+	ctx = context.WithValue(ctx, RequestContextKey, r)
+	ctx = context.WithValue(ctx, BackchannelAuthenticationRequestContextKey, request)
+
+	if r.Method != "POST" {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHintf("HTTP method is '%s', expected 'POST'.", r.Method))
+	} else if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithWrap(err).WithDebug(err.Error()))
+	} else if len(r.PostForm) == 0 {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("The POST body can not be empty."))
+	}
+
+	request.Form = r.PostForm
+
+	ctx, err := f.extractRequestContext(ctx, r, &request.Request)
+	if err != nil {
+		return request, err
+	}
+
+	client, err := f.AuthenticateClient(ctx, r, r.PostForm)
+	if err != nil {
+		return request, err
+	}
+	request.Client = client
+
+	scope := f.parseScope(r.PostForm.Get("scope"))
+	for _, permission := range scope {
+		if !f.ScopeStrategy(client.GetScopes(), permission) {
+			return request, errorsx.WithStack(ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope '%s'.", permission))
+		}
+	}
+	request.SetRequestedScopes(scope)
+
+	audience := GetAudiences(r.PostForm)
+	if err := f.AudienceMatchingStrategy(client.GetAudience(), audience); err != nil {
+		return request, err
+	}
+	request.SetRequestedAudience(audience)
+
+	var hints int
+	for _, hint := range []string{"login_hint", "login_hint_token", "id_token_hint"} {
+		if r.PostForm.Get(hint) != "" {
+			hints++
+		}
+	}
+	if hints != 1 {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("Exactly one of the parameters 'login_hint', 'login_hint_token' and 'id_token_hint' must be set."))
+	}
+
+	if requestedExpiry := r.PostForm.Get("requested_expiry"); requestedExpiry != "" {
+		if expiry, err := strconv.ParseInt(requestedExpiry, 10, 64); err != nil || expiry <= 0 {
+			return request, errorsx.WithStack(ErrInvalidRequest.WithHint("Parameter 'requested_expiry' must be a positive integer."))
+		}
+	}
+
+	return request, nil
+}
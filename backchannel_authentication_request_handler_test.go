@@ -0,0 +1,120 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+)
+
+func TestNewBackchannelAuthenticationRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := internal.NewMockStorage(ctrl)
+	defer ctrl.Finish()
+
+	client := &DefaultClient{ID: "foo", Public: true, Scopes: []string{"openid", "email"}}
+	fosite := &Fosite{Store: store, ScopeStrategy: ExactScopeStrategy, AudienceMatchingStrategy: DefaultAudienceMatchingStrategy}
+	for k, c := range []struct {
+		method    string
+		form      url.Values
+		mock      func()
+		expectErr error
+		expect    Arguments
+	}{
+		{
+			method:    "GET",
+			mock:      func() {},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			method: "POST",
+			form:   url.Values{"client_id": {"foo"}, "scope": {"openid profile"}, "login_hint": {"peter"}},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), gomock.Eq("foo")).Return(client, nil)
+			},
+			expectErr: ErrInvalidScope,
+		},
+		{
+			method: "POST",
+			form:   url.Values{"client_id": {"foo"}, "scope": {"openid"}},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), gomock.Eq("foo")).Return(client, nil)
+			},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			method: "POST",
+			form:   url.Values{"client_id": {"foo"}, "scope": {"openid"}, "login_hint": {"peter"}, "id_token_hint": {"foo.bar.baz"}},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), gomock.Eq("foo")).Return(client, nil)
+			},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			method: "POST",
+			form:   url.Values{"client_id": {"foo"}, "scope": {"openid"}, "login_hint": {"peter"}, "requested_expiry": {"-1"}},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), gomock.Eq("foo")).Return(client, nil)
+			},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			method: "POST",
+			form:   url.Values{"client_id": {"foo"}, "scope": {"openid email"}, "login_hint": {"peter"}, "requested_expiry": {"120"}},
+			mock: func() {
+				store.EXPECT().GetClient(gomock.Any(), gomock.Eq("foo")).Return(client, nil)
+			},
+			expect: Arguments{"openid", "email"},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			r := &http.Request{
+				Header:   http.Header{},
+				PostForm: c.form,
+				Form:     c.form,
+				Method:   c.method,
+			}
+			c.mock()
+
+			br, err := fosite.NewBackchannelAuthenticationRequest(context.Background(), r)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, client, br.GetClient())
+			assert.Equal(t, c.expect, br.GetRequestedScopes())
+			assert.Equal(t, "peter", br.GetRequestForm().Get("login_hint"))
+		})
+	}
+}
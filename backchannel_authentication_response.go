@@ -0,0 +1,70 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import "time"
+
+// BackchannelAuthenticationResponse is an implementation of BackchannelAuthenticationResponder
+type BackchannelAuthenticationResponse struct {
+	AuthReqID string `json:"auth_req_id"`
+	ExpiresIn int64  `json:"expires_in"`
+	Interval  int64  `json:"interval,omitempty"`
+}
+
+func NewBackchannelAuthenticationResponse() *BackchannelAuthenticationResponse {
+	return &BackchannelAuthenticationResponse{}
+}
+
+func (b *BackchannelAuthenticationResponse) GetAuthReqID() string {
+	return b.AuthReqID
+}
+
+func (b *BackchannelAuthenticationResponse) SetAuthReqID(id string) {
+	b.AuthReqID = id
+}
+
+func (b *BackchannelAuthenticationResponse) GetExpiresIn() int64 {
+	return b.ExpiresIn
+}
+
+func (b *BackchannelAuthenticationResponse) SetExpiresIn(expiresIn time.Duration) {
+	b.ExpiresIn = int64(expiresIn / time.Second)
+}
+
+func (b *BackchannelAuthenticationResponse) GetInterval() int64 {
+	return b.Interval
+}
+
+func (b *BackchannelAuthenticationResponse) SetInterval(interval time.Duration) {
+	b.Interval = int64(interval / time.Second)
+}
+
+func (b *BackchannelAuthenticationResponse) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"auth_req_id": b.AuthReqID,
+		"expires_in":  b.ExpiresIn,
+	}
+	if b.Interval > 0 {
+		m["interval"] = b.Interval
+	}
+	return m
+}
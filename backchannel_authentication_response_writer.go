@@ -0,0 +1,50 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+
+	"github.com/ory/x/errorsx"
+)
+
+func (f *Fosite) NewBackchannelAuthenticationResponse(ctx context.Context, requester BackchannelAuthenticationRequester, session Session) (BackchannelAuthenticationResponder, error) {
+	var resp = NewBackchannelAuthenticationResponse()
+
+	ctx = context.WithValue(ctx, BackchannelAuthenticationRequestContextKey, requester)
+	ctx = context.WithValue(ctx, BackchannelAuthenticationResponseContextKey, resp)
+
+	requester.SetSession(session)
+	for _, h := range f.BackchannelAuthenticationEndpointHandlers {
+		if err := f.withStorageTimeout(ctx, func(ctx context.Context) error {
+			return h.HandleBackchannelAuthenticationEndpointRequest(ctx, requester, resp)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.GetAuthReqID() == "" {
+		return nil, errorsx.WithStack(ErrServerError.WithHint("An internal server occurred while trying to complete the request.").WithDebug("Backchannel authentication request ID not set by BackchannelAuthenticationEndpointHandlers."))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func (f *Fosite) WriteBackchannelAuthenticationResponse(rw http.ResponseWriter, _ BackchannelAuthenticationRequester, responder BackchannelAuthenticationResponder) {
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+
+	js, err := json.Marshal(responder.ToMap())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
+
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(js)
+}
+
+func (f *Fosite) WriteBackchannelAuthenticationError(rw http.ResponseWriter, _ BackchannelAuthenticationRequester, err error) {
+	f.writeJsonError(rw, err)
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultBearerTokenType is the token_type of bearer access tokens unless BearerTokenType is set.
+const DefaultBearerTokenType = "Bearer"
+
+// bearerTokenType returns the token_type of bearer access tokens in token and authorize responses.
+func (f *Fosite) bearerTokenType() string {
+	if strings.EqualFold(f.BearerTokenType, BearerAccessToken) {
+		return f.BearerTokenType
+	}
+	return DefaultBearerTokenType
+}
+
+// WriteBearerTokenError writes the error of validating the bearer access token a protected resource received, for
+// example the error returned by IntrospectToken, together with the WWW-Authenticate challenge defined in
+// https://tools.ietf.org/html/rfc6750#section-3
+func (f *Fosite) WriteBearerTokenError(rw http.ResponseWriter, err error) {
+	rw.Header().Set("WWW-Authenticate", f.bearerChallenge(ErrorToRFC6749Error(err)))
+	f.writeJsonError(rw, err)
+}
+
+// bearerChallenge returns the WWW-Authenticate challenge for the error. The realm is only included if BearerRealm is
+// set.
+func (f *Fosite) bearerChallenge(rfcerr *RFC6749Error) string {
+	var params []string
+	if f.BearerRealm != "" {
+		params = append(params, fmt.Sprintf(`realm="%s"`, challengeParamValue(f.BearerRealm)))
+	}
+	params = append(params, fmt.Sprintf(`error="%s"`, challengeParamValue(rfcerr.ErrorField)))
+	if description := rfcerr.WithExposeDebug(f.SendDebugMessagesToClients).GetDescription(); description != "" {
+		params = append(params, fmt.Sprintf(`error_description="%s"`, challengeParamValue(description)))
+	}
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// challengeParamValue removes the characters which are not allowed in quoted challenge parameters.
+func challengeParamValue(value string) string {
+	return strings.NewReplacer(`"`, "'", `\`, "", "\r", "", "\n", " ").Replace(value)
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+)
+
+func TestBearerTokenType(t *testing.T) {
+	for _, c := range []struct {
+		configured string
+		issued     string
+		expect     string
+	}{
+		{configured: "", issued: "bearer", expect: "Bearer"},
+		{configured: "bearer", issued: "bearer", expect: "bearer"},
+		{configured: "Bearer", issued: "bearer", expect: "Bearer"},
+		{configured: "invalid", issued: "bearer", expect: "Bearer"},
+		{configured: "bearer", issued: "DPoP", expect: "DPoP"},
+	} {
+		t.Run("configured="+c.configured+"/issued="+c.issued, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			handler := internal.NewMockTokenEndpointHandler(ctrl)
+			defer ctrl.Finish()
+
+			handler.EXPECT().PopulateTokenEndpointResponse(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ AccessRequester, resp AccessResponder) {
+				resp.SetAccessToken("foo")
+				resp.SetTokenType(c.issued)
+			}).Return(nil)
+
+			f := &Fosite{TokenEndpointHandlers: TokenEndpointHandlers{handler}, BearerTokenType: c.configured}
+			resp, err := f.NewAccessResponse(context.Background(), NewAccessRequest(nil))
+			require.NoError(t, err)
+			assert.Equal(t, c.expect, resp.GetTokenType())
+		})
+	}
+}
+
+func TestWriteBearerTokenError(t *testing.T) {
+	for _, c := range []struct {
+		d            string
+		f            *Fosite
+		err          error
+		expectHeader string
+		expectCode   int
+	}{
+		{
+			d:            "should include the realm",
+			f:            &Fosite{BearerRealm: "example"},
+			err:          ErrTokenExpired,
+			expectHeader: `Bearer realm="example", error="invalid_token", error_description="Token expired. The token expired."`,
+			expectCode:   401,
+		},
+		{
+			d:            "should omit the realm by default",
+			f:            &Fosite{},
+			err:          ErrInvalidTokenFormat.WithHint(`The token is not a "JWT".`),
+			expectHeader: `Bearer error="invalid_token", error_description="Invalid token format. The token is not a 'JWT'."`,
+			expectCode:   400,
+		},
+		{
+			d:            "should use the error of insufficient scopes",
+			f:            &Fosite{BearerRealm: "example"},
+			err:          ErrScopeNotGranted,
+			expectHeader: `Bearer realm="example", error="scope_not_granted", error_description="The token was not granted the requested scope. The resource owner did not grant the requested scope."`,
+			expectCode:   403,
+		},
+	} {
+		t.Run(c.d, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			c.f.WriteBearerTokenError(rw, c.err)
+			assert.Equal(t, c.expectHeader, rw.Header().Get("WWW-Authenticate"))
+			assert.Equal(t, c.expectCode, rw.Code)
+		})
+	}
+}
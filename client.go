@@ -21,7 +21,11 @@
 
 package fosite
 
-import jose "gopkg.in/square/go-jose.v2"
+import (
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
 
 // Client represents a client or an app.
 type Client interface {
@@ -52,13 +56,26 @@ type Client interface {
 	GetAudience() Arguments
 }
 
-// ClientWithSecretRotation extends Client interface by a method providing a slice of rotated secrets.
+// ClientWithSecretRotation extends Client interface by a method providing a slice of rotated secrets. Client
+// authentication accepts the current secret as well as any of the rotated ones, which allows to roll out a new
+// secret without downtime. Rotated secrets should be removed once all deployments of the client use the new secret.
 type ClientWithSecretRotation interface {
 	Client
 	// GetRotatedHashes returns a slice of hashed secrets used for secrets rotation.
 	GetRotatedHashes() [][]byte
 }
 
+// ClientWithPlaintextSecret represents a client which can provide its secret in plain text. This is required by the
+// client_secret_jwt client authentication method, because client assertions are signed with the client secret.
+type ClientWithPlaintextSecret interface {
+	// GetPlaintextSecret returns the client secret in plain text or nil if it is not available.
+	GetPlaintextSecret() []byte
+
+	// GetRotatedPlaintextSecrets returns previous client secrets in plain text, which are still accepted during a
+	// secret rotation.
+	GetRotatedPlaintextSecrets() [][]byte
+}
+
 // OpenIDConnectClient represents a client capable of performing OpenID Connect requests.
 type OpenIDConnectClient interface {
 	// GetRequestURIs is an array of request_uri values that are pre-registered by the RP for use at the OP. Servers MAY
@@ -87,12 +104,209 @@ type OpenIDConnectClient interface {
 	GetTokenEndpointAuthSigningAlgorithm() string
 }
 
+// IDTokenSigningClient represents a client which registered the algorithm its ID tokens must be signed with as
+// defined in https://openid.net/specs/openid-connect-registration-1_0.html#ClientMetadata
+type IDTokenSigningClient interface {
+	// GetIDTokenSignedResponseAlg returns the JWS [JWS] alg algorithm [JWA] required for signing the ID Token issued
+	// to this client. If empty, the default algorithm of the OpenID Connect provider is used.
+	GetIDTokenSignedResponseAlg() string
+}
+
+// UserinfoResponseClient represents a client which registered how its userinfo responses must be signed and encrypted
+// as defined in https://openid.net/specs/openid-connect-registration-1_0.html#ClientMetadata
+type UserinfoResponseClient interface {
+	// GetUserinfoSignedResponseAlg returns the JWS [JWS] alg algorithm [JWA] required for signing userinfo responses.
+	// If empty, userinfo responses are not signed.
+	GetUserinfoSignedResponseAlg() string
+
+	// GetUserinfoEncryptedResponseAlg returns the JWE [JWE] alg algorithm [JWA] required for encrypting userinfo
+	// responses. If empty, userinfo responses are not encrypted.
+	GetUserinfoEncryptedResponseAlg() string
+
+	// GetUserinfoEncryptedResponseEnc returns the JWE [JWE] enc algorithm [JWA] required for encrypting userinfo
+	// responses. If empty, A128CBC-HS256 is used.
+	GetUserinfoEncryptedResponseEnc() string
+}
+
+// DefaultMaxAgeClient represents a client which registered a default maximum authentication age as defined in
+// https://openid.net/specs/openid-connect-registration-1_0.html#ClientMetadata
+type DefaultMaxAgeClient interface {
+	// GetDefaultMaxAge returns the number of seconds after which the end-user must be actively re-authenticated if the
+	// authorization request does not contain the max_age parameter. Zero means no default is set.
+	GetDefaultMaxAge() int64
+}
+
+// BackChannelLogoutClient represents a client which registered for OpenID Connect Back-Channel Logout as defined in
+// https://openid.net/specs/openid-connect-backchannel-1_0.html#BCRegistration
+type BackChannelLogoutClient interface {
+	// GetBackChannelLogoutURI returns the URI the logout tokens are sent to. If empty, the client does not support
+	// back-channel logout.
+	GetBackChannelLogoutURI() string
+
+	// GetBackChannelLogoutSessionRequired returns true if the client requires the sid claim in logout tokens.
+	GetBackChannelLogoutSessionRequired() bool
+}
+
+// FrontChannelLogoutClient represents a client which registered for OpenID Connect Front-Channel Logout as defined in
+// https://openid.net/specs/openid-connect-frontchannel-1_0.html#RPLogout
+type FrontChannelLogoutClient interface {
+	// GetFrontChannelLogoutURI returns the URI which is rendered in an iframe to log out the end-user at the client.
+	// If empty, the client does not support front-channel logout.
+	GetFrontChannelLogoutURI() string
+
+	// GetFrontChannelLogoutSessionRequired returns true if the client requires the iss and sid query parameters.
+	GetFrontChannelLogoutSessionRequired() bool
+}
+
+// RPInitiatedLogoutClient represents a client which registered for OpenID Connect RP-Initiated Logout as defined in
+// https://openid.net/specs/openid-connect-rpinitiated-1_0.html#ClientMetadata
+type RPInitiatedLogoutClient interface {
+	// GetPostLogoutRedirectURIs returns the URIs the end-user may be redirected to after logging out.
+	GetPostLogoutRedirectURIs() []string
+}
+
+// TLSClient represents a client capable of authenticating using mutual TLS as defined in
+// https://tools.ietf.org/html/rfc8705#section-2
+type TLSClient interface {
+	Client
+
+	// GetTokenEndpointAuthMethod returns the requested client authentication method for the token endpoint. Mutual
+	// TLS client authentication is used if it is either tls_client_auth or self_signed_tls_client_auth.
+	GetTokenEndpointAuthMethod() string
+
+	// GetTLSClientAuthSubjectDN returns the expected subject distinguished name of the certificate the client
+	// authenticates with when using tls_client_auth.
+	GetTLSClientAuthSubjectDN() string
+
+	// GetTLSClientAuthSANDNS returns the expected dNSName SAN entries of the certificate the client authenticates
+	// with when using tls_client_auth. One of them must be present in the certificate.
+	GetTLSClientAuthSANDNS() []string
+
+	// GetJSONWebKeys returns the JSON Web Key Set containing the self-signed certificates or public keys the client
+	// authenticates with when using self_signed_tls_client_auth.
+	GetJSONWebKeys() *jose.JSONWebKeySet
+}
+
+// DPoPClient represents a client which may be required to use DPoP as defined in
+// https://datatracker.ietf.org/doc/html/rfc9449
+type DPoPClient interface {
+	// GetDPoPBoundAccessTokens returns true if the client must always use DPoP for its token requests.
+	GetDPoPBoundAccessTokens() bool
+}
+
+// PKCEClient represents a client which may be required to use PKCE as defined in https://tools.ietf.org/html/rfc7636
+type PKCEClient interface {
+	// GetRequirePKCE returns true if the client must use PKCE with the authorize code flow, even if it is a
+	// confidential client.
+	GetRequirePKCE() bool
+
+	// GetPKCEChallengeMethods returns the code challenge methods the client may use. If empty, the client may use
+	// all methods allowed by the authorization server.
+	GetPKCEChallengeMethods() []string
+}
+
+// PushedAuthorizeClient represents a client which may be required to use pushed authorization requests as defined in
+// https://tools.ietf.org/html/rfc9126#section-6
+type PushedAuthorizeClient interface {
+	// GetRequirePushedAuthorizationRequests returns true if the client must pass its authorization requests through
+	// the pushed authorization request endpoint.
+	GetRequirePushedAuthorizationRequests() bool
+}
+
+// StateClient represents a client which may be required to protect its authorization requests against CSRF with the
+// state parameter, see https://tools.ietf.org/html/rfc6819#section-4.4.1.8
+type StateClient interface {
+	// GetRequireState returns true if the authorization requests of the client must carry a state of at least
+	// MinStateLength characters.
+	GetRequireState() bool
+}
+
+// NativeAppClient represents a native app client which may receive authorization responses on a loopback redirect URI
+// as defined in https://tools.ietf.org/html/rfc8252#section-7.3
+type NativeAppClient interface {
+	// GetAllowLoopbackRedirectWithDynamicPort returns true if the port of the requested redirect URI is ignored when
+	// it is matched against a registered loopback redirect URI.
+	GetAllowLoopbackRedirectWithDynamicPort() bool
+}
+
+// OfflineAccessClient represents a client which may be restricted from obtaining refresh tokens through the
+// offline_access scope as defined in https://openid.net/specs/openid-connect-core-1_0.html#OfflineAccess
+type OfflineAccessClient interface {
+	// GetAllowOfflineAccess returns true if the client may obtain refresh tokens when the authorization server
+	// enforces offline access.
+	GetAllowOfflineAccess() bool
+}
+
+const (
+	// AccessTokenFormatOpaque identifies opaque access tokens which are looked up in the storage.
+	AccessTokenFormatOpaque = "opaque"
+	// AccessTokenFormatJWT identifies self-contained JWT access tokens.
+	AccessTokenFormatJWT = "jwt"
+)
+
+// AccessTokenFormatClient represents a client which may choose the format of the access tokens issued to it.
+type AccessTokenFormatClient interface {
+	// GetAccessTokenFormat returns either AccessTokenFormatOpaque or AccessTokenFormatJWT. If empty, the default
+	// format of the provider is used.
+	GetAccessTokenFormat() string
+}
+
 // ResponseModeClient represents a client capable of handling response_mode
 type ResponseModeClient interface {
 	// GetResponseMode returns the response modes that client is allowed to send
 	GetResponseModes() []ResponseModeType
 }
 
+// JARMClient represents a client capable of receiving JWT secured authorization responses as defined in
+// https://openid.net/specs/oauth-v2-jarm.html
+type JARMClient interface {
+	// GetAuthorizationSignedResponseAlg returns the JWS [JWS] alg algorithm [JWA] that MUST be used for signing
+	// authorization responses. Defaults to RS256.
+	GetAuthorizationSignedResponseAlg() string
+}
+
+// ClientWithCustomTokenLifespans represents a client with token lifespans that take precedence over the lifespans
+// configured for the authorization server.
+type ClientWithCustomTokenLifespans interface {
+	// GetTokenLifespans returns the token lifespans of the client, or nil if the defaults apply.
+	GetTokenLifespans() *ClientLifespanConfig
+}
+
+// TokenLifespans maps token types, for example AccessToken, to their lifespan.
+type TokenLifespans map[TokenType]time.Duration
+
+// ClientLifespanConfig holds the token lifespans of a client. Token types without a lifespan fall back to the
+// lifespans configured for the authorization server.
+type ClientLifespanConfig struct {
+	// Default holds the lifespans of tokens issued by any grant type.
+	Default TokenLifespans `json:"default,omitempty"`
+
+	// GrantTypes holds lifespans of tokens issued by a specific grant type, for example "client_credentials", and
+	// takes precedence over Default. Tokens issued at the authorization endpoint belong to the "implicit" grant type,
+	// except for authorization codes which belong to the "authorization_code" grant type.
+	GrantTypes map[string]TokenLifespans `json:"grant_types,omitempty"`
+}
+
+// GetEffectiveLifespan returns the lifespan of a token of the given type issued to the client by the given grant type.
+// The lifespan the client configured for the grant type takes precedence over the lifespan the client configured for
+// all grant types. If the client configured neither, fallback is returned.
+func GetEffectiveLifespan(c Client, grantType string, tokenType TokenType, fallback time.Duration) time.Duration {
+	clc, ok := c.(ClientWithCustomTokenLifespans)
+	if !ok {
+		return fallback
+	}
+
+	config := clc.GetTokenLifespans()
+	if config == nil {
+		return fallback
+	} else if lifespan, ok := config.GrantTypes[grantType][tokenType]; ok {
+		return lifespan
+	} else if lifespan, ok := config.Default[tokenType]; ok {
+		return lifespan
+	}
+	return fallback
+}
+
 // DefaultClient is a simple default implementation of the Client interface.
 type DefaultClient struct {
 	ID             string   `json:"id"`
@@ -104,21 +318,65 @@ type DefaultClient struct {
 	Scopes         []string `json:"scopes"`
 	Audience       []string `json:"audience"`
 	Public         bool     `json:"public"`
+	// DPoPBoundAccessTokens requires the client to send a DPoP proof with all token requests.
+	DPoPBoundAccessTokens bool `json:"dpop_bound_access_tokens,omitempty"`
+	// AuthorizationDetailsTypes are the authorization details types the client may request.
+	AuthorizationDetailsTypes []string `json:"authorization_details_types,omitempty"`
+	// AccessTokenFormat is the format of the access tokens issued to the client, either "opaque" or "jwt".
+	AccessTokenFormat string `json:"access_token_format,omitempty"`
+	// RequirePKCE requires the client to use PKCE with the authorize code flow, even if it is a confidential client.
+	RequirePKCE bool `json:"require_pkce,omitempty"`
+	// PKCEChallengeMethods are the code challenge methods the client may use.
+	PKCEChallengeMethods []string `json:"pkce_challenge_methods,omitempty"`
+	// TokenLifespans overrides the lifespans of the tokens issued to the client.
+	TokenLifespans *ClientLifespanConfig `json:"token_lifespans,omitempty"`
+	// AllowLoopbackRedirectWithDynamicPort allows the client to use any port with its loopback redirect URIs.
+	AllowLoopbackRedirectWithDynamicPort bool `json:"allow_loopback_redirect_with_dynamic_port,omitempty"`
+	// AllowOfflineAccess allows the client to obtain refresh tokens when the authorization server enforces offline
+	// access.
+	AllowOfflineAccess bool `json:"allow_offline_access,omitempty"`
 }
 
 type DefaultOpenIDConnectClient struct {
 	*DefaultClient
-	JSONWebKeysURI                    string              `json:"jwks_uri"`
-	JSONWebKeys                       *jose.JSONWebKeySet `json:"jwks"`
-	TokenEndpointAuthMethod           string              `json:"token_endpoint_auth_method"`
-	RequestURIs                       []string            `json:"request_uris"`
-	RequestObjectSigningAlgorithm     string              `json:"request_object_signing_alg"`
-	TokenEndpointAuthSigningAlgorithm string              `json:"token_endpoint_auth_signing_alg"`
+	JSONWebKeysURI                     string              `json:"jwks_uri"`
+	JSONWebKeys                        *jose.JSONWebKeySet `json:"jwks"`
+	TokenEndpointAuthMethod            string              `json:"token_endpoint_auth_method"`
+	RequestURIs                        []string            `json:"request_uris"`
+	RequestObjectSigningAlgorithm      string              `json:"request_object_signing_alg"`
+	TokenEndpointAuthSigningAlgorithm  string              `json:"token_endpoint_auth_signing_alg"`
+	IDTokenSignedResponseAlg           string              `json:"id_token_signed_response_alg,omitempty"`
+	UserinfoSignedResponseAlg          string              `json:"userinfo_signed_response_alg,omitempty"`
+	UserinfoEncryptedResponseAlg       string              `json:"userinfo_encrypted_response_alg,omitempty"`
+	UserinfoEncryptedResponseEnc       string              `json:"userinfo_encrypted_response_enc,omitempty"`
+	DefaultMaxAge                      int64               `json:"default_max_age,omitempty"`
+	BackChannelLogoutURI               string              `json:"backchannel_logout_uri,omitempty"`
+	BackChannelLogoutSessionRequired   bool                `json:"backchannel_logout_session_required,omitempty"`
+	FrontChannelLogoutURI              string              `json:"frontchannel_logout_uri,omitempty"`
+	FrontChannelLogoutSessionRequired  bool                `json:"frontchannel_logout_session_required,omitempty"`
+	PostLogoutRedirectURIs             []string            `json:"post_logout_redirect_uris,omitempty"`
+	RequirePushedAuthorizationRequests bool                `json:"require_pushed_authorization_requests,omitempty"`
+	RequireState                       bool                `json:"require_state,omitempty"`
+
+	// PlaintextSecret is the client secret in plain text, which verifies client assertions of the client_secret_jwt
+	// client authentication method. It is never serialized.
+	PlaintextSecret []byte `json:"-"`
+
+	// RotatedPlaintextSecrets are previous plaintext secrets accepted for client_secret_jwt during a secret
+	// rotation. They are never serialized.
+	RotatedPlaintextSecrets [][]byte `json:"-"`
+}
+
+type DefaultTLSClient struct {
+	*DefaultOpenIDConnectClient
+	TLSClientAuthSubjectDN string   `json:"tls_client_auth_subject_dn"`
+	TLSClientAuthSANDNS    []string `json:"tls_client_auth_san_dns"`
 }
 
 type DefaultResponseModeClient struct {
 	*DefaultClient
-	ResponseModes []ResponseModeType `json:"response_modes"`
+	ResponseModes                  []ResponseModeType `json:"response_modes"`
+	AuthorizationSignedResponseAlg string             `json:"authorization_signed_response_alg"`
 }
 
 func (c *DefaultClient) GetID() string {
@@ -149,6 +407,38 @@ func (c *DefaultClient) GetScopes() Arguments {
 	return c.Scopes
 }
 
+func (c *DefaultClient) GetDPoPBoundAccessTokens() bool {
+	return c.DPoPBoundAccessTokens
+}
+
+func (c *DefaultClient) GetAuthorizationDetailsTypes() []string {
+	return c.AuthorizationDetailsTypes
+}
+
+func (c *DefaultClient) GetAccessTokenFormat() string {
+	return c.AccessTokenFormat
+}
+
+func (c *DefaultClient) GetTokenLifespans() *ClientLifespanConfig {
+	return c.TokenLifespans
+}
+
+func (c *DefaultClient) GetRequirePKCE() bool {
+	return c.RequirePKCE
+}
+
+func (c *DefaultClient) GetPKCEChallengeMethods() []string {
+	return c.PKCEChallengeMethods
+}
+
+func (c *DefaultClient) GetAllowLoopbackRedirectWithDynamicPort() bool {
+	return c.AllowLoopbackRedirectWithDynamicPort
+}
+
+func (c *DefaultClient) GetAllowOfflineAccess() bool {
+	return c.AllowOfflineAccess
+}
+
 func (c *DefaultClient) GetGrantTypes() Arguments {
 	// https://openid.net/specs/openid-connect-registration-1_0.html#ClientMetadata
 	//
@@ -183,12 +473,23 @@ func (c *DefaultOpenIDConnectClient) GetJSONWebKeys() *jose.JSONWebKeySet {
 
 func (c *DefaultOpenIDConnectClient) GetTokenEndpointAuthSigningAlgorithm() string {
 	if c.TokenEndpointAuthSigningAlgorithm == "" {
+		if c.TokenEndpointAuthMethod == "client_secret_jwt" {
+			return "HS256"
+		}
 		return "RS256"
 	} else {
 		return c.TokenEndpointAuthSigningAlgorithm
 	}
 }
 
+func (c *DefaultOpenIDConnectClient) GetPlaintextSecret() []byte {
+	return c.PlaintextSecret
+}
+
+func (c *DefaultOpenIDConnectClient) GetRotatedPlaintextSecrets() [][]byte {
+	return c.RotatedPlaintextSecrets
+}
+
 func (c *DefaultOpenIDConnectClient) GetRequestObjectSigningAlgorithm() string {
 	return c.RequestObjectSigningAlgorithm
 }
@@ -201,6 +502,69 @@ func (c *DefaultOpenIDConnectClient) GetRequestURIs() []string {
 	return c.RequestURIs
 }
 
+func (c *DefaultOpenIDConnectClient) GetIDTokenSignedResponseAlg() string {
+	return c.IDTokenSignedResponseAlg
+}
+
+func (c *DefaultOpenIDConnectClient) GetUserinfoSignedResponseAlg() string {
+	return c.UserinfoSignedResponseAlg
+}
+
+func (c *DefaultOpenIDConnectClient) GetUserinfoEncryptedResponseAlg() string {
+	return c.UserinfoEncryptedResponseAlg
+}
+
+func (c *DefaultOpenIDConnectClient) GetUserinfoEncryptedResponseEnc() string {
+	return c.UserinfoEncryptedResponseEnc
+}
+
+func (c *DefaultOpenIDConnectClient) GetDefaultMaxAge() int64 {
+	return c.DefaultMaxAge
+}
+
+func (c *DefaultOpenIDConnectClient) GetBackChannelLogoutURI() string {
+	return c.BackChannelLogoutURI
+}
+
+func (c *DefaultOpenIDConnectClient) GetBackChannelLogoutSessionRequired() bool {
+	return c.BackChannelLogoutSessionRequired
+}
+
+func (c *DefaultOpenIDConnectClient) GetFrontChannelLogoutURI() string {
+	return c.FrontChannelLogoutURI
+}
+
+func (c *DefaultOpenIDConnectClient) GetFrontChannelLogoutSessionRequired() bool {
+	return c.FrontChannelLogoutSessionRequired
+}
+
+func (c *DefaultOpenIDConnectClient) GetPostLogoutRedirectURIs() []string {
+	return c.PostLogoutRedirectURIs
+}
+
+func (c *DefaultOpenIDConnectClient) GetRequirePushedAuthorizationRequests() bool {
+	return c.RequirePushedAuthorizationRequests
+}
+
+func (c *DefaultOpenIDConnectClient) GetRequireState() bool {
+	return c.RequireState
+}
+
 func (c *DefaultResponseModeClient) GetResponseModes() []ResponseModeType {
 	return c.ResponseModes
 }
+
+func (c *DefaultResponseModeClient) GetAuthorizationSignedResponseAlg() string {
+	if c.AuthorizationSignedResponseAlg == "" {
+		return "RS256"
+	}
+	return c.AuthorizationSignedResponseAlg
+}
+
+func (c *DefaultTLSClient) GetTLSClientAuthSubjectDN() string {
+	return c.TLSClientAuthSubjectDN
+}
+
+func (c *DefaultTLSClient) GetTLSClientAuthSANDNS() []string {
+	return c.TLSClientAuthSANDNS
+}
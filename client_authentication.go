@@ -79,7 +79,7 @@ func (f *Fosite) AuthenticateClient(ctx context.Context, r *http.Request, form u
 }
 
 // DefaultClientAuthenticationStrategy provides the fosite's default client authentication strategy,
-// HTTP Basic Authentication and JWT Bearer
+// HTTP Basic Authentication and JWT Bearer (private_key_jwt and client_secret_jwt)
 func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *http.Request, form url.Values) (Client, error) {
 	if assertionType := form.Get("client_assertion_type"); assertionType == clientAssertionJWTBearerType {
 		assertion := form.Get("client_assertion")
@@ -89,8 +89,9 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 
 		var clientID string
 		var client Client
+		var rotatedSecrets [][]byte
 
-		token, err := jwt.ParseWithClaims(assertion, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
+		token, err := jwt.ParseWithClaimsAndClockSkew(assertion, jwt.MapClaims{}, f.ClockSkew, func(t *jwt.Token) (interface{}, error) {
 			var err error
 			clientID, _, err = clientCredentialsFromRequestBody(form, false)
 			if err != nil {
@@ -106,8 +107,10 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 				}
 			}
 
-			client, err = f.Store.GetClient(ctx, clientID)
-			if err != nil {
+			client, err = f.getClient(ctx, clientID)
+			if errors.Is(err, ErrTemporarilyUnavailable) {
+				return nil, err
+			} else if err != nil {
 				return nil, errorsx.WithStack(ErrInvalidClient.WithWrap(err).WithDebug(err.Error()))
 			}
 
@@ -116,8 +119,9 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 				return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("The server configuration does not support OpenID Connect specific authentication methods."))
 			}
 
-			switch oidcClient.GetTokenEndpointAuthMethod() {
-			case "private_key_jwt":
+			authMethod := oidcClient.GetTokenEndpointAuthMethod()
+			switch authMethod {
+			case "private_key_jwt", "client_secret_jwt":
 				break
 			case "none":
 				return nil, errorsx.WithStack(ErrInvalidClient.WithHint("This requested OAuth 2.0 client does not support client authentication, however 'client_assertion' was provided in the request."))
@@ -125,15 +129,36 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 				fallthrough
 			case "client_secret_basic":
 				return nil, errorsx.WithStack(ErrInvalidClient.WithHintf("This requested OAuth 2.0 client only supports client authentication method '%s', however 'client_assertion' was provided in the request.", oidcClient.GetTokenEndpointAuthMethod()))
-			case "client_secret_jwt":
-				fallthrough
 			default:
 				return nil, errorsx.WithStack(ErrInvalidClient.WithHintf("This requested OAuth 2.0 client only supports client authentication method '%s', however that method is not supported by this server.", oidcClient.GetTokenEndpointAuthMethod()))
 			}
 
+			if t.Method == jwt.SigningMethodNone {
+				return nil, errorsx.WithStack(ErrInvalidClient.WithHint("The 'client_assertion' must be signed, signing algorithm 'none' is not allowed."))
+			} else if !f.isJWTSigningAlgorithmAllowed(t) {
+				return nil, errorsx.WithStack(ErrInvalidClient.WithHintf("The 'client_assertion' uses signing algorithm '%s' which is not allowed by the authorization server.", t.Header["alg"]))
+			}
+
 			if oidcClient.GetTokenEndpointAuthSigningAlgorithm() != fmt.Sprintf("%s", t.Header["alg"]) {
 				return nil, errorsx.WithStack(ErrInvalidClient.WithHintf("The 'client_assertion' uses signing algorithm '%s' but the requested OAuth 2.0 Client enforces signing algorithm '%s'.", t.Header["alg"], oidcClient.GetTokenEndpointAuthSigningAlgorithm()))
 			}
+			switch t.Method {
+			case jose.HS256, jose.HS384, jose.HS512:
+				if authMethod != "client_secret_jwt" {
+					return nil, errorsx.WithStack(ErrInvalidClient.WithHintf("The 'client_assertion' is signed with the client secret but the requested OAuth 2.0 Client uses client authentication method '%s'.", authMethod))
+				}
+				secrets, err := findClientSecretKeys(client)
+				if err != nil {
+					return nil, err
+				}
+				rotatedSecrets = secrets[1:]
+				return secrets[0], nil
+			}
+
+			if authMethod == "client_secret_jwt" {
+				return nil, errorsx.WithStack(ErrInvalidClient.WithHintf("The requested OAuth 2.0 Client uses client authentication method 'client_secret_jwt' which requires a HMAC signed 'client_assertion' but signing algorithm '%s' was used.", t.Header["alg"]))
+			}
+
 			switch t.Method {
 			case jose.RS256, jose.RS384, jose.RS512:
 				return f.findClientPublicJWK(oidcClient, t, true)
@@ -141,12 +166,23 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 				return f.findClientPublicJWK(oidcClient, t, false)
 			case jose.PS256, jose.PS384, jose.PS512:
 				return f.findClientPublicJWK(oidcClient, t, true)
-			case jose.HS256, jose.HS384, jose.HS512:
-				return nil, errorsx.WithStack(ErrInvalidClient.WithHint("This authorization server does not support client authentication method 'client_secret_jwt'."))
 			default:
 				return nil, errorsx.WithStack(ErrInvalidClient.WithHintf("The 'client_assertion' request parameter uses unsupported signing algorithm '%s'.", t.Header["alg"]))
 			}
 		})
+
+		// Assertions signed with a rotated client secret fail to verify with the current one.
+		for _, secret := range rotatedSecrets {
+			var e *jwt.ValidationError
+			if !errors.As(err, &e) || e.Errors&jwt.ValidationErrorSignatureInvalid == 0 {
+				break
+			}
+			secret := secret
+			token, err = jwt.ParseWithClaimsAndClockSkew(assertion, jwt.MapClaims{}, f.ClockSkew, func(*jwt.Token) (interface{}, error) {
+				return secret, nil
+			})
+		}
+
 		if err != nil {
 			// Do not re-process already enhanced errors
 			var e *jwt.ValidationError
@@ -157,7 +193,7 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 				return nil, errorsx.WithStack(ErrInvalidClient.WithHint("Unable to verify the integrity of the 'client_assertion' value.").WithWrap(err).WithDebug(err.Error()))
 			}
 			return nil, err
-		} else if err := token.Claims.Valid(); err != nil {
+		} else if err := token.Claims.ValidWithClockSkew(f.ClockSkew); err != nil {
 			return nil, errorsx.WithStack(ErrInvalidClient.WithHint("Unable to verify the request object because its claims could not be validated, check if the expiry time is set correctly.").WithWrap(err).WithDebug(err.Error()))
 		}
 
@@ -171,8 +207,10 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 			return nil, errorsx.WithStack(ErrInvalidClient.WithHint("Claim 'sub' from 'client_assertion' must match the 'client_id' of the OAuth 2.0 Client."))
 		} else if jti, ok = claims["jti"].(string); !ok || len(jti) == 0 {
 			return nil, errorsx.WithStack(ErrInvalidClient.WithHint("Claim 'jti' from 'client_assertion' must be set but is not."))
-		} else if f.Store.ClientAssertionJWTValid(ctx, jti) != nil {
-			return nil, errorsx.WithStack(ErrJTIKnown.WithHint("Claim 'jti' from 'client_assertion' MUST only be used once."))
+		} else if err := f.Store.ClientAssertionJWTValid(ctx, jti); errors.Is(err, ErrJTIKnown) {
+			return nil, errorsx.WithStack(ErrInvalidClient.WithHint("Claim 'jti' from 'client_assertion' MUST only be used once.").WithWrap(err).WithDebug(err.Error()))
+		} else if err != nil {
+			return nil, errorsx.WithStack(ErrServerError.WithWrap(err).WithDebug(err.Error()))
 		}
 
 		// type conversion according to jwt.MapClaims.VerifyExpiresAt
@@ -192,8 +230,10 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 		if err != nil {
 			return nil, errorsx.WithStack(err)
 		}
-		if err := f.Store.SetClientAssertionJWT(ctx, jti, time.Unix(expiry, 0)); err != nil {
-			return nil, err
+		if err := f.Store.SetClientAssertionJWT(ctx, jti, time.Unix(expiry, 0).Add(f.ClockSkew)); errors.Is(err, ErrJTIKnown) {
+			return nil, errorsx.WithStack(ErrInvalidClient.WithHint("Claim 'jti' from 'client_assertion' MUST only be used once.").WithWrap(err).WithDebug(err.Error()))
+		} else if err != nil {
+			return nil, errorsx.WithStack(ErrServerError.WithWrap(err).WithDebug(err.Error()))
 		}
 
 		if auds, ok := claims["aud"].([]interface{}); !ok {
@@ -224,8 +264,10 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 		return nil, err
 	}
 
-	client, err := f.Store.GetClient(ctx, clientID)
-	if err != nil {
+	client, err := f.getClient(ctx, clientID)
+	if errors.Is(err, ErrTemporarilyUnavailable) {
+		return nil, err
+	} else if err != nil {
 		return nil, errorsx.WithStack(ErrInvalidClient.WithWrap(err).WithDebug(err.Error()))
 	}
 
@@ -251,24 +293,47 @@ func (f *Fosite) DefaultClientAuthenticationStrategy(ctx context.Context, r *htt
 	return client, nil
 }
 
+// checkClientSecret compares the secret with the hashed secret of the client and its rotated hashes. All hashes are
+// compared even if one of them matches, so that the time it takes does not reveal which one does. With BCrypt, every
+// hash costs a full bcrypt comparison, so the time grows with the number of rotated secrets of the client and does
+// reveal that number, but neither the length of the secrets nor how far a given secret matches them.
 func (f *Fosite) checkClientSecret(ctx context.Context, client Client, clientSecret []byte) error {
+	hashes := [][]byte{client.GetHashedSecret()}
+	if cc, ok := client.(ClientWithSecretRotation); ok {
+		hashes = append(hashes, cc.GetRotatedHashes()...)
+	}
+
+	var matched bool
 	var err error
-	err = f.Hasher.Compare(ctx, client.GetHashedSecret(), clientSecret)
-	if err == nil {
-		return nil
+	for _, hash := range hashes {
+		if compareErr := f.Hasher.Compare(ctx, hash, clientSecret); compareErr == nil {
+			matched = true
+		} else {
+			err = compareErr
+		}
 	}
-	cc, ok := client.(ClientWithSecretRotation)
-	if !ok {
-		return err
+
+	if matched {
+		return nil
 	}
-	for _, hash := range cc.GetRotatedHashes() {
-		err = f.Hasher.Compare(ctx, hash, clientSecret)
-		if err == nil {
-			return nil
+	return err
+}
+
+// findClientSecretKeys returns the current plaintext secret of the client followed by its rotated ones.
+func findClientSecretKeys(client Client) ([][]byte, error) {
+	if c, ok := client.(ClientWithPlaintextSecret); ok {
+		var secrets [][]byte
+		for _, secret := range append([][]byte{c.GetPlaintextSecret()}, c.GetRotatedPlaintextSecrets()...) {
+			if len(secret) > 0 {
+				secrets = append(secrets, secret)
+			}
+		}
+		if len(secrets) > 0 {
+			return secrets, nil
 		}
 	}
 
-	return err
+	return nil, errorsx.WithStack(ErrInvalidClient.WithHint("The OAuth 2.0 Client uses client authentication method 'client_secret_jwt' but its secret is not available in plain text, which is needed to verify the 'client_assertion'."))
 }
 
 func findPublicKey(t *jwt.Token, set *jose.JSONWebKeySet, expectsRSAKey bool) (interface{}, error) {
@@ -25,6 +25,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/ory/x/errorsx"
 
@@ -40,25 +41,57 @@ type JWKSFetcherStrategy interface {
 	Resolve(location string, forceRefresh bool) (*jose.JSONWebKeySet, error)
 }
 
+// DefaultJWKSFetcherTTL is how long DefaultJWKSFetcherStrategy caches a JSON Web Key Set if no other TTL is set.
+const DefaultJWKSFetcherTTL = time.Hour
+
+// DefaultJWKSFetcherStrategy fetches JSON Web Key Sets via HTTP and caches them in memory for the configured TTL.
 type DefaultJWKSFetcherStrategy struct {
 	client *http.Client
-	keys   map[string]jose.JSONWebKeySet
+	ttl    time.Duration
+	keys   map[string]cachedJSONWebKeySet
 	sync.Mutex
 }
 
-func NewDefaultJWKSFetcherStrategy() JWKSFetcherStrategy {
-	return &DefaultJWKSFetcherStrategy{
-		keys:   make(map[string]jose.JSONWebKeySet),
+type cachedJSONWebKeySet struct {
+	set       jose.JSONWebKeySet
+	expiresAt time.Time
+}
+
+// JWKSFetcherOption configures the DefaultJWKSFetcherStrategy.
+type JWKSFetcherOption func(*DefaultJWKSFetcherStrategy)
+
+// JWKSFetcherWithHTTPClient sets the HTTP client used to fetch JSON Web Key Sets. Defaults to http.DefaultClient.
+func JWKSFetcherWithHTTPClient(client *http.Client) JWKSFetcherOption {
+	return func(s *DefaultJWKSFetcherStrategy) {
+		s.client = client
+	}
+}
+
+// JWKSFetcherWithTTL sets how long fetched JSON Web Key Sets are cached. Defaults to DefaultJWKSFetcherTTL.
+func JWKSFetcherWithTTL(ttl time.Duration) JWKSFetcherOption {
+	return func(s *DefaultJWKSFetcherStrategy) {
+		s.ttl = ttl
+	}
+}
+
+func NewDefaultJWKSFetcherStrategy(opts ...JWKSFetcherOption) JWKSFetcherStrategy {
+	s := &DefaultJWKSFetcherStrategy{
+		keys:   make(map[string]cachedJSONWebKeySet),
 		client: http.DefaultClient,
+		ttl:    DefaultJWKSFetcherTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 func (s *DefaultJWKSFetcherStrategy) Resolve(location string, forceRefresh bool) (*jose.JSONWebKeySet, error) {
 	s.Lock()
 	defer s.Unlock()
 
-	keys, ok := s.keys[location]
-	if !ok || forceRefresh {
+	cached, ok := s.keys[location]
+	if !ok || forceRefresh || time.Now().After(cached.expiresAt) {
 		response, err := s.client.Get(location)
 		if err != nil {
 			return nil, errorsx.WithStack(ErrServerError.WithHintf("Unable to fetch JSON Web Keys from location '%s'. Check for typos or other network issues.", location).WithWrap(err).WithDebug(err.Error()))
@@ -74,9 +107,9 @@ func (s *DefaultJWKSFetcherStrategy) Resolve(location string, forceRefresh bool)
 			return nil, errorsx.WithStack(ErrServerError.WithHintf("Unable to decode JSON Web Keys from location '%s'. Please check for typos and if the URL returns valid JSON.", location).WithWrap(err).WithDebug(err.Error()))
 		}
 
-		s.keys[location] = set
+		s.keys[location] = cachedJSONWebKeySet{set: set, expiresAt: time.Now().Add(s.ttl)}
 		return &set, nil
 	}
 
-	return &keys, nil
+	return &cached.set, nil
 }
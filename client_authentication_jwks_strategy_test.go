@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -107,3 +108,41 @@ func TestDefaultJWKSFetcherStrategy(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestDefaultJWKSFetcherStrategyTTL(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.NoError(t, json.NewEncoder(w).Encode(&jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{{KeyID: "foo", Use: "sig", Key: &internal.MustRSAKey().PublicKey}},
+		}))
+	}))
+	defer ts.Close()
+
+	s := NewDefaultJWKSFetcherStrategy(JWKSFetcherWithTTL(time.Millisecond*50), JWKSFetcherWithHTTPClient(ts.Client()))
+
+	_, err := s.Resolve(ts.URL, false)
+	require.NoError(t, err)
+	_, err = s.Resolve(ts.URL, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	time.Sleep(time.Millisecond * 100)
+
+	keys, err := s.Resolve(ts.URL, false)
+	require.NoError(t, err)
+	assert.Len(t, keys.Key("foo"), 1)
+	assert.Equal(t, 2, requests)
+}
+
+func TestDefaultJWKSFetcherStrategyHTTPClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond * 200)
+		require.NoError(t, json.NewEncoder(w).Encode(&jose.JSONWebKeySet{}))
+	}))
+	defer ts.Close()
+
+	s := NewDefaultJWKSFetcherStrategy(JWKSFetcherWithHTTPClient(&http.Client{Timeout: time.Millisecond * 50}))
+	_, err := s.Resolve(ts.URL, false)
+	require.Error(t, err)
+}
@@ -61,9 +61,15 @@ func mustGenerateECDSAAssertion(t *testing.T, claims jwt.MapClaims, key *ecdsa.P
 	return tokenString
 }
 
+var hsAssertionSecret = []byte("aaaaaaaaaaaaaaabbbbbbbbbbbbbbbbbbbbbbbcccccccccccccccccccccddddddddddddddddddddddd")
+
 func mustGenerateHSAssertion(t *testing.T, claims jwt.MapClaims, key *rsa.PrivateKey, kid string) string {
-	token := jwt.NewWithClaims(jose.HS256, claims)
-	tokenString, err := token.SignedString([]byte("aaaaaaaaaaaaaaabbbbbbbbbbbbbbbbbbbbbbbcccccccccccccccccccccddddddddddddddddddddddd"))
+	return mustGenerateHSAssertionWithAlg(t, jose.HS256, claims)
+}
+
+func mustGenerateHSAssertionWithAlg(t *testing.T, alg jose.SignatureAlgorithm, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(alg, claims)
+	tokenString, err := token.SignedString(hsAssertionSecret)
 	require.NoError(t, err)
 	return tokenString
 }
@@ -239,6 +245,20 @@ func TestAuthenticateClient(t *testing.T) {
 			r:         &http.Request{Header: clientBasicAuthHeader("foo", "baz")},
 			expectErr: ErrInvalidClient,
 		},
+		{
+			d:         "should fail because client is confidential and a prefix of the secret is given in header",
+			client:    &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: barSecret}, TokenEndpointAuthMethod: "client_secret_basic"},
+			form:      url.Values{},
+			r:         &http.Request{Header: clientBasicAuthHeader("foo", "ba")},
+			expectErr: ErrInvalidClient,
+		},
+		{
+			d:         "should fail because client is confidential and a longer secret is given in post body",
+			client:    &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: barSecret}, TokenEndpointAuthMethod: "client_secret_post"},
+			form:      url.Values{"client_id": []string{"foo"}, "client_secret": []string{"barbar"}},
+			r:         new(http.Request),
+			expectErr: ErrInvalidClient,
+		},
 		{
 			d:         "should fail because client is confidential and neither secret nor rotated does match in header",
 			client:    &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: barSecret, RotatedSecrets: [][]byte{barSecret}}, TokenEndpointAuthMethod: "client_secret_basic"},
@@ -433,6 +453,94 @@ func TestAuthenticateClient(t *testing.T) {
 			r:         new(http.Request),
 			expectErr: ErrInvalidClient,
 		},
+		{
+			d:      "should pass with client_secret_jwt",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, PlaintextSecret: hsAssertionSecret, TokenEndpointAuthMethod: "client_secret_jwt"},
+			form: url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateHSAssertionWithAlg(t, jose.HS256, jwt.MapClaims{
+				"sub": "bar",
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"iss": "bar",
+				"jti": "12345",
+				"aud": "token-url",
+			})}, "client_assertion_type": []string{at}},
+			r: new(http.Request),
+		},
+		{
+			d:      "should pass with client_secret_jwt and registered algorithm HS512",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, PlaintextSecret: hsAssertionSecret, TokenEndpointAuthMethod: "client_secret_jwt", TokenEndpointAuthSigningAlgorithm: "HS512"},
+			form: url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateHSAssertionWithAlg(t, jose.HS512, jwt.MapClaims{
+				"sub": "bar",
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"iss": "bar",
+				"jti": "12345",
+				"aud": "token-url",
+			})}, "client_assertion_type": []string{at}},
+			r: new(http.Request),
+		},
+		{
+			d:      "should fail with client_secret_jwt because the algorithm does not match the registered one",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, PlaintextSecret: hsAssertionSecret, TokenEndpointAuthMethod: "client_secret_jwt"},
+			form: url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateHSAssertionWithAlg(t, jose.HS512, jwt.MapClaims{
+				"sub": "bar",
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"iss": "bar",
+				"jti": "12345",
+				"aud": "token-url",
+			})}, "client_assertion_type": []string{at}},
+			r:         new(http.Request),
+			expectErr: ErrInvalidClient,
+		},
+		{
+			d:      "should fail with client_secret_jwt because the secret does not match",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, PlaintextSecret: []byte("another-secret"), TokenEndpointAuthMethod: "client_secret_jwt"},
+			form: url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateHSAssertionWithAlg(t, jose.HS256, jwt.MapClaims{
+				"sub": "bar",
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"iss": "bar",
+				"jti": "12345",
+				"aud": "token-url",
+			})}, "client_assertion_type": []string{at}},
+			r:         new(http.Request),
+			expectErr: ErrInvalidClient,
+		},
+		{
+			d:      "should pass with client_secret_jwt signed with a rotated secret",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, PlaintextSecret: []byte("another-secret"), RotatedPlaintextSecrets: [][]byte{[]byte("yet-another-secret"), hsAssertionSecret}, TokenEndpointAuthMethod: "client_secret_jwt"},
+			form: url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateHSAssertionWithAlg(t, jose.HS256, jwt.MapClaims{
+				"sub": "bar",
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"iss": "bar",
+				"jti": "12345",
+				"aud": "token-url",
+			})}, "client_assertion_type": []string{at}},
+			r: new(http.Request),
+		},
+		{
+			d:      "should fail with client_secret_jwt because neither the current nor a rotated secret match",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, PlaintextSecret: []byte("another-secret"), RotatedPlaintextSecrets: [][]byte{[]byte("yet-another-secret")}, TokenEndpointAuthMethod: "client_secret_jwt"},
+			form: url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateHSAssertionWithAlg(t, jose.HS256, jwt.MapClaims{
+				"sub": "bar",
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"iss": "bar",
+				"jti": "12345",
+				"aud": "token-url",
+			})}, "client_assertion_type": []string{at}},
+			r:         new(http.Request),
+			expectErr: ErrInvalidClient,
+		},
+		{
+			d:      "should fail with client_secret_jwt because the plaintext secret is not available",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, PlaintextSecret: nil, TokenEndpointAuthMethod: "client_secret_jwt"},
+			form: url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateHSAssertionWithAlg(t, jose.HS256, jwt.MapClaims{
+				"sub": "bar",
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"iss": "bar",
+				"jti": "12345",
+				"aud": "token-url",
+			})}, "client_assertion_type": []string{at}},
+			r:         new(http.Request),
+			expectErr: ErrInvalidClient,
+		},
 		{
 			d:      "should fail because JWT algorithm is none",
 			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, JSONWebKeys: rsaJwks, TokenEndpointAuthMethod: "private_key_jwt"},
@@ -446,6 +554,19 @@ func TestAuthenticateClient(t *testing.T) {
 			r:         new(http.Request),
 			expectErr: ErrInvalidClient,
 		},
+		{
+			d:      "should fail because JWT algorithm is none even though the client registered it",
+			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, JSONWebKeys: rsaJwks, TokenEndpointAuthMethod: "private_key_jwt", TokenEndpointAuthSigningAlgorithm: "none"},
+			form: url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateNoneAssertion(t, jwt.MapClaims{
+				"sub": "bar",
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"iss": "bar",
+				"jti": "12345",
+				"aud": "token-url",
+			}, rsaKey, "kid-foo")}, "client_assertion_type": []string{at}},
+			r:         new(http.Request),
+			expectErr: ErrInvalidClient,
+		},
 		{
 			d:      "should pass with proper assertion when JWKs URI is set",
 			client: &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "bar", Secret: barSecret}, JSONWebKeysURI: ts.URL, TokenEndpointAuthMethod: "private_key_jwt"},
@@ -583,6 +704,174 @@ func TestAuthenticateClientTwice(t *testing.T) {
 	// replay the request and expect it to fail
 	c, err = f.AuthenticateClient(nil, new(http.Request), formValues)
 	require.Error(t, err)
-	assert.EqualError(t, err, ErrJTIKnown.Error())
+	assert.EqualError(t, err, ErrInvalidClient.Error())
+	assert.ErrorIs(t, err, ErrJTIKnown)
 	assert.Nil(t, c)
 }
+
+type countingPlaintextHasher struct {
+	compared int
+}
+
+func (h *countingPlaintextHasher) Compare(_ context.Context, hash, data []byte) error {
+	h.compared++
+	if !SecretsEqual(data, hash) {
+		return errors.New("secret mismatch")
+	}
+	return nil
+}
+
+func (h *countingPlaintextHasher) Hash(_ context.Context, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func TestAuthenticateClientSecretLengthMismatch(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultOpenIDConnectClient{
+		DefaultClient: &DefaultClient{
+			ID:             "foo",
+			Secret:         []byte("current-secret"),
+			RotatedSecrets: [][]byte{[]byte("rotated-secret")},
+		},
+		TokenEndpointAuthMethod: "client_secret_post",
+	}
+
+	for k, c := range []struct {
+		secret    string
+		expectErr bool
+	}{
+		{secret: "current-secret"},
+		{secret: "rotated-secret"},
+		{secret: "current", expectErr: true},
+		{secret: "current-secret-and-more", expectErr: true},
+		{secret: "", expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			hasher := new(countingPlaintextHasher)
+			f := &Fosite{Store: store, Hasher: hasher}
+
+			_, err := f.AuthenticateClient(context.Background(), new(http.Request), url.Values{"client_id": {"foo"}, "client_secret": {c.secret}})
+			if c.expectErr {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrInvalidClient), "%+v", err)
+			} else {
+				require.NoError(t, err, "%+v", err)
+			}
+			assert.Equal(t, 2, hasher.compared, "every hash is compared regardless of the secret length")
+		})
+	}
+}
+
+type failingClientAssertionStore struct {
+	*storage.MemoryStore
+}
+
+func (s *failingClientAssertionStore) ClientAssertionJWTValid(_ context.Context, _ string) error {
+	return errors.New("database unavailable")
+}
+
+func TestAuthenticateClientJTIStorageError(t *testing.T) {
+	const at = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	key := internal.MustRSAKey()
+	client := &DefaultOpenIDConnectClient{
+		DefaultClient: &DefaultClient{ID: "bar"},
+		JSONWebKeys: &jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{{KeyID: "kid-foo", Use: "sig", Key: &key.PublicKey}},
+		},
+		TokenEndpointAuthMethod: "private_key_jwt",
+	}
+	store := &failingClientAssertionStore{MemoryStore: storage.NewMemoryStore()}
+	store.Clients[client.ID] = client
+	f := &Fosite{JWKSFetcherStrategy: NewDefaultJWKSFetcherStrategy(), Store: store, TokenURL: "token-url"}
+
+	form := url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateRSAAssertion(t, jwt.MapClaims{
+		"sub": "bar",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iss": "bar",
+		"jti": "12345",
+		"aud": "token-url",
+	}, key, "kid-foo")}, "client_assertion_type": []string{at}}
+
+	_, err := f.AuthenticateClient(nil, new(http.Request), form)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrServerError)
+	assert.False(t, errors.Is(err, ErrInvalidClient))
+}
+
+func TestAuthenticateClientWithClockSkew(t *testing.T) {
+	const at = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	key := internal.MustRSAKey()
+	client := &DefaultOpenIDConnectClient{
+		DefaultClient: &DefaultClient{ID: "bar"},
+		JSONWebKeys: &jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{{KeyID: "kid-foo", Use: "sig", Key: &key.PublicKey}},
+		},
+		TokenEndpointAuthMethod: "private_key_jwt",
+	}
+
+	for k, claims := range []jwt.MapClaims{
+		{"exp": time.Now().Add(-30 * time.Second).Unix()},
+		{"exp": time.Now().Add(time.Hour).Unix(), "nbf": time.Now().Add(30 * time.Second).Unix()},
+		{"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Add(30 * time.Second).Unix()},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			claims["sub"], claims["iss"], claims["aud"], claims["jti"] = "bar", "bar", "token-url", fmt.Sprintf("jti-%d", k)
+			form := url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateRSAAssertion(t, claims, key, "kid-foo")}, "client_assertion_type": []string{at}}
+
+			store := storage.NewMemoryStore()
+			store.Clients[client.ID] = client
+			f := &Fosite{JWKSFetcherStrategy: NewDefaultJWKSFetcherStrategy(), Store: store, TokenURL: "token-url"}
+
+			_, err := f.AuthenticateClient(nil, new(http.Request), form)
+			require.Error(t, err)
+
+			f.ClockSkew = time.Minute
+			c, err := f.AuthenticateClient(nil, new(http.Request), form)
+			require.NoError(t, err, "%+v", err)
+			assert.Equal(t, client, c)
+		})
+	}
+}
+
+func TestAuthenticateClientWithAllowedJWTSigningAlgorithms(t *testing.T) {
+	const at = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	key := internal.MustRSAKey()
+	client := &DefaultOpenIDConnectClient{
+		DefaultClient: &DefaultClient{ID: "bar"},
+		JSONWebKeys: &jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{{KeyID: "kid-foo", Use: "sig", Key: &key.PublicKey}},
+		},
+		TokenEndpointAuthMethod: "private_key_jwt",
+	}
+
+	for k, c := range []struct {
+		allowed   []jose.SignatureAlgorithm
+		expectErr error
+	}{
+		{},
+		{allowed: []jose.SignatureAlgorithm{jose.RS256, jose.ES256}},
+		{allowed: []jose.SignatureAlgorithm{jose.PS256}, expectErr: ErrInvalidClient},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			claims := jwt.MapClaims{"sub": "bar", "iss": "bar", "aud": "token-url", "jti": fmt.Sprintf("jti-%d", k), "exp": time.Now().Add(time.Hour).Unix()}
+			form := url.Values{"client_id": []string{"bar"}, "client_assertion": {mustGenerateRSAAssertion(t, claims, key, "kid-foo")}, "client_assertion_type": []string{at}}
+
+			store := storage.NewMemoryStore()
+			store.Clients[client.ID] = client
+			f := &Fosite{JWKSFetcherStrategy: NewDefaultJWKSFetcherStrategy(), Store: store, TokenURL: "token-url", AllowedJWTSigningAlgorithms: c.allowed}
+
+			// The assertion is validly signed, but must be rejected if its algorithm is not allowed.
+			_, err := f.AuthenticateClient(nil, new(http.Request), form)
+			if c.expectErr != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, c.expectErr))
+				assert.Contains(t, ErrorToRFC6749Error(err).HintField, "not allowed")
+				return
+			}
+			require.NoError(t, err, "%+v", err)
+		})
+	}
+}
@@ -24,6 +24,8 @@ package fosite
 import (
 	"context"
 	"time"
+
+	"github.com/ory/x/errorsx"
 )
 
 // ClientManager defines the (persistent) manager interface for clients.
@@ -31,6 +33,80 @@ type ClientManager interface {
 	// GetClient loads the client by its ID or returns an error
 	// if the client does not exist or another error occurred.
 	GetClient(ctx context.Context, id string) (Client, error)
+
+	ClientAssertionJTIStorage
+}
+
+// ClientIdentifier identifies a client by its client_id and the tenant it is registered with.
+type ClientIdentifier struct {
+	Tenant string
+	ID     string
+}
+
+// GetClientIdentifier returns the identifier of the client of the requester, which consists of its tenant and its
+// client_id. Comparing identifiers instead of client IDs ensures that the tokens of a tenant can not be used by the
+// client with the same client_id of another tenant.
+func GetClientIdentifier(requester Requester) ClientIdentifier {
+	return ClientIdentifier{Tenant: GetTenant(requester), ID: requester.GetClient().GetID()}
+}
+
+// ClientManagerWithContext is implemented by storages which key clients by composite identifiers, for example in
+// multi-tenant deployments where the same client_id exists under different tenants. Fosite prefers it over
+// ClientManager.GetClient and identifies the client by the tenant added to the context with WithTenant.
+type ClientManagerWithContext interface {
+	// GetClientByIdentifier loads the client by its identifier or returns an error if the client does not exist or
+	// another error occurred.
+	GetClientByIdentifier(ctx context.Context, identifier ClientIdentifier) (Client, error)
+}
+
+// WithTenant adds the tenant to the context, which client lookups pass to ClientManagerWithContext. Requests handled
+// with the context store the tenant in their RequestContext, see TenantRequestContextKey.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, TenantContextKey, tenant)
+}
+
+// TenantFromContext returns the tenant added to the context with WithTenant, if any.
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(TenantContextKey).(string)
+	return tenant
+}
+
+// GetClientWithContext loads the client with the id using ClientManagerWithContext if the manager implements it,
+// and ClientManager.GetClient otherwise.
+func GetClientWithContext(ctx context.Context, manager ClientManager, id string) (Client, error) {
+	if m, ok := manager.(ClientManagerWithContext); ok {
+		return m.GetClientByIdentifier(ctx, ClientIdentifier{Tenant: TenantFromContext(ctx), ID: id})
+	}
+	return manager.GetClient(ctx, id)
+}
+
+// ClientManagerAdapter adapts a ClientManager to ClientManagerWithContext by ignoring the tenant.
+type ClientManagerAdapter struct {
+	ClientManager
+}
+
+func (a ClientManagerAdapter) GetClientByIdentifier(ctx context.Context, identifier ClientIdentifier) (Client, error) {
+	return a.GetClient(ctx, identifier.ID)
+}
+
+// TenantClientManagers implements ClientManagerWithContext by looking up the client in the ClientManager of its
+// tenant, which allows to reuse ClientManager implementations for multi-tenant deployments.
+type TenantClientManagers map[string]ClientManager
+
+func (m TenantClientManagers) GetClientByIdentifier(ctx context.Context, identifier ClientIdentifier) (Client, error) {
+	manager, ok := m[identifier.Tenant]
+	if !ok {
+		return nil, errorsx.WithStack(ErrNotFound.WithHintf("The tenant '%s' is unknown.", identifier.Tenant))
+	}
+	return manager.GetClient(ctx, identifier.ID)
+}
+
+// ClientAssertionJTIStorage keeps track of the "jti" values of client assertions used for the private_key_jwt and
+// client_secret_jwt client authentication methods, so that an assertion can not be replayed.
+type ClientAssertionJTIStorage interface {
 	// ClientAssertionJWTValid returns an error if the JTI is
 	// known or the DB check failed and nil if the JTI is not known.
 	ClientAssertionJWTValid(ctx context.Context, jti string) error
@@ -0,0 +1,162 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+type tenantStore struct {
+	*storage.MemoryStore
+	TenantClientManagers
+}
+
+func TestGetClientWithContext(t *testing.T) {
+	ctx := context.Background()
+	tenantA, tenantB := storage.NewMemoryStore(), storage.NewMemoryStore()
+	tenantA.Clients["foo"] = &DefaultClient{ID: "foo", Audience: []string{"tenant-a"}}
+	tenantB.Clients["foo"] = &DefaultClient{ID: "foo", Audience: []string{"tenant-b"}}
+	managers := TenantClientManagers{"a": tenantA, "b": tenantB}
+
+	for k, c := range []struct {
+		d              string
+		manager        ClientManager
+		tenant         string
+		expectAudience string
+		expectErr      error
+	}{
+		{d: "should resolve the client of tenant a", manager: &tenantStore{TenantClientManagers: managers}, tenant: "a", expectAudience: "tenant-a"},
+		{d: "should resolve the client of tenant b", manager: &tenantStore{TenantClientManagers: managers}, tenant: "b", expectAudience: "tenant-b"},
+		{d: "should fail because the tenant is unknown", manager: &tenantStore{TenantClientManagers: managers}, tenant: "c", expectErr: ErrNotFound},
+		{d: "should fail because no tenant is given", manager: &tenantStore{TenantClientManagers: managers}, expectErr: ErrNotFound},
+		{d: "should ignore the tenant of a client manager without tenants", manager: tenantA, tenant: "b", expectAudience: "tenant-a"},
+	} {
+		t.Run(c.d, func(t *testing.T) {
+			client, err := GetClientWithContext(WithTenant(ctx, c.tenant), c.manager, "foo")
+			if c.expectErr != nil {
+				require.Error(t, err, "%d", k)
+				assert.True(t, errors.Is(err, c.expectErr), "%+v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, Arguments{c.expectAudience}, client.GetAudience())
+		})
+	}
+
+	client, err := ClientManagerAdapter{ClientManager: tenantB}.GetClientByIdentifier(ctx, ClientIdentifier{Tenant: "a", ID: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, Arguments{"tenant-b"}, client.GetAudience())
+}
+
+func TestAuthenticateClientWithTenants(t *testing.T) {
+	hasher := &BCrypt{WorkFactor: 4}
+	newTenant := func(secret string) *storage.MemoryStore {
+		hash, err := hasher.Hash(context.Background(), []byte(secret))
+		require.NoError(t, err)
+		s := storage.NewMemoryStore()
+		s.Clients["foo"] = &DefaultOpenIDConnectClient{DefaultClient: &DefaultClient{ID: "foo", Secret: hash}, TokenEndpointAuthMethod: "client_secret_basic"}
+		return s
+	}
+	f := &Fosite{Hasher: hasher, Store: &tenantStore{
+		MemoryStore:          storage.NewMemoryStore(),
+		TenantClientManagers: TenantClientManagers{"a": newTenant("secret-a"), "b": newTenant("secret-b")},
+	}}
+
+	for k, c := range []struct {
+		tenant    string
+		secret    string
+		expectErr error
+	}{
+		{tenant: "a", secret: "secret-a"},
+		{tenant: "b", secret: "secret-b"},
+		{tenant: "a", secret: "secret-b", expectErr: ErrInvalidClient},
+		{tenant: "b", secret: "secret-a", expectErr: ErrInvalidClient},
+	} {
+		_, err := f.AuthenticateClient(WithTenant(context.Background(), c.tenant), &http.Request{Header: clientBasicAuthHeader("foo", c.secret)}, url.Values{})
+		if c.expectErr != nil {
+			assert.True(t, errors.Is(err, c.expectErr), "%d: %+v", k, err)
+		} else {
+			assert.NoError(t, err, "%d: %+v", k, err)
+		}
+	}
+}
+
+func TestTenantRequestContext(t *testing.T) {
+	tenantA := storage.NewMemoryStore()
+	tenantA.Clients["foo"] = &DefaultClient{ID: "foo", RedirectURIs: []string{"https://foo.com/cb"}, ResponseTypes: []string{"code"}}
+	extractor := func(ctx context.Context, r *http.Request) (RequestContext, error) {
+		return RequestContext{TenantRequestContextKey: r.Header.Get("X-Tenant")}, nil
+	}
+
+	for k, c := range []struct {
+		d             string
+		extractor     RequestContextExtractor
+		contextTenant string
+		headerTenant  string
+		expectErr     error
+	}{
+		{d: "should look up the client using the extracted tenant", extractor: extractor, headerTenant: "a"},
+		{d: "should fail because the extracted tenant does not know the client", extractor: extractor, headerTenant: "b", expectErr: ErrInvalidClient},
+		{d: "should store the tenant of the context in the request context", contextTenant: "a"},
+		{d: "should prefer the tenant of the context", extractor: extractor, contextTenant: "a", headerTenant: "b"},
+	} {
+		t.Run(c.d, func(t *testing.T) {
+			f := &Fosite{
+				Store: &tenantStore{
+					MemoryStore:          storage.NewMemoryStore(),
+					TenantClientManagers: TenantClientManagers{"a": tenantA, "b": storage.NewMemoryStore()},
+				},
+				RequestContextExtractor:  c.extractor,
+				ScopeStrategy:            ExactScopeStrategy,
+				AudienceMatchingStrategy: DefaultAudienceMatchingStrategy,
+			}
+			r, err := http.NewRequest(http.MethodGet, "/auth?client_id=foo&response_type=code&redirect_uri=https%3A%2F%2Ffoo.com%2Fcb&state=strong-state-value", nil)
+			require.NoError(t, err)
+			r.Header.Set("X-Tenant", c.headerTenant)
+
+			ctx := context.Background()
+			if c.contextTenant != "" {
+				ctx = WithTenant(ctx, c.contextTenant)
+			}
+
+			ar, err := f.NewAuthorizeRequest(ctx, r)
+			if c.expectErr != nil {
+				require.Error(t, err, "%d", k)
+				assert.True(t, errors.Is(err, c.expectErr), "%+v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "a", GetTenant(ar))
+			assert.Equal(t, ClientIdentifier{Tenant: "a", ID: "foo"}, GetClientIdentifier(ar))
+		})
+	}
+}
@@ -22,7 +22,9 @@
 package fosite
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -58,3 +60,32 @@ func TestDefaultResponseModeClient_GetResponseMode(t *testing.T) {
 	rc := &DefaultResponseModeClient{ResponseModes: []ResponseModeType{ResponseModeFragment}}
 	assert.Equal(t, []ResponseModeType{ResponseModeFragment}, rc.GetResponseModes())
 }
+
+func TestGetEffectiveLifespan(t *testing.T) {
+	client := &DefaultClient{
+		TokenLifespans: &ClientLifespanConfig{
+			Default: TokenLifespans{AccessToken: time.Minute, RefreshToken: time.Hour},
+			GrantTypes: map[string]TokenLifespans{
+				"client_credentials": {AccessToken: time.Second},
+			},
+		},
+	}
+
+	for k, c := range []struct {
+		client    Client
+		grantType string
+		tokenType TokenType
+		expected  time.Duration
+	}{
+		{client: client, grantType: "client_credentials", tokenType: AccessToken, expected: time.Second},
+		{client: client, grantType: "authorization_code", tokenType: AccessToken, expected: time.Minute},
+		{client: client, grantType: "client_credentials", tokenType: RefreshToken, expected: time.Hour},
+		{client: client, grantType: "authorization_code", tokenType: IDToken, expected: 5 * time.Hour},
+		{client: &DefaultClient{}, grantType: "client_credentials", tokenType: AccessToken, expected: 5 * time.Hour},
+		{client: &DefaultOpenIDConnectClient{DefaultClient: client}, grantType: "refresh_token", tokenType: AccessToken, expected: time.Minute},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, c.expected, GetEffectiveLifespan(c.client, c.grantType, c.tokenType, 5*time.Hour))
+		})
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"crypto/rsa"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
 	"github.com/ory/fosite/token/jwt"
 )
 
@@ -54,7 +55,7 @@ type Factory func(config *Config, storage interface{}, strategy interface{}) int
 // Compose makes use of interface{} types in order to be able to handle a all types of stores, strategies and handlers.
 func Compose(config *Config, storage interface{}, strategy interface{}, hasher fosite.Hasher, factories ...Factory) fosite.OAuth2Provider {
 	if hasher == nil {
-		hasher = &fosite.BCrypt{WorkFactor: config.GetHashCost()}
+		hasher = config.GetClientSecretHasher()
 	}
 
 	f := &fosite.Fosite{
@@ -63,16 +64,80 @@ func Compose(config *Config, storage interface{}, strategy interface{}, hasher f
 		TokenEndpointHandlers:        fosite.TokenEndpointHandlers{},
 		TokenIntrospectionHandlers:   fosite.TokenIntrospectionHandlers{},
 		RevocationHandlers:           fosite.RevocationHandlers{},
+		DeviceEndpointHandlers:       fosite.DeviceEndpointHandlers{},
 		Hasher:                       hasher,
 		ScopeStrategy:                config.GetScopeStrategy(),
 		AudienceMatchingStrategy:     config.GetAudienceStrategy(),
+		RedirectURIMatchingStrategy:  config.GetRedirectURIMatchingStrategy(),
+		RedirectURISecurityPolicy:    config.RedirectURISecurityPolicy,
 		SendDebugMessagesToClients:   config.SendDebugMessagesToClients,
 		TokenURL:                     config.TokenURL,
 		JWKSFetcherStrategy:          config.GetJWKSFetcherStrategy(),
 		MinParameterEntropy:          config.GetMinParameterEntropy(),
+		MinStateLength:               config.MinStateLength,
 		UseLegacyErrorFormat:         config.UseLegacyErrorFormat,
+		BearerTokenType:              config.BearerTokenType,
+		BearerRealm:                  config.BearerRealm,
+		ErrorWriteStrategy:           config.ErrorWriteStrategy,
+		ErrorHook:                    config.ErrorHook,
+		AuditLogger:                  config.GetAuditLogger(),
+		Observer:                     config.GetObserver(),
+		Tracer:                       config.Tracer,
 		ClientAuthenticationStrategy: config.GetClientAuthenticationStrategy(),
 		ResponseModeHandlerExtension: config.ResponseModeHandlerExtension,
+		ResponseModeHandlers:         config.ResponseModeHandlers,
+		RequestURIAllowlist:          config.RequestURIAllowlist,
+		RequestURIFetchTimeout:       config.RequestURIFetchTimeout,
+		RequestURIAllowedHosts:       config.RequestURIAllowedHosts,
+		RequestURIMaxResponseSize:    config.RequestURIMaxResponseSize,
+		StorageTimeout:               config.StorageTimeout,
+		RequestObjectDecryptionKey:   config.RequestObjectDecryptionKey,
+		IntrospectionCacheMaxAge:     config.IntrospectionCacheMaxAge,
+		IntrospectionAuthorizer:      config.IntrospectionAuthorizer,
+		RestrictIntrospectionScopes:  config.RestrictIntrospectionScopes,
+		IntrospectionScopeFilter:     config.IntrospectionScopeFilter,
+		ScopeDelimiters:              config.ScopeDelimiters,
+		ClockSkew:                    config.ClockSkew,
+		MaxScopes:                    config.MaxScopes,
+		MaxAudiences:                 config.MaxAudiences,
+		MaxRequestBodySize:           config.MaxRequestBodySize,
+		MaxParameterLengths:          config.MaxParameterLengths,
+		RequestContextExtractor:      config.RequestContextExtractor,
+		LoginHintValidator:           config.LoginHintValidator,
+		ConsentStrategy:              config.ConsentStrategy,
+		Issuer:                       config.GetIssuer(),
+		AllowedJWTSigningAlgorithms:  config.AllowedJWTSigningAlgorithms,
+		AllowUnknownDisplayValues:    config.AllowUnknownDisplayValues,
+
+		AuthorizationDetailsValidator:           config.AuthorizationDetailsValidator,
+		JWTSecuredAuthorizeResponseModeSigners:  config.JWTSecuredAuthorizeResponseModeSigners,
+		JWTSecuredAuthorizeResponseModeIssuer:   config.GetJWTSecuredAuthorizeResponseModeIssuer(),
+		JWTSecuredAuthorizeResponseModeLifespan: config.JWTSecuredAuthorizeResponseModeLifespan,
+		IDTokenHintStrategy:                     config.IDTokenHintStrategy,
+		IncludeRequestedScopeInAccessResponse:   config.IncludeRequestedScopeInAccessResponse,
+		PushedAuthorizeRequestLifespan:          config.GetPushedAuthorizeRequestLifespan(),
+		RequirePushedAuthorizationRequests:      config.RequirePushedAuthorizationRequests,
+		RevokePushedAuthorizeRequestsByClient:   config.RevokePushedAuthorizeRequestsByClient,
+	}
+
+	if cs, ok := strategy.(*CommonStrategy); ok && f.IDTokenHintStrategy == nil && cs.JWTStrategy != nil {
+		f.IDTokenHintStrategy = cs.JWTStrategy
+	}
+
+	if cs, ok := strategy.(*CommonStrategy); ok {
+		setJWTAccessTokenIssuer(cs.CoreStrategy, config.GetIssuer())
+	}
+
+	if config.EnableMTLSClientAuthentication {
+		fallback := f.ClientAuthenticationStrategy
+		if fallback == nil {
+			fallback = f.DefaultClientAuthenticationStrategy
+		}
+		f.ClientAuthenticationStrategy = (&oauth2.MTLSClientAuthenticationStrategy{
+			Store:             f.Store,
+			ClientCertificate: config.GetTLSClientCertificate(),
+			Fallback:          fallback,
+		}).AuthenticateClient
 	}
 
 	for _, factory := range factories {
@@ -89,21 +154,38 @@ func Compose(config *Config, storage interface{}, strategy interface{}, hasher f
 		if rh, ok := res.(fosite.RevocationHandler); ok {
 			f.RevocationHandlers.Append(rh)
 		}
+		if dh, ok := res.(fosite.DeviceEndpointHandler); ok {
+			f.DeviceEndpointHandlers.Append(dh)
+		}
+		if bh, ok := res.(fosite.BackchannelAuthenticationEndpointHandler); ok {
+			f.BackchannelAuthenticationEndpointHandlers.Append(bh)
+		}
+		if ph, ok := res.(fosite.PushedAuthorizeEndpointHandler); ok {
+			f.PushedAuthorizeEndpointHandlers.Append(ph)
+		}
 	}
 
 	return f
 }
 
-// ComposeAllEnabled returns a fosite instance with all OAuth2 and OpenID Connect handlers enabled.
+// ComposeAllEnabled returns a fosite instance with all OAuth2 and OpenID Connect handlers enabled. HMAC based tokens
+// are signed with the given secret, or Config.GlobalSecret if it is empty.
 func ComposeAllEnabled(config *Config, storage interface{}, secret []byte, key *rsa.PrivateKey) fosite.OAuth2Provider {
+	if len(secret) == 0 {
+		secret = config.GlobalSecret
+	}
+
 	return Compose(
 		config,
 		storage,
 		&CommonStrategy{
-			CoreStrategy:               NewOAuth2HMACStrategy(config, secret, nil),
+			CoreStrategy:               NewOAuth2HMACStrategy(config, secret, config.RotatedGlobalSecrets),
 			OpenIDConnectTokenStrategy: NewOpenIDConnectStrategy(config, key),
 			JWTStrategy: &jwt.RS256JWTStrategy{
 				PrivateKey: key,
+				ClockSkew:  config.ClockSkew,
+
+				AllowedAlgorithms: config.AllowedJWTSigningAlgorithms,
 			},
 		},
 		nil,
@@ -36,12 +36,16 @@ func OAuth2AuthorizeExplicitFactory(config *Config, storage interface{}, strateg
 		CoreStorage:              storage.(oauth2.CoreStorage),
 		AuthCodeLifespan:         config.GetAuthorizeCodeLifespan(),
 		RefreshTokenLifespan:     config.GetRefreshTokenLifespan(),
-		AccessTokenLifespan:      config.GetAccessTokenLifespan(),
+		AccessTokenLifespan:      config.GetGrantTypeAccessTokenLifespan("authorization_code"),
 		ScopeStrategy:            config.GetScopeStrategy(),
 		AudienceMatchingStrategy: config.GetAudienceStrategy(),
 		TokenRevocationStorage:   storage.(oauth2.TokenRevocationStorage),
 		IsRedirectURISecure:      config.GetRedirectSecureChecker(),
 		RefreshTokenScopes:       config.GetRefreshTokenScopes(),
+		CodeReuseGracePeriod:     config.CodeReuseGracePeriod,
+		EnforceOfflineAccess:     config.EnforceOfflineAccess,
+
+		IssuanceHooks: config.GetTokenIssuanceHooks(),
 	}
 }
 
@@ -52,7 +56,9 @@ func OAuth2ClientCredentialsGrantFactory(config *Config, storage interface{}, st
 		HandleHelper: &oauth2.HandleHelper{
 			AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
 			AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
-			AccessTokenLifespan: config.GetAccessTokenLifespan(),
+			AccessTokenLifespan: config.GetGrantTypeAccessTokenLifespan("client_credentials"),
+
+			IssuanceHooks: config.GetTokenIssuanceHooks(),
 		},
 		ScopeStrategy:            config.GetScopeStrategy(),
 		AudienceMatchingStrategy: config.GetAudienceStrategy(),
@@ -66,11 +72,19 @@ func OAuth2RefreshTokenGrantFactory(config *Config, storage interface{}, strateg
 		AccessTokenStrategy:      strategy.(oauth2.AccessTokenStrategy),
 		RefreshTokenStrategy:     strategy.(oauth2.RefreshTokenStrategy),
 		TokenRevocationStorage:   storage.(oauth2.TokenRevocationStorage),
-		AccessTokenLifespan:      config.GetAccessTokenLifespan(),
+		AccessTokenLifespan:      config.GetGrantTypeAccessTokenLifespan("refresh_token"),
 		RefreshTokenLifespan:     config.GetRefreshTokenLifespan(),
 		ScopeStrategy:            config.GetScopeStrategy(),
 		AudienceMatchingStrategy: config.GetAudienceStrategy(),
 		RefreshTokenScopes:       config.GetRefreshTokenScopes(),
+
+		RefreshTokenRotation:            config.RefreshTokenRotation,
+		RefreshTokenRotationGracePeriod: config.RefreshTokenRotationGracePeriod,
+		AbsoluteRefreshTokenLifespan:    config.AbsoluteRefreshTokenLifespan,
+		MaxRefreshChainDepth:            config.MaxRefreshChainDepth,
+
+		IssuanceHooks:    config.GetTokenIssuanceHooks(),
+		SessionValidator: config.SessionValidator,
 	}
 }
 
@@ -80,9 +94,12 @@ func OAuth2AuthorizeImplicitFactory(config *Config, storage interface{}, strateg
 	return &oauth2.AuthorizeImplicitGrantTypeHandler{
 		AccessTokenStrategy:      strategy.(oauth2.AccessTokenStrategy),
 		AccessTokenStorage:       storage.(oauth2.AccessTokenStorage),
-		AccessTokenLifespan:      config.GetAccessTokenLifespan(),
+		AccessTokenLifespan:      config.GetGrantTypeAccessTokenLifespan("implicit"),
 		ScopeStrategy:            config.GetScopeStrategy(),
 		AudienceMatchingStrategy: config.GetAudienceStrategy(),
+		Disabled:                 config.DisableImplicitGrant,
+
+		IssuanceHooks: config.GetTokenIssuanceHooks(),
 	}
 }
 
@@ -94,23 +111,36 @@ func OAuth2ResourceOwnerPasswordCredentialsFactory(config *Config, storage inter
 		HandleHelper: &oauth2.HandleHelper{
 			AccessTokenStrategy:  strategy.(oauth2.AccessTokenStrategy),
 			AccessTokenStorage:   storage.(oauth2.AccessTokenStorage),
-			AccessTokenLifespan:  config.GetAccessTokenLifespan(),
+			AccessTokenLifespan:  config.GetGrantTypeAccessTokenLifespan("password"),
 			RefreshTokenLifespan: config.GetRefreshTokenLifespan(),
+
+			IssuanceHooks: config.GetTokenIssuanceHooks(),
 		},
 		RefreshTokenStrategy:     strategy.(oauth2.RefreshTokenStrategy),
 		ScopeStrategy:            config.GetScopeStrategy(),
 		AudienceMatchingStrategy: config.GetAudienceStrategy(),
 		RefreshTokenScopes:       config.GetRefreshTokenScopes(),
+		Disabled:                 config.DisableResourceOwnerPasswordCredentialsGrant,
 	}
 }
 
 // OAuth2TokenRevocationFactory creates an OAuth2 token revocation handler.
 func OAuth2TokenRevocationFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
-	return &oauth2.TokenRevocationHandler{
+	h := &oauth2.TokenRevocationHandler{
 		TokenRevocationStorage: storage.(oauth2.TokenRevocationStorage),
 		AccessTokenStrategy:    strategy.(oauth2.AccessTokenStrategy),
 		RefreshTokenStrategy:   strategy.(oauth2.RefreshTokenStrategy),
+		RevocationHook:         config.RevocationHook,
 	}
+
+	if config.EnableJWTAccessTokenDenylist {
+		h.RevokedJTIStorage = storage.(oauth2.RevokedJTIStorage)
+		h.JWTStrategy = strategy.(jwt.JWTStrategy)
+		h.ClockSkew = config.ClockSkew
+		h.RevokedSubjectStorage = revokedSubjectStorage(config, storage)
+	}
+
+	return h
 }
 
 // OAuth2TokenIntrospectionFactory creates an OAuth2 token introspection handler and registers
@@ -121,6 +151,7 @@ func OAuth2TokenIntrospectionFactory(config *Config, storage interface{}, strate
 		CoreStorage:                   storage.(oauth2.CoreStorage),
 		ScopeStrategy:                 config.GetScopeStrategy(),
 		DisableRefreshTokenValidation: config.DisableRefreshTokenValidation,
+		SessionValidator:              config.SessionValidator,
 	}
 }
 
@@ -129,11 +160,50 @@ func OAuth2TokenIntrospectionFactory(config *Config, storage interface{}, strate
 // statelessly, meaning it uses only the data available in the JWT itself, and does not access the
 // storage implementation at all.
 //
-// Due to the stateless nature of this factory, THE BUILT-IN REVOCATION MECHANISMS WILL NOT WORK.
-// If you need revocation, you can validate JWTs statefully, using the other factories.
+// Due to the stateless nature of this factory, THE BUILT-IN REVOCATION MECHANISMS WILL NOT WORK, unless
+// Config.EnableJWTAccessTokenDenylist is set. Otherwise, you can validate JWTs statefully, using the other factories.
 func OAuth2StatelessJWTIntrospectionFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
 	return &oauth2.StatelessJWTValidator{
 		JWTStrategy:   strategy.(jwt.JWTStrategy),
 		ScopeStrategy: config.GetScopeStrategy(),
+
+		ClockSkew:             config.ClockSkew,
+		RevokedJTIStorage:     revokedJTIStorage(config, storage),
+		RevokedSubjectStorage: revokedSubjectStorage(config, storage),
+	}
+}
+
+// OAuth2JWTProfileAccessTokenFactory creates an OAuth2 token introspection handler for access tokens following
+// the JWT profile defined in https://datatracker.ietf.org/doc/html/rfc9068. Like OAuth2StatelessJWTIntrospectionFactory
+// it decodes and verifies tokens locally without accessing the storage, but rejects JWTs which are not access tokens.
+//
+// The access tokens must be issued by a strategy created with NewOAuth2JWTStrategy(key, strategy).WithJWTProfile().
+func OAuth2JWTProfileAccessTokenFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
+	return &oauth2.StatelessJWTValidator{
+		JWTStrategy:   strategy.(jwt.JWTStrategy),
+		ScopeStrategy: config.GetScopeStrategy(),
+		JWTProfile:    true,
+
+		ClockSkew:             config.ClockSkew,
+		RevokedJTIStorage:     revokedJTIStorage(config, storage),
+		RevokedSubjectStorage: revokedSubjectStorage(config, storage),
+	}
+}
+
+// revokedJTIStorage returns the denylist of revoked JWT access tokens if it is enabled.
+func revokedJTIStorage(config *Config, storage interface{}) oauth2.RevokedJTIStorage {
+	if !config.EnableJWTAccessTokenDenylist {
+		return nil
+	}
+	return storage.(oauth2.RevokedJTIStorage)
+}
+
+// revokedSubjectStorage returns the denylist of subjects whose tokens were revoked at once if the denylist is enabled
+// and the storage implements it.
+func revokedSubjectStorage(config *Config, storage interface{}) oauth2.RevokedSubjectStorage {
+	if !config.EnableJWTAccessTokenDenylist {
+		return nil
 	}
+	s, _ := storage.(oauth2.RevokedSubjectStorage)
+	return s
 }
@@ -33,11 +33,31 @@ import (
 func OpenIDConnectExplicitFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
 	return &openid.OpenIDConnectExplicitHandler{
 		OpenIDConnectRequestStorage: storage.(openid.OpenIDConnectRequestStorage),
-		IDTokenHandleHelper: &openid.IDTokenHandleHelper{
-			IDTokenStrategy: strategy.(openid.OpenIDConnectTokenStrategy),
-		},
+		IDTokenHandleHelper:         newAuthorizeIDTokenHandleHelper(config, storage, strategy),
 		OpenIDConnectRequestValidator: openid.NewOpenIDConnectRequestValidator(config.AllowedPromptValues, strategy.(jwt.JWTStrategy)).
-			WithRedirectSecureChecker(config.GetRedirectSecureChecker()),
+			WithRedirectSecureChecker(config.GetRedirectSecureChecker()).
+			WithSilentAuthenticationStrategy(config.SilentAuthenticationStrategy).
+			WithACRStrategy(config.ACRStrategy).
+			WithIssuer(config.GetIDTokenIssuer()),
+	}
+}
+
+// newAuthorizeIDTokenHandleHelper creates the ID Token helper of the handlers at the authorization endpoint, which
+// also consume the nonce of authentication requests if EnforceNonceUniqueness is enabled.
+func newAuthorizeIDTokenHandleHelper(config *Config, storage interface{}, strategy interface{}) *openid.IDTokenHandleHelper {
+	helper := newIDTokenHandleHelper(config, strategy)
+	if config.EnforceNonceUniqueness {
+		helper.NonceStorage = storage.(openid.NonceStorage)
+		helper.NonceLifespan = config.GetIDTokenLifespan()
+	}
+	return helper
+}
+
+// newIDTokenHandleHelper creates the ID Token helper of the handlers issuing ID Tokens.
+func newIDTokenHandleHelper(config *Config, strategy interface{}) *openid.IDTokenHandleHelper {
+	return &openid.IDTokenHandleHelper{
+		IDTokenStrategy: strategy.(openid.OpenIDConnectTokenStrategy),
+		HashStrategy:    config.GetIDTokenHashStrategy(strategy),
 	}
 }
 
@@ -46,9 +66,7 @@ func OpenIDConnectExplicitFactory(config *Config, storage interface{}, strategy
 // **Important note:** You must add this handler *after* you have added an OAuth2 authorize code handler!
 func OpenIDConnectRefreshFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
 	return &openid.OpenIDConnectRefreshHandler{
-		IDTokenHandleHelper: &openid.IDTokenHandleHelper{
-			IDTokenStrategy: strategy.(openid.OpenIDConnectTokenStrategy),
-		},
+		IDTokenHandleHelper: newIDTokenHandleHelper(config, strategy),
 	}
 }
 
@@ -60,15 +78,20 @@ func OpenIDConnectImplicitFactory(config *Config, storage interface{}, strategy
 		AuthorizeImplicitGrantTypeHandler: &oauth2.AuthorizeImplicitGrantTypeHandler{
 			AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
 			AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
-			AccessTokenLifespan: config.GetAccessTokenLifespan(),
-		},
-		ScopeStrategy: config.GetScopeStrategy(),
-		IDTokenHandleHelper: &openid.IDTokenHandleHelper{
-			IDTokenStrategy: strategy.(openid.OpenIDConnectTokenStrategy),
+			AccessTokenLifespan: config.GetGrantTypeAccessTokenLifespan("implicit"),
+			Disabled:            config.DisableImplicitGrant,
+
+			IssuanceHooks: config.GetTokenIssuanceHooks(),
 		},
+		ScopeStrategy:       config.GetScopeStrategy(),
+		IDTokenHandleHelper: newAuthorizeIDTokenHandleHelper(config, storage, strategy),
 		OpenIDConnectRequestValidator: openid.NewOpenIDConnectRequestValidator(config.AllowedPromptValues, strategy.(jwt.JWTStrategy)).
-			WithRedirectSecureChecker(config.GetRedirectSecureChecker()),
+			WithRedirectSecureChecker(config.GetRedirectSecureChecker()).
+			WithSilentAuthenticationStrategy(config.SilentAuthenticationStrategy).
+			WithACRStrategy(config.ACRStrategy).
+			WithIssuer(config.GetIDTokenIssuer()),
 		MinParameterEntropy: config.GetMinParameterEntropy(),
+		AllowMissingNonce:   config.AllowMissingNonce,
 	}
 }
 
@@ -83,22 +106,63 @@ func OpenIDConnectHybridFactory(config *Config, storage interface{}, strategy in
 			AuthorizeCodeStrategy: strategy.(oauth2.AuthorizeCodeStrategy),
 			CoreStorage:           storage.(oauth2.CoreStorage),
 			AuthCodeLifespan:      config.GetAuthorizeCodeLifespan(),
-			AccessTokenLifespan:   config.GetAccessTokenLifespan(),
+			AccessTokenLifespan:   config.GetGrantTypeAccessTokenLifespan("authorization_code"),
 			RefreshTokenLifespan:  config.GetRefreshTokenLifespan(),
 			IsRedirectURISecure:   config.GetRedirectSecureChecker(),
+
+			IssuanceHooks: config.GetTokenIssuanceHooks(),
 		},
 		ScopeStrategy: config.GetScopeStrategy(),
 		AuthorizeImplicitGrantTypeHandler: &oauth2.AuthorizeImplicitGrantTypeHandler{
 			AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
 			AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
-			AccessTokenLifespan: config.GetAccessTokenLifespan(),
-		},
-		IDTokenHandleHelper: &openid.IDTokenHandleHelper{
-			IDTokenStrategy: strategy.(openid.OpenIDConnectTokenStrategy),
+			AccessTokenLifespan: config.GetGrantTypeAccessTokenLifespan("implicit"),
+			Disabled:            config.DisableImplicitGrant,
+
+			IssuanceHooks: config.GetTokenIssuanceHooks(),
 		},
+		IDTokenHandleHelper:         newAuthorizeIDTokenHandleHelper(config, storage, strategy),
 		OpenIDConnectRequestStorage: storage.(openid.OpenIDConnectRequestStorage),
 		OpenIDConnectRequestValidator: openid.NewOpenIDConnectRequestValidator(config.AllowedPromptValues, strategy.(jwt.JWTStrategy)).
-			WithRedirectSecureChecker(config.GetRedirectSecureChecker()),
+			WithRedirectSecureChecker(config.GetRedirectSecureChecker()).
+			WithSilentAuthenticationStrategy(config.SilentAuthenticationStrategy).
+			WithACRStrategy(config.ACRStrategy).
+			WithIssuer(config.GetIDTokenIssuer()),
 		MinParameterEntropy: config.GetMinParameterEntropy(),
+		AllowMissingNonce:   config.AllowMissingNonce,
+	}
+}
+
+// OpenIDConnectCIBAFactory creates an OpenID Connect Client-Initiated Backchannel Authentication handler supporting
+// the poll mode. It handles both the backchannel authentication endpoint and the
+// "urn:openid:params:grant-type:ciba" grant at the token endpoint.
+func OpenIDConnectCIBAFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
+	return &openid.OpenIDConnectCIBAHandler{
+		Storage:              storage.(openid.CIBACoreStorage),
+		AuthReqIDStrategy:    strategy.(openid.AuthReqIDStrategy),
+		AccessTokenStrategy:  strategy.(oauth2.AccessTokenStrategy),
+		RefreshTokenStrategy: strategy.(oauth2.RefreshTokenStrategy),
+		IDTokenHintStrategy:  strategy.(jwt.JWTStrategy),
+		IDTokenHintIssuer:    config.GetIDTokenIssuer(),
+		IDTokenHandleHelper:  newIDTokenHandleHelper(config, strategy),
+		AuthReqIDLifespan:    config.GetCIBAAuthReqIDLifespan(),
+		PollingInterval:      config.GetCIBAPollingInterval(),
+		AccessTokenLifespan:  config.GetGrantTypeAccessTokenLifespan("urn:openid:params:grant-type:ciba"),
+		RefreshTokenLifespan: config.GetRefreshTokenLifespan(),
+		RefreshTokenScopes:   config.GetRefreshTokenScopes(),
+
+		IssuanceHooks: config.GetTokenIssuanceHooks(),
+	}
+}
+
+// OpenIDConnectBackChannelLogoutFactory creates an OpenID Connect back-channel logout handler, which sends logout
+// tokens signed by the JWT strategy to clients. The handler is not registered with the provider and has to be called
+// when end-user sessions end.
+func OpenIDConnectBackChannelLogoutFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
+	return &openid.OpenIDConnectBackChannelLogoutHandler{
+		LogoutTokenStrategy: &openid.DefaultLogoutTokenStrategy{
+			JWTStrategy: strategy.(jwt.JWTStrategy),
+			Issuer:      config.GetIDTokenIssuer(),
+		},
 	}
 }
@@ -34,5 +34,6 @@ func OAuth2PKCEFactory(config *Config, storage interface{}, strategy interface{}
 		Force:                      config.EnforcePKCE,
 		ForceForPublicClients:      config.EnforcePKCEForPublicClients,
 		EnablePlainChallengeMethod: config.EnablePKCEPlainChallengeMethod,
+		AllowedChallengeMethods:    config.AllowedPKCEMethods,
 	}
 }
@@ -13,14 +13,18 @@ func RFC7523AssertionGrantFactory(config *Config, storage interface{}, strategy
 		ScopeStrategy:            config.GetScopeStrategy(),
 		AudienceMatchingStrategy: config.GetAudienceStrategy(),
 		TokenURL:                 config.TokenURL,
+		AllowedAudiences:         config.GrantTypeJWTBearerAllowedAudiences,
 		SkipClientAuth:           config.GrantTypeJWTBearerCanSkipClientAuth,
 		JWTIDOptional:            config.GrantTypeJWTBearerIDOptional,
 		JWTIssuedDateOptional:    config.GrantTypeJWTBearerIssuedDateOptional,
 		JWTMaxDuration:           config.GetJWTMaxDuration(),
+		ClockSkew:                config.ClockSkew,
 		HandleHelper: &oauth2.HandleHelper{
 			AccessTokenStrategy: strategy.(oauth2.AccessTokenStrategy),
 			AccessTokenStorage:  storage.(oauth2.AccessTokenStorage),
-			AccessTokenLifespan: config.GetAccessTokenLifespan(),
+			AccessTokenLifespan: config.GetGrantTypeAccessTokenLifespan("urn:ietf:params:oauth:grant-type:jwt-bearer"),
+
+			IssuanceHooks: config.GetTokenIssuanceHooks(),
 		},
 	}
 }
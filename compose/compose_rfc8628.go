@@ -0,0 +1,56 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package compose
+
+import (
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/rfc8628"
+)
+
+// OAuth2DeviceAuthorizeFactory creates an OAuth2 device authorization endpoint handler as defined in
+// https://tools.ietf.org/html/rfc8628#section-3.1 and registers it.
+func OAuth2DeviceAuthorizeFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
+	return &rfc8628.DeviceAuthHandler{
+		DeviceCodeStrategy:        strategy.(rfc8628.RFC8628CodeStrategy),
+		Storage:                   storage.(rfc8628.DeviceCodeStorage),
+		DeviceAndUserCodeLifespan: config.GetDeviceAndUserCodeLifespan(),
+		PollingInterval:           config.GetDeviceAuthTokenPollingInterval(),
+		VerificationURI:           config.DeviceVerificationURL,
+	}
+}
+
+// OAuth2DeviceTokenFactory creates an OAuth2 device authorization grant token endpoint handler as defined in
+// https://tools.ietf.org/html/rfc8628#section-3.4 and registers it.
+func OAuth2DeviceTokenFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
+	return &rfc8628.DeviceCodeTokenHandler{
+		Storage:              storage.(rfc8628.RFC8628CoreStorage),
+		DeviceCodeStrategy:   strategy.(rfc8628.RFC8628CodeStrategy),
+		AccessTokenStrategy:  strategy.(oauth2.AccessTokenStrategy),
+		RefreshTokenStrategy: strategy.(oauth2.RefreshTokenStrategy),
+		AccessTokenLifespan:  config.GetGrantTypeAccessTokenLifespan("urn:ietf:params:oauth:grant-type:device_code"),
+		RefreshTokenLifespan: config.GetRefreshTokenLifespan(),
+		PollingInterval:      config.GetDeviceAuthTokenPollingInterval(),
+		RefreshTokenScopes:   config.GetRefreshTokenScopes(),
+
+		IssuanceHooks: config.GetTokenIssuanceHooks(),
+	}
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package compose
+
+import (
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/handler/rfc8693"
+	"github.com/ory/fosite/token/jwt"
+)
+
+// OAuth2TokenExchangeFactory creates an OAuth2 token exchange grant handler as defined in
+// https://tools.ietf.org/html/rfc8693 and registers it. ID tokens can be requested and used as subject or actor
+// tokens if the strategy implements the OpenID Connect token strategy and the JWT strategy. Access and refresh tokens
+// used as subject or actor tokens are validated like at the introspection endpoint.
+func OAuth2TokenExchangeFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
+	idTokenStrategy, _ := strategy.(openid.OpenIDConnectTokenStrategy)
+	jwtStrategy, _ := strategy.(jwt.JWTStrategy)
+	return &rfc8693.Handler{
+		Storage:                  storage.(rfc8693.TokenExchangeStorage),
+		AccessTokenStrategy:      strategy.(oauth2.AccessTokenStrategy),
+		RefreshTokenStrategy:     strategy.(oauth2.RefreshTokenStrategy),
+		IDTokenStrategy:          idTokenStrategy,
+		TokenValidator:           OAuth2TokenIntrospectionFactory(config, storage, strategy).(fosite.TokenIntrospector),
+		JWTStrategy:              jwtStrategy,
+		Issuer:                   config.GetIDTokenIssuer(),
+		ScopeStrategy:            config.GetScopeStrategy(),
+		AudienceMatchingStrategy: config.GetAudienceStrategy(),
+		ScopeValidator:           config.TokenExchangeScopeValidator,
+		AccessTokenLifespan:      config.GetGrantTypeAccessTokenLifespan("urn:ietf:params:oauth:grant-type:token-exchange"),
+		RefreshTokenLifespan:     config.GetRefreshTokenLifespan(),
+
+		IssuanceHooks: config.GetTokenIssuanceHooks(),
+	}
+}
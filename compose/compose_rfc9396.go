@@ -0,0 +1,33 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package compose
+
+import (
+	"github.com/ory/fosite/handler/rfc9396"
+)
+
+// OAuth2AuthorizationDetailsFactory creates a handler storing and returning the authorization details of rich
+// authorization requests as defined in https://tools.ietf.org/html/rfc9396 and registers it. It must be listed after
+// the grant factories.
+func OAuth2AuthorizationDetailsFactory(config *Config, storage interface{}, strategy interface{}) interface{} {
+	return &rfc9396.AuthorizationDetailsHandler{}
+}
@@ -23,10 +23,14 @@ package compose
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 
+	"gopkg.in/square/go-jose.v2"
+
 	"github.com/ory/fosite/handler/oauth2"
 	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/handler/rfc8628"
 	"github.com/ory/fosite/token/hmac"
 	"github.com/ory/fosite/token/jwt"
 )
@@ -35,6 +39,8 @@ type CommonStrategy struct {
 	oauth2.CoreStrategy
 	openid.OpenIDConnectTokenStrategy
 	jwt.JWTStrategy
+	rfc8628.RFC8628CodeStrategy
+	openid.AuthReqIDStrategy
 }
 
 func NewOAuth2HMACStrategy(config *Config, secret []byte, rotatedSecrets [][]byte) *oauth2.HMACSHAStrategy {
@@ -43,10 +49,44 @@ func NewOAuth2HMACStrategy(config *Config, secret []byte, rotatedSecrets [][]byt
 			GlobalSecret:         secret,
 			RotatedGlobalSecrets: rotatedSecrets,
 			TokenEntropy:         config.GetTokenEntropy(),
+			Hash:                 config.TokenHMACHash,
+			Encoding:             config.TokenEncoding,
+
+			AllowUnprefixedTokens: config.AllowUnprefixedTokens,
 		},
 		AccessTokenLifespan:   config.GetAccessTokenLifespan(),
 		AuthorizeCodeLifespan: config.GetAuthorizeCodeLifespan(),
 		RefreshTokenLifespan:  config.GetRefreshTokenLifespan(),
+
+		AccessTokenPrefix:   config.AccessTokenPrefix,
+		RefreshTokenPrefix:  config.RefreshTokenPrefix,
+		AuthorizeCodePrefix: config.AuthorizeCodePrefix,
+	}
+}
+
+func NewDeviceStrategy(config *Config, secret []byte) *rfc8628.DefaultDeviceStrategy {
+	return &rfc8628.DefaultDeviceStrategy{
+		Enigma: &hmac.HMACStrategy{
+			GlobalSecret:         secret,
+			RotatedGlobalSecrets: config.RotatedGlobalSecrets,
+			TokenEntropy:         config.GetTokenEntropy(),
+			Hash:                 config.TokenHMACHash,
+			Encoding:             config.TokenEncoding,
+		},
+		DeviceAndUserCodeLifespan: config.GetDeviceAndUserCodeLifespan(),
+	}
+}
+
+func NewOpenIDConnectCIBAStrategy(config *Config, secret []byte) *openid.DefaultAuthReqIDStrategy {
+	return &openid.DefaultAuthReqIDStrategy{
+		Enigma: &hmac.HMACStrategy{
+			GlobalSecret:         secret,
+			RotatedGlobalSecrets: config.RotatedGlobalSecrets,
+			TokenEntropy:         config.GetTokenEntropy(),
+			Hash:                 config.TokenHMACHash,
+			Encoding:             config.TokenEncoding,
+		},
+		AuthReqIDLifespan: config.GetCIBAAuthReqIDLifespan(),
 	}
 }
 
@@ -68,6 +108,37 @@ func NewOAuth2JWTECDSAStrategy(key *ecdsa.PrivateKey, strategy *oauth2.HMACSHASt
 	}
 }
 
+func NewOAuth2JWTEd25519Strategy(key ed25519.PrivateKey, strategy *oauth2.HMACSHAStrategy) *oauth2.DefaultJWTStrategy {
+	return &oauth2.DefaultJWTStrategy{
+		JWTStrategy: &jwt.Ed25519JWTStrategy{
+			PrivateKey: key,
+		},
+		HMACSHAStrategy: strategy,
+	}
+}
+
+// NewOAuth2JWTRSAPSSStrategy creates a JWT access token strategy using RSA-PSS. The algorithm is one of PS256, PS384
+// and PS512.
+func NewOAuth2JWTRSAPSSStrategy(key *rsa.PrivateKey, alg jose.SignatureAlgorithm, strategy *oauth2.HMACSHAStrategy) *oauth2.DefaultJWTStrategy {
+	return &oauth2.DefaultJWTStrategy{
+		JWTStrategy: &jwt.RSAPSSJWTStrategy{
+			PrivateKey: key,
+			Algorithm:  alg,
+		},
+		HMACSHAStrategy: strategy,
+	}
+}
+
+// NewOAuth2AccessTokenFormatStrategy creates a strategy which issues opaque or JWT access tokens depending on the
+// access token format of the client.
+func NewOAuth2AccessTokenFormatStrategy(config *Config, strategy *oauth2.HMACSHAStrategy, jwtStrategy *oauth2.DefaultJWTStrategy) *oauth2.AccessTokenFormatStrategy {
+	return &oauth2.AccessTokenFormatStrategy{
+		HMACSHAStrategy:          strategy,
+		JWTStrategy:              jwtStrategy,
+		DefaultAccessTokenFormat: config.AccessTokenFormat,
+	}
+}
+
 // Deprecated: Use NewOAuth2JWTStrategy(key, strategy).WithIssuer(issuer) instead.
 func NewOAuth2JWTStrategyWithIssuer(key *rsa.PrivateKey, strategy *oauth2.HMACSHAStrategy, issuer string) *oauth2.DefaultJWTStrategy {
 	return NewOAuth2JWTStrategy(key, strategy).WithIssuer(issuer)
@@ -82,10 +153,18 @@ func NewOpenIDConnectStrategy(config *Config, key *rsa.PrivateKey) *openid.Defau
 	return &openid.DefaultStrategy{
 		JWTStrategy: &jwt.RS256JWTStrategy{
 			PrivateKey: key,
+			ClockSkew:  config.ClockSkew,
+
+			AllowedAlgorithms: config.AllowedJWTSigningAlgorithms,
 		},
 		Expiry:              config.GetIDTokenLifespan(),
-		Issuer:              config.IDTokenIssuer,
+		Issuer:              config.GetIDTokenIssuer(),
 		MinParameterEntropy: config.GetMinParameterEntropy(),
+
+		ClaimsRequestStrategy:            config.ClaimsRequestStrategy,
+		RejectUnfulfilledEssentialClaims: config.RejectUnfulfilledEssentialClaims,
+		RejectUnsatisfiedACRValues:       config.RejectUnsatisfiedACRValues,
+		ACRStrategy:                      config.ACRStrategy,
 	}
 }
 
@@ -93,9 +172,90 @@ func NewOpenIDConnectECDSAStrategy(config *Config, key *ecdsa.PrivateKey) *openi
 	return &openid.DefaultStrategy{
 		JWTStrategy: &jwt.ES256JWTStrategy{
 			PrivateKey: key,
+			ClockSkew:  config.ClockSkew,
+
+			AllowedAlgorithms: config.AllowedJWTSigningAlgorithms,
+		},
+		Expiry:              config.GetIDTokenLifespan(),
+		Issuer:              config.GetIDTokenIssuer(),
+		MinParameterEntropy: config.GetMinParameterEntropy(),
+
+		ClaimsRequestStrategy:            config.ClaimsRequestStrategy,
+		RejectUnfulfilledEssentialClaims: config.RejectUnfulfilledEssentialClaims,
+		RejectUnsatisfiedACRValues:       config.RejectUnsatisfiedACRValues,
+		ACRStrategy:                      config.ACRStrategy,
+	}
+}
+
+func NewOpenIDConnectEd25519Strategy(config *Config, key ed25519.PrivateKey) *openid.DefaultStrategy {
+	return &openid.DefaultStrategy{
+		JWTStrategy: &jwt.Ed25519JWTStrategy{
+			PrivateKey: key,
+			ClockSkew:  config.ClockSkew,
+
+			AllowedAlgorithms: config.AllowedJWTSigningAlgorithms,
 		},
 		Expiry:              config.GetIDTokenLifespan(),
-		Issuer:              config.IDTokenIssuer,
+		Issuer:              config.GetIDTokenIssuer(),
 		MinParameterEntropy: config.GetMinParameterEntropy(),
+
+		ClaimsRequestStrategy:            config.ClaimsRequestStrategy,
+		RejectUnfulfilledEssentialClaims: config.RejectUnfulfilledEssentialClaims,
+		RejectUnsatisfiedACRValues:       config.RejectUnsatisfiedACRValues,
+		ACRStrategy:                      config.ACRStrategy,
+	}
+}
+
+// NewOpenIDConnectRSAPSSStrategy creates an ID token strategy using RSA-PSS. The algorithm is one of PS256, PS384 and
+// PS512.
+func NewOpenIDConnectRSAPSSStrategy(config *Config, key *rsa.PrivateKey, alg jose.SignatureAlgorithm) *openid.DefaultStrategy {
+	return &openid.DefaultStrategy{
+		JWTStrategy: &jwt.RSAPSSJWTStrategy{
+			PrivateKey: key,
+			Algorithm:  alg,
+			ClockSkew:  config.ClockSkew,
+
+			AllowedAlgorithms: config.AllowedJWTSigningAlgorithms,
+		},
+		Expiry:              config.GetIDTokenLifespan(),
+		Issuer:              config.GetIDTokenIssuer(),
+		MinParameterEntropy: config.GetMinParameterEntropy(),
+
+		ClaimsRequestStrategy:            config.ClaimsRequestStrategy,
+		RejectUnfulfilledEssentialClaims: config.RejectUnfulfilledEssentialClaims,
+		RejectUnsatisfiedACRValues:       config.RejectUnsatisfiedACRValues,
+		ACRStrategy:                      config.ACRStrategy,
+	}
+}
+
+// NewOpenIDConnectKeySetStrategy creates an ID token strategy signing ID tokens with the active key of the key set,
+// which makes it possible to rotate the signing key. The key set should be used as the JWTStrategy of the
+// CommonStrategy as well, so that ID token hints signed by any of its keys are accepted.
+func NewOpenIDConnectKeySetStrategy(config *Config, keys *jwt.KeySetJWTStrategy) *openid.DefaultStrategy {
+	return &openid.DefaultStrategy{
+		JWTStrategy:         keys,
+		Expiry:              config.GetIDTokenLifespan(),
+		Issuer:              config.GetIDTokenIssuer(),
+		MinParameterEntropy: config.GetMinParameterEntropy(),
+
+		ClaimsRequestStrategy:            config.ClaimsRequestStrategy,
+		RejectUnfulfilledEssentialClaims: config.RejectUnfulfilledEssentialClaims,
+		RejectUnsatisfiedACRValues:       config.RejectUnsatisfiedACRValues,
+		ACRStrategy:                      config.ACRStrategy,
+	}
+}
+
+// setJWTAccessTokenIssuer sets the issuer of the JWT access tokens issued by the strategy, unless it is set already.
+func setJWTAccessTokenIssuer(strategy oauth2.CoreStrategy, issuer string) {
+	var s *oauth2.DefaultJWTStrategy
+	switch c := strategy.(type) {
+	case *oauth2.DefaultJWTStrategy:
+		s = c
+	case *oauth2.AccessTokenFormatStrategy:
+		s = c.JWTStrategy
+	}
+
+	if s != nil && s.Issuer == "" {
+		s.Issuer = issuer
 	}
 }
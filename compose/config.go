@@ -22,32 +22,108 @@
 package compose
 
 import (
+	"crypto"
+	"net/http"
 	"net/url"
 	"time"
 
+	"gopkg.in/square/go-jose.v2"
+
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/handler/rfc8693"
+	"github.com/ory/fosite/token/hmac"
+	"github.com/ory/fosite/token/jwt"
 )
 
 type Config struct {
 	// AccessTokenLifespan sets how long an access token is going to be valid. Defaults to one hour.
 	AccessTokenLifespan time.Duration
 
+	// GrantTypeAccessTokenLifespans sets how long the access tokens issued by a grant type, for example
+	// "client_credentials", are going to be valid. Access tokens issued at the authorization endpoint belong to the
+	// "implicit" grant type. Grant types without a lifespan use AccessTokenLifespan. The lifespans a client configures
+	// through fosite.ClientWithCustomTokenLifespans take precedence.
+	GrantTypeAccessTokenLifespans map[string]time.Duration
+
 	// RefreshTokenLifespan sets how long a refresh token is going to be valid. Defaults to 30 days. Set to -1 for
 	// refresh tokens that never expire.
 	RefreshTokenLifespan time.Duration
 
+	// AbsoluteRefreshTokenLifespan sets for how long, counted from the original authorization grant, refresh tokens
+	// can be refreshed. Defaults to zero, which disables the limit.
+	AbsoluteRefreshTokenLifespan time.Duration
+
+	// MaxRefreshChainDepth sets how often a refresh token can be rotated. Requires sessions implementing
+	// fosite.RefreshChainSession. Defaults to zero, which disables the limit.
+	MaxRefreshChainDepth int
+
 	// AuthorizeCodeLifespan sets how long an authorize code is going to be valid. Defaults to fifteen minutes.
 	AuthorizeCodeLifespan time.Duration
 
 	// IDTokenLifespan sets the default id token lifetime. Defaults to one hour.
 	IDTokenLifespan time.Duration
 
-	// IDTokenIssuer sets the default issuer of the ID Token.
+	// EnforceNonceUniqueness rejects OpenID Connect authentication requests with a nonce the client has used before.
+	// The storage must implement openid.NonceStorage, used nonces are remembered for IDTokenLifespan. In the hybrid
+	// flow the nonce is consumed at the authorization endpoint, the ID Token issued when exchanging the authorization
+	// code carries the same nonce and is not affected. Defaults to false.
+	EnforceNonceUniqueness bool
+
+	// IDTokenHashStrategy selects the hash function of the "at_hash", "c_hash" and "s_hash" ID Token claims. Defaults
+	// to the hash function of the algorithm the ID Token is signed with.
+	IDTokenHashStrategy openid.IDTokenHashStrategy
+
+	// IDTokenIssuer sets the default issuer of the ID Token. Defaults to Issuer.
 	IDTokenIssuer string
 
-	// HashCost sets the cost of the password hashing cost. Defaults to 12.
+	// Issuer is the issuer identifier of the authorization server. It is the "iss" claim of ID tokens, JWT access
+	// tokens, JWT secured authorization responses and logout tokens unless set specifically, and the issuer
+	// id_token_hint values and request objects are validated against. Defaults to IDTokenIssuer.
+	Issuer string
+
+	// GlobalSecret is the secret HMAC based tokens are signed with, if ComposeAllEnabled is not given a secret. It must
+	// be at least 32 bytes long.
+	GlobalSecret []byte
+
+	// RotatedGlobalSecrets are previously used global secrets. Tokens signed with them remain valid, while new tokens
+	// are always signed with the current secret, which allows rotating the secret without invalidating live tokens.
+	RotatedGlobalSecrets [][]byte
+
+	// HashCost sets the bcrypt cost of the default ClientSecretHasher. Defaults to 12.
 	HashCost int
 
+	// ClientSecretHasher compares the client secrets presented during client authentication with the hashed secrets
+	// of the clients. Defaults to fosite.BCrypt using HashCost. It is used if Compose is called without a hasher.
+	ClientSecretHasher fosite.ClientSecretHasher
+
+	// ScopeDelimiters are the characters accepted as separators of scopes in requests, for example []string{" ", ","}
+	// for clients sending comma-separated scopes. Defaults to a space only.
+	ScopeDelimiters []string
+
+	// IncludeRequestedScopeInAccessResponse returns the requested scopes as "requested_scope" in access token
+	// responses, which tells clients which of them were not granted.
+	IncludeRequestedScopeInAccessResponse bool
+
+	// IntrospectionAuthorizer decides whether the authenticated caller of the introspection endpoint may introspect
+	// a token, for example only if the caller is part of the token's audience. If nil, any authenticated caller may
+	// introspect any token.
+	IntrospectionAuthorizer fosite.IntrospectionAuthorizer
+
+	// RestrictIntrospectionScopes lists the token types whose introspection responses only disclose the scopes
+	// visible to the caller. Defaults to none, which discloses all granted scopes.
+	RestrictIntrospectionScopes []fosite.TokenUse
+
+	// IntrospectionScopeFilter computes the scopes visible to the caller for the token types listed in
+	// RestrictIntrospectionScopes. If nil, the caller sees the granted scopes which are matched by the scopes of the
+	// access token it authenticated with, or by the scopes registered for it.
+	IntrospectionScopeFilter fosite.IntrospectionScopeFilter
+
+	// IntrospectionCacheMaxAge allows resource servers to cache the introspection responses of active tokens for up to
+	// the given duration, but never beyond the expiry of the token. Defaults to zero, which disables caching.
+	IntrospectionCacheMaxAge time.Duration
+
 	// DisableRefreshTokenValidation sets the introspection endpoint to disable refresh token validation.
 	DisableRefreshTokenValidation bool
 
@@ -60,8 +136,21 @@ type Config struct {
 	ScopeStrategy fosite.ScopeStrategy
 
 	// AudienceMatchingStrategy sets the audience matching strategy that should be supported, defaults to fosite.DefaultsAudienceMatchingStrategy.
+	// Use fosite.ExactAudienceMatchingStrategy for audiences which are not URIs, or fosite.WildcardAudienceMatchingStrategy
+	// to whitelist audiences like "https://api.example.com/*".
 	AudienceMatchingStrategy fosite.AudienceMatchingStrategy
 
+	// RedirectURIMatchingStrategy sets how requested redirect URIs are matched against the registered ones, defaults
+	// to fosite.LoopbackIPFlexibleRedirectURIMatchingStrategy, which allows native apps to use any port on loopback IP
+	// redirect URIs. Use fosite.ExactRedirectURIMatchingStrategy to compare redirect URIs as strings only. Clients may
+	// override it by implementing fosite.RedirectURIMatchingStrategyClient.
+	RedirectURIMatchingStrategy fosite.RedirectURIMatchingStrategy
+
+	// RedirectURISecurityPolicy, if set, rejects authorize requests with redirect URIs which do not use HTTPS, or HTTP
+	// for a loopback host, with invalid_request. It is also used by the handlers unless RedirectSecureChecker is set.
+	// Use fosite.RedirectURISecurityPolicy.ValidateClient to apply it when registering clients.
+	RedirectURISecurityPolicy *fosite.RedirectURISecurityPolicy
+
 	// EnforcePKCE, if set to true, requires clients to perform authorize code flows with PKCE. Defaults to false.
 	EnforcePKCE bool
 
@@ -71,11 +160,15 @@ type Config struct {
 	// EnablePKCEPlainChallengeMethod sets whether or not to allow the plain challenge method (S256 should be used whenever possible, plain is really discouraged). Defaults to false.
 	EnablePKCEPlainChallengeMethod bool
 
+	// AllowedPKCEMethods restricts the PKCE code challenge methods clients may use, for example to []string{"S256"}. If
+	// set, EnablePKCEPlainChallengeMethod is ignored.
+	AllowedPKCEMethods []string
+
 	// AllowedPromptValues sets which OpenID Connect prompt values the server supports. Defaults to []string{"login", "none", "consent", "select_account"}.
 	AllowedPromptValues []string
 
 	// TokenURL is the the URL of the Authorization Server's Token Endpoint. If the authorization server is intended
-	// to be compatible with the private_key_jwt client authentication method (see http://openid.net/specs/openid-connect-core-1_0.html#CodeFlowAuth),
+	// to be compatible with the private_key_jwt and client_secret_jwt client authentication methods (see http://openid.net/specs/openid-connect-core-1_0.html#CodeFlowAuth),
 	// this value MUST be set.
 	TokenURL string
 
@@ -83,23 +176,202 @@ type Config struct {
 	// client authentication method is used. Defaults to fosite.DefaultJWKSFetcherStrategy.
 	JWKSFetcher fosite.JWKSFetcherStrategy
 
-	// TokenEntropy indicates the entropy of the random string, used as the "message" part of the HMAC token.
-	// Defaults to 32.
+	// ClockSkew is tolerated when validating the "exp", "nbf" and "iat" claims of JWTs, for example client assertions,
+	// request objects, JWT access tokens and assertions of the JWT bearer grant. Tokens are accepted for ClockSkew
+	// after they expired and ClockSkew before they become valid. Defaults to zero.
+	ClockSkew time.Duration
+
+	// MaxScopes limits the number of scopes authorize and token requests may ask for. Requests asking for more
+	// scopes fail with invalid_request. Defaults to zero, which means unlimited.
+	MaxScopes int
+
+	// MaxAudiences limits the number of audiences and resource indicators authorize and token requests may ask for.
+	// Requests asking for more fail with invalid_target. Defaults to zero, which means unlimited.
+	MaxAudiences int
+
+	// MaxRequestBodySize limits the size in bytes of the bodies of authorize, pushed authorize and token requests.
+	// Defaults to fosite.DefaultMaxRequestBodySize.
+	MaxRequestBodySize int64
+
+	// MaxParameterLengths limits the length in bytes of request parameters such as scope, state, redirect_uri and
+	// request. Requests exceeding a limit fail with invalid_request. Defaults to fosite.DefaultMaxParameterLengths.
+	MaxParameterLengths map[string]int
+
+	// RequestContextExtractor populates the fosite.RequestContext of incoming requests from the HTTP request, for
+	// example with a tenant or trace identifier. Handlers and storage implementations can read it from the request.
+	// The tenant, see fosite.TenantRequestContextKey, is used for client lookups.
+	RequestContextExtractor fosite.RequestContextExtractor
+
+	// LoginHintValidator validates the format of the login_hint parameter of authorize requests. If nil, any
+	// login_hint is accepted.
+	LoginHintValidator fosite.LoginHintValidator
+
+	// ConsentStrategy decides which of the requested scopes and audiences of authorize requests are granted. If nil,
+	// the scopes and audiences granted by the caller of NewAuthorizeResponse are used. Use
+	// fosite.DefaultConsentStrategy to grant all requested scopes and audiences.
+	ConsentStrategy fosite.ConsentStrategy
+
+	// AllowedJWTSigningAlgorithms restricts the algorithms accepted for client assertions, request objects and
+	// id_token_hint values. The "none" algorithm is only accepted if it is listed. If empty, the algorithms are not
+	// restricted further.
+	AllowedJWTSigningAlgorithms []jose.SignatureAlgorithm
+
+	// AllowUnknownDisplayValues accepts display values of authorize requests which are not defined by OpenID Connect.
+	AllowUnknownDisplayValues bool
+
+	// JWKSFetcherHTTPClient is the HTTP client the default JWKSFetcherStrategy uses to fetch the JSON Web Key Sets
+	// registered as a client's jwks_uri. Defaults to http.DefaultClient.
+	JWKSFetcherHTTPClient *http.Client
+
+	// JWKSFetcherTimeout limits how long the default JWKSFetcherStrategy waits for a JSON Web Key Set. Defaults to
+	// the timeout of JWKSFetcherHTTPClient.
+	JWKSFetcherTimeout time.Duration
+
+	// JWKSFetcherCacheTTL sets how long the default JWKSFetcherStrategy caches fetched JSON Web Key Sets. Defaults to
+	// fosite.DefaultJWKSFetcherTTL.
+	JWKSFetcherCacheTTL time.Duration
+
+	// TokenEntropy indicates the entropy of the random string in bytes, used as the "message" part of HMAC based access
+	// tokens, refresh tokens, authorize codes, device codes and auth_req_ids. Defaults to 32. Values below 16 are
+	// raised to 16. Every additional 3 bytes make tokens 4 characters longer.
 	TokenEntropy int
 
+	// TokenHMACHash is the hash function of the HMAC signing access tokens, refresh tokens, authorize codes and other
+	// HMAC based tokens, for example crypto.SHA512. Defaults to crypto.SHA512_256. Changing it invalidates all
+	// existing tokens.
+	TokenHMACHash crypto.Hash
+
+	// TokenEncoding converts the message and signature of HMAC based tokens to text, for example hmac.Base62Encoding.
+	// Defaults to unpadded base64url. Changing it invalidates all existing tokens.
+	TokenEncoding hmac.TokenEncoding
+
+	// AccessTokenPrefix, RefreshTokenPrefix and AuthorizeCodePrefix are prepended to HMAC-SHA based access tokens,
+	// refresh tokens and authorize codes, for example "ory_at_", "ory_rt_" and "ory_ac_". This makes them
+	// recognizable for secret scanning tools.
+	AccessTokenPrefix   string
+	RefreshTokenPrefix  string
+	AuthorizeCodePrefix string
+
+	// AccessTokenFormat sets the format of access tokens issued to clients which did not register an access token
+	// format, either "opaque" or "jwt". Only used by NewOAuth2AccessTokenFormatStrategy. Defaults to "opaque".
+	AccessTokenFormat string
+
+	// AllowUnprefixedTokens accepts tokens without prefix, for example tokens which have been issued before the
+	// prefixes were configured.
+	AllowUnprefixedTokens bool
+
 	// RedirectSecureChecker is a function that returns true if the provided URL can be securely used as a redirect URL.
 	RedirectSecureChecker func(*url.URL) bool
 
 	// RefreshTokenScopes defines which OAuth scopes will be given refresh tokens during the authorization code grant exchange. This defaults to "offline" and "offline_access". When set to an empty array, all exchanges will be given refresh tokens.
 	RefreshTokenScopes []string
 
+	// EnforceOfflineAccess issues refresh tokens in the authorization code flow only if the offline_access scope has
+	// been granted to a client allowed offline access, see fosite.OfflineAccessClient. For OpenID Connect requests,
+	// prompt=consent is required as well. Otherwise the refresh token is withheld.
+	EnforceOfflineAccess bool
+
+	// RefreshTokenRotation defines how refresh tokens are rotated when they are used. One of "rotating",
+	// "rotating_with_reuse_detection" and "static". Defaults to "rotating_with_reuse_detection".
+	RefreshTokenRotation oauth2.RefreshTokenRotationPolicy
+
+	// RefreshTokenRotationGracePeriod sets for how long a rotated refresh token is still accepted. Defaults to zero.
+	RefreshTokenRotationGracePeriod time.Duration
+
+	// CodeReuseGracePeriod sets for how long a retry of an authorize code exchange with the same parameters is
+	// answered with the tokens issued before instead of revoking them. Requires a storage implementing
+	// fosite.AuthorizeCodeExchangeStorage. Defaults to zero.
+	CodeReuseGracePeriod time.Duration
+
+	// RevocationHook is called for every token revoked at the revocation endpoint, after it has been removed from the
+	// storage. For tokens revoked along with the presented one, it receives the request ID instead of the token value.
+	RevocationHook oauth2.RevocationHook
+
+	// EnableJWTAccessTokenDenylist makes the revocation endpoint record the jti of revoked JWT access tokens until
+	// they expire, and the stateless JWT introspection factories reject them. Requires a storage implementing
+	// oauth2.RevokedJTIStorage. If the storage implements oauth2.RevokedSubjectStorage as well, the JWT access tokens
+	// revoked by fosite.OAuth2Provider.RevokeTokensBySubject are rejected, too.
+	EnableJWTAccessTokenDenylist bool
+
+	// OnAccessTokenIssued and OnRefreshTokenIssued are called for every issued access and refresh token, after it
+	// has been persisted.
+	OnAccessTokenIssued  oauth2.TokenIssuedHook
+	OnRefreshTokenIssued oauth2.TokenIssuedHook
+
+	// StrictHooks makes the token issuance fail if OnAccessTokenIssued or OnRefreshTokenIssued return an error.
+	// Otherwise hook errors are ignored.
+	StrictHooks bool
+
+	// SessionValidator is called before a refresh token is honored at the token and introspection endpoints, and
+	// before an access token is introspected. It should return an error if the user session has ended.
+	SessionValidator oauth2.SessionValidator
+
 	// MinParameterEntropy controls the minimum size of state and nonce parameters. Defaults to fosite.MinParameterEntropy.
 	MinParameterEntropy int
 
+	// AllowMissingNonce accepts implicit and hybrid flow requests for an ID Token from the authorization endpoint
+	// without a nonce, which OpenID Connect requires. It should only be enabled for legacy clients. Defaults to false.
+	AllowMissingNonce bool
+
+	// MinStateLength rejects authorize requests of clients requiring the state parameter, see fosite.StateClient,
+	// whose state is shorter. Defaults to zero, which only requires these clients to send a state.
+	MinStateLength int
+
+	// PushedAuthorizeRequestLifespan sets how long a request_uri returned by the pushed authorization request endpoint
+	// is valid. Defaults to one minute.
+	PushedAuthorizeRequestLifespan time.Duration
+
+	// RequirePushedAuthorizationRequests forces all clients to use the pushed authorization request endpoint, which
+	// requires the OAuth2PARFactory.
+	RequirePushedAuthorizationRequests bool
+
+	// RevokePushedAuthorizeRequestsByClient makes fosite.OAuth2Provider.RevokeTokensByClient delete the pushed
+	// authorization requests of the client as well, which requires a storage implementing
+	// fosite.PARClientRevocationStorage.
+	RevokePushedAuthorizeRequestsByClient bool
+
+	// DisableImplicitGrant rejects the implicit grant, including the OpenID Connect implicit flow and hybrid flows
+	// issuing an access token from the authorization endpoint, even if their factories are composed. OAuth 2.1
+	// removes the implicit grant.
+	DisableImplicitGrant bool
+
+	// DisableResourceOwnerPasswordCredentialsGrant rejects the resource owner password credentials grant, even if its
+	// factory is composed. OAuth 2.1 removes the resource owner password credentials grant.
+	DisableResourceOwnerPasswordCredentialsGrant bool
+
+	// BearerTokenType is the token_type of bearer access tokens in token and authorize responses, either "Bearer" or
+	// "bearer". Defaults to "Bearer".
+	BearerTokenType string
+
+	// BearerRealm is the realm of the WWW-Authenticate challenge written for invalid bearer access tokens. If empty,
+	// the challenge carries no realm.
+	BearerRealm string
+
 	// UseLegacyErrorFormat controls whether the legacy error format (with `error_debug`, `error_hint`, ...)
 	// should be used or not.
 	UseLegacyErrorFormat bool
 
+	// ErrorWriteStrategy customizes the JSON error responses of the token, introspection, device authorization and
+	// backchannel authentication endpoints, for example to add a trace identifier. Defaults to
+	// fosite.DefaultErrorWriteStrategy.
+	ErrorWriteStrategy fosite.ErrorWriteStrategy
+
+	// ErrorHook is invoked with every error written to a client, including its debug details regardless of
+	// SendDebugMessagesToClients, for example to log it.
+	ErrorHook fosite.ErrorHook
+
+	// AuditLogger receives the outcome of authorize, token, introspection and revocation requests, for example to
+	// build a security audit trail. Defaults to fosite.NoopAuditLogger.
+	AuditLogger fosite.AuditLogger
+
+	// Observer receives measurements of authorize, token, introspection and revocation requests, for example to
+	// build Prometheus metrics. Defaults to fosite.NoopObserver.
+	Observer fosite.Observer
+
+	// Tracer is notified at the boundaries of authorize, token, introspection and revocation requests, for example to
+	// wrap them in OpenTelemetry spans.
+	Tracer fosite.Tracer
+
 	// GrantTypeJWTBearerCanSkipClientAuth indicates, if client authentication can be skipped, when using jwt as assertion.
 	GrantTypeJWTBearerCanSkipClientAuth bool
 
@@ -109,6 +381,10 @@ type Config struct {
 	// GrantTypeJWTBearerIssuedDateOptional indicates, if "iat" (issued at) claim required or not in JWT.
 	GrantTypeJWTBearerIssuedDateOptional bool
 
+	// GrantTypeJWTBearerAllowedAudiences are accepted as "aud" (audience) claim of JWT bearer assertions in addition to
+	// the TokenURL.
+	GrantTypeJWTBearerAllowedAudiences []string
+
 	// GrantTypeJWTBearerMaxDuration sets the maximum time after JWT issued date, during which the JWT is considered valid.
 	GrantTypeJWTBearerMaxDuration time.Duration
 
@@ -117,6 +393,112 @@ type Config struct {
 
 	// ResponseModeHandlerExtension provides a handler for custom response modes
 	ResponseModeHandlerExtension fosite.ResponseModeHandler
+
+	// ResponseModeHandlers registers handlers for additional response modes. They take precedence over the built-in
+	// query, fragment and form_post response modes.
+	ResponseModeHandlers []fosite.ResponseModeHandler
+
+	// DeviceAndUserCodeLifespan sets how long the device and user codes of the device authorization grant are going
+	// to be valid. Defaults to ten minutes.
+	DeviceAndUserCodeLifespan time.Duration
+
+	// DeviceAuthTokenPollingInterval sets the minimum amount of time a client has to wait between polling the token
+	// endpoint during the device authorization grant. Defaults to five seconds.
+	DeviceAuthTokenPollingInterval time.Duration
+
+	// DeviceVerificationURL is the end-user verification URI returned by the device authorization endpoint.
+	DeviceVerificationURL string
+
+	// CIBAAuthReqIDLifespan sets how long the request IDs of OpenID Connect Client-Initiated Backchannel
+	// Authentication are valid. Defaults to ten minutes.
+	CIBAAuthReqIDLifespan time.Duration
+
+	// CIBAPollingInterval sets the minimum amount of time a client has to wait between polling the token endpoint
+	// during OpenID Connect Client-Initiated Backchannel Authentication. Defaults to five seconds.
+	CIBAPollingInterval time.Duration
+
+	// TokenExchangeScopeValidator is an optional policy hook restricting which clients may impersonate a subject or
+	// act on its behalf when using the token exchange grant.
+	TokenExchangeScopeValidator rfc8693.TokenExchangeScopeValidator
+
+	// EnableMTLSClientAuthentication enables the tls_client_auth and self_signed_tls_client_auth client
+	// authentication methods. Clients using other methods are authenticated by the ClientAuthenticationStrategy.
+	EnableMTLSClientAuthentication bool
+
+	// TLSClientCertificate returns the client certificate of a request. Defaults to oauth2.DefaultClientCertificate,
+	// which reads the certificate from the TLS connection state.
+	TLSClientCertificate oauth2.ClientCertificateFunc
+
+	// DPoPProofMaxAge sets how long after its issuance a DPoP proof is accepted. Defaults to one minute.
+	DPoPProofMaxAge time.Duration
+
+	// DPoPRequestURL returns the URL a request was sent to, which DPoP proofs are checked against. This must be set
+	// if fosite runs behind a proxy. Defaults to a URL built from the request's TLS state, host and path.
+	DPoPRequestURL func(r *http.Request) *url.URL
+
+	// AuthorizationDetailsValidator validates the authorization details of rich authorization requests. Defaults to
+	// fosite.DefaultAuthorizationDetailsValidator, which checks the types registered for the client.
+	AuthorizationDetailsValidator fosite.AuthorizationDetailsValidator
+
+	// RequestURIAllowlist restricts the request_uri values request objects are fetched from to the given URL prefixes,
+	// in addition to the request URIs registered by the client. Scheme, host and path prefix are compared separately.
+	RequestURIAllowlist []string
+
+	// RequestURIFetchTimeout sets the timeout for fetching request objects from a request_uri. Defaults to ten seconds.
+	RequestURIFetchTimeout time.Duration
+
+	// RequestURIAllowedHosts restricts the hosts request objects are fetched from, including the targets of redirects.
+	// Defaults to all hosts.
+	RequestURIAllowedHosts []string
+
+	// RequestURIMaxResponseSize limits the size in bytes of request objects fetched from a request_uri. Defaults to
+	// 64 KiB.
+	RequestURIMaxResponseSize int64
+
+	// StorageTimeout limits how long each endpoint handler and client lookup may wait for the storage, which must
+	// respect the deadline of the context. Requests exceeding it fail with temporarily_unavailable instead of hanging.
+	// Defaults to no timeout.
+	StorageTimeout time.Duration
+
+	// RequestObjectDecryptionKey is the private key used to decrypt encrypted request objects.
+	RequestObjectDecryptionKey interface{}
+
+	// JWTSecuredAuthorizeResponseModeSigners sign the authorization responses of the JWT Secured Authorization Response
+	// Mode (JARM), keyed by the signing algorithm. The JARM response modes are only supported if a signer is set.
+	JWTSecuredAuthorizeResponseModeSigners map[string]jwt.JWTStrategy
+
+	// JWTSecuredAuthorizeResponseModeIssuer sets the "iss" claim of JWT secured authorization responses. Defaults to
+	// IDTokenIssuer.
+	JWTSecuredAuthorizeResponseModeIssuer string
+
+	// JWTSecuredAuthorizeResponseModeLifespan sets how long JWT secured authorization responses are valid. Defaults to
+	// ten minutes.
+	JWTSecuredAuthorizeResponseModeLifespan time.Duration
+
+	// IDTokenHintStrategy decodes the id_token_hint of OpenID Connect RP-Initiated Logout requests. Defaults to the
+	// JWTStrategy of the CommonStrategy passed to Compose.
+	IDTokenHintStrategy jwt.JWTStrategy
+
+	// ClaimsRequestStrategy decides which claims requested using the OpenID Connect claims parameter are added to
+	// id tokens. If nil, requested claims are not added.
+	ClaimsRequestStrategy openid.ClaimsRequestStrategy
+
+	// RejectUnfulfilledEssentialClaims makes id token generation fail if an essential claim requested using the claims
+	// parameter can not be fulfilled. By default such claims are omitted.
+	RejectUnfulfilledEssentialClaims bool
+
+	// RejectUnsatisfiedACRValues makes id token generation fail with login_required if the acr of the session is not
+	// one of the acr_values requested by the client. By default the id token is issued anyway.
+	RejectUnsatisfiedACRValues bool
+
+	// ACRStrategy decides whether the acr of the session satisfies the acr values requested using acr_values or an
+	// essential acr claim. If set, unsatisfied acr values are rejected at the authorization endpoint with a
+	// login_required error wrapping openid.ErrStepUpRequired, asking for step-up authentication.
+	ACRStrategy openid.ACRStrategy
+
+	// SilentAuthenticationStrategy reports whether the end-user is authenticated and consented when prompt=none is
+	// requested, which is translated into login_required, consent_required or interaction_required errors.
+	SilentAuthenticationStrategy openid.SilentAuthenticationStrategy
 }
 
 // GetScopeStrategy returns the scope strategy to be used. Defaults to glob scope strategy.
@@ -128,6 +510,15 @@ func (c *Config) GetScopeStrategy() fosite.ScopeStrategy {
 }
 
 // GetAudienceStrategy returns the scope strategy to be used. Defaults to glob scope strategy.
+// GetRedirectURIMatchingStrategy returns the redirect URI matching strategy. Defaults to matching loopback IP
+// redirect URIs with any port.
+func (c *Config) GetRedirectURIMatchingStrategy() fosite.RedirectURIMatchingStrategy {
+	if c.RedirectURIMatchingStrategy == nil {
+		return fosite.LoopbackIPFlexibleRedirectURIMatchingStrategy
+	}
+	return c.RedirectURIMatchingStrategy
+}
+
 func (c *Config) GetAudienceStrategy() fosite.AudienceMatchingStrategy {
 	if c.AudienceMatchingStrategy == nil {
 		c.AudienceMatchingStrategy = fosite.DefaultAudienceMatchingStrategy
@@ -151,6 +542,15 @@ func (c *Config) GetIDTokenLifespan() time.Duration {
 	return c.IDTokenLifespan
 }
 
+// GetIDTokenHashStrategy returns the strategy selecting the hash function of ID Token hash claims. Defaults to
+// openid.SigningAlgorithmHashStrategy using the signing algorithm of the ID Token strategy.
+func (c *Config) GetIDTokenHashStrategy(strategy interface{}) openid.IDTokenHashStrategy {
+	if c.IDTokenHashStrategy == nil {
+		return &openid.SigningAlgorithmHashStrategy{SigningAlgorithmProvider: idTokenSigningAlgorithmProvider(strategy)}
+	}
+	return c.IDTokenHashStrategy
+}
+
 // GetAccessTokenLifespan returns how long an access token should be valid. Defaults to one hour.
 func (c *Config) GetAccessTokenLifespan() time.Duration {
 	if c.AccessTokenLifespan == 0 {
@@ -159,6 +559,15 @@ func (c *Config) GetAccessTokenLifespan() time.Duration {
 	return c.AccessTokenLifespan
 }
 
+// GetGrantTypeAccessTokenLifespan returns how long an access token issued by the grant type should be valid. Defaults
+// to GetAccessTokenLifespan.
+func (c *Config) GetGrantTypeAccessTokenLifespan(grantType string) time.Duration {
+	if lifespan, ok := c.GrantTypeAccessTokenLifespans[grantType]; ok {
+		return lifespan
+	}
+	return c.GetAccessTokenLifespan()
+}
+
 // GetRefreshTokenLifespan sets how long a refresh token is going to be valid. Defaults to 30 days. Set to -1 for
 // refresh tokens that never expire.
 func (c *Config) GetRefreshTokenLifespan() time.Duration {
@@ -176,25 +585,54 @@ func (c *Config) GetHashCost() int {
 	return c.HashCost
 }
 
+// GetClientSecretHasher returns the ClientSecretHasher. Defaults to fosite.BCrypt using the configured HashCost.
+func (c *Config) GetClientSecretHasher() fosite.ClientSecretHasher {
+	if c.ClientSecretHasher == nil {
+		return &fosite.BCrypt{WorkFactor: c.GetHashCost()}
+	}
+	return c.ClientSecretHasher
+}
+
 // GetJWKSFetcherStrategy returns the JWKSFetcherStrategy.
 func (c *Config) GetJWKSFetcherStrategy() fosite.JWKSFetcherStrategy {
 	if c.JWKSFetcher == nil {
-		c.JWKSFetcher = fosite.NewDefaultJWKSFetcherStrategy()
+		var opts []fosite.JWKSFetcherOption
+		if c.JWKSFetcherHTTPClient != nil || c.JWKSFetcherTimeout > 0 {
+			client := new(http.Client)
+			if c.JWKSFetcherHTTPClient != nil {
+				*client = *c.JWKSFetcherHTTPClient
+			}
+			if c.JWKSFetcherTimeout > 0 {
+				client.Timeout = c.JWKSFetcherTimeout
+			}
+			opts = append(opts, fosite.JWKSFetcherWithHTTPClient(client))
+		}
+		if c.JWKSFetcherCacheTTL > 0 {
+			opts = append(opts, fosite.JWKSFetcherWithTTL(c.JWKSFetcherCacheTTL))
+		}
+		c.JWKSFetcher = fosite.NewDefaultJWKSFetcherStrategy(opts...)
 	}
 	return c.JWKSFetcher
 }
 
-// GetTokenEntropy returns the entropy of the "message" part of a HMAC Token. Defaults to 32.
+// GetTokenEntropy returns the entropy of the "message" part of a HMAC Token. Defaults to 32 and is at least
+// hmac.MinimumTokenEntropy, so that the strategies created by compose never fail to generate tokens.
 func (c *Config) GetTokenEntropy() int {
 	if c.TokenEntropy == 0 {
-		return 32
+		return hmac.DefaultTokenEntropy
+	} else if c.TokenEntropy < hmac.MinimumTokenEntropy {
+		return hmac.MinimumTokenEntropy
 	}
 	return c.TokenEntropy
 }
 
-// GetRedirectSecureChecker returns the checker to check if redirect URI is secure. Defaults to fosite.IsRedirectURISecure.
+// GetRedirectSecureChecker returns the checker to check if redirect URI is secure. Defaults to the
+// RedirectURISecurityPolicy if set, and fosite.IsRedirectURISecure otherwise.
 func (c *Config) GetRedirectSecureChecker() func(*url.URL) bool {
 	if c.RedirectSecureChecker == nil {
+		if c.RedirectURISecurityPolicy != nil {
+			return c.RedirectURISecurityPolicy.IsSecure
+		}
 		return fosite.IsRedirectURISecure
 	}
 	return c.RedirectSecureChecker
@@ -208,6 +646,18 @@ func (c *Config) GetRefreshTokenScopes() []string {
 	return c.RefreshTokenScopes
 }
 
+// GetTokenIssuanceHooks returns the token issuance hooks, or nil if none are configured.
+func (c *Config) GetTokenIssuanceHooks() *oauth2.TokenIssuanceHooks {
+	if c.OnAccessTokenIssued == nil && c.OnRefreshTokenIssued == nil {
+		return nil
+	}
+	return &oauth2.TokenIssuanceHooks{
+		OnAccessTokenIssued:  c.OnAccessTokenIssued,
+		OnRefreshTokenIssued: c.OnRefreshTokenIssued,
+		StrictHooks:          c.StrictHooks,
+	}
+}
+
 // GetMinParameterEntropy returns MinParameterEntropy if set. Defaults to fosite.MinParameterEntropy.
 func (c *Config) GetMinParameterEntropy() int {
 	if c.MinParameterEntropy == 0 {
@@ -235,3 +685,104 @@ func (c *Config) GetJWTMaxDuration() time.Duration {
 func (c *Config) GetClientAuthenticationStrategy() fosite.ClientAuthenticationStrategy {
 	return c.ClientAuthenticationStrategy
 }
+
+// GetDeviceAndUserCodeLifespan returns how long the device and user codes should be valid. Defaults to ten minutes.
+func (c *Config) GetDeviceAndUserCodeLifespan() time.Duration {
+	if c.DeviceAndUserCodeLifespan == 0 {
+		return time.Minute * 10
+	}
+	return c.DeviceAndUserCodeLifespan
+}
+
+// GetDeviceAuthTokenPollingInterval returns the minimum polling interval of the device authorization grant.
+// Defaults to five seconds.
+func (c *Config) GetDeviceAuthTokenPollingInterval() time.Duration {
+	if c.DeviceAuthTokenPollingInterval == 0 {
+		return time.Second * 5
+	}
+	return c.DeviceAuthTokenPollingInterval
+}
+
+// GetCIBAAuthReqIDLifespan returns how long backchannel authentication request IDs should be valid. Defaults to ten
+// minutes.
+func (c *Config) GetCIBAAuthReqIDLifespan() time.Duration {
+	if c.CIBAAuthReqIDLifespan == 0 {
+		return time.Minute * 10
+	}
+	return c.CIBAAuthReqIDLifespan
+}
+
+// GetCIBAPollingInterval returns the minimum polling interval of OpenID Connect Client-Initiated Backchannel
+// Authentication. Defaults to five seconds.
+func (c *Config) GetCIBAPollingInterval() time.Duration {
+	if c.CIBAPollingInterval == 0 {
+		return time.Second * 5
+	}
+	return c.CIBAPollingInterval
+}
+
+// GetTLSClientCertificate returns the function used to read the client certificate from a request.
+// Defaults to oauth2.DefaultClientCertificate.
+func (c *Config) GetTLSClientCertificate() oauth2.ClientCertificateFunc {
+	if c.TLSClientCertificate == nil {
+		return oauth2.DefaultClientCertificate
+	}
+	return c.TLSClientCertificate
+}
+
+// GetDPoPProofMaxAge returns how long after its issuance a DPoP proof is accepted. Defaults to one minute.
+func (c *Config) GetDPoPProofMaxAge() time.Duration {
+	if c.DPoPProofMaxAge == 0 {
+		return time.Minute
+	}
+	return c.DPoPProofMaxAge
+}
+
+// GetJWTSecuredAuthorizeResponseModeIssuer returns the issuer of JWT secured authorization responses. Defaults to
+// GetIssuer().
+func (c *Config) GetJWTSecuredAuthorizeResponseModeIssuer() string {
+	if c.JWTSecuredAuthorizeResponseModeIssuer == "" {
+		return c.GetIssuer()
+	}
+	return c.JWTSecuredAuthorizeResponseModeIssuer
+}
+
+// GetAuditLogger returns the audit logger. Defaults to fosite.NoopAuditLogger.
+func (c *Config) GetAuditLogger() fosite.AuditLogger {
+	if c.AuditLogger == nil {
+		return &fosite.NoopAuditLogger{}
+	}
+	return c.AuditLogger
+}
+
+// GetObserver returns the observer. Defaults to fosite.NoopObserver.
+func (c *Config) GetObserver() fosite.Observer {
+	if c.Observer == nil {
+		return &fosite.NoopObserver{}
+	}
+	return c.Observer
+}
+
+// GetPushedAuthorizeRequestLifespan returns how long a pushed authorization request is valid. Defaults to one minute.
+func (c *Config) GetPushedAuthorizeRequestLifespan() time.Duration {
+	if c.PushedAuthorizeRequestLifespan == 0 {
+		return time.Minute
+	}
+	return c.PushedAuthorizeRequestLifespan
+}
+
+// GetIssuer returns the issuer identifier of the authorization server. Defaults to IDTokenIssuer.
+func (c *Config) GetIssuer() string {
+	if c.Issuer == "" {
+		return c.IDTokenIssuer
+	}
+	return c.Issuer
+}
+
+// GetIDTokenIssuer returns the issuer of ID tokens. Defaults to Issuer.
+func (c *Config) GetIDTokenIssuer() string {
+	if c.IDTokenIssuer == "" {
+		return c.Issuer
+	}
+	return c.IDTokenIssuer
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package compose
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/jwt"
+)
+
+func TestGrantTypeAccessTokenLifespans(t *testing.T) {
+	config := &Config{
+		AccessTokenLifespan: time.Hour,
+		GrantTypeAccessTokenLifespans: map[string]time.Duration{
+			"client_credentials": 10 * time.Minute,
+		},
+	}
+	assert.Equal(t, 10*time.Minute, config.GetGrantTypeAccessTokenLifespan("client_credentials"))
+	assert.Equal(t, time.Hour, config.GetGrantTypeAccessTokenLifespan("authorization_code"))
+
+	strategy := NewOAuth2HMACStrategy(config, []byte("some-secret-thats-random-some-secret-thats-random-"), nil)
+	h := OAuth2ClientCredentialsGrantFactory(config, storage.NewMemoryStore(), strategy).(*oauth2.ClientCredentialsGrantHandler)
+
+	for k, c := range []struct {
+		lifespans *fosite.ClientLifespanConfig
+		expected  time.Duration
+	}{
+		{
+			// The grant type lifespan of the authorization server takes precedence over the global lifespan.
+			expected: 10 * time.Minute,
+		},
+		{
+			// The default lifespan of the client takes precedence over the grant type lifespan of the authorization
+			// server.
+			lifespans: &fosite.ClientLifespanConfig{
+				Default: fosite.TokenLifespans{fosite.AccessToken: 5 * time.Minute},
+			},
+			expected: 5 * time.Minute,
+		},
+		{
+			// The grant type lifespan of the client takes precedence over all other lifespans.
+			lifespans: &fosite.ClientLifespanConfig{
+				Default:    fosite.TokenLifespans{fosite.AccessToken: 5 * time.Minute},
+				GrantTypes: map[string]fosite.TokenLifespans{"client_credentials": {fosite.AccessToken: time.Minute}},
+			},
+			expected: time.Minute,
+		},
+		{
+			// Lifespans of other grant types are ignored.
+			lifespans: &fosite.ClientLifespanConfig{
+				GrantTypes: map[string]fosite.TokenLifespans{"refresh_token": {fosite.AccessToken: time.Minute}},
+			},
+			expected: 10 * time.Minute,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			areq := fosite.NewAccessRequest(new(fosite.DefaultSession))
+			areq.GrantTypes = fosite.Arguments{"client_credentials"}
+			areq.Client = &fosite.DefaultClient{
+				GrantTypes:     fosite.Arguments{"client_credentials"},
+				TokenLifespans: c.lifespans,
+			}
+
+			require.NoError(t, h.HandleTokenEndpointRequest(context.Background(), areq))
+			assert.WithinDuration(t, time.Now().UTC().Add(c.expected), areq.GetSession().GetExpiresAt(fosite.AccessToken), 5*time.Second)
+		})
+	}
+}
+
+func TestIssuer(t *testing.T) {
+	key := internal.MustRSAKey()
+	config := &Config{Issuer: "https://auth.example.com"}
+	assert.Equal(t, "https://auth.example.com", config.GetIDTokenIssuer())
+	assert.Equal(t, "https://auth.example.com", config.GetJWTSecuredAuthorizeResponseModeIssuer())
+
+	hmacStrategy := NewOAuth2HMACStrategy(config, []byte("some-secret-thats-random-some-secret-thats-random-"), nil)
+	strategy := &CommonStrategy{
+		CoreStrategy:               NewOAuth2JWTStrategy(key, hmacStrategy),
+		OpenIDConnectTokenStrategy: NewOpenIDConnectStrategy(config, key),
+		JWTStrategy:                &jwt.RS256JWTStrategy{PrivateKey: key},
+	}
+	f := Compose(config, storage.NewMemoryStore(), strategy, nil).(*fosite.Fosite)
+	assert.Equal(t, "https://auth.example.com", f.Issuer)
+	assert.Equal(t, "https://auth.example.com", strategy.CoreStrategy.(*oauth2.DefaultJWTStrategy).Issuer)
+
+	idToken, err := strategy.GenerateIDToken(context.Background(), &fosite.AccessRequest{Request: fosite.Request{
+		Client: &fosite.DefaultClient{ID: "foo"},
+		Form:   url.Values{},
+		Session: &openid.DefaultSession{Claims: &jwt.IDTokenClaims{
+			Subject:     "peter",
+			RequestedAt: time.Now().UTC(),
+			AuthTime:    time.Now().UTC(),
+		}},
+	}})
+	require.NoError(t, err)
+
+	decoded, err := strategy.JWTStrategy.Decode(context.Background(), idToken)
+	require.NoError(t, err)
+	assert.Equal(t, "https://auth.example.com", decoded.Claims["iss"])
+
+	// The id_token_hint must have been issued by the configured issuer.
+	validator := OpenIDConnectExplicitFactory(config, storage.NewMemoryStore(), strategy).(*openid.OpenIDConnectExplicitHandler).OpenIDConnectRequestValidator
+	other, _, err := strategy.JWTStrategy.Generate(context.Background(), jwt.MapClaims{"iss": "https://other.example.com", "sub": "peter"}, jwt.NewHeaders())
+	require.NoError(t, err)
+	for hint, valid := range map[string]bool{idToken: true, other: false} {
+		err := validator.ValidatePrompt(context.Background(), &fosite.AuthorizeRequest{
+			RedirectURI: &url.URL{Scheme: "https", Host: "foo.example.com"},
+			Request: fosite.Request{
+				Client:  &fosite.DefaultClient{ID: "foo"},
+				Form:    url.Values{"id_token_hint": {hint}},
+				Session: &openid.DefaultSession{Claims: &jwt.IDTokenClaims{Subject: "peter", RequestedAt: time.Now().UTC(), AuthTime: time.Now().UTC()}},
+			},
+		})
+		if valid {
+			assert.NoError(t, err)
+		} else {
+			assert.True(t, errors.Is(err, fosite.ErrInvalidRequest), "%+v", err)
+		}
+	}
+}
+
+func TestTokenEntropy(t *testing.T) {
+	secret := []byte("some-secret-thats-random-some-secret-thats-random-")
+	for k, c := range []struct {
+		entropy  int
+		expected int
+	}{
+		{entropy: 0, expected: 32},
+		{entropy: 8, expected: 16},
+		{entropy: -1, expected: 16},
+		{entropy: 16, expected: 16},
+		{entropy: 64, expected: 64},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			config := &Config{TokenEntropy: c.entropy}
+			assert.Equal(t, c.expected, config.GetTokenEntropy())
+
+			_, _, err := NewOAuth2HMACStrategy(config, secret, nil).GenerateAccessToken(context.Background(), nil)
+			require.NoError(t, err)
+			_, _, err = NewDeviceStrategy(config, secret).GenerateDeviceCode(context.Background(), nil)
+			require.NoError(t, err)
+			_, _, err = NewOpenIDConnectCIBAStrategy(config, secret).GenerateAuthReqID(context.Background(), nil)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestIDTokenHashStrategy(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	config := &Config{}
+	secret := []byte("some-secret-thats-random-some-secret-thats-random-")
+	strategy := &CommonStrategy{
+		CoreStrategy:               NewOAuth2HMACStrategy(config, secret, nil),
+		OpenIDConnectTokenStrategy: &openid.DefaultStrategy{JWTStrategy: &jwt.Ed25519JWTStrategy{PrivateKey: key}},
+		JWTStrategy:                &jwt.Ed25519JWTStrategy{PrivateKey: key},
+		AuthReqIDStrategy:          NewOpenIDConnectCIBAStrategy(config, secret),
+	}
+	request := &fosite.Request{Client: &fosite.DefaultClient{}}
+
+	for k, helper := range []*openid.IDTokenHandleHelper{
+		OpenIDConnectExplicitFactory(config, storage.NewMemoryStore(), strategy).(*openid.OpenIDConnectExplicitHandler).IDTokenHandleHelper,
+		OpenIDConnectRefreshFactory(config, storage.NewMemoryStore(), strategy).(*openid.OpenIDConnectRefreshHandler).IDTokenHandleHelper,
+		OpenIDConnectCIBAFactory(config, storage.NewMemoryStore(), strategy).(*openid.OpenIDConnectCIBAHandler).IDTokenHandleHelper,
+	} {
+		require.NotNil(t, helper.HashStrategy, "%d", k)
+		h, err := helper.HashStrategy.GetIDTokenHash(context.Background(), request)
+		require.NoError(t, err, "%d", k)
+		assert.Equal(t, crypto.SHA512, h, "%d", k)
+	}
+
+	fixed := &openid.FixedHashStrategy{Hash: crypto.SHA384}
+	assert.Equal(t, fixed, (&Config{IDTokenHashStrategy: fixed}).GetIDTokenHashStrategy(strategy))
+}
@@ -0,0 +1,277 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package compose
+
+import (
+	"sort"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/handler/par"
+	"github.com/ory/fosite/handler/pkce"
+	"github.com/ory/fosite/handler/rfc7523"
+	"github.com/ory/fosite/handler/rfc8628"
+	"github.com/ory/fosite/handler/rfc8693"
+	"github.com/ory/fosite/token/jwt"
+)
+
+// DiscoveryEndpoints holds the URLs at which the endpoints of the authorization server are served. Endpoints which
+// are left empty are omitted from the metadata document.
+type DiscoveryEndpoints struct {
+	// Issuer defaults to Config.GetIssuer().
+	Issuer string
+
+	AuthorizationEndpoint string
+
+	// TokenEndpoint defaults to Config.TokenURL.
+	TokenEndpoint string
+
+	UserinfoEndpoint                   string
+	JWKSURI                            string
+	IntrospectionEndpoint              string
+	RevocationEndpoint                 string
+	DeviceAuthorizationEndpoint        string
+	PushedAuthorizationRequestEndpoint string
+	BackchannelAuthenticationEndpoint  string
+	EndSessionEndpoint                 string
+}
+
+// ProviderMetadata is the OAuth 2.0 Authorization Server Metadata (https://tools.ietf.org/html/rfc8414#section-2)
+// and OpenID Provider Metadata (https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata)
+// document, typically served at /.well-known/openid-configuration or /.well-known/oauth-authorization-server.
+type ProviderMetadata struct {
+	Issuer                                     string   `json:"issuer"`
+	AuthorizationEndpoint                      string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                              string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                           string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI                                    string   `json:"jwks_uri,omitempty"`
+	IntrospectionEndpoint                      string   `json:"introspection_endpoint,omitempty"`
+	RevocationEndpoint                         string   `json:"revocation_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint                string   `json:"device_authorization_endpoint,omitempty"`
+	PushedAuthorizationRequestEndpoint         string   `json:"pushed_authorization_request_endpoint,omitempty"`
+	RequirePushedAuthorizationRequests         bool     `json:"require_pushed_authorization_requests,omitempty"`
+	BackchannelAuthenticationEndpoint          string   `json:"backchannel_authentication_endpoint,omitempty"`
+	BackchannelTokenDeliveryModesSupported     []string `json:"backchannel_token_delivery_modes_supported,omitempty"`
+	EndSessionEndpoint                         string   `json:"end_session_endpoint,omitempty"`
+	ScopesSupported                            []string `json:"scopes_supported,omitempty"`
+	ResponseTypesSupported                     []string `json:"response_types_supported"`
+	ResponseModesSupported                     []string `json:"response_modes_supported,omitempty"`
+	GrantTypesSupported                        []string `json:"grant_types_supported,omitempty"`
+	SubjectTypesSupported                      []string `json:"subject_types_supported,omitempty"`
+	IDTokenSigningAlgValuesSupported           []string `json:"id_token_signing_alg_values_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	CodeChallengeMethodsSupported              []string `json:"code_challenge_methods_supported,omitempty"`
+	TLSClientCertificateBoundAccessTokens      bool     `json:"tls_client_certificate_bound_access_tokens,omitempty"`
+	AuthorizationResponseIssParameterSupported bool     `json:"authorization_response_iss_parameter_supported,omitempty"`
+}
+
+// DiscoveryMetadata builds the metadata document of an OAuth2Provider created by Compose, using the same config and
+// strategy. Grant types, response types and code challenge methods are derived from the handlers actually
+// registered with the provider, so the document only advertises what the provider supports.
+func DiscoveryMetadata(config *Config, provider fosite.OAuth2Provider, strategy interface{}, endpoints DiscoveryEndpoints) *ProviderMetadata {
+	m := &ProviderMetadata{
+		Issuer:                             endpoints.Issuer,
+		AuthorizationEndpoint:              endpoints.AuthorizationEndpoint,
+		TokenEndpoint:                      endpoints.TokenEndpoint,
+		UserinfoEndpoint:                   endpoints.UserinfoEndpoint,
+		JWKSURI:                            endpoints.JWKSURI,
+		IntrospectionEndpoint:              endpoints.IntrospectionEndpoint,
+		RevocationEndpoint:                 endpoints.RevocationEndpoint,
+		DeviceAuthorizationEndpoint:        endpoints.DeviceAuthorizationEndpoint,
+		PushedAuthorizationRequestEndpoint: endpoints.PushedAuthorizationRequestEndpoint,
+		BackchannelAuthenticationEndpoint:  endpoints.BackchannelAuthenticationEndpoint,
+		EndSessionEndpoint:                 endpoints.EndSessionEndpoint,
+		ResponseTypesSupported:             []string{},
+		TokenEndpointAuthMethodsSupported:  []string{"client_secret_basic", "client_secret_post", "private_key_jwt", "client_secret_jwt", "none"},
+	}
+	if m.Issuer == "" {
+		m.Issuer = config.GetIssuer()
+	}
+	if m.TokenEndpoint == "" {
+		m.TokenEndpoint = config.TokenURL
+	}
+	if config.EnableMTLSClientAuthentication {
+		m.TokenEndpointAuthMethodsSupported = append(m.TokenEndpointAuthMethodsSupported, "tls_client_auth", "self_signed_tls_client_auth")
+		m.TLSClientCertificateBoundAccessTokens = true
+	}
+
+	f, ok := provider.(*fosite.Fosite)
+	if !ok {
+		return m
+	}
+
+	var grantTypes, responseTypes, scopes, challengeMethods fosite.Arguments
+	add := func(to *fosite.Arguments, values ...string) {
+		for _, v := range values {
+			if !to.Has(v) {
+				*to = append(*to, v)
+			}
+		}
+	}
+
+	var handlers []interface{}
+	for _, h := range f.AuthorizeEndpointHandlers {
+		handlers = append(handlers, h)
+	}
+	for _, h := range f.TokenEndpointHandlers {
+		handlers = append(handlers, h)
+	}
+	for _, h := range f.DeviceEndpointHandlers {
+		handlers = append(handlers, h)
+	}
+	for _, h := range f.BackchannelAuthenticationEndpointHandlers {
+		handlers = append(handlers, h)
+	}
+	for _, h := range f.PushedAuthorizeEndpointHandlers {
+		handlers = append(handlers, h)
+	}
+
+	for _, h := range handlers {
+		switch h := h.(type) {
+		case *oauth2.AuthorizeExplicitGrantHandler:
+			add(&responseTypes, "code")
+			add(&grantTypes, "authorization_code")
+		case *oauth2.AuthorizeImplicitGrantTypeHandler:
+			if !h.Disabled {
+				add(&responseTypes, "token")
+				add(&grantTypes, "implicit")
+			}
+		case *oauth2.ClientCredentialsGrantHandler:
+			add(&grantTypes, "client_credentials")
+		case *oauth2.RefreshTokenGrantHandler:
+			add(&grantTypes, "refresh_token")
+		case *oauth2.ResourceOwnerPasswordCredentialsGrantHandler:
+			if !h.Disabled {
+				add(&grantTypes, "password")
+			}
+		case *rfc7523.Handler:
+			add(&grantTypes, "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		case *rfc8628.DeviceCodeTokenHandler:
+			add(&grantTypes, "urn:ietf:params:oauth:grant-type:device_code")
+		case *rfc8693.Handler:
+			add(&grantTypes, "urn:ietf:params:oauth:grant-type:token-exchange")
+		case *openid.OpenIDConnectExplicitHandler:
+			add(&scopes, "openid")
+		case *openid.OpenIDConnectImplicitHandler:
+			add(&scopes, "openid")
+			// The OpenID Connect implicit flow rejects both of its response types if the implicit grant is disabled.
+			if !h.AuthorizeImplicitGrantTypeHandler.Disabled {
+				add(&responseTypes, "id_token", "id_token token")
+			}
+		case *openid.OpenIDConnectHybridHandler:
+			add(&scopes, "openid")
+			add(&responseTypes, "code id_token")
+			if !h.AuthorizeImplicitGrantTypeHandler.Disabled {
+				add(&responseTypes, "code token", "code id_token token")
+			}
+		case *openid.OpenIDConnectCIBAHandler:
+			add(&scopes, "openid")
+			add(&grantTypes, "urn:openid:params:grant-type:ciba")
+			m.BackchannelTokenDeliveryModesSupported = []string{"poll"}
+		case *pkce.Handler:
+			if len(h.AllowedChallengeMethods) > 0 {
+				add(&challengeMethods, h.AllowedChallengeMethods...)
+			} else {
+				add(&challengeMethods, "S256")
+				if h.EnablePlainChallengeMethod {
+					add(&challengeMethods, "plain")
+				}
+			}
+		case *par.PushedAuthorizeHandler:
+			m.RequirePushedAuthorizationRequests = f.RequirePushedAuthorizationRequests
+		}
+	}
+
+	m.GrantTypesSupported = grantTypes
+	m.ResponseTypesSupported = append(m.ResponseTypesSupported, responseTypes...)
+	m.ScopesSupported = scopes
+	m.CodeChallengeMethodsSupported = challengeMethods
+
+	if scopes.Has("openid") {
+		m.SubjectTypesSupported = []string{"public"}
+		m.IDTokenSigningAlgValuesSupported = idTokenSigningAlgorithms(strategy)
+	}
+
+	if len(responseTypes) > 0 {
+		m.ResponseModesSupported = []string{"query", "fragment", "form_post"}
+		if len(config.JWTSecuredAuthorizeResponseModeSigners) > 0 {
+			m.ResponseModesSupported = append(m.ResponseModesSupported, "jwt", "query.jwt", "fragment.jwt", "form_post.jwt")
+			m.AuthorizationResponseIssParameterSupported = true
+		}
+		modes := fosite.Arguments(m.ResponseModesSupported)
+		custom := append([]fosite.ResponseModeHandler{}, f.ResponseModeHandlers...)
+		if f.ResponseModeHandlerExtension != nil {
+			custom = append(custom, f.ResponseModeHandlerExtension)
+		}
+		for _, h := range custom {
+			for _, rm := range h.ResponseModes() {
+				if rm != fosite.ResponseModeDefault {
+					add(&modes, string(rm))
+				}
+			}
+		}
+		m.ResponseModesSupported = modes
+	}
+
+	return m
+}
+
+// idTokenSigningAlgorithms returns the algorithms the OpenID Connect token strategy signs ID tokens with.
+func idTokenSigningAlgorithms(strategy interface{}) []string {
+	s := idTokenDefaultStrategy(strategy)
+	if s == nil {
+		return nil
+	}
+
+	var algs fosite.Arguments
+	if p, ok := s.JWTStrategy.(jwt.SigningAlgorithmProvider); ok {
+		algs = append(algs, p.GetSigningAlgorithm())
+	}
+	var signers []string
+	for alg := range s.IDTokenSigners {
+		if !algs.Has(alg) {
+			signers = append(signers, alg)
+		}
+	}
+	sort.Strings(signers)
+	return append(algs, signers...)
+}
+
+// idTokenDefaultStrategy returns the openid.DefaultStrategy signing ID Tokens, or nil if strategy does not use one.
+func idTokenDefaultStrategy(strategy interface{}) *openid.DefaultStrategy {
+	if cs, ok := strategy.(*CommonStrategy); ok {
+		strategy = cs.OpenIDConnectTokenStrategy
+	}
+	s, _ := strategy.(*openid.DefaultStrategy)
+	return s
+}
+
+// idTokenSigningAlgorithmProvider returns the JWT strategy signing ID Tokens if it exposes its signing algorithm.
+func idTokenSigningAlgorithmProvider(strategy interface{}) jwt.SigningAlgorithmProvider {
+	s := idTokenDefaultStrategy(strategy)
+	if s == nil {
+		return nil
+	}
+	p, _ := s.JWTStrategy.(jwt.SigningAlgorithmProvider)
+	return p
+}
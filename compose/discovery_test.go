@@ -0,0 +1,130 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package compose
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/jwt"
+)
+
+func TestDiscoveryMetadata(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	config := &Config{
+		IDTokenIssuer: "https://auth.example.com",
+		TokenURL:      "https://auth.example.com/oauth2/token",
+	}
+	strategy := &CommonStrategy{
+		CoreStrategy:               NewOAuth2HMACStrategy(config, []byte("some-secret-thats-random-some-secret-thats-random-"), nil),
+		OpenIDConnectTokenStrategy: NewOpenIDConnectStrategy(config, key),
+		JWTStrategy:                &jwt.RS256JWTStrategy{PrivateKey: key},
+	}
+	endpoints := DiscoveryEndpoints{AuthorizationEndpoint: "https://auth.example.com/oauth2/auth"}
+
+	t.Run("case=reflects the composed factories", func(t *testing.T) {
+		provider := Compose(config, storage.NewMemoryStore(), strategy, nil,
+			OAuth2AuthorizeExplicitFactory,
+			OAuth2RefreshTokenGrantFactory,
+			OpenIDConnectExplicitFactory,
+		)
+		m := DiscoveryMetadata(config, provider, strategy, endpoints)
+
+		assert.Equal(t, "https://auth.example.com", m.Issuer)
+		assert.Equal(t, "https://auth.example.com/oauth2/auth", m.AuthorizationEndpoint)
+		assert.Equal(t, "https://auth.example.com/oauth2/token", m.TokenEndpoint)
+		assert.Equal(t, []string{"authorization_code", "refresh_token"}, m.GrantTypesSupported)
+		assert.Equal(t, []string{"code"}, m.ResponseTypesSupported)
+		assert.Equal(t, []string{"openid"}, m.ScopesSupported)
+		assert.Equal(t, []string{"RS256"}, m.IDTokenSigningAlgValuesSupported)
+		assert.Empty(t, m.CodeChallengeMethodsSupported)
+	})
+
+	t.Run("case=advertises PKCE only if the PKCE factory is composed", func(t *testing.T) {
+		for k, c := range []struct {
+			config    *Config
+			factories []Factory
+			expected  []string
+		}{
+			{
+				config:    config,
+				factories: []Factory{OAuth2AuthorizeExplicitFactory},
+			},
+			{
+				config:    config,
+				factories: []Factory{OAuth2AuthorizeExplicitFactory, OAuth2PKCEFactory},
+				expected:  []string{"S256"},
+			},
+			{
+				config:    &Config{EnablePKCEPlainChallengeMethod: true},
+				factories: []Factory{OAuth2AuthorizeExplicitFactory, OAuth2PKCEFactory},
+				expected:  []string{"S256", "plain"},
+			},
+		} {
+			provider := Compose(c.config, storage.NewMemoryStore(), strategy, nil, c.factories...)
+			m := DiscoveryMetadata(c.config, provider, strategy, endpoints)
+			if c.expected == nil {
+				assert.Empty(t, m.CodeChallengeMethodsSupported, "%d", k)
+			} else {
+				assert.Equal(t, c.expected, m.CodeChallengeMethodsSupported, "%d", k)
+			}
+		}
+	})
+
+	t.Run("case=omits disabled grants and their response types", func(t *testing.T) {
+		config := &Config{DisableImplicitGrant: true, DisableResourceOwnerPasswordCredentialsGrant: true}
+		provider := Compose(config, storage.NewMemoryStore(), strategy, nil,
+			OAuth2AuthorizeExplicitFactory,
+			OAuth2AuthorizeImplicitFactory,
+			OAuth2ResourceOwnerPasswordCredentialsFactory,
+			OpenIDConnectImplicitFactory,
+			OpenIDConnectHybridFactory,
+		)
+		m := DiscoveryMetadata(config, provider, strategy, endpoints)
+
+		assert.Equal(t, []string{"authorization_code"}, m.GrantTypesSupported)
+		assert.Equal(t, []string{"code", "code id_token"}, m.ResponseTypesSupported)
+	})
+
+	t.Run("case=lists additional id token signing algorithms", func(t *testing.T) {
+		oidc := NewOpenIDConnectStrategy(config, key)
+		oidc.IDTokenSigners = map[string]jwt.JWTStrategy{
+			"PS256": &jwt.RSAPSSJWTStrategy{PrivateKey: key},
+			"RS256": oidc.JWTStrategy,
+		}
+		provider := Compose(config, storage.NewMemoryStore(), &CommonStrategy{
+			CoreStrategy:               strategy.CoreStrategy,
+			OpenIDConnectTokenStrategy: oidc,
+			JWTStrategy:                strategy.JWTStrategy,
+		}, nil, OAuth2AuthorizeExplicitFactory, OpenIDConnectExplicitFactory)
+
+		m := DiscoveryMetadata(config, provider, oidc, endpoints)
+		assert.Equal(t, []string{"RS256", "PS256"}, m.IDTokenSigningAlgValuesSupported)
+	})
+}
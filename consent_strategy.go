@@ -0,0 +1,90 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ory/x/errorsx"
+	"github.com/pkg/errors"
+)
+
+// ConsentStrategy decides which of the requested scopes and audiences the end-user consents to. It is called by
+// NewAuthorizeResponse before the authorize endpoint handlers are invoked. The scopes and audiences it returns are
+// granted in addition to the ones the caller of NewAuthorizeResponse granted already, so callers using a
+// ConsentStrategy should leave granting to it.
+type ConsentStrategy interface {
+	// GrantConsent returns the scopes and audiences to grant for the authorize request of the end-user identified by
+	// the session. Both must be subsets of the requested ones. Errors which are not an *RFC6749Error are returned as
+	// ErrServerError, return ErrConsentRequired or ErrAccessDenied to reject the request instead.
+	GrantConsent(ctx context.Context, requester AuthorizeRequester, session Session) (scopes Arguments, audience Arguments, err error)
+}
+
+// DefaultConsentStrategy grants all requested scopes and audiences.
+type DefaultConsentStrategy struct{}
+
+func (DefaultConsentStrategy) GrantConsent(ctx context.Context, requester AuthorizeRequester, session Session) (Arguments, Arguments, error) {
+	return requester.GetRequestedScopes(), requester.GetRequestedAudience(), nil
+}
+
+// IsConsentPrompted returns true if the request asks the authorization server to prompt the end-user for consent,
+// even if they consented to the request before, see https://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+func IsConsentPrompted(requester Requester) bool {
+	for _, prompt := range strings.Split(requester.GetRequestForm().Get("prompt"), " ") {
+		if prompt == "consent" {
+			return true
+		}
+	}
+	return false
+}
+
+// grantConsent grants the scopes and audiences returned by ConsentStrategy, if set, in addition to the ones granted by
+// the caller of NewAuthorizeResponse. Without a ConsentStrategy, the granted scopes and audiences are used as is.
+func (f *Fosite) grantConsent(ctx context.Context, ar AuthorizeRequester, session Session) error {
+	if f.ConsentStrategy == nil {
+		return nil
+	}
+
+	scopes, audience, err := f.ConsentStrategy.GrantConsent(ctx, ar, session)
+	if err != nil {
+		var rfcErr *RFC6749Error
+		if errors.As(err, &rfcErr) {
+			return errorsx.WithStack(rfcErr)
+		}
+		return errorsx.WithStack(ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	for _, scope := range scopes {
+		if !ar.GetRequestedScopes().Has(scope) {
+			return errorsx.WithStack(ErrServerError.WithDebugf("The consent strategy granted scope '%s' which has not been requested.", scope))
+		}
+		ar.GrantScope(scope)
+	}
+	for _, aud := range audience {
+		if !ar.GetRequestedAudience().Has(aud) {
+			return errorsx.WithStack(ErrServerError.WithDebugf("The consent strategy granted audience '%s' which has not been requested.", aud))
+		}
+		ar.GrantAudience(aud)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/compose"
+	"github.com/ory/fosite/storage"
+)
+
+type consentStrategyFunc func(ctx context.Context, requester AuthorizeRequester, session Session) (Arguments, Arguments, error)
+
+func (f consentStrategyFunc) GrantConsent(ctx context.Context, requester AuthorizeRequester, session Session) (Arguments, Arguments, error) {
+	return f(ctx, requester, session)
+}
+
+func TestConsentStrategy(t *testing.T) {
+	grant := func(scopes, audience Arguments, err error) ConsentStrategy {
+		return consentStrategyFunc(func(_ context.Context, _ AuthorizeRequester, _ Session) (Arguments, Arguments, error) {
+			return scopes, audience, err
+		})
+	}
+
+	for k, c := range []struct {
+		d              string
+		strategy       ConsentStrategy
+		granted        Arguments
+		expectErr      error
+		expectScope    string
+		expectAudience Arguments
+	}{
+		{
+			d:              "should grant the scopes and audiences granted by the caller if no strategy is set",
+			expectScope:    "",
+			expectAudience: Arguments{},
+		},
+		{
+			d:              "should grant everything requested using the default strategy",
+			strategy:       DefaultConsentStrategy{},
+			expectScope:    "foo bar",
+			expectAudience: Arguments{"https://www.ory.sh/api"},
+		},
+		{
+			d:              "should grant the subset returned by the strategy",
+			strategy:       grant(Arguments{"foo"}, Arguments{}, nil),
+			expectScope:    "foo",
+			expectAudience: Arguments{},
+		},
+		{
+			d:              "should grant the scopes returned by the strategy in addition to the ones granted by the caller",
+			strategy:       grant(Arguments{"foo"}, Arguments{}, nil),
+			granted:        Arguments{"bar"},
+			expectScope:    "bar foo",
+			expectAudience: Arguments{},
+		},
+		{
+			d:         "should fail because the strategy granted a scope which has not been requested",
+			strategy:  grant(Arguments{"foo", "baz"}, nil, nil),
+			expectErr: ErrServerError,
+		},
+		{
+			d:         "should fail because the strategy granted an audience which has not been requested",
+			strategy:  grant(nil, Arguments{"https://www.ory.sh/other"}, nil),
+			expectErr: ErrServerError,
+		},
+		{
+			d:         "should pass through errors of the strategy",
+			strategy:  grant(nil, nil, ErrConsentRequired),
+			expectErr: ErrConsentRequired,
+		},
+		{
+			d:         "should fail with a server error if the strategy fails",
+			strategy:  grant(nil, nil, errors.New("consent store unavailable")),
+			expectErr: ErrServerError,
+		},
+	} {
+		t.Run(c.d, func(t *testing.T) {
+			f := compose.ComposeAllEnabled(&compose.Config{ConsentStrategy: c.strategy}, storage.NewMemoryStore(), []byte("some-super-cool-secret-that-nobody-knows"), nil)
+
+			ar := NewAuthorizeRequest()
+			ar.Client = &DefaultClient{
+				ID:            "foo",
+				GrantTypes:    Arguments{"implicit"},
+				ResponseTypes: Arguments{"token"},
+				Scopes:        []string{"foo", "bar"},
+				Audience:      []string{"https://www.ory.sh/api"},
+			}
+			ar.ResponseTypes = Arguments{"token"}
+			ar.RequestedScope = Arguments{"foo", "bar"}
+			ar.RequestedAudience = Arguments{"https://www.ory.sh/api"}
+			ar.RedirectURI, _ = url.Parse("https://www.ory.sh/cb")
+			ar.State = "some-state-value"
+			for _, scope := range c.granted {
+				ar.GrantScope(scope)
+			}
+
+			resp, err := f.NewAuthorizeResponse(context.Background(), ar, new(DefaultSession))
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error(), "%d", k)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expectScope, resp.GetParameters().Get("scope"))
+			assert.Equal(t, c.expectAudience, ar.GetGrantedAudience())
+		})
+	}
+}
+
+func TestIsConsentPrompted(t *testing.T) {
+	r := NewRequest()
+	assert.False(t, IsConsentPrompted(r))
+
+	r.Form.Set("prompt", "login consent")
+	assert.True(t, IsConsentPrompted(r))
+}
@@ -35,4 +35,14 @@ const (
 	AccessResponseContextKey    = ContextKey("accessResponse")
 	AuthorizeRequestContextKey  = ContextKey("authorizeRequest")
 	AuthorizeResponseContextKey = ContextKey("authorizeResponse")
+	DeviceRequestContextKey     = ContextKey("deviceRequest")
+	DeviceResponseContextKey    = ContextKey("deviceResponse")
+
+	PushedAuthorizeResponseContextKey = ContextKey("pushedAuthorizeResponse")
+
+	BackchannelAuthenticationRequestContextKey  = ContextKey("backchannelAuthenticationRequest")
+	BackchannelAuthenticationResponseContextKey = ContextKey("backchannelAuthenticationResponse")
+
+	// TenantContextKey holds the tenant added with WithTenant.
+	TenantContextKey = ContextKey("tenant")
 )
@@ -0,0 +1,77 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ory/x/errorsx"
+)
+
+// NewDeviceRequest parses and validates a device authorization request as defined in
+// https://tools.ietf.org/html/rfc8628#section-3.1. The client authentication requirements of the token endpoint
+// apply to this endpoint as well, which means that confidential clients authenticate in the same manner as when
+// making requests to the token endpoint, and public clients provide the "client_id" parameter to identify themselves.
+func (f *Fosite) NewDeviceRequest(ctx context.Context, r *http.Request) (DeviceRequester, error) {
+	request := NewDeviceRequest()
+
+	ctx = context.WithValue(ctx, RequestContextKey, r)
+	ctx = context.WithValue(ctx, DeviceRequestContextKey, request)
+
+	if r.Method != "POST" {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHintf("HTTP method is '%s', expected 'POST'.", r.Method))
+	} else if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithWrap(err).WithDebug(err.Error()))
+	} else if len(r.PostForm) == 0 {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("The POST body can not be empty."))
+	}
+
+	request.Form = r.PostForm
+
+	ctx, err := f.extractRequestContext(ctx, r, &request.Request)
+	if err != nil {
+		return request, err
+	}
+
+	client, err := f.AuthenticateClient(ctx, r, r.PostForm)
+	if err != nil {
+		return request, err
+	}
+	request.Client = client
+
+	scope := f.parseScope(r.PostForm.Get("scope"))
+	for _, permission := range scope {
+		if !f.ScopeStrategy(client.GetScopes(), permission) {
+			return request, errorsx.WithStack(ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope '%s'.", permission))
+		}
+	}
+	request.SetRequestedScopes(scope)
+
+	audience := GetAudiences(r.PostForm)
+	if err := f.AudienceMatchingStrategy(client.GetAudience(), audience); err != nil {
+		return request, err
+	}
+	request.SetRequestedAudience(audience)
+
+	return request, nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import "time"
+
+// DeviceResponse is an implementation of DeviceResponder
+type DeviceResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval,omitempty"`
+}
+
+func NewDeviceResponse() *DeviceResponse {
+	return &DeviceResponse{}
+}
+
+func (d *DeviceResponse) GetDeviceCode() string {
+	return d.DeviceCode
+}
+
+func (d *DeviceResponse) SetDeviceCode(code string) {
+	d.DeviceCode = code
+}
+
+func (d *DeviceResponse) GetUserCode() string {
+	return d.UserCode
+}
+
+func (d *DeviceResponse) SetUserCode(code string) {
+	d.UserCode = code
+}
+
+func (d *DeviceResponse) GetVerificationURI() string {
+	return d.VerificationURI
+}
+
+func (d *DeviceResponse) SetVerificationURI(uri string) {
+	d.VerificationURI = uri
+}
+
+func (d *DeviceResponse) GetVerificationURIComplete() string {
+	return d.VerificationURIComplete
+}
+
+func (d *DeviceResponse) SetVerificationURIComplete(uri string) {
+	d.VerificationURIComplete = uri
+}
+
+func (d *DeviceResponse) GetExpiresIn() int64 {
+	return d.ExpiresIn
+}
+
+func (d *DeviceResponse) SetExpiresIn(expiresIn time.Duration) {
+	d.ExpiresIn = int64(expiresIn / time.Second)
+}
+
+func (d *DeviceResponse) GetInterval() int64 {
+	return d.Interval
+}
+
+func (d *DeviceResponse) SetInterval(interval time.Duration) {
+	d.Interval = int64(interval / time.Second)
+}
+
+func (d *DeviceResponse) ToMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"device_code":      d.DeviceCode,
+		"user_code":        d.UserCode,
+		"verification_uri": d.VerificationURI,
+		"expires_in":       d.ExpiresIn,
+	}
+	if d.VerificationURIComplete != "" {
+		m["verification_uri_complete"] = d.VerificationURIComplete
+	}
+	if d.Interval > 0 {
+		m["interval"] = d.Interval
+	}
+	return m
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+)
+
+func TestNewDeviceResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	handler := internal.NewMockDeviceEndpointHandler(ctrl)
+	defer ctrl.Finish()
+
+	oauth2 := &Fosite{DeviceEndpointHandlers: DeviceEndpointHandlers{handler}}
+	dr := NewDeviceRequest()
+	sess := &DefaultSession{}
+
+	handler.EXPECT().HandleDeviceEndpointRequest(gomock.Any(), dr, gomock.Any()).Return(errors.New(""))
+	_, err := oauth2.NewDeviceResponse(context.Background(), dr, sess)
+	require.Error(t, err)
+
+	handler.EXPECT().HandleDeviceEndpointRequest(gomock.Any(), dr, gomock.Any()).Return(nil)
+	_, err = oauth2.NewDeviceResponse(context.Background(), dr, sess)
+	require.EqualError(t, err, ErrServerError.Error())
+
+	handler.EXPECT().HandleDeviceEndpointRequest(gomock.Any(), dr, gomock.Any()).DoAndReturn(func(_ context.Context, _ DeviceRequester, resp DeviceResponder) error {
+		resp.SetDeviceCode("device_code")
+		resp.SetUserCode("user_code")
+		return nil
+	})
+	resp, err := oauth2.NewDeviceResponse(context.Background(), dr, sess)
+	require.NoError(t, err)
+	assert.Equal(t, "device_code", resp.GetDeviceCode())
+	assert.Equal(t, "user_code", resp.GetUserCode())
+	assert.Equal(t, sess, dr.GetSession())
+}
@@ -39,6 +39,11 @@ var (
 	// ErrInvalidatedAuthorizeCode is an error indicating that an authorization code has been
 	// used previously.
 	ErrInvalidatedAuthorizeCode = errors.New("Authorization code has ben invalidated")
+	// ErrInvalidatedDeviceCode is an error indicating that a device code has been used previously.
+	ErrInvalidatedDeviceCode = errors.New("Device code has been invalidated")
+	// ErrInvalidatedAuthReqID is an error indicating that a backchannel authentication request ID has been used
+	// previously.
+	ErrInvalidatedAuthReqID = errors.New("Backchannel authentication request ID has been invalidated")
 	// ErrSerializationFailure is an error indicating that the transactional capable storage could not guarantee
 	// consistency of Update & Delete operations on the same rows between multiple sessions.
 	ErrSerializationFailure = errors.New("The request could not be completed due to concurrent access")
@@ -106,6 +111,12 @@ var (
 		DescriptionField: "The provided authorization grant (e.g., authorization code, resource owner credentials) or refresh token is invalid, expired, revoked, does not match the redirection URI used in the authorization request, or was issued to another client.",
 		CodeField:        http.StatusBadRequest,
 	}
+	ErrSessionExpired = &RFC6749Error{
+		ErrorField:       errInvalidGrantName,
+		DescriptionField: "The provided authorization grant or refresh token is invalid because the session it was issued in has ended.",
+		HintField:        "The end-user has to authenticate again.",
+		CodeField:        http.StatusBadRequest,
+	}
 	ErrInvalidClient = &RFC6749Error{
 		ErrorField:       errInvalidClientName,
 		DescriptionField: "Client authentication failed (e.g., unknown client, no client authentication included, or unsupported authentication method).",
@@ -218,6 +229,56 @@ var (
 		ErrorField:       errJTIKnownName,
 		CodeField:        http.StatusBadRequest,
 	}
+	ErrAuthorizationPending = &RFC6749Error{
+		DescriptionField: "The authorization request is still pending as the end user hasn't yet completed the user-interaction steps.",
+		ErrorField:       errAuthorizationPendingName,
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrSlowDown = &RFC6749Error{
+		DescriptionField: "The authorization request is still pending and polling should continue, but the interval MUST be increased by 5 seconds for this and all subsequent requests.",
+		ErrorField:       errSlowDownName,
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrExpiredToken = &RFC6749Error{
+		DescriptionField: "The device_code has expired, and the device authorization session has concluded.",
+		ErrorField:       errExpiredTokenName,
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrInvalidDPoPProof = &RFC6749Error{
+		DescriptionField: "The DPoP proof is missing, malformed or invalid.",
+		ErrorField:       errInvalidDPoPProofName,
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrInvalidToken = &RFC6749Error{
+		DescriptionField: "The access token provided is expired, revoked, malformed, or invalid for other reasons.",
+		ErrorField:       errInvalidTokenName,
+		CodeField:        http.StatusUnauthorized,
+	}
+	ErrInvalidTarget = &RFC6749Error{
+		DescriptionField: "The requested resource is invalid, missing, unknown, or malformed.",
+		ErrorField:       errInvalidTargetName,
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrInvalidAuthorizationDetails = &RFC6749Error{
+		DescriptionField: "The requested authorization details are invalid, unknown, or malformed.",
+		ErrorField:       errInvalidAuthorizationDetailsName,
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrUnknownUserID = &RFC6749Error{
+		DescriptionField: "The OpenID Provider is not able to identify which end-user the Client wishes to be authenticated by means of the hint provided in the request.",
+		ErrorField:       errUnknownUserIDName,
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrExpiredLoginHintToken = &RFC6749Error{
+		DescriptionField: "The login_hint_token provided in the authentication request is not valid because it has expired.",
+		ErrorField:       errExpiredLoginHintTokenName,
+		CodeField:        http.StatusBadRequest,
+	}
+	ErrInvalidBindingMessage = &RFC6749Error{
+		DescriptionField: "The binding message is invalid or unacceptable for use in the context of the given request.",
+		ErrorField:       errInvalidBindingMessageName,
+		CodeField:        http.StatusBadRequest,
+	}
 )
 
 const (
@@ -250,11 +311,21 @@ const (
 	errTokenClaimName              = "token_claim"
 	errTokenInactiveName           = "token_inactive"
 	// errAuthorizationCodeInactiveName = "authorization_code_inactive"
-	errUnknownErrorName             = "error"
-	errRequestNotSupportedName      = "request_not_supported"
-	errRequestURINotSupportedName   = "request_uri_not_supported"
-	errRegistrationNotSupportedName = "registration_not_supported"
-	errJTIKnownName                 = "jti_known"
+	errUnknownErrorName                = "error"
+	errRequestNotSupportedName         = "request_not_supported"
+	errRequestURINotSupportedName      = "request_uri_not_supported"
+	errRegistrationNotSupportedName    = "registration_not_supported"
+	errJTIKnownName                    = "jti_known"
+	errAuthorizationPendingName        = "authorization_pending"
+	errSlowDownName                    = "slow_down"
+	errExpiredTokenName                = "expired_token"
+	errInvalidDPoPProofName            = "invalid_dpop_proof"
+	errInvalidAuthorizationDetailsName = "invalid_authorization_details"
+	errInvalidTargetName               = "invalid_target"
+	errInvalidTokenName                = "invalid_token" // https://tools.ietf.org/html/rfc6750#section-3.1
+	errUnknownUserIDName               = "unknown_user_id"
+	errExpiredLoginHintTokenName       = "expired_login_hint_token"
+	errInvalidBindingMessageName       = "invalid_binding_message"
 )
 
 type (
@@ -264,6 +335,7 @@ type (
 		HintField        string
 		CodeField        int
 		DebugField       string
+		URIField         string
 		cause            error
 		useLegacyFormat  bool
 		exposeDebug      bool
@@ -396,6 +468,13 @@ func (e *RFC6749Error) WithDebugf(debug string, args ...interface{}) *RFC6749Err
 	return e.WithDebug(fmt.Sprintf(debug, args...))
 }
 
+// WithURI sets the error_uri, which identifies a human-readable web page with information about the error.
+func (e *RFC6749Error) WithURI(uri string) *RFC6749Error {
+	err := *e
+	err.URIField = uri
+	return &err
+}
+
 func (e *RFC6749Error) WithDescription(description string) *RFC6749Error {
 	err := *e
 	err.DescriptionField = description
@@ -434,6 +513,7 @@ func (e *RFC6749Error) GetDescription() string {
 type RFC6749ErrorJson struct {
 	Name        string `json:"error"`
 	Description string `json:"error_description"`
+	URI         string `json:"error_uri,omitempty"`
 	Hint        string `json:"error_hint,omitempty"`
 	Code        int    `json:"status_code,omitempty"`
 	Debug       string `json:"error_debug,omitempty"`
@@ -449,6 +529,7 @@ func (e *RFC6749Error) UnmarshalJSON(b []byte) error {
 	e.ErrorField = data.Name
 	e.CodeField = data.Code
 	e.DescriptionField = data.Description
+	e.URIField = data.URI
 
 	if len(data.Hint+data.Debug) > 0 {
 		e.HintField = data.Hint
@@ -464,6 +545,7 @@ func (e RFC6749Error) MarshalJSON() ([]byte, error) {
 		return json.Marshal(&RFC6749ErrorJson{
 			Name:        e.ErrorField,
 			Description: e.GetDescription(),
+			URI:         e.URIField,
 		})
 	}
 
@@ -475,6 +557,7 @@ func (e RFC6749Error) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&RFC6749ErrorJson{
 		Name:        e.ErrorField,
 		Description: e.DescriptionField,
+		URI:         e.URIField,
 		Hint:        e.HintField,
 		Code:        e.CodeField,
 		Debug:       debug,
@@ -485,6 +568,9 @@ func (e *RFC6749Error) ToValues() url.Values {
 	values := url.Values{}
 	values.Set("error", e.ErrorField)
 	values.Set("error_description", e.GetDescription())
+	if e.URIField != "" {
+		values.Set("error_uri", e.URIField)
+	}
 
 	if e.useLegacyFormat {
 		values.Set("error_description", e.DescriptionField)
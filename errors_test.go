@@ -22,6 +22,7 @@
 package fosite
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -43,4 +44,17 @@ func TestRFC6749Error(t *testing.T) {
 
 		assert.Empty(t, wrap.StackTrace())
 	})
+
+	t.Run("case=error_uri", func(t *testing.T) {
+		err := ErrInvalidRequest.WithDebug("some-debug").WithURI("https://example.com/errors")
+
+		values := err.ToValues()
+		assert.Equal(t, "https://example.com/errors", values.Get("error_uri"))
+		assert.NotContains(t, values.Get("error_description"), "some-debug")
+
+		js, _ := json.Marshal(err)
+		var decoded RFC6749Error
+		assert.NoError(t, json.Unmarshal(js, &decoded))
+		assert.Equal(t, "https://example.com/errors", decoded.URIField)
+	})
 }
@@ -24,7 +24,14 @@ package fosite
 import (
 	"html/template"
 	"net/http"
+	"net/url"
 	"reflect"
+	"time"
+
+	"github.com/ory/x/errorsx"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/ory/fosite/token/jwt"
 )
 
 // AuthorizeEndpointHandlers is a list of AuthorizeEndpointHandler
@@ -83,6 +90,48 @@ func (t *RevocationHandlers) Append(h RevocationHandler) {
 	*t = append(*t, h)
 }
 
+// DeviceEndpointHandlers is a list of DeviceEndpointHandler
+type DeviceEndpointHandlers []DeviceEndpointHandler
+
+// Append adds an DeviceEndpointHandler to this list. Ignores duplicates based on reflect.TypeOf.
+func (d *DeviceEndpointHandlers) Append(h DeviceEndpointHandler) {
+	for _, this := range *d {
+		if reflect.TypeOf(this) == reflect.TypeOf(h) {
+			return
+		}
+	}
+
+	*d = append(*d, h)
+}
+
+// PushedAuthorizeEndpointHandlers is a list of PushedAuthorizeEndpointHandler
+type PushedAuthorizeEndpointHandlers []PushedAuthorizeEndpointHandler
+
+// Append adds an PushedAuthorizeEndpointHandler to this list. Ignores duplicates based on reflect.TypeOf.
+func (p *PushedAuthorizeEndpointHandlers) Append(h PushedAuthorizeEndpointHandler) {
+	for _, this := range *p {
+		if reflect.TypeOf(this) == reflect.TypeOf(h) {
+			return
+		}
+	}
+
+	*p = append(*p, h)
+}
+
+// BackchannelAuthenticationEndpointHandlers is a list of BackchannelAuthenticationEndpointHandler
+type BackchannelAuthenticationEndpointHandlers []BackchannelAuthenticationEndpointHandler
+
+// Append adds an BackchannelAuthenticationEndpointHandler to this list. Ignores duplicates based on reflect.TypeOf.
+func (b *BackchannelAuthenticationEndpointHandlers) Append(h BackchannelAuthenticationEndpointHandler) {
+	for _, this := range *b {
+		if reflect.TypeOf(this) == reflect.TypeOf(h) {
+			return
+		}
+	}
+
+	*b = append(*b, h)
+}
+
 // Fosite implements OAuth2Provider.
 type Fosite struct {
 	Store                      Storage
@@ -90,12 +139,51 @@ type Fosite struct {
 	TokenEndpointHandlers      TokenEndpointHandlers
 	TokenIntrospectionHandlers TokenIntrospectionHandlers
 	RevocationHandlers         RevocationHandlers
+	DeviceEndpointHandlers     DeviceEndpointHandlers
 	Hasher                     Hasher
 	ScopeStrategy              ScopeStrategy
 	AudienceMatchingStrategy   AudienceMatchingStrategy
-	JWKSFetcherStrategy        JWKSFetcherStrategy
-	HTTPClient                 *http.Client
-	UseLegacyErrorFormat       bool
+
+	// RedirectURIMatchingStrategy matches requested redirect URIs against the registered ones, unless the client
+	// implements RedirectURIMatchingStrategyClient. Defaults to LoopbackIPFlexibleRedirectURIMatchingStrategy.
+	RedirectURIMatchingStrategy RedirectURIMatchingStrategy
+	JWKSFetcherStrategy         JWKSFetcherStrategy
+	HTTPClient                  *http.Client
+	UseLegacyErrorFormat        bool
+
+	// RedirectURISecurityPolicy, if set, rejects authorize requests whose redirect URI it does not allow.
+	RedirectURISecurityPolicy *RedirectURISecurityPolicy
+
+	// ErrorWriteStrategy writes the JSON error responses of the token, introspection, device authorization and
+	// backchannel authentication endpoints. Defaults to DefaultErrorWriteStrategy.
+	ErrorWriteStrategy ErrorWriteStrategy
+
+	// ErrorHook is invoked with every error written to a client, for example to log it. The error always carries
+	// its debug details, regardless of SendDebugMessagesToClients.
+	ErrorHook ErrorHook
+
+	// AuditLogger receives the outcome of authorize, token, introspection and revocation requests.
+	AuditLogger AuditLogger
+
+	// Observer receives measurements of authorize, token, introspection and revocation requests, for example to
+	// build metrics.
+	Observer Observer
+
+	// Tracer is notified at the boundaries of authorize, token, introspection and revocation requests, for example to
+	// wrap them in spans.
+	Tracer Tracer
+
+	// AuthorizationDetailsValidator validates the requested authorization details. Defaults to
+	// DefaultAuthorizationDetailsValidator.
+	AuthorizationDetailsValidator AuthorizationDetailsValidator
+
+	// BearerTokenType is the token_type of bearer access tokens in token and authorize responses, either "Bearer" or
+	// "bearer". Defaults to DefaultBearerTokenType.
+	BearerTokenType string
+
+	// BearerRealm is the realm of the WWW-Authenticate challenge written by WriteBearerTokenError. If empty, the
+	// challenge carries no realm.
+	BearerRealm string
 
 	// TokenURL is the the URL of the Authorization Server's Token Endpoint.
 	TokenURL string
@@ -108,6 +196,10 @@ type Fosite struct {
 	// MinParameterEntropy controls the minimum size of state and nonce parameters. Defaults to fosite.MinParameterEntropy.
 	MinParameterEntropy int
 
+	// MinStateLength is the minimum length of the state parameter of clients which require it, see
+	// StateClient. Defaults to zero, which only requires these clients to send a state.
+	MinStateLength int
+
 	// FormPostHTMLTemplate sets html template for rendering the authorization response when the request has response_mode=form_post. Defaults to fosite.FormPostDefaultTemplate
 	FormPostHTMLTemplate *template.Template
 
@@ -115,6 +207,140 @@ type Fosite struct {
 	ClientAuthenticationStrategy ClientAuthenticationStrategy
 
 	ResponseModeHandlerExtension ResponseModeHandler
+
+	// ResponseModeHandlers registers handlers for additional response modes. They take precedence over
+	// ResponseModeHandlerExtension and the built-in response modes, which makes it possible to replace those as well.
+	ResponseModeHandlers []ResponseModeHandler
+
+	// RequestURIAllowlist restricts the request_uri values the authorization server fetches request objects from to the
+	// given URL prefixes, in addition to the request URIs registered by the client. A request_uri matches a prefix if
+	// it has the same scheme and host and its path is below the path of the prefix. Request objects are only fetched
+	// over HTTPS.
+	RequestURIAllowlist []string
+
+	// RequestURIFetchTimeout sets the timeout for fetching request objects from a request_uri. Defaults to ten seconds.
+	RequestURIFetchTimeout time.Duration
+
+	// RequestURIAllowedHosts restricts the hosts request objects are fetched from, including the targets of redirects.
+	// Hosts are compared case-insensitively and without the port. Defaults to all hosts.
+	RequestURIAllowedHosts []string
+
+	// RequestURIMaxResponseSize limits the size in bytes of request objects fetched from a request_uri. Defaults to
+	// 64 KiB.
+	RequestURIMaxResponseSize int64
+
+	// StorageTimeout limits how long each endpoint handler and client lookup may wait for the storage. Requests
+	// exceeding it fail with temporarily_unavailable. Defaults to no timeout.
+	StorageTimeout time.Duration
+
+	// RequestObjectDecryptionKey is the private key used to decrypt encrypted request objects.
+	RequestObjectDecryptionKey interface{}
+
+	// JWTSecuredAuthorizeResponseModeSigners sign the authorization responses of the JWT Secured Authorization Response
+	// Mode (JARM), keyed by the signing algorithm. The JARM response modes are only supported if a signer is set.
+	JWTSecuredAuthorizeResponseModeSigners map[string]jwt.JWTStrategy
+
+	// Issuer is the issuer identifier of the authorization server. If set, id_token_hint values must have been issued
+	// by it and the audience of request objects, if present, must contain it.
+	Issuer string
+
+	// JWTSecuredAuthorizeResponseModeIssuer sets the "iss" claim of JWT secured authorization responses.
+	JWTSecuredAuthorizeResponseModeIssuer string
+
+	// JWTSecuredAuthorizeResponseModeLifespan sets how long JWT secured authorization responses are valid. Defaults to
+	// ten minutes.
+	JWTSecuredAuthorizeResponseModeLifespan time.Duration
+
+	// IDTokenHintStrategy decodes the id_token_hint of OpenID Connect RP-Initiated Logout requests. It must be able to
+	// verify the ID Tokens issued by the authorization server.
+	IDTokenHintStrategy jwt.JWTStrategy
+
+	// ScopeDelimiters are the characters separating scopes in the scope parameter of requests. Defaults to a
+	// space only, as defined in https://tools.ietf.org/html/rfc6749#section-3.3. Scopes in responses are always
+	// separated by spaces.
+	ScopeDelimiters []string
+
+	// IncludeRequestedScopeInAccessResponse adds the scopes requested by the client as "requested_scope" to access
+	// token responses, next to the granted scopes in "scope".
+	IncludeRequestedScopeInAccessResponse bool
+
+	// MaxScopes limits the number of scopes an authorize or token request may ask for. Defaults to zero, which means
+	// unlimited.
+	MaxScopes int
+
+	// MaxAudiences limits the number of audiences and resources an authorize or token request may ask for. Defaults
+	// to zero, which means unlimited.
+	MaxAudiences int
+
+	// MaxRequestBodySize limits the size in bytes of the bodies of authorize, pushed authorize and token requests.
+	// Defaults to DefaultMaxRequestBodySize.
+	MaxRequestBodySize int64
+
+	// MaxParameterLengths limits the length in bytes of the given parameters of authorize, pushed authorize and token
+	// requests. A limit of zero disables the check of the parameter. Defaults to DefaultMaxParameterLengths.
+	MaxParameterLengths map[string]int
+
+	// RequestContextExtractor populates the RequestContext of authorize, token, device and backchannel authentication
+	// requests from the incoming HTTP request. If nil, requests carry no request context. The tenant of the request
+	// context, see TenantRequestContextKey, is used for client lookups at all endpoints including revocation and
+	// introspection.
+	RequestContextExtractor RequestContextExtractor
+
+	// LoginHintValidator validates the login_hint parameter of authorize requests. If nil, any login_hint is accepted.
+	LoginHintValidator LoginHintValidator
+
+	// ConsentStrategy decides which of the requested scopes and audiences are granted, before the authorize endpoint
+	// handlers are invoked. If nil, the scopes and audiences granted by the caller of NewAuthorizeResponse are used.
+	ConsentStrategy ConsentStrategy
+
+	// AllowedJWTSigningAlgorithms restricts the algorithms client assertions and request objects may be signed with.
+	// The "none" algorithm is only accepted if it is listed. If empty, all algorithms supported for the client are
+	// accepted.
+	AllowedJWTSigningAlgorithms []jose.SignatureAlgorithm
+
+	// AllowUnknownDisplayValues accepts display values of authorize requests which are not defined by OpenID Connect.
+	// By default, they are rejected.
+	AllowUnknownDisplayValues bool
+
+	// IntrospectionAuthorizer decides whether the authenticated caller of the introspection endpoint may introspect
+	// a token. If nil, any authenticated caller may introspect any token.
+	IntrospectionAuthorizer IntrospectionAuthorizer
+
+	// RestrictIntrospectionScopes lists the token types, for example AccessToken, whose introspection responses only
+	// disclose the scopes visible to the caller. If empty, all granted scopes are disclosed.
+	RestrictIntrospectionScopes []TokenUse
+
+	// IntrospectionScopeFilter computes the scopes visible to the caller for the token types listed in
+	// RestrictIntrospectionScopes. If nil, the caller sees the granted scopes which are matched by its own scopes.
+	IntrospectionScopeFilter IntrospectionScopeFilter
+
+	// IntrospectionCacheMaxAge allows resource servers to cache the introspection responses of active tokens for up to
+	// the given duration, but never beyond the expiry of the token. By default introspection responses must not be
+	// cached.
+	IntrospectionCacheMaxAge time.Duration
+
+	// ClockSkew is tolerated when validating the time based claims of client assertions and request objects. Defaults
+	// to zero.
+	ClockSkew time.Duration
+
+	// BackchannelAuthenticationEndpointHandlers handle requests to the OpenID Connect Client-Initiated Backchannel
+	// Authentication endpoint.
+	BackchannelAuthenticationEndpointHandlers BackchannelAuthenticationEndpointHandlers
+
+	// PushedAuthorizeEndpointHandlers handle requests to the pushed authorization request endpoint.
+	PushedAuthorizeEndpointHandlers PushedAuthorizeEndpointHandlers
+
+	// PushedAuthorizeRequestLifespan is how long a request_uri returned by the pushed authorization request endpoint
+	// is valid. Defaults to one minute.
+	PushedAuthorizeRequestLifespan time.Duration
+
+	// RequirePushedAuthorizationRequests rejects authorize requests which do not use a request_uri returned by the
+	// pushed authorization request endpoint.
+	RequirePushedAuthorizationRequests bool
+
+	// RevokePushedAuthorizeRequestsByClient makes RevokeTokensByClient delete the pushed authorization requests of
+	// the client as well, which requires the storage to implement PARClientRevocationStorage.
+	RevokePushedAuthorizeRequestsByClient bool
 }
 
 const MinParameterEntropy = 8
@@ -128,11 +354,96 @@ func (f *Fosite) GetMinParameterEntropy() int {
 	}
 }
 
+// GetPushedAuthorizeRequestLifespan returns PushedAuthorizeRequestLifespan if set. Defaults to one minute.
+func (f *Fosite) GetPushedAuthorizeRequestLifespan() time.Duration {
+	if f.PushedAuthorizeRequestLifespan == 0 {
+		return time.Minute
+	}
+	return f.PushedAuthorizeRequestLifespan
+}
+
+// GetRequestURIFetchTimeout returns RequestURIFetchTimeout if set. Defaults to ten seconds.
+func (f *Fosite) GetRequestURIFetchTimeout() time.Duration {
+	if f.RequestURIFetchTimeout == 0 {
+		return time.Second * 10
+	}
+	return f.RequestURIFetchTimeout
+}
+
+// GetMaxRequestBodySize returns MaxRequestBodySize if set. Defaults to DefaultMaxRequestBodySize.
+func (f *Fosite) GetMaxRequestBodySize() int64 {
+	if f.MaxRequestBodySize <= 0 {
+		return DefaultMaxRequestBodySize
+	}
+	return f.MaxRequestBodySize
+}
+
+// GetMaxParameterLengths returns MaxParameterLengths if set. Defaults to DefaultMaxParameterLengths.
+func (f *Fosite) GetMaxParameterLengths() map[string]int {
+	if f.MaxParameterLengths == nil {
+		return DefaultMaxParameterLengths
+	}
+	return f.MaxParameterLengths
+}
+
+// GetRequestURIMaxResponseSize returns RequestURIMaxResponseSize if set. Defaults to 64 KiB.
+func (f *Fosite) GetRequestURIMaxResponseSize() int64 {
+	if f.RequestURIMaxResponseSize <= 0 {
+		return 64 << 10
+	}
+	return f.RequestURIMaxResponseSize
+}
+
 var defaultResponseModeHandler = &DefaultResponseModeHandler{}
 
+// Deprecated: Use GetResponseModeHandler instead.
 func (f *Fosite) ResponseModeHandler() ResponseModeHandler {
 	if f.ResponseModeHandlerExtension == nil {
 		return defaultResponseModeHandler
 	}
 	return f.ResponseModeHandlerExtension
 }
+
+// GetResponseModeHandler returns the handler responsible for the response mode, or nil if the response mode is not
+// supported. The JWT Secured Authorization Response Modes are only supported if
+// JWTSecuredAuthorizeResponseModeSigners is set.
+func (f *Fosite) GetResponseModeHandler(rm ResponseModeType) ResponseModeHandler {
+	handlers := append([]ResponseModeHandler{}, f.ResponseModeHandlers...)
+	if f.ResponseModeHandlerExtension != nil {
+		handlers = append(handlers, f.ResponseModeHandlerExtension)
+	}
+	handlers = append(handlers,
+		&QueryResponseModeHandler{},
+		&FragmentResponseModeHandler{},
+		&FormPostResponseModeHandler{Template: f.FormPostHTMLTemplate},
+	)
+	if len(f.JWTSecuredAuthorizeResponseModeSigners) > 0 {
+		handlers = append(handlers, &jwtSecuredResponseModeHandler{f: f})
+	}
+
+	for _, h := range handlers {
+		if h.ResponseModes().Has(rm) {
+			return h
+		}
+	}
+	return nil
+}
+
+func (f *Fosite) parseScope(scope string) Arguments {
+	return ParseScope(scope, f.ScopeDelimiters...)
+}
+
+func (f *Fosite) validateScopeCount(scope Arguments) error {
+	if f.MaxScopes > 0 && len(scope) > f.MaxScopes {
+		return errorsx.WithStack(ErrInvalidRequest.WithHintf("The request asks for %d scopes, but at most %d scopes are allowed.", len(scope), f.MaxScopes))
+	}
+	return nil
+}
+
+// validateAudienceCount counts the "audience" and "resource" parameters of the form against MaxAudiences.
+func (f *Fosite) validateAudienceCount(form url.Values) error {
+	if count := len(GetAudiences(form)) + len(RemoveEmpty(form["resource"])); f.MaxAudiences > 0 && count > f.MaxAudiences {
+		return errorsx.WithStack(ErrInvalidTarget.WithHintf("The request asks for %d audiences, but at most %d audiences are allowed.", count, f.MaxAudiences))
+	}
+	return nil
+}
@@ -20,6 +20,7 @@ mockgen -package internal -destination internal/id_token_strategy.go github.com/
 mockgen -package internal -destination internal/pkce_storage_strategy.go github.com/ory/fosite/handler/pkce PKCERequestStorage
 mockgen -package internal -destination internal/authorize_handler.go github.com/ory/fosite AuthorizeEndpointHandler
 mockgen -package internal -destination internal/revoke_handler.go github.com/ory/fosite RevocationHandler
+mockgen -package internal -destination internal/device_handler.go github.com/ory/fosite DeviceEndpointHandler
 mockgen -package internal -destination internal/token_handler.go github.com/ory/fosite TokenEndpointHandler
 mockgen -package internal -destination internal/introspector.go github.com/ory/fosite TokenIntrospector
 mockgen -package internal -destination internal/client.go github.com/ory/fosite Client
@@ -76,3 +76,45 @@ type RevocationHandler interface {
 	// RevokeToken handles access and refresh token revocation.
 	RevokeToken(ctx context.Context, token string, tokenType TokenType, client Client) error
 }
+
+// SubjectRevocationHandler is implemented by revocation handlers which are able to revoke all tokens of a subject at
+// once.
+type SubjectRevocationHandler interface {
+	// RevokeTokensBySubject revokes the tokens of all clients issued to the subject and returns their number. It must
+	// return ErrUnknownRequest if the handler is not able to revoke tokens by subject.
+	RevokeTokensBySubject(ctx context.Context, subject string) (int, error)
+}
+
+// ClientRevocationHandler is implemented by revocation handlers which are able to revoke all tokens issued to a client
+// at once.
+type ClientRevocationHandler interface {
+	// RevokeTokensByClient revokes the tokens issued to the client and returns their number. It must return
+	// ErrUnknownRequest if the handler is not able to revoke tokens by client.
+	RevokeTokensByClient(ctx context.Context, clientID string) (int, error)
+}
+
+// PushedAuthorizeEndpointHandler is the interface that allows to handle pushed authorization requests as defined in
+// https://tools.ietf.org/html/rfc9126#section-2.1
+type PushedAuthorizeEndpointHandler interface {
+	// HandlePushedAuthorizeEndpointRequest handles a pushed authorization endpoint request. If the handler is not
+	// responsible for the request, it must return nil and NOT modify session nor responder neither requester.
+	HandlePushedAuthorizeEndpointRequest(ctx context.Context, requester AuthorizeRequester, responder PushedAuthorizeResponder) error
+}
+
+// DeviceEndpointHandler is the interface that allows to handle device authorization requests as defined in
+// https://tools.ietf.org/html/rfc8628#section-3.1
+type DeviceEndpointHandler interface {
+	// HandleDeviceEndpointRequest handles a device authorization endpoint request. If the handler feels that he is not
+	// responsible for the device authorization request, he must return nil and NOT modify session nor responder
+	// neither requester.
+	HandleDeviceEndpointRequest(ctx context.Context, requester DeviceRequester, responder DeviceResponder) error
+}
+
+// BackchannelAuthenticationEndpointHandler is the interface that allows to handle backchannel authentication requests
+// as defined in https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#auth_request
+type BackchannelAuthenticationEndpointHandler interface {
+	// HandleBackchannelAuthenticationEndpointRequest handles a backchannel authentication endpoint request. If the
+	// handler is not responsible for the request, it must return nil and NOT modify session nor responder neither
+	// requester.
+	HandleBackchannelAuthenticationEndpointRequest(ctx context.Context, requester BackchannelAuthenticationRequester, responder BackchannelAuthenticationResponder) error
+}
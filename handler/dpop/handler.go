@@ -0,0 +1,175 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package dpop
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/ory/x/errorsx"
+	"github.com/pkg/errors"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/token/jwt"
+)
+
+// Handler binds access tokens to the key of the DPoP proof sent to the token endpoint, as defined in
+// https://datatracker.ietf.org/doc/html/rfc9449#section-5. The JWK thumbprint is added as "cnf" claim to the
+// session, which is part of JWT access tokens and of the introspection response, and the token type is set to DPoP.
+//
+// The handler never handles a grant on its own. It must be registered after the handlers of the grants the
+// client uses, because these replace the session of the access request.
+type Handler struct {
+	Validator *DPoPProofValidator
+}
+
+func (c *Handler) HandleTokenEndpointRequest(ctx context.Context, request fosite.AccessRequester) error {
+	r, ok := ctx.Value(fosite.RequestContextKey).(*http.Request)
+	if !ok {
+		return errorsx.WithStack(fosite.ErrServerError.WithDebug("The HTTP request is missing in the context."))
+	}
+
+	bound := getThumbprint(request.GetSession())
+	proof, err := c.Validator.ValidateProof(ctx, r, "")
+	if errors.Is(err, fosite.ErrUnknownRequest) {
+		if client, ok := request.GetClient().(fosite.DPoPClient); ok && client.GetDPoPBoundAccessTokens() {
+			return errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The OAuth 2.0 Client must send a DPoP proof with the token request."))
+		}
+		if bound != "" {
+			return errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The grant is bound to a DPoP key, but no DPoP proof was sent with the token request."))
+		}
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	} else if err != nil {
+		return err
+	}
+
+	// Refresh tokens keep the session of the original grant, which must be used with the same key.
+	if bound != "" && subtle.ConstantTimeCompare([]byte(bound), []byte(proof.Thumbprint)) != 1 {
+		return errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The grant is bound to a different DPoP key."))
+	}
+
+	if err := bindKey(request.GetSession(), proof.Thumbprint); err != nil {
+		return err
+	}
+
+	// The request is not handled by this handler, the grant handlers take care of it.
+	return errorsx.WithStack(fosite.ErrUnknownRequest)
+}
+
+func (c *Handler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	if getThumbprint(requester.GetSession()) == "" || !strings.EqualFold(responder.GetTokenType(), "bearer") {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	responder.SetTokenType(TokenType)
+	return nil
+}
+
+// CanSkipClientAuth returns false, because DPoP never replaces client authentication. Otherwise proofs sent with
+// requests failing client authentication would be consumed before the request is rejected.
+func (c *Handler) CanSkipClientAuth(requester fosite.AccessRequester) bool {
+	return false
+}
+
+func (c *Handler) CanHandleTokenEndpointRequest(requester fosite.AccessRequester) bool {
+	return true
+}
+
+// ValidateResourceRequest checks that a DPoP-bound access token is sent using the DPoP authorization scheme together
+// with a DPoP proof which is bound to the access token and signed with the key the token is bound to, as defined in
+// https://datatracker.ietf.org/doc/html/rfc9449#section-7. Tokens which are not DPoP-bound pass the validation.
+func (v *DPoPProofValidator) ValidateResourceRequest(ctx context.Context, r *http.Request, accessToken string, requester fosite.Requester) error {
+	thumbprint := getThumbprint(requester.GetSession())
+	if thumbprint == "" {
+		return nil
+	}
+
+	if scheme := strings.SplitN(r.Header.Get("Authorization"), " ", 2)[0]; !strings.EqualFold(scheme, TokenType) {
+		return errorsx.WithStack(fosite.ErrRequestUnauthorized.WithHint("The access token is bound to a DPoP key and must be sent using the DPoP authorization scheme."))
+	}
+
+	proof, err := v.ValidateProof(ctx, r, accessToken)
+	if errors.Is(err, fosite.ErrUnknownRequest) {
+		return errorsx.WithStack(fosite.ErrRequestUnauthorized.WithHint("The access token is bound to a DPoP key, but no DPoP proof was presented."))
+	} else if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(proof.Thumbprint)) != 1 {
+		return errorsx.WithStack(fosite.ErrRequestUnauthorized.WithHint("The access token is bound to a different DPoP key."))
+	}
+
+	return nil
+}
+
+// AccessTokenFromRequest returns the access token sent using the DPoP authorization scheme, or an empty string.
+func AccessTokenFromRequest(r *http.Request) string {
+	split := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(split) != 2 || !strings.EqualFold(split[0], TokenType) {
+		return ""
+	}
+	return split[1]
+}
+
+func bindKey(session fosite.Session, thumbprint string) error {
+	switch s := session.(type) {
+	case oauth2.JWTSessionContainer:
+		claims, ok := s.GetJWTClaims().(*jwt.JWTClaims)
+		if !ok {
+			return errorsx.WithStack(fosite.ErrServerError.WithDebug("Unable to bind the access token to the DPoP key because the JWT claims are not of type *jwt.JWTClaims."))
+		}
+		cnf, _ := claims.Extra["cnf"].(map[string]interface{})
+		claims.Add("cnf", withThumbprint(cnf, thumbprint))
+	case fosite.ExtraClaimsSession:
+		extra := s.GetExtraClaims()
+		cnf, _ := extra["cnf"].(map[string]interface{})
+		extra["cnf"] = withThumbprint(cnf, thumbprint)
+	default:
+		return errorsx.WithStack(fosite.ErrServerError.WithDebug("Unable to bind the access token to the DPoP key because the session does not support extra claims."))
+	}
+	return nil
+}
+
+// withThumbprint returns a copy of the confirmation claim with the "jkt" member set, so that other confirmation
+// methods like a certificate binding are kept.
+func withThumbprint(cnf map[string]interface{}, thumbprint string) map[string]interface{} {
+	result := map[string]interface{}{"jkt": thumbprint}
+	for k, v := range cnf {
+		if k != "jkt" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func getThumbprint(session fosite.Session) string {
+	s, ok := session.(fosite.ExtraClaimsSession)
+	if !ok {
+		return ""
+	}
+	cnf, _ := s.GetExtraClaims()["cnf"].(map[string]interface{})
+	thumbprint, _ := cnf["jkt"].(string)
+	return thumbprint
+}
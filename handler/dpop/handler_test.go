@@ -0,0 +1,201 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package dpop
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/storage"
+)
+
+func TestHandler(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	const target = "https://server.example.com/token"
+	h := &Handler{Validator: &DPoPProofValidator{Storage: storage.NewMemoryStore()}}
+
+	handle := func(areq fosite.AccessRequester, proofs ...string) error {
+		ctx := context.WithValue(context.Background(), fosite.RequestContextKey, mustRequest(t, "POST", target, proofs...))
+		require.True(t, h.CanHandleTokenEndpointRequest(areq))
+		require.False(t, h.CanSkipClientAuth(areq))
+		return h.HandleTokenEndpointRequest(ctx, areq)
+	}
+
+	t.Run("case=ignores requests without proof", func(t *testing.T) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.Client = &fosite.DefaultClient{ID: "foo"}
+		require.EqualError(t, handle(areq), fosite.ErrUnknownRequest.Error())
+
+		resp := fosite.NewAccessResponse()
+		resp.SetTokenType("bearer")
+		require.EqualError(t, h.PopulateTokenEndpointResponse(context.Background(), areq, resp), fosite.ErrUnknownRequest.Error())
+		assert.Equal(t, "bearer", resp.GetTokenType())
+	})
+
+	t.Run("case=fails without proof if the client requires DPoP", func(t *testing.T) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.Client = &fosite.DefaultClient{ID: "foo", DPoPBoundAccessTokens: true}
+		require.EqualError(t, handle(areq), fosite.ErrInvalidDPoPProof.Error())
+	})
+
+	t.Run("case=fails with invalid proof", func(t *testing.T) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.Client = &fosite.DefaultClient{ID: "foo"}
+		require.EqualError(t, handle(areq, "foo"), fosite.ErrInvalidDPoPProof.Error())
+	})
+
+	for k, session := range []fosite.Session{
+		&fosite.DefaultSession{Extra: map[string]interface{}{"cnf": map[string]interface{}{"x5t#S256": "bar"}}},
+		&oauth2.JWTSession{},
+	} {
+		t.Run(fmt.Sprintf("case=%d/binds the access token", k), func(t *testing.T) {
+			areq := fosite.NewAccessRequest(session)
+			areq.Client = &fosite.DefaultClient{ID: "foo", DPoPBoundAccessTokens: true}
+			proof := mustProof(t, key, nil)
+			require.EqualError(t, handle(areq, proof), fosite.ErrUnknownRequest.Error())
+
+			thumbprint := getThumbprint(session)
+			require.NotEmpty(t, thumbprint)
+			if k == 0 {
+				assert.Equal(t, "bar", session.(fosite.ExtraClaimsSession).GetExtraClaims()["cnf"].(map[string]interface{})["x5t#S256"])
+			}
+
+			resp := fosite.NewAccessResponse()
+			resp.SetTokenType("bearer")
+			require.NoError(t, h.PopulateTokenEndpointResponse(context.Background(), areq, resp))
+			assert.Equal(t, TokenType, resp.GetTokenType())
+
+			// The refreshed grant keeps the session and must be used with the same key.
+			require.EqualError(t, handle(areq), fosite.ErrInvalidDPoPProof.Error())
+			require.EqualError(t, handle(areq, mustProof(t, otherKey, nil)), fosite.ErrInvalidDPoPProof.Error())
+			require.EqualError(t, handle(areq, mustProof(t, key, nil)), fosite.ErrUnknownRequest.Error())
+			assert.Equal(t, thumbprint, getThumbprint(session))
+		})
+	}
+}
+
+func TestHandlerRequiresClientAuthentication(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	const target = "https://server.example.com/token"
+	store := storage.NewMemoryStore()
+	h := &Handler{Validator: &DPoPProofValidator{Storage: store}}
+	f := &fosite.Fosite{Store: store, TokenEndpointHandlers: fosite.TokenEndpointHandlers{h}}
+
+	proof := mustProof(t, key, nil)
+	r := mustRequest(t, "POST", target, proof)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Body = ioutil.NopCloser(strings.NewReader(url.Values{"grant_type": {"client_credentials"}, "client_id": {"unknown"}}.Encode()))
+
+	_, err = f.NewAccessRequest(context.Background(), r, &fosite.DefaultSession{})
+	require.Error(t, err)
+	assert.EqualError(t, err, fosite.ErrInvalidClient.Error())
+
+	// The proof has not been consumed by the rejected request.
+	areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+	areq.Client = &fosite.DefaultClient{ID: "foo"}
+	ctx := context.WithValue(context.Background(), fosite.RequestContextKey, mustRequest(t, "POST", target, proof))
+	require.EqualError(t, h.HandleTokenEndpointRequest(ctx, areq), fosite.ErrUnknownRequest.Error())
+	assert.NotEmpty(t, getThumbprint(areq.GetSession()))
+}
+
+func TestValidateResourceRequest(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	const target = "https://server.example.com/resource"
+	v := &DPoPProofValidator{Storage: storage.NewMemoryStore()}
+
+	h := &Handler{Validator: v}
+	bound := fosite.NewAccessRequest(&fosite.DefaultSession{})
+	bound.Client = &fosite.DefaultClient{ID: "foo"}
+	ctx := context.WithValue(context.Background(), fosite.RequestContextKey, mustRequest(t, "POST", "https://server.example.com/token", mustProof(t, key, nil)))
+	require.EqualError(t, h.HandleTokenEndpointRequest(ctx, bound), fosite.ErrUnknownRequest.Error())
+
+	unbound := fosite.NewAccessRequest(&fosite.DefaultSession{})
+
+	withProof := func(scheme string, key interface{}, ath string) func(t *testing.T) error {
+		return func(t *testing.T) error {
+			r := mustRequest(t, "GET", target)
+			if key != nil {
+				r.Header.Set(HeaderName, mustProof(t, key, func(o *proofOptions) {
+					o.htm = "GET"
+					o.htu = target
+					o.ath = AccessTokenHash(ath)
+				}))
+			}
+			r.Header.Set("Authorization", scheme+" foo")
+			requester := bound
+			if scheme == "Bearer" && key == nil {
+				requester = unbound
+			}
+			return v.ValidateResourceRequest(context.Background(), r, "foo", requester)
+		}
+	}
+
+	for k, c := range []struct {
+		description string
+		validate    func(t *testing.T) error
+		expectErr   error
+	}{
+		{description: "should pass unbound tokens", validate: withProof("Bearer", nil, "")},
+		{description: "should pass", validate: withProof("DPoP", key, "foo")},
+		{description: "should fail with bearer scheme", validate: withProof("Bearer", key, "foo"), expectErr: fosite.ErrRequestUnauthorized},
+		{description: "should fail without proof", validate: withProof("DPoP", nil, ""), expectErr: fosite.ErrRequestUnauthorized},
+		{description: "should fail with wrong ath", validate: withProof("DPoP", key, "bar"), expectErr: fosite.ErrInvalidDPoPProof},
+		{description: "should fail with other key", validate: withProof("DPoP", otherKey, "foo"), expectErr: fosite.ErrRequestUnauthorized},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			err := c.validate(t)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	r := mustRequest(t, "GET", target)
+	r.Header.Set("Authorization", "DPoP foo")
+	assert.Equal(t, "foo", AccessTokenFromRequest(r))
+	r.Header.Set("Authorization", "Bearer foo")
+	assert.Empty(t, AccessTokenFromRequest(r))
+}
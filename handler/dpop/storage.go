@@ -0,0 +1,39 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package dpop
+
+import (
+	"context"
+	"time"
+)
+
+// DPoPJTIStorage keeps track of the "jti" values of DPoP proofs which have been used already.
+type DPoPJTIStorage interface {
+	// IsDPoPJTIUsed returns true, if a DPoP proof with the given "jti" has been seen before and its replay window has
+	// not expired yet.
+	IsDPoPJTIUsed(ctx context.Context, jti string) (bool, error)
+
+	// MarkDPoPJTIUsedForTime marks the "jti" of a DPoP proof as used until exp. This prevents proofs from being
+	// replayed for as long as they would be accepted (https://datatracker.ietf.org/doc/html/rfc9449#section-11.1).
+	// It must check and mark the "jti" atomically and return fosite.ErrJTIKnown if it is used already.
+	MarkDPoPJTIUsedForTime(ctx context.Context, jti string, exp time.Time) error
+}
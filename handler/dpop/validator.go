@@ -0,0 +1,246 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package dpop
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ory/x/errorsx"
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/ory/fosite"
+)
+
+const (
+	// HeaderName is the name of the HTTP header carrying the DPoP proof.
+	HeaderName = "DPoP"
+	// TokenType is the token type of DPoP-bound access tokens.
+	TokenType = "DPoP"
+
+	proofType        = "dpop+jwt"
+	defaultMaxAge    = time.Minute
+	defaultClockSkew = 5 * time.Second
+)
+
+// DefaultSigningAlgorithms lists the asymmetric algorithms accepted for DPoP proofs unless configured otherwise.
+var DefaultSigningAlgorithms = []string{
+	string(jose.RS256), string(jose.RS384), string(jose.RS512),
+	string(jose.PS256), string(jose.PS384), string(jose.PS512),
+	string(jose.ES256), string(jose.ES384), string(jose.ES512),
+	string(jose.EdDSA),
+}
+
+// Proof is a validated DPoP proof.
+type Proof struct {
+	// ID is the "jti" claim of the proof.
+	ID string
+	// HTTPMethod is the "htm" claim of the proof.
+	HTTPMethod string
+	// HTTPURI is the "htu" claim of the proof.
+	HTTPURI string
+	// IssuedAt is the "iat" claim of the proof.
+	IssuedAt time.Time
+	// AccessTokenHash is the "ath" claim of the proof.
+	AccessTokenHash string
+	// JSONWebKey is the public key the proof is signed with.
+	JSONWebKey *jose.JSONWebKey
+	// Thumbprint is the base64url encoded JWK SHA-256 thumbprint of the public key, used as "jkt" confirmation.
+	Thumbprint string
+}
+
+type proofClaims struct {
+	HTTPMethod      string `json:"htm"`
+	HTTPURI         string `json:"htu"`
+	AccessTokenHash string `json:"ath,omitempty"`
+}
+
+// DPoPProofValidator validates DPoP proofs as defined in https://datatracker.ietf.org/doc/html/rfc9449#section-4.3
+type DPoPProofValidator struct {
+	Storage DPoPJTIStorage
+
+	// MaxAge is the maximum age of a proof based on its "iat" claim. Defaults to one minute.
+	MaxAge time.Duration
+	// ClockSkew is the tolerated difference between the clocks of the client and the server. Defaults to five seconds.
+	ClockSkew time.Duration
+	// SigningAlgorithms are the accepted proof signing algorithms. Defaults to DefaultSigningAlgorithms.
+	SigningAlgorithms []string
+	// RequestURL returns the URL the request was sent to, which is compared with the "htu" claim. This needs to
+	// be set if the server runs behind a proxy. Defaults to a URL built from the request's TLS state, host and path.
+	RequestURL func(r *http.Request) *url.URL
+}
+
+// ValidateProof validates the DPoP proof of the request. If accessToken is not empty, the proof must be bound to it
+// using the "ath" claim. It returns ErrUnknownRequest if the request does not carry a DPoP proof.
+func (v *DPoPProofValidator) ValidateProof(ctx context.Context, r *http.Request, accessToken string) (*Proof, error) {
+	values := r.Header.Values(HeaderName)
+	if len(values) == 0 {
+		return nil, errorsx.WithStack(fosite.ErrUnknownRequest)
+	} else if len(values) > 1 {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The request must not contain more than one DPoP proof."))
+	}
+
+	token, err := jwt.ParseSigned(values[0])
+	if err != nil {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("Unable to parse the DPoP proof.").WithWrap(err).WithDebug(err.Error()))
+	}
+	if len(token.Headers) != 1 {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The DPoP proof must have exactly one signature."))
+	}
+
+	header := token.Headers[0]
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != proofType {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHintf("The DPoP proof must have the \"typ\" header set to \"%s\".", proofType))
+	}
+	if !v.isAllowedAlgorithm(header.Algorithm) {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHintf("The DPoP proof is signed using the unsupported algorithm \"%s\".", header.Algorithm))
+	}
+	if header.JSONWebKey == nil || !header.JSONWebKey.Valid() || !header.JSONWebKey.IsPublic() {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The DPoP proof must contain a valid public key in the \"jwk\" header."))
+	}
+
+	var claims jwt.Claims
+	var dpopClaims proofClaims
+	if err := token.Claims(header.JSONWebKey, &claims, &dpopClaims); err != nil {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("Unable to verify the signature of the DPoP proof.").WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if claims.ID == "" {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The DPoP proof must contain a \"jti\" claim."))
+	}
+
+	if dpopClaims.HTTPMethod != r.Method {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHintf("The \"htm\" claim of the DPoP proof does not match the request method \"%s\".", r.Method))
+	}
+
+	if err := v.validateHTTPURI(r, dpopClaims.HTTPURI); err != nil {
+		return nil, err
+	}
+
+	if claims.IssuedAt == nil {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The DPoP proof must contain an \"iat\" claim."))
+	}
+	now := time.Now().UTC()
+	iat := claims.IssuedAt.Time()
+	if iat.After(now.Add(v.getClockSkew())) {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The DPoP proof was issued in the future."))
+	}
+	if iat.Add(v.getMaxAge()).Before(now.Add(-v.getClockSkew())) {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The DPoP proof is too old."))
+	}
+
+	if accessToken != "" {
+		if dpopClaims.AccessTokenHash == "" {
+			return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The DPoP proof must contain an \"ath\" claim when presented with an access token."))
+		}
+		if subtle.ConstantTimeCompare([]byte(dpopClaims.AccessTokenHash), []byte(AccessTokenHash(accessToken))) != 1 {
+			return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The \"ath\" claim of the DPoP proof does not match the access token."))
+		}
+	}
+
+	thumbprint, err := header.JSONWebKey.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("Unable to compute the thumbprint of the DPoP proof key.").WithWrap(err).WithDebug(err.Error()))
+	}
+
+	// The proof is accepted until it is too old, so its jti must be remembered at least for that long. Marking the
+	// jti fails atomically for replayed proofs, a separate lookup would let concurrent replays pass.
+	if err := v.Storage.MarkDPoPJTIUsedForTime(ctx, claims.ID, iat.Add(v.getMaxAge()+v.getClockSkew())); errors.Is(err, fosite.ErrJTIKnown) {
+		return nil, errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The DPoP proof has been used before.").WithWrap(err).WithDebug(err.Error()))
+	} else if err != nil {
+		return nil, errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	return &Proof{
+		ID:              claims.ID,
+		HTTPMethod:      dpopClaims.HTTPMethod,
+		HTTPURI:         dpopClaims.HTTPURI,
+		IssuedAt:        iat,
+		AccessTokenHash: dpopClaims.AccessTokenHash,
+		JSONWebKey:      header.JSONWebKey,
+		Thumbprint:      base64.RawURLEncoding.EncodeToString(thumbprint),
+	}, nil
+}
+
+// AccessTokenHash returns the value of the "ath" claim for the given access token.
+func AccessTokenHash(accessToken string) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func (v *DPoPProofValidator) validateHTTPURI(r *http.Request, htu string) error {
+	actual, err := url.Parse(htu)
+	if err != nil || htu == "" {
+		return errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The DPoP proof must contain a valid \"htu\" claim."))
+	}
+
+	var expected *url.URL
+	if v.RequestURL != nil {
+		expected = v.RequestURL(r)
+	} else {
+		expected = &url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+		if r.TLS != nil {
+			expected.Scheme = "https"
+		}
+	}
+
+	// The query and fragment parts are ignored as per https://datatracker.ietf.org/doc/html/rfc9449#section-4.3
+	if actual.Scheme != expected.Scheme || actual.Host != expected.Host || actual.Path != expected.Path {
+		return errorsx.WithStack(fosite.ErrInvalidDPoPProof.WithHint("The \"htu\" claim of the DPoP proof does not match the request URL."))
+	}
+	return nil
+}
+
+func (v *DPoPProofValidator) isAllowedAlgorithm(alg string) bool {
+	algorithms := v.SigningAlgorithms
+	if len(algorithms) == 0 {
+		algorithms = DefaultSigningAlgorithms
+	}
+	for _, a := range algorithms {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *DPoPProofValidator) getMaxAge() time.Duration {
+	if v.MaxAge == 0 {
+		return defaultMaxAge
+	}
+	return v.MaxAge
+}
+
+func (v *DPoPProofValidator) getClockSkew() time.Duration {
+	if v.ClockSkew == 0 {
+		return defaultClockSkew
+	}
+	return v.ClockSkew
+}
@@ -0,0 +1,294 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package dpop
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+type failingJTIStorage struct {
+	*storage.MemoryStore
+}
+
+func (s failingJTIStorage) MarkDPoPJTIUsedForTime(context.Context, string, time.Time) error {
+	return errors.New("database unavailable")
+}
+
+type proofOptions struct {
+	typ   string
+	key   interface{}
+	alg   jose.SignatureAlgorithm
+	htm   string
+	htu   string
+	iat   time.Time
+	jti   string
+	ath   string
+	noJWK bool
+}
+
+func mustProof(t *testing.T, key interface{}, modify func(o *proofOptions)) string {
+	o := &proofOptions{
+		typ: "dpop+jwt",
+		key: key,
+		alg: jose.ES256,
+		htm: "POST",
+		htu: "https://server.example.com/token",
+		iat: time.Now(),
+		jti: uuid.New(),
+	}
+	if modify != nil {
+		modify(o)
+	}
+
+	opts := (&jose.SignerOptions{}).WithType(jose.ContentType(o.typ))
+	if !o.noJWK {
+		opts.EmbedJWK = true
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: o.alg, Key: o.key}, opts)
+	require.NoError(t, err)
+
+	proof, err := jwt.Signed(signer).
+		Claims(jwt.Claims{ID: o.jti, IssuedAt: jwt.NewNumericDate(o.iat)}).
+		Claims(proofClaims{HTTPMethod: o.htm, HTTPURI: o.htu, AccessTokenHash: o.ath}).
+		CompactSerialize()
+	require.NoError(t, err)
+	return proof
+}
+
+func mustRequest(t *testing.T, method, target string, proofs ...string) *http.Request {
+	r, err := http.NewRequest(method, target, nil)
+	require.NoError(t, err)
+	if r.URL.Scheme == "https" {
+		r.TLS = &tls.ConnectionState{}
+	}
+	for _, proof := range proofs {
+		r.Header.Add(HeaderName, proof)
+	}
+	return r
+}
+
+func TestDPoPProofValidator(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	const target = "https://server.example.com/token"
+	usedProof := mustProof(t, key, nil)
+
+	v := &DPoPProofValidator{Storage: storage.NewMemoryStore()}
+	_, err = v.ValidateProof(context.Background(), mustRequest(t, "POST", target, usedProof), "")
+	require.NoError(t, err)
+
+	for k, c := range []struct {
+		description string
+		request     *http.Request
+		accessToken string
+		expectErr   error
+	}{
+		{
+			description: "should return unknown request without proof",
+			request:     mustRequest(t, "POST", target),
+			expectErr:   fosite.ErrUnknownRequest,
+		},
+		{
+			description: "should pass",
+			request:     mustRequest(t, "POST", target, mustProof(t, key, nil)),
+		},
+		{
+			description: "should pass with RSA key and ignore the query",
+			request: mustRequest(t, "POST", target+"?foo=bar", mustProof(t, rsaKey, func(o *proofOptions) {
+				o.alg = jose.PS256
+			})),
+		},
+		{
+			description: "should fail with multiple proofs",
+			request:     mustRequest(t, "POST", target, mustProof(t, key, nil), mustProof(t, key, nil)),
+			expectErr:   fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail with malformed proof",
+			request:     mustRequest(t, "POST", target, "foo.bar.baz"),
+			expectErr:   fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail with wrong typ",
+			request: mustRequest(t, "POST", target, mustProof(t, key, func(o *proofOptions) {
+				o.typ = "JWT"
+			})),
+			expectErr: fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail with symmetric algorithm",
+			request: mustRequest(t, "POST", target, mustProof(t, []byte("some-super-secret-key-of-32-bytes"), func(o *proofOptions) {
+				o.alg = jose.HS256
+				o.noJWK = true
+			})),
+			expectErr: fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail without jwk",
+			request: mustRequest(t, "POST", target, mustProof(t, key, func(o *proofOptions) {
+				o.noJWK = true
+			})),
+			expectErr: fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail with wrong htm",
+			request:     mustRequest(t, "GET", target, mustProof(t, key, nil)),
+			expectErr:   fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail with wrong htu",
+			request:     mustRequest(t, "POST", "https://server.example.com/other", mustProof(t, key, nil)),
+			expectErr:   fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail with plain http",
+			request:     mustRequest(t, "POST", "http://server.example.com/token", mustProof(t, key, nil)),
+			expectErr:   fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail without jti",
+			request: mustRequest(t, "POST", target, mustProof(t, key, func(o *proofOptions) {
+				o.jti = ""
+			})),
+			expectErr: fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail when too old",
+			request: mustRequest(t, "POST", target, mustProof(t, key, func(o *proofOptions) {
+				o.iat = time.Now().Add(-time.Hour)
+			})),
+			expectErr: fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail when issued in the future",
+			request: mustRequest(t, "POST", target, mustProof(t, key, func(o *proofOptions) {
+				o.iat = time.Now().Add(time.Hour)
+			})),
+			expectErr: fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail when replayed",
+			request:     mustRequest(t, "POST", target, usedProof),
+			expectErr:   fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should pass with matching ath",
+			request: mustRequest(t, "POST", target, mustProof(t, key, func(o *proofOptions) {
+				o.ath = AccessTokenHash("foo")
+			})),
+			accessToken: "foo",
+		},
+		{
+			description: "should fail without ath",
+			request:     mustRequest(t, "POST", target, mustProof(t, key, nil)),
+			accessToken: "foo",
+			expectErr:   fosite.ErrInvalidDPoPProof,
+		},
+		{
+			description: "should fail with mismatching ath",
+			request: mustRequest(t, "POST", target, mustProof(t, key, func(o *proofOptions) {
+				o.ath = AccessTokenHash("bar")
+			})),
+			accessToken: "foo",
+			expectErr:   fosite.ErrInvalidDPoPProof,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			proof, err := v.ValidateProof(context.Background(), c.request, c.accessToken)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, proof.Thumbprint)
+			assert.Equal(t, c.request.Method, proof.HTTPMethod)
+		})
+	}
+
+	t.Run("case=uses the configured request url", func(t *testing.T) {
+		v := &DPoPProofValidator{
+			Storage: storage.NewMemoryStore(),
+			RequestURL: func(r *http.Request) *url.URL {
+				return &url.URL{Scheme: "https", Host: "server.example.com", Path: r.URL.Path}
+			},
+		}
+		_, err := v.ValidateProof(context.Background(), mustRequest(t, "POST", "http://internal/token", mustProof(t, key, nil)), "")
+		require.NoError(t, err)
+	})
+
+	t.Run("case=accepts concurrent replays once only", func(t *testing.T) {
+		v := &DPoPProofValidator{Storage: storage.NewMemoryStore()}
+		proof := mustProof(t, key, nil)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 10)
+		for i := range errs {
+			r := mustRequest(t, "POST", target, proof)
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = v.ValidateProof(context.Background(), r, "")
+			}(i)
+		}
+		wg.Wait()
+
+		var accepted int
+		for _, err := range errs {
+			if err == nil {
+				accepted++
+			} else {
+				assert.EqualError(t, err, fosite.ErrInvalidDPoPProof.Error())
+			}
+		}
+		assert.Equal(t, 1, accepted)
+	})
+
+	t.Run("case=fails with a server error if the jti can not be stored", func(t *testing.T) {
+		v := &DPoPProofValidator{Storage: failingJTIStorage{MemoryStore: storage.NewMemoryStore()}}
+		_, err := v.ValidateProof(context.Background(), mustRequest(t, "POST", target, mustProof(t, key, nil)), "")
+		require.EqualError(t, err, fosite.ErrServerError.Error())
+	})
+}
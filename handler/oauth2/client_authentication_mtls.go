@@ -0,0 +1,168 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+)
+
+// Client authentication methods as defined in https://tools.ietf.org/html/rfc8705#section-2.1.1 and
+// https://tools.ietf.org/html/rfc8705#section-2.2.1
+const (
+	ClientAuthMethodTLS           = "tls_client_auth"
+	ClientAuthMethodSelfSignedTLS = "self_signed_tls_client_auth"
+)
+
+// ClientCertificateFunc returns the client certificate presented during the TLS handshake, or nil if there is none.
+// A custom function is needed if TLS is terminated by a proxy which forwards the certificate, for example in an
+// HTTP header. The certificate returned by the function must have been validated against the trusted certificate
+// authorities, only self-signed certificates may be returned unvalidated.
+type ClientCertificateFunc func(r *http.Request) *x509.Certificate
+
+// DefaultClientCertificate returns the leaf certificate the client presented during the TLS handshake.
+func DefaultClientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return r.TLS.PeerCertificates[0]
+}
+
+// CertificateThumbprint returns the base64url-encoded SHA-256 thumbprint of the DER encoding of the certificate as
+// used by the "x5t#S256" confirmation method.
+func CertificateThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// MTLSClientAuthenticationStrategy authenticates clients using mutual TLS as defined in
+// https://tools.ietf.org/html/rfc8705#section-2. Clients not using tls_client_auth or self_signed_tls_client_auth
+// are authenticated using the Fallback strategy, which usually is fosite.Fosite.DefaultClientAuthenticationStrategy.
+// Use AuthenticateClient as fosite.Fosite.ClientAuthenticationStrategy.
+type MTLSClientAuthenticationStrategy struct {
+	Store             fosite.ClientManager
+	ClientCertificate ClientCertificateFunc
+	Fallback          fosite.ClientAuthenticationStrategy
+}
+
+func (s *MTLSClientAuthenticationStrategy) AuthenticateClient(ctx context.Context, r *http.Request, form url.Values) (fosite.Client, error) {
+	clientID := form.Get("client_id")
+	if clientID == "" {
+		return s.fallback(ctx, r, form)
+	}
+
+	client, err := fosite.GetClientWithContext(ctx, s.Store, clientID)
+	if err != nil {
+		return nil, errorsx.WithStack(fosite.ErrInvalidClient.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	tlsClient, ok := client.(fosite.TLSClient)
+	if !ok {
+		return s.fallback(ctx, r, form)
+	}
+
+	method := tlsClient.GetTokenEndpointAuthMethod()
+	if method != ClientAuthMethodTLS && method != ClientAuthMethodSelfSignedTLS {
+		return s.fallback(ctx, r, form)
+	}
+
+	cert := s.clientCertificate(r)
+	if cert == nil {
+		return nil, errorsx.WithStack(fosite.ErrInvalidClient.WithHintf("The OAuth 2.0 Client supports client authentication method '%s', but no client certificate was presented.", method))
+	}
+
+	if method == ClientAuthMethodTLS {
+		if !matchesTLSClientAuthMetadata(tlsClient, cert) {
+			return nil, errorsx.WithStack(fosite.ErrInvalidClient.WithHint("The client certificate does not match the certificate subject or subject alternative names registered for the OAuth 2.0 Client."))
+		}
+		return client, nil
+	}
+
+	if !matchesRegisteredKey(tlsClient, cert) {
+		return nil, errorsx.WithStack(fosite.ErrInvalidClient.WithHint("The client certificate does not match any of the keys registered for the OAuth 2.0 Client."))
+	}
+	return client, nil
+}
+
+func (s *MTLSClientAuthenticationStrategy) fallback(ctx context.Context, r *http.Request, form url.Values) (fosite.Client, error) {
+	if s.Fallback == nil {
+		return nil, errorsx.WithStack(fosite.ErrInvalidClient.WithHint("The OAuth 2.0 Client must authenticate using mutual TLS."))
+	}
+	return s.Fallback(ctx, r, form)
+}
+
+func (s *MTLSClientAuthenticationStrategy) clientCertificate(r *http.Request) *x509.Certificate {
+	if s.ClientCertificate == nil {
+		return DefaultClientCertificate(r)
+	}
+	return s.ClientCertificate(r)
+}
+
+// matchesTLSClientAuthMetadata checks the certificate against the subject DN registered for the client or, if the
+// client did not register one, against the registered subject alternative names.
+func matchesTLSClientAuthMetadata(client fosite.TLSClient, cert *x509.Certificate) bool {
+	if dn := client.GetTLSClientAuthSubjectDN(); dn != "" {
+		return dn == cert.Subject.String()
+	}
+
+	for _, expected := range client.GetTLSClientAuthSANDNS() {
+		for _, name := range cert.DNSNames {
+			if expected == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchesRegisteredKey(client fosite.TLSClient, cert *x509.Certificate) bool {
+	keys := client.GetJSONWebKeys()
+	if keys == nil {
+		return false
+	}
+
+	for _, key := range keys.Keys {
+		for _, registered := range key.Certificates {
+			if bytes.Equal(registered.Raw, cert.Raw) {
+				return true
+			}
+		}
+
+		pub, ok := key.Public().Key.(interface{ Equal(x crypto.PublicKey) bool })
+		if ok && pub.Equal(cert.PublicKey) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func mustCertificate(t *testing.T, cn string, dnsNames ...string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func TestMTLSClientAuthenticationStrategy(t *testing.T) {
+	cert, key := mustCertificate(t, "foo", "foo.example.com")
+	otherCert, _ := mustCertificate(t, "bar", "bar.example.com")
+
+	store := storage.NewMemoryStore()
+	store.Clients["tls"] = &fosite.DefaultTLSClient{
+		DefaultOpenIDConnectClient: &fosite.DefaultOpenIDConnectClient{
+			DefaultClient:           &fosite.DefaultClient{ID: "tls"},
+			TokenEndpointAuthMethod: ClientAuthMethodTLS,
+		},
+		TLSClientAuthSubjectDN: "CN=foo",
+	}
+	store.Clients["tls-san"] = &fosite.DefaultTLSClient{
+		DefaultOpenIDConnectClient: &fosite.DefaultOpenIDConnectClient{
+			DefaultClient:           &fosite.DefaultClient{ID: "tls-san"},
+			TokenEndpointAuthMethod: ClientAuthMethodTLS,
+		},
+		TLSClientAuthSANDNS: []string{"foo.example.com"},
+	}
+	store.Clients["tls-dn-san"] = &fosite.DefaultTLSClient{
+		DefaultOpenIDConnectClient: &fosite.DefaultOpenIDConnectClient{
+			DefaultClient:           &fosite.DefaultClient{ID: "tls-dn-san"},
+			TokenEndpointAuthMethod: ClientAuthMethodTLS,
+		},
+		TLSClientAuthSubjectDN: "CN=bar",
+		TLSClientAuthSANDNS:    []string{"foo.example.com"},
+	}
+	store.Clients["self-signed"] = &fosite.DefaultTLSClient{
+		DefaultOpenIDConnectClient: &fosite.DefaultOpenIDConnectClient{
+			DefaultClient:           &fosite.DefaultClient{ID: "self-signed"},
+			TokenEndpointAuthMethod: ClientAuthMethodSelfSignedTLS,
+			JSONWebKeys:             &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey}}},
+		},
+	}
+	store.Clients["basic"] = &fosite.DefaultClient{ID: "basic"}
+
+	fallback := func(_ context.Context, _ *http.Request, form url.Values) (fosite.Client, error) {
+		return &fosite.DefaultClient{ID: "fallback"}, nil
+	}
+
+	for k, c := range []struct {
+		description string
+		clientID    string
+		cert        *x509.Certificate
+		fallback    fosite.ClientAuthenticationStrategy
+		expectErr   error
+		expectID    string
+	}{
+		{
+			description: "should use the fallback because no client id is given",
+			fallback:    fallback,
+			expectID:    "fallback",
+		},
+		{
+			description: "should use the fallback because the client does not use mutual TLS",
+			clientID:    "basic",
+			fallback:    fallback,
+			expectID:    "fallback",
+		},
+		{
+			description: "should fail because the client does not use mutual TLS and no fallback is set",
+			clientID:    "basic",
+			expectErr:   fosite.ErrInvalidClient,
+		},
+		{
+			description: "should fail because the client is unknown",
+			clientID:    "unknown",
+			expectErr:   fosite.ErrInvalidClient,
+		},
+		{
+			description: "should fail because no certificate is presented",
+			clientID:    "tls",
+			expectErr:   fosite.ErrInvalidClient,
+		},
+		{
+			description: "should fail because the subject dn does not match",
+			clientID:    "tls",
+			cert:        otherCert,
+			expectErr:   fosite.ErrInvalidClient,
+		},
+		{
+			description: "should pass because the subject dn matches",
+			clientID:    "tls",
+			cert:        cert,
+			expectID:    "tls",
+		},
+		{
+			description: "should pass because the dns san matches",
+			clientID:    "tls-san",
+			cert:        cert,
+			expectID:    "tls-san",
+		},
+		{
+			description: "should fail because the subject dn does not match even though the dns san matches",
+			clientID:    "tls-dn-san",
+			cert:        cert,
+			expectErr:   fosite.ErrInvalidClient,
+		},
+		{
+			description: "should fail because the self-signed certificate is not registered",
+			clientID:    "self-signed",
+			cert:        otherCert,
+			expectErr:   fosite.ErrInvalidClient,
+		},
+		{
+			description: "should pass because the self-signed certificate is registered",
+			clientID:    "self-signed",
+			cert:        cert,
+			expectID:    "self-signed",
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			s := &MTLSClientAuthenticationStrategy{Store: store, Fallback: c.fallback}
+			r := &http.Request{}
+			if c.cert != nil {
+				r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{c.cert}}
+			}
+
+			form := url.Values{}
+			if c.clientID != "" {
+				form.Set("client_id", c.clientID)
+			}
+
+			client, err := s.AuthenticateClient(context.Background(), r, form)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expectID, client.GetID())
+		})
+	}
+}
@@ -63,9 +63,24 @@ type AuthorizeExplicitGrantHandler struct {
 
 	RefreshTokenScopes []string
 
+	// EnforceOfflineAccess issues refresh tokens only if the offline_access scope has been granted to a client which
+	// implements fosite.OfflineAccessClient and is allowed offline access. For OpenID Connect requests, the end-user
+	// must additionally have been prompted for consent. Otherwise the refresh token is withheld, the token request
+	// does not fail.
+	EnforceOfflineAccess bool
+
 	// OmitRedirectScopeParam must be set to true if the scope query param is to be omitted
 	// in the authorization's redirect URI
 	OmitRedirectScopeParam bool
+
+	// CodeReuseGracePeriod is the period after an authorize code exchange in which a retry of the token request with
+	// the same parameters is answered with the tokens issued before, instead of being treated as a replay which
+	// revokes them. Requires a CoreStorage implementing fosite.AuthorizeCodeExchangeStorage. Defaults to zero, which
+	// treats every reuse of an authorize code as a replay.
+	CodeReuseGracePeriod time.Duration
+
+	// IssuanceHooks are notified about the issued tokens once they have been persisted.
+	IssuanceHooks *TokenIssuanceHooks
 }
 
 func (c *AuthorizeExplicitGrantHandler) secureChecker() func(*url.URL) bool {
@@ -81,6 +96,10 @@ func (c *AuthorizeExplicitGrantHandler) HandleAuthorizeEndpointRequest(ctx conte
 		return nil
 	}
 
+	if err := fosite.ValidateClientGrantType(ar.GetClient(), "authorization_code"); err != nil {
+		return err
+	}
+
 	ar.SetDefaultResponseMode(fosite.ResponseModeQuery)
 
 	// Disabled because this is already handled at the authorize_request_handler
@@ -112,8 +131,8 @@ func (c *AuthorizeExplicitGrantHandler) IssueAuthorizeCode(ctx context.Context,
 		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
 	}
 
-	ar.GetSession().SetExpiresAt(fosite.AuthorizeCode, time.Now().UTC().Add(c.AuthCodeLifespan))
-	if err := c.CoreStorage.CreateAuthorizeCodeSession(ctx, signature, ar.Sanitize(c.GetSanitationWhiteList())); err != nil {
+	ar.GetSession().SetExpiresAt(fosite.AuthorizeCode, time.Now().UTC().Add(fosite.GetEffectiveLifespan(ar.GetClient(), "authorization_code", fosite.AuthorizeCode, c.AuthCodeLifespan)))
+	if err := c.CoreStorage.CreateAuthorizeCodeSession(ctx, signature, c.authorizeCodeStoreRequest(ar)); err != nil {
 		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
 	}
 
@@ -127,6 +146,19 @@ func (c *AuthorizeExplicitGrantHandler) IssueAuthorizeCode(ctx context.Context,
 	return nil
 }
 
+// authorizeCodeStoreRequest sanitizes the authorize request for storage. The redirect_uri and prompt parameters are
+// always kept, even if they are not part of the sanitation white list, because the token request has to be validated
+// against them.
+func (c *AuthorizeExplicitGrantHandler) authorizeCodeStoreRequest(ar fosite.AuthorizeRequester) fosite.Requester {
+	storeReq := ar.Sanitize(c.GetSanitationWhiteList())
+	for _, param := range []string{"redirect_uri", "prompt"} {
+		if value := ar.GetRequestForm().Get(param); value != "" {
+			storeReq.GetRequestForm().Set(param, value)
+		}
+	}
+	return storeReq
+}
+
 func (c *AuthorizeExplicitGrantHandler) GetSanitationWhiteList() []string {
 	if len(c.SanitationWhiteList) > 0 {
 		return c.SanitationWhiteList
@@ -22,6 +22,7 @@
 package oauth2
 
 import (
+	"context"
 	"net/url"
 	"strings"
 	"testing"
@@ -89,6 +90,22 @@ func TestAuthorizeCode_HandleAuthorizeEndpointRequest(t *testing.T) {
 					description: "should fail because redirect uri is not https",
 					expectErr:   fosite.ErrInvalidRequest,
 				},
+				{
+					handler: handler,
+					areq: &fosite.AuthorizeRequest{
+						ResponseTypes: fosite.Arguments{"code"},
+						Request: fosite.Request{
+							Client: &fosite.DefaultClient{
+								GrantTypes:    fosite.Arguments{"implicit"},
+								ResponseTypes: fosite.Arguments{"code"},
+								RedirectURIs:  []string{"https://asdf.com/cb"},
+							},
+						},
+						RedirectURI: parseUrl("https://asdf.com/cb"),
+					},
+					description: "should fail because client is not registered for the authorization code grant",
+					expectErr:   fosite.ErrUnauthorizedClient,
+				},
 				{
 					handler: handler,
 					areq: &fosite.AuthorizeRequest{
@@ -190,3 +207,26 @@ func TestAuthorizeCode_HandleAuthorizeEndpointRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorizeCode_IssueAuthorizeCodeStoresPrompt(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := AuthorizeExplicitGrantHandler{
+		CoreStorage:           store,
+		AuthorizeCodeStrategy: hmacshaStrategy,
+		AuthCodeLifespan:      time.Minute,
+	}
+
+	ar := fosite.NewAuthorizeRequest()
+	ar.Client = &fosite.DefaultClient{}
+	ar.Session = &fosite.DefaultSession{}
+	ar.Form.Set("prompt", "consent")
+	ar.Form.Set("login_hint", "peter")
+
+	resp := fosite.NewAuthorizeResponse()
+	require.NoError(t, h.IssueAuthorizeCode(context.Background(), ar, resp))
+
+	stored, err := store.GetAuthorizeCodeSession(context.Background(), hmacshaStrategy.AuthorizeCodeSignature(resp.GetCode()), &fosite.DefaultSession{})
+	require.NoError(t, err)
+	assert.Equal(t, "consent", stored.GetRequestForm().Get("prompt"))
+	assert.Empty(t, stored.GetRequestForm().Get("login_hint"))
+}
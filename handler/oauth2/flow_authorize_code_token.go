@@ -41,13 +41,19 @@ func (c *AuthorizeExplicitGrantHandler) HandleTokenEndpointRequest(ctx context.C
 		return errorsx.WithStack(errorsx.WithStack(fosite.ErrUnknownRequest))
 	}
 
-	if !request.GetClient().GetGrantTypes().Has("authorization_code") {
-		return errorsx.WithStack(fosite.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use authorization grant \"authorization_code\"."))
+	if err := fosite.ValidateClientGrantType(request.GetClient(), "authorization_code"); err != nil {
+		return err
 	}
 
 	code := request.GetRequestForm().Get("code")
 	signature := c.AuthorizeCodeStrategy.AuthorizeCodeSignature(code)
 	authorizeRequest, err := c.CoreStorage.GetAuthorizeCodeSession(ctx, signature, request.GetSession())
+	if errors.Is(err, fosite.ErrInvalidatedAuthorizeCode) && authorizeRequest != nil && c.getCodeExchangeRetry(ctx, signature, request) != nil {
+		// The token request is a retry of the exchange of the authorize code. It is validated like the original
+		// request and answered with the original response by PopulateTokenEndpointResponse.
+		err = nil
+	}
+
 	if errors.Is(err, fosite.ErrInvalidatedAuthorizeCode) {
 		if authorizeRequest == nil {
 			return fosite.ErrServerError.
@@ -67,6 +73,12 @@ func (c *AuthorizeExplicitGrantHandler) HandleTokenEndpointRequest(ctx context.C
 			hint += " Additionally, an error occurred during processing the refresh token revocation."
 			debug += "Revocation of refresh_token lead to error " + revErr.Error() + "."
 		}
+		// Retries must not be answered with the revoked tokens.
+		if exchangeStorage, ok := c.CoreStorage.(fosite.AuthorizeCodeExchangeStorage); ok {
+			if delErr := exchangeStorage.DeleteAuthorizeCodeExchange(ctx, signature); delErr != nil {
+				debug += "Deletion of the authorization code exchange lead to error " + delErr.Error() + "."
+			}
+		}
 		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint(hint).WithDebug(debug))
 	} else if err != nil && errors.Is(err, fosite.ErrNotFound) {
 		return errorsx.WithStack(fosite.ErrInvalidGrant.WithWrap(err).WithDebug(err.Error()))
@@ -89,7 +101,7 @@ func (c *AuthorizeExplicitGrantHandler) HandleTokenEndpointRequest(ctx context.C
 	// The authorization server MUST ensure that the authorization code was issued to the authenticated
 	// confidential client, or if the client is public, ensure that the
 	// code was issued to "client_id" in the request,
-	if authorizeRequest.GetClient().GetID() != request.GetClient().GetID() {
+	if fosite.GetClientIdentifier(authorizeRequest) != fosite.GetClientIdentifier(request) {
 		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client ID from this request does not match the one from the authorize request."))
 	}
 
@@ -102,6 +114,12 @@ func (c *AuthorizeExplicitGrantHandler) HandleTokenEndpointRequest(ctx context.C
 		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The \"redirect_uri\" from this request does not match the one from the authorize request."))
 	}
 
+	// If the "redirect_uri" parameter was omitted in the authorize request, the code was sent to the redirect URI
+	// registered by the client. A "redirect_uri" parameter in the token request must thus be a registered one.
+	if redirectURI := request.GetRequestForm().Get("redirect_uri"); forcedRedirectURI == "" && redirectURI != "" && !fosite.StringInSlice(redirectURI, authorizeRequest.GetClient().GetRedirectURIs()) {
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The \"redirect_uri\" from this request was omitted in the authorize request and is not registered for the OAuth 2.0 Client."))
+	}
+
 	// Checking of POST client_id skipped, because:
 	// If the client type is confidential or the client was issued client
 	// credentials (or assigned other authentication requirements), the
@@ -110,9 +128,9 @@ func (c *AuthorizeExplicitGrantHandler) HandleTokenEndpointRequest(ctx context.C
 	request.SetSession(authorizeRequest.GetSession())
 	request.SetID(authorizeRequest.GetID())
 
-	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan).Round(time.Second))
-	if c.RefreshTokenLifespan > -1 {
-		request.GetSession().SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(c.RefreshTokenLifespan).Round(time.Second))
+	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(fosite.GetEffectiveLifespan(request.GetClient(), "authorization_code", fosite.AccessToken, c.AccessTokenLifespan)).Round(time.Second))
+	if refreshTokenLifespan := fosite.GetEffectiveLifespan(request.GetClient(), "authorization_code", fosite.RefreshToken, c.RefreshTokenLifespan); refreshTokenLifespan > -1 {
+		request.GetSession().SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(refreshTokenLifespan).Round(time.Second))
 	}
 
 	return nil
@@ -127,6 +145,25 @@ func canIssueRefreshToken(c *AuthorizeExplicitGrantHandler, request fosite.Reque
 	if !request.GetClient().GetGrantTypes().Has("refresh_token") {
 		return false
 	}
+	if c.EnforceOfflineAccess && !hasOfflineAccess(request) {
+		return false
+	}
+	return true
+}
+
+// hasOfflineAccess returns true if the offline_access scope has been granted to a client allowed offline access and,
+// for OpenID Connect requests, the end-user has been prompted for consent as required by
+// https://openid.net/specs/openid-connect-core-1_0.html#OfflineAccess
+func hasOfflineAccess(request fosite.Requester) bool {
+	if !request.GetGrantedScopes().Has("offline_access") {
+		return false
+	}
+	if oc, ok := request.GetClient().(fosite.OfflineAccessClient); !ok || !oc.GetAllowOfflineAccess() {
+		return false
+	}
+	if request.GetGrantedScopes().Has("openid") && !fosite.IsConsentPrompted(request) {
+		return false
+	}
 	return true
 }
 
@@ -138,6 +175,19 @@ func (c *AuthorizeExplicitGrantHandler) PopulateTokenEndpointResponse(ctx contex
 	code := requester.GetRequestForm().Get("code")
 	signature := c.AuthorizeCodeStrategy.AuthorizeCodeSignature(code)
 	authorizeRequest, err := c.CoreStorage.GetAuthorizeCodeSession(ctx, signature, requester.GetSession())
+	if errors.Is(err, fosite.ErrInvalidatedAuthorizeCode) && authorizeRequest != nil {
+		if exchange := c.getCodeExchangeRetry(ctx, signature, requester); exchange != nil {
+			for _, scope := range authorizeRequest.GetGrantedScopes() {
+				requester.GrantScope(scope)
+			}
+			for _, audience := range authorizeRequest.GetGrantedAudience() {
+				requester.GrantAudience(audience)
+			}
+			writeCodeExchangeResponse(requester, responder, exchange)
+			return nil
+		}
+	}
+
 	if err != nil {
 		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
 	} else if err := c.AuthorizeCodeStrategy.ValidateAuthorizeCode(ctx, requester, code); err != nil {
@@ -198,13 +248,76 @@ func (c *AuthorizeExplicitGrantHandler) PopulateTokenEndpointResponse(ctx contex
 		responder.SetExtra("refresh_token", refresh)
 	}
 
+	if err := c.createCodeExchange(ctx, signature, requester, responder); err != nil {
+		if rollBackTxnErr := storage.MaybeRollbackTx(ctx, c.CoreStorage); rollBackTxnErr != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("error: %s; rollback error: %s", err, rollBackTxnErr))
+		}
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
 	if err := storage.MaybeCommitTx(ctx, c.CoreStorage); err != nil {
 		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
 	}
 
+	if err := c.IssuanceHooks.TokensIssued(ctx, requester, accessSignature, refreshSignature); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// createCodeExchange records the response of the exchange of the authorize code for retries within
+// CodeReuseGracePeriod.
+func (c *AuthorizeExplicitGrantHandler) createCodeExchange(ctx context.Context, signature string, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	exchangeStorage, ok := c.CoreStorage.(fosite.AuthorizeCodeExchangeStorage)
+	if c.CodeReuseGracePeriod <= 0 || !ok {
+		return nil
+	}
+
+	response := map[string]interface{}{}
+	for k, v := range responder.ToMap() {
+		response[k] = v
+	}
+
+	return exchangeStorage.CreateAuthorizeCodeExchange(ctx, signature, &fosite.AuthorizeCodeExchange{
+		RequestHash: fosite.AuthorizeCodeExchangeRequestHash(requester),
+		Response:    response,
+		ExpiresAt:   time.Now().UTC().Add(c.CodeReuseGracePeriod),
+	})
+}
+
+// getCodeExchangeRetry returns the recorded exchange of the authorize code if the token request is a retry of it
+// within CodeReuseGracePeriod.
+func (c *AuthorizeExplicitGrantHandler) getCodeExchangeRetry(ctx context.Context, signature string, requester fosite.AccessRequester) *fosite.AuthorizeCodeExchange {
+	exchangeStorage, ok := c.CoreStorage.(fosite.AuthorizeCodeExchangeStorage)
+	if c.CodeReuseGracePeriod <= 0 || !ok {
+		return nil
+	}
+
+	exchange, err := exchangeStorage.GetAuthorizeCodeExchange(ctx, signature)
+	if err != nil || !exchange.IsRetryOf(requester) {
+		return nil
+	}
+	return exchange
+}
+
+func writeCodeExchangeResponse(requester fosite.AccessRequester, responder fosite.AccessResponder, exchange *fosite.AuthorizeCodeExchange) {
+	for k, v := range exchange.Response {
+		switch k {
+		case "access_token":
+			token, _ := v.(string)
+			responder.SetAccessToken(token)
+		case "token_type":
+			tokenType, _ := v.(string)
+			responder.SetTokenType(tokenType)
+		case "scope":
+			responder.SetScopes(requester.GetGrantedScopes())
+		default:
+			responder.SetExtra(k, v)
+		}
+	}
+}
+
 func (c *AuthorizeExplicitGrantHandler) CanSkipClientAuth(requester fosite.AccessRequester) bool {
 	return false
 }
@@ -391,6 +391,63 @@ func TestAuthorizeCode_HandleTokenEndpointRequest(t *testing.T) {
 					authreq: &fosite.AuthorizeRequest{
 						Request: fosite.Request{
 							Client:         &fosite.DefaultClient{ID: "foo", GrantTypes: []string{"authorization_code"}},
+							Form:           url.Values{"redirect_uri": []string{"request-redir"}},
+							Session:        &fosite.DefaultSession{},
+							RequestedScope: fosite.Arguments{"a", "b"},
+							RequestedAt:    time.Now().UTC(),
+						},
+					},
+					description: "should fail because redirect uri of the /token call does not match the one of the /authorize call",
+					setup: func(t *testing.T, areq *fosite.AccessRequest, authreq *fosite.AuthorizeRequest) {
+						token, signature, err := strategy.GenerateAuthorizeCode(nil, nil)
+						require.NoError(t, err)
+						areq.Form = url.Values{"code": {token}, "redirect_uri": {"other-redir"}}
+
+						require.NoError(t, store.CreateAuthorizeCodeSession(nil, signature, authreq))
+					},
+					expectErr: fosite.ErrInvalidGrant,
+				},
+				{
+					areq: &fosite.AccessRequest{
+						GrantTypes: fosite.Arguments{"authorization_code"},
+						Request: fosite.Request{
+							Client:      &fosite.DefaultClient{ID: "foo", GrantTypes: []string{"authorization_code"}},
+							Form:        url.Values{"redirect_uri": []string{"request-redir"}},
+							Session:     &fosite.DefaultSession{},
+							RequestedAt: time.Now().UTC(),
+						},
+					},
+					authreq: &fosite.AuthorizeRequest{
+						Request: fosite.Request{
+							Client:         &fosite.DefaultClient{ID: "foo", GrantTypes: []string{"authorization_code"}, RedirectURIs: []string{"registered-redir"}},
+							Session:        &fosite.DefaultSession{},
+							RequestedScope: fosite.Arguments{"a", "b"},
+							RequestedAt:    time.Now().UTC(),
+						},
+					},
+					description: "should fail because redirect uri was omitted during /authorize call and the one of the /token call is not registered",
+					setup: func(t *testing.T, areq *fosite.AccessRequest, authreq *fosite.AuthorizeRequest) {
+						token, signature, err := strategy.GenerateAuthorizeCode(nil, nil)
+						require.NoError(t, err)
+						areq.Form.Set("code", token)
+
+						require.NoError(t, store.CreateAuthorizeCodeSession(nil, signature, authreq))
+					},
+					expectErr: fosite.ErrInvalidGrant,
+				},
+				{
+					areq: &fosite.AccessRequest{
+						GrantTypes: fosite.Arguments{"authorization_code"},
+						Request: fosite.Request{
+							Client:      &fosite.DefaultClient{ID: "foo", GrantTypes: []string{"authorization_code"}},
+							Form:        url.Values{"redirect_uri": []string{"request-redir"}},
+							Session:     &fosite.DefaultSession{},
+							RequestedAt: time.Now().UTC(),
+						},
+					},
+					authreq: &fosite.AuthorizeRequest{
+						Request: fosite.Request{
+							Client:         &fosite.DefaultClient{ID: "foo", GrantTypes: []string{"authorization_code"}, RedirectURIs: []string{"request-redir"}},
 							Session:        &fosite.DefaultSession{},
 							RequestedScope: fosite.Arguments{"a", "b"},
 							RequestedAt:    time.Now().UTC(),
@@ -680,3 +737,122 @@ func TestAuthorizeCodeTransactional_HandleTokenEndpointRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorizeCode_BindsClientAndRedirectURI(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := AuthorizeExplicitGrantHandler{
+		CoreStorage:              store,
+		AuthorizeCodeStrategy:    hmacshaStrategy,
+		ScopeStrategy:            fosite.HierarchicScopeStrategy,
+		AudienceMatchingStrategy: fosite.DefaultAudienceMatchingStrategy,
+		TokenRevocationStorage:   store,
+		AuthCodeLifespan:         time.Minute,
+		SanitationWhiteList:      []string{"code"},
+	}
+
+	clientA := &fosite.DefaultClient{ID: "a", GrantTypes: []string{"authorization_code"}, RedirectURIs: []string{"https://a.example.com/cb"}}
+	clientB := &fosite.DefaultClient{ID: "b", GrantTypes: []string{"authorization_code"}, RedirectURIs: []string{"https://a.example.com/cb"}}
+
+	issue := func(t *testing.T) string {
+		ar := fosite.NewAuthorizeRequest()
+		ar.Client = clientA
+		ar.Session = &fosite.DefaultSession{}
+		ar.Form.Set("redirect_uri", "https://a.example.com/cb")
+		ar.RedirectURI, _ = url.Parse("https://a.example.com/cb")
+		ar.ResponseTypes = fosite.Arguments{"code"}
+
+		resp := fosite.NewAuthorizeResponse()
+		require.NoError(t, h.IssueAuthorizeCode(context.Background(), ar, resp))
+		return resp.GetCode()
+	}
+
+	for k, c := range []struct {
+		d           string
+		client      fosite.Client
+		redirectURI string
+		tenant      string
+		expectErr   error
+	}{
+		{d: "should fail because the code was issued to another client", client: clientB, redirectURI: "https://a.example.com/cb", expectErr: fosite.ErrInvalidGrant},
+		{d: "should fail because the code was issued to the client of another tenant", client: clientA, redirectURI: "https://a.example.com/cb", tenant: "other", expectErr: fosite.ErrInvalidGrant},
+		{d: "should fail because the redirect uri was not presented although not in the sanitation white list", client: clientA, expectErr: fosite.ErrInvalidGrant},
+		{d: "should fail because the redirect uri does not match", client: clientA, redirectURI: "https://a.example.com/other", expectErr: fosite.ErrInvalidGrant},
+		{d: "should pass", client: clientA, redirectURI: "https://a.example.com/cb"},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.d), func(t *testing.T) {
+			areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+			areq.GrantTypes = fosite.Arguments{"authorization_code"}
+			areq.Client = c.client
+			areq.Form.Set("code", issue(t))
+			if c.redirectURI != "" {
+				areq.Form.Set("redirect_uri", c.redirectURI)
+			}
+			if c.tenant != "" {
+				areq.RequestContext = fosite.RequestContext{fosite.TenantRequestContextKey: c.tenant}
+			}
+
+			err := h.HandleTokenEndpointRequest(context.Background(), areq)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthorizeCode_EnforceOfflineAccess(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := AuthorizeExplicitGrantHandler{
+		CoreStorage:              store,
+		AuthorizeCodeStrategy:    hmacshaStrategy,
+		AccessTokenStrategy:      hmacshaStrategy,
+		RefreshTokenStrategy:     hmacshaStrategy,
+		ScopeStrategy:            fosite.HierarchicScopeStrategy,
+		AudienceMatchingStrategy: fosite.DefaultAudienceMatchingStrategy,
+		AccessTokenLifespan:      time.Minute,
+		RefreshTokenScopes:       []string{"offline", "offline_access"},
+		EnforceOfflineAccess:     true,
+	}
+
+	for k, c := range []struct {
+		d             string
+		scopes        fosite.Arguments
+		allowOffline  bool
+		prompt        string
+		expectRefresh bool
+	}{
+		{d: "should withhold the refresh token without offline_access", scopes: fosite.Arguments{"foo", "offline"}, allowOffline: true},
+		{d: "should withhold the refresh token because the client is not allowed offline access", scopes: fosite.Arguments{"foo", "offline_access"}},
+		{d: "should withhold the refresh token because consent was not prompted", scopes: fosite.Arguments{"openid", "offline_access"}, allowOffline: true, prompt: "login"},
+		{d: "should issue a refresh token for OAuth 2.0 requests", scopes: fosite.Arguments{"foo", "offline_access"}, allowOffline: true, expectRefresh: true},
+		{d: "should issue a refresh token because consent was prompted", scopes: fosite.Arguments{"openid", "offline_access"}, allowOffline: true, prompt: "login consent", expectRefresh: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.d), func(t *testing.T) {
+			areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+			areq.GrantTypes = fosite.Arguments{"authorization_code"}
+			areq.Client = &fosite.DefaultClient{
+				GrantTypes:         fosite.Arguments{"authorization_code", "refresh_token"},
+				AllowOfflineAccess: c.allowOffline,
+			}
+			areq.GrantedScope = c.scopes
+			if c.prompt != "" {
+				areq.Form.Set("prompt", c.prompt)
+			}
+
+			code, sig, err := hmacshaStrategy.GenerateAuthorizeCode(nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, store.CreateAuthorizeCodeSession(nil, sig, areq))
+			areq.Form.Set("code", code)
+
+			aresp := fosite.NewAccessResponse()
+			require.NoError(t, h.PopulateTokenEndpointResponse(nil, areq, aresp))
+			assert.NotEmpty(t, aresp.AccessToken)
+			if c.expectRefresh {
+				assert.NotEmpty(t, aresp.GetExtra("refresh_token"))
+			} else {
+				assert.Empty(t, aresp.GetExtra("refresh_token"))
+			}
+		})
+	}
+}
@@ -45,6 +45,12 @@ type AuthorizeImplicitGrantTypeHandler struct {
 
 	ScopeStrategy            fosite.ScopeStrategy
 	AudienceMatchingStrategy fosite.AudienceMatchingStrategy
+
+	// IssuanceHooks are notified about the issued tokens once they have been persisted.
+	IssuanceHooks *TokenIssuanceHooks
+
+	// Disabled rejects implicit grant requests with unsupported_response_type, as required by OAuth 2.1.
+	Disabled bool
 }
 
 func (c *AuthorizeImplicitGrantTypeHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
@@ -53,6 +59,10 @@ func (c *AuthorizeImplicitGrantTypeHandler) HandleAuthorizeEndpointRequest(ctx c
 		return nil
 	}
 
+	if c.Disabled {
+		return errorsx.WithStack(fosite.ErrUnsupportedResponseType.WithHint("The authorization server does not support the implicit grant."))
+	}
+
 	ar.SetDefaultResponseMode(fosite.ResponseModeFragment)
 
 	// Disabled because this is already handled at the authorize_request_handler
@@ -60,8 +70,8 @@ func (c *AuthorizeImplicitGrantTypeHandler) HandleAuthorizeEndpointRequest(ctx c
 	// 	 return errorsx.WithStack(fosite.ErrInvalidGrant.WithDebug("The client is not allowed to use response type token"))
 	// }
 
-	if !ar.GetClient().GetGrantTypes().Has("implicit") {
-		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client is not allowed to use the authorization grant 'implicit'."))
+	if err := fosite.ValidateClientGrantType(ar.GetClient(), "implicit"); err != nil {
+		return err
 	}
 
 	client := ar.GetClient()
@@ -84,7 +94,7 @@ func (c *AuthorizeImplicitGrantTypeHandler) HandleAuthorizeEndpointRequest(ctx c
 func (c *AuthorizeImplicitGrantTypeHandler) IssueImplicitAccessToken(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
 	// Only override expiry if none is set.
 	if ar.GetSession().GetExpiresAt(fosite.AccessToken).IsZero() {
-		ar.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan).Round(time.Second))
+		ar.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(fosite.GetEffectiveLifespan(ar.GetClient(), "implicit", fosite.AccessToken, c.AccessTokenLifespan)).Round(time.Second))
 	}
 
 	// Generate the code
@@ -95,6 +105,8 @@ func (c *AuthorizeImplicitGrantTypeHandler) IssueImplicitAccessToken(ctx context
 
 	if err := c.AccessTokenStorage.CreateAccessTokenSession(ctx, signature, ar.Sanitize([]string{})); err != nil {
 		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	} else if err := c.IssuanceHooks.AccessTokenIssued(ctx, ar, signature); err != nil {
+		return err
 	}
 	resp.AddParameter("access_token", token)
 	resp.AddParameter("expires_in", strconv.FormatInt(int64(getExpiresIn(ar, fosite.AccessToken, c.AccessTokenLifespan, time.Now().UTC())/time.Second), 10))
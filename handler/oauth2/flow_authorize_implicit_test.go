@@ -55,6 +55,17 @@ func TestAuthorizeImplicit_EndpointHandler(t *testing.T) {
 				areq.ResponseTypes = fosite.Arguments{"a"}
 			},
 		},
+		{
+			description: "should fail because client is not registered for the implicit grant",
+			setup: func() {
+				areq.ResponseTypes = fosite.Arguments{"token"}
+				areq.Client = &fosite.DefaultClient{
+					GrantTypes:    fosite.Arguments{"authorization_code"},
+					ResponseTypes: fosite.Arguments{"token"},
+				}
+			},
+			expectErr: fosite.ErrUnauthorizedClient,
+		},
 		{
 			description: "should fail because access token generation failed",
 			setup: func() {
@@ -119,6 +130,13 @@ func TestAuthorizeImplicit_EndpointHandler(t *testing.T) {
 			},
 			expectErr: nil,
 		},
+		{
+			description: "should fail because the implicit grant is disabled",
+			setup: func() {
+				h.Disabled = true
+			},
+			expectErr: fosite.ErrUnsupportedResponseType,
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			c.setup()
@@ -0,0 +1,136 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"net/http"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+)
+
+// CertificateBoundTokenHandler binds the issued access tokens to the certificate the client authenticated with when
+// using mutual TLS client authentication, as defined in https://tools.ietf.org/html/rfc8705#section-3. The
+// certificate thumbprint is added as "cnf" claim to the session, which is part of JWT access tokens and of the
+// introspection response.
+//
+// The handler never handles a grant on its own. It must be registered after the handlers of the grants the
+// client uses, because these replace the session of the access request.
+type CertificateBoundTokenHandler struct {
+	ClientCertificate ClientCertificateFunc
+}
+
+func (c *CertificateBoundTokenHandler) HandleTokenEndpointRequest(ctx context.Context, request fosite.AccessRequester) error {
+	if !c.CanHandleTokenEndpointRequest(request) {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	r, ok := ctx.Value(fosite.RequestContextKey).(*http.Request)
+	if !ok {
+		return errorsx.WithStack(fosite.ErrServerError.WithDebug("The HTTP request is missing in the context."))
+	}
+
+	var cert *x509.Certificate
+	if c.ClientCertificate == nil {
+		cert = DefaultClientCertificate(r)
+	} else {
+		cert = c.ClientCertificate(r)
+	}
+	if cert == nil {
+		return errorsx.WithStack(fosite.ErrInvalidClient.WithHint("The OAuth 2.0 Client authenticated using mutual TLS, but no client certificate was presented."))
+	}
+
+	if err := bindCertificate(request.GetSession(), CertificateThumbprint(cert)); err != nil {
+		return err
+	}
+
+	// The request is not handled by this handler, the grant handlers take care of it.
+	return errorsx.WithStack(fosite.ErrUnknownRequest)
+}
+
+func (c *CertificateBoundTokenHandler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	return errorsx.WithStack(fosite.ErrUnknownRequest)
+}
+
+func (c *CertificateBoundTokenHandler) CanSkipClientAuth(requester fosite.AccessRequester) bool {
+	return false
+}
+
+func (c *CertificateBoundTokenHandler) CanHandleTokenEndpointRequest(requester fosite.AccessRequester) bool {
+	client, ok := requester.GetClient().(fosite.TLSClient)
+	if !ok {
+		return false
+	}
+	method := client.GetTokenEndpointAuthMethod()
+	return method == ClientAuthMethodTLS || method == ClientAuthMethodSelfSignedTLS
+}
+
+// ValidateCertificateBinding checks that the access token of the given request is bound to the certificate the
+// client presented to the resource server. Tokens which are not certificate-bound pass the validation.
+func ValidateCertificateBinding(requester fosite.Requester, cert *x509.Certificate) error {
+	thumbprint := getCertificateThumbprint(requester.GetSession())
+	if thumbprint == "" {
+		return nil
+	}
+
+	if cert == nil {
+		return errorsx.WithStack(fosite.ErrRequestUnauthorized.WithHint("The access token is bound to a client certificate, but no client certificate was presented."))
+	}
+
+	if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(CertificateThumbprint(cert))) != 1 {
+		return errorsx.WithStack(fosite.ErrRequestUnauthorized.WithHint("The access token is bound to a different client certificate."))
+	}
+
+	return nil
+}
+
+func bindCertificate(session fosite.Session, thumbprint string) error {
+	cnf := map[string]interface{}{"x5t#S256": thumbprint}
+	switch s := session.(type) {
+	case JWTSessionContainer:
+		claims, ok := s.GetJWTClaims().(*jwt.JWTClaims)
+		if !ok {
+			return errorsx.WithStack(fosite.ErrServerError.WithDebug("Unable to bind the access token to the client certificate because the JWT claims are not of type *jwt.JWTClaims."))
+		}
+		claims.Add("cnf", cnf)
+	case fosite.ExtraClaimsSession:
+		s.GetExtraClaims()["cnf"] = cnf
+	default:
+		return errorsx.WithStack(fosite.ErrServerError.WithDebug("Unable to bind the access token to the client certificate because the session does not support extra claims."))
+	}
+	return nil
+}
+
+func getCertificateThumbprint(session fosite.Session) string {
+	s, ok := session.(fosite.ExtraClaimsSession)
+	if !ok {
+		return ""
+	}
+	cnf, _ := s.GetExtraClaims()["cnf"].(map[string]interface{})
+	thumbprint, _ := cnf["x5t#S256"].(string)
+	return thumbprint
+}
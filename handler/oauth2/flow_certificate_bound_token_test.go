@@ -0,0 +1,86 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+)
+
+func TestCertificateBoundTokenHandler(t *testing.T) {
+	cert, _ := mustCertificate(t, "foo")
+	otherCert, _ := mustCertificate(t, "bar")
+
+	tlsClient := &fosite.DefaultTLSClient{
+		DefaultOpenIDConnectClient: &fosite.DefaultOpenIDConnectClient{
+			DefaultClient:           &fosite.DefaultClient{ID: "foo"},
+			TokenEndpointAuthMethod: ClientAuthMethodTLS,
+		},
+	}
+	h := &CertificateBoundTokenHandler{}
+	r := &http.Request{TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}}
+	ctx := context.WithValue(context.Background(), fosite.RequestContextKey, r)
+
+	t.Run("case=ignores clients not using mutual TLS", func(t *testing.T) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.Client = &fosite.DefaultClient{ID: "foo"}
+		assert.False(t, h.CanHandleTokenEndpointRequest(areq))
+	})
+
+	t.Run("case=fails without certificate", func(t *testing.T) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.Client = tlsClient
+		err := h.HandleTokenEndpointRequest(context.WithValue(context.Background(), fosite.RequestContextKey, &http.Request{}), areq)
+		require.EqualError(t, err, fosite.ErrInvalidClient.Error())
+	})
+
+	for _, session := range []fosite.Session{
+		&fosite.DefaultSession{},
+		&JWTSession{},
+	} {
+		areq := fosite.NewAccessRequest(session)
+		areq.Client = tlsClient
+		require.True(t, h.CanHandleTokenEndpointRequest(areq))
+
+		err := h.HandleTokenEndpointRequest(ctx, areq)
+		require.EqualError(t, err, fosite.ErrUnknownRequest.Error())
+
+		claims := session.(fosite.ExtraClaimsSession).GetExtraClaims()
+		assert.Equal(t, map[string]interface{}{"x5t#S256": CertificateThumbprint(cert)}, claims["cnf"])
+
+		require.NoError(t, ValidateCertificateBinding(areq, cert))
+		require.EqualError(t, ValidateCertificateBinding(areq, otherCert), fosite.ErrRequestUnauthorized.Error())
+		require.EqualError(t, ValidateCertificateBinding(areq, nil), fosite.ErrRequestUnauthorized.Error())
+	}
+
+	t.Run("case=passes tokens which are not certificate-bound", func(t *testing.T) {
+		require.NoError(t, ValidateCertificateBinding(fosite.NewAccessRequest(&fosite.DefaultSession{}), nil))
+	})
+}
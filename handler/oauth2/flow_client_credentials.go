@@ -61,7 +61,7 @@ func (c *ClientCredentialsGrantHandler) HandleTokenEndpointRequest(_ context.Con
 	}
 	// if the client is not public, he has already been authenticated by the access request handler.
 
-	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan))
+	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(fosite.GetEffectiveLifespan(client, "client_credentials", fosite.AccessToken, c.AccessTokenLifespan)))
 	return nil
 }
 
@@ -71,8 +71,8 @@ func (c *ClientCredentialsGrantHandler) PopulateTokenEndpointResponse(ctx contex
 		return errorsx.WithStack(fosite.ErrUnknownRequest)
 	}
 
-	if !request.GetClient().GetGrantTypes().Has("client_credentials") {
-		return errorsx.WithStack(fosite.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use authorization grant 'client_credentials'."))
+	if err := fosite.ValidateClientGrantType(request.GetClient(), "client_credentials"); err != nil {
+		return err
 	}
 
 	return c.IssueAccessToken(ctx, request, response)
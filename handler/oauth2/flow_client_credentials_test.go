@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/fosite"
@@ -39,6 +40,7 @@ func TestClientCredentials_HandleTokenEndpointRequest(t *testing.T) {
 	store := internal.NewMockClientCredentialsGrantStorage(ctrl)
 	chgen := internal.NewMockAccessTokenStrategy(ctrl)
 	areq := internal.NewMockAccessRequester(ctrl)
+	sess := new(fosite.DefaultSession)
 	defer ctrl.Finish()
 
 	h := ClientCredentialsGrantHandler{
@@ -55,6 +57,7 @@ func TestClientCredentials_HandleTokenEndpointRequest(t *testing.T) {
 		mock        func()
 		req         *http.Request
 		expectErr   error
+		check       func()
 	}{
 		{
 			description: "should fail because not responsible",
@@ -101,6 +104,27 @@ func TestClientCredentials_HandleTokenEndpointRequest(t *testing.T) {
 				})
 			},
 		},
+		{
+			description: "should pass and apply the access token lifespan of the client",
+			mock: func() {
+				areq.EXPECT().GetSession().Return(sess)
+				areq.EXPECT().GetGrantTypes().Return(fosite.Arguments{"client_credentials"})
+				areq.EXPECT().GetRequestedScopes().Return([]string{"foo"})
+				areq.EXPECT().GetRequestedAudience().Return([]string{})
+				areq.EXPECT().GetClient().Return(&fosite.DefaultClient{
+					GrantTypes: fosite.Arguments{"client_credentials"},
+					Scopes:     []string{"foo"},
+					TokenLifespans: &fosite.ClientLifespanConfig{
+						GrantTypes: map[string]fosite.TokenLifespans{
+							"client_credentials": {fosite.AccessToken: time.Minute},
+						},
+					},
+				})
+			},
+			check: func() {
+				assert.WithinDuration(t, time.Now().UTC().Add(time.Minute), sess.GetExpiresAt(fosite.AccessToken), 5*time.Second)
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			c.mock()
@@ -110,6 +134,10 @@ func TestClientCredentials_HandleTokenEndpointRequest(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 			}
+
+			if c.check != nil {
+				c.check()
+			}
 		})
 	}
 }
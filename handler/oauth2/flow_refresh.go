@@ -35,6 +35,22 @@ import (
 	"github.com/ory/fosite/storage"
 )
 
+// RefreshTokenRotationPolicy defines how refresh tokens are handled when they are used at the token endpoint.
+type RefreshTokenRotationPolicy string
+
+const (
+	// RefreshTokenRotationRotating issues a new refresh token on every use and invalidates the presented one.
+	RefreshTokenRotationRotating RefreshTokenRotationPolicy = "rotating"
+
+	// RefreshTokenRotationWithReuseDetection behaves like RefreshTokenRotationRotating but additionally revokes the
+	// whole token family when an already rotated refresh token is presented, see
+	// https://tools.ietf.org/html/rfc6819#section-5.2.2.3
+	RefreshTokenRotationWithReuseDetection RefreshTokenRotationPolicy = "rotating_with_reuse_detection"
+
+	// RefreshTokenRotationStatic keeps the presented refresh token valid and does not issue a new one.
+	RefreshTokenRotationStatic RefreshTokenRotationPolicy = "static"
+)
+
 type RefreshTokenGrantHandler struct {
 	AccessTokenStrategy    AccessTokenStrategy
 	RefreshTokenStrategy   RefreshTokenStrategy
@@ -46,9 +62,37 @@ type RefreshTokenGrantHandler struct {
 	// RefreshTokenLifespan defines the lifetime of a refresh token.
 	RefreshTokenLifespan time.Duration
 
+	// AbsoluteRefreshTokenLifespan defines for how long, counted from the issuance of the original authorization grant,
+	// refresh tokens can be refreshed. Defaults to zero, which disables the limit.
+	AbsoluteRefreshTokenLifespan time.Duration
+
+	// MaxRefreshChainDepth defines how often a refresh token can be rotated. It requires a session implementing
+	// fosite.RefreshChainSession. Defaults to zero, which disables the limit.
+	MaxRefreshChainDepth int
+
 	ScopeStrategy            fosite.ScopeStrategy
 	AudienceMatchingStrategy fosite.AudienceMatchingStrategy
 	RefreshTokenScopes       []string
+
+	// RefreshTokenRotation defines how refresh tokens are rotated. Defaults to RefreshTokenRotationWithReuseDetection.
+	RefreshTokenRotation RefreshTokenRotationPolicy
+
+	// RefreshTokenRotationGracePeriod defines for how long a rotated refresh token is still accepted, which allows
+	// clients to retry a refresh request whose response got lost. Defaults to zero, which disables the grace period.
+	RefreshTokenRotationGracePeriod time.Duration
+
+	// IssuanceHooks are notified about the issued tokens once they have been persisted.
+	IssuanceHooks *TokenIssuanceHooks
+
+	// SessionValidator, if set, confirms that the user session the refresh token is bound to has not ended.
+	SessionValidator SessionValidator
+}
+
+func (c *RefreshTokenGrantHandler) getRefreshTokenRotation() RefreshTokenRotationPolicy {
+	if c.RefreshTokenRotation == "" {
+		return RefreshTokenRotationWithReuseDetection
+	}
+	return c.RefreshTokenRotation
 }
 
 // HandleTokenEndpointRequest implements https://tools.ietf.org/html/rfc6749#section-6
@@ -57,14 +101,18 @@ func (c *RefreshTokenGrantHandler) HandleTokenEndpointRequest(ctx context.Contex
 		return errorsx.WithStack(fosite.ErrUnknownRequest)
 	}
 
-	if !request.GetClient().GetGrantTypes().Has("refresh_token") {
-		return errorsx.WithStack(fosite.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use authorization grant 'refresh_token'."))
+	if err := fosite.ValidateClientGrantType(request.GetClient(), "refresh_token"); err != nil {
+		return err
 	}
 
 	refresh := request.GetRequestForm().Get("refresh_token")
 	signature := c.RefreshTokenStrategy.RefreshTokenSignature(refresh)
 	originalRequest, err := c.TokenRevocationStorage.GetRefreshTokenSession(ctx, signature, request.GetSession())
 	if errors.Is(err, fosite.ErrInactiveToken) {
+		if c.getRefreshTokenRotation() != RefreshTokenRotationWithReuseDetection {
+			return errorsx.WithStack(fosite.ErrInactiveToken.WithWrap(err).WithDebug(err.Error()))
+		}
+
 		// Detected refresh token reuse
 		if rErr := c.handleRefreshTokenReuse(ctx, signature, originalRequest); rErr != nil {
 			return errorsx.WithStack(fosite.ErrServerError.WithWrap(rErr).WithDebug(rErr.Error()))
@@ -89,15 +137,37 @@ func (c *RefreshTokenGrantHandler) HandleTokenEndpointRequest(ctx context.Contex
 	}
 
 	// The authorization server MUST ... and ensure that the refresh token was issued to the authenticated client
-	if originalRequest.GetClient().GetID() != request.GetClient().GetID() {
+	if fosite.GetClientIdentifier(originalRequest) != fosite.GetClientIdentifier(request) {
 		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client ID from this request does not match the ID during the initial token issuance."))
 	}
 
+	if err := validateSession(ctx, c.SessionValidator, originalRequest); err != nil {
+		return err
+	}
+
+	// The client may narrow the scope of the new access token, but never expand it beyond the scope originally
+	// granted by the resource owner, see https://tools.ietf.org/html/rfc6749#section-6
+	scopes := originalRequest.GetGrantedScopes()
+	if isScopeNarrowed(request) {
+		for _, scope := range request.GetRequestedScopes() {
+			if !c.ScopeStrategy(originalRequest.GetGrantedScopes(), scope) {
+				return errorsx.WithStack(fosite.ErrInvalidScope.WithHintf("The requested scope '%s' was not granted in the initial token issuance.", scope))
+			}
+		}
+		scopes = request.GetRequestedScopes()
+	} else {
+		request.SetRequestedScopes(originalRequest.GetRequestedScopes())
+	}
+
 	request.SetSession(originalRequest.GetSession().Clone())
-	request.SetRequestedScopes(originalRequest.GetRequestedScopes())
 	request.SetRequestedAudience(originalRequest.GetRequestedAudience())
 
-	for _, scope := range originalRequest.GetGrantedScopes() {
+	grantedAt, err := c.enforceRefreshChainLimits(originalRequest, request.GetSession())
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range scopes {
 		if !c.ScopeStrategy(request.GetClient().GetScopes(), scope) {
 			return errorsx.WithStack(fosite.ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope '%s'.", scope))
 		}
@@ -108,18 +178,71 @@ func (c *RefreshTokenGrantHandler) HandleTokenEndpointRequest(ctx context.Contex
 		return err
 	}
 
-	for _, audience := range originalRequest.GetGrantedAudience() {
+	// The client may narrow the audience of the new tokens using resource indicators, but never expand it, see
+	// https://tools.ietf.org/html/rfc8707#section-2.2
+	audiences := originalRequest.GetGrantedAudience()
+	resources, err := fosite.GetResources(request.GetRequestForm())
+	if err != nil {
+		return err
+	} else if len(resources) > 0 {
+		for _, resource := range resources {
+			if !audiences.Has(resource) {
+				return errorsx.WithStack(fosite.ErrInvalidTarget.WithHintf("The resource '%s' was not granted in the initial token issuance.", resource))
+			}
+		}
+		audiences = resources
+		request.SetRequestedAudience(resources)
+	}
+
+	for _, audience := range audiences {
 		request.GrantAudience(audience)
 	}
 
-	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan).Round(time.Second))
-	if c.RefreshTokenLifespan > -1 {
-		request.GetSession().SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(c.RefreshTokenLifespan).Round(time.Second))
+	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(fosite.GetEffectiveLifespan(request.GetClient(), "refresh_token", fosite.AccessToken, c.AccessTokenLifespan)).Round(time.Second))
+	if refreshTokenLifespan := fosite.GetEffectiveLifespan(request.GetClient(), "refresh_token", fosite.RefreshToken, c.RefreshTokenLifespan); refreshTokenLifespan > -1 {
+		request.GetSession().SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(refreshTokenLifespan).Round(time.Second))
+	}
+
+	if c.AbsoluteRefreshTokenLifespan > 0 {
+		absoluteExpiry := grantedAt.Add(c.AbsoluteRefreshTokenLifespan).UTC().Round(time.Second)
+		if exp := request.GetSession().GetExpiresAt(fosite.RefreshToken); exp.IsZero() || exp.After(absoluteExpiry) {
+			request.GetSession().SetExpiresAt(fosite.RefreshToken, absoluteExpiry)
+		}
 	}
 
 	return nil
 }
 
+// enforceRefreshChainLimits returns when the original authorization grant of the refresh token chain was issued and
+// rejects the refresh if the chain exceeds its absolute lifetime or maximum depth. If the session implements
+// fosite.RefreshChainSession, the issuance time and depth are carried over to the rotated tokens.
+func (c *RefreshTokenGrantHandler) enforceRefreshChainLimits(originalRequest fosite.Requester, session fosite.Session) (time.Time, error) {
+	grantedAt := originalRequest.GetRequestedAt()
+	chain, ok := session.(fosite.RefreshChainSession)
+	if ok {
+		if t := chain.GetGrantedAt(); !t.IsZero() {
+			grantedAt = t
+		}
+		chain.SetGrantedAt(grantedAt)
+	}
+
+	if c.AbsoluteRefreshTokenLifespan > 0 && time.Now().UTC().After(grantedAt.Add(c.AbsoluteRefreshTokenLifespan)) {
+		return grantedAt, errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The refresh token has exceeded its absolute lifetime, the end-user has to authorize the OAuth 2.0 Client again."))
+	}
+
+	if !ok {
+		return grantedAt, nil
+	}
+
+	depth := chain.GetRefreshChainDepth() + 1
+	if c.MaxRefreshChainDepth > 0 && depth > c.MaxRefreshChainDepth {
+		return grantedAt, errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The refresh token has been refreshed too often, the end-user has to authorize the OAuth 2.0 Client again."))
+	}
+	chain.SetRefreshChainDepth(depth)
+
+	return grantedAt, nil
+}
+
 // PopulateTokenEndpointResponse implements https://tools.ietf.org/html/rfc6749#section-6
 func (c *RefreshTokenGrantHandler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
 	if !c.CanHandleTokenEndpointRequest(requester) {
@@ -131,6 +254,10 @@ func (c *RefreshTokenGrantHandler) PopulateTokenEndpointResponse(ctx context.Con
 		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
 	}
 
+	if c.getRefreshTokenRotation() == RefreshTokenRotationStatic {
+		return c.populateStaticRefreshTokenEndpointResponse(ctx, requester, responder, accessToken, accessSignature)
+	}
+
 	refreshToken, refreshSignature, err := c.RefreshTokenStrategy.GenerateRefreshToken(ctx, requester)
 	if err != nil {
 		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
@@ -148,7 +275,7 @@ func (c *RefreshTokenGrantHandler) PopulateTokenEndpointResponse(ctx context.Con
 		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
 	} else if err := c.TokenRevocationStorage.RevokeAccessToken(ctx, ts.GetID()); err != nil {
 		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
-	} else if err := c.TokenRevocationStorage.RevokeRefreshToken(ctx, ts.GetID()); err != nil {
+	} else if err := c.revokeRefreshTokenMaybeGracePeriod(ctx, ts.GetID(), signature); err != nil {
 		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
 	}
 
@@ -159,7 +286,7 @@ func (c *RefreshTokenGrantHandler) PopulateTokenEndpointResponse(ctx context.Con
 		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
 	}
 
-	if err := c.TokenRevocationStorage.CreateRefreshTokenSession(ctx, refreshSignature, storeReq); err != nil {
+	if err := c.TokenRevocationStorage.CreateRefreshTokenSession(ctx, refreshSignature, c.refreshTokenStoreRequest(requester, storeReq, ts)); err != nil {
 		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
 	}
 
@@ -173,9 +300,111 @@ func (c *RefreshTokenGrantHandler) PopulateTokenEndpointResponse(ctx context.Con
 		return c.handleRefreshTokenEndpointStorageError(ctx, false, err)
 	}
 
+	if err := c.IssuanceHooks.TokensIssued(ctx, storeReq, accessSignature, refreshSignature); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// isScopeNarrowed returns true if the refresh request asks for a subset of the originally granted scope.
+func isScopeNarrowed(requester fosite.AccessRequester) bool {
+	return requester.GetRequestForm().Get("scope") != "" && len(requester.GetRequestedScopes()) > 0
+}
+
+// isAudienceNarrowed returns true if the refresh request uses resource indicators to ask for a subset of the
+// originally granted audience.
+func isAudienceNarrowed(requester fosite.AccessRequester) bool {
+	resources, err := fosite.GetResources(requester.GetRequestForm())
+	return err == nil && len(resources) > 0
+}
+
+// refreshTokenStoreRequest returns the request to persist with the new refresh token. If the client narrowed the
+// scope or the audience of the access token, the refresh token keeps the originally granted scope and audience so
+// that later refresh requests can widen them again up to the original grant.
+func (c *RefreshTokenGrantHandler) refreshTokenStoreRequest(requester fosite.AccessRequester, storeReq, originalRequest fosite.Requester) fosite.Requester {
+	r, ok := storeReq.(*fosite.Request)
+	if !ok || (!isScopeNarrowed(requester) && !isAudienceNarrowed(requester)) {
+		return storeReq
+	}
+
+	refreshReq := *r
+	if isScopeNarrowed(requester) {
+		refreshReq.RequestedScope = fosite.Arguments{}
+		refreshReq.GrantedScope = fosite.Arguments{}
+		for _, scope := range originalRequest.GetRequestedScopes() {
+			refreshReq.AppendRequestedScope(scope)
+		}
+		for _, scope := range originalRequest.GetGrantedScopes() {
+			if c.ScopeStrategy(requester.GetClient().GetScopes(), scope) {
+				refreshReq.GrantScope(scope)
+			}
+		}
+	}
+
+	if isAudienceNarrowed(requester) {
+		refreshReq.RequestedAudience = fosite.Arguments{}
+		refreshReq.GrantedAudience = fosite.Arguments{}
+		for _, audience := range originalRequest.GetRequestedAudience() {
+			refreshReq.AppendRequestedAudience(audience)
+		}
+		for _, audience := range originalRequest.GetGrantedAudience() {
+			refreshReq.GrantAudience(audience)
+		}
+	}
+
+	return &refreshReq
+}
+
+// populateStaticRefreshTokenEndpointResponse issues a new access token while the client keeps using the presented
+// refresh token.
+func (c *RefreshTokenGrantHandler) populateStaticRefreshTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder, accessToken, accessSignature string) (err error) {
+	signature := c.RefreshTokenStrategy.RefreshTokenSignature(requester.GetRequestForm().Get("refresh_token"))
+
+	ctx, err = storage.MaybeBeginTx(ctx, c.TokenRevocationStorage)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	ts, err := c.TokenRevocationStorage.GetRefreshTokenSession(ctx, signature, nil)
+	if err != nil {
+		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
+	} else if err := c.TokenRevocationStorage.RevokeAccessToken(ctx, ts.GetID()); err != nil {
+		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
+	}
+
+	storeReq := requester.Sanitize([]string{})
+	storeReq.SetID(ts.GetID())
+
+	if err := c.TokenRevocationStorage.CreateAccessTokenSession(ctx, accessSignature, storeReq); err != nil {
+		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
+	}
+
+	responder.SetAccessToken(accessToken)
+	responder.SetTokenType("bearer")
+	responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, c.AccessTokenLifespan, time.Now().UTC()))
+	responder.SetScopes(requester.GetGrantedScopes())
+
+	if err := storage.MaybeCommitTx(ctx, c.TokenRevocationStorage); err != nil {
+		return c.handleRefreshTokenEndpointStorageError(ctx, false, err)
+	}
+
+	if err := c.IssuanceHooks.AccessTokenIssued(ctx, storeReq, accessSignature); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// revokeRefreshTokenMaybeGracePeriod revokes the rotated refresh token. If a grace period is configured, the token is
+// kept usable until the grace period has elapsed, so that clients can retry requests whose response got lost.
+func (c *RefreshTokenGrantHandler) revokeRefreshTokenMaybeGracePeriod(ctx context.Context, requestID string, signature string) error {
+	if c.RefreshTokenRotationGracePeriod <= 0 {
+		return c.TokenRevocationStorage.RevokeRefreshToken(ctx, requestID)
+	}
+	return c.TokenRevocationStorage.RevokeRefreshTokenMaybeGracePeriod(ctx, requestID, signature, c.RefreshTokenRotationGracePeriod)
+}
+
 // Reference: https://tools.ietf.org/html/rfc6819#section-5.2.2.3
 //
 //     The basic idea is to change the refresh token
@@ -194,7 +423,7 @@ func (c *RefreshTokenGrantHandler) handleRefreshTokenReuse(ctx context.Context,
 
 	if err := c.TokenRevocationStorage.DeleteRefreshTokenSession(ctx, signature); err != nil {
 		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
-	} else if err := c.TokenRevocationStorage.RevokeRefreshToken(
+	} else if err := c.TokenRevocationStorage.RevokeRefreshTokenFamily(
 		ctx, req.GetID(),
 	); err != nil && !errors.Is(err, fosite.ErrNotFound) {
 		return c.handleRefreshTokenEndpointStorageError(ctx, true, err)
@@ -65,6 +65,14 @@ func TestRefreshFlow_HandleTokenEndpointRequest(t *testing.T) {
 						areq.GrantTypes = fosite.Arguments{"123"}
 					},
 				},
+				{
+					description: "should fail because client is not registered for the refresh token grant",
+					setup: func() {
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{GrantTypes: fosite.Arguments{"authorization_code"}}
+					},
+					expectErr: fosite.ErrUnauthorizedClient,
+				},
 				{
 					description: "should fail because token invalid",
 					setup: func() {
@@ -168,6 +176,275 @@ func TestRefreshFlow_HandleTokenEndpointRequest(t *testing.T) {
 						assert.Equal(t, time.Now().Add(time.Hour).UTC().Round(time.Second), areq.GetSession().GetExpiresAt(fosite.RefreshToken))
 					},
 				},
+				{
+					description: "should fail because the session has ended",
+					setup: func() {
+						h.SessionValidator = func(_ context.Context, requester fosite.Requester) error {
+							assert.Equal(t, "othersub", requester.GetSession().GetSubject())
+							return errors.New("the user has logged out")
+						}
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+							Scopes:     []string{"foo", "offline"},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:       areq.Client,
+							GrantedScope: fosite.Arguments{"foo", "offline"},
+							Session:      sess,
+							Form:         url.Values{},
+							RequestedAt:  time.Now().UTC(),
+						})
+						require.NoError(t, err)
+					},
+					expectErr: fosite.ErrSessionExpired,
+				},
+				{
+					description: "should fail because the refresh token chain has exceeded its absolute lifetime",
+					setup: func() {
+						h.AbsoluteRefreshTokenLifespan = time.Hour
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+							Scopes:     []string{"foo", "offline"},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:       areq.Client,
+							GrantedScope: fosite.Arguments{"foo", "offline"},
+							Session:      &fosite.DefaultSession{GrantedAt: time.Now().UTC().Add(-time.Hour - time.Minute)},
+							Form:         url.Values{},
+							RequestedAt:  time.Now().UTC(),
+						})
+						require.NoError(t, err)
+					},
+					expectErr: fosite.ErrInvalidGrant,
+				},
+				{
+					description: "should pass and cap the refresh token lifespan at the absolute lifetime of the chain",
+					setup: func() {
+						h.AbsoluteRefreshTokenLifespan = time.Hour
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+							Scopes:     []string{"foo", "offline"},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:       areq.Client,
+							GrantedScope: fosite.Arguments{"foo", "offline"},
+							Session:      &fosite.DefaultSession{},
+							Form:         url.Values{},
+							RequestedAt:  time.Now().UTC().Add(-time.Minute * 50).Round(time.Second),
+						})
+						require.NoError(t, err)
+					},
+					expect: func(t *testing.T) {
+						grantedAt := time.Now().UTC().Add(-time.Minute * 50).Round(time.Second)
+						session := areq.GetSession().(*fosite.DefaultSession)
+						assert.WithinDuration(t, grantedAt, session.GrantedAt, time.Second)
+						assert.Equal(t, 1, session.RefreshChainDepth)
+						assert.WithinDuration(t, grantedAt.Add(time.Hour), session.GetExpiresAt(fosite.RefreshToken), time.Second)
+					},
+				},
+				{
+					description: "should fail because the refresh token chain has exceeded its maximum depth",
+					setup: func() {
+						h.MaxRefreshChainDepth = 2
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+							Scopes:     []string{"foo", "offline"},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:       areq.Client,
+							GrantedScope: fosite.Arguments{"foo", "offline"},
+							Session:      &fosite.DefaultSession{GrantedAt: time.Now().UTC(), RefreshChainDepth: 2},
+							Form:         url.Values{},
+							RequestedAt:  time.Now().UTC(),
+						})
+						require.NoError(t, err)
+					},
+					expectErr: fosite.ErrInvalidGrant,
+				},
+				{
+					description: "should pass and apply the token lifespans of the client",
+					setup: func() {
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+							Scopes:     []string{"foo", "bar", "offline"},
+							TokenLifespans: &fosite.ClientLifespanConfig{
+								Default: fosite.TokenLifespans{fosite.AccessToken: time.Minute, fosite.RefreshToken: 2 * time.Hour},
+								GrantTypes: map[string]fosite.TokenLifespans{
+									"refresh_token": {fosite.AccessToken: 30 * time.Minute},
+								},
+							},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:         areq.Client,
+							GrantedScope:   fosite.Arguments{"foo", "offline"},
+							RequestedScope: fosite.Arguments{"foo", "bar", "offline"},
+							Session:        sess,
+							Form:           url.Values{"foo": []string{"bar"}},
+							RequestedAt:    time.Now().UTC().Add(-time.Hour).Round(time.Hour),
+						})
+						require.NoError(t, err)
+					},
+					expect: func(t *testing.T) {
+						assert.Equal(t, time.Now().Add(30*time.Minute).UTC().Round(time.Second), areq.GetSession().GetExpiresAt(fosite.AccessToken))
+						assert.Equal(t, time.Now().Add(2*time.Hour).UTC().Round(time.Second), areq.GetSession().GetExpiresAt(fosite.RefreshToken))
+					},
+				},
+				{
+					description: "should pass and narrow the audience to the requested resource",
+					setup: func() {
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+							Scopes:     []string{"foo", "bar", "offline"},
+							Audience:   []string{"https://a.example.com", "https://b.example.com"},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						areq.Form.Add("resource", "https://b.example.com")
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:            areq.Client,
+							GrantedScope:      fosite.Arguments{"foo", "offline"},
+							RequestedScope:    fosite.Arguments{"foo", "bar", "offline"},
+							GrantedAudience:   fosite.Arguments{"https://a.example.com", "https://b.example.com"},
+							RequestedAudience: fosite.Arguments{"https://a.example.com", "https://b.example.com"},
+							Session:           sess,
+							Form:              url.Values{"foo": []string{"bar"}},
+							RequestedAt:       time.Now().UTC().Add(-time.Hour).Round(time.Hour),
+						})
+						require.NoError(t, err)
+					},
+					expect: func(t *testing.T) {
+						assert.Equal(t, fosite.Arguments{"https://b.example.com"}, areq.GrantedAudience)
+						assert.Equal(t, fosite.Arguments{"https://b.example.com"}, areq.RequestedAudience)
+					},
+				},
+				{
+					description: "should fail because the requested resource was not granted",
+					setup: func() {
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+							Scopes:     []string{"foo", "bar", "offline"},
+							Audience:   []string{"https://a.example.com", "https://b.example.com"},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						areq.Form.Add("resource", "https://b.example.com")
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:          areq.Client,
+							GrantedScope:    fosite.Arguments{"foo", "offline"},
+							RequestedScope:  fosite.Arguments{"foo", "bar", "offline"},
+							GrantedAudience: fosite.Arguments{"https://a.example.com"},
+							Session:         sess,
+							Form:            url.Values{"foo": []string{"bar"}},
+							RequestedAt:     time.Now().UTC().Add(-time.Hour).Round(time.Hour),
+						})
+						require.NoError(t, err)
+					},
+					expectErr: fosite.ErrInvalidTarget,
+				},
+				{
+					description: "should pass and narrow the scope to the requested subset",
+					setup: func() {
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+							Scopes:     []string{"foo", "bar", "offline"},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						areq.Form.Add("scope", "foo")
+						areq.RequestedScope = fosite.Arguments{"foo"}
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:         areq.Client,
+							GrantedScope:   fosite.Arguments{"foo", "bar", "offline"},
+							RequestedScope: fosite.Arguments{"foo", "bar", "offline"},
+							Session:        sess,
+							Form:           url.Values{"foo": []string{"bar"}},
+							RequestedAt:    time.Now().UTC().Add(-time.Hour).Round(time.Hour),
+						})
+						require.NoError(t, err)
+					},
+					expect: func(t *testing.T) {
+						assert.Equal(t, fosite.Arguments{"foo"}, areq.GrantedScope)
+						assert.Equal(t, fosite.Arguments{"foo"}, areq.RequestedScope)
+					},
+				},
+				{
+					description: "should fail because the requested scope was not granted",
+					setup: func() {
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{
+							ID:         "foo",
+							GrantTypes: fosite.Arguments{"refresh_token"},
+							Scopes:     []string{"foo", "bar", "baz", "offline"},
+						}
+
+						token, sig, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+
+						areq.Form.Add("refresh_token", token)
+						areq.Form.Add("scope", "foo baz")
+						areq.RequestedScope = fosite.Arguments{"foo", "baz"}
+						err = store.CreateRefreshTokenSession(nil, sig, &fosite.Request{
+							Client:         areq.Client,
+							GrantedScope:   fosite.Arguments{"foo", "bar", "offline"},
+							RequestedScope: fosite.Arguments{"foo", "bar", "offline"},
+							Session:        sess,
+							Form:           url.Values{"foo": []string{"bar"}},
+							RequestedAt:    time.Now().UTC().Add(-time.Hour).Round(time.Hour),
+						})
+						require.NoError(t, err)
+					},
+					expectErr: fosite.ErrInvalidScope,
+				},
 				{
 					description: "should fail without offline scope",
 					setup: func() {
@@ -332,7 +609,7 @@ func TestRefreshFlowTransactional_HandleTokenEndpointRequest(t *testing.T) {
 					Times(1)
 				mockRevocationStore.
 					EXPECT().
-					RevokeRefreshToken(propagatedContext, gomock.Any()).
+					RevokeRefreshTokenFamily(propagatedContext, gomock.Any()).
 					Return(nil).
 					Times(1)
 				mockRevocationStore.
@@ -435,6 +712,71 @@ func TestRefreshFlow_PopulateTokenEndpointResponse(t *testing.T) {
 						assert.Equal(t, "foo bar", aresp.ToMap()["scope"])
 					},
 				},
+				{
+					description: "should pass and keep the original scope for the refresh token when narrowing",
+					setup: func() {
+						areq.ID = "req-id"
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+						areq.Client = &fosite.DefaultClient{Scopes: []string{"foo", "bar"}}
+
+						token, signature, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+						require.NoError(t, store.CreateRefreshTokenSession(nil, signature, &fosite.Request{
+							ID:             areq.ID,
+							Client:         areq.Client,
+							RequestedScope: fosite.Arguments{"foo", "bar"},
+							GrantedScope:   fosite.Arguments{"foo", "bar"},
+							Session:        areq.Session,
+						}))
+						areq.Form.Add("refresh_token", token)
+						areq.Form.Add("scope", "foo")
+						areq.RequestedScope = fosite.Arguments{"foo"}
+						areq.GrantedScope = fosite.Arguments{"foo"}
+					},
+					check: func() {
+						assert.Equal(t, "foo", aresp.ToMap()["scope"])
+
+						at, err := store.GetAccessTokenSession(nil, strategy.AccessTokenSignature(aresp.GetAccessToken()), nil)
+						require.NoError(t, err)
+						assert.Equal(t, fosite.Arguments{"foo"}, at.GetGrantedScopes())
+
+						rt, err := store.GetRefreshTokenSession(nil, strategy.RefreshTokenSignature(aresp.ToMap()["refresh_token"].(string)), nil)
+						require.NoError(t, err)
+						assert.Equal(t, fosite.Arguments{"foo", "bar"}, rt.GetGrantedScopes())
+						assert.Equal(t, fosite.Arguments{"foo", "bar"}, rt.GetRequestedScopes())
+					},
+				},
+				{
+					description: "should pass and keep the original audience for the refresh token when narrowing",
+					setup: func() {
+						areq.ID = "req-id"
+						areq.GrantTypes = fosite.Arguments{"refresh_token"}
+
+						token, signature, err := strategy.GenerateRefreshToken(nil, nil)
+						require.NoError(t, err)
+						require.NoError(t, store.CreateRefreshTokenSession(nil, signature, &fosite.Request{
+							ID:                areq.ID,
+							Client:            areq.Client,
+							RequestedAudience: fosite.Arguments{"https://a.example.com", "https://b.example.com"},
+							GrantedAudience:   fosite.Arguments{"https://a.example.com", "https://b.example.com"},
+							Session:           areq.Session,
+						}))
+						areq.Form.Add("refresh_token", token)
+						areq.Form.Add("resource", "https://b.example.com")
+						areq.RequestedAudience = fosite.Arguments{"https://b.example.com"}
+						areq.GrantedAudience = fosite.Arguments{"https://b.example.com"}
+					},
+					check: func() {
+						at, err := store.GetAccessTokenSession(nil, strategy.AccessTokenSignature(aresp.GetAccessToken()), nil)
+						require.NoError(t, err)
+						assert.Equal(t, fosite.Arguments{"https://b.example.com"}, at.GetGrantedAudience())
+
+						rt, err := store.GetRefreshTokenSession(nil, strategy.RefreshTokenSignature(aresp.ToMap()["refresh_token"].(string)), nil)
+						require.NoError(t, err)
+						assert.Equal(t, fosite.Arguments{"https://a.example.com", "https://b.example.com"}, rt.GetGrantedAudience())
+						assert.Equal(t, fosite.Arguments{"https://a.example.com", "https://b.example.com"}, rt.GetRequestedAudience())
+					},
+				},
 			} {
 				t.Run("case="+c.description, func(t *testing.T) {
 					areq = fosite.NewAccessRequest(&fosite.DefaultSession{})
@@ -460,6 +802,137 @@ func TestRefreshFlow_PopulateTokenEndpointResponse(t *testing.T) {
 	}
 }
 
+func TestRefreshFlow_RefreshTokenRotation(t *testing.T) {
+	refresh := func(t *testing.T, h *RefreshTokenGrantHandler, token string) (*fosite.AccessResponse, error) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		areq.GrantTypes = fosite.Arguments{"refresh_token"}
+		areq.Client = &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"refresh_token"}}
+		areq.Form = url.Values{"refresh_token": {token}}
+		if err := h.HandleTokenEndpointRequest(nil, areq); err != nil {
+			return nil, err
+		}
+
+		aresp := fosite.NewAccessResponse()
+		if err := h.PopulateTokenEndpointResponse(nil, areq, aresp); err != nil {
+			return nil, err
+		}
+		return aresp, nil
+	}
+
+	for _, c := range []struct {
+		description string
+		policy      RefreshTokenRotationPolicy
+		gracePeriod time.Duration
+		check       func(t *testing.T, h *RefreshTokenGrantHandler, token string)
+	}{
+		{
+			description: "should revoke the token family on reuse by default",
+			check: func(t *testing.T, h *RefreshTokenGrantHandler, token string) {
+				aresp, err := refresh(t, h, token)
+				require.NoError(t, err)
+				rotated := aresp.ToMap()["refresh_token"].(string)
+
+				_, err = refresh(t, h, token)
+				require.EqualError(t, err, fosite.ErrInactiveToken.Error())
+
+				_, err = refresh(t, h, rotated)
+				require.EqualError(t, err, fosite.ErrInactiveToken.Error())
+			},
+		},
+		{
+			description: "should not revoke the token family on reuse when rotating",
+			policy:      RefreshTokenRotationRotating,
+			check: func(t *testing.T, h *RefreshTokenGrantHandler, token string) {
+				aresp, err := refresh(t, h, token)
+				require.NoError(t, err)
+				rotated := aresp.ToMap()["refresh_token"].(string)
+
+				_, err = refresh(t, h, token)
+				require.EqualError(t, err, fosite.ErrInactiveToken.Error())
+
+				_, err = refresh(t, h, rotated)
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "should keep the refresh token when static",
+			policy:      RefreshTokenRotationStatic,
+			check: func(t *testing.T, h *RefreshTokenGrantHandler, token string) {
+				aresp, err := refresh(t, h, token)
+				require.NoError(t, err)
+				assert.NotEmpty(t, aresp.GetAccessToken())
+				assert.Nil(t, aresp.ToMap()["refresh_token"])
+
+				_, err = refresh(t, h, token)
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "should accept a rotated token within the grace period",
+			policy:      RefreshTokenRotationWithReuseDetection,
+			gracePeriod: time.Minute,
+			check: func(t *testing.T, h *RefreshTokenGrantHandler, token string) {
+				aresp, err := refresh(t, h, token)
+				require.NoError(t, err)
+				lost := aresp.ToMap()["refresh_token"].(string)
+
+				aresp, err = refresh(t, h, token)
+				require.NoError(t, err)
+				rotated := aresp.ToMap()["refresh_token"].(string)
+
+				_, err = refresh(t, h, lost)
+				require.EqualError(t, err, fosite.ErrInactiveToken.Error())
+
+				_, err = refresh(t, h, token)
+				require.EqualError(t, err, fosite.ErrInactiveToken.Error())
+
+				_, err = refresh(t, h, rotated)
+				require.EqualError(t, err, fosite.ErrInactiveToken.Error())
+			},
+		},
+		{
+			description: "should reject a rotated token after the grace period",
+			policy:      RefreshTokenRotationWithReuseDetection,
+			gracePeriod: time.Millisecond,
+			check: func(t *testing.T, h *RefreshTokenGrantHandler, token string) {
+				_, err := refresh(t, h, token)
+				require.NoError(t, err)
+
+				time.Sleep(time.Millisecond * 10)
+				_, err = refresh(t, h, token)
+				require.EqualError(t, err, fosite.ErrInactiveToken.Error())
+			},
+		},
+	} {
+		t.Run("case="+c.description, func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			h := &RefreshTokenGrantHandler{
+				TokenRevocationStorage:          store,
+				RefreshTokenStrategy:            &hmacshaStrategy,
+				AccessTokenStrategy:             &hmacshaStrategy,
+				AccessTokenLifespan:             time.Hour,
+				RefreshTokenLifespan:            time.Hour,
+				ScopeStrategy:                   fosite.HierarchicScopeStrategy,
+				AudienceMatchingStrategy:        fosite.DefaultAudienceMatchingStrategy,
+				RefreshTokenRotation:            c.policy,
+				RefreshTokenRotationGracePeriod: c.gracePeriod,
+			}
+
+			token, signature, err := hmacshaStrategy.GenerateRefreshToken(nil, nil)
+			require.NoError(t, err)
+			require.NoError(t, store.CreateRefreshTokenSession(nil, signature, &fosite.Request{
+				ID:          "req-id",
+				Client:      &fosite.DefaultClient{ID: "foo"},
+				Session:     &fosite.DefaultSession{},
+				Form:        url.Values{},
+				RequestedAt: time.Now().UTC(),
+			}))
+
+			c.check(t, h, token)
+		})
+	}
+}
+
 func TestRefreshFlowTransactional_PopulateTokenEndpointResponse(t *testing.T) {
 	var mockTransactional *internal.MockTransactional
 	var mockRevocationStore *internal.MockTokenRevocationStorage
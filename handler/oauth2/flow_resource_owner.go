@@ -30,6 +30,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
 )
 
 type ResourceOwnerPasswordCredentialsGrantHandler struct {
@@ -41,6 +42,10 @@ type ResourceOwnerPasswordCredentialsGrantHandler struct {
 	AudienceMatchingStrategy fosite.AudienceMatchingStrategy
 	RefreshTokenScopes       []string
 
+	// Disabled rejects resource owner password credentials grant requests with unsupported_grant_type, as required
+	// by OAuth 2.1.
+	Disabled bool
+
 	*HandleHelper
 }
 
@@ -50,8 +55,12 @@ func (c *ResourceOwnerPasswordCredentialsGrantHandler) HandleTokenEndpointReques
 		return errorsx.WithStack(fosite.ErrUnknownRequest)
 	}
 
-	if !request.GetClient().GetGrantTypes().Has("password") {
-		return errorsx.WithStack(fosite.ErrUnauthorizedClient.WithHint("The client is not allowed to use authorization grant 'password'."))
+	if c.Disabled {
+		return errorsx.WithStack(fosite.ErrUnsupportedGrantType.WithHint("The authorization server does not support the authorization grant 'password'."))
+	}
+
+	if err := fosite.ValidateClientGrantType(request.GetClient(), "password"); err != nil {
+		return err
 	}
 
 	client := request.GetClient()
@@ -78,9 +87,9 @@ func (c *ResourceOwnerPasswordCredentialsGrantHandler) HandleTokenEndpointReques
 	// Credentials must not be passed around, potentially leaking to the database!
 	delete(request.GetRequestForm(), "password")
 
-	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.AccessTokenLifespan).Round(time.Second))
-	if c.RefreshTokenLifespan > -1 {
-		request.GetSession().SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(c.RefreshTokenLifespan).Round(time.Second))
+	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(fosite.GetEffectiveLifespan(request.GetClient(), "password", fosite.AccessToken, c.AccessTokenLifespan)).Round(time.Second))
+	if refreshTokenLifespan := fosite.GetEffectiveLifespan(request.GetClient(), "password", fosite.RefreshToken, c.RefreshTokenLifespan); refreshTokenLifespan > -1 {
+		request.GetSession().SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(refreshTokenLifespan).Round(time.Second))
 	}
 
 	return nil
@@ -92,6 +101,27 @@ func (c *ResourceOwnerPasswordCredentialsGrantHandler) PopulateTokenEndpointResp
 		return errorsx.WithStack(fosite.ErrUnknownRequest)
 	}
 
+	// The refresh and the access token are persisted within one transaction if the storage supports transactions.
+	ctx, err := storage.MaybeBeginTx(ctx, c.ResourceOwnerPasswordCredentialsGrantStorage)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if err := c.populateTokenEndpointResponse(ctx, requester, responder); err != nil {
+		if rollBackTxnErr := storage.MaybeRollbackTx(ctx, c.ResourceOwnerPasswordCredentialsGrantStorage); rollBackTxnErr != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("error: %s; rollback error: %s", err, rollBackTxnErr))
+		}
+		return err
+	}
+
+	if err := storage.MaybeCommitTx(ctx, c.ResourceOwnerPasswordCredentialsGrantStorage); err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	return nil
+}
+
+func (c *ResourceOwnerPasswordCredentialsGrantHandler) populateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
 	var refresh, refreshSignature string
 	if len(c.RefreshTokenScopes) == 0 || requester.GetGrantedScopes().HasOneOf(c.RefreshTokenScopes...) {
 		var err error
@@ -100,6 +130,8 @@ func (c *ResourceOwnerPasswordCredentialsGrantHandler) PopulateTokenEndpointResp
 			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
 		} else if err := c.ResourceOwnerPasswordCredentialsGrantStorage.CreateRefreshTokenSession(ctx, refreshSignature, requester.Sanitize([]string{})); err != nil {
 			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		} else if err := c.IssuanceHooks.RefreshTokenIssued(ctx, requester, refreshSignature); err != nil {
+			return err
 		}
 	}
 
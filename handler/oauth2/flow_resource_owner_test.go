@@ -22,6 +22,7 @@
 package oauth2
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"testing"
@@ -34,6 +35,7 @@ import (
 
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
 )
 
 func TestResourceOwnerFlow_HandleTokenEndpointRequest(t *testing.T) {
@@ -121,6 +123,13 @@ func TestResourceOwnerFlow_HandleTokenEndpointRequest(t *testing.T) {
 				assert.Equal(t, time.Now().Add(time.Hour).UTC().Round(time.Second), areq.GetSession().GetExpiresAt(fosite.RefreshToken))
 			},
 		},
+		{
+			description: "should fail because the grant is disabled",
+			setup: func() {
+				h.Disabled = true
+			},
+			expectErr: fosite.ErrUnsupportedGrantType,
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
 			c.setup()
@@ -231,3 +240,107 @@ func TestResourceOwnerFlow_PopulateTokenEndpointResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestResourceOwnerFlowTransactional_PopulateTokenEndpointResponse(t *testing.T) {
+	var mockTransactional *internal.MockTransactional
+	var mockStore *internal.MockResourceOwnerPasswordCredentialsGrantStorage
+	var chgen *internal.MockAccessTokenStrategy
+	var rtstr *internal.MockRefreshTokenStrategy
+	propagatedContext := context.Background()
+
+	// some storage implementation that has support for transactions, notice the embedded type `storage.Transactional`
+	type transactionalStore struct {
+		storage.Transactional
+		ResourceOwnerPasswordCredentialsGrantStorage
+	}
+
+	for _, testCase := range []struct {
+		description string
+		setup       func()
+		expectError error
+	}{
+		{
+			description: "transaction should be committed successfully if no errors occur",
+			setup: func() {
+				mockTransactional.EXPECT().BeginTX(propagatedContext).Return(propagatedContext, nil).Times(1)
+				rtstr.EXPECT().GenerateRefreshToken(propagatedContext, gomock.Any()).Return("refresh", "refresh-signature", nil)
+				mockStore.EXPECT().CreateRefreshTokenSession(propagatedContext, "refresh-signature", gomock.Any()).Return(nil).Times(1)
+				chgen.EXPECT().GenerateAccessToken(propagatedContext, gomock.Any()).Return("access", "access-signature", nil)
+				mockStore.EXPECT().CreateAccessTokenSession(propagatedContext, "access-signature", gomock.Any()).Return(nil).Times(1)
+				mockTransactional.EXPECT().Commit(propagatedContext).Return(nil).Times(1)
+			},
+		},
+		{
+			description: "transaction should be rolled back if the access token can not be persisted after the refresh token",
+			setup: func() {
+				mockTransactional.EXPECT().BeginTX(propagatedContext).Return(propagatedContext, nil).Times(1)
+				rtstr.EXPECT().GenerateRefreshToken(propagatedContext, gomock.Any()).Return("refresh", "refresh-signature", nil)
+				mockStore.EXPECT().CreateRefreshTokenSession(propagatedContext, "refresh-signature", gomock.Any()).Return(nil).Times(1)
+				chgen.EXPECT().GenerateAccessToken(propagatedContext, gomock.Any()).Return("access", "access-signature", nil)
+				mockStore.EXPECT().CreateAccessTokenSession(propagatedContext, "access-signature", gomock.Any()).Return(errors.New("Whoops, a nasty database error occurred!")).Times(1)
+				mockTransactional.EXPECT().Rollback(propagatedContext).Return(nil).Times(1)
+			},
+			expectError: errors.New("Whoops, a nasty database error occurred!"),
+		},
+		{
+			description: "should result in a server error if the transaction can not be rolled back",
+			setup: func() {
+				mockTransactional.EXPECT().BeginTX(propagatedContext).Return(propagatedContext, nil).Times(1)
+				rtstr.EXPECT().GenerateRefreshToken(propagatedContext, gomock.Any()).Return("refresh", "refresh-signature", nil)
+				mockStore.EXPECT().CreateRefreshTokenSession(propagatedContext, "refresh-signature", gomock.Any()).Return(errors.New("Whoops, a nasty database error occurred!")).Times(1)
+				mockTransactional.EXPECT().Rollback(propagatedContext).Return(errors.New("Whoops, unable to rollback transaction!")).Times(1)
+			},
+			expectError: fosite.ErrServerError,
+		},
+		{
+			description: "should result in a server error if the transaction can not be created",
+			setup: func() {
+				mockTransactional.EXPECT().BeginTX(propagatedContext).Return(nil, errors.New("Whoops, unable to create transaction!"))
+			},
+			expectError: fosite.ErrServerError,
+		},
+		{
+			description: "should result in a server error if the transaction can not be committed",
+			setup: func() {
+				mockTransactional.EXPECT().BeginTX(propagatedContext).Return(propagatedContext, nil).Times(1)
+				rtstr.EXPECT().GenerateRefreshToken(propagatedContext, gomock.Any()).Return("refresh", "refresh-signature", nil)
+				mockStore.EXPECT().CreateRefreshTokenSession(propagatedContext, "refresh-signature", gomock.Any()).Return(nil).Times(1)
+				chgen.EXPECT().GenerateAccessToken(propagatedContext, gomock.Any()).Return("access", "access-signature", nil)
+				mockStore.EXPECT().CreateAccessTokenSession(propagatedContext, "access-signature", gomock.Any()).Return(nil).Times(1)
+				mockTransactional.EXPECT().Commit(propagatedContext).Return(errors.New("Whoops, unable to commit transaction!")).Times(1)
+			},
+			expectError: fosite.ErrServerError,
+		},
+	} {
+		t.Run(fmt.Sprintf("scenario=%s", testCase.description), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTransactional = internal.NewMockTransactional(ctrl)
+			mockStore = internal.NewMockResourceOwnerPasswordCredentialsGrantStorage(ctrl)
+			chgen = internal.NewMockAccessTokenStrategy(ctrl)
+			rtstr = internal.NewMockRefreshTokenStrategy(ctrl)
+			testCase.setup()
+
+			store := transactionalStore{mockTransactional, mockStore}
+			handler := ResourceOwnerPasswordCredentialsGrantHandler{
+				ResourceOwnerPasswordCredentialsGrantStorage: store,
+				HandleHelper: &HandleHelper{
+					AccessTokenStorage:  store,
+					AccessTokenStrategy: chgen,
+					AccessTokenLifespan: time.Hour,
+				},
+				RefreshTokenStrategy: rtstr,
+			}
+
+			request := fosite.NewAccessRequest(&fosite.DefaultSession{})
+			request.GrantTypes = fosite.Arguments{"password"}
+			err := handler.PopulateTokenEndpointResponse(propagatedContext, request, fosite.NewAccessResponse())
+			if testCase.expectError != nil {
+				assert.EqualError(t, err, testCase.expectError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
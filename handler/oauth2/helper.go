@@ -33,6 +33,9 @@ type HandleHelper struct {
 	AccessTokenStorage   AccessTokenStorage
 	AccessTokenLifespan  time.Duration
 	RefreshTokenLifespan time.Duration
+
+	// IssuanceHooks are notified about the issued tokens once they have been persisted.
+	IssuanceHooks *TokenIssuanceHooks
 }
 
 func (h *HandleHelper) IssueAccessToken(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
@@ -41,6 +44,8 @@ func (h *HandleHelper) IssueAccessToken(ctx context.Context, requester fosite.Ac
 		return err
 	} else if err := h.AccessTokenStorage.CreateAccessTokenSession(ctx, signature, requester.Sanitize([]string{})); err != nil {
 		return err
+	} else if err := h.IssuanceHooks.AccessTokenIssued(ctx, requester, signature); err != nil {
+		return err
 	}
 
 	responder.SetAccessToken(token)
@@ -0,0 +1,100 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+)
+
+// TokenIssuedHook is called with the request, its session and the signature of a newly issued token. It is only
+// called once the token has been persisted.
+type TokenIssuedHook func(ctx context.Context, requester fosite.Requester, session fosite.Session, signature string) error
+
+// TokenIssuanceHooks notifies about issued access and refresh tokens, for example to populate external caches or to
+// emit metrics. A nil *TokenIssuanceHooks does nothing.
+type TokenIssuanceHooks struct {
+	OnAccessTokenIssued  TokenIssuedHook
+	OnRefreshTokenIssued TokenIssuedHook
+
+	// StrictHooks aborts the token issuance with a server error if a hook fails. Otherwise hook errors are ignored.
+	StrictHooks bool
+}
+
+// AccessTokenIssued runs the OnAccessTokenIssued hook for the access token with the given signature.
+func (h *TokenIssuanceHooks) AccessTokenIssued(ctx context.Context, requester fosite.Requester, signature string) error {
+	if h == nil {
+		return nil
+	}
+	return h.run(ctx, h.OnAccessTokenIssued, requester, signature)
+}
+
+// RefreshTokenIssued runs the OnRefreshTokenIssued hook for the refresh token with the given signature.
+func (h *TokenIssuanceHooks) RefreshTokenIssued(ctx context.Context, requester fosite.Requester, signature string) error {
+	if h == nil {
+		return nil
+	}
+	return h.run(ctx, h.OnRefreshTokenIssued, requester, signature)
+}
+
+// TokensIssued runs the hooks for an access token and, if the signature is not empty, a refresh token.
+func (h *TokenIssuanceHooks) TokensIssued(ctx context.Context, requester fosite.Requester, accessSignature, refreshSignature string) error {
+	if err := h.AccessTokenIssued(ctx, requester, accessSignature); err != nil {
+		return err
+	}
+	if refreshSignature == "" {
+		return nil
+	}
+	return h.RefreshTokenIssued(ctx, requester, refreshSignature)
+}
+
+func (h *TokenIssuanceHooks) run(ctx context.Context, hook TokenIssuedHook, requester fosite.Requester, signature string) error {
+	if hook == nil {
+		return nil
+	}
+	if err := hook(ctx, requester, requester.GetSession(), signature); err != nil && h.StrictHooks {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+	return nil
+}
+
+// SessionValidator is called with the request a token was issued for before the token is honored. It returns an error
+// if the user session the token is bound to has ended, for example because the user has logged out.
+type SessionValidator func(ctx context.Context, requester fosite.Requester) error
+
+func validateSession(ctx context.Context, validator SessionValidator, requester fosite.Requester) error {
+	if validator == nil {
+		return nil
+	}
+	if err := validator(ctx, requester); err != nil {
+		var rfcErr *fosite.RFC6749Error
+		if errors.As(err, &rfcErr) {
+			return errorsx.WithStack(rfcErr)
+		}
+		return errorsx.WithStack(fosite.ErrSessionExpired.WithWrap(err).WithDebug(err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+)
+
+func TestTokenIssuanceHooks(t *testing.T) {
+	areq := fosite.NewAccessRequest(&fosite.DefaultSession{Subject: "peter"})
+	hookErr := errors.New("cache unavailable")
+
+	for k, c := range []struct {
+		description string
+		withoutHook bool
+		hookErr     error
+		strict      bool
+		expectErr   error
+		expectCalls []string
+	}{
+		{
+			description: "should do nothing without hooks",
+			withoutHook: true,
+		},
+		{
+			description: "should call the hooks with the signatures",
+			expectCalls: []string{"access:at-sig", "refresh:rt-sig"},
+		},
+		{
+			description: "should ignore hook errors by default",
+			hookErr:     hookErr,
+			expectCalls: []string{"access:at-sig", "refresh:rt-sig"},
+		},
+		{
+			description: "should abort on hook errors if strict",
+			hookErr:     hookErr,
+			strict:      true,
+			expectErr:   fosite.ErrServerError,
+			expectCalls: []string{"access:at-sig"},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			var calls []string
+			var hooks *TokenIssuanceHooks
+			if !c.withoutHook {
+				hooks = &TokenIssuanceHooks{
+					OnAccessTokenIssued: func(_ context.Context, requester fosite.Requester, session fosite.Session, signature string) error {
+						assert.Equal(t, areq, requester)
+						assert.Equal(t, areq.GetSession(), session)
+						calls = append(calls, "access:"+signature)
+						return c.hookErr
+					},
+					OnRefreshTokenIssued: func(_ context.Context, _ fosite.Requester, _ fosite.Session, signature string) error {
+						calls = append(calls, "refresh:"+signature)
+						return c.hookErr
+					},
+					StrictHooks: c.strict,
+				}
+			}
+
+			err := hooks.TokensIssued(context.Background(), areq, "at-sig", "rt-sig")
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, c.expectCalls, calls)
+		})
+	}
+}
+
+func TestIssueAccessTokenCallsHookAfterPersistence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	accessStrat := internal.NewMockAccessTokenStrategy(ctrl)
+	accessStore := internal.NewMockAccessTokenStorage(ctrl)
+
+	areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+	var persisted bool
+	helper := HandleHelper{
+		AccessTokenStorage:  accessStore,
+		AccessTokenStrategy: accessStrat,
+		AccessTokenLifespan: time.Hour,
+		IssuanceHooks: &TokenIssuanceHooks{
+			OnAccessTokenIssued: func(_ context.Context, _ fosite.Requester, _ fosite.Session, signature string) error {
+				assert.True(t, persisted)
+				assert.Equal(t, "signature", signature)
+				return errors.New("downstream unavailable")
+			},
+			StrictHooks: true,
+		},
+	}
+
+	accessStrat.EXPECT().GenerateAccessToken(nil, areq).Return("token", "signature", nil)
+	accessStore.EXPECT().CreateAccessTokenSession(nil, "signature", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ fosite.Requester) error {
+		persisted = true
+		return nil
+	})
+
+	err := helper.IssueAccessToken(nil, areq, fosite.NewAccessResponse())
+	require.EqualError(t, err, fosite.ErrServerError.Error())
+}
@@ -34,6 +34,10 @@ type CoreValidator struct {
 	CoreStorage
 	ScopeStrategy                 fosite.ScopeStrategy
 	DisableRefreshTokenValidation bool
+
+	// SessionValidator, if set, confirms that the user session a token is bound to has not ended. Tokens of ended
+	// sessions are inactive.
+	SessionValidator SessionValidator
 }
 
 func (c *CoreValidator) IntrospectToken(ctx context.Context, token string, tokenUse fosite.TokenUse, accessRequest fosite.AccessRequester, scopes []string) (fosite.TokenUse, error) {
@@ -85,6 +89,8 @@ func (c *CoreValidator) introspectAccessToken(ctx context.Context, token string,
 		return errorsx.WithStack(fosite.ErrRequestUnauthorized.WithWrap(err).WithDebug(err.Error()))
 	} else if err := c.CoreStrategy.ValidateAccessToken(ctx, or, token); err != nil {
 		return err
+	} else if err := validateSession(ctx, c.SessionValidator, or); err != nil {
+		return err
 	}
 
 	if err := matchScopes(c.ScopeStrategy, or.GetGrantedScopes(), scopes); err != nil {
@@ -103,6 +109,8 @@ func (c *CoreValidator) introspectRefreshToken(ctx context.Context, token string
 		return errorsx.WithStack(fosite.ErrRequestUnauthorized.WithWrap(err).WithDebug(err.Error()))
 	} else if err := c.CoreStrategy.ValidateRefreshToken(ctx, or, token); err != nil {
 		return err
+	} else if err := validateSession(ctx, c.SessionValidator, or); err != nil {
+		return err
 	}
 
 	if err := matchScopes(c.ScopeStrategy, or.GetGrantedScopes(), scopes); err != nil {
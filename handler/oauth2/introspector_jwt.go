@@ -25,6 +25,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/ory/x/errorsx"
+
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/token/jwt"
 )
@@ -32,6 +34,20 @@ import (
 type StatelessJWTValidator struct {
 	jwt.JWTStrategy
 	ScopeStrategy fosite.ScopeStrategy
+
+	// JWTProfile requires tokens to be access tokens following https://datatracker.ietf.org/doc/html/rfc9068, which
+	// makes it possible to tell them apart from other JWTs such as ID tokens.
+	JWTProfile bool
+
+	// ClockSkew is tolerated when validating the time based claims of tokens. Defaults to zero.
+	ClockSkew time.Duration
+
+	// RevokedJTIStorage, if set, rejects tokens whose jti has been denylisted by the TokenRevocationHandler.
+	RevokedJTIStorage RevokedJTIStorage
+
+	// RevokedSubjectStorage, if set, rejects tokens issued to a subject before its tokens were revoked by the
+	// TokenRevocationHandler.
+	RevokedSubjectStorage RevokedSubjectStorage
 }
 
 // AccessTokenJWTToRequest tries to reconstruct fosite.Request from a JWT.
@@ -85,12 +101,46 @@ func AccessTokenJWTToRequest(token *jwt.Token) fosite.Requester {
 }
 
 func (v *StatelessJWTValidator) IntrospectToken(ctx context.Context, token string, tokenUse fosite.TokenUse, accessRequest fosite.AccessRequester, scopes []string) (fosite.TokenUse, error) {
-	t, err := validate(ctx, v.JWTStrategy, token)
+	t, err := validate(ctx, v.JWTStrategy, token, v.ClockSkew)
 	if err != nil {
 		return "", err
 	}
 
-	// TODO: From here we assume it is an access token, but how do we know it is really and that is not an ID token?
+	if v.JWTProfile {
+		if !isJWTProfileAccessToken(t.Header) {
+			return "", errorsx.WithStack(fosite.ErrInvalidTokenFormat.WithHintf("The token type header must be '%s'.", JWTProfileAccessTokenType))
+		} else if _, ok := t.Claims["exp"]; !ok {
+			return "", errorsx.WithStack(fosite.ErrTokenClaim.WithHint("The token does not specify an expiry."))
+		}
+	}
+
+	if v.RevokedJTIStorage != nil {
+		if jti, _ := t.Claims["jti"].(string); jti != "" {
+			revoked, err := v.RevokedJTIStorage.IsJTIRevoked(ctx, jti)
+			if err != nil {
+				return "", errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+			} else if revoked {
+				return "", errorsx.WithStack(fosite.ErrInactiveToken.WithHint("The token has been revoked."))
+			}
+		}
+	}
+
+	if v.RevokedSubjectStorage != nil {
+		if sub, _ := t.Claims["sub"].(string); sub != "" {
+			revokedAt, err := v.RevokedSubjectStorage.GetSubjectRevokedAt(ctx, sub)
+			if err != nil {
+				return "", errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+			}
+
+			claims := jwt.JWTClaims{}
+			claims.FromMapClaims(t.Claims)
+			if !revokedAt.IsZero() && !claims.IssuedAt.After(revokedAt) {
+				return "", errorsx.WithStack(fosite.ErrInactiveToken.WithHint("The tokens of the subject have been revoked."))
+			}
+		}
+	}
+
+	// TODO: Unless JWTProfile is set we assume it is an access token, but how do we know it is really and that is not an ID token?
 
 	requester := AccessTokenJWTToRequest(t)
 
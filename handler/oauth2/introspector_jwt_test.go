@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -129,6 +130,91 @@ func TestIntrospectJWT(t *testing.T) {
 	}
 }
 
+func TestIntrospectJWTClockSkew(t *testing.T) {
+	strat := &DefaultJWTStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{
+			PrivateKey: internal.MustRSAKey(),
+		},
+	}
+
+	r := jwtExpiredCase(fosite.AccessToken)
+	r.Session.(*JWTSession).JWTClaims.ExpiresAt = time.Now().UTC().Add(-30 * time.Second)
+	r.Session.SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(-30*time.Second))
+	token, _, err := strat.GenerateAccessToken(nil, r)
+	require.NoError(t, err)
+
+	_, err = (&StatelessJWTValidator{JWTStrategy: strat, ScopeStrategy: fosite.HierarchicScopeStrategy}).
+		IntrospectToken(nil, token, fosite.AccessToken, fosite.NewAccessRequest(nil), []string{})
+	require.EqualError(t, err, fosite.ErrTokenExpired.Error())
+
+	_, err = (&StatelessJWTValidator{JWTStrategy: strat, ScopeStrategy: fosite.HierarchicScopeStrategy, ClockSkew: time.Minute}).
+		IntrospectToken(nil, token, fosite.AccessToken, fosite.NewAccessRequest(nil), []string{})
+	require.NoError(t, err)
+}
+
+func TestIntrospectJWTProfile(t *testing.T) {
+	signer := &jwt.RS256JWTStrategy{
+		PrivateKey: internal.MustRSAKey(),
+	}
+	strat := &DefaultJWTStrategy{JWTStrategy: signer}
+	profile := (&DefaultJWTStrategy{JWTStrategy: signer}).WithJWTProfile()
+
+	v := &StatelessJWTValidator{
+		JWTStrategy:   signer,
+		ScopeStrategy: fosite.HierarchicScopeStrategy,
+		JWTProfile:    true,
+	}
+
+	for k, c := range []struct {
+		description string
+		token       func() string
+		expectErr   error
+	}{
+		{
+			description: "should pass because the token follows the profile",
+			token: func() string {
+				r := jwtValidCase(fosite.AccessToken)
+				r.Client = &fosite.DefaultClient{ID: "my-client"}
+				token, _, err := profile.GenerateAccessToken(nil, r)
+				require.NoError(t, err)
+				return token
+			},
+		},
+		{
+			description: "should fail because the token is not a JWT profile access token",
+			token: func() string {
+				token, _, err := strat.GenerateAccessToken(nil, jwtValidCase(fosite.AccessToken))
+				require.NoError(t, err)
+				return token
+			},
+			expectErr: fosite.ErrInvalidTokenFormat,
+		},
+		{
+			description: "should fail because the token is expired",
+			token: func() string {
+				token, _, err := profile.GenerateAccessToken(nil, jwtExpiredCase(fosite.AccessToken))
+				require.NoError(t, err)
+				return token
+			},
+			expectErr: fosite.ErrTokenExpired,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			areq := fosite.NewAccessRequest(nil)
+			_, err := v.IntrospectToken(nil, c.token(), fosite.AccessToken, areq, []string{})
+
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "peter", areq.Session.GetSubject())
+				assert.Equal(t, "my-client", areq.Client.GetID())
+				assert.Equal(t, fosite.Arguments{"email", "offline"}, areq.GetGrantedScopes())
+			}
+		})
+	}
+}
+
 func BenchmarkIntrospectJWT(b *testing.B) {
 	strat := &DefaultJWTStrategy{
 		JWTStrategy: &jwt.RS256JWTStrategy{
@@ -22,6 +22,7 @@
 package oauth2
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
@@ -117,3 +118,141 @@ func TestIntrospectToken(t *testing.T) {
 		})
 	}
 }
+
+func TestIntrospectTokenSessionValidator(t *testing.T) {
+	for k, c := range []struct {
+		description string
+		validator   SessionValidator
+		expectErr   error
+	}{
+		{
+			description: "should fail because the session has ended",
+			validator: func(_ context.Context, _ fosite.Requester) error {
+				return errors.New("the user has logged out")
+			},
+			expectErr: fosite.ErrSessionExpired,
+		},
+		{
+			description: "should pass because the session is still valid",
+			validator: func(_ context.Context, _ fosite.Requester) error {
+				return nil
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := internal.NewMockCoreStorage(ctrl)
+			chgen := internal.NewMockCoreStrategy(ctrl)
+			or := fosite.NewAccessRequest(&fosite.DefaultSession{})
+			v := &CoreValidator{
+				CoreStrategy:     chgen,
+				CoreStorage:      store,
+				SessionValidator: c.validator,
+			}
+
+			chgen.EXPECT().RefreshTokenSignature("token").Return("sig")
+			store.EXPECT().GetRefreshTokenSession(nil, "sig", nil).Return(or, nil)
+			chgen.EXPECT().ValidateRefreshToken(nil, or, "token").Return(nil)
+			if c.expectErr != nil {
+				chgen.EXPECT().AccessTokenSignature("token").Return("sig")
+				store.EXPECT().GetAccessTokenSession(nil, "sig", nil).Return(or, nil)
+				chgen.EXPECT().ValidateAccessToken(nil, or, "token").Return(nil)
+			}
+
+			tu, err := v.IntrospectToken(nil, "token", fosite.RefreshToken, fosite.NewAccessRequest(nil), []string{})
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, fosite.RefreshToken, tu)
+		})
+	}
+}
+
+func TestIntrospectTokenTypeHint(t *testing.T) {
+	for k, c := range []struct {
+		description string
+		hint        fosite.TokenUse
+		setup       func(store *internal.MockCoreStorage, chgen *internal.MockCoreStrategy, or fosite.Requester)
+		expectTU    fosite.TokenUse
+	}{
+		{
+			description: "should only look up the refresh token if hinted",
+			hint:        fosite.RefreshToken,
+			setup: func(store *internal.MockCoreStorage, chgen *internal.MockCoreStrategy, or fosite.Requester) {
+				chgen.EXPECT().RefreshTokenSignature("token").Return("sig")
+				store.EXPECT().GetRefreshTokenSession(nil, "sig", nil).Return(or, nil)
+				chgen.EXPECT().ValidateRefreshToken(nil, or, "token").Return(nil)
+			},
+			expectTU: fosite.RefreshToken,
+		},
+		{
+			description: "should only look up the access token if hinted",
+			hint:        fosite.AccessToken,
+			setup: func(store *internal.MockCoreStorage, chgen *internal.MockCoreStrategy, or fosite.Requester) {
+				chgen.EXPECT().AccessTokenSignature("token").Return("sig")
+				store.EXPECT().GetAccessTokenSession(nil, "sig", nil).Return(or, nil)
+				chgen.EXPECT().ValidateAccessToken(nil, or, "token").Return(nil)
+			},
+			expectTU: fosite.AccessToken,
+		},
+		{
+			description: "should fall back to the access token if the refresh token hint is wrong",
+			hint:        fosite.RefreshToken,
+			setup: func(store *internal.MockCoreStorage, chgen *internal.MockCoreStrategy, or fosite.Requester) {
+				chgen.EXPECT().RefreshTokenSignature("token").Return("sig")
+				store.EXPECT().GetRefreshTokenSession(nil, "sig", nil).Return(nil, fosite.ErrNotFound)
+				chgen.EXPECT().AccessTokenSignature("token").Return("sig")
+				store.EXPECT().GetAccessTokenSession(nil, "sig", nil).Return(or, nil)
+				chgen.EXPECT().ValidateAccessToken(nil, or, "token").Return(nil)
+			},
+			expectTU: fosite.AccessToken,
+		},
+		{
+			description: "should fall back to the refresh token if the access token hint is wrong",
+			hint:        fosite.AccessToken,
+			setup: func(store *internal.MockCoreStorage, chgen *internal.MockCoreStrategy, or fosite.Requester) {
+				chgen.EXPECT().AccessTokenSignature("token").Return("sig")
+				store.EXPECT().GetAccessTokenSession(nil, "sig", nil).Return(nil, fosite.ErrNotFound)
+				chgen.EXPECT().RefreshTokenSignature("token").Return("sig")
+				store.EXPECT().GetRefreshTokenSession(nil, "sig", nil).Return(or, nil)
+				chgen.EXPECT().ValidateRefreshToken(nil, or, "token").Return(nil)
+			},
+			expectTU: fosite.RefreshToken,
+		},
+		{
+			description: "should find the refresh token without a hint",
+			setup: func(store *internal.MockCoreStorage, chgen *internal.MockCoreStrategy, or fosite.Requester) {
+				chgen.EXPECT().AccessTokenSignature("token").Return("sig")
+				store.EXPECT().GetAccessTokenSession(nil, "sig", nil).Return(nil, fosite.ErrNotFound)
+				chgen.EXPECT().RefreshTokenSignature("token").Return("sig")
+				store.EXPECT().GetRefreshTokenSession(nil, "sig", nil).Return(or, nil)
+				chgen.EXPECT().ValidateRefreshToken(nil, or, "token").Return(nil)
+			},
+			expectTU: fosite.RefreshToken,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := internal.NewMockCoreStorage(ctrl)
+			chgen := internal.NewMockCoreStrategy(ctrl)
+
+			or := fosite.NewAccessRequest(nil)
+			or.GrantScope("offline")
+			or.GrantAudience("https://api.example.com")
+			c.setup(store, chgen, or)
+
+			v := &CoreValidator{CoreStrategy: chgen, CoreStorage: store, ScopeStrategy: fosite.HierarchicScopeStrategy}
+			areq := fosite.NewAccessRequest(nil)
+			tu, err := v.IntrospectToken(nil, "token", c.hint, areq, []string{"offline"})
+			require.NoError(t, err)
+			assert.Equal(t, c.expectTU, tu)
+			assert.Equal(t, fosite.Arguments{"offline"}, areq.GetGrantedScopes())
+			assert.Equal(t, fosite.Arguments{"https://api.example.com"}, areq.GetGrantedAudience())
+		})
+	}
+}
@@ -23,23 +23,50 @@ package oauth2
 
 import (
 	"context"
+	"time"
 
 	"github.com/ory/x/errorsx"
 
 	"github.com/pkg/errors"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
 )
 
+// RevocationHook is notified about every token revoked by the TokenRevocationHandler. The token is the value
+// presented by the client for the revoked token itself, and the request ID for tokens of the other type that were
+// revoked along with it, as their values are not known to the authorization server.
+type RevocationHook func(ctx context.Context, token string, tokenType fosite.TokenType)
+
 type TokenRevocationHandler struct {
 	TokenRevocationStorage TokenRevocationStorage
 	RefreshTokenStrategy   RefreshTokenStrategy
 	AccessTokenStrategy    AccessTokenStrategy
+
+	// RevocationHook, if set, is called once for every revoked token after it has been removed from the storage.
+	// It cannot fail the revocation.
+	RevocationHook RevocationHook
+
+	// RevokedJTIStorage, if set together with JWTStrategy, receives the jti of every revoked JWT access token, so that
+	// validators which do not consult the TokenRevocationStorage reject it until it expires.
+	RevokedJTIStorage RevokedJTIStorage
+
+	// JWTStrategy validates revoked tokens which are JWT access tokens. It must verify the signatures of the tokens
+	// issued by the access token strategy.
+	JWTStrategy jwt.JWTStrategy
+
+	// ClockSkew is added to the expiry of denylisted tokens, as validators tolerate it. Defaults to zero.
+	ClockSkew time.Duration
+
+	// RevokedSubjectStorage, if set, denies the JWT access tokens of subjects whose tokens are revoked by
+	// RevokeTokensBySubject.
+	RevokedSubjectStorage RevokedSubjectStorage
 }
 
 // RevokeToken implements https://tools.ietf.org/html/rfc7009#section-2.1
 // The token type hint indicates which token type check should be performed first.
 func (r *TokenRevocationHandler) RevokeToken(ctx context.Context, token string, tokenType fosite.TokenType, client fosite.Client) error {
+	discoveryTypes := []fosite.TokenType{fosite.RefreshToken, fosite.AccessToken}
 	discoveryFuncs := []func() (request fosite.Requester, err error){
 		func() (request fosite.Requester, err error) {
 			// Refresh token
@@ -56,19 +83,34 @@ func (r *TokenRevocationHandler) RevokeToken(ctx context.Context, token string,
 	// Token type hinting
 	if tokenType == fosite.AccessToken {
 		discoveryFuncs[0], discoveryFuncs[1] = discoveryFuncs[1], discoveryFuncs[0]
+		discoveryTypes[0], discoveryTypes[1] = discoveryTypes[1], discoveryTypes[0]
 	}
 
 	var ar fosite.Requester
 	var err1, err2 error
+	foundType := discoveryTypes[0]
 	if ar, err1 = discoveryFuncs[0](); err1 != nil {
 		ar, err2 = discoveryFuncs[1]()
+		foundType = discoveryTypes[1]
 	}
 	// err2 can only be not nil if first err1 was not nil
 	if err2 != nil {
-		return storeErrorsToRevocationError(err1, err2)
+		if err := storeErrorsToRevocationError(err1, err2); err != nil {
+			return err
+		}
+
+		// The token is unknown to the storage, but it might still be a JWT access token issued statelessly.
+		revoked, err := r.revokeJWTAccessToken(ctx, token, client, true)
+		if err != nil {
+			return err
+		}
+		if revoked && r.RevocationHook != nil {
+			r.RevocationHook(ctx, token, fosite.AccessToken)
+		}
+		return nil
 	}
 
-	if ar.GetClient().GetID() != client.GetID() {
+	if fosite.GetClientIdentifier(ar) != (fosite.ClientIdentifier{Tenant: fosite.TenantFromContext(ctx), ID: client.GetID()}) {
 		return errorsx.WithStack(fosite.ErrUnauthorizedClient)
 	}
 
@@ -76,7 +118,121 @@ func (r *TokenRevocationHandler) RevokeToken(ctx context.Context, token string,
 	err1 = r.TokenRevocationStorage.RevokeRefreshToken(ctx, requestID)
 	err2 = r.TokenRevocationStorage.RevokeAccessToken(ctx, requestID)
 
-	return storeErrorsToRevocationError(err1, err2)
+	if err := storeErrorsToRevocationError(err1, err2); err != nil {
+		return err
+	}
+
+	if foundType == fosite.AccessToken {
+		if _, err := r.revokeJWTAccessToken(ctx, token, client, false); err != nil {
+			return err
+		}
+	}
+
+	if r.RevocationHook != nil {
+		revoked := map[fosite.TokenType]error{fosite.RefreshToken: err1, fosite.AccessToken: err2}
+		for _, tokenType := range discoveryTypes {
+			if revoked[tokenType] != nil {
+				continue
+			}
+			if tokenType == foundType {
+				r.RevocationHook(ctx, token, tokenType)
+			} else {
+				r.RevocationHook(ctx, requestID, tokenType)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RevokeTokensBySubject revokes the access and refresh tokens of all clients issued to the subject and returns their
+// number. The RevocationHook is not called, as the revoked tokens are not known individually. It returns
+// fosite.ErrUnknownRequest if the storage does not implement SubjectTokenRevocationStorage.
+func (r *TokenRevocationHandler) RevokeTokensBySubject(ctx context.Context, subject string) (int, error) {
+	storage, ok := r.TokenRevocationStorage.(SubjectTokenRevocationStorage)
+	if !ok {
+		return 0, errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	refreshTokens, err := storage.DeleteRefreshTokensBySubject(ctx, subject)
+	if err != nil {
+		return 0, errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	accessTokens, err := storage.DeleteAccessTokensBySubject(ctx, subject)
+	if err != nil {
+		return refreshTokens, errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if r.RevokedSubjectStorage != nil {
+		if err := r.RevokedSubjectStorage.RevokeSubject(ctx, subject, time.Now().UTC()); err != nil {
+			return refreshTokens + accessTokens, errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+	}
+
+	return refreshTokens + accessTokens, nil
+}
+
+// RevokeTokensByClient revokes the access tokens, refresh tokens and authorize codes issued to the client and returns
+// their number. The RevocationHook is not called, as the revoked tokens are not known individually. It returns
+// fosite.ErrUnknownRequest if the storage does not implement ClientTokenRevocationStorage.
+func (r *TokenRevocationHandler) RevokeTokensByClient(ctx context.Context, clientID string) (int, error) {
+	storage, ok := r.TokenRevocationStorage.(ClientTokenRevocationStorage)
+	if !ok {
+		return 0, errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	var revoked int
+	for _, deleteTokens := range []func(ctx context.Context, clientID string) (int, error){
+		storage.DeleteAuthorizeCodesByClient,
+		storage.DeleteRefreshTokensByClient,
+		storage.DeleteAccessTokensByClient,
+	} {
+		n, err := deleteTokens(ctx, clientID)
+		revoked += n
+		if err != nil {
+			return revoked, errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+	}
+
+	return revoked, nil
+}
+
+// revokeJWTAccessToken adds the jti of the token to the denylist if the token is a valid JWT access token. Tokens which
+// are no JWTs, are expired or do not have a jti are ignored. The client is verified against the client_id claim if
+// the token was not found in the storage.
+func (r *TokenRevocationHandler) revokeJWTAccessToken(ctx context.Context, token string, client fosite.Client, verifyClient bool) (bool, error) {
+	if r.RevokedJTIStorage == nil || r.JWTStrategy == nil {
+		return false, nil
+	}
+
+	t, err := validate(ctx, r.JWTStrategy, token, r.ClockSkew)
+	if err != nil {
+		return false, nil
+	}
+
+	claims := jwt.JWTClaims{}
+	claims.FromMapClaims(t.Claims)
+	if claims.JTI == "" {
+		return false, nil
+	}
+
+	if verifyClient {
+		if clientID, _ := t.Claims["client_id"].(string); clientID != client.GetID() {
+			return false, errorsx.WithStack(fosite.ErrUnauthorizedClient)
+		}
+	}
+
+	exp := claims.ExpiresAt
+	if !exp.IsZero() {
+		exp = exp.Add(r.ClockSkew)
+	}
+
+	if err := r.RevokedJTIStorage.RevokeJTI(ctx, claims.JTI, exp); err != nil {
+		return false, errorsx.WithStack(fosite.ErrTemporarilyUnavailable.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	return true, nil
 }
 
 func storeErrorsToRevocationError(err1, err2 error) error {
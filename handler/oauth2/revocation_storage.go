@@ -23,6 +23,7 @@ package oauth2
 
 import (
 	"context"
+	"time"
 )
 
 // TokenRevocationStorage provides the storage implementation
@@ -47,3 +48,52 @@ type TokenRevocationStorage interface {
 	// token as well.
 	RevokeAccessToken(ctx context.Context, requestID string) error
 }
+
+// RevokedJTIStorage is a denylist for the jti of revoked JWT access tokens, which remain valid for stateless
+// validators after they have been removed from the TokenRevocationStorage.
+type RevokedJTIStorage interface {
+	// RevokeJTI adds the jti to the denylist. The entry can be deleted once exp has passed, as the token is expired
+	// then anyway. A zero exp denies the jti forever.
+	RevokeJTI(ctx context.Context, jti string, exp time.Time) error
+
+	// IsJTIRevoked returns true if the jti is on the denylist and its entry has not expired yet.
+	IsJTIRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// SubjectTokenRevocationStorage deletes all tokens of a subject at once, for example after the account of the resource
+// owner has been compromised.
+type SubjectTokenRevocationStorage interface {
+	// DeleteAccessTokensBySubject deletes the access tokens of all clients issued to the subject and returns their
+	// number.
+	DeleteAccessTokensBySubject(ctx context.Context, subject string) (int, error)
+
+	// DeleteRefreshTokensBySubject deletes the refresh tokens of all clients issued to the subject and returns the
+	// number of those which were not revoked yet.
+	DeleteRefreshTokensBySubject(ctx context.Context, subject string) (int, error)
+}
+
+// RevokedSubjectStorage is a denylist for the JWT access tokens of subjects whose tokens have been revoked at once, as
+// their jti values are not known to the authorization server.
+type RevokedSubjectStorage interface {
+	// RevokeSubject denies all JWT access tokens of the subject issued until revokedAt. The entry can be deleted once
+	// these tokens have expired.
+	RevokeSubject(ctx context.Context, subject string, revokedAt time.Time) error
+
+	// GetSubjectRevokedAt returns when the tokens of the subject were revoked, or the zero time if they were not.
+	GetSubjectRevokedAt(ctx context.Context, subject string) (time.Time, error)
+}
+
+// ClientTokenRevocationStorage deletes all tokens issued to a client at once, for example when the client is
+// decommissioned.
+type ClientTokenRevocationStorage interface {
+	// DeleteAccessTokensByClient deletes the access tokens issued to the client and returns their number.
+	DeleteAccessTokensByClient(ctx context.Context, clientID string) (int, error)
+
+	// DeleteRefreshTokensByClient deletes the refresh tokens issued to the client and returns the number of those
+	// which were not revoked yet.
+	DeleteRefreshTokensByClient(ctx context.Context, clientID string) (int, error)
+
+	// DeleteAuthorizeCodesByClient deletes the authorize codes issued to the client and returns the number of those
+	// which were not used yet.
+	DeleteAuthorizeCodesByClient(ctx context.Context, clientID string) (int, error)
+}
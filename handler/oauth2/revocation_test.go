@@ -22,14 +22,19 @@
 package oauth2
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/jwt"
 )
 
 func TestRevokeToken(t *testing.T) {
@@ -234,3 +239,232 @@ func TestRevokeToken(t *testing.T) {
 		})
 	}
 }
+
+func TestRevokeTokenHook(t *testing.T) {
+	type revocation struct {
+		token     string
+		tokenType fosite.TokenType
+	}
+
+	for k, c := range []struct {
+		description string
+		tokenType   fosite.TokenType
+		mock        func(store *internal.MockTokenRevocationStorage, atStrat *internal.MockAccessTokenStrategy, rtStrat *internal.MockRefreshTokenStrategy, ar fosite.Requester)
+		expectErr   error
+		expect      []revocation
+	}{
+		{
+			description: "should notify about the refresh token and the cascaded access token",
+			tokenType:   fosite.RefreshToken,
+			mock: func(store *internal.MockTokenRevocationStorage, atStrat *internal.MockAccessTokenStrategy, rtStrat *internal.MockRefreshTokenStrategy, ar fosite.Requester) {
+				rtStrat.EXPECT().RefreshTokenSignature("foo").Return("sig")
+				store.EXPECT().GetRefreshTokenSession(gomock.Any(), "sig", nil).Return(ar, nil)
+				store.EXPECT().RevokeRefreshToken(gomock.Any(), "request-id")
+				store.EXPECT().RevokeAccessToken(gomock.Any(), "request-id")
+			},
+			expect: []revocation{{"foo", fosite.RefreshToken}, {"request-id", fosite.AccessToken}},
+		},
+		{
+			description: "should notify about the access token and the cascaded refresh token",
+			tokenType:   fosite.AccessToken,
+			mock: func(store *internal.MockTokenRevocationStorage, atStrat *internal.MockAccessTokenStrategy, rtStrat *internal.MockRefreshTokenStrategy, ar fosite.Requester) {
+				atStrat.EXPECT().AccessTokenSignature("foo").Return("sig")
+				store.EXPECT().GetAccessTokenSession(gomock.Any(), "sig", nil).Return(ar, nil)
+				store.EXPECT().RevokeRefreshToken(gomock.Any(), "request-id")
+				store.EXPECT().RevokeAccessToken(gomock.Any(), "request-id")
+			},
+			expect: []revocation{{"foo", fosite.AccessToken}, {"request-id", fosite.RefreshToken}},
+		},
+		{
+			description: "should notify about the refresh token only when the hint was wrong and no access token exists",
+			tokenType:   fosite.AccessToken,
+			mock: func(store *internal.MockTokenRevocationStorage, atStrat *internal.MockAccessTokenStrategy, rtStrat *internal.MockRefreshTokenStrategy, ar fosite.Requester) {
+				atStrat.EXPECT().AccessTokenSignature("foo").Return("sig")
+				store.EXPECT().GetAccessTokenSession(gomock.Any(), "sig", nil).Return(nil, fosite.ErrNotFound)
+				rtStrat.EXPECT().RefreshTokenSignature("foo").Return("sig")
+				store.EXPECT().GetRefreshTokenSession(gomock.Any(), "sig", nil).Return(ar, nil)
+				store.EXPECT().RevokeRefreshToken(gomock.Any(), "request-id")
+				store.EXPECT().RevokeAccessToken(gomock.Any(), "request-id").Return(fosite.ErrNotFound)
+			},
+			expect: []revocation{{"foo", fosite.RefreshToken}},
+		},
+		{
+			description: "should not notify when the storage fails",
+			tokenType:   fosite.RefreshToken,
+			mock: func(store *internal.MockTokenRevocationStorage, atStrat *internal.MockAccessTokenStrategy, rtStrat *internal.MockRefreshTokenStrategy, ar fosite.Requester) {
+				rtStrat.EXPECT().RefreshTokenSignature("foo").Return("sig")
+				store.EXPECT().GetRefreshTokenSession(gomock.Any(), "sig", nil).Return(ar, nil)
+				store.EXPECT().RevokeRefreshToken(gomock.Any(), "request-id").Return(errors.New("connection lost"))
+				store.EXPECT().RevokeAccessToken(gomock.Any(), "request-id")
+			},
+			expectErr: fosite.ErrTemporarilyUnavailable,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := internal.NewMockTokenRevocationStorage(ctrl)
+			atStrat := internal.NewMockAccessTokenStrategy(ctrl)
+			rtStrat := internal.NewMockRefreshTokenStrategy(ctrl)
+
+			ar := fosite.NewAccessRequest(nil)
+			ar.ID = "request-id"
+			ar.Client = &fosite.DefaultClient{ID: "bar"}
+			c.mock(store, atStrat, rtStrat, ar)
+
+			var revoked []revocation
+			h := TokenRevocationHandler{
+				TokenRevocationStorage: store,
+				RefreshTokenStrategy:   rtStrat,
+				AccessTokenStrategy:    atStrat,
+				RevocationHook: func(ctx context.Context, token string, tokenType fosite.TokenType) {
+					revoked = append(revoked, revocation{token, tokenType})
+				},
+			}
+
+			err := h.RevokeToken(context.Background(), "foo", c.tokenType, &fosite.DefaultClient{ID: "bar"})
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, c.expect, revoked)
+		})
+	}
+}
+
+func TestRevokeJWTAccessToken(t *testing.T) {
+	strat := (&DefaultJWTStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{
+			PrivateKey: internal.MustRSAKey(),
+		},
+		HMACSHAStrategy: &hmacshaStrategy,
+	}).WithJWTProfile()
+
+	for k, c := range []struct {
+		description   string
+		stored        bool
+		clientID      string
+		disabled      bool
+		expectErr     error
+		expectRevoked bool
+	}{
+		{
+			description:   "should deny a stored token",
+			stored:        true,
+			clientID:      "bar",
+			expectRevoked: true,
+		},
+		{
+			description:   "should deny a token unknown to the storage",
+			clientID:      "bar",
+			expectRevoked: true,
+		},
+		{
+			description: "should fail because the token was issued to another client",
+			clientID:    "baz",
+			expectErr:   fosite.ErrUnauthorizedClient,
+		},
+		{
+			description: "should not deny the token if the denylist is disabled",
+			stored:      true,
+			clientID:    "bar",
+			disabled:    true,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			store := storage.NewMemoryStore()
+
+			ar := jwtValidCase(fosite.AccessToken)
+			ar.ID = "request-id"
+			ar.Client = &fosite.DefaultClient{ID: "bar"}
+			token, signature, err := strat.GenerateAccessToken(context.Background(), ar)
+			require.NoError(t, err)
+			if c.stored {
+				require.NoError(t, store.CreateAccessTokenSession(context.Background(), signature, ar))
+			}
+
+			h := TokenRevocationHandler{
+				TokenRevocationStorage: store,
+				RefreshTokenStrategy:   strat,
+				AccessTokenStrategy:    strat,
+				RevokedJTIStorage:      store,
+				JWTStrategy:            strat,
+			}
+			if c.disabled {
+				h.RevokedJTIStorage = nil
+			}
+
+			err = h.RevokeToken(context.Background(), token, fosite.AccessToken, &fosite.DefaultClient{ID: c.clientID})
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+
+			v := &StatelessJWTValidator{
+				JWTStrategy:       strat,
+				ScopeStrategy:     fosite.HierarchicScopeStrategy,
+				JWTProfile:        true,
+				RevokedJTIStorage: store,
+			}
+			_, err = v.IntrospectToken(context.Background(), token, fosite.AccessToken, fosite.NewAccessRequest(nil), []string{})
+			if c.expectRevoked {
+				require.EqualError(t, err, fosite.ErrInactiveToken.Error())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRevokeTokensBySubject(t *testing.T) {
+	strat := (&DefaultJWTStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{
+			PrivateKey: internal.MustRSAKey(),
+		},
+		HMACSHAStrategy: &hmacshaStrategy,
+	}).WithJWTProfile()
+
+	t.Run("case=should fail because the storage can not revoke tokens by subject", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h := TokenRevocationHandler{TokenRevocationStorage: internal.NewMockTokenRevocationStorage(ctrl)}
+		_, err := h.RevokeTokensBySubject(context.Background(), "peter")
+		require.EqualError(t, err, fosite.ErrUnknownRequest.Error())
+	})
+
+	t.Run("case=should deny the JWT access tokens of the subject", func(t *testing.T) {
+		store := storage.NewMemoryStore()
+		newToken := func(subject string) string {
+			ar := jwtValidCase(fosite.AccessToken)
+			ar.Client = &fosite.DefaultClient{ID: "bar"}
+			ar.Session.(*JWTSession).Subject = subject
+			ar.Session.(*JWTSession).JWTClaims.Subject = subject
+			token, signature, err := strat.GenerateAccessToken(context.Background(), ar)
+			require.NoError(t, err)
+			require.NoError(t, store.CreateAccessTokenSession(context.Background(), signature, ar))
+			return token
+		}
+		peter, alice := newToken("peter"), newToken("alice")
+
+		h := TokenRevocationHandler{
+			TokenRevocationStorage: store,
+			RevokedSubjectStorage:  store,
+		}
+		revoked, err := h.RevokeTokensBySubject(context.Background(), "peter")
+		require.NoError(t, err)
+		assert.Equal(t, 1, revoked)
+
+		v := &StatelessJWTValidator{
+			JWTStrategy:           strat,
+			ScopeStrategy:         fosite.HierarchicScopeStrategy,
+			RevokedSubjectStorage: store,
+		}
+		_, err = v.IntrospectToken(context.Background(), peter, fosite.AccessToken, fosite.NewAccessRequest(nil), []string{})
+		require.EqualError(t, err, fosite.ErrInactiveToken.Error())
+		_, err = v.IntrospectToken(context.Background(), alice, fosite.AccessToken, fosite.NewAccessRequest(nil), []string{})
+		require.NoError(t, err)
+	})
+}
@@ -23,6 +23,7 @@ package oauth2
 
 import (
 	"context"
+	"time"
 
 	"github.com/ory/fosite"
 )
@@ -65,4 +66,14 @@ type RefreshTokenStorage interface {
 	GetRefreshTokenSession(ctx context.Context, signature string, session fosite.Session) (request fosite.Requester, err error)
 
 	DeleteRefreshTokenSession(ctx context.Context, signature string) (err error)
+
+	// RevokeRefreshTokenMaybeGracePeriod is called when the refresh token with the given signature, which belongs to
+	// the request with the given ID, has been rotated. All other refresh tokens of the request must be revoked
+	// immediately, while the given one must remain usable until the grace period has elapsed. Afterwards,
+	// GetRefreshTokenSession must return the fosite.ErrInactiveToken error for it.
+	RevokeRefreshTokenMaybeGracePeriod(ctx context.Context, requestID string, signature string, gracePeriod time.Duration) (err error)
+
+	// RevokeRefreshTokenFamily looks up all refresh tokens which belong to the request with the given ID, including
+	// tokens which have already been rotated, and invalidates them.
+	RevokeRefreshTokenFamily(ctx context.Context, requestID string) (err error)
 }
@@ -0,0 +1,80 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+)
+
+// AccessTokenFormatStrategy issues opaque or JWT access tokens depending on the access token format registered by
+// the client. Refresh tokens and authorize codes are always opaque. Access tokens of both formats are stored, which
+// is why they can be introspected, refreshed and revoked alike.
+type AccessTokenFormatStrategy struct {
+	*HMACSHAStrategy
+	JWTStrategy *DefaultJWTStrategy
+
+	// DefaultAccessTokenFormat is used for clients which did not register an access token format. Defaults to
+	// fosite.AccessTokenFormatOpaque.
+	DefaultAccessTokenFormat string
+}
+
+func (h *AccessTokenFormatStrategy) getAccessTokenFormat(client fosite.Client) string {
+	if c, ok := client.(fosite.AccessTokenFormatClient); ok && c.GetAccessTokenFormat() != "" {
+		return c.GetAccessTokenFormat()
+	} else if h.DefaultAccessTokenFormat != "" {
+		return h.DefaultAccessTokenFormat
+	}
+	return fosite.AccessTokenFormatOpaque
+}
+
+// strategyForToken picks the strategy based on the shape of the token, because the token might have been issued
+// before the client changed its access token format.
+func (h *AccessTokenFormatStrategy) strategyForToken(token string) AccessTokenStrategy {
+	if strings.Count(token, ".") == 2 {
+		return h.JWTStrategy
+	}
+	return h.HMACSHAStrategy
+}
+
+func (h *AccessTokenFormatStrategy) AccessTokenSignature(token string) string {
+	return h.strategyForToken(token).AccessTokenSignature(token)
+}
+
+func (h *AccessTokenFormatStrategy) GenerateAccessToken(ctx context.Context, requester fosite.Requester) (token string, signature string, err error) {
+	switch format := h.getAccessTokenFormat(requester.GetClient()); format {
+	case fosite.AccessTokenFormatOpaque:
+		return h.HMACSHAStrategy.GenerateAccessToken(ctx, requester)
+	case fosite.AccessTokenFormatJWT:
+		return h.JWTStrategy.GenerateAccessToken(ctx, requester)
+	default:
+		return "", "", errorsx.WithStack(fosite.ErrServerError.WithDebugf("The access token format '%s' of the OAuth 2.0 Client is not supported.", format))
+	}
+}
+
+func (h *AccessTokenFormatStrategy) ValidateAccessToken(ctx context.Context, requester fosite.Requester, token string) error {
+	return h.strategyForToken(token).ValidateAccessToken(ctx, requester, token)
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+)
+
+func TestAccessTokenFormatStrategy(t *testing.T) {
+	strategy := &AccessTokenFormatStrategy{
+		HMACSHAStrategy: &hmacshaStrategy,
+		JWTStrategy:     j,
+	}
+
+	for k, c := range []struct {
+		format        string
+		defaultFormat string
+		expectJWT     bool
+		expectErr     bool
+	}{
+		{format: ""},
+		{format: fosite.AccessTokenFormatOpaque},
+		{format: fosite.AccessTokenFormatJWT, expectJWT: true},
+		{format: "", defaultFormat: fosite.AccessTokenFormatJWT, expectJWT: true},
+		{format: fosite.AccessTokenFormatOpaque, defaultFormat: fosite.AccessTokenFormatJWT},
+		{format: "foo", expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			strategy.DefaultAccessTokenFormat = c.defaultFormat
+			req := jwtValidCase(fosite.AccessToken)
+			req.Client = &fosite.DefaultClient{ID: "foo", AccessTokenFormat: c.format}
+
+			token, signature, err := strategy.GenerateAccessToken(nil, req)
+			if c.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, c.expectJWT, strings.Count(token, ".") == 2)
+			assert.Equal(t, signature, strategy.AccessTokenSignature(token))
+			require.NoError(t, strategy.ValidateAccessToken(nil, req, token))
+
+			// Tokens keep being validated in their format after the client switched formats.
+			req.Client = &fosite.DefaultClient{ID: "foo"}
+			require.NoError(t, strategy.ValidateAccessToken(nil, req, token))
+		})
+	}
+}
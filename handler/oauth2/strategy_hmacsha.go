@@ -36,20 +36,26 @@ type HMACSHAStrategy struct {
 	AccessTokenLifespan   time.Duration
 	RefreshTokenLifespan  time.Duration
 	AuthorizeCodeLifespan time.Duration
+
+	// AccessTokenPrefix, RefreshTokenPrefix and AuthorizeCodePrefix are prepended to the respective tokens, for
+	// example "ory_at_", which makes them recognizable for secret scanning tools.
+	AccessTokenPrefix   string
+	RefreshTokenPrefix  string
+	AuthorizeCodePrefix string
 }
 
 func (h HMACSHAStrategy) AccessTokenSignature(token string) string {
-	return h.Enigma.Signature(token)
+	return h.Enigma.SignatureWithPrefix(h.AccessTokenPrefix, token)
 }
 func (h HMACSHAStrategy) RefreshTokenSignature(token string) string {
-	return h.Enigma.Signature(token)
+	return h.Enigma.SignatureWithPrefix(h.RefreshTokenPrefix, token)
 }
 func (h HMACSHAStrategy) AuthorizeCodeSignature(token string) string {
-	return h.Enigma.Signature(token)
+	return h.Enigma.SignatureWithPrefix(h.AuthorizeCodePrefix, token)
 }
 
 func (h HMACSHAStrategy) GenerateAccessToken(_ context.Context, _ fosite.Requester) (token string, signature string, err error) {
-	return h.Enigma.Generate()
+	return h.Enigma.GenerateWithPrefix(h.AccessTokenPrefix)
 }
 
 func (h HMACSHAStrategy) ValidateAccessToken(_ context.Context, r fosite.Requester, token string) (err error) {
@@ -60,27 +66,27 @@ func (h HMACSHAStrategy) ValidateAccessToken(_ context.Context, r fosite.Request
 	if !exp.IsZero() && exp.Before(time.Now().UTC()) {
 		return errorsx.WithStack(fosite.ErrTokenExpired.WithHintf("Access token expired at '%s'.", exp))
 	}
-	return h.Enigma.Validate(token)
+	return h.Enigma.ValidateWithPrefix(h.AccessTokenPrefix, token)
 }
 
 func (h HMACSHAStrategy) GenerateRefreshToken(_ context.Context, _ fosite.Requester) (token string, signature string, err error) {
-	return h.Enigma.Generate()
+	return h.Enigma.GenerateWithPrefix(h.RefreshTokenPrefix)
 }
 
 func (h HMACSHAStrategy) ValidateRefreshToken(_ context.Context, r fosite.Requester, token string) (err error) {
 	var exp = r.GetSession().GetExpiresAt(fosite.RefreshToken)
 	if exp.IsZero() {
 		// Unlimited lifetime
-		return h.Enigma.Validate(token)
+		return h.Enigma.ValidateWithPrefix(h.RefreshTokenPrefix, token)
 	}
 	if !exp.IsZero() && exp.Before(time.Now().UTC()) {
 		return errorsx.WithStack(fosite.ErrTokenExpired.WithHintf("Refresh token expired at '%s'.", exp))
 	}
-	return h.Enigma.Validate(token)
+	return h.Enigma.ValidateWithPrefix(h.RefreshTokenPrefix, token)
 }
 
 func (h HMACSHAStrategy) GenerateAuthorizeCode(_ context.Context, _ fosite.Requester) (token string, signature string, err error) {
-	return h.Enigma.Generate()
+	return h.Enigma.GenerateWithPrefix(h.AuthorizeCodePrefix)
 }
 
 func (h HMACSHAStrategy) ValidateAuthorizeCode(_ context.Context, r fosite.Requester, token string) (err error) {
@@ -92,5 +98,5 @@ func (h HMACSHAStrategy) ValidateAuthorizeCode(_ context.Context, r fosite.Reque
 		return errorsx.WithStack(fosite.ErrTokenExpired.WithHintf("Authorize code expired at '%s'.", exp))
 	}
 
-	return h.Enigma.Validate(token)
+	return h.Enigma.ValidateWithPrefix(h.AuthorizeCodePrefix, token)
 }
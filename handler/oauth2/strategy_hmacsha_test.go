@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/token/hmac"
@@ -170,3 +171,72 @@ func TestHMACAuthorizeCode(t *testing.T) {
 		})
 	}
 }
+
+func TestHMACTokenPrefix(t *testing.T) {
+	strategy := HMACSHAStrategy{
+		Enigma:              &hmac.HMACStrategy{GlobalSecret: []byte("foobarfoobarfoobarfoobarfoobarfoobarfoobarfoobar")},
+		AccessTokenPrefix:   "ory_at_",
+		RefreshTokenPrefix:  "ory_rt_",
+		AuthorizeCodePrefix: "ory_ac_",
+	}
+
+	accessToken, accessSignature, err := strategy.GenerateAccessToken(nil, &hmacValidCase)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(accessToken, "ory_at_"))
+	assert.Equal(t, accessSignature, strategy.AccessTokenSignature(accessToken))
+	assert.NoError(t, strategy.ValidateAccessToken(nil, &hmacValidCase, accessToken))
+
+	refreshToken, refreshSignature, err := strategy.GenerateRefreshToken(nil, &hmacValidCase)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(refreshToken, "ory_rt_"))
+	assert.Equal(t, refreshSignature, strategy.RefreshTokenSignature(refreshToken))
+	assert.NoError(t, strategy.ValidateRefreshToken(nil, &hmacValidCase, refreshToken))
+
+	code, codeSignature, err := strategy.GenerateAuthorizeCode(nil, &hmacValidCase)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(code, "ory_ac_"))
+	assert.Equal(t, codeSignature, strategy.AuthorizeCodeSignature(code))
+	assert.NoError(t, strategy.ValidateAuthorizeCode(nil, &hmacValidCase, code))
+
+	// Tokens of one type must not be accepted as tokens of another type.
+	assert.Error(t, strategy.ValidateAccessToken(nil, &hmacValidCase, refreshToken))
+	assert.Error(t, strategy.ValidateRefreshToken(nil, &hmacValidCase, code))
+	assert.Error(t, strategy.ValidateAuthorizeCode(nil, &hmacValidCase, accessToken))
+}
+
+func TestHMACSecretRotation(t *testing.T) {
+	oldSecret := []byte("foobarfoobarfoobarfoobarfoobarfoobarfoobarfoobar")
+	newSecret := []byte("barfoobarfoobarfoobarfoobarfoobarfoobarfoobarfoo")
+
+	before := HMACSHAStrategy{
+		Enigma:                &hmac.HMACStrategy{GlobalSecret: oldSecret},
+		AccessTokenLifespan:   time.Hour,
+		RefreshTokenLifespan:  time.Hour,
+		AuthorizeCodeLifespan: time.Hour,
+	}
+	after := HMACSHAStrategy{
+		Enigma:                &hmac.HMACStrategy{GlobalSecret: newSecret, RotatedGlobalSecrets: [][]byte{oldSecret}},
+		AccessTokenLifespan:   time.Hour,
+		RefreshTokenLifespan:  time.Hour,
+		AuthorizeCodeLifespan: time.Hour,
+	}
+
+	accessToken, _, err := before.GenerateAccessToken(nil, &hmacValidCase)
+	require.NoError(t, err)
+	refreshToken, _, err := before.GenerateRefreshToken(nil, &hmacValidCase)
+	require.NoError(t, err)
+
+	// Tokens issued before the rotation remain valid.
+	assert.NoError(t, after.ValidateAccessToken(nil, &hmacValidCase, accessToken))
+	assert.NoError(t, after.ValidateRefreshToken(nil, &hmacValidCase, refreshToken))
+
+	// New tokens are signed with the new secret only.
+	rotatedToken, _, err := after.GenerateAccessToken(nil, &hmacValidCase)
+	require.NoError(t, err)
+	assert.NoError(t, after.ValidateAccessToken(nil, &hmacValidCase, rotatedToken))
+	assert.EqualError(t, before.ValidateAccessToken(nil, &hmacValidCase, rotatedToken), fosite.ErrTokenSignatureMismatch.Error())
+
+	// Tokens signed with secrets that have been dropped are rejected.
+	dropped := HMACSHAStrategy{Enigma: &hmac.HMACStrategy{GlobalSecret: newSecret}}
+	assert.EqualError(t, dropped.ValidateAccessToken(nil, &hmacValidCase, accessToken), fosite.ErrTokenSignatureMismatch.Error())
+}
@@ -40,6 +40,13 @@ type DefaultJWTStrategy struct {
 	HMACSHAStrategy *HMACSHAStrategy
 	Issuer          string
 	ScopeField      jwt.JWTScopeFieldEnum
+
+	// JWTProfile makes access tokens conform to the JWT profile for OAuth 2.0 access tokens as defined in
+	// https://datatracker.ietf.org/doc/html/rfc9068
+	JWTProfile bool
+
+	// ClockSkew is tolerated when validating the time based claims of access tokens. Defaults to zero.
+	ClockSkew time.Duration
 }
 
 func (h *DefaultJWTStrategy) WithIssuer(issuer string) *DefaultJWTStrategy {
@@ -52,6 +59,12 @@ func (h *DefaultJWTStrategy) WithScopeField(scopeField jwt.JWTScopeFieldEnum) *D
 	return h
 }
 
+// WithJWTProfile makes the strategy issue access tokens following https://datatracker.ietf.org/doc/html/rfc9068
+func (h *DefaultJWTStrategy) WithJWTProfile() *DefaultJWTStrategy {
+	h.JWTProfile = true
+	return h
+}
+
 func (h DefaultJWTStrategy) signature(token string) string {
 	split := strings.Split(token, ".")
 	if len(split) != 3 {
@@ -70,7 +83,7 @@ func (h *DefaultJWTStrategy) GenerateAccessToken(ctx context.Context, requester
 }
 
 func (h *DefaultJWTStrategy) ValidateAccessToken(ctx context.Context, _ fosite.Requester, token string) error {
-	_, err := validate(ctx, h.JWTStrategy, token)
+	_, err := validate(ctx, h.JWTStrategy, token, h.ClockSkew)
 	return err
 }
 
@@ -98,11 +111,20 @@ func (h *DefaultJWTStrategy) ValidateAuthorizeCode(ctx context.Context, req fosi
 	return h.HMACSHAStrategy.ValidateAuthorizeCode(ctx, req, token)
 }
 
-func validate(ctx context.Context, jwtStrategy jwt.JWTStrategy, token string) (t *jwt.Token, err error) {
+const timeClaimsValidationErrors = jwt.ValidationErrorExpired | jwt.ValidationErrorIssuedAt | jwt.ValidationErrorNotValidYet
+
+func validate(ctx context.Context, jwtStrategy jwt.JWTStrategy, token string, clockSkew time.Duration) (t *jwt.Token, err error) {
 	t, err = jwtStrategy.Decode(ctx, token)
 
+	// The strategy might have rejected the time based claims of an otherwise valid token without tolerating the clock
+	// skew, so they are validated again.
+	var ve *jwt.ValidationError
+	if err != nil && t != nil && errors.As(err, &ve) && ve.Errors&^timeClaimsValidationErrors == 0 {
+		err = nil
+	}
+
 	if err == nil {
-		err = t.Claims.Valid()
+		err = t.Claims.ValidWithClockSkew(clockSkew)
 	}
 
 	if err != nil {
@@ -144,6 +166,18 @@ func (h *DefaultJWTStrategy) generate(ctx context.Context, tokenType fosite.Toke
 	} else if jwtSession.GetJWTClaims() == nil {
 		return "", "", errors.New("GetTokenClaims() must not be nil")
 	} else {
+		scopeField := h.ScopeField
+		profile := h.JWTProfile && tokenType == fosite.AccessToken
+		if profile {
+			// The JWT profile requires the scope claim to be a space-delimited string.
+			switch scopeField {
+			case jwt.JWTScopeFieldUnset:
+				scopeField = jwt.JWTScopeFieldString
+			case jwt.JWTScopeFieldList:
+				scopeField = jwt.JWTScopeFieldBoth
+			}
+		}
+
 		claims := jwtSession.GetJWTClaims().
 			With(
 				jwtSession.GetExpiresAt(tokenType),
@@ -155,9 +189,52 @@ func (h *DefaultJWTStrategy) generate(ctx context.Context, tokenType fosite.Toke
 				h.Issuer,
 			).
 			WithScopeField(
-				h.ScopeField,
+				scopeField,
 			)
 
-		return h.JWTStrategy.Generate(ctx, claims.ToMapClaims(), jwtSession.GetJWTHeader())
+		if !profile {
+			return h.JWTStrategy.Generate(ctx, claims.ToMapClaims(), jwtSession.GetJWTHeader())
+		}
+
+		mapClaims := claims.ToMapClaims()
+		if _, ok := mapClaims["exp"]; !ok {
+			return "", "", errors.New("JWT profile access tokens must have an expiry")
+		}
+
+		clientID := requester.GetClient().GetID()
+		mapClaims["client_id"] = clientID
+		if sub, _ := mapClaims["sub"].(string); sub == "" {
+			// https://datatracker.ietf.org/doc/html/rfc9068#section-2.2
+			//  In cases of access tokens obtained through grants where no resource owner is involved, such as the client
+			//  credentials grant, the value of sub SHOULD correspond to an identifier the authorization server uses to
+			//  indicate the client application.
+			mapClaims["sub"] = clientID
+		}
+
+		return h.JWTStrategy.Generate(ctx, mapClaims, &jwtProfileHeader{Mapper: jwtSession.GetJWTHeader()})
+	}
+}
+
+// jwtProfileHeader sets the typ header of JWT profile access tokens, which can not be set using jwt.Headers.
+type jwtProfileHeader struct {
+	jwt.Mapper
+}
+
+func (h *jwtProfileHeader) ToMap() map[string]interface{} {
+	var header map[string]interface{}
+	if h.Mapper != nil {
+		header = h.Mapper.ToMap()
 	}
+	if header == nil {
+		header = map[string]interface{}{}
+	}
+	header[string(jwt.JWTHeaderType)] = JWTProfileAccessTokenType
+	return header
+}
+
+// isJWTProfileAccessToken returns true if the typ header identifies a JWT profile access token. The "application/"
+// prefix is optional as per https://datatracker.ietf.org/doc/html/rfc9068#section-4
+func isJWTProfileAccessToken(header map[string]interface{}) bool {
+	typ, _ := header[string(jwt.JWTHeaderType)].(string)
+	return strings.TrimPrefix(strings.ToLower(typ), "application/") == JWTProfileAccessTokenType
 }
@@ -47,6 +47,9 @@ type JWTSession struct {
 	ExpiresAt map[fosite.TokenType]time.Time
 	Username  string
 	Subject   string
+
+	GrantedAt         time.Time
+	RefreshChainDepth int
 }
 
 func (j *JWTSession) GetJWTClaims() jwt.JWTClaimsContainer {
@@ -100,6 +103,22 @@ func (j *JWTSession) GetSubject() string {
 	return j.Subject
 }
 
+func (j *JWTSession) GetGrantedAt() time.Time {
+	return j.GrantedAt
+}
+
+func (j *JWTSession) SetGrantedAt(t time.Time) {
+	j.GrantedAt = t
+}
+
+func (j *JWTSession) GetRefreshChainDepth() int {
+	return j.RefreshChainDepth
+}
+
+func (j *JWTSession) SetRefreshChainDepth(depth int) {
+	j.RefreshChainDepth = depth
+}
+
 func (j *JWTSession) Clone() fosite.Session {
 	if j == nil {
 		return nil
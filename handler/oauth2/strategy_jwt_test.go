@@ -242,3 +242,97 @@ func TestAccessToken(t *testing.T) {
 		}
 	}
 }
+
+func TestAccessTokenJWTProfile(t *testing.T) {
+	p := (&DefaultJWTStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{
+			PrivateKey: internal.MustRSAKey(),
+		},
+	}).WithIssuer("https://auth.example.com").WithJWTProfile()
+
+	for k, c := range []struct {
+		d       string
+		r       func() *fosite.Request
+		pass    bool
+		checkFn func(t *testing.T, claims map[string]interface{})
+	}{
+		{
+			d: "should carry the claims required by the profile",
+			r: func() *fosite.Request {
+				r := jwtValidCase(fosite.AccessToken)
+				r.Client = &fosite.DefaultClient{ID: "my-client"}
+				r.Session.(*JWTSession).JWTClaims.AuthTime = time.Now().UTC().Add(-time.Minute)
+				return r
+			},
+			pass: true,
+			checkFn: func(t *testing.T, claims map[string]interface{}) {
+				assert.Equal(t, "peter", claims["sub"])
+				assert.Equal(t, "fosite", claims["iss"])
+				assert.Equal(t, "my-client", claims["client_id"])
+				assert.Equal(t, "email offline", claims["scope"])
+				assert.Equal(t, []interface{}{"group0"}, claims["aud"])
+				assert.Equal(t, "bar", claims["foo"])
+				for _, claim := range []string{"exp", "iat", "jti", "auth_time"} {
+					assert.Contains(t, claims, claim)
+				}
+				assert.NotContains(t, claims, "scp")
+			},
+		},
+		{
+			d: "should use the client as subject if there is no resource owner",
+			r: func() *fosite.Request {
+				r := jwtValidCase(fosite.AccessToken)
+				r.Client = &fosite.DefaultClient{ID: "my-client"}
+				r.Session.(*JWTSession).JWTClaims.Subject = ""
+				r.Session.(*JWTSession).JWTClaims.Issuer = ""
+				return r
+			},
+			pass: true,
+			checkFn: func(t *testing.T, claims map[string]interface{}) {
+				assert.Equal(t, "my-client", claims["sub"])
+				assert.Equal(t, "https://auth.example.com", claims["iss"])
+				assert.NotContains(t, claims, "auth_time")
+			},
+		},
+		{
+			d: "should fail because the token does not expire",
+			r: func() *fosite.Request {
+				r := jwtValidCase(fosite.AccessToken)
+				r.Session.(*JWTSession).ExpiresAt = nil
+				return r
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.d), func(t *testing.T) {
+			token, _, err := p.GenerateAccessToken(nil, c.r())
+			if !c.pass {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			parts := strings.Split(token, ".")
+			require.Len(t, parts, 3)
+
+			rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+			require.NoError(t, err)
+			var header map[string]interface{}
+			require.NoError(t, json.Unmarshal(rawHeader, &header))
+			assert.Equal(t, "at+jwt", header["typ"])
+
+			rawPayload, err := base64.RawURLEncoding.DecodeString(parts[1])
+			require.NoError(t, err)
+			var payload map[string]interface{}
+			require.NoError(t, json.Unmarshal(rawPayload, &payload))
+			c.checkFn(t, payload)
+		})
+	}
+
+	t.Run("case=strategies without the profile keep the JWT type", func(t *testing.T) {
+		token, _, err := j.GenerateAccessToken(nil, jwtValidCase(fosite.AccessToken))
+		require.NoError(t, err)
+		rawHeader, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(rawHeader), `"typ":"JWT"`)
+	})
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+)
+
+// JWTProfileAccessTokenType is the value of the typ header of JWT access tokens as defined in
+// https://datatracker.ietf.org/doc/html/rfc9068#section-2.1
+const JWTProfileAccessTokenType = "at+jwt"
+
+// JWTProfileAccessTokenValidator can be used by resource servers to validate JWT access tokens following
+// https://datatracker.ietf.org/doc/html/rfc9068#section-4
+type JWTProfileAccessTokenValidator struct {
+	// JWTStrategy verifies the signature of the access tokens.
+	JWTStrategy jwt.JWTStrategy
+
+	// Issuer is the expected value of the iss claim.
+	Issuer string
+
+	// ExpectedAudience is the identifier of the resource server, which must be contained in the aud claim.
+	ExpectedAudience string
+}
+
+// NewJWTProfileValidator returns a validator for JWT access tokens which are intended for the given audience. The
+// JWT strategy and issuer must be set using WithJWTStrategy and WithIssuer.
+func NewJWTProfileValidator(expectedAudience string) *JWTProfileAccessTokenValidator {
+	return &JWTProfileAccessTokenValidator{ExpectedAudience: expectedAudience}
+}
+
+func (v *JWTProfileAccessTokenValidator) WithJWTStrategy(strategy jwt.JWTStrategy) *JWTProfileAccessTokenValidator {
+	v.JWTStrategy = strategy
+	return v
+}
+
+func (v *JWTProfileAccessTokenValidator) WithIssuer(issuer string) *JWTProfileAccessTokenValidator {
+	v.Issuer = issuer
+	return v
+}
+
+// Validate validates the JWT access token and returns its claims. If the token is not valid, fosite.ErrInvalidToken
+// is returned.
+func (v *JWTProfileAccessTokenValidator) Validate(ctx context.Context, token string) (*jwt.JWTClaims, error) {
+	if v.JWTStrategy == nil || v.Issuer == "" || v.ExpectedAudience == "" {
+		return nil, errorsx.WithStack(fosite.ErrMisconfiguration.WithDebug("The JWT access token validator requires a JWT strategy, an issuer and an expected audience."))
+	}
+
+	t, err := v.JWTStrategy.Decode(ctx, token)
+	if err != nil {
+		return nil, errorsx.WithStack(fosite.ErrInvalidToken.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if !isJWTProfileAccessToken(t.Header) {
+		return nil, errorsx.WithStack(fosite.ErrInvalidToken.WithHintf("The token type header must be '%s' but got '%v'.", JWTProfileAccessTokenType, t.Header[string(jwt.JWTHeaderType)]))
+	} else if !t.Claims.VerifyExpiresAt(time.Now().UTC().Unix(), true) {
+		return nil, errorsx.WithStack(fosite.ErrInvalidToken.WithHint("The token is expired or does not specify an expiry."))
+	} else if !t.Claims.VerifyIssuer(v.Issuer, true) {
+		return nil, errorsx.WithStack(fosite.ErrInvalidToken.WithHint("The token was not issued by the expected issuer."))
+	} else if !t.Claims.VerifyAudience(v.ExpectedAudience, true) {
+		return nil, errorsx.WithStack(fosite.ErrInvalidToken.WithHintf("The token is not intended for audience '%s'.", v.ExpectedAudience))
+	}
+
+	claims := new(jwt.JWTClaims)
+	claims.FromMapClaims(t.Claims)
+	return claims, nil
+}
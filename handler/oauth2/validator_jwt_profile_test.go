@@ -0,0 +1,150 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/token/jwt"
+)
+
+// typHeader sets the typ header, which jwt.Headers does not allow to override.
+type typHeader string
+
+func (h typHeader) ToMap() map[string]interface{}     { return map[string]interface{}{"typ": string(h)} }
+func (h typHeader) Add(key string, value interface{}) {}
+func (h typHeader) Get(key string) interface{}        { return h.ToMap()[key] }
+
+func TestJWTProfileAccessTokenValidator(t *testing.T) {
+	signer := &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()}
+	v := NewJWTProfileValidator("https://api.example.com").
+		WithJWTStrategy(signer).
+		WithIssuer("https://auth.example.com")
+
+	generate := func(t *testing.T, typ string, claims jwt.MapClaims) string {
+		token, _, err := signer.Generate(context.TODO(), claims, typHeader(typ))
+		require.NoError(t, err)
+		return token
+	}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": "https://auth.example.com",
+			"sub": "peter",
+			"aud": []string{"https://other.example.com", "https://api.example.com"},
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	for k, c := range []struct {
+		description string
+		token       func(t *testing.T) string
+		expectErr   bool
+	}{
+		{
+			description: "should pass",
+			token: func(t *testing.T) string {
+				return generate(t, "at+jwt", validClaims())
+			},
+		},
+		{
+			description: "should pass with the media type",
+			token: func(t *testing.T) string {
+				return generate(t, "application/at+JWT", validClaims())
+			},
+		},
+		{
+			description: "should fail because of the wrong type",
+			token: func(t *testing.T) string {
+				return generate(t, "JWT", validClaims())
+			},
+			expectErr: true,
+		},
+		{
+			description: "should fail because of the wrong audience",
+			token: func(t *testing.T) string {
+				claims := validClaims()
+				claims["aud"] = "https://other.example.com"
+				return generate(t, "at+jwt", claims)
+			},
+			expectErr: true,
+		},
+		{
+			description: "should fail because of the wrong issuer",
+			token: func(t *testing.T) string {
+				claims := validClaims()
+				claims["iss"] = "https://evil.example.com"
+				return generate(t, "at+jwt", claims)
+			},
+			expectErr: true,
+		},
+		{
+			description: "should fail because the token is expired",
+			token: func(t *testing.T) string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+				return generate(t, "at+jwt", claims)
+			},
+			expectErr: true,
+		},
+		{
+			description: "should fail because the token does not expire",
+			token: func(t *testing.T) string {
+				claims := validClaims()
+				delete(claims, "exp")
+				return generate(t, "at+jwt", claims)
+			},
+			expectErr: true,
+		},
+		{
+			description: "should fail because the token was signed with another key",
+			token: func(t *testing.T) string {
+				token, _, err := (&jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()}).Generate(context.TODO(), validClaims(), typHeader("at+jwt"))
+				require.NoError(t, err)
+				return token
+			},
+			expectErr: true,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			claims, err := v.Validate(context.TODO(), c.token(t))
+			if c.expectErr {
+				require.Error(t, err)
+				assert.Equal(t, fosite.ErrInvalidToken.ErrorField, fosite.ErrorToRFC6749Error(err).ErrorField)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "peter", claims.Subject)
+		})
+	}
+
+	_, err := NewJWTProfileValidator("https://api.example.com").Validate(context.TODO(), "foo")
+	require.Error(t, err)
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/go-convenience/stringslice"
+
+	"github.com/ory/fosite"
+)
+
+// ACRStrategy decides whether the authentication context class reference (acr) the end-user was authenticated with
+// satisfies the acr values requested using the acr_values parameter or an essential acr claim. See
+// https://openid.net/specs/openid-connect-core-1_0.html#acrSemantics
+type ACRStrategy interface {
+	// IsACRSatisfied returns true if achieved satisfies at least one of the requested acr values.
+	IsACRSatisfied(ctx context.Context, requester fosite.Requester, achieved string, requested []string) bool
+}
+
+// ExactACRStrategy is satisfied if the achieved acr is one of the requested acr values.
+type ExactACRStrategy struct{}
+
+func (s *ExactACRStrategy) IsACRSatisfied(ctx context.Context, requester fosite.Requester, achieved string, requested []string) bool {
+	return stringslice.Has(requested, achieved)
+}
+
+// OrderedACRStrategy ranks acr values from the weakest to the strongest authentication context class in Levels. It is
+// satisfied if the achieved acr is one of the requested acr values, or is ranked at least as strong as one of them.
+type OrderedACRStrategy struct {
+	Levels []string
+}
+
+func (s *OrderedACRStrategy) IsACRSatisfied(ctx context.Context, requester fosite.Requester, achieved string, requested []string) bool {
+	if stringslice.Has(requested, achieved) {
+		return true
+	}
+
+	level := s.level(achieved)
+	if level < 0 {
+		return false
+	}
+
+	for _, r := range requested {
+		if l := s.level(r); l >= 0 && l <= level {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *OrderedACRStrategy) level(acr string) int {
+	for i, l := range s.Levels {
+		if l == acr {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateACR returns login_required wrapping ErrStepUpRequired if the acr of the session does not satisfy the acr
+// values requested using the acr_values parameter or an essential acr claim, asking for step-up authentication.
+func validateACR(ctx context.Context, strategy ACRStrategy, requester fosite.Requester, acr string) error {
+	claimsRequest, err := GetClaimsRequest(requester)
+	if err != nil {
+		return err
+	}
+
+	for _, values := range [][]string{
+		claimsRequest.EssentialACRValues(),
+		fosite.RemoveEmpty(strings.Split(requester.GetRequestForm().Get("acr_values"), " ")),
+	} {
+		if len(values) > 0 && !strategy.IsACRSatisfied(ctx, requester, acr, values) {
+			return errorsx.WithStack(fosite.ErrLoginRequired.
+				WithHintf("The end-user must be authenticated using one of the authentication context class references %v.", values).
+				WithWrap(ErrStepUpRequired).
+				WithDebugf("The authentication context class reference '%s' of the session does not satisfy the requested values.", acr))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ory/fosite"
+)
+
+func TestACRStrategy(t *testing.T) {
+	ordered := &OrderedACRStrategy{Levels: []string{"bronze", "silver", "gold"}}
+	for k, tc := range []struct {
+		strategy  ACRStrategy
+		achieved  string
+		requested []string
+		expect    bool
+	}{
+		{strategy: &ExactACRStrategy{}, achieved: "gold", requested: []string{"silver", "gold"}, expect: true},
+		{strategy: &ExactACRStrategy{}, achieved: "gold", requested: []string{"silver"}, expect: false},
+		{strategy: &ExactACRStrategy{}, achieved: "", requested: []string{"silver"}, expect: false},
+		{strategy: ordered, achieved: "gold", requested: []string{"silver"}, expect: true},
+		{strategy: ordered, achieved: "silver", requested: []string{"silver"}, expect: true},
+		{strategy: ordered, achieved: "bronze", requested: []string{"silver", "gold"}, expect: false},
+		{strategy: ordered, achieved: "platinum", requested: []string{"bronze"}, expect: false},
+		{strategy: ordered, achieved: "gold", requested: []string{"platinum"}, expect: false},
+		{strategy: ordered, achieved: "platinum", requested: []string{"platinum"}, expect: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, tc.expect, tc.strategy.IsACRSatisfied(context.Background(), fosite.NewRequest(), tc.achieved, tc.requested))
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+)
+
+// ClaimsRequestTarget is a member of the claims request parameter, which defines where the requested claims are
+// returned.
+type ClaimsRequestTarget string
+
+const (
+	ClaimsRequestTargetIDToken  ClaimsRequestTarget = "id_token"
+	ClaimsRequestTargetUserInfo ClaimsRequestTarget = "userinfo"
+)
+
+// ClaimsRequest is the claims request parameter as defined in
+// https://openid.net/specs/openid-connect-core-1_0.html#ClaimsParameter
+type ClaimsRequest struct {
+	IDToken  map[string]*ClaimRequest `json:"id_token,omitempty"`
+	UserInfo map[string]*ClaimRequest `json:"userinfo,omitempty"`
+}
+
+// ClaimRequest holds the constraints of an individual claim. Claims which are requested in the default manner ("null")
+// are represented by a nil ClaimRequest.
+type ClaimRequest struct {
+	Essential bool          `json:"essential,omitempty"`
+	Value     interface{}   `json:"value,omitempty"`
+	Values    []interface{} `json:"values,omitempty"`
+}
+
+// ClaimsRequestStrategy decides which of the requested claims are honored.
+type ClaimsRequestStrategy interface {
+	// HandleClaimsRequest returns the values of the requested claims which should be added to the id token or the
+	// userinfo response, depending on target. Claims which are not returned are omitted.
+	HandleClaimsRequest(ctx context.Context, requester fosite.Requester, target ClaimsRequestTarget, claims map[string]*ClaimRequest) (map[string]interface{}, error)
+}
+
+// ParseClaimsRequest parses the JSON encoded claims request parameter.
+func ParseClaimsRequest(raw string) (*ClaimsRequest, error) {
+	var claims ClaimsRequest
+	if err := json.Unmarshal([]byte(raw), &claims); err != nil {
+		return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Unable to parse the 'claims' parameter because it is not a valid JSON object.").WithWrap(err).WithDebug(err.Error()))
+	}
+	return &claims, nil
+}
+
+// GetClaimsRequest returns the claims request parameter of the request, or nil if the parameter is not set.
+func GetClaimsRequest(requester fosite.Requester) (*ClaimsRequest, error) {
+	raw := requester.GetRequestForm().Get("claims")
+	if raw == "" {
+		return nil, nil
+	}
+	return ParseClaimsRequest(raw)
+}
+
+// Get returns the requested claims of the target.
+func (c *ClaimsRequest) Get(target ClaimsRequestTarget) map[string]*ClaimRequest {
+	if c == nil {
+		return nil
+	}
+
+	switch target {
+	case ClaimsRequestTargetIDToken:
+		return c.IDToken
+	case ClaimsRequestTargetUserInfo:
+		return c.UserInfo
+	}
+	return nil
+}
+
+// GetUserInfoAuthenticationClaims returns the acr and amr claims of the session which were requested for the userinfo
+// response using the claims parameter. Claims the session does not have a value for are omitted.
+func GetUserInfoAuthenticationClaims(requester fosite.Requester) (map[string]interface{}, error) {
+	claimsRequest, err := GetClaimsRequest(requester)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, ok := requester.GetSession().(Session)
+	if !ok {
+		return nil, errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to get the authentication claims because session must be of type fosite/handler/openid.Session."))
+	}
+
+	requested := claimsRequest.Get(ClaimsRequestTargetUserInfo)
+	claims := map[string]interface{}{}
+	if _, ok := requested["acr"]; ok && sess.GetACR() != "" {
+		claims["acr"] = sess.GetACR()
+	}
+	if _, ok := requested["amr"]; ok && len(sess.GetAMR()) > 0 {
+		claims["amr"] = sess.GetAMR()
+	}
+	return claims, nil
+}
+
+// EssentialACRValues returns the acr values requested as an essential claim of the id token. If the end-user was
+// authenticated using another authentication context class, the authentication has to be stepped up.
+func (c *ClaimsRequest) EssentialACRValues() []string {
+	acr := c.Get(ClaimsRequestTargetIDToken)["acr"]
+	if acr == nil || !acr.Essential {
+		return nil
+	}
+
+	var values []string
+	for _, v := range acr.allowedValues() {
+		values = append(values, fmt.Sprintf("%v", v))
+	}
+	return values
+}
+
+func (c *ClaimRequest) allowedValues() []interface{} {
+	if c.Value != nil {
+		return []interface{}{c.Value}
+	}
+	return c.Values
+}
+
+// IsEssential returns true if the claim was requested as an essential claim.
+func (c *ClaimRequest) IsEssential() bool {
+	return c != nil && c.Essential
+}
+
+// Matches returns true if the value satisfies the value and values constraints of the claim.
+func (c *ClaimRequest) Matches(value interface{}) bool {
+	if c == nil {
+		return true
+	}
+
+	allowed := c.allowedValues()
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, v := range allowed {
+		if fmt.Sprintf("%v", v) == fmt.Sprintf("%v", value) {
+			return true
+		}
+	}
+	return false
+}
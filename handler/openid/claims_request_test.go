@@ -0,0 +1,134 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+)
+
+func TestGetClaimsRequest(t *testing.T) {
+	for k, c := range []struct {
+		claims    string
+		expectErr bool
+		check     func(t *testing.T, cr *ClaimsRequest)
+	}{
+		{
+			check: func(t *testing.T, cr *ClaimsRequest) {
+				assert.Nil(t, cr)
+				assert.Nil(t, cr.Get(ClaimsRequestTargetIDToken))
+				assert.Empty(t, cr.EssentialACRValues())
+			},
+		},
+		{
+			claims:    "not-json",
+			expectErr: true,
+		},
+		{
+			claims: `{"userinfo":{"given_name":{"essential":true},"nickname":null},"id_token":{"auth_time":{"essential":true},"acr":{"values":["urn:mace:incommon:iap:silver"]}}}`,
+			check: func(t *testing.T, cr *ClaimsRequest) {
+				require.NotNil(t, cr)
+				assert.Len(t, cr.Get(ClaimsRequestTargetUserInfo), 2)
+				assert.True(t, cr.Get(ClaimsRequestTargetUserInfo)["given_name"].IsEssential())
+				assert.False(t, cr.Get(ClaimsRequestTargetUserInfo)["nickname"].IsEssential())
+				assert.True(t, cr.Get(ClaimsRequestTargetIDToken)["auth_time"].IsEssential())
+				// acr is not essential, so no step-up is required.
+				assert.Empty(t, cr.EssentialACRValues())
+			},
+		},
+		{
+			claims: `{"id_token":{"acr":{"essential":true,"value":"urn:mace:incommon:iap:silver"}}}`,
+			check: func(t *testing.T, cr *ClaimsRequest) {
+				assert.Equal(t, []string{"urn:mace:incommon:iap:silver"}, cr.EssentialACRValues())
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			r := &fosite.Request{Form: url.Values{"claims": {c.claims}}}
+			cr, err := GetClaimsRequest(r)
+			if c.expectErr {
+				require.Error(t, err)
+				assert.EqualError(t, err, fosite.ErrInvalidRequest.Error())
+				return
+			}
+			require.NoError(t, err)
+			c.check(t, cr)
+		})
+	}
+}
+
+func TestGetUserInfoAuthenticationClaims(t *testing.T) {
+	sess := NewDefaultSession()
+	sess.SetACR("urn:mace:incommon:iap:silver")
+	sess.SetAMR([]string{"pwd", "otp"})
+
+	for k, c := range []struct {
+		claims    string
+		expect    map[string]interface{}
+		expectErr bool
+	}{
+		{claims: "", expect: map[string]interface{}{}},
+		{claims: `{"id_token":{"acr":null,"amr":null}}`, expect: map[string]interface{}{}},
+		{claims: `{"userinfo":{"amr":null}}`, expect: map[string]interface{}{"amr": []string{"pwd", "otp"}}},
+		{claims: `{"userinfo":{"acr":{"essential":true},"amr":null}}`, expect: map[string]interface{}{"acr": "urn:mace:incommon:iap:silver", "amr": []string{"pwd", "otp"}}},
+		{claims: `{"userinfo":`, expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			req := fosite.NewAccessRequest(sess)
+			req.Form = url.Values{"claims": {c.claims}}
+
+			claims, err := GetUserInfoAuthenticationClaims(req)
+			if c.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expect, claims)
+		})
+	}
+}
+
+func TestClaimRequest_Matches(t *testing.T) {
+	for k, c := range []struct {
+		claim  *ClaimRequest
+		value  interface{}
+		expect bool
+	}{
+		{claim: nil, value: "foo", expect: true},
+		{claim: &ClaimRequest{Essential: true}, value: "foo", expect: true},
+		{claim: &ClaimRequest{Value: "foo"}, value: "foo", expect: true},
+		{claim: &ClaimRequest{Value: "foo"}, value: "bar", expect: false},
+		{claim: &ClaimRequest{Values: []interface{}{"foo", "bar"}}, value: "bar", expect: true},
+		{claim: &ClaimRequest{Values: []interface{}{"foo", "bar"}}, value: "baz", expect: false},
+		{claim: &ClaimRequest{Value: float64(1)}, value: 1, expect: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, c.expect, c.claim.Matches(c.value))
+		})
+	}
+}
@@ -25,4 +25,14 @@ import "github.com/pkg/errors"
 
 var (
 	ErrInvalidSession = errors.New("Session type mismatch")
+
+	// ErrMaxAgeExceeded is wrapped by fosite.ErrLoginRequired if the end-user authenticated longer ago than allowed by
+	// max_age or the client's default_max_age. Use errors.Is to tell it apart from other login_required errors and
+	// re-authenticate the end-user.
+	ErrMaxAgeExceeded = errors.New("The end-user authentication is older than the maximum authentication age")
+
+	// ErrStepUpRequired is wrapped by fosite.ErrLoginRequired if the authentication context class reference of the
+	// session does not satisfy the requested acr values. Use errors.Is to tell it apart from other login_required
+	// errors and step up the authentication of the end-user.
+	ErrStepUpRequired = errors.New("The end-user must be authenticated using a stronger authentication context class")
 )
@@ -0,0 +1,454 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/mohae/deepcopy"
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/jwt"
+)
+
+const grantTypeCIBA = "urn:openid:params:grant-type:ciba"
+
+// cibaSlowDownIncrement is added to the polling interval of an auth_req_id each time the client polls too fast.
+const cibaSlowDownIncrement = 5 * time.Second
+
+// BackchannelAuthRequestStatus is the state of a backchannel authentication request as decided by the end-user.
+type BackchannelAuthRequestStatus string
+
+const (
+	BackchannelAuthRequestStatusPending  BackchannelAuthRequestStatus = "pending"
+	BackchannelAuthRequestStatusApproved BackchannelAuthRequestStatus = "approved"
+	BackchannelAuthRequestStatusDenied   BackchannelAuthRequestStatus = "denied"
+)
+
+// BackchannelAuthRequestSession must be implemented by the session if OpenID Connect Client-Initiated Backchannel
+// Authentication is to be supported. It keeps track of whether the end-user has approved the request and when the
+// client last polled the token endpoint.
+type BackchannelAuthRequestSession interface {
+	Session
+
+	// GetBackchannelAuthRequestStatus returns the status of the backchannel authentication request. An empty status
+	// is treated as pending.
+	GetBackchannelAuthRequestStatus() BackchannelAuthRequestStatus
+
+	// SetBackchannelAuthRequestStatus sets the status of the backchannel authentication request.
+	SetBackchannelAuthRequestStatus(status BackchannelAuthRequestStatus)
+
+	// GetLastPolledAt returns the time the client last polled the token endpoint using the request ID.
+	GetLastPolledAt() time.Time
+
+	// SetLastPolledAt sets the time the client last polled the token endpoint using the request ID.
+	SetLastPolledAt(t time.Time)
+
+	// GetPollingInterval returns the polling interval enforced for the request ID, which grows each time the client
+	// polls too fast. Zero means the interval of the handler applies.
+	GetPollingInterval() time.Duration
+
+	// SetPollingInterval sets the polling interval enforced for the request ID.
+	SetPollingInterval(interval time.Duration)
+}
+
+// DefaultBackchannelAuthRequestSession is a default implementation of the BackchannelAuthRequestSession interface.
+type DefaultBackchannelAuthRequestSession struct {
+	DefaultSession
+	BackchannelAuthRequestStatus BackchannelAuthRequestStatus `json:"backchannel_auth_request_status"`
+	LastPolledAt                 time.Time                    `json:"last_polled_at"`
+	PollingInterval              time.Duration                `json:"polling_interval,omitempty"`
+}
+
+func (s *DefaultBackchannelAuthRequestSession) GetBackchannelAuthRequestStatus() BackchannelAuthRequestStatus {
+	if s.BackchannelAuthRequestStatus == "" {
+		return BackchannelAuthRequestStatusPending
+	}
+	return s.BackchannelAuthRequestStatus
+}
+
+func (s *DefaultBackchannelAuthRequestSession) SetBackchannelAuthRequestStatus(status BackchannelAuthRequestStatus) {
+	s.BackchannelAuthRequestStatus = status
+}
+
+func (s *DefaultBackchannelAuthRequestSession) GetLastPolledAt() time.Time {
+	return s.LastPolledAt
+}
+
+func (s *DefaultBackchannelAuthRequestSession) SetLastPolledAt(t time.Time) {
+	s.LastPolledAt = t
+}
+
+func (s *DefaultBackchannelAuthRequestSession) GetPollingInterval() time.Duration {
+	return s.PollingInterval
+}
+
+func (s *DefaultBackchannelAuthRequestSession) SetPollingInterval(interval time.Duration) {
+	s.PollingInterval = interval
+}
+
+func (s *DefaultBackchannelAuthRequestSession) Clone() fosite.Session {
+	if s == nil {
+		return nil
+	}
+
+	return deepcopy.Copy(s).(fosite.Session)
+}
+
+var cibaParameters = []string{
+	"acr_values",
+	"binding_message",
+	"claims",
+	"id_token_hint",
+	"login_hint",
+	"login_hint_token",
+	"requested_expiry",
+	"user_code",
+}
+
+// OpenIDConnectCIBAHandler implements the poll mode of OpenID Connect Client-Initiated Backchannel Authentication as
+// defined in https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html
+//
+// The end-user is authenticated out-of-band by the authorization server. Once this happened, the stored request must
+// be updated with the end-user's claims, the granted scopes and the approved status using CIBAStorage.
+type OpenIDConnectCIBAHandler struct {
+	Storage              CIBACoreStorage
+	AuthReqIDStrategy    AuthReqIDStrategy
+	AccessTokenStrategy  oauth2.AccessTokenStrategy
+	RefreshTokenStrategy oauth2.RefreshTokenStrategy
+
+	// IDTokenHintStrategy decodes the id_token_hint parameter.
+	IDTokenHintStrategy jwt.JWTStrategy
+
+	// IDTokenHintIssuer, if set, must be the "iss" claim of the id_token_hint.
+	IDTokenHintIssuer string
+
+	*IDTokenHandleHelper
+
+	// AuthReqIDLifespan defines how long request IDs are valid. Clients may ask for a shorter lifespan using the
+	// requested_expiry parameter.
+	AuthReqIDLifespan time.Duration
+
+	// PollingInterval is returned as "interval" by the backchannel authentication endpoint. Token requests for an
+	// auth_req_id sent before it elapsed fail with "slow_down", which adds five seconds to the interval enforced for
+	// that auth_req_id.
+	PollingInterval time.Duration
+
+	AccessTokenLifespan  time.Duration
+	RefreshTokenLifespan time.Duration
+
+	RefreshTokenScopes []string
+
+	// IssuanceHooks are notified about the issued tokens once they have been persisted.
+	IssuanceHooks *oauth2.TokenIssuanceHooks
+}
+
+// HandleBackchannelAuthenticationEndpointRequest implements
+// https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#auth_request
+func (c *OpenIDConnectCIBAHandler) HandleBackchannelAuthenticationEndpointRequest(ctx context.Context, request fosite.BackchannelAuthenticationRequester, resp fosite.BackchannelAuthenticationResponder) error {
+	if err := fosite.ValidateClientGrantType(request.GetClient(), grantTypeCIBA); err != nil {
+		return err
+	}
+
+	if !request.GetRequestedScopes().Has("openid") {
+		return errorsx.WithStack(fosite.ErrInvalidScope.WithHint("Backchannel authentication requests must contain the 'openid' scope."))
+	}
+
+	if hint := request.GetRequestForm().Get("id_token_hint"); hint != "" {
+		if err := c.validateIDTokenHint(ctx, hint); err != nil {
+			return err
+		}
+	}
+
+	session, ok := request.GetSession().(BackchannelAuthRequestSession)
+	if !ok {
+		return errorsx.WithStack(fosite.ErrServerError.WithHint("Session must be of type openid.BackchannelAuthRequestSession."))
+	}
+
+	lifespan := c.AuthReqIDLifespan
+	if requestedExpiry, err := strconv.ParseInt(request.GetRequestForm().Get("requested_expiry"), 10, 64); err == nil && requestedExpiry > 0 {
+		if requested := time.Duration(requestedExpiry) * time.Second; requested < lifespan {
+			lifespan = requested
+		}
+	}
+
+	id, signature, err := c.AuthReqIDStrategy.GenerateAuthReqID(ctx, request)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	expiresAt := time.Now().UTC().Add(lifespan).Round(time.Second)
+	session.SetExpiresAt(fosite.AuthReqID, expiresAt)
+	session.SetBackchannelAuthRequestStatus(BackchannelAuthRequestStatusPending)
+
+	if err := c.Storage.CreateBackchannelAuthRequestSession(ctx, signature, request.Sanitize(cibaParameters)); err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	resp.SetAuthReqID(id)
+	resp.SetExpiresIn(time.Duration(expiresAt.UnixNano() - time.Now().UTC().UnixNano()))
+	resp.SetInterval(c.PollingInterval)
+	return nil
+}
+
+func (c *OpenIDConnectCIBAHandler) validateIDTokenHint(ctx context.Context, hint string) error {
+	if c.IDTokenHintStrategy == nil {
+		return errorsx.WithStack(fosite.ErrMisconfiguration.WithDebug("The authorization server is unable to decode the id_token_hint because no IDTokenHintStrategy is configured."))
+	}
+
+	token, err := c.IDTokenHintStrategy.Decode(ctx, hint)
+	var ve *jwt.ValidationError
+	if errors.As(err, &ve) && ve.Errors == jwt.ValidationErrorExpired {
+		// Expired ID Tokens are allowed as values to id_token_hint
+	} else if err != nil {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Unable to decode the id token from the 'id_token_hint' parameter.").WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if sub, _ := token.Claims["sub"].(string); sub == "" {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The id token from the 'id_token_hint' parameter does not have a subject."))
+	} else if c.IDTokenHintIssuer != "" && !token.Claims.VerifyIssuer(c.IDTokenHintIssuer, true) {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The id token from the 'id_token_hint' parameter was not issued by this authorization server."))
+	}
+	return nil
+}
+
+// HandleTokenEndpointRequest implements
+// https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#token_request
+func (c *OpenIDConnectCIBAHandler) HandleTokenEndpointRequest(ctx context.Context, request fosite.AccessRequester) error {
+	if !c.CanHandleTokenEndpointRequest(request) {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	if err := fosite.ValidateClientGrantType(request.GetClient(), grantTypeCIBA); err != nil {
+		return err
+	}
+
+	id := request.GetRequestForm().Get("auth_req_id")
+	if id == "" {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The \"auth_req_id\" parameter is missing."))
+	}
+
+	signature := c.AuthReqIDStrategy.AuthReqIDSignature(id)
+	cibaRequest, err := c.Storage.GetBackchannelAuthRequestSession(ctx, signature, request.GetSession())
+	if errors.Is(err, fosite.ErrInvalidatedAuthReqID) {
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The auth_req_id has already been used."))
+	} else if err != nil && errors.Is(err, fosite.ErrNotFound) {
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithWrap(err).WithDebug(err.Error()))
+	} else if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	// This needs to happen after store retrieval for the session to be hydrated properly
+	if err := c.AuthReqIDStrategy.ValidateAuthReqID(ctx, cibaRequest, id); err != nil {
+		if errors.Is(err, fosite.ErrExpiredToken) {
+			return err
+		}
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if fosite.GetClientIdentifier(cibaRequest) != fosite.GetClientIdentifier(request) {
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client ID from this request does not match the one from the backchannel authentication request."))
+	}
+
+	session, ok := cibaRequest.GetSession().(BackchannelAuthRequestSession)
+	if !ok {
+		return errorsx.WithStack(fosite.ErrServerError.WithHint("Session must be of type openid.BackchannelAuthRequestSession."))
+	}
+
+	// Clients receiving "slow_down" must increase their interval by five seconds, see
+	// https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#token_error_response
+	now := time.Now().UTC()
+	interval := session.GetPollingInterval()
+	if interval < c.PollingInterval {
+		interval = c.PollingInterval
+	}
+	lastPolledAt := session.GetLastPolledAt()
+	slowDown := !lastPolledAt.IsZero() && now.Sub(lastPolledAt) < interval
+	if slowDown {
+		interval += cibaSlowDownIncrement
+	}
+	session.SetLastPolledAt(now)
+	session.SetPollingInterval(interval)
+	if err := c.Storage.UpdateBackchannelAuthRequestSession(ctx, signature, cibaRequest); err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+	if slowDown {
+		return errorsx.WithStack(fosite.ErrSlowDown)
+	}
+
+	switch session.GetBackchannelAuthRequestStatus() {
+	case BackchannelAuthRequestStatusApproved:
+	case BackchannelAuthRequestStatusDenied:
+		return errorsx.WithStack(fosite.ErrAccessDenied.WithHint("The end-user denied the backchannel authentication request."))
+	default:
+		return errorsx.WithStack(fosite.ErrAuthorizationPending)
+	}
+
+	if !cibaRequest.GetGrantedScopes().Has("openid") {
+		return errorsx.WithStack(fosite.ErrMisconfiguration.WithDebug("The backchannel authentication request was approved but the openid scope was not granted."))
+	}
+
+	request.SetRequestedScopes(cibaRequest.GetRequestedScopes())
+	request.SetRequestedAudience(cibaRequest.GetRequestedAudience())
+	for _, scope := range cibaRequest.GetGrantedScopes() {
+		request.GrantScope(scope)
+	}
+	for _, audience := range cibaRequest.GetGrantedAudience() {
+		request.GrantAudience(audience)
+	}
+
+	request.SetSession(cibaRequest.GetSession())
+	request.SetID(cibaRequest.GetID())
+
+	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(fosite.GetEffectiveLifespan(request.GetClient(), grantTypeCIBA, fosite.AccessToken, c.AccessTokenLifespan)).Round(time.Second))
+	if refreshTokenLifespan := fosite.GetEffectiveLifespan(request.GetClient(), grantTypeCIBA, fosite.RefreshToken, c.RefreshTokenLifespan); refreshTokenLifespan > -1 {
+		request.GetSession().SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(refreshTokenLifespan).Round(time.Second))
+	}
+
+	return nil
+}
+
+func (c *OpenIDConnectCIBAHandler) canIssueRefreshToken(request fosite.Requester) bool {
+	// Require one of the refresh token scopes, if set.
+	if len(c.RefreshTokenScopes) > 0 && !request.GetGrantedScopes().HasOneOf(c.RefreshTokenScopes...) {
+		return false
+	}
+	// Do not issue a refresh token to clients that cannot use the refresh token grant type.
+	if !request.GetClient().GetGrantTypes().Has("refresh_token") {
+		return false
+	}
+	return true
+}
+
+// PopulateTokenEndpointResponse issues the access, refresh and ID token. The ID token is generated from the stored
+// backchannel authentication request, in the same way as for the authorization code flow.
+func (c *OpenIDConnectCIBAHandler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	if !c.CanHandleTokenEndpointRequest(requester) {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	signature := c.AuthReqIDStrategy.AuthReqIDSignature(requester.GetRequestForm().Get("auth_req_id"))
+	cibaRequest, err := c.Storage.GetBackchannelAuthRequestSession(ctx, signature, requester.GetSession())
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	access, accessSignature, err := c.AccessTokenStrategy.GenerateAccessToken(ctx, requester)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	var refresh, refreshSignature string
+	if c.canIssueRefreshToken(requester) {
+		refresh, refreshSignature, err = c.RefreshTokenStrategy.GenerateRefreshToken(ctx, requester)
+		if err != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+	}
+
+	responder.SetAccessToken(access)
+	responder.SetTokenType("bearer")
+	responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, c.AccessTokenLifespan, time.Now().UTC()))
+	responder.SetScopes(requester.GetGrantedScopes())
+	if refresh != "" {
+		responder.SetExtra("refresh_token", refresh)
+	}
+
+	sess, ok := requester.GetSession().(Session)
+	if !ok {
+		return errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because session must be of type fosite/handler/openid.Session."))
+	}
+
+	claims := sess.IDTokenClaims()
+	if claims.Subject == "" {
+		return errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because subject is an empty string."))
+	}
+	atHash, err := c.GetAccessTokenHash(ctx, requester, responder)
+	if err != nil {
+		return err
+	}
+	claims.AccessTokenHash = atHash
+	applyIDTokenLifespan(claims, requester.GetClient(), grantTypeCIBA)
+
+	cibaRequest.SetSession(sess)
+	if err := c.IssueExplicitIDToken(ctx, cibaRequest, responder); err != nil {
+		return err
+	}
+
+	ctx, err = storage.MaybeBeginTx(ctx, c.Storage)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if err := c.Storage.InvalidateBackchannelAuthRequestSession(ctx, signature); err != nil {
+		if rollBackTxnErr := storage.MaybeRollbackTx(ctx, c.Storage); rollBackTxnErr != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("error: %s; rollback error: %s", err, rollBackTxnErr))
+		}
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	} else if err := c.Storage.CreateAccessTokenSession(ctx, accessSignature, requester.Sanitize([]string{})); err != nil {
+		if rollBackTxnErr := storage.MaybeRollbackTx(ctx, c.Storage); rollBackTxnErr != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("error: %s; rollback error: %s", err, rollBackTxnErr))
+		}
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	} else if refreshSignature != "" {
+		if err := c.Storage.CreateRefreshTokenSession(ctx, refreshSignature, requester.Sanitize([]string{})); err != nil {
+			if rollBackTxnErr := storage.MaybeRollbackTx(ctx, c.Storage); rollBackTxnErr != nil {
+				return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("error: %s; rollback error: %s", err, rollBackTxnErr))
+			}
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+	}
+
+	if err := storage.MaybeCommitTx(ctx, c.Storage); err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if err := c.IssuanceHooks.TokensIssued(ctx, requester, accessSignature, refreshSignature); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *OpenIDConnectCIBAHandler) CanSkipClientAuth(requester fosite.AccessRequester) bool {
+	return false
+}
+
+func (c *OpenIDConnectCIBAHandler) CanHandleTokenEndpointRequest(requester fosite.AccessRequester) bool {
+	// grant_type REQUIRED.
+	// Value MUST be set to "urn:openid:params:grant-type:ciba"
+	return requester.GetGrantTypes().ExactOne(grantTypeCIBA)
+}
+
+func getExpiresIn(r fosite.Requester, key fosite.TokenType, defaultLifespan time.Duration, now time.Time) time.Duration {
+	if r.GetSession().GetExpiresAt(key).IsZero() {
+		return defaultLifespan
+	}
+	return time.Duration(r.GetSession().GetExpiresAt(key).UnixNano() - now.UnixNano())
+}
@@ -0,0 +1,344 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/hmac"
+	"github.com/ory/fosite/token/jwt"
+)
+
+func newCIBAHandler(store *storage.MemoryStore) *OpenIDConnectCIBAHandler {
+	signer := &jwt.RS256JWTStrategy{PrivateKey: key}
+	return &OpenIDConnectCIBAHandler{
+		Storage: store,
+		AuthReqIDStrategy: &DefaultAuthReqIDStrategy{
+			Enigma: &hmac.HMACStrategy{GlobalSecret: []byte("foobarfoobarfoobarfoobarfoobarfoobarfoobarfoobar")},
+		},
+		AccessTokenStrategy:  hmacStrategy,
+		RefreshTokenStrategy: hmacStrategy,
+		IDTokenHintStrategy:  signer,
+		IDTokenHandleHelper: &IDTokenHandleHelper{
+			IDTokenStrategy: &DefaultStrategy{JWTStrategy: signer, MinParameterEntropy: fosite.MinParameterEntropy},
+		},
+		AuthReqIDLifespan:    time.Minute * 10,
+		PollingInterval:      time.Second * 5,
+		AccessTokenLifespan:  time.Hour,
+		RefreshTokenLifespan: time.Hour * 24,
+	}
+}
+
+func newCIBASession() *DefaultBackchannelAuthRequestSession {
+	return &DefaultBackchannelAuthRequestSession{
+		DefaultSession: DefaultSession{
+			Claims:  &jwt.IDTokenClaims{Subject: "peter"},
+			Headers: &jwt.Headers{},
+		},
+	}
+}
+
+func TestOpenIDConnectCIBAHandler_HandleBackchannelAuthenticationEndpointRequest(t *testing.T) {
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{grantTypeCIBA}}
+	hint, _, err := (&jwt.RS256JWTStrategy{PrivateKey: key}).Generate(context.Background(), jwt.MapClaims{"sub": "peter", "exp": time.Now().Add(-time.Hour).Unix()}, jwt.NewHeaders())
+	require.NoError(t, err)
+
+	for k, c := range []struct {
+		description string
+		setup       func(br *fosite.BackchannelAuthenticationRequest)
+		expectErr   error
+		check       func(t *testing.T, store *storage.MemoryStore, resp *fosite.BackchannelAuthenticationResponse)
+	}{
+		{
+			description: "should fail because the client is not allowed to use the grant type",
+			setup: func(br *fosite.BackchannelAuthenticationRequest) {
+				br.Client = &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"authorization_code"}}
+			},
+			expectErr: fosite.ErrUnauthorizedClient,
+		},
+		{
+			description: "should fail because the openid scope is missing",
+			setup: func(br *fosite.BackchannelAuthenticationRequest) {
+				br.RequestedScope = fosite.Arguments{"email"}
+			},
+			expectErr: fosite.ErrInvalidScope,
+		},
+		{
+			description: "should fail because the id_token_hint is invalid",
+			setup: func(br *fosite.BackchannelAuthenticationRequest) {
+				br.Form = url.Values{"id_token_hint": {"foo.bar.baz"}}
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should pass with an expired id_token_hint",
+			setup: func(br *fosite.BackchannelAuthenticationRequest) {
+				br.Form = url.Values{"id_token_hint": {hint}}
+			},
+			check: func(t *testing.T, store *storage.MemoryStore, resp *fosite.BackchannelAuthenticationResponse) {
+				assert.NotEmpty(t, resp.AuthReqID)
+				assert.Len(t, store.BackchannelAuthRequests, 1)
+			},
+		},
+		{
+			description: "should pass and honor a shorter requested_expiry",
+			setup: func(br *fosite.BackchannelAuthenticationRequest) {
+				br.Form = url.Values{"login_hint": {"peter"}, "requested_expiry": {"60"}, "binding_message": {"W4SCT"}}
+			},
+			check: func(t *testing.T, store *storage.MemoryStore, resp *fosite.BackchannelAuthenticationResponse) {
+				assert.NotEmpty(t, resp.AuthReqID)
+				assert.InDelta(t, 60, resp.ExpiresIn, 1)
+				assert.EqualValues(t, 5, resp.Interval)
+				for _, r := range store.BackchannelAuthRequests {
+					assert.Equal(t, "W4SCT", r.GetRequestForm().Get("binding_message"))
+					assert.Equal(t, BackchannelAuthRequestStatusPending, r.GetSession().(BackchannelAuthRequestSession).GetBackchannelAuthRequestStatus())
+				}
+			},
+		},
+		{
+			description: "should pass and cap a longer requested_expiry",
+			setup: func(br *fosite.BackchannelAuthenticationRequest) {
+				br.Form = url.Values{"login_hint": {"peter"}, "requested_expiry": {"3600"}}
+			},
+			check: func(t *testing.T, _ *storage.MemoryStore, resp *fosite.BackchannelAuthenticationResponse) {
+				assert.InDelta(t, 600, resp.ExpiresIn, 1)
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			h := newCIBAHandler(store)
+
+			br := fosite.NewBackchannelAuthenticationRequest()
+			br.Client = client
+			br.RequestedScope = fosite.Arguments{"openid"}
+			br.Form = url.Values{"login_hint": {"peter"}}
+			br.Session = newCIBASession()
+			if c.setup != nil {
+				c.setup(br)
+			}
+
+			resp := fosite.NewBackchannelAuthenticationResponse()
+			err := h.HandleBackchannelAuthenticationEndpointRequest(context.Background(), br, resp)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			c.check(t, store, resp)
+		})
+	}
+}
+
+func TestOpenIDConnectCIBAHandler_SlowDown(t *testing.T) {
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{grantTypeCIBA}}
+	store := storage.NewMemoryStore()
+	h := newCIBAHandler(store)
+
+	br := fosite.NewBackchannelAuthenticationRequest()
+	br.Client = client
+	br.RequestedScope = fosite.Arguments{"openid"}
+	br.Session = newCIBASession()
+	br.Session.SetExpiresAt(fosite.AuthReqID, time.Now().UTC().Add(time.Minute))
+	id, signature, err := h.AuthReqIDStrategy.GenerateAuthReqID(context.Background(), br)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateBackchannelAuthRequestSession(context.Background(), signature, br))
+
+	poll := func() error {
+		return h.HandleTokenEndpointRequest(context.Background(), &fosite.AccessRequest{
+			GrantTypes: fosite.Arguments{grantTypeCIBA},
+			Request:    fosite.Request{Client: client, Form: url.Values{"auth_req_id": {id}}, Session: newCIBASession()},
+		})
+	}
+	interval := func() time.Duration {
+		stored, err := store.GetBackchannelAuthRequestSession(context.Background(), signature, newCIBASession())
+		require.NoError(t, err)
+		return stored.GetSession().(BackchannelAuthRequestSession).GetPollingInterval()
+	}
+
+	require.EqualError(t, poll(), fosite.ErrAuthorizationPending.Error())
+	assert.Equal(t, time.Second*5, interval())
+
+	require.EqualError(t, poll(), fosite.ErrSlowDown.Error())
+	assert.Equal(t, time.Second*10, interval())
+
+	require.EqualError(t, poll(), fosite.ErrSlowDown.Error())
+	assert.Equal(t, time.Second*15, interval())
+}
+
+func TestOpenIDConnectCIBAHandler_HandleTokenEndpointRequest(t *testing.T) {
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{grantTypeCIBA, "refresh_token"}}
+
+	for k, c := range []struct {
+		description string
+		setup       func(areq *fosite.AccessRequest, br *fosite.BackchannelAuthenticationRequest)
+		expectErr   error
+		check       func(t *testing.T, aresp *fosite.AccessResponse)
+	}{
+		{
+			description: "should fail because the grant type is not supported by the handler",
+			setup: func(areq *fosite.AccessRequest, _ *fosite.BackchannelAuthenticationRequest) {
+				areq.GrantTypes = fosite.Arguments{"authorization_code"}
+			},
+			expectErr: fosite.ErrUnknownRequest,
+		},
+		{
+			description: "should fail because the client is not allowed to use the grant type",
+			setup: func(areq *fosite.AccessRequest, _ *fosite.BackchannelAuthenticationRequest) {
+				areq.Client = &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"authorization_code"}}
+			},
+			expectErr: fosite.ErrUnauthorizedClient,
+		},
+		{
+			description: "should fail because the auth_req_id is missing",
+			setup: func(areq *fosite.AccessRequest, _ *fosite.BackchannelAuthenticationRequest) {
+				areq.Form.Del("auth_req_id")
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the auth_req_id is unknown",
+			setup: func(areq *fosite.AccessRequest, _ *fosite.BackchannelAuthenticationRequest) {
+				areq.Form.Set("auth_req_id", "foo.bar")
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should fail because the end-user has not yet approved the request",
+			expectErr:   fosite.ErrAuthorizationPending,
+		},
+		{
+			description: "should fail because the client polls too fast",
+			setup: func(_ *fosite.AccessRequest, br *fosite.BackchannelAuthenticationRequest) {
+				br.Session.(*DefaultBackchannelAuthRequestSession).LastPolledAt = time.Now().UTC().Add(-time.Second)
+			},
+			expectErr: fosite.ErrSlowDown,
+		},
+		{
+			description: "should fail because the client polls faster than the interval raised by a previous slow_down",
+			setup: func(_ *fosite.AccessRequest, br *fosite.BackchannelAuthenticationRequest) {
+				br.Session.(*DefaultBackchannelAuthRequestSession).BackchannelAuthRequestStatus = BackchannelAuthRequestStatusApproved
+				br.Session.(*DefaultBackchannelAuthRequestSession).LastPolledAt = time.Now().UTC().Add(-time.Hour).Add(time.Second)
+				br.Session.(*DefaultBackchannelAuthRequestSession).PollingInterval = time.Hour
+			},
+			expectErr: fosite.ErrSlowDown,
+		},
+		{
+			description: "should fail because the end-user denied the request",
+			setup: func(_ *fosite.AccessRequest, br *fosite.BackchannelAuthenticationRequest) {
+				br.Session.(*DefaultBackchannelAuthRequestSession).BackchannelAuthRequestStatus = BackchannelAuthRequestStatusDenied
+			},
+			expectErr: fosite.ErrAccessDenied,
+		},
+		{
+			description: "should fail because the auth_req_id expired",
+			setup: func(_ *fosite.AccessRequest, br *fosite.BackchannelAuthenticationRequest) {
+				br.Session.SetExpiresAt(fosite.AuthReqID, time.Now().UTC().Add(-time.Minute))
+			},
+			expectErr: fosite.ErrExpiredToken,
+		},
+		{
+			description: "should fail because the auth_req_id was issued to another client",
+			setup: func(_ *fosite.AccessRequest, br *fosite.BackchannelAuthenticationRequest) {
+				br.Client = &fosite.DefaultClient{ID: "bar", GrantTypes: fosite.Arguments{grantTypeCIBA}}
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should pass and issue an access, refresh and id token",
+			setup: func(_ *fosite.AccessRequest, br *fosite.BackchannelAuthenticationRequest) {
+				br.Session.(*DefaultBackchannelAuthRequestSession).BackchannelAuthRequestStatus = BackchannelAuthRequestStatusApproved
+				br.Form.Set("acr_values", "urn:mace:incommon:iap:silver")
+			},
+			check: func(t *testing.T, aresp *fosite.AccessResponse) {
+				assert.NotEmpty(t, aresp.AccessToken)
+				assert.Equal(t, "bearer", aresp.TokenType)
+				assert.NotEmpty(t, aresp.GetExtra("refresh_token"))
+
+				idToken, ok := aresp.GetExtra("id_token").(string)
+				require.True(t, ok)
+				token, err := (&jwt.RS256JWTStrategy{PrivateKey: key}).Decode(context.Background(), idToken)
+				require.NoError(t, err)
+				assert.Equal(t, "peter", token.Claims["sub"])
+				assert.Equal(t, "foo", token.Claims["aud"].([]interface{})[0])
+				assert.Equal(t, "0", token.Claims["acr"])
+				assert.NotEmpty(t, token.Claims["at_hash"])
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			h := newCIBAHandler(store)
+
+			br := fosite.NewBackchannelAuthenticationRequest()
+			br.Client = client
+			br.RequestedScope = fosite.Arguments{"openid"}
+			br.GrantedScope = fosite.Arguments{"openid"}
+			br.Session = newCIBASession()
+			br.Session.SetExpiresAt(fosite.AuthReqID, time.Now().UTC().Add(time.Minute))
+
+			id, signature, err := h.AuthReqIDStrategy.GenerateAuthReqID(context.Background(), br)
+			require.NoError(t, err)
+
+			areq := &fosite.AccessRequest{
+				GrantTypes: fosite.Arguments{grantTypeCIBA},
+				Request: fosite.Request{
+					Client:      client,
+					Form:        url.Values{"auth_req_id": {id}},
+					Session:     newCIBASession(),
+					RequestedAt: time.Now().UTC(),
+				},
+			}
+			if c.setup != nil {
+				c.setup(areq, br)
+			}
+			require.NoError(t, store.CreateBackchannelAuthRequestSession(context.Background(), signature, br))
+
+			err = h.HandleTokenEndpointRequest(context.Background(), areq)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			aresp := fosite.NewAccessResponse()
+			require.NoError(t, h.PopulateTokenEndpointResponse(context.Background(), areq, aresp))
+			c.check(t, aresp)
+
+			_, err = store.GetBackchannelAuthRequestSession(context.Background(), signature, nil)
+			assert.ErrorIs(t, err, fosite.ErrInvalidatedAuthReqID)
+
+			areq.Session = newCIBASession()
+			err = h.HandleTokenEndpointRequest(context.Background(), areq)
+			require.EqualError(t, err, fosite.ErrInvalidGrant.Error())
+		})
+	}
+}
@@ -43,6 +43,7 @@ var oidcParameters = []string{"grant_type",
 	"acr_values",
 	"id_token_hint",
 	"nonce",
+	"claims",
 }
 
 func (c *OpenIDConnectExplicitHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
@@ -62,6 +63,14 @@ func (c *OpenIDConnectExplicitHandler) HandleAuthorizeEndpointRequest(ctx contex
 		return err
 	}
 
+	if _, err := GetClaimsRequest(ar); err != nil {
+		return err
+	}
+
+	if err := c.markNonceUsed(ctx, ar); err != nil {
+		return err
+	}
+
 	if err := c.OpenIDConnectRequestStorage.CreateOpenIDConnectSession(ctx, resp.GetCode(), ar.Sanitize(oidcParameters)); err != nil {
 		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
 	}
@@ -51,8 +51,8 @@ func (c *OpenIDConnectExplicitHandler) PopulateTokenEndpointResponse(ctx context
 		return errorsx.WithStack(fosite.ErrMisconfiguration.WithDebug("An OpenID Connect session was found but the openid scope is missing, probably due to a broken code configuration."))
 	}
 
-	if !requester.GetClient().GetGrantTypes().Has("authorization_code") {
-		return errorsx.WithStack(fosite.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use the authorization grant \"authorization_code\"."))
+	if err := fosite.ValidateClientGrantType(requester.GetClient(), "authorization_code"); err != nil {
+		return err
 	}
 
 	sess, ok := requester.GetSession().(Session)
@@ -65,7 +65,12 @@ func (c *OpenIDConnectExplicitHandler) PopulateTokenEndpointResponse(ctx context
 		return errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because subject is an empty string."))
 	}
 
-	claims.AccessTokenHash = c.GetAccessTokenHash(ctx, requester, responder)
+	atHash, err := c.GetAccessTokenHash(ctx, requester, responder)
+	if err != nil {
+		return err
+	}
+	claims.AccessTokenHash = atHash
+	applyIDTokenLifespan(claims, requester.GetClient(), "authorization_code")
 
 	// The response type `id_token` is only required when performing the implicit or hybrid flow, see:
 	// https://openid.net/specs/openid-connect-registration-1_0.html
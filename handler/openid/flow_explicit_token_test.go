@@ -32,6 +32,7 @@ import (
 
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
 	"github.com/ory/fosite/token/jwt"
 )
 
@@ -161,3 +162,57 @@ func TestExplicit_PopulateTokenEndpointResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestExplicit_ClaimsRequestReachesTokenEndpoint(t *testing.T) {
+	store := storage.NewMemoryStore()
+	j := &DefaultStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{
+			PrivateKey: key,
+		},
+		MinParameterEntropy:   fosite.MinParameterEntropy,
+		ClaimsRequestStrategy: staticClaimsRequestStrategy{"email": "peter@example.com"},
+	}
+	h := &OpenIDConnectExplicitHandler{
+		OpenIDConnectRequestStorage: store,
+		IDTokenHandleHelper: &IDTokenHandleHelper{
+			IDTokenStrategy: j,
+		},
+		OpenIDConnectRequestValidator: NewOpenIDConnectRequestValidator(nil, j.JWTStrategy),
+	}
+
+	client := &fosite.DefaultClient{
+		ResponseTypes: fosite.Arguments{"code"},
+		GrantTypes:    fosite.Arguments{"authorization_code"},
+	}
+	session := &DefaultSession{
+		Claims:  &jwt.IDTokenClaims{Subject: "peter"},
+		Headers: &jwt.Headers{},
+	}
+
+	areq := fosite.NewAuthorizeRequest()
+	areq.Client = client
+	areq.Session = session
+	areq.ResponseTypes = fosite.Arguments{"code"}
+	areq.GrantedScope = fosite.Arguments{"openid"}
+	areq.Form.Set("nonce", "11111111111111111111111111111")
+	areq.Form.Set("claims", `{"id_token":{"email":{"essential":true}}}`)
+
+	aresp := fosite.NewAuthorizeResponse()
+	aresp.AddParameter("code", "codeexample")
+	require.NoError(t, h.HandleAuthorizeEndpointRequest(nil, areq, aresp))
+
+	treq := fosite.NewAccessRequest(session)
+	treq.Client = client
+	treq.GrantTypes = fosite.Arguments{"authorization_code"}
+	treq.Form.Set("code", "codeexample")
+
+	tresp := fosite.NewAccessResponse()
+	require.NoError(t, h.PopulateTokenEndpointResponse(nil, treq, tresp))
+
+	idToken, _ := tresp.GetExtra("id_token").(string)
+	decoded, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		return key.PublicKey, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "peter@example.com", decoded.Claims["email"])
+}
@@ -23,7 +23,6 @@ package openid
 
 import (
 	"context"
-	"encoding/base64"
 	"time"
 
 	"github.com/ory/x/errorsx"
@@ -44,6 +43,10 @@ type OpenIDConnectHybridHandler struct {
 	Enigma *jwt.RS256JWTStrategy
 
 	MinParameterEntropy int
+
+	// AllowMissingNonce accepts hybrid flow requests for an ID Token from the authorization endpoint without a nonce
+	// for legacy clients, although OpenID Connect requires it. Defaults to false.
+	AllowMissingNonce bool
 }
 
 func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
@@ -57,6 +60,10 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 
 	ar.SetDefaultResponseMode(fosite.ResponseModeFragment)
 
+	if ar.GetResponseTypes().Has("token") && c.AuthorizeImplicitGrantTypeHandler.Disabled {
+		return errorsx.WithStack(fosite.ErrUnsupportedResponseType.WithHint("The authorization server does not issue access tokens from the authorization endpoint."))
+	}
+
 	// Disabled because this is already handled at the authorize_request_handler
 	//if ar.GetResponseTypes().Matches("token") && !ar.GetClient().GetResponseTypes().Has("token") {
 	//	return errorsx.WithStack(fosite.ErrInvalidGrant.WithDebug("The client is not allowed to use the token response type"))
@@ -71,7 +78,7 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 	//
 	nonce := ar.GetRequestForm().Get("nonce")
 
-	if len(nonce) == 0 && ar.GetResponseTypes().Has("id_token") {
+	if len(nonce) == 0 && ar.GetResponseTypes().Has("id_token") && !c.AllowMissingNonce {
 		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Parameter 'nonce' must be set when requesting an ID Token using the OpenID Connect Hybrid Flow."))
 	}
 
@@ -88,6 +95,10 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 		return err
 	}
 
+	if _, err := GetClaimsRequest(ar); err != nil {
+		return err
+	}
+
 	client := ar.GetClient()
 	for _, scope := range ar.GetRequestedScopes() {
 		if !c.ScopeStrategy(client.GetScopes(), scope) {
@@ -95,10 +106,16 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 		}
 	}
 
+	// The nonce is consumed once per authentication request here. The ID Token issued when the client later exchanges
+	// the authorization code carries the same nonce, which is expected and not treated as a replay.
+	if err := c.IDTokenHandleHelper.markNonceUsed(ctx, ar); err != nil {
+		return err
+	}
+
 	claims := sess.IDTokenClaims()
 	if ar.GetResponseTypes().Has("code") {
-		if !ar.GetClient().GetGrantTypes().Has("authorization_code") {
-			return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client is not allowed to use authorization grant 'authorization_code'."))
+		if err := fosite.ValidateClientGrantType(ar.GetClient(), "authorization_code"); err != nil {
+			return err
 		}
 
 		code, signature, err := c.AuthorizeExplicitGrantHandler.AuthorizeCodeStrategy.GenerateAuthorizeCode(ctx, ar)
@@ -114,7 +131,7 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 		// }
 
 		// This is required because we must limit the authorize code lifespan.
-		ar.GetSession().SetExpiresAt(fosite.AuthorizeCode, time.Now().UTC().Add(c.AuthorizeExplicitGrantHandler.AuthCodeLifespan).Round(time.Second))
+		ar.GetSession().SetExpiresAt(fosite.AuthorizeCode, time.Now().UTC().Add(fosite.GetEffectiveLifespan(ar.GetClient(), "authorization_code", fosite.AuthorizeCode, c.AuthorizeExplicitGrantHandler.AuthCodeLifespan)).Round(time.Second))
 		if err := c.AuthorizeExplicitGrantHandler.CoreStorage.CreateAuthorizeCodeSession(ctx, signature, ar.Sanitize(c.AuthorizeExplicitGrantHandler.GetSanitationWhiteList())); err != nil {
 			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
 		}
@@ -122,11 +139,11 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 		resp.AddParameter("code", code)
 		ar.SetResponseTypeHandled("code")
 
-		hash, err := c.Enigma.Hash(ctx, []byte(resp.GetParameters().Get("code")))
+		hash, err := c.IDTokenHandleHelper.hashForIDToken(ctx, ar, c.Enigma, resp.GetParameters().Get("code"))
 		if err != nil {
 			return err
 		}
-		claims.CodeHash = base64.RawURLEncoding.EncodeToString([]byte(hash[:c.Enigma.GetSigningMethodLength()/2]))
+		claims.CodeHash = hash
 
 		if ar.GetGrantedScopes().Has("openid") {
 			if err := c.OpenIDConnectRequestStorage.CreateOpenIDConnectSession(ctx, resp.GetCode(), ar.Sanitize(oidcParameters)); err != nil {
@@ -136,18 +153,26 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 	}
 
 	if ar.GetResponseTypes().Has("token") {
-		if !ar.GetClient().GetGrantTypes().Has("implicit") {
-			return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client is not allowed to use the authorization grant 'implicit'."))
+		if err := fosite.ValidateClientGrantType(ar.GetClient(), "implicit"); err != nil {
+			return err
 		} else if err := c.AuthorizeImplicitGrantTypeHandler.IssueImplicitAccessToken(ctx, ar, resp); err != nil {
 			return errorsx.WithStack(err)
 		}
 		ar.SetResponseTypeHandled("token")
 
-		hash, err := c.Enigma.Hash(ctx, []byte(resp.GetParameters().Get("access_token")))
+		hash, err := c.IDTokenHandleHelper.hashForIDToken(ctx, ar, c.Enigma, resp.GetParameters().Get("access_token"))
+		if err != nil {
+			return err
+		}
+		claims.AccessTokenHash = hash
+	}
+
+	if state := ar.GetState(); state != "" {
+		hash, err := c.IDTokenHandleHelper.hashForIDToken(ctx, ar, c.Enigma, state)
 		if err != nil {
 			return err
 		}
-		claims.AccessTokenHash = base64.RawURLEncoding.EncodeToString([]byte(hash[:c.Enigma.GetSigningMethodLength()/2]))
+		claims.StateHash = hash
 	}
 
 	if resp.GetParameters().Get("state") == "" {
@@ -159,6 +184,7 @@ func (c *OpenIDConnectHybridHandler) HandleAuthorizeEndpointRequest(ctx context.
 		return nil
 	}
 
+	applyIDTokenLifespan(claims, ar.GetClient(), "implicit")
 	if err := c.IDTokenHandleHelper.IssueImplicitIDToken(ctx, ar, resp); err != nil {
 		return errorsx.WithStack(err)
 	}
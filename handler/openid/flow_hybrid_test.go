@@ -22,6 +22,7 @@
 package openid
 
 import (
+	"crypto"
 	"fmt"
 	"net/url"
 	"testing"
@@ -104,6 +105,30 @@ func (s *defaultSession) IDTokenClaims() *jwt.IDTokenClaims {
 	return s.Claims
 }
 
+func (s *defaultSession) GetAuthTime() time.Time {
+	return s.IDTokenClaims().AuthTime
+}
+
+func (s *defaultSession) GetRequestedAt() time.Time {
+	return s.IDTokenClaims().RequestedAt
+}
+
+func (s *defaultSession) GetACR() string {
+	return s.IDTokenClaims().AuthenticationContextClassReference
+}
+
+func (s *defaultSession) SetACR(acr string) {
+	s.IDTokenClaims().AuthenticationContextClassReference = acr
+}
+
+func (s *defaultSession) GetAMR() []string {
+	return s.IDTokenClaims().AuthenticationMethodsReferences
+}
+
+func (s *defaultSession) SetAMR(amr []string) {
+	s.IDTokenClaims().AuthenticationMethodsReferences = amr
+}
+
 func TestHybrid_HandleAuthorizeEndpointRequest(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -193,7 +218,7 @@ func TestHybrid_HandleAuthorizeEndpointRequest(t *testing.T) {
 				}
 				return makeOpenIDConnectHybridHandler(fosite.MinParameterEntropy)
 			},
-			expectErr: fosite.ErrInvalidGrant,
+			expectErr: fosite.ErrUnauthorizedClient,
 		},
 		{
 			description: "should pass because nonce was set with sufficient entropy",
@@ -265,6 +290,68 @@ func TestHybrid_HandleAuthorizeEndpointRequest(t *testing.T) {
 				assert.Equal(t, time.Now().Add(time.Hour).UTC().Round(time.Second), areq.GetSession().GetExpiresAt(fosite.AuthorizeCode))
 			},
 		},
+		{
+			description: "should pass and set s_hash using the hash of the signing algorithm",
+			setup: func() OpenIDConnectHybridHandler {
+				areq.State = "some-random-state-foobar"
+				return makeOpenIDConnectHybridHandler(fosite.MinParameterEntropy)
+			},
+			check: func() {
+				claims := areq.GetSession().(*DefaultSession).IDTokenClaims()
+				assert.Equal(t, "Eu2huZu4WpysUeRHQCwhgw", claims.StateHash)
+				assert.NotEmpty(t, claims.CodeHash)
+				assert.NotEmpty(t, claims.AccessTokenHash)
+			},
+		},
+		{
+			description: "should pass and set s_hash using the hash of the hash strategy",
+			setup: func() OpenIDConnectHybridHandler {
+				areq.State = "some-random-state-foobar"
+				h := makeOpenIDConnectHybridHandler(fosite.MinParameterEntropy)
+				h.IDTokenHandleHelper.HashStrategy = &FixedHashStrategy{Hash: crypto.SHA384}
+				return h
+			},
+			check: func() {
+				assert.Equal(t, "K4gFOoRGilgFXwGB9gb2a2aaJVHGClvu", areq.GetSession().(*DefaultSession).IDTokenClaims().StateHash)
+			},
+		},
+		{
+			description: "should pass and carry the nonce into the ID token",
+			setup: func() OpenIDConnectHybridHandler {
+				areq.Form.Set("nonce", "another-foobar-nonce-win")
+				return makeOpenIDConnectHybridHandler(fosite.MinParameterEntropy)
+			},
+			check: func() {
+				assert.NotEmpty(t, aresp.GetParameters().Get("id_token"))
+				assert.Equal(t, "another-foobar-nonce-win", areq.GetSession().(*DefaultSession).IDTokenClaims().Nonce)
+			},
+		},
+		{
+			description: "should fail because nonce is missing when requesting an ID token",
+			setup: func() OpenIDConnectHybridHandler {
+				areq.Form.Del("nonce")
+				return makeOpenIDConnectHybridHandler(fosite.MinParameterEntropy)
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should pass without nonce because missing nonces are allowed",
+			setup: func() OpenIDConnectHybridHandler {
+				h := makeOpenIDConnectHybridHandler(fosite.MinParameterEntropy)
+				h.AllowMissingNonce = true
+				return h
+			},
+			check: func() {
+				assert.NotEmpty(t, aresp.GetParameters().Get("id_token"))
+			},
+		},
+		{
+			description: "should pass without nonce because no ID token is requested from the authorization endpoint",
+			setup: func() OpenIDConnectHybridHandler {
+				areq.ResponseTypes = fosite.Arguments{"token", "code"}
+				return makeOpenIDConnectHybridHandler(fosite.MinParameterEntropy)
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			h := c.setup()
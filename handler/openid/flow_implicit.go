@@ -23,7 +23,6 @@ package openid
 
 import (
 	"context"
-	"encoding/base64"
 
 	"github.com/ory/x/errorsx"
 
@@ -41,6 +40,10 @@ type OpenIDConnectImplicitHandler struct {
 	RS256JWTStrategy *jwt.RS256JWTStrategy
 
 	MinParameterEntropy int
+
+	// AllowMissingNonce accepts implicit flow requests without a nonce for legacy clients, although OpenID Connect
+	// requires it to mitigate replay attacks. Defaults to false.
+	AllowMissingNonce bool
 }
 
 func (c *OpenIDConnectImplicitHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
@@ -53,8 +56,12 @@ func (c *OpenIDConnectImplicitHandler) HandleAuthorizeEndpointRequest(ctx contex
 
 	ar.SetDefaultResponseMode(fosite.ResponseModeFragment)
 
-	if !ar.GetClient().GetGrantTypes().Has("implicit") {
-		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client is not allowed to use the authorization grant 'implicit'."))
+	if c.AuthorizeImplicitGrantTypeHandler.Disabled {
+		return errorsx.WithStack(fosite.ErrUnsupportedResponseType.WithHint("The authorization server does not support the implicit grant."))
+	}
+
+	if err := fosite.ValidateClientGrantType(ar.GetClient(), "implicit"); err != nil {
+		return err
 	}
 
 	// Disabled because this is already handled at the authorize_request_handler
@@ -65,7 +72,9 @@ func (c *OpenIDConnectImplicitHandler) HandleAuthorizeEndpointRequest(ctx contex
 	//}
 
 	if nonce := ar.GetRequestForm().Get("nonce"); len(nonce) == 0 {
-		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Parameter 'nonce' must be set when using the OpenID Connect Implicit Flow."))
+		if !c.AllowMissingNonce {
+			return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Parameter 'nonce' must be set when using the OpenID Connect Implicit Flow."))
+		}
 	} else if len(nonce) < c.MinParameterEntropy {
 		return errorsx.WithStack(fosite.ErrInsufficientEntropy.WithHintf("Parameter 'nonce' is set but does not satisfy the minimum entropy of %d characters.", c.MinParameterEntropy))
 	}
@@ -86,6 +95,14 @@ func (c *OpenIDConnectImplicitHandler) HandleAuthorizeEndpointRequest(ctx contex
 		return err
 	}
 
+	if _, err := GetClaimsRequest(ar); err != nil {
+		return err
+	}
+
+	if err := c.markNonceUsed(ctx, ar); err != nil {
+		return err
+	}
+
 	claims := sess.IDTokenClaims()
 	if ar.GetResponseTypes().Has("token") {
 		if err := c.AuthorizeImplicitGrantTypeHandler.IssueImplicitAccessToken(ctx, ar, resp); err != nil {
@@ -93,16 +110,25 @@ func (c *OpenIDConnectImplicitHandler) HandleAuthorizeEndpointRequest(ctx contex
 		}
 
 		ar.SetResponseTypeHandled("token")
-		hash, err := c.RS256JWTStrategy.Hash(ctx, []byte(resp.GetParameters().Get("access_token")))
+		hash, err := c.hashForIDToken(ctx, ar, c.RS256JWTStrategy, resp.GetParameters().Get("access_token"))
 		if err != nil {
 			return err
 		}
 
-		claims.AccessTokenHash = base64.RawURLEncoding.EncodeToString([]byte(hash[:c.RS256JWTStrategy.GetSigningMethodLength()/2]))
+		claims.AccessTokenHash = hash
 	} else {
 		resp.AddParameter("state", ar.GetState())
 	}
 
+	if state := ar.GetState(); state != "" {
+		hash, err := c.hashForIDToken(ctx, ar, c.RS256JWTStrategy, state)
+		if err != nil {
+			return err
+		}
+		claims.StateHash = hash
+	}
+
+	applyIDTokenLifespan(claims, ar.GetClient(), "implicit")
 	if err := c.IssueImplicitIDToken(ctx, ar, resp); err != nil {
 		return errorsx.WithStack(err)
 	}
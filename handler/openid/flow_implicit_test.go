@@ -74,6 +74,7 @@ func TestImplicit_HandleAuthorizeEndpointRequest(t *testing.T) {
 	aresp := fosite.NewAuthorizeResponse()
 	areq := fosite.NewAuthorizeRequest()
 	areq.Session = new(fosite.DefaultSession)
+	nonceStore := storage.NewMemoryStore()
 
 	for k, c := range []struct {
 		description string
@@ -122,7 +123,7 @@ func TestImplicit_HandleAuthorizeEndpointRequest(t *testing.T) {
 				}
 				return makeOpenIDConnectImplicitHandler(fosite.MinParameterEntropy)
 			},
-			expectErr: fosite.ErrInvalidGrant,
+			expectErr: fosite.ErrUnauthorizedClient,
 		},
 		// Disabled because this is already handled at the authorize_request_handler
 		//{
@@ -247,6 +248,55 @@ func TestImplicit_HandleAuthorizeEndpointRequest(t *testing.T) {
 				assert.NotEmpty(t, aresp.GetParameters().Get("access_token"))
 			},
 		},
+		{
+			description: "should pass because nonce is used for the first time",
+			setup: func() OpenIDConnectImplicitHandler {
+				areq.Form.Set("nonce", "some-single-use-nonce")
+				h := makeOpenIDConnectImplicitHandler(fosite.MinParameterEntropy)
+				h.NonceStorage = nonceStore
+				return h
+			},
+			check: func() {
+				assert.NotEmpty(t, aresp.GetParameters().Get("id_token"))
+			},
+		},
+		{
+			description: "should fail because nonce has already been used",
+			setup: func() OpenIDConnectImplicitHandler {
+				h := makeOpenIDConnectImplicitHandler(fosite.MinParameterEntropy)
+				h.NonceStorage = nonceStore
+				return h
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the implicit grant is disabled",
+			setup: func() OpenIDConnectImplicitHandler {
+				h := makeOpenIDConnectImplicitHandler(fosite.MinParameterEntropy)
+				h.AuthorizeImplicitGrantTypeHandler.Disabled = true
+				return h
+			},
+			expectErr: fosite.ErrUnsupportedResponseType,
+		},
+		{
+			description: "should fail because nonce is missing",
+			setup: func() OpenIDConnectImplicitHandler {
+				areq.Form.Del("nonce")
+				return makeOpenIDConnectImplicitHandler(fosite.MinParameterEntropy)
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should pass without nonce because missing nonces are allowed",
+			setup: func() OpenIDConnectImplicitHandler {
+				h := makeOpenIDConnectImplicitHandler(fosite.MinParameterEntropy)
+				h.AllowMissingNonce = true
+				return h
+			},
+			check: func() {
+				assert.NotEmpty(t, aresp.GetParameters().Get("id_token"))
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
 			h := c.setup()
@@ -47,8 +47,8 @@ func (c *OpenIDConnectRefreshHandler) HandleTokenEndpointRequest(ctx context.Con
 		return errorsx.WithStack(fosite.ErrUnknownRequest)
 	}
 
-	if !request.GetClient().GetGrantTypes().Has("refresh_token") {
-		return errorsx.WithStack(fosite.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use the authorization grant \"refresh_token\"."))
+	if err := fosite.ValidateClientGrantType(request.GetClient(), "refresh_token"); err != nil {
+		return err
 	}
 
 	// Refresh tokens can only be issued by an authorize_code which in turn disables the need to check if the id_token
@@ -72,6 +72,7 @@ func (c *OpenIDConnectRefreshHandler) HandleTokenEndpointRequest(ctx context.Con
 
 	// We are not issuing a code so there is no need for this field.
 	sess.IDTokenClaims().CodeHash = ""
+	sess.IDTokenClaims().StateHash = ""
 
 	return nil
 }
@@ -85,8 +86,8 @@ func (c *OpenIDConnectRefreshHandler) PopulateTokenEndpointResponse(ctx context.
 		return errorsx.WithStack(fosite.ErrUnknownRequest)
 	}
 
-	if !requester.GetClient().GetGrantTypes().Has("refresh_token") {
-		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client is not allowed to use the authorization grant \"refresh_token\"."))
+	if err := fosite.ValidateClientGrantType(requester.GetClient(), "refresh_token"); err != nil {
+		return err
 	}
 
 	// Disabled because this is already handled at the authorize_request_handler
@@ -104,10 +105,15 @@ func (c *OpenIDConnectRefreshHandler) PopulateTokenEndpointResponse(ctx context.
 		return errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because subject is an empty string."))
 	}
 
-	claims.AccessTokenHash = c.GetAccessTokenHash(ctx, requester, responder)
+	atHash, err := c.GetAccessTokenHash(ctx, requester, responder)
+	if err != nil {
+		return err
+	}
+	claims.AccessTokenHash = atHash
 	claims.JTI = uuid.New()
 	claims.CodeHash = ""
 	claims.IssuedAt = time.Now().Truncate(time.Second)
+	applyIDTokenLifespan(claims, requester.GetClient(), "refresh_token")
 
 	return c.IssueExplicitIDToken(ctx, requester, responder)
 }
@@ -0,0 +1,118 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+)
+
+// IDTokenHashStrategy selects the hash function used to compute the "at_hash", "c_hash" and "s_hash" claims of ID
+// Tokens, see https://openid.net/specs/openid-connect-core-1_0.html#HybridIDToken and
+// https://openid.net/specs/openid-financial-api-part-2-1_0.html#id-token-as-detached-signature
+type IDTokenHashStrategy interface {
+	// GetIDTokenHash returns the hash function for the ID Token issued in response to the request.
+	GetIDTokenHash(ctx context.Context, requester fosite.Requester) (crypto.Hash, error)
+}
+
+// SigningAlgorithmHashStrategy uses the hash function of the algorithm the ID Token is signed with, which is the
+// id_token_signed_response_alg of the client or DefaultSigningAlgorithm otherwise. If DefaultSigningAlgorithm is not
+// set, the algorithm of SigningAlgorithmProvider is used, which should be the JWT strategy signing the ID Tokens.
+// Without either, RS256 is assumed.
+type SigningAlgorithmHashStrategy struct {
+	DefaultSigningAlgorithm  string
+	SigningAlgorithmProvider jwt.SigningAlgorithmProvider
+}
+
+func (s *SigningAlgorithmHashStrategy) GetIDTokenHash(ctx context.Context, requester fosite.Requester) (crypto.Hash, error) {
+	alg := s.DefaultSigningAlgorithm
+	if c, ok := requester.GetClient().(fosite.IDTokenSigningClient); ok && c.GetIDTokenSignedResponseAlg() != "" {
+		alg = c.GetIDTokenSignedResponseAlg()
+	}
+	if alg == "" && s.SigningAlgorithmProvider != nil {
+		alg = s.SigningAlgorithmProvider.GetSigningAlgorithm()
+	}
+	if alg == "" {
+		alg = "RS256"
+	}
+	return GetHashForSigningAlgorithm(alg)
+}
+
+// FixedHashStrategy always uses Hash, independent of the algorithm the ID Token is signed with.
+type FixedHashStrategy struct {
+	Hash crypto.Hash
+}
+
+func (s *FixedHashStrategy) GetIDTokenHash(ctx context.Context, requester fosite.Requester) (crypto.Hash, error) {
+	return s.Hash, nil
+}
+
+// GetHashForSigningAlgorithm returns the hash function used by the JWS algorithm alg. EdDSA uses SHA-512.
+func GetHashForSigningAlgorithm(alg string) (crypto.Hash, error) {
+	switch alg {
+	case "HS256", "RS256", "ES256", "PS256":
+		return crypto.SHA256, nil
+	case "HS384", "RS384", "ES384", "PS384":
+		return crypto.SHA384, nil
+	case "HS512", "RS512", "ES512", "PS512", "EdDSA":
+		return crypto.SHA512, nil
+	}
+	return 0, errorsx.WithStack(fosite.ErrServerError.WithDebugf("The signing algorithm '%s' is not supported for ID Token hash claims.", alg))
+}
+
+// hashForIDToken returns the base64url encoded left-most half of the hash of value. The hash function is selected by
+// HashStrategy, or using the hash function of fallback if HashStrategy is nil. Without either, SHA-256 is used.
+func (i *IDTokenHandleHelper) hashForIDToken(ctx context.Context, requester fosite.Requester, fallback jwt.JWTStrategy, value string) (string, error) {
+	if i.HashStrategy == nil && fallback == nil {
+		return leftMostHalf(crypto.SHA256, value), nil
+	} else if i.HashStrategy == nil {
+		hash, err := fallback.Hash(ctx, []byte(value))
+		if err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(hash[:fallback.GetSigningMethodLength()/2]), nil
+	}
+
+	h, err := i.HashStrategy.GetIDTokenHash(ctx, requester)
+	if err != nil {
+		return "", err
+	} else if !h.Available() {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithDebug("The hash function for ID Token hash claims is not available."))
+	}
+
+	return leftMostHalf(h, value), nil
+}
+
+// leftMostHalf returns the base64url encoded left-most half of the hash of value.
+func leftMostHalf(h crypto.Hash, value string) string {
+	hash := h.New()
+	// hash.Hash.Write() never returns an error
+	_, _ = hash.Write([]byte(value))
+	sum := hash.Sum(nil)
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+)
+
+func TestSigningAlgorithmHashStrategy(t *testing.T) {
+	for k, tc := range []struct {
+		strategy *SigningAlgorithmHashStrategy
+		client   fosite.Client
+		expected crypto.Hash
+		err      bool
+	}{
+		{strategy: &SigningAlgorithmHashStrategy{}, client: &fosite.DefaultClient{}, expected: crypto.SHA256},
+		{strategy: &SigningAlgorithmHashStrategy{DefaultSigningAlgorithm: "PS384"}, client: &fosite.DefaultClient{}, expected: crypto.SHA384},
+		{strategy: &SigningAlgorithmHashStrategy{DefaultSigningAlgorithm: "PS384"}, client: &fosite.DefaultOpenIDConnectClient{IDTokenSignedResponseAlg: "EdDSA"}, expected: crypto.SHA512},
+		{strategy: &SigningAlgorithmHashStrategy{}, client: &fosite.DefaultOpenIDConnectClient{IDTokenSignedResponseAlg: "none"}, err: true},
+		{strategy: &SigningAlgorithmHashStrategy{SigningAlgorithmProvider: new(jwt.Ed25519JWTStrategy)}, client: &fosite.DefaultClient{}, expected: crypto.SHA512},
+		{strategy: &SigningAlgorithmHashStrategy{SigningAlgorithmProvider: new(jwt.Ed25519JWTStrategy)}, client: &fosite.DefaultOpenIDConnectClient{IDTokenSignedResponseAlg: "ES384"}, expected: crypto.SHA384},
+		{strategy: &SigningAlgorithmHashStrategy{DefaultSigningAlgorithm: "PS384", SigningAlgorithmProvider: new(jwt.Ed25519JWTStrategy)}, client: &fosite.DefaultClient{}, expected: crypto.SHA384},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			h, err := tc.strategy.GetIDTokenHash(context.Background(), &fosite.Request{Client: tc.client})
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, h)
+		})
+	}
+}
+
+func TestIDTokenHandleHelper_hashForIDToken(t *testing.T) {
+	state := "some-random-state-foobar"
+	for k, tc := range []struct {
+		strategy IDTokenHashStrategy
+		client   fosite.Client
+		expected string
+	}{
+		{strategy: nil, client: &fosite.DefaultClient{}, expected: "Eu2huZu4WpysUeRHQCwhgw"},
+		{strategy: &SigningAlgorithmHashStrategy{}, client: &fosite.DefaultClient{}, expected: "Eu2huZu4WpysUeRHQCwhgw"},
+		{strategy: &SigningAlgorithmHashStrategy{}, client: &fosite.DefaultOpenIDConnectClient{IDTokenSignedResponseAlg: "ES384"}, expected: "K4gFOoRGilgFXwGB9gb2a2aaJVHGClvu"},
+		{strategy: &FixedHashStrategy{Hash: crypto.SHA512}, client: &fosite.DefaultOpenIDConnectClient{IDTokenSignedResponseAlg: "RS256"}, expected: "KrpMkmABU0P2yDA--W92A7dER-iyXRVM0muFRrsI6RA"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			helper := &IDTokenHandleHelper{HashStrategy: tc.strategy}
+			hash, err := helper.hashForIDToken(context.Background(), &fosite.Request{Client: tc.client}, new(jwt.RS256JWTStrategy), state)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, hash)
+		})
+	}
+}
+
+func TestIDTokenHandleHelper_Ed25519HashClaims(t *testing.T) {
+	leftMostHalfOfSHA512 := func(value string) string {
+		sum := sha512.Sum512([]byte(value))
+		return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+	}
+
+	helper := &IDTokenHandleHelper{HashStrategy: &SigningAlgorithmHashStrategy{SigningAlgorithmProvider: new(jwt.Ed25519JWTStrategy)}}
+	requester := &fosite.AccessRequest{Request: fosite.Request{Client: &fosite.DefaultClient{}}}
+	responder := fosite.NewAccessResponse()
+	responder.SetAccessToken("some-access-token")
+
+	atHash, err := helper.GetAccessTokenHash(context.Background(), requester, responder)
+	require.NoError(t, err)
+	assert.Equal(t, leftMostHalfOfSHA512("some-access-token"), atHash)
+	assert.Len(t, atHash, 43)
+
+	cHash, err := helper.hashForIDToken(context.Background(), requester, new(jwt.RS256JWTStrategy), "some-code")
+	require.NoError(t, err)
+	assert.Equal(t, leftMostHalfOfSHA512("some-code"), cHash)
+	assert.Len(t, cHash, 43)
+}
@@ -22,31 +22,64 @@
 package openid
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/base64"
+	"time"
+
+	"github.com/ory/x/errorsx"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
 )
 
 type IDTokenHandleHelper struct {
 	IDTokenStrategy OpenIDConnectTokenStrategy
+
+	// NonceStorage makes nonces single-use by rejecting authentication requests with a nonce the client used before.
+	// Nonces are not checked if NonceStorage is nil.
+	NonceStorage NonceStorage
+
+	// NonceLifespan sets for how long used nonces are remembered. It should be at least the lifespan of ID Tokens,
+	// which is also the default.
+	NonceLifespan time.Duration
+
+	// HashStrategy selects the hash function of the "at_hash", "c_hash" and "s_hash" claims. If nil, the hash
+	// function of the handler's JWT strategy is used.
+	HashStrategy IDTokenHashStrategy
 }
 
-func (i *IDTokenHandleHelper) GetAccessTokenHash(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) string {
-	token := responder.GetAccessToken()
+// GetAccessTokenHash returns the "at_hash" claim for the access token of the response. The hash function is selected
+// by HashStrategy and defaults to SHA-256.
+func (i *IDTokenHandleHelper) GetAccessTokenHash(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) (string, error) {
+	return i.hashForIDToken(ctx, requester, nil, responder.GetAccessToken())
+}
 
-	buffer := bytes.NewBufferString(token)
-	hash := sha256.New()
-	// sha256.digest.Write() always returns nil for err, the panic should never happen
-	_, err := hash.Write(buffer.Bytes())
-	if err != nil {
-		panic(err)
+// applyIDTokenLifespan sets the expiry of the ID Token if the client overrides the ID Token lifespan for the grant
+// type, unless the session already defines the expiry.
+func applyIDTokenLifespan(claims *jwt.IDTokenClaims, client fosite.Client, grantType string) {
+	if lifespan := fosite.GetEffectiveLifespan(client, grantType, fosite.IDToken, 0); lifespan > 0 && claims.ExpiresAt.IsZero() {
+		claims.ExpiresAt = time.Now().UTC().Add(lifespan)
 	}
-	hashBuf := bytes.NewBuffer(hash.Sum([]byte{}))
+}
 
-	return base64.RawURLEncoding.EncodeToString(hashBuf.Bytes()[:hashBuf.Len()/2])
+// markNonceUsed rejects the authentication request if NonceStorage is set and the client used the nonce before.
+// Requests without a nonce are not checked.
+func (i *IDTokenHandleHelper) markNonceUsed(ctx context.Context, ar fosite.Requester) error {
+	nonce := ar.GetRequestForm().Get("nonce")
+	if i.NonceStorage == nil || nonce == "" {
+		return nil
+	}
+
+	lifespan := i.NonceLifespan
+	if lifespan == 0 {
+		lifespan = defaultExpiryTime
+	}
+
+	if used, err := i.NonceStorage.MarkNonceUsedForTime(ctx, ar.GetClient().GetID(), nonce, time.Now().UTC().Add(lifespan)); err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	} else if used {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Parameter 'nonce' has already been used in a previous authentication request."))
+	}
+	return nil
 }
 
 func (i *IDTokenHandleHelper) generateIDToken(ctx context.Context, fosr fosite.Requester) (token string, err error) {
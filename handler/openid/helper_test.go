@@ -24,10 +24,12 @@ package openid
 import (
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/internal"
@@ -134,6 +136,27 @@ func TestGetAccessTokenHash(t *testing.T) {
 
 	h := &IDTokenHandleHelper{IDTokenStrategy: strat}
 
-	hash := h.GetAccessTokenHash(nil, req, resp)
+	hash, err := h.GetAccessTokenHash(nil, req, resp)
+	require.NoError(t, err)
 	assert.Equal(t, "Zfn_XBitThuDJiETU3OALQ", hash)
 }
+
+func TestApplyIDTokenLifespan(t *testing.T) {
+	client := &fosite.DefaultClient{
+		TokenLifespans: &fosite.ClientLifespanConfig{
+			GrantTypes: map[string]fosite.TokenLifespans{"implicit": {fosite.IDToken: time.Minute}},
+		},
+	}
+
+	claims := &jwt.IDTokenClaims{}
+	applyIDTokenLifespan(claims, client, "authorization_code")
+	assert.True(t, claims.ExpiresAt.IsZero())
+
+	applyIDTokenLifespan(claims, client, "implicit")
+	assert.WithinDuration(t, time.Now().UTC().Add(time.Minute), claims.ExpiresAt, time.Second)
+
+	expiresAt := time.Now().UTC().Add(time.Hour)
+	claims = &jwt.IDTokenClaims{ExpiresAt: expiresAt}
+	applyIDTokenLifespan(claims, client, "implicit")
+	assert.Equal(t, expiresAt, claims.ExpiresAt)
+}
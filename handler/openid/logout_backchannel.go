@@ -0,0 +1,182 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ory/x/errorsx"
+	"github.com/pborman/uuid"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+)
+
+const (
+	// BackChannelLogoutEvent is the member of the events claim identifying a logout token as defined in
+	// https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+	BackChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+	// LogoutTokenType is the typ header of logout tokens.
+	LogoutTokenType = "logout+jwt"
+)
+
+// LogoutTokenStrategy generates the logout tokens sent to clients using back-channel logout.
+type LogoutTokenStrategy interface {
+	// GenerateLogoutToken returns a logout token for the client, identifying the end-user by the subject, the session
+	// id or both.
+	GenerateLogoutToken(ctx context.Context, client fosite.Client, subject, sessionID string) (string, error)
+}
+
+// DefaultLogoutTokenStrategy signs logout tokens using a JWT strategy.
+type DefaultLogoutTokenStrategy struct {
+	jwt.JWTStrategy
+
+	Issuer string
+
+	// Expiry sets how long logout tokens are valid. Defaults to two minutes.
+	Expiry time.Duration
+}
+
+func (s *DefaultLogoutTokenStrategy) GenerateLogoutToken(ctx context.Context, client fosite.Client, subject, sessionID string) (string, error) {
+	if subject == "" && sessionID == "" {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to generate logout token because neither a subject nor a session id is set."))
+	}
+
+	expiry := s.Expiry
+	if expiry == 0 {
+		expiry = time.Minute * 2
+	}
+
+	now := time.Now().UTC()
+	claims := jwt.MapClaims{
+		"iss":    s.Issuer,
+		"aud":    []string{client.GetID()},
+		"iat":    now.Unix(),
+		"exp":    now.Add(expiry).Unix(),
+		"jti":    uuid.New(),
+		"events": map[string]interface{}{BackChannelLogoutEvent: map[string]interface{}{}},
+	}
+	if subject != "" {
+		claims["sub"] = subject
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+
+	token, _, err := s.Generate(ctx, claims, &logoutTokenHeader{})
+	if err != nil {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+	return token, nil
+}
+
+// logoutTokenHeader sets the typ header of logout tokens, which can not be set using jwt.Headers.
+type logoutTokenHeader struct{}
+
+func (h *logoutTokenHeader) ToMap() map[string]interface{} {
+	return map[string]interface{}{string(jwt.JWTHeaderType): LogoutTokenType}
+}
+
+func (h *logoutTokenHeader) Add(key string, value interface{}) {}
+
+func (h *logoutTokenHeader) Get(key string) interface{} {
+	return h.ToMap()[key]
+}
+
+// LogoutSession is a session of an end-user at a client which is ended.
+type LogoutSession struct {
+	Client    fosite.Client
+	Subject   string
+	SessionID string
+}
+
+// OpenIDConnectBackChannelLogoutHandler notifies clients about ended end-user sessions as defined in
+// https://openid.net/specs/openid-connect-backchannel-1_0.html
+type OpenIDConnectBackChannelLogoutHandler struct {
+	LogoutTokenStrategy LogoutTokenStrategy
+
+	// HTTPClient sends the logout requests. Defaults to a client with a timeout of ten seconds.
+	HTTPClient *http.Client
+}
+
+func (h *OpenIDConnectBackChannelLogoutHandler) httpClient() *http.Client {
+	if h.HTTPClient == nil {
+		return &http.Client{Timeout: time.Second * 10}
+	}
+	return h.HTTPClient
+}
+
+// Logout sends a logout token to the back-channel logout URI of the client of each session. Clients which did not
+// register a back-channel logout URI are skipped. All sessions are processed even if notifying a client fails, in
+// which case the first error is returned.
+func (h *OpenIDConnectBackChannelLogoutHandler) Logout(ctx context.Context, sessions []LogoutSession) error {
+	var firstErr error
+	for _, session := range sessions {
+		if err := h.logout(ctx, session); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h *OpenIDConnectBackChannelLogoutHandler) logout(ctx context.Context, session LogoutSession) error {
+	client, ok := session.Client.(fosite.BackChannelLogoutClient)
+	if !ok || client.GetBackChannelLogoutURI() == "" {
+		return nil
+	}
+
+	if client.GetBackChannelLogoutSessionRequired() && session.SessionID == "" {
+		return errorsx.WithStack(fosite.ErrServerError.WithDebugf("Unable to log out client '%s' because it requires a session id, but none is set.", session.Client.GetID()))
+	}
+
+	token, err := h.LogoutTokenStrategy.GenerateLogoutToken(ctx, session.Client, session.Subject, session.SessionID)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", client.GetBackChannelLogoutURI(), strings.NewReader(url.Values{"logout_token": {token}}.Encode()))
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := h.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("Unable to log out client '%s': %s", session.Client.GetID(), err))
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	// https://openid.net/specs/openid-connect-backchannel-1_0.html#BCResponse
+	//  If the logout succeeded, the RP MUST respond with HTTP 200 OK.
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNoContent {
+		return errorsx.WithStack(fosite.ErrServerError.WithDebugf("Unable to log out client '%s' because the back-channel logout URI responded with status code %d.", session.Client.GetID(), res.StatusCode))
+	}
+	return nil
+}
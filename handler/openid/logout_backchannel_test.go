@@ -0,0 +1,115 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+)
+
+func TestBackChannelLogout(t *testing.T) {
+	signer := &jwt.RS256JWTStrategy{PrivateKey: key}
+	h := &OpenIDConnectBackChannelLogoutHandler{
+		LogoutTokenStrategy: &DefaultLogoutTokenStrategy{JWTStrategy: signer, Issuer: "https://auth.example.com"},
+	}
+
+	var received []*jwt.Token
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		token, err := signer.Decode(context.TODO(), r.PostForm.Get("logout_token"))
+		require.NoError(t, err)
+		received = append(received, token)
+	}))
+	defer ok.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+
+	client := func(id, uri string, sessionRequired bool) fosite.Client {
+		return &fosite.DefaultOpenIDConnectClient{
+			DefaultClient:                    &fosite.DefaultClient{ID: id},
+			BackChannelLogoutURI:             uri,
+			BackChannelLogoutSessionRequired: sessionRequired,
+		}
+	}
+
+	t.Run("case=should send logout tokens", func(t *testing.T) {
+		received = nil
+		require.NoError(t, h.Logout(context.TODO(), []LogoutSession{
+			{Client: client("foo", ok.URL, true), Subject: "peter", SessionID: "session-1"},
+			{Client: client("bar", ok.URL, false), Subject: "peter"},
+			{Client: client("no-uri", "", false), Subject: "peter"},
+			{Client: &fosite.DefaultClient{ID: "not-oidc"}, Subject: "peter"},
+		}))
+
+		require.Len(t, received, 2)
+		assert.Equal(t, LogoutTokenType, received[0].Header["typ"])
+		assert.Equal(t, "https://auth.example.com", received[0].Claims["iss"])
+		assert.Equal(t, []interface{}{"foo"}, received[0].Claims["aud"])
+		assert.Equal(t, "peter", received[0].Claims["sub"])
+		assert.Equal(t, "session-1", received[0].Claims["sid"])
+		assert.Contains(t, received[0].Claims["events"], BackChannelLogoutEvent)
+		assert.NotEmpty(t, received[0].Claims["jti"])
+		assert.NotContains(t, received[0].Claims, "nonce")
+
+		assert.Equal(t, []interface{}{"bar"}, received[1].Claims["aud"])
+		assert.NotContains(t, received[1].Claims, "sid")
+	})
+
+	t.Run("case=should notify all clients but fail", func(t *testing.T) {
+		received = nil
+		err := h.Logout(context.TODO(), []LogoutSession{
+			{Client: client("foo", failing.URL, false), Subject: "peter"},
+			{Client: client("bar", ok.URL, true), Subject: "peter"},
+			{Client: client("baz", ok.URL, false), Subject: "peter"},
+		})
+		assert.EqualError(t, err, fosite.ErrServerError.Error())
+		assert.Len(t, received, 1)
+	})
+
+	t.Run("case=should fail because neither subject nor session is set", func(t *testing.T) {
+		err := h.Logout(context.TODO(), []LogoutSession{{Client: client("foo", ok.URL, false)}})
+		assert.EqualError(t, err, fosite.ErrServerError.Error())
+	})
+}
+
+func TestIDTokenContainsSessionID(t *testing.T) {
+	j := &DefaultStrategy{JWTStrategy: &jwt.RS256JWTStrategy{PrivateKey: key}}
+	session := &DefaultSession{Claims: &jwt.IDTokenClaims{Subject: "peter", SessionID: "session-1"}, Headers: &jwt.Headers{}}
+	assert.Equal(t, "session-1", session.GetSessionID())
+
+	token, err := j.GenerateIDToken(context.TODO(), fosite.NewAccessRequest(session))
+	require.NoError(t, err)
+	decoded, err := j.Decode(context.TODO(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "session-1", decoded.Claims["sid"])
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"net/url"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+)
+
+// FrontChannelLogoutURIs returns the front-channel logout URIs of the clients of the sessions, which the logout page
+// of the OpenID provider renders in iframes as defined in https://openid.net/specs/openid-connect-frontchannel-1_0.html
+// Clients which did not register a front-channel logout URI are skipped. If a client requires the session, the iss
+// and sid query parameters are added to its logout URI.
+//
+// The scheme, host and port of the logout URI must match one of the client's redirect URIs.
+func FrontChannelLogoutURIs(issuer string, sessions []LogoutSession) ([]string, error) {
+	var uris []string
+	seen := map[string]bool{}
+	for _, session := range sessions {
+		client, ok := session.Client.(fosite.FrontChannelLogoutClient)
+		if !ok || client.GetFrontChannelLogoutURI() == "" {
+			continue
+		}
+
+		uri, err := frontChannelLogoutURI(issuer, session, client)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[uri] {
+			seen[uri] = true
+			uris = append(uris, uri)
+		}
+	}
+	return uris, nil
+}
+
+func frontChannelLogoutURI(issuer string, session LogoutSession, client fosite.FrontChannelLogoutClient) (string, error) {
+	u, err := url.Parse(client.GetFrontChannelLogoutURI())
+	if err != nil {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("The front-channel logout URI of client '%s' is malformed: %s", session.Client.GetID(), err))
+	} else if !u.IsAbs() || u.Fragment != "" {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithDebugf("The front-channel logout URI of client '%s' must be an absolute URI without a fragment.", session.Client.GetID()))
+	} else if !matchesRedirectURIOrigin(u, session.Client.GetRedirectURIs()) {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithDebugf("The front-channel logout URI of client '%s' does not match the scheme, host and port of a registered redirect URI.", session.Client.GetID()))
+	}
+
+	if client.GetFrontChannelLogoutSessionRequired() {
+		if session.SessionID == "" {
+			return "", errorsx.WithStack(fosite.ErrServerError.WithDebugf("Unable to log out client '%s' because it requires a session id, but none is set.", session.Client.GetID()))
+		}
+
+		query := u.Query()
+		query.Set("iss", issuer)
+		query.Set("sid", session.SessionID)
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String(), nil
+}
+
+func matchesRedirectURIOrigin(u *url.URL, redirectURIs []string) bool {
+	for _, raw := range redirectURIs {
+		redirectURI, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+
+		if redirectURI.Scheme == u.Scheme && redirectURI.Host == u.Host {
+			return true
+		}
+	}
+	return false
+}
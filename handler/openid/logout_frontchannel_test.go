@@ -0,0 +1,101 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+)
+
+func TestFrontChannelLogoutURIs(t *testing.T) {
+	client := func(id, uri string, sessionRequired bool) fosite.Client {
+		return &fosite.DefaultOpenIDConnectClient{
+			DefaultClient:                     &fosite.DefaultClient{ID: id, RedirectURIs: []string{"https://rp.example.com/callback"}},
+			FrontChannelLogoutURI:             uri,
+			FrontChannelLogoutSessionRequired: sessionRequired,
+		}
+	}
+
+	for k, c := range []struct {
+		d         string
+		sessions  []LogoutSession
+		expect    []string
+		expectErr bool
+	}{
+		{
+			d: "should return the logout URIs and add iss and sid if required",
+			sessions: []LogoutSession{
+				{Client: client("foo", "https://rp.example.com/logout?foo=bar", true), SessionID: "session-1"},
+				{Client: client("bar", "https://rp.example.com/other-logout", false), SessionID: "session-1"},
+				{Client: client("no-uri", "", true), SessionID: "session-1"},
+				{Client: &fosite.DefaultClient{ID: "not-oidc"}, SessionID: "session-1"},
+			},
+			expect: []string{
+				"https://rp.example.com/logout?foo=bar&iss=https%3A%2F%2Fop.example.com&sid=session-1",
+				"https://rp.example.com/other-logout",
+			},
+		},
+		{
+			d: "should only return each logout URI once",
+			sessions: []LogoutSession{
+				{Client: client("bar", "https://rp.example.com/logout", false)},
+				{Client: client("bar", "https://rp.example.com/logout", false)},
+			},
+			expect: []string{"https://rp.example.com/logout"},
+		},
+		{
+			d:         "should fail because the session is required",
+			sessions:  []LogoutSession{{Client: client("foo", "https://rp.example.com/logout", true)}},
+			expectErr: true,
+		},
+		{
+			d:         "should fail because the logout URI does not match a redirect URI",
+			sessions:  []LogoutSession{{Client: client("foo", "https://evil.example.com/logout", false)}},
+			expectErr: true,
+		},
+		{
+			d:         "should fail because the logout URI has a fragment",
+			sessions:  []LogoutSession{{Client: client("foo", "https://rp.example.com/logout#foo", false)}},
+			expectErr: true,
+		},
+		{
+			d:         "should fail because the logout URI is relative",
+			sessions:  []LogoutSession{{Client: client("foo", "/logout", false)}},
+			expectErr: true,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.d), func(t *testing.T) {
+			uris, err := FrontChannelLogoutURIs("https://op.example.com", c.sessions)
+			if c.expectErr {
+				assert.EqualError(t, err, fosite.ErrServerError.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expect, uris)
+		})
+	}
+}
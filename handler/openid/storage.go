@@ -23,8 +23,10 @@ package openid
 
 import (
 	"context"
+	"time"
 
 	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
 )
 
 var ErrNoSessionFound = fosite.ErrNotFound
@@ -43,3 +45,38 @@ type OpenIDConnectRequestStorage interface {
 	// DeleteOpenIDConnectSession removes an open id connect session from the store.
 	DeleteOpenIDConnectSession(ctx context.Context, authorizeCode string) error
 }
+
+// NonceStorage keeps track of the nonces of OpenID Connect authentication requests to reject replayed requests.
+type NonceStorage interface {
+	// MarkNonceUsedForTime marks the nonce the client sent with an authentication request as used until exp and
+	// returns true if the nonce had already been marked as used by the client and has not expired yet. The check and
+	// the update must happen atomically. Implementations should delete nonces once they expire.
+	MarkNonceUsedForTime(ctx context.Context, clientID, nonce string, exp time.Time) (used bool, err error)
+}
+
+// CIBAStorage stores the requests created at the OpenID Connect Client-Initiated Backchannel Authentication endpoint.
+type CIBAStorage interface {
+	// CreateBackchannelAuthRequestSession stores the backchannel authentication request.
+	CreateBackchannelAuthRequestSession(ctx context.Context, authReqIDSignature string, request fosite.Requester) (err error)
+
+	// GetBackchannelAuthRequestSession hydrates the session based on the given request ID signature and returns the
+	// backchannel authentication request. If the request ID has been invalidated with
+	// `InvalidateBackchannelAuthRequestSession` this method MUST return the ErrInvalidatedAuthReqID error together
+	// with the request.
+	GetBackchannelAuthRequestSession(ctx context.Context, authReqIDSignature string, session fosite.Session) (request fosite.Requester, err error)
+
+	// UpdateBackchannelAuthRequestSession replaces the stored backchannel authentication request, for example once
+	// the end-user approved the request or when the client has polled the token endpoint.
+	UpdateBackchannelAuthRequestSession(ctx context.Context, authReqIDSignature string, request fosite.Requester) (err error)
+
+	// InvalidateBackchannelAuthRequestSession is called when a request ID is being used. Consecutive requests to
+	// GetBackchannelAuthRequestSession should return the ErrInvalidatedAuthReqID error.
+	InvalidateBackchannelAuthRequestSession(ctx context.Context, authReqIDSignature string) (err error)
+}
+
+// CIBACoreStorage is the storage needed by the OpenID Connect Client-Initiated Backchannel Authentication handler.
+type CIBACoreStorage interface {
+	CIBAStorage
+	oauth2.AccessTokenStorage
+	oauth2.RefreshTokenStorage
+}
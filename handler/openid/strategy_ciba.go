@@ -0,0 +1,67 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	enigma "github.com/ory/fosite/token/hmac"
+)
+
+// AuthReqIDStrategy generates and validates the request IDs of OpenID Connect Client-Initiated Backchannel
+// Authentication.
+type AuthReqIDStrategy interface {
+	AuthReqIDSignature(id string) string
+	GenerateAuthReqID(ctx context.Context, requester fosite.Requester) (id string, signature string, err error)
+	ValidateAuthReqID(ctx context.Context, requester fosite.Requester, id string) (err error)
+}
+
+// DefaultAuthReqIDStrategy generates HMAC signed backchannel authentication request IDs.
+type DefaultAuthReqIDStrategy struct {
+	Enigma *enigma.HMACStrategy
+
+	// AuthReqIDLifespan is used to validate request IDs whose session has no expiry set.
+	AuthReqIDLifespan time.Duration
+}
+
+func (h *DefaultAuthReqIDStrategy) AuthReqIDSignature(id string) string {
+	return h.Enigma.Signature(id)
+}
+
+func (h *DefaultAuthReqIDStrategy) GenerateAuthReqID(_ context.Context, _ fosite.Requester) (id string, signature string, err error) {
+	return h.Enigma.Generate()
+}
+
+func (h *DefaultAuthReqIDStrategy) ValidateAuthReqID(_ context.Context, r fosite.Requester, id string) (err error) {
+	exp := r.GetSession().GetExpiresAt(fosite.AuthReqID)
+	if exp.IsZero() {
+		exp = r.GetRequestedAt().Add(h.AuthReqIDLifespan)
+	}
+	if exp.Before(time.Now().UTC()) {
+		return errorsx.WithStack(fosite.ErrExpiredToken.WithHintf("The auth_req_id expired at '%s'.", exp))
+	}
+	return h.Enigma.Validate(id)
+}
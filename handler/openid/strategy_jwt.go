@@ -23,7 +23,7 @@ package openid
 
 import (
 	"context"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ory/x/errorsx"
@@ -45,6 +45,19 @@ type Session interface {
 	// IDTokenHeaders returns a pointer to header values which will be modified in-place by handlers.
 	// Session should store this pointer and return always the same pointer.
 	IDTokenHeaders() *jwt.Headers
+	// GetAuthTime returns the time when the end-user authenticated.
+	GetAuthTime() time.Time
+	// GetRequestedAt returns the time when the authorization request was made.
+	GetRequestedAt() time.Time
+	// GetACR returns the authentication context class reference (acr) the end-user was authenticated with.
+	GetACR() string
+	// SetACR sets the authentication context class reference (acr) the end-user was authenticated with.
+	SetACR(acr string)
+	// GetAMR returns the authentication methods references (amr) the end-user was authenticated with.
+	GetAMR() []string
+	// SetAMR sets the authentication methods references (amr) the end-user was authenticated with, for example
+	// "pwd" and "otp".
+	SetAMR(amr []string)
 
 	fosite.Session
 }
@@ -56,6 +69,9 @@ type DefaultSession struct {
 	ExpiresAt map[fosite.TokenType]time.Time
 	Username  string
 	Subject   string
+
+	GrantedAt         time.Time
+	RefreshChainDepth int
 }
 
 func NewDefaultSession() *DefaultSession {
@@ -112,6 +128,22 @@ func (s *DefaultSession) GetSubject() string {
 	return s.Subject
 }
 
+func (s *DefaultSession) GetGrantedAt() time.Time {
+	return s.GrantedAt
+}
+
+func (s *DefaultSession) SetGrantedAt(t time.Time) {
+	s.GrantedAt = t
+}
+
+func (s *DefaultSession) GetRefreshChainDepth() int {
+	return s.RefreshChainDepth
+}
+
+func (s *DefaultSession) SetRefreshChainDepth(depth int) {
+	s.RefreshChainDepth = depth
+}
+
 func (s *DefaultSession) IDTokenHeaders() *jwt.Headers {
 	if s.Headers == nil {
 		s.Headers = &jwt.Headers{}
@@ -126,6 +158,36 @@ func (s *DefaultSession) IDTokenClaims() *jwt.IDTokenClaims {
 	return s.Claims
 }
 
+func (s *DefaultSession) GetAuthTime() time.Time {
+	return s.IDTokenClaims().AuthTime
+}
+
+func (s *DefaultSession) GetRequestedAt() time.Time {
+	return s.IDTokenClaims().RequestedAt
+}
+
+func (s *DefaultSession) GetACR() string {
+	return s.IDTokenClaims().AuthenticationContextClassReference
+}
+
+func (s *DefaultSession) SetACR(acr string) {
+	s.IDTokenClaims().AuthenticationContextClassReference = acr
+}
+
+func (s *DefaultSession) GetAMR() []string {
+	return s.IDTokenClaims().AuthenticationMethodsReferences
+}
+
+func (s *DefaultSession) SetAMR(amr []string) {
+	s.IDTokenClaims().AuthenticationMethodsReferences = amr
+}
+
+// GetSessionID returns the sid of the end-user's session at the OpenID provider, which is embedded in id tokens and
+// logout tokens.
+func (s *DefaultSession) GetSessionID() string {
+	return s.IDTokenClaims().SessionID
+}
+
 type DefaultStrategy struct {
 	jwt.JWTStrategy
 
@@ -133,6 +195,95 @@ type DefaultStrategy struct {
 	Issuer string
 
 	MinParameterEntropy int
+
+	// IDTokenSigners maps JWS algorithms to the strategies used for signing ID tokens of clients which registered the
+	// respective id_token_signed_response_alg. All other ID tokens are signed using JWTStrategy.
+	IDTokenSigners map[string]jwt.JWTStrategy
+
+	// ClaimsRequestStrategy decides which of the claims requested using the claims parameter are added to the id token.
+	// If nil, no requested claims are added.
+	ClaimsRequestStrategy ClaimsRequestStrategy
+
+	// RejectUnfulfilledEssentialClaims makes id token generation fail if an essential claim requested using the claims
+	// parameter can not be fulfilled. By default such claims are omitted from the id token.
+	RejectUnfulfilledEssentialClaims bool
+
+	// RejectUnsatisfiedACRValues makes id token generation fail with login_required if the acr of the session is not
+	// one of the acr_values requested by the client. By default the id token is issued with the acr of the session, or
+	// "0" if the session has no acr.
+	RejectUnsatisfiedACRValues bool
+
+	// ACRStrategy decides whether the acr of the session satisfies the requested acr values. Defaults to
+	// ExactACRStrategy.
+	ACRStrategy ACRStrategy
+}
+
+func (h DefaultStrategy) acrStrategy() ACRStrategy {
+	if h.ACRStrategy == nil {
+		return &ExactACRStrategy{}
+	}
+	return h.ACRStrategy
+}
+
+func (h DefaultStrategy) getIDTokenSigner(client fosite.Client) (jwt.JWTStrategy, error) {
+	c, ok := client.(fosite.IDTokenSigningClient)
+	if !ok || c.GetIDTokenSignedResponseAlg() == "" {
+		return h.JWTStrategy, nil
+	}
+
+	alg := c.GetIDTokenSignedResponseAlg()
+	if signer, ok := h.IDTokenSigners[alg]; ok {
+		return signer, nil
+	} else if p, ok := h.JWTStrategy.(jwt.SigningAlgorithmProvider); ok && p.GetSigningAlgorithm() == alg {
+		return h.JWTStrategy, nil
+	}
+
+	return nil, errorsx.WithStack(fosite.ErrServerError.WithDebugf("Failed to generate id token because no signer is configured for the client's id_token_signed_response_alg '%s'.", alg))
+}
+
+func (h DefaultStrategy) handleClaimsRequest(ctx context.Context, requester fosite.Requester, claims *jwt.IDTokenClaims) error {
+	claimsRequest, err := GetClaimsRequest(requester)
+	if err != nil {
+		return err
+	}
+
+	requested := claimsRequest.Get(ClaimsRequestTargetIDToken)
+	if len(requested) == 0 {
+		return nil
+	}
+
+	// https://openid.net/specs/openid-connect-core-1_0.html#acrSemantics
+	//  If this is an Essential Claim and the requirement cannot be met, then the Authorization Server MUST treat that
+	//  outcome as a failed authentication attempt.
+	if values := claimsRequest.EssentialACRValues(); len(values) > 0 && !h.acrStrategy().IsACRSatisfied(ctx, requester, claims.AuthenticationContextClassReference, values) {
+		return errorsx.WithStack(fosite.ErrLoginRequired.WithHintf("The end-user must be authenticated using one of the authentication context class references %v, which were requested as an essential claim.", values).WithWrap(ErrStepUpRequired))
+	}
+
+	if h.ClaimsRequestStrategy != nil {
+		values, err := h.ClaimsRequestStrategy.HandleClaimsRequest(ctx, requester, ClaimsRequestTargetIDToken, requested)
+		if err != nil {
+			return err
+		}
+
+		for name, value := range values {
+			if _, ok := requested[name]; ok {
+				claims.Add(name, value)
+			}
+		}
+	}
+
+	if !h.RejectUnfulfilledEssentialClaims {
+		return nil
+	}
+
+	fulfilled := claims.ToMap()
+	for name, c := range requested {
+		if value, ok := fulfilled[name]; c.IsEssential() && (!ok || !c.Matches(value)) {
+			return errorsx.WithStack(fosite.ErrAccessDenied.WithHintf("The essential claim '%s' which was requested using the 'claims' parameter can not be fulfilled.", name))
+		}
+	}
+
+	return nil
 }
 
 func (h DefaultStrategy) GenerateIDToken(ctx context.Context, requester fosite.Requester) (token string, err error) {
@@ -145,16 +296,18 @@ func (h DefaultStrategy) GenerateIDToken(ctx context.Context, requester fosite.R
 		return "", errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because session must be of type fosite/handler/openid.Session."))
 	}
 
+	signer, err := h.getIDTokenSigner(requester.GetClient())
+	if err != nil {
+		return "", err
+	}
+
 	claims := sess.IDTokenClaims()
 	if claims.Subject == "" {
 		return "", errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to generate id token because subject is an empty string."))
 	}
 
 	if requester.GetRequestForm().Get("grant_type") != "refresh_token" {
-		maxAge, err := strconv.ParseInt(requester.GetRequestForm().Get("max_age"), 10, 64)
-		if err != nil {
-			maxAge = 0
-		}
+		maxAge := getMaxAge(requester)
 
 		// Adds a bit of wiggle room for timing issues
 		if claims.AuthTime.After(time.Now().UTC().Add(time.Second * 5)) {
@@ -191,14 +344,24 @@ func (h DefaultStrategy) GenerateIDToken(ctx context.Context, requester fosite.R
 			}
 		}
 
-		// If acr_values was requested but no acr value was provided in the ID token, fall back to level 0 which means least
-		// confidence in authentication.
-		if requester.GetRequestForm().Get("acr_values") != "" && claims.AuthenticationContextClassReference == "" {
-			claims.AuthenticationContextClassReference = "0"
+		if acrValues := requester.GetRequestForm().Get("acr_values"); acrValues != "" {
+			if !h.acrStrategy().IsACRSatisfied(ctx, requester, claims.AuthenticationContextClassReference, fosite.RemoveEmpty(strings.Split(acrValues, " "))) && h.RejectUnsatisfiedACRValues {
+				return "", errorsx.WithStack(fosite.ErrLoginRequired.WithHintf("The end-user must be authenticated using one of the requested authentication context class references '%s'.", acrValues).WithWrap(ErrStepUpRequired))
+			}
+
+			// If acr_values was requested but no acr value was provided in the ID token, fall back to level 0 which
+			// means least confidence in authentication.
+			if claims.AuthenticationContextClassReference == "" {
+				claims.AuthenticationContextClassReference = "0"
+			}
+		}
+
+		if err := h.handleClaimsRequest(ctx, requester, claims); err != nil {
+			return "", err
 		}
 
 		if tokenHintString := requester.GetRequestForm().Get("id_token_hint"); tokenHintString != "" {
-			tokenHint, err := h.JWTStrategy.Decode(ctx, tokenHintString)
+			tokenHint, err := signer.Decode(ctx, tokenHintString)
 			var ve *jwt.ValidationError
 			if errors.As(err, &ve) && ve.Errors == jwt.ValidationErrorExpired {
 				// Expired ID Tokens are allowed as values to id_token_hint
@@ -210,6 +373,8 @@ func (h DefaultStrategy) GenerateIDToken(ctx context.Context, requester fosite.R
 				return "", errorsx.WithStack(fosite.ErrServerError.WithDebug("Provided id token from 'id_token_hint' does not have a subject."))
 			} else if hintSub != claims.Subject {
 				return "", errorsx.WithStack(fosite.ErrServerError.WithDebug("Subject from authorization mismatches id token subject from 'id_token_hint'."))
+			} else if h.Issuer != "" && !tokenHint.Claims.VerifyIssuer(h.Issuer, true) {
+				return "", errorsx.WithStack(fosite.ErrServerError.WithDebug("Provided id token from 'id_token_hint' was not issued by this authorization server."))
 			}
 		}
 	}
@@ -242,6 +407,6 @@ func (h DefaultStrategy) GenerateIDToken(ctx context.Context, requester fosite.R
 	claims.Audience = stringslice.Unique(append(claims.Audience, requester.GetClient().GetID()))
 	claims.IssuedAt = time.Now().UTC()
 
-	token, _, err = h.JWTStrategy.Generate(ctx, claims.ToMapClaims(), sess.IDTokenHeaders())
+	token, _, err = signer.Generate(ctx, claims.ToMapClaims(), sess.IDTokenHeaders())
 	return token, err
 }
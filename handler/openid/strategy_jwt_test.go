@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ory/fosite"
 	"github.com/ory/fosite/token/jwt"
@@ -298,3 +299,219 @@ func TestJWTStrategy_GenerateIDToken(t *testing.T) {
 		})
 	}
 }
+
+func TestJWTStrategy_GenerateIDTokenWithClientSigningAlgorithm(t *testing.T) {
+	ps256 := &jwt.RSAPSSJWTStrategy{PrivateKey: key}
+	var j = &DefaultStrategy{
+		JWTStrategy: &jwt.RS256JWTStrategy{
+			PrivateKey: key,
+		},
+		MinParameterEntropy: fosite.MinParameterEntropy,
+		IDTokenSigners:      map[string]jwt.JWTStrategy{"PS256": ps256},
+	}
+
+	for k, c := range []struct {
+		alg       string
+		expectAlg string
+		expectErr bool
+	}{
+		{alg: "", expectAlg: "RS256"},
+		{alg: "RS256", expectAlg: "RS256"},
+		{alg: "PS256", expectAlg: "PS256"},
+		{alg: "ES256", expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			req := fosite.NewAccessRequest(&DefaultSession{
+				Claims: &jwt.IDTokenClaims{
+					Subject: "peter",
+				},
+				Headers: &jwt.Headers{},
+			})
+			req.Client = &fosite.DefaultOpenIDConnectClient{
+				DefaultClient:            &fosite.DefaultClient{ID: "foo"},
+				IDTokenSignedResponseAlg: c.alg,
+			}
+
+			token, err := j.GenerateIDToken(context.TODO(), req)
+			if c.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			decoded, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+			assert.NoError(t, err)
+			assert.EqualValues(t, c.expectAlg, decoded.Method)
+		})
+	}
+}
+
+type staticClaimsRequestStrategy map[string]interface{}
+
+func (s staticClaimsRequestStrategy) HandleClaimsRequest(_ context.Context, _ fosite.Requester, _ ClaimsRequestTarget, _ map[string]*ClaimRequest) (map[string]interface{}, error) {
+	return s, nil
+}
+
+func TestJWTStrategy_GenerateIDTokenWithACRAndAMR(t *testing.T) {
+	for k, c := range []struct {
+		description string
+		acr         string
+		acrValues   string
+		reject      bool
+		expectErr   error
+		expectACR   interface{}
+	}{
+		{
+			description: "should include acr and amr of the session",
+			acr:         "urn:mace:incommon:iap:silver",
+			expectACR:   "urn:mace:incommon:iap:silver",
+		},
+		{
+			description: "should fall back to acr 0 because acr_values was requested and the session has no acr",
+			acrValues:   "urn:mace:incommon:iap:silver",
+			expectACR:   "0",
+		},
+		{
+			description: "should include the acr of the session although it does not satisfy acr_values by default",
+			acr:         "urn:mace:incommon:iap:bronze",
+			acrValues:   "urn:mace:incommon:iap:silver urn:mace:incommon:iap:gold",
+			expectACR:   "urn:mace:incommon:iap:bronze",
+		},
+		{
+			description: "should pass because the acr of the session satisfies acr_values",
+			acr:         "urn:mace:incommon:iap:gold",
+			acrValues:   "urn:mace:incommon:iap:silver urn:mace:incommon:iap:gold",
+			reject:      true,
+			expectACR:   "urn:mace:incommon:iap:gold",
+		},
+		{
+			description: "should fail because the acr of the session does not satisfy acr_values",
+			acr:         "urn:mace:incommon:iap:bronze",
+			acrValues:   "urn:mace:incommon:iap:silver urn:mace:incommon:iap:gold",
+			reject:      true,
+			expectErr:   fosite.ErrLoginRequired,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			j := &DefaultStrategy{
+				JWTStrategy: &jwt.RS256JWTStrategy{
+					PrivateKey: key,
+				},
+				RejectUnsatisfiedACRValues: c.reject,
+			}
+
+			sess := NewDefaultSession()
+			sess.Claims.Subject = "peter"
+			sess.SetACR(c.acr)
+			sess.SetAMR([]string{"pwd", "otp"})
+			req := fosite.NewAccessRequest(sess)
+			if c.acrValues != "" {
+				req.Form.Set("acr_values", c.acrValues)
+			}
+
+			token, err := j.GenerateIDToken(context.TODO(), req)
+			if c.expectErr != nil {
+				assert.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			decoded, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+			require.NoError(t, err)
+			assert.Equal(t, c.expectACR, decoded.Claims["acr"])
+			assert.Equal(t, []interface{}{"pwd", "otp"}, decoded.Claims["amr"])
+		})
+	}
+}
+
+func TestJWTStrategy_GenerateIDTokenWithClaimsRequest(t *testing.T) {
+	for k, c := range []struct {
+		description string
+		claims      string
+		acr         string
+		reject      bool
+		expectErr   error
+		expect      map[string]interface{}
+		notExpect   []string
+	}{
+		{
+			description: "should add the requested claims which are honored",
+			claims:      `{"id_token":{"email":{"essential":true},"name":null},"userinfo":{"phone_number":null}}`,
+			expect:      map[string]interface{}{"email": "peter@example.com"},
+			notExpect:   []string{"name", "phone_number", "website"},
+		},
+		{
+			description: "should omit unfulfilled essential claims by default",
+			claims:      `{"id_token":{"name":{"essential":true}}}`,
+			notExpect:   []string{"name"},
+		},
+		{
+			description: "should fail because an essential claim can not be fulfilled",
+			claims:      `{"id_token":{"name":{"essential":true}}}`,
+			reject:      true,
+			expectErr:   fosite.ErrAccessDenied,
+		},
+		{
+			description: "should fail because an essential claim does not match the requested value",
+			claims:      `{"id_token":{"email":{"essential":true,"value":"bob@example.com"}}}`,
+			reject:      true,
+			expectErr:   fosite.ErrAccessDenied,
+		},
+		{
+			description: "should pass because the essential acr is satisfied",
+			claims:      `{"id_token":{"acr":{"essential":true,"values":["urn:mace:incommon:iap:silver","urn:mace:incommon:iap:gold"]}}}`,
+			acr:         "urn:mace:incommon:iap:gold",
+			reject:      true,
+			expect:      map[string]interface{}{"acr": "urn:mace:incommon:iap:gold"},
+		},
+		{
+			description: "should require step-up authentication because the essential acr is not satisfied",
+			claims:      `{"id_token":{"acr":{"essential":true,"value":"urn:mace:incommon:iap:gold"}}}`,
+			acr:         "urn:mace:incommon:iap:bronze",
+			expectErr:   fosite.ErrLoginRequired,
+		},
+		{
+			description: "should fail because the claims parameter is malformed",
+			claims:      `{"id_token":`,
+			expectErr:   fosite.ErrInvalidRequest,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			j := &DefaultStrategy{
+				JWTStrategy: &jwt.RS256JWTStrategy{
+					PrivateKey: key,
+				},
+				ClaimsRequestStrategy: staticClaimsRequestStrategy{
+					"email":   "peter@example.com",
+					"website": "https://example.com",
+				},
+				RejectUnfulfilledEssentialClaims: c.reject,
+			}
+
+			req := fosite.NewAccessRequest(&DefaultSession{
+				Claims: &jwt.IDTokenClaims{
+					Subject:                             "peter",
+					AuthenticationContextClassReference: c.acr,
+				},
+				Headers: &jwt.Headers{},
+			})
+			req.Form.Set("claims", c.claims)
+
+			token, err := j.GenerateIDToken(context.TODO(), req)
+			if c.expectErr != nil {
+				assert.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			decoded, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+			assert.NoError(t, err)
+			for name, value := range c.expect {
+				assert.Equal(t, value, decoded.Claims[name])
+			}
+			for _, name := range c.notExpect {
+				assert.NotContains(t, decoded.Claims, name)
+			}
+		})
+	}
+}
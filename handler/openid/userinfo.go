@@ -0,0 +1,193 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/ory/x/errorsx"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/jwt"
+)
+
+// OpenIDConnectUserinfoHandler writes userinfo responses as defined in
+// https://openid.net/specs/openid-connect-core-1_0.html#UserInfoResponse
+//
+// Userinfo responses are JSON, unless the client registered userinfo_signed_response_alg or
+// userinfo_encrypted_response_alg. Clients which registered neither receive a JWT signed with DefaultSigningAlgorithm
+// if they ask for "application/jwt" using the Accept header. Signed responses contain the "iss" and "aud" claims.
+type OpenIDConnectUserinfoHandler struct {
+	// Signers sign userinfo responses, keyed by the JWS algorithm.
+	Signers map[string]jwt.JWTStrategy
+
+	// DefaultSigningAlgorithm signs userinfo responses of clients which accept "application/jwt" but did not register
+	// userinfo_signed_response_alg. If empty, these clients receive JSON.
+	DefaultSigningAlgorithm string
+
+	// Issuer sets the "iss" claim of signed userinfo responses.
+	Issuer string
+
+	// JWKSFetcherStrategy fetches the encryption keys of clients which registered a jwks_uri.
+	JWKSFetcherStrategy fosite.JWKSFetcherStrategy
+}
+
+// NewUserinfoResponse encodes the userinfo claims for the client and returns the content type and the body of the
+// userinfo response.
+func (h *OpenIDConnectUserinfoHandler) NewUserinfoResponse(ctx context.Context, r *http.Request, client fosite.Client, claims map[string]interface{}) (contentType string, body []byte, err error) {
+	var alg, keyAlg, enc string
+	if c, ok := client.(fosite.UserinfoResponseClient); ok {
+		alg, keyAlg, enc = c.GetUserinfoSignedResponseAlg(), c.GetUserinfoEncryptedResponseAlg(), c.GetUserinfoEncryptedResponseEnc()
+	}
+	if alg == "" && keyAlg == "" && acceptsJWT(r) {
+		alg = h.DefaultSigningAlgorithm
+	}
+
+	if alg == "" {
+		body, err = json.Marshal(claims)
+		if err != nil {
+			return "", nil, errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+	} else {
+		token, err := h.sign(ctx, client, alg, claims)
+		if err != nil {
+			return "", nil, err
+		}
+		body = []byte(token)
+	}
+
+	if keyAlg != "" {
+		token, err := h.encrypt(client, keyAlg, enc, body, alg != "")
+		if err != nil {
+			return "", nil, err
+		}
+		return "application/jwt", []byte(token), nil
+	} else if alg != "" {
+		return "application/jwt", body, nil
+	}
+	return "application/json;charset=UTF-8", body, nil
+}
+
+// WriteUserinfoResponse writes the userinfo response for the client. If the response can not be encoded, the error
+// is returned and nothing is written.
+func (h *OpenIDConnectUserinfoHandler) WriteUserinfoResponse(ctx context.Context, rw http.ResponseWriter, r *http.Request, client fosite.Client, claims map[string]interface{}) error {
+	contentType, body, err := h.NewUserinfoResponse(ctx, r, client, claims)
+	if err != nil {
+		return err
+	}
+
+	rw.Header().Set("Content-Type", contentType)
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(body)
+	return nil
+}
+
+func (h *OpenIDConnectUserinfoHandler) sign(ctx context.Context, client fosite.Client, alg string, claims map[string]interface{}) (string, error) {
+	signer, ok := h.Signers[alg]
+	if !ok {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithDebugf("No signer is configured for the userinfo signing algorithm '%s'.", alg))
+	}
+
+	mapClaims := jwt.MapClaims(jwt.Copy(claims))
+	if h.Issuer != "" {
+		mapClaims["iss"] = h.Issuer
+	}
+	mapClaims["aud"] = client.GetID()
+
+	token, _, err := signer.Generate(ctx, mapClaims, &jwt.Headers{})
+	if err != nil {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+	return token, nil
+}
+
+func (h *OpenIDConnectUserinfoHandler) encrypt(client fosite.Client, keyAlg, enc string, payload []byte, nested bool) (string, error) {
+	if enc == "" {
+		enc = string(jose.A128CBC_HS256)
+	}
+
+	key, err := h.findEncryptionKey(client, keyAlg)
+	if err != nil {
+		return "", err
+	}
+
+	opts := &jose.EncrypterOptions{}
+	if nested {
+		opts = opts.WithContentType("JWT")
+	}
+
+	encrypter, err := jose.NewEncrypter(jose.ContentEncryption(enc), jose.Recipient{Algorithm: jose.KeyAlgorithm(keyAlg), Key: key.Key, KeyID: key.KeyID}, opts)
+	if err != nil {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	encrypted, err := encrypter.Encrypt(payload)
+	if err != nil {
+		return "", errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+	return encrypted.CompactSerialize()
+}
+
+func (h *OpenIDConnectUserinfoHandler) findEncryptionKey(client fosite.Client, keyAlg string) (*jose.JSONWebKey, error) {
+	c, ok := client.(fosite.OpenIDConnectClient)
+	if !ok {
+		return nil, errorsx.WithStack(fosite.ErrServerError.WithDebug("The client registered userinfo_encrypted_response_alg, but is not an OpenID Connect client with JSON Web Keys."))
+	}
+
+	set := c.GetJSONWebKeys()
+	if set == nil && c.GetJSONWebKeysURI() != "" && h.JWKSFetcherStrategy != nil {
+		var err error
+		if set, err = h.JWKSFetcherStrategy.Resolve(c.GetJSONWebKeysURI(), false); err != nil {
+			return nil, err
+		}
+	}
+
+	if set != nil {
+		for _, key := range set.Keys {
+			if (key.Use == "" || key.Use == "enc") && (key.Algorithm == "" || key.Algorithm == keyAlg) {
+				return &key, nil
+			}
+		}
+	}
+	return nil, errorsx.WithStack(fosite.ErrServerError.WithDebugf("The client has no JSON Web Key registered for encrypting userinfo responses using '%s'.", keyAlg))
+}
+
+// acceptsJWT returns true if the request accepts "application/jwt" responses.
+func acceptsJWT(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == "application/jwt" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package openid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/token/jwt"
+)
+
+func TestOpenIDConnectUserinfoHandler_WriteUserinfoResponse(t *testing.T) {
+	encryptionKey := internal.MustRSAKey()
+	h := &OpenIDConnectUserinfoHandler{
+		Signers: map[string]jwt.JWTStrategy{
+			"RS256": &jwt.RS256JWTStrategy{PrivateKey: key},
+		},
+		Issuer: "https://auth.example.com",
+	}
+	claims := map[string]interface{}{"sub": "peter", "email": "peter@example.com"}
+
+	client := func(alg, keyAlg string) fosite.Client {
+		return &fosite.DefaultOpenIDConnectClient{
+			DefaultClient:                &fosite.DefaultClient{ID: "foo"},
+			UserinfoSignedResponseAlg:    alg,
+			UserinfoEncryptedResponseAlg: keyAlg,
+			JSONWebKeys: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{Key: &key.PublicKey, Use: "sig", KeyID: "sig"},
+				{Key: &encryptionKey.PublicKey, Use: "enc", KeyID: "enc"},
+			}},
+		}
+	}
+
+	verify := func(t *testing.T, token string) {
+		decoded, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+		require.NoError(t, err)
+		assert.Equal(t, "peter", decoded.Claims["sub"])
+		assert.Equal(t, "peter@example.com", decoded.Claims["email"])
+		assert.Equal(t, "https://auth.example.com", decoded.Claims["iss"])
+		assert.Equal(t, "foo", decoded.Claims["aud"])
+	}
+
+	decrypt := func(t *testing.T, token string) []byte {
+		encrypted, err := jose.ParseEncrypted(token)
+		require.NoError(t, err)
+		assert.Equal(t, "enc", encrypted.Header.KeyID)
+		payload, err := encrypted.Decrypt(encryptionKey)
+		require.NoError(t, err)
+		return payload
+	}
+
+	for k, tc := range []struct {
+		d                 string
+		client            fosite.Client
+		accept            string
+		defaultAlg        string
+		expectErr         error
+		expectContentType string
+		check             func(t *testing.T, body []byte)
+	}{
+		{
+			d:                 "should return JSON because the client registered no algorithms",
+			client:            &fosite.DefaultClient{ID: "foo"},
+			expectContentType: "application/json;charset=UTF-8",
+			check: func(t *testing.T, body []byte) {
+				var actual map[string]interface{}
+				require.NoError(t, json.Unmarshal(body, &actual))
+				assert.Equal(t, claims, actual)
+			},
+		},
+		{
+			d:                 "should return JSON because no default signing algorithm is set",
+			client:            client("", ""),
+			accept:            "application/jwt",
+			expectContentType: "application/json;charset=UTF-8",
+		},
+		{
+			d:                 "should return a signed JWT because the client registered userinfo_signed_response_alg",
+			client:            client("RS256", ""),
+			expectContentType: "application/jwt",
+			check: func(t *testing.T, body []byte) {
+				verify(t, string(body))
+			},
+		},
+		{
+			d:                 "should return a signed JWT because the client accepts application/jwt",
+			client:            client("", ""),
+			accept:            "application/json;q=0.5, application/jwt",
+			defaultAlg:        "RS256",
+			expectContentType: "application/jwt",
+			check: func(t *testing.T, body []byte) {
+				verify(t, string(body))
+			},
+		},
+		{
+			d:                 "should return a signed and encrypted JWT",
+			client:            client("RS256", "RSA-OAEP"),
+			expectContentType: "application/jwt",
+			check: func(t *testing.T, body []byte) {
+				verify(t, string(decrypt(t, string(body))))
+			},
+		},
+		{
+			d:                 "should return encrypted JSON",
+			client:            client("", "RSA-OAEP-256"),
+			expectContentType: "application/jwt",
+			check: func(t *testing.T, body []byte) {
+				var actual map[string]interface{}
+				require.NoError(t, json.Unmarshal(decrypt(t, string(body)), &actual))
+				assert.Equal(t, claims, actual)
+			},
+		},
+		{
+			d:         "should fail because no signer is configured for the algorithm",
+			client:    client("ES256", ""),
+			expectErr: fosite.ErrServerError,
+		},
+		{
+			d: "should fail because the client has no encryption key",
+			client: &fosite.DefaultOpenIDConnectClient{
+				DefaultClient:                &fosite.DefaultClient{ID: "foo"},
+				UserinfoEncryptedResponseAlg: "RSA-OAEP",
+			},
+			expectErr: fosite.ErrServerError,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+			h.DefaultSigningAlgorithm = tc.defaultAlg
+			r := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+			r.Header.Set("Accept", tc.accept)
+			rw := httptest.NewRecorder()
+
+			err := h.WriteUserinfoResponse(context.Background(), rw, r, tc.client, claims)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectContentType, rw.Header().Get("Content-Type"))
+			assert.Equal(t, "no-store", rw.Header().Get("Cache-Control"))
+			if tc.check != nil {
+				tc.check(t, rw.Body.Bytes())
+			}
+		})
+	}
+}
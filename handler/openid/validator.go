@@ -37,10 +37,42 @@ import (
 	"github.com/ory/go-convenience/stringslice"
 )
 
+// SilentAuthenticationState is the authentication and consent state of the end-user when prompt=none is requested.
+type SilentAuthenticationState struct {
+	// Authenticated is true if the end-user is already authenticated.
+	Authenticated bool
+
+	// ConsentGiven is true if the end-user already consented to the request.
+	ConsentGiven bool
+
+	// InteractionRequired is true if the request can not be completed without displaying a user interface for
+	// other reasons, for example to select an account.
+	InteractionRequired bool
+}
+
+// SilentAuthenticationStrategy reports the authentication and consent state of the end-user for requests using
+// prompt=none, which must be completed without displaying any user interface as defined in
+// https://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+type SilentAuthenticationStrategy interface {
+	GetSilentAuthenticationState(ctx context.Context, req fosite.AuthorizeRequester) (*SilentAuthenticationState, error)
+}
+
 type OpenIDConnectRequestValidator struct {
 	AllowedPrompt       []string
 	Strategy            jwt.JWTStrategy
 	IsRedirectURISecure func(*url.URL) bool
+
+	// SilentAuthenticationStrategy is used to answer prompt=none requests with login_required, consent_required or
+	// interaction_required errors. If nil, the state of the session is validated only.
+	SilentAuthenticationStrategy SilentAuthenticationStrategy
+
+	// ACRStrategy decides whether the acr of the session satisfies the acr values requested using the acr_values
+	// parameter or an essential acr claim. If not, step-up authentication is requested by returning login_required
+	// wrapping ErrStepUpRequired. The acr is not validated if ACRStrategy is nil.
+	ACRStrategy ACRStrategy
+
+	// Issuer, if set, must be the "iss" claim of the id_token_hint.
+	Issuer string
 }
 
 func NewOpenIDConnectRequestValidator(prompt []string, strategy jwt.JWTStrategy) *OpenIDConnectRequestValidator {
@@ -59,6 +91,22 @@ func (v *OpenIDConnectRequestValidator) WithRedirectSecureChecker(checker func(*
 	return v
 }
 
+func (v *OpenIDConnectRequestValidator) WithSilentAuthenticationStrategy(strategy SilentAuthenticationStrategy) *OpenIDConnectRequestValidator {
+	v.SilentAuthenticationStrategy = strategy
+	return v
+}
+
+func (v *OpenIDConnectRequestValidator) WithACRStrategy(strategy ACRStrategy) *OpenIDConnectRequestValidator {
+	v.ACRStrategy = strategy
+	return v
+}
+
+// WithIssuer sets the issuer the id_token_hint must have been issued by.
+func (v *OpenIDConnectRequestValidator) WithIssuer(issuer string) *OpenIDConnectRequestValidator {
+	v.Issuer = issuer
+	return v
+}
+
 func (v *OpenIDConnectRequestValidator) secureChecker() func(*url.URL) bool {
 	if v.IsRedirectURISecure == nil {
 		v.IsRedirectURISecure = fosite.IsRedirectURISecure
@@ -105,9 +153,10 @@ func (v *OpenIDConnectRequestValidator) ValidatePrompt(ctx context.Context, req
 		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Parameter 'prompt' was set to 'none', but contains other values as well which is not allowed."))
 	}
 
-	maxAge, err := strconv.ParseInt(req.GetRequestForm().Get("max_age"), 10, 64)
-	if err != nil {
-		maxAge = 0
+	if stringslice.Has(prompt, "none") {
+		if err := v.validateSilentAuthentication(ctx, req); err != nil {
+			return err
+		}
 	}
 
 	session, ok := req.GetSession().(Session)
@@ -125,13 +174,17 @@ func (v *OpenIDConnectRequestValidator) ValidatePrompt(ctx context.Context, req
 		return errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to validate OpenID Connect request because authentication time is in the future."))
 	}
 
-	if maxAge > 0 {
-		if claims.AuthTime.IsZero() {
+	if maxAge := getMaxAge(req); maxAge > 0 {
+		authTime, requestedAt := session.GetAuthTime(), session.GetRequestedAt()
+		if authTime.IsZero() {
 			return errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to validate OpenID Connect request because authentication time claim is required when max_age is set."))
-		} else if claims.RequestedAt.IsZero() {
+		} else if requestedAt.IsZero() {
 			return errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to validate OpenID Connect request because requested at claim is required when max_age is set."))
-		} else if claims.AuthTime.Add(time.Second * time.Duration(maxAge)).Before(claims.RequestedAt) {
-			return errorsx.WithStack(fosite.ErrLoginRequired.WithDebug("Failed to validate OpenID Connect request because authentication time does not satisfy max_age time."))
+		} else if authTime.Add(time.Second * time.Duration(maxAge)).Before(requestedAt) {
+			return errorsx.WithStack(fosite.ErrLoginRequired.
+				WithHintf("The end-user authenticated at '%s', which is more than %d seconds before the authorization request, and must be re-authenticated.", authTime, maxAge).
+				WithWrap(ErrMaxAgeExceeded).
+				WithDebug("Failed to validate OpenID Connect request because authentication time does not satisfy max_age time."))
 		}
 	}
 
@@ -151,6 +204,15 @@ func (v *OpenIDConnectRequestValidator) ValidatePrompt(ctx context.Context, req
 		}
 	}
 
+	if v.ACRStrategy != nil {
+		if err := validateACR(ctx, v.ACRStrategy, req, session.GetACR()); errors.Is(err, ErrStepUpRequired) && stringslice.Has(prompt, "none") {
+			// Step-up authentication requires end-user interaction, which is not allowed with prompt=none.
+			return errorsx.WithStack(fosite.ErrLoginRequired.WithHint("Parameter 'prompt' was set to 'none', but the end-user must be authenticated using a stronger authentication context class.").WithDebug(err.Error()))
+		} else if err != nil {
+			return err
+		}
+	}
+
 	idTokenHint := req.GetRequestForm().Get("id_token_hint")
 	if idTokenHint == "" {
 		return nil
@@ -164,6 +226,10 @@ func (v *OpenIDConnectRequestValidator) ValidatePrompt(ctx context.Context, req
 		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Failed to validate OpenID Connect request as decoding id token from id_token_hint parameter failed.").WithWrap(err).WithDebug(err.Error()))
 	}
 
+	if v.Issuer != "" && !tokenHint.Claims.VerifyIssuer(v.Issuer, true) {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Failed to validate OpenID Connect request because the provided id token from id_token_hint was not issued by this authorization server."))
+	}
+
 	if hintSub, _ := tokenHint.Claims["sub"].(string); hintSub == "" {
 		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Failed to validate OpenID Connect request because provided id token from id_token_hint does not have a subject."))
 	} else if hintSub != claims.Subject {
@@ -173,6 +239,41 @@ func (v *OpenIDConnectRequestValidator) ValidatePrompt(ctx context.Context, req
 	return nil
 }
 
+func (v *OpenIDConnectRequestValidator) validateSilentAuthentication(ctx context.Context, req fosite.AuthorizeRequester) error {
+	if v.SilentAuthenticationStrategy == nil {
+		return nil
+	}
+
+	state, err := v.SilentAuthenticationStrategy.GetSilentAuthenticationState(ctx, req)
+	if err != nil {
+		return err
+	} else if state == nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithDebug("Failed to validate OpenID Connect request because the silent authentication strategy returned no state."))
+	}
+
+	switch {
+	case !state.Authenticated:
+		return errorsx.WithStack(fosite.ErrLoginRequired.WithHint("Parameter 'prompt' was set to 'none', but the end-user is not authenticated."))
+	case state.InteractionRequired:
+		return errorsx.WithStack(fosite.ErrInteractionRequired.WithHint("Parameter 'prompt' was set to 'none', but the request can not be completed without end-user interaction."))
+	case !state.ConsentGiven:
+		return errorsx.WithStack(fosite.ErrConsentRequired.WithHint("Parameter 'prompt' was set to 'none', but the end-user has not consented to the request."))
+	}
+	return nil
+}
+
+// getMaxAge returns the max_age parameter of the request, falling back to the client's default_max_age.
+func getMaxAge(requester fosite.Requester) int64 {
+	if maxAge, err := strconv.ParseInt(requester.GetRequestForm().Get("max_age"), 10, 64); err == nil {
+		return maxAge
+	}
+
+	if c, ok := requester.GetClient().(fosite.DefaultMaxAgeClient); ok {
+		return c.GetDefaultMaxAge()
+	}
+	return 0
+}
+
 func isWhitelisted(items []string, whiteList []string) bool {
 	for _, item := range items {
 		if !stringslice.Has(whiteList, item) {
@@ -28,6 +28,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -280,7 +281,243 @@ func TestValidatePrompt(t *testing.T) {
 	}
 }
 
+func TestValidateMaxAge(t *testing.T) {
+	v := NewOpenIDConnectRequestValidator(nil, &jwt.RS256JWTStrategy{PrivateKey: key})
+
+	session := func(authTime time.Duration) *DefaultSession {
+		return &DefaultSession{
+			Subject: "foo",
+			Claims: &jwt.IDTokenClaims{
+				Subject:     "foo",
+				RequestedAt: time.Now().UTC(),
+				AuthTime:    time.Now().UTC().Add(-authTime),
+			},
+		}
+	}
+
+	for k, tc := range []struct {
+		d             string
+		maxAge        string
+		defaultMaxAge int64
+		s             *DefaultSession
+		expectMaxAge  bool
+	}{
+		{
+			d: "should pass because no max age is set",
+			s: session(time.Hour),
+		},
+		{
+			d:      "should pass because the authentication is recent enough",
+			maxAge: "3600",
+			s:      session(time.Minute),
+		},
+		{
+			d:            "should fail because the authentication is too old",
+			maxAge:       "60",
+			s:            session(time.Hour),
+			expectMaxAge: true,
+		},
+		{
+			d:             "should fail because the authentication is older than the client's default max age",
+			defaultMaxAge: 60,
+			s:             session(time.Hour),
+			expectMaxAge:  true,
+		},
+		{
+			d:             "should pass because max_age takes precedence over the client's default max age",
+			maxAge:        "7200",
+			defaultMaxAge: 60,
+			s:             session(time.Hour),
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+			err := v.ValidatePrompt(context.TODO(), &fosite.AuthorizeRequest{
+				Request: fosite.Request{
+					Form: url.Values{"max_age": {tc.maxAge}},
+					Client: &fosite.DefaultOpenIDConnectClient{
+						DefaultClient: &fosite.DefaultClient{},
+						DefaultMaxAge: tc.defaultMaxAge,
+					},
+					Session: tc.s,
+				},
+				RedirectURI: parse("https://foo-bar/"),
+			})
+			if tc.expectMaxAge {
+				assert.ErrorIs(t, err, fosite.ErrLoginRequired)
+				assert.ErrorIs(t, err, ErrMaxAgeExceeded)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+type staticSilentAuthenticationStrategy SilentAuthenticationState
+
+func (s staticSilentAuthenticationStrategy) GetSilentAuthenticationState(context.Context, fosite.AuthorizeRequester) (*SilentAuthenticationState, error) {
+	state := SilentAuthenticationState(s)
+	return &state, nil
+}
+
+func TestValidateSilentAuthentication(t *testing.T) {
+	for k, tc := range []struct {
+		d         string
+		prompt    string
+		state     SilentAuthenticationState
+		expectErr error
+	}{
+		{
+			d:      "should pass because the end-user is authenticated and consented",
+			prompt: "none",
+			state:  SilentAuthenticationState{Authenticated: true, ConsentGiven: true},
+		},
+		{
+			d:         "should fail because the end-user is not authenticated",
+			prompt:    "none",
+			state:     SilentAuthenticationState{ConsentGiven: true},
+			expectErr: fosite.ErrLoginRequired,
+		},
+		{
+			d:         "should fail because consent is missing",
+			prompt:    "none",
+			state:     SilentAuthenticationState{Authenticated: true},
+			expectErr: fosite.ErrConsentRequired,
+		},
+		{
+			d:         "should fail because interaction is required",
+			prompt:    "none",
+			state:     SilentAuthenticationState{Authenticated: true, ConsentGiven: true, InteractionRequired: true},
+			expectErr: fosite.ErrInteractionRequired,
+		},
+		{
+			d:         "should fail because prompt=none is combined with other values",
+			prompt:    "none login",
+			state:     SilentAuthenticationState{Authenticated: true, ConsentGiven: true},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			d:      "should pass because the strategy is only used for prompt=none",
+			prompt: "login",
+			state:  SilentAuthenticationState{},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+			v := NewOpenIDConnectRequestValidator(nil, &jwt.RS256JWTStrategy{PrivateKey: key}).
+				WithSilentAuthenticationStrategy(staticSilentAuthenticationStrategy(tc.state))
+
+			now := time.Now().UTC()
+			err := v.ValidatePrompt(context.TODO(), &fosite.AuthorizeRequest{
+				Request: fosite.Request{
+					Form:   url.Values{"prompt": {tc.prompt}},
+					Client: &fosite.DefaultClient{},
+					Session: &DefaultSession{
+						Subject: "foo",
+						Claims: &jwt.IDTokenClaims{
+							Subject:     "foo",
+							RequestedAt: now,
+							AuthTime:    now,
+						},
+					},
+				},
+				RedirectURI: parse("https://foo-bar/"),
+			})
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func parse(u string) *url.URL {
 	o, _ := url.Parse(u)
 	return o
 }
+
+func TestValidateACR(t *testing.T) {
+	for k, tc := range []struct {
+		d            string
+		form         url.Values
+		acr          string
+		strategy     ACRStrategy
+		expectErr    error
+		expectStepUp bool
+	}{
+		{
+			d:    "should pass because no acr is validated without strategy",
+			form: url.Values{"acr_values": {"gold"}},
+			acr:  "bronze",
+		},
+		{
+			d:        "should pass because the acr satisfies acr_values",
+			form:     url.Values{"acr_values": {"silver gold"}},
+			acr:      "gold",
+			strategy: &ExactACRStrategy{},
+		},
+		{
+			d:        "should pass because no acr was requested",
+			form:     url.Values{},
+			acr:      "bronze",
+			strategy: &ExactACRStrategy{},
+		},
+		{
+			d:            "should require step-up because the acr does not satisfy acr_values",
+			form:         url.Values{"acr_values": {"silver gold"}},
+			acr:          "bronze",
+			strategy:     &ExactACRStrategy{},
+			expectErr:    fosite.ErrLoginRequired,
+			expectStepUp: true,
+		},
+		{
+			d:            "should require step-up because the acr does not satisfy the essential acr claim",
+			form:         url.Values{"claims": {`{"id_token":{"acr":{"essential":true,"value":"gold"}}}`}},
+			acr:          "silver",
+			strategy:     &OrderedACRStrategy{Levels: []string{"bronze", "silver", "gold"}},
+			expectErr:    fosite.ErrLoginRequired,
+			expectStepUp: true,
+		},
+		{
+			d:        "should pass because the acr is stronger than the essential acr claim",
+			form:     url.Values{"claims": {`{"id_token":{"acr":{"essential":true,"value":"silver"}}}`}},
+			acr:      "gold",
+			strategy: &OrderedACRStrategy{Levels: []string{"bronze", "silver", "gold"}},
+		},
+		{
+			d:         "should fail without step-up because prompt=none forbids interaction",
+			form:      url.Values{"acr_values": {"gold"}, "prompt": {"none"}},
+			acr:       "bronze",
+			strategy:  &ExactACRStrategy{},
+			expectErr: fosite.ErrLoginRequired,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
+			v := NewOpenIDConnectRequestValidator(nil, &jwt.RS256JWTStrategy{PrivateKey: key}).
+				WithACRStrategy(tc.strategy)
+
+			now := time.Now().UTC()
+			err := v.ValidatePrompt(context.TODO(), &fosite.AuthorizeRequest{
+				Request: fosite.Request{
+					Form:   tc.form,
+					Client: &fosite.DefaultClient{},
+					Session: &DefaultSession{
+						Subject: "foo",
+						Claims: &jwt.IDTokenClaims{
+							Subject:                             "foo",
+							RequestedAt:                         now,
+							AuthTime:                            now,
+							AuthenticationContextClassReference: tc.acr,
+						},
+					},
+				},
+				RedirectURI: parse("https://foo-bar/"),
+			})
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+				assert.Equal(t, tc.expectStepUp, errors.Is(err, ErrStepUpRequired))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
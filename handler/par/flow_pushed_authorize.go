@@ -0,0 +1,62 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package par
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/hmac"
+)
+
+// PushedAuthorizeHandler stores pushed authorization requests and returns the request_uri referencing them as
+// defined in https://tools.ietf.org/html/rfc9126#section-2.2. The authorization endpoint loads and deletes the
+// referenced request, see fosite.PARStorage.
+type PushedAuthorizeHandler struct {
+	Storage fosite.PARStorage
+
+	// RequestURILifespan defines how long the request_uri is valid. It must equal the
+	// PushedAuthorizeRequestLifespan of the authorization server.
+	RequestURILifespan time.Duration
+}
+
+func (c *PushedAuthorizeHandler) HandlePushedAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.PushedAuthorizeResponder) error {
+	// The request_uri must contain a cryptographically strong pseudorandom part, see
+	// https://tools.ietf.org/html/rfc9126#section-7.1
+	key, err := hmac.RandomBytes(32)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	requestURI := fosite.PushedAuthorizeRequestURIPrefix + base64.RawURLEncoding.EncodeToString(key)
+	if err := c.Storage.CreatePARSession(ctx, requestURI, ar); err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	resp.SetRequestURI(requestURI)
+	resp.SetExpiresIn(c.RequestURILifespan)
+	return nil
+}
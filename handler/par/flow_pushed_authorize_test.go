@@ -0,0 +1,57 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package par
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestPushedAuthorizeHandler(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := &PushedAuthorizeHandler{Storage: store, RequestURILifespan: time.Minute}
+
+	ar := fosite.NewAuthorizeRequest()
+	ar.Client = &fosite.DefaultClient{ID: "foo"}
+	ar.State = "some-random-state"
+
+	resp := fosite.NewPushedAuthorizeResponse()
+	require.NoError(t, h.HandlePushedAuthorizeEndpointRequest(context.Background(), ar, resp))
+	assert.True(t, strings.HasPrefix(resp.GetRequestURI(), fosite.PushedAuthorizeRequestURIPrefix))
+	assert.EqualValues(t, 60, resp.GetExpiresIn())
+
+	stored, err := store.GetPARSession(context.Background(), resp.GetRequestURI())
+	require.NoError(t, err)
+	assert.Equal(t, ar, stored)
+
+	other := fosite.NewPushedAuthorizeResponse()
+	require.NoError(t, h.HandlePushedAuthorizeEndpointRequest(context.Background(), ar, other))
+	assert.NotEqual(t, resp.GetRequestURI(), other.GetRequestURI())
+}
@@ -26,8 +26,10 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"regexp"
+	"strings"
 
 	"github.com/ory/x/errorsx"
+	"github.com/ory/x/stringslice"
 
 	"github.com/pkg/errors"
 
@@ -45,6 +47,10 @@ type Handler struct {
 	// Whether or not to allow the plain challenge method (S256 should be used whenever possible, plain is really discouraged).
 	EnablePlainChallengeMethod bool
 
+	// AllowedChallengeMethods restricts the code challenge methods clients may use, for example to []string{"S256"}.
+	// If set, EnablePlainChallengeMethod is ignored.
+	AllowedChallengeMethods []string
+
 	AuthorizeCodeStrategy oauth2.AuthorizeCodeStrategy
 	Storage               PKCERequestStorage
 }
@@ -99,6 +105,11 @@ func (c *Handler) validate(challenge, method string, client fosite.Client) error
 				WithHint("This client must include a code_challenge when performing the authorize code flow, but it is missing.").
 				WithDebug("The server is configured in a way that enforces PKCE for this client."))
 		}
+		if pc, ok := client.(fosite.PKCEClient); ok && pc.GetRequirePKCE() {
+			return errorsx.WithStack(fosite.ErrInvalidRequest.
+				WithHint("This client must include a code_challenge when performing the authorize code flow, but it is missing.").
+				WithDebug("The client is configured in a way that requires PKCE."))
+		}
 		return nil
 	}
 
@@ -114,7 +125,8 @@ func (c *Handler) validate(challenge, method string, client fosite.Client) error
 	case "plain":
 		fallthrough
 	case "":
-		if !c.EnablePlainChallengeMethod {
+		method = "plain"
+		if len(c.AllowedChallengeMethods) == 0 && !c.EnablePlainChallengeMethod {
 			return errorsx.WithStack(fosite.ErrInvalidRequest.
 				WithHint("Clients must use code_challenge_method=S256, plain is not allowed.").
 				WithDebug("The server is configured in a way that enforces PKCE S256 as challenge method for clients."))
@@ -123,6 +135,17 @@ func (c *Handler) validate(challenge, method string, client fosite.Client) error
 		return errorsx.WithStack(fosite.ErrInvalidRequest.
 			WithHint("The code_challenge_method is not supported, use S256 instead."))
 	}
+
+	if len(c.AllowedChallengeMethods) > 0 && !stringslice.Has(c.AllowedChallengeMethods, method) {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.
+			WithHintf("Clients must use one of the code_challenge_method values '%s', '%s' is not allowed.", strings.Join(c.AllowedChallengeMethods, "', '"), method).
+			WithDebug("The server is configured in a way that restricts the PKCE challenge methods clients may use."))
+	}
+
+	if pc, ok := client.(fosite.PKCEClient); ok && len(pc.GetPKCEChallengeMethods()) > 0 && !stringslice.Has(pc.GetPKCEChallengeMethods(), method) {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.
+			WithHintf("The OAuth 2.0 Client is not allowed to use code_challenge_method '%s', use one of '%s'.", method, strings.Join(pc.GetPKCEChallengeMethods(), "', '")))
+	}
 	return nil
 }
 
@@ -142,7 +165,10 @@ func (c *Handler) HandleTokenEndpointRequest(ctx context.Context, request fosite
 	code := request.GetRequestForm().Get("code")
 	signature := c.AuthorizeCodeStrategy.AuthorizeCodeSignature(code)
 	authorizeRequest, err := c.Storage.GetPKCERequestSession(ctx, signature, request.GetSession())
-	if errors.Is(err, fosite.ErrNotFound) {
+	if errors.Is(err, fosite.ErrNotFound) && c.isCodeExchangeRetry(ctx, signature, request) {
+		// The PKCE session has been deleted by the original exchange, which verified the same code_verifier.
+		return nil
+	} else if errors.Is(err, fosite.ErrNotFound) {
 		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("Unable to find initial PKCE data tied to this request").WithWrap(err).WithDebug(err.Error()))
 	} else if err != nil {
 		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
@@ -239,3 +265,15 @@ func (c *Handler) CanHandleTokenEndpointRequest(requester fosite.AccessRequester
 	// Value MUST be set to "authorization_code"
 	return requester.GetGrantTypes().ExactOne("authorization_code")
 }
+
+// isCodeExchangeRetry returns true if the token request is a retry of an authorize code exchange which is answered
+// with the original response, see oauth2.AuthorizeExplicitGrantHandler.CodeReuseGracePeriod.
+func (c *Handler) isCodeExchangeRetry(ctx context.Context, signature string, request fosite.AccessRequester) bool {
+	exchangeStorage, ok := c.Storage.(fosite.AuthorizeCodeExchangeStorage)
+	if !ok {
+		return false
+	}
+
+	exchange, err := exchangeStorage.GetAuthorizeCodeExchange(ctx, signature)
+	return err == nil && exchange.IsRetryOf(request)
+}
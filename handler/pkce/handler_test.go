@@ -288,6 +288,7 @@ func TestPKCEHandleTokenEndpointRequest(t *testing.T) {
 		method      string
 		expectErr   bool
 		client      *fosite.DefaultClient
+		allowed     []string
 	}{
 		{
 			d: "should pass because pkce is not enforced",
@@ -344,12 +345,83 @@ func TestPKCEHandleTokenEndpointRequest(t *testing.T) {
 			method:      "S256",
 			challenge:   "challenge",
 		},
+		{
+			d:         "should fail because the confidential client requires pkce and no challenge was given",
+			client:    &fosite.DefaultClient{RequirePKCE: true},
+			expectErr: true,
+		},
+		{
+			d:         "should pass because the confidential client requires pkce and a challenge was given",
+			client:    &fosite.DefaultClient{RequirePKCE: true},
+			method:    "S256",
+			challenge: "challenge",
+		},
+		{
+			d:           "should fail because only S256 is allowed even though plain is enabled",
+			enablePlain: true,
+			allowed:     []string{"S256"},
+			expectErr:   true,
+			method:      "plain",
+			challenge:   "challenge",
+		},
+		{
+			d:         "should fail because only S256 is allowed and method is empty which defaults to plain",
+			allowed:   []string{"S256"},
+			expectErr: true,
+			challenge: "challenge",
+		},
+		{
+			d:         "should pass because plain is allowed",
+			allowed:   []string{"S256", "plain"},
+			method:    "plain",
+			challenge: "challenge",
+		},
+		{
+			d:         "should pass because only S256 is allowed and method is S256",
+			allowed:   []string{"S256"},
+			method:    "S256",
+			challenge: "challenge",
+		},
+		{
+			d:           "should fail because the client did not register the plain method",
+			enablePlain: true,
+			client:      &fosite.DefaultClient{PKCEChallengeMethods: []string{"S256"}},
+			expectErr:   true,
+			method:      "plain",
+			challenge:   "challenge",
+		},
+		{
+			d:           "should pass because the client registered the plain method",
+			enablePlain: true,
+			client:      &fosite.DefaultClient{PKCEChallengeMethods: []string{"plain"}},
+			method:      "plain",
+			challenge:   "challenge",
+		},
+		{
+			d:         "should fail because the client registered the plain method but only S256 is allowed",
+			allowed:   []string{"S256"},
+			client:    &fosite.DefaultClient{PKCEChallengeMethods: []string{"plain", "S256"}},
+			expectErr: true,
+			method:    "plain",
+			challenge: "challenge",
+		},
+		{
+			d:         "should pass because the method is allowed by both the client and the server",
+			allowed:   []string{"S256"},
+			client:    &fosite.DefaultClient{PKCEChallengeMethods: []string{"plain", "S256"}},
+			method:    "S256",
+			challenge: "challenge",
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d/description=%s", k, tc.d), func(t *testing.T) {
 			h := &Handler{
 				Force:                      tc.force,
 				ForceForPublicClients:      tc.forcePublic,
 				EnablePlainChallengeMethod: tc.enablePlain,
+				AllowedChallengeMethods:    tc.allowed,
+			}
+			if tc.client == nil {
+				tc.client = &fosite.DefaultClient{}
 			}
 
 			if tc.expectErr {
@@ -23,6 +23,7 @@ package rfc7523
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/ory/fosite/handler/oauth2"
@@ -43,6 +44,9 @@ type Handler struct {
 
 	// TokenURL is the the URL of the Authorization Server's Token Endpoint.
 	TokenURL string
+	// AllowedAudiences are accepted as "aud" (audience) claim of assertions in addition to the TokenURL, for example
+	// the issuer URL of the authorization server.
+	AllowedAudiences []string
 	// SkipClientAuth indicates, if client authentication can be skipped.
 	SkipClientAuth bool
 	// JWTIDOptional indicates, if jti (JWT ID) claim required or not.
@@ -52,6 +56,9 @@ type Handler struct {
 	// JWTMaxDuration sets the maximum time after token issued date (if present), during which the token is
 	// considered valid. If "iat" claim is not present, then current time will be used as issued date.
 	JWTMaxDuration time.Duration
+	// ClockSkew is tolerated when checking the "exp" (expiration time) and "nbf" (not before) claims. Defaults to
+	// zero.
+	ClockSkew time.Duration
 
 	*oauth2.HandleHelper
 }
@@ -110,7 +117,7 @@ func (c *Handler) HandleTokenEndpointRequest(ctx context.Context, request fosite
 	}
 
 	if claims.ID != "" {
-		if err := c.Storage.MarkJWTUsedForTime(ctx, claims.ID, claims.Expiry.Time()); err != nil {
+		if err := c.Storage.MarkJWTUsedForTime(ctx, claims.ID, claims.Expiry.Time().Add(c.ClockSkew)); err != nil {
 			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
 		}
 	}
@@ -127,7 +134,7 @@ func (c *Handler) HandleTokenEndpointRequest(ctx context.Context, request fosite
 	if err != nil {
 		return err
 	}
-	session.SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(c.HandleHelper.AccessTokenLifespan).Round(time.Second))
+	session.SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(fosite.GetEffectiveLifespan(request.GetClient(), grantTypeJWTBearer, fosite.AccessToken, c.HandleHelper.AccessTokenLifespan)).Round(time.Second))
 	session.SetSubject(claims.Subject)
 
 	return nil
@@ -164,8 +171,10 @@ func (c *Handler) CheckRequest(request fosite.AccessRequester) error {
 	//   relies on the parameter is used.
 
 	// if client is authenticated, check grant types
-	if !c.CanSkipClientAuth(request) && !request.GetClient().GetGrantTypes().Has(grantTypeJWTBearer) {
-		return errorsx.WithStack(fosite.ErrUnauthorizedClient.WithHintf("The OAuth 2.0 Client is not allowed to use authorization grant \"%s\".", grantTypeJWTBearer))
+	if !c.CanSkipClientAuth(request) {
+		if err := fosite.ValidateClientGrantType(request.GetClient(), grantTypeJWTBearer); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -243,7 +252,15 @@ func (c *Handler) validateTokenClaims(ctx context.Context, claims jwt.Claims, ke
 		)
 	}
 
-	if !claims.Audience.Contains(c.TokenURL) {
+	if !c.isAllowedAudience(claims.Audience) {
+		if len(c.AllowedAudiences) > 0 {
+			return errorsx.WithStack(fosite.ErrInvalidGrant.
+				WithHintf(
+					"The JWT in \"assertion\" request parameter MUST contain an \"aud\" (audience) claim containing one of the values \"%s\" that identify the authorization server as an intended audience.",
+					strings.Join(append([]string{c.TokenURL}, c.AllowedAudiences...), "\", \""),
+				),
+			)
+		}
 		return errorsx.WithStack(fosite.ErrInvalidGrant.
 			WithHintf(
 				"The JWT in \"assertion\" request parameter MUST contain an \"aud\" (audience) claim containing a value \"%s\" that identifies the authorization server as an intended audience.",
@@ -258,13 +275,13 @@ func (c *Handler) validateTokenClaims(ctx context.Context, claims jwt.Claims, ke
 		)
 	}
 
-	if claims.Expiry.Time().Before(time.Now()) {
+	if claims.Expiry.Time().Add(c.ClockSkew).Before(time.Now()) {
 		return errorsx.WithStack(fosite.ErrInvalidGrant.
 			WithHint("The JWT in \"assertion\" request parameter expired."),
 		)
 	}
 
-	if claims.NotBefore != nil && !claims.NotBefore.Time().Before(time.Now()) {
+	if claims.NotBefore != nil && !claims.NotBefore.Time().Before(time.Now().Add(c.ClockSkew)) {
 		return errorsx.WithStack(fosite.ErrInvalidGrant.
 			WithHintf(
 				"The JWT in \"assertion\" request parameter contains an \"nbf\" (not before) claim, that identifies the time '%s' before which the token MUST NOT be accepted.",
@@ -329,3 +346,15 @@ func (c *Handler) getSessionFromRequest(requester fosite.AccessRequester) (exten
 		return jwtSession, nil
 	}
 }
+
+func (c *Handler) isAllowedAudience(audience jwt.Audience) bool {
+	if audience.Contains(c.TokenURL) {
+		return true
+	}
+	for _, allowed := range c.AllowedAudiences {
+		if allowed != "" && audience.Contains(allowed) {
+			return true
+		}
+	}
+	return false
+}
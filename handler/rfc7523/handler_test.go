@@ -282,6 +282,26 @@ func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestWrongPublicKeysToCheckAss
 	)
 }
 
+func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestRotatedPublicKeysToCheckAssertionSignature() {
+	// arrange
+	ctx := context.Background()
+	s.accessRequest.GrantTypes = []string{grantTypeJWTBearer}
+	keyID := "" // provide no hint of what key was used to sign assertion
+	cl := s.createStandardClaim()
+	s.accessRequest.Form.Add("assertion", s.createTestAssertion(cl, keyID))
+	pubKey := s.createJWK(s.privateKey.Public(), "rotated_key")
+	s.mockStore.EXPECT().GetPublicKeys(ctx, cl.Issuer, cl.Subject).Return(s.createJWS(s.createRandomTestJWK(), pubKey), nil)
+	s.mockStore.EXPECT().GetPublicKeyScopes(ctx, cl.Issuer, cl.Subject, "rotated_key").Return([]string{}, nil)
+	s.mockStore.EXPECT().IsJWTUsed(ctx, cl.ID).Return(false, nil)
+	s.mockStore.EXPECT().MarkJWTUsedForTime(ctx, cl.ID, cl.Expiry.Time()).Return(nil)
+
+	// act
+	err := s.handler.HandleTokenEndpointRequest(ctx, s.accessRequest)
+
+	// assert
+	s.NoError(err, "no error expected, because one of the registered public keys signed the assertion")
+}
+
 func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestNoAudienceInAssertion() {
 	// arrange
 	ctx := context.Background()
@@ -331,6 +351,57 @@ func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestNotValidAudienceInAsserti
 	)
 }
 
+func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestNotAllowedAudienceInAssertion() {
+	// arrange
+	ctx := context.Background()
+	s.handler.AllowedAudiences = []string{"https://www.example.com/"}
+	s.accessRequest.GrantTypes = []string{grantTypeJWTBearer}
+	keyID := "my_key"
+	pubKey := s.createJWK(s.privateKey.Public(), keyID)
+	cl := s.createStandardClaim()
+	cl.Audience = jwt.Audience{"leela", "fry"}
+	s.accessRequest.Form.Add("assertion", s.createTestAssertion(cl, keyID))
+	s.mockStore.EXPECT().GetPublicKey(ctx, cl.Issuer, cl.Subject, keyID).Return(&pubKey, nil)
+
+	// act
+	err := s.handler.HandleTokenEndpointRequest(ctx, s.accessRequest)
+
+	// assert
+	s.True(errors.Is(err, fosite.ErrInvalidGrant))
+	s.Equal(
+		fmt.Sprintf(
+			"The JWT in \"assertion\" request parameter MUST contain an \"aud\" (audience) claim containing one of the values \"%s\", \"https://www.example.com/\" that identify the authorization server as an intended audience.",
+			s.handler.TokenURL,
+		),
+		err.(*fosite.RFC6749Error).HintField,
+	)
+}
+
+func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestAllowedAudienceInAssertion() {
+	// arrange
+	ctx := context.Background()
+	s.handler.AllowedAudiences = []string{"https://www.example.com/"}
+	s.handler.JWTIDOptional = true
+	s.handler.JWTIssuedDateOptional = true
+	s.accessRequest.GrantTypes = []string{grantTypeJWTBearer}
+	keyID := "my_key"
+	pubKey := s.createJWK(s.privateKey.Public(), keyID)
+	cl := s.createStandardClaim()
+	cl.Audience = jwt.Audience{"https://www.example.com/"}
+	cl.ID = ""
+	cl.IssuedAt = nil
+	cl.Expiry = jwt.NewNumericDate(time.Now().Add(time.Hour))
+	s.accessRequest.Form.Add("assertion", s.createTestAssertion(cl, keyID))
+	s.mockStore.EXPECT().GetPublicKey(ctx, cl.Issuer, cl.Subject, keyID).Return(&pubKey, nil)
+	s.mockStore.EXPECT().GetPublicKeyScopes(ctx, cl.Issuer, cl.Subject, keyID).Return([]string{}, nil)
+
+	// act
+	err := s.handler.HandleTokenEndpointRequest(ctx, s.accessRequest)
+
+	// assert
+	s.NoError(err, "no error expected, because the audience of the assertion is allowed")
+}
+
 func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestNoExpirationInAssertion() {
 	// arrange
 	ctx := context.Background()
@@ -404,6 +475,51 @@ func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestAssertionNotAcceptedBefor
 	)
 }
 
+func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestExpiredAssertionWithinClockSkew() {
+	// arrange
+	ctx := context.Background()
+	s.handler.ClockSkew = time.Minute
+	s.accessRequest.GrantTypes = []string{grantTypeJWTBearer}
+	keyID := "my_key"
+	pubKey := s.createJWK(s.privateKey.Public(), keyID)
+	cl := s.createStandardClaim()
+	cl.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	cl.Expiry = jwt.NewNumericDate(time.Now().Add(-30 * time.Second))
+	s.accessRequest.Form.Add("assertion", s.createTestAssertion(cl, keyID))
+	s.mockStore.EXPECT().GetPublicKey(ctx, cl.Issuer, cl.Subject, keyID).Return(&pubKey, nil)
+	s.mockStore.EXPECT().GetPublicKeyScopes(ctx, cl.Issuer, cl.Subject, keyID).Return([]string{}, nil)
+	s.mockStore.EXPECT().IsJWTUsed(ctx, cl.ID).Return(false, nil)
+	s.mockStore.EXPECT().MarkJWTUsedForTime(ctx, cl.ID, cl.Expiry.Time().Add(time.Minute)).Return(nil)
+
+	// act
+	err := s.handler.HandleTokenEndpointRequest(ctx, s.accessRequest)
+
+	// assert
+	s.NoError(err, "no error expected, because assertion expired within the tolerated clock skew")
+}
+
+func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestAssertionNotAcceptedBeforeDateWithinClockSkew() {
+	// arrange
+	ctx := context.Background()
+	s.handler.ClockSkew = time.Minute
+	s.accessRequest.GrantTypes = []string{grantTypeJWTBearer}
+	keyID := "my_key"
+	pubKey := s.createJWK(s.privateKey.Public(), keyID)
+	cl := s.createStandardClaim()
+	cl.NotBefore = jwt.NewNumericDate(time.Now().Add(30 * time.Second))
+	s.accessRequest.Form.Add("assertion", s.createTestAssertion(cl, keyID))
+	s.mockStore.EXPECT().GetPublicKey(ctx, cl.Issuer, cl.Subject, keyID).Return(&pubKey, nil)
+	s.mockStore.EXPECT().GetPublicKeyScopes(ctx, cl.Issuer, cl.Subject, keyID).Return([]string{}, nil)
+	s.mockStore.EXPECT().IsJWTUsed(ctx, cl.ID).Return(false, nil)
+	s.mockStore.EXPECT().MarkJWTUsedForTime(ctx, cl.ID, cl.Expiry.Time().Add(time.Minute)).Return(nil)
+
+	// act
+	err := s.handler.HandleTokenEndpointRequest(ctx, s.accessRequest)
+
+	// assert
+	s.NoError(err, "no error expected, because nbf claim is within the tolerated clock skew")
+}
+
 func (s *AuthorizeJWTGrantRequestHandlerTestSuite) TestAssertionWithoutRequiredIssueDate() {
 	// arrange
 	ctx := context.Background()
@@ -0,0 +1,104 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8628
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+)
+
+const grantTypeDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
+
+// DeviceAuthHandler is a response handler for the device authorization endpoint as defined in
+// https://tools.ietf.org/html/rfc8628#section-3.1
+type DeviceAuthHandler struct {
+	DeviceCodeStrategy RFC8628CodeStrategy
+	Storage            DeviceCodeStorage
+
+	// DeviceAndUserCodeLifespan defines how long the device and user codes are valid.
+	DeviceAndUserCodeLifespan time.Duration
+
+	// PollingInterval is the minimum amount of time the client should wait between polling requests.
+	PollingInterval time.Duration
+
+	// VerificationURI is the end-user verification URI on the authorization server.
+	VerificationURI string
+}
+
+func (d *DeviceAuthHandler) HandleDeviceEndpointRequest(ctx context.Context, dr fosite.DeviceRequester, resp fosite.DeviceResponder) error {
+	if err := fosite.ValidateClientGrantType(dr.GetClient(), grantTypeDeviceCode); err != nil {
+		return err
+	}
+
+	if d.VerificationURI == "" {
+		return errorsx.WithStack(fosite.ErrMisconfiguration.WithDebug("The device verification URI is not set."))
+	}
+
+	session, ok := dr.GetSession().(DeviceCodeSession)
+	if !ok {
+		return errorsx.WithStack(fosite.ErrServerError.WithHint("Session must be of type rfc8628.DeviceCodeSession."))
+	}
+
+	deviceCode, deviceCodeSignature, err := d.DeviceCodeStrategy.GenerateDeviceCode(ctx, dr)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	userCode, userCodeSignature, err := d.DeviceCodeStrategy.GenerateUserCode(ctx, dr)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	expiresAt := time.Now().UTC().Add(d.DeviceAndUserCodeLifespan).Round(time.Second)
+	session.SetExpiresAt(fosite.DeviceCode, expiresAt)
+	session.SetExpiresAt(fosite.UserCode, expiresAt)
+	session.SetDeviceCodeStatus(DeviceCodeStatusPending)
+
+	if err := d.Storage.CreateDeviceCodeSession(ctx, deviceCodeSignature, userCodeSignature, dr.Sanitize([]string{})); err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	resp.SetDeviceCode(deviceCode)
+	resp.SetUserCode(userCode)
+	resp.SetVerificationURI(d.VerificationURI)
+	resp.SetVerificationURIComplete(verificationURIComplete(d.VerificationURI, userCode))
+	resp.SetExpiresIn(time.Duration(expiresAt.UnixNano() - time.Now().UTC().UnixNano()))
+	resp.SetInterval(d.PollingInterval)
+	return nil
+}
+
+func verificationURIComplete(verificationURI, userCode string) string {
+	u, err := url.Parse(verificationURI)
+	if err != nil {
+		return fmt.Sprintf("%s?user_code=%s", verificationURI, url.QueryEscape(userCode))
+	}
+	q := u.Query()
+	q.Set("user_code", userCode)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8628
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestDeviceAuthHandler_HandleDeviceEndpointRequest(t *testing.T) {
+	store := storage.NewMemoryStore()
+	h := &DeviceAuthHandler{
+		DeviceCodeStrategy:        deviceStrategy,
+		Storage:                   store,
+		DeviceAndUserCodeLifespan: time.Minute * 10,
+		PollingInterval:           time.Second * 5,
+		VerificationURI:           "https://www.ory.sh/device",
+	}
+
+	for k, c := range []struct {
+		description string
+		setup       func(dr *fosite.DeviceRequest)
+		handler     *DeviceAuthHandler
+		expectErr   error
+	}{
+		{
+			description: "should fail because client is not allowed to use the grant type",
+			setup: func(dr *fosite.DeviceRequest) {
+				dr.Client = &fosite.DefaultClient{GrantTypes: fosite.Arguments{"authorization_code"}}
+			},
+			expectErr: fosite.ErrUnauthorizedClient,
+		},
+		{
+			description: "should fail because the verification uri is not set",
+			handler:     &DeviceAuthHandler{DeviceCodeStrategy: deviceStrategy, Storage: store},
+			expectErr:   fosite.ErrMisconfiguration,
+		},
+		{
+			description: "should fail because the session is not a device code session",
+			setup: func(dr *fosite.DeviceRequest) {
+				dr.Session = &fosite.DefaultSession{}
+			},
+			expectErr: fosite.ErrServerError,
+		},
+		{
+			description: "should pass",
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			dr := fosite.NewDeviceRequest()
+			dr.Client = &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{grantTypeDeviceCode}}
+			dr.Session = &DefaultSession{}
+			if c.setup != nil {
+				c.setup(dr)
+			}
+			handler := h
+			if c.handler != nil {
+				handler = c.handler
+			}
+
+			resp := fosite.NewDeviceResponse()
+			err := handler.HandleDeviceEndpointRequest(context.Background(), dr, resp)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEmpty(t, resp.GetDeviceCode())
+			assert.NotEmpty(t, resp.GetUserCode())
+			assert.Equal(t, "https://www.ory.sh/device", resp.GetVerificationURI())
+			assert.Equal(t, "https://www.ory.sh/device?user_code="+url.QueryEscape(resp.GetUserCode()), resp.GetVerificationURIComplete())
+			assert.InDelta(t, 600, resp.GetExpiresIn(), 1)
+			assert.EqualValues(t, 5, resp.GetInterval())
+
+			stored, err := store.GetDeviceCodeSession(context.Background(), deviceStrategy.DeviceCodeSignature(resp.GetDeviceCode()), nil)
+			require.NoError(t, err)
+			assert.Equal(t, DeviceCodeStatusPending, stored.GetSession().(DeviceCodeSession).GetDeviceCodeStatus())
+
+			userCodeSignature, err := deviceStrategy.UserCodeSignature(resp.GetUserCode())
+			require.NoError(t, err)
+			deviceCodeSignature, _, err := store.GetDeviceCodeSessionByUserCode(context.Background(), userCodeSignature, nil)
+			require.NoError(t, err)
+			assert.Equal(t, deviceStrategy.DeviceCodeSignature(resp.GetDeviceCode()), deviceCodeSignature)
+		})
+	}
+}
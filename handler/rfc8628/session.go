@@ -0,0 +1,123 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8628
+
+import (
+	"time"
+
+	"github.com/mohae/deepcopy"
+
+	"github.com/ory/fosite"
+)
+
+// DeviceCodeStatus is the state of a device authorization request as decided by the end-user.
+type DeviceCodeStatus string
+
+const (
+	DeviceCodeStatusPending  DeviceCodeStatus = "pending"
+	DeviceCodeStatusApproved DeviceCodeStatus = "approved"
+	DeviceCodeStatusDenied   DeviceCodeStatus = "denied"
+)
+
+// DeviceCodeSession must be implemented by the session if RFC8628 is to be supported. It keeps track of whether
+// the end-user has approved the request and when the client last polled the token endpoint.
+type DeviceCodeSession interface {
+	fosite.Session
+
+	// GetDeviceCodeStatus returns the status of the device authorization request. An empty status is treated
+	// as pending.
+	GetDeviceCodeStatus() DeviceCodeStatus
+
+	// SetDeviceCodeStatus sets the status of the device authorization request.
+	SetDeviceCodeStatus(status DeviceCodeStatus)
+
+	// GetLastPolledAt returns the time the client last polled the token endpoint using the device code.
+	GetLastPolledAt() time.Time
+
+	// SetLastPolledAt sets the time the client last polled the token endpoint using the device code.
+	SetLastPolledAt(t time.Time)
+
+	// GetPollingInterval returns the polling interval enforced for the device code, which grows each time the client
+	// polls too fast. Zero means the interval of the token endpoint handler applies.
+	GetPollingInterval() time.Duration
+
+	// SetPollingInterval sets the polling interval enforced for the device code.
+	SetPollingInterval(interval time.Duration)
+
+	// GetUserCodeSignature returns the signature of the user code issued together with the device code.
+	GetUserCodeSignature() string
+
+	// SetUserCodeSignature sets the signature of the user code issued together with the device code.
+	SetUserCodeSignature(signature string)
+}
+
+// DefaultSession is a default implementation of the DeviceCodeSession interface.
+type DefaultSession struct {
+	fosite.DefaultSession
+	DeviceCodeStatus  DeviceCodeStatus `json:"device_code_status"`
+	LastPolledAt      time.Time        `json:"last_polled_at"`
+	PollingInterval   time.Duration    `json:"polling_interval,omitempty"`
+	UserCodeSignature string           `json:"user_code_signature,omitempty"`
+}
+
+func (s *DefaultSession) GetDeviceCodeStatus() DeviceCodeStatus {
+	if s.DeviceCodeStatus == "" {
+		return DeviceCodeStatusPending
+	}
+	return s.DeviceCodeStatus
+}
+
+func (s *DefaultSession) SetDeviceCodeStatus(status DeviceCodeStatus) {
+	s.DeviceCodeStatus = status
+}
+
+func (s *DefaultSession) GetLastPolledAt() time.Time {
+	return s.LastPolledAt
+}
+
+func (s *DefaultSession) SetLastPolledAt(t time.Time) {
+	s.LastPolledAt = t
+}
+
+func (s *DefaultSession) GetPollingInterval() time.Duration {
+	return s.PollingInterval
+}
+
+func (s *DefaultSession) SetPollingInterval(interval time.Duration) {
+	s.PollingInterval = interval
+}
+
+func (s *DefaultSession) GetUserCodeSignature() string {
+	return s.UserCodeSignature
+}
+
+func (s *DefaultSession) SetUserCodeSignature(signature string) {
+	s.UserCodeSignature = signature
+}
+
+func (s *DefaultSession) Clone() fosite.Session {
+	if s == nil {
+		return nil
+	}
+
+	return deepcopy.Copy(s).(fosite.Session)
+}
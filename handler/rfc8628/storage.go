@@ -0,0 +1,62 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8628
+
+import (
+	"context"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+)
+
+// RFC8628CoreStorage is the storage needed by the device authorization grant token endpoint handler.
+type RFC8628CoreStorage interface {
+	DeviceCodeStorage
+	oauth2.AccessTokenStorage
+	oauth2.RefreshTokenStorage
+}
+
+// DeviceCodeStorage stores the device authorization sessions created at the device authorization endpoint.
+type DeviceCodeStorage interface {
+	// CreateDeviceCodeSession stores the device authorization request. The session can be looked up using the
+	// device code signature as well as the user code signature.
+	CreateDeviceCodeSession(ctx context.Context, deviceCodeSignature string, userCodeSignature string, request fosite.Requester) (err error)
+
+	// GetDeviceCodeSession hydrates the session based on the given device code signature and returns the device
+	// authorization request. If the device code has been invalidated with `InvalidateDeviceCodeSession` this
+	// method MUST return the ErrInvalidatedDeviceCode error together with the request.
+	GetDeviceCodeSession(ctx context.Context, deviceCodeSignature string, session fosite.Session) (request fosite.Requester, err error)
+
+	// GetDeviceCodeSessionByUserCode hydrates the session based on the given user code signature and returns the
+	// device code signature and the device authorization request. The verification endpoint uses it to look up
+	// the request the end-user is approving or denying.
+	GetDeviceCodeSessionByUserCode(ctx context.Context, userCodeSignature string, session fosite.Session) (deviceCodeSignature string, request fosite.Requester, err error)
+
+	// UpdateDeviceCodeSession replaces the stored device authorization request, for example once the end-user
+	// approved the request or when the client has polled the token endpoint.
+	UpdateDeviceCodeSession(ctx context.Context, deviceCodeSignature string, request fosite.Requester) (err error)
+
+	// InvalidateDeviceCodeSession is called when a device code is being used. The state of the device code should
+	// be set to invalid and consecutive requests to GetDeviceCodeSession should return the
+	// ErrInvalidatedDeviceCode error.
+	InvalidateDeviceCodeSession(ctx context.Context, deviceCodeSignature string) (err error)
+}
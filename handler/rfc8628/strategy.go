@@ -0,0 +1,46 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8628
+
+import (
+	"context"
+
+	"github.com/ory/fosite"
+)
+
+// RFC8628CodeStrategy generates and validates the device and user codes used by the device authorization grant.
+type RFC8628CodeStrategy interface {
+	DeviceCodeStrategy
+	UserCodeStrategy
+}
+
+type DeviceCodeStrategy interface {
+	DeviceCodeSignature(code string) string
+	GenerateDeviceCode(ctx context.Context, requester fosite.Requester) (code string, signature string, err error)
+	ValidateDeviceCode(ctx context.Context, requester fosite.Requester, code string) (err error)
+}
+
+type UserCodeStrategy interface {
+	UserCodeSignature(code string) (signature string, err error)
+	GenerateUserCode(ctx context.Context, requester fosite.Requester) (code string, signature string, err error)
+	ValidateUserCode(ctx context.Context, requester fosite.Requester, code string) (err error)
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8628
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	enigma "github.com/ory/fosite/token/hmac"
+)
+
+// userCodeCharset omits vowels and easily confused characters, as recommended by
+// https://tools.ietf.org/html/rfc8628#section-6.1
+const userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+
+const userCodeLength = 8
+
+type DefaultDeviceStrategy struct {
+	Enigma                    *enigma.HMACStrategy
+	DeviceAndUserCodeLifespan time.Duration
+}
+
+func (h *DefaultDeviceStrategy) DeviceCodeSignature(code string) string {
+	return h.Enigma.Signature(code)
+}
+
+func (h *DefaultDeviceStrategy) GenerateDeviceCode(_ context.Context, _ fosite.Requester) (code string, signature string, err error) {
+	return h.Enigma.Generate()
+}
+
+func (h *DefaultDeviceStrategy) ValidateDeviceCode(_ context.Context, r fosite.Requester, code string) (err error) {
+	if err := h.validateExpiry(r, fosite.DeviceCode); err != nil {
+		return err
+	}
+	return h.Enigma.Validate(code)
+}
+
+// UserCodeSignature returns the HMAC of the normalized user code. Dashes, spaces and lower case letters entered
+// by the end-user are ignored, as the user code is meant to be typed in by hand.
+func (h *DefaultDeviceStrategy) UserCodeSignature(code string) (signature string, err error) {
+	return h.Enigma.GenerateHMACForString(normalizeUserCode(code))
+}
+
+// GenerateUserCode generates a user code and records its signature in the session, if it is a DeviceCodeSession, so
+// that ValidateUserCode can verify the code later on.
+func (h *DefaultDeviceStrategy) GenerateUserCode(_ context.Context, r fosite.Requester) (code string, signature string, err error) {
+	max := big.NewInt(int64(len(userCodeCharset)))
+	var b strings.Builder
+	for i := 0; i < userCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", "", errorsx.WithStack(err)
+		}
+		b.WriteByte(userCodeCharset[n.Int64()])
+	}
+
+	code = b.String()
+	signature, err = h.UserCodeSignature(code)
+	if err != nil {
+		return "", "", err
+	}
+	if s, ok := r.GetSession().(DeviceCodeSession); ok {
+		s.SetUserCodeSignature(signature)
+	}
+	return code, signature, nil
+}
+
+// ValidateUserCode checks that the user code has not expired and matches the user code signature recorded in the
+// session by GenerateUserCode.
+func (h *DefaultDeviceStrategy) ValidateUserCode(_ context.Context, r fosite.Requester, code string) (err error) {
+	if err := h.validateExpiry(r, fosite.UserCode); err != nil {
+		return err
+	}
+
+	s, ok := r.GetSession().(DeviceCodeSession)
+	if !ok || s.GetUserCodeSignature() == "" {
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The user code has not been issued for this device authorization request."))
+	}
+	signature, err := h.UserCodeSignature(code)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	} else if subtle.ConstantTimeCompare([]byte(signature), []byte(s.GetUserCodeSignature())) != 1 {
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The user code does not match the device authorization request."))
+	}
+	return nil
+}
+
+func (h *DefaultDeviceStrategy) validateExpiry(r fosite.Requester, tokenType fosite.TokenType) error {
+	exp := r.GetSession().GetExpiresAt(tokenType)
+	if exp.IsZero() {
+		exp = r.GetRequestedAt().Add(h.DeviceAndUserCodeLifespan)
+	}
+	if exp.Before(time.Now().UTC()) {
+		return errorsx.WithStack(fosite.ErrExpiredToken.WithHintf("The %s expired at '%s'.", tokenType, exp))
+	}
+	return nil
+}
+
+func normalizeUserCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8628
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/token/hmac"
+)
+
+var deviceStrategy = &DefaultDeviceStrategy{
+	Enigma:                    &hmac.HMACStrategy{GlobalSecret: []byte("foobarfoobarfoobarfoobarfoobarfoobarfoobarfoobar")},
+	DeviceAndUserCodeLifespan: time.Minute * 10,
+}
+
+func TestDefaultDeviceStrategy_DeviceCode(t *testing.T) {
+	r := &fosite.Request{
+		RequestedAt: time.Now().UTC(),
+		Session:     &DefaultSession{},
+	}
+
+	code, signature, err := deviceStrategy.GenerateDeviceCode(context.Background(), r)
+	require.NoError(t, err)
+	assert.Equal(t, signature, deviceStrategy.DeviceCodeSignature(code))
+	require.NoError(t, deviceStrategy.ValidateDeviceCode(context.Background(), r, code))
+
+	r.Session.SetExpiresAt(fosite.DeviceCode, time.Now().UTC().Add(-time.Minute))
+	err = deviceStrategy.ValidateDeviceCode(context.Background(), r, code)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, fosite.ErrExpiredToken)
+}
+
+func TestDefaultDeviceStrategy_UserCode(t *testing.T) {
+	r := &fosite.Request{
+		RequestedAt: time.Now().UTC(),
+		Session:     &DefaultSession{},
+	}
+
+	code, signature, err := deviceStrategy.GenerateUserCode(context.Background(), r)
+	require.NoError(t, err)
+	require.Len(t, code, userCodeLength)
+	for _, c := range code {
+		assert.Contains(t, userCodeCharset, string(c))
+	}
+
+	for _, entered := range []string{code, code[:4] + "-" + code[4:], code[:4] + " " + code[4:]} {
+		actual, err := deviceStrategy.UserCodeSignature(entered)
+		require.NoError(t, err)
+		assert.Equal(t, signature, actual, "%s", entered)
+	}
+
+	assert.Equal(t, signature, r.Session.(*DefaultSession).UserCodeSignature)
+	require.NoError(t, deviceStrategy.ValidateUserCode(context.Background(), r, code))
+	require.NoError(t, deviceStrategy.ValidateUserCode(context.Background(), r, code[:4]+"-"+code[4:]))
+
+	other, _, err := deviceStrategy.GenerateUserCode(context.Background(), &fosite.Request{Session: &DefaultSession{}})
+	require.NoError(t, err)
+	if other != code {
+		assert.ErrorIs(t, deviceStrategy.ValidateUserCode(context.Background(), r, other), fosite.ErrInvalidGrant)
+	}
+	assert.ErrorIs(t, deviceStrategy.ValidateUserCode(context.Background(), &fosite.Request{RequestedAt: time.Now().UTC(), Session: &DefaultSession{}}, code), fosite.ErrInvalidGrant)
+
+	r.RequestedAt = time.Now().UTC().Add(-time.Hour)
+	assert.ErrorIs(t, deviceStrategy.ValidateUserCode(context.Background(), r, code), fosite.ErrExpiredToken)
+}
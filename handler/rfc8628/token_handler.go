@@ -0,0 +1,244 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8628
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/storage"
+)
+
+// slowDownIncrement is added to the polling interval of a device code each time the client polls too fast.
+const slowDownIncrement = 5 * time.Second
+
+// DeviceCodeTokenHandler is a token endpoint handler for the device authorization grant as defined in
+// https://tools.ietf.org/html/rfc8628#section-3.4
+type DeviceCodeTokenHandler struct {
+	Storage              RFC8628CoreStorage
+	DeviceCodeStrategy   DeviceCodeStrategy
+	AccessTokenStrategy  oauth2.AccessTokenStrategy
+	RefreshTokenStrategy oauth2.RefreshTokenStrategy
+
+	AccessTokenLifespan  time.Duration
+	RefreshTokenLifespan time.Duration
+
+	// PollingInterval is the minimum amount of time the client has to wait between polling requests. Clients
+	// polling more frequently receive a "slow_down" error and the interval enforced for their device code grows by
+	// five seconds.
+	PollingInterval time.Duration
+
+	RefreshTokenScopes []string
+
+	// IssuanceHooks are notified about the issued tokens once they have been persisted.
+	IssuanceHooks *oauth2.TokenIssuanceHooks
+}
+
+// HandleTokenEndpointRequest implements https://tools.ietf.org/html/rfc8628#section-3.4
+func (c *DeviceCodeTokenHandler) HandleTokenEndpointRequest(ctx context.Context, request fosite.AccessRequester) error {
+	if !c.CanHandleTokenEndpointRequest(request) {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	if err := fosite.ValidateClientGrantType(request.GetClient(), grantTypeDeviceCode); err != nil {
+		return err
+	}
+
+	code := request.GetRequestForm().Get("device_code")
+	if code == "" {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The \"device_code\" parameter is missing."))
+	}
+
+	signature := c.DeviceCodeStrategy.DeviceCodeSignature(code)
+	deviceRequest, err := c.Storage.GetDeviceCodeSession(ctx, signature, request.GetSession())
+	if errors.Is(err, fosite.ErrInvalidatedDeviceCode) {
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The device code has already been used."))
+	} else if err != nil && errors.Is(err, fosite.ErrNotFound) {
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithWrap(err).WithDebug(err.Error()))
+	} else if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	// This needs to happen after store retrieval for the session to be hydrated properly
+	if err := c.DeviceCodeStrategy.ValidateDeviceCode(ctx, deviceRequest, code); err != nil {
+		if errors.Is(err, fosite.ErrExpiredToken) {
+			return err
+		}
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if deviceRequest.GetClient().GetID() != request.GetClient().GetID() {
+		return errorsx.WithStack(fosite.ErrInvalidGrant.WithHint("The OAuth 2.0 Client ID from this request does not match the one from the device authorization request."))
+	}
+
+	session, ok := deviceRequest.GetSession().(DeviceCodeSession)
+	if !ok {
+		return errorsx.WithStack(fosite.ErrServerError.WithHint("Session must be of type rfc8628.DeviceCodeSession."))
+	}
+
+	// The client is expected to wait at least the polling interval between two requests. Each "slow_down" error
+	// increases the interval for this and all subsequent requests by five seconds, see
+	// https://tools.ietf.org/html/rfc8628#section-3.5
+	now := time.Now().UTC()
+	interval := session.GetPollingInterval()
+	if interval < c.PollingInterval {
+		interval = c.PollingInterval
+	}
+	lastPolledAt := session.GetLastPolledAt()
+	slowDown := !lastPolledAt.IsZero() && now.Sub(lastPolledAt) < interval
+	if slowDown {
+		interval += slowDownIncrement
+	}
+	session.SetLastPolledAt(now)
+	session.SetPollingInterval(interval)
+	if err := c.Storage.UpdateDeviceCodeSession(ctx, signature, deviceRequest); err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+	if slowDown {
+		return errorsx.WithStack(fosite.ErrSlowDown)
+	}
+
+	switch session.GetDeviceCodeStatus() {
+	case DeviceCodeStatusApproved:
+	case DeviceCodeStatusDenied:
+		return errorsx.WithStack(fosite.ErrAccessDenied.WithHint("The end-user denied the device authorization request."))
+	default:
+		return errorsx.WithStack(fosite.ErrAuthorizationPending)
+	}
+
+	request.SetRequestedScopes(deviceRequest.GetRequestedScopes())
+	request.SetRequestedAudience(deviceRequest.GetRequestedAudience())
+	for _, scope := range deviceRequest.GetGrantedScopes() {
+		request.GrantScope(scope)
+	}
+	for _, audience := range deviceRequest.GetGrantedAudience() {
+		request.GrantAudience(audience)
+	}
+
+	request.SetSession(deviceRequest.GetSession())
+	request.SetID(deviceRequest.GetID())
+
+	request.GetSession().SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(fosite.GetEffectiveLifespan(request.GetClient(), grantTypeDeviceCode, fosite.AccessToken, c.AccessTokenLifespan)).Round(time.Second))
+	if refreshTokenLifespan := fosite.GetEffectiveLifespan(request.GetClient(), grantTypeDeviceCode, fosite.RefreshToken, c.RefreshTokenLifespan); refreshTokenLifespan > -1 {
+		request.GetSession().SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(refreshTokenLifespan).Round(time.Second))
+	}
+
+	return nil
+}
+
+func (c *DeviceCodeTokenHandler) canIssueRefreshToken(request fosite.Requester) bool {
+	// Require one of the refresh token scopes, if set.
+	if len(c.RefreshTokenScopes) > 0 && !request.GetGrantedScopes().HasOneOf(c.RefreshTokenScopes...) {
+		return false
+	}
+	// Do not issue a refresh token to clients that cannot use the refresh token grant type.
+	if !request.GetClient().GetGrantTypes().Has("refresh_token") {
+		return false
+	}
+	return true
+}
+
+func (c *DeviceCodeTokenHandler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	if !c.CanHandleTokenEndpointRequest(requester) {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	signature := c.DeviceCodeStrategy.DeviceCodeSignature(requester.GetRequestForm().Get("device_code"))
+
+	access, accessSignature, err := c.AccessTokenStrategy.GenerateAccessToken(ctx, requester)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	var refresh, refreshSignature string
+	if c.canIssueRefreshToken(requester) {
+		refresh, refreshSignature, err = c.RefreshTokenStrategy.GenerateRefreshToken(ctx, requester)
+		if err != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+	}
+
+	ctx, err = storage.MaybeBeginTx(ctx, c.Storage)
+	if err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if err := c.Storage.InvalidateDeviceCodeSession(ctx, signature); err != nil {
+		if rollBackTxnErr := storage.MaybeRollbackTx(ctx, c.Storage); rollBackTxnErr != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("error: %s; rollback error: %s", err, rollBackTxnErr))
+		}
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	} else if err := c.Storage.CreateAccessTokenSession(ctx, accessSignature, requester.Sanitize([]string{})); err != nil {
+		if rollBackTxnErr := storage.MaybeRollbackTx(ctx, c.Storage); rollBackTxnErr != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("error: %s; rollback error: %s", err, rollBackTxnErr))
+		}
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	} else if refreshSignature != "" {
+		if err := c.Storage.CreateRefreshTokenSession(ctx, refreshSignature, requester.Sanitize([]string{})); err != nil {
+			if rollBackTxnErr := storage.MaybeRollbackTx(ctx, c.Storage); rollBackTxnErr != nil {
+				return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebugf("error: %s; rollback error: %s", err, rollBackTxnErr))
+			}
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+	}
+
+	responder.SetAccessToken(access)
+	responder.SetTokenType("bearer")
+	responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, c.AccessTokenLifespan, time.Now().UTC()))
+	responder.SetScopes(requester.GetGrantedScopes())
+	if refresh != "" {
+		responder.SetExtra("refresh_token", refresh)
+	}
+
+	if err := storage.MaybeCommitTx(ctx, c.Storage); err != nil {
+		return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	if err := c.IssuanceHooks.TokensIssued(ctx, requester, accessSignature, refreshSignature); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *DeviceCodeTokenHandler) CanSkipClientAuth(requester fosite.AccessRequester) bool {
+	return false
+}
+
+func (c *DeviceCodeTokenHandler) CanHandleTokenEndpointRequest(requester fosite.AccessRequester) bool {
+	// grant_type REQUIRED.
+	// Value MUST be set to "urn:ietf:params:oauth:grant-type:device_code"
+	return requester.GetGrantTypes().ExactOne(grantTypeDeviceCode)
+}
+
+func getExpiresIn(r fosite.Requester, key fosite.TokenType, defaultLifespan time.Duration, now time.Time) time.Duration {
+	if r.GetSession().GetExpiresAt(key).IsZero() {
+		return defaultLifespan
+	}
+	return time.Duration(r.GetSession().GetExpiresAt(key).UnixNano() - now.UnixNano())
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8628
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/hmac"
+)
+
+var hmacshaStrategy = oauth2.HMACSHAStrategy{
+	Enigma:               &hmac.HMACStrategy{GlobalSecret: []byte("foobarfoobarfoobarfoobarfoobarfoobarfoobarfoobar")},
+	AccessTokenLifespan:  time.Hour,
+	RefreshTokenLifespan: time.Hour * 24,
+}
+
+func TestDeviceCodeTokenHandlerSlowDown(t *testing.T) {
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{grantTypeDeviceCode}}
+	store := storage.NewMemoryStore()
+	h := &DeviceCodeTokenHandler{
+		Storage:            store,
+		DeviceCodeStrategy: deviceStrategy,
+		PollingInterval:    time.Second * 5,
+	}
+
+	dr := fosite.NewDeviceRequest()
+	dr.Client = client
+	dr.Session = &DefaultSession{}
+	dr.Session.SetExpiresAt(fosite.DeviceCode, time.Now().UTC().Add(time.Minute))
+	deviceCode, signature, err := deviceStrategy.GenerateDeviceCode(context.Background(), dr)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateDeviceCodeSession(context.Background(), signature, "user-code-signature", dr))
+
+	poll := func() error {
+		return h.HandleTokenEndpointRequest(context.Background(), &fosite.AccessRequest{
+			GrantTypes: fosite.Arguments{grantTypeDeviceCode},
+			Request:    fosite.Request{Client: client, Form: url.Values{"device_code": {deviceCode}}, Session: &DefaultSession{}},
+		})
+	}
+	interval := func() time.Duration {
+		stored, err := store.GetDeviceCodeSession(context.Background(), signature, &DefaultSession{})
+		require.NoError(t, err)
+		return stored.GetSession().(DeviceCodeSession).GetPollingInterval()
+	}
+
+	require.EqualError(t, poll(), fosite.ErrAuthorizationPending.Error())
+	assert.Equal(t, time.Second*5, interval())
+
+	require.EqualError(t, poll(), fosite.ErrSlowDown.Error())
+	assert.Equal(t, time.Second*10, interval())
+
+	require.EqualError(t, poll(), fosite.ErrSlowDown.Error())
+	assert.Equal(t, time.Second*15, interval())
+}
+
+func TestDeviceCodeTokenHandler(t *testing.T) {
+	client := &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{grantTypeDeviceCode, "refresh_token"}}
+
+	for k, c := range []struct {
+		description string
+		setup       func(t *testing.T, store *storage.MemoryStore, areq *fosite.AccessRequest, dr *fosite.DeviceRequest)
+		expectErr   error
+		check       func(t *testing.T, aresp *fosite.AccessResponse)
+	}{
+		{
+			description: "should fail because the grant type is not supported by the handler",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest, _ *fosite.DeviceRequest) {
+				areq.GrantTypes = fosite.Arguments{"authorization_code"}
+			},
+			expectErr: fosite.ErrUnknownRequest,
+		},
+		{
+			description: "should fail because the client is not allowed to use the grant type",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest, _ *fosite.DeviceRequest) {
+				areq.Client = &fosite.DefaultClient{ID: "foo", GrantTypes: fosite.Arguments{"authorization_code"}}
+			},
+			expectErr: fosite.ErrUnauthorizedClient,
+		},
+		{
+			description: "should fail because the device code is missing",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest, _ *fosite.DeviceRequest) {
+				areq.Form.Del("device_code")
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the device code is unknown",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest, _ *fosite.DeviceRequest) {
+				areq.Form.Set("device_code", "foo.bar")
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should fail because the end-user has not yet approved the request",
+			expectErr:   fosite.ErrAuthorizationPending,
+		},
+		{
+			description: "should fail because the client polls too fast",
+			setup: func(t *testing.T, _ *storage.MemoryStore, _ *fosite.AccessRequest, dr *fosite.DeviceRequest) {
+				dr.Session.(*DefaultSession).LastPolledAt = time.Now().UTC().Add(-time.Second)
+			},
+			expectErr: fosite.ErrSlowDown,
+		},
+		{
+			description: "should fail because the client polls faster than the interval raised by a previous slow_down",
+			setup: func(t *testing.T, _ *storage.MemoryStore, _ *fosite.AccessRequest, dr *fosite.DeviceRequest) {
+				dr.Session.(*DefaultSession).DeviceCodeStatus = DeviceCodeStatusApproved
+				dr.Session.(*DefaultSession).LastPolledAt = time.Now().UTC().Add(-time.Second * 7)
+				dr.Session.(*DefaultSession).PollingInterval = time.Second * 10
+			},
+			expectErr: fosite.ErrSlowDown,
+		},
+		{
+			description: "should fail because the end-user denied the request",
+			setup: func(t *testing.T, _ *storage.MemoryStore, _ *fosite.AccessRequest, dr *fosite.DeviceRequest) {
+				dr.Session.(*DefaultSession).DeviceCodeStatus = DeviceCodeStatusDenied
+			},
+			expectErr: fosite.ErrAccessDenied,
+		},
+		{
+			description: "should fail because the device code expired",
+			setup: func(t *testing.T, _ *storage.MemoryStore, _ *fosite.AccessRequest, dr *fosite.DeviceRequest) {
+				dr.Session.SetExpiresAt(fosite.DeviceCode, time.Now().UTC().Add(-time.Minute))
+			},
+			expectErr: fosite.ErrExpiredToken,
+		},
+		{
+			description: "should fail because the device code was issued to another client",
+			setup: func(t *testing.T, _ *storage.MemoryStore, _ *fosite.AccessRequest, dr *fosite.DeviceRequest) {
+				dr.Client = &fosite.DefaultClient{ID: "bar", GrantTypes: fosite.Arguments{grantTypeDeviceCode}}
+			},
+			expectErr: fosite.ErrInvalidGrant,
+		},
+		{
+			description: "should pass and issue an access and a refresh token",
+			setup: func(t *testing.T, _ *storage.MemoryStore, _ *fosite.AccessRequest, dr *fosite.DeviceRequest) {
+				dr.Session.(*DefaultSession).DeviceCodeStatus = DeviceCodeStatusApproved
+				dr.Session.(*DefaultSession).LastPolledAt = time.Now().UTC().Add(-time.Minute)
+			},
+			check: func(t *testing.T, aresp *fosite.AccessResponse) {
+				assert.NotEmpty(t, aresp.AccessToken)
+				assert.Equal(t, "bearer", aresp.TokenType)
+				assert.NotEmpty(t, aresp.GetExtra("refresh_token"))
+				assert.Equal(t, "offline", aresp.GetExtra("scope"))
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			h := &DeviceCodeTokenHandler{
+				Storage:              store,
+				DeviceCodeStrategy:   deviceStrategy,
+				AccessTokenStrategy:  &hmacshaStrategy,
+				RefreshTokenStrategy: &hmacshaStrategy,
+				AccessTokenLifespan:  time.Hour,
+				RefreshTokenLifespan: time.Hour * 24,
+				PollingInterval:      time.Second * 5,
+				RefreshTokenScopes:   []string{"offline"},
+			}
+
+			dr := fosite.NewDeviceRequest()
+			dr.Client = client
+			dr.RequestedScope = fosite.Arguments{"offline"}
+			dr.GrantedScope = fosite.Arguments{"offline"}
+			dr.Session = &DefaultSession{}
+			dr.Session.SetExpiresAt(fosite.DeviceCode, time.Now().UTC().Add(time.Minute))
+
+			deviceCode, deviceCodeSignature, err := deviceStrategy.GenerateDeviceCode(context.Background(), dr)
+			require.NoError(t, err)
+			_, userCodeSignature, err := deviceStrategy.GenerateUserCode(context.Background(), dr)
+			require.NoError(t, err)
+
+			areq := &fosite.AccessRequest{
+				GrantTypes: fosite.Arguments{grantTypeDeviceCode},
+				Request: fosite.Request{
+					Client:      client,
+					Form:        url.Values{"device_code": {deviceCode}},
+					Session:     &DefaultSession{},
+					RequestedAt: time.Now().UTC(),
+				},
+			}
+			if c.setup != nil {
+				c.setup(t, store, areq, dr)
+			}
+			require.NoError(t, store.CreateDeviceCodeSession(context.Background(), deviceCodeSignature, userCodeSignature, dr))
+
+			err = h.HandleTokenEndpointRequest(context.Background(), areq)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			aresp := fosite.NewAccessResponse()
+			require.NoError(t, h.PopulateTokenEndpointResponse(context.Background(), areq, aresp))
+			c.check(t, aresp)
+
+			_, err = store.GetDeviceCodeSession(context.Background(), deviceCodeSignature, nil)
+			assert.ErrorIs(t, err, fosite.ErrInvalidatedDeviceCode)
+
+			areq.Session = &DefaultSession{}
+			err = h.HandleTokenEndpointRequest(context.Background(), areq)
+			require.EqualError(t, err, fosite.ErrInvalidGrant.Error())
+		})
+	}
+}
@@ -0,0 +1,403 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8693
+
+import (
+	"context"
+	"time"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/token/jwt"
+)
+
+const grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+// Token type identifiers as defined in https://tools.ietf.org/html/rfc8693#section-3
+const (
+	AccessTokenType  = "urn:ietf:params:oauth:token-type:access_token"
+	RefreshTokenType = "urn:ietf:params:oauth:token-type:refresh_token"
+	IDTokenType      = "urn:ietf:params:oauth:token-type:id_token"
+	JWTTokenType     = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// Handler is a token endpoint handler for the OAuth 2.0 Token Exchange grant as defined in
+// https://tools.ietf.org/html/rfc8693
+type Handler struct {
+	Storage              TokenExchangeStorage
+	AccessTokenStrategy  oauth2.AccessTokenStrategy
+	RefreshTokenStrategy oauth2.RefreshTokenStrategy
+
+	// IDTokenStrategy issues ID tokens if "requested_token_type" is set to the ID token type. ID tokens can not be
+	// requested if it is nil.
+	IDTokenStrategy openid.OpenIDConnectTokenStrategy
+
+	// TokenValidator validates access and refresh tokens used as subject or actor tokens, usually it is the
+	// oauth2.CoreValidator of the token introspection. If it is nil, the tokens are looked up in Storage and validated
+	// using the token strategies only.
+	TokenValidator fosite.TokenIntrospector
+
+	// JWTStrategy validates ID tokens and JWTs used as subject or actor tokens. Such tokens are rejected if it
+	// is nil.
+	JWTStrategy jwt.JWTStrategy
+
+	// Issuer, if set, must be the "iss" claim of ID tokens and JWTs used as subject or actor tokens.
+	Issuer string
+
+	ScopeStrategy            fosite.ScopeStrategy
+	AudienceMatchingStrategy fosite.AudienceMatchingStrategy
+
+	// ScopeValidator is an optional policy hook deciding whether the client may impersonate or act on
+	// behalf of the subject.
+	ScopeValidator TokenExchangeScopeValidator
+
+	AccessTokenLifespan  time.Duration
+	RefreshTokenLifespan time.Duration
+
+	// IssuanceHooks are notified about the issued tokens once they have been persisted.
+	IssuanceHooks *oauth2.TokenIssuanceHooks
+}
+
+// HandleTokenEndpointRequest implements https://tools.ietf.org/html/rfc8693#section-2.1
+func (c *Handler) HandleTokenEndpointRequest(ctx context.Context, request fosite.AccessRequester) error {
+	if !c.CanHandleTokenEndpointRequest(request) {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	client := request.GetClient()
+	if err := fosite.ValidateClientGrantType(client, grantTypeTokenExchange); err != nil {
+		return err
+	}
+
+	form := request.GetRequestForm()
+	if form.Get("subject_token") == "" {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The \"subject_token\" parameter is missing."))
+	} else if form.Get("subject_token_type") == "" {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The \"subject_token_type\" parameter is missing."))
+	} else if form.Get("actor_token") != "" && form.Get("actor_token_type") == "" {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The \"actor_token_type\" parameter is required when the \"actor_token\" parameter is set."))
+	} else if form.Get("actor_token") == "" && form.Get("actor_token_type") != "" {
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The \"actor_token_type\" parameter must not be set when the \"actor_token\" parameter is missing."))
+	}
+
+	switch requestedTokenType(request) {
+	case AccessTokenType:
+	case RefreshTokenType:
+		if !client.GetGrantTypes().Has("refresh_token") {
+			return errorsx.WithStack(fosite.ErrUnauthorizedClient.WithHint("The OAuth 2.0 Client is not allowed to use the refresh_token grant and can therefore not request refresh tokens."))
+		}
+	case IDTokenType:
+		if c.IDTokenStrategy == nil {
+			return errorsx.WithStack(fosite.ErrInvalidRequest.WithHintf("The requested token type '%s' is not supported.", IDTokenType))
+		}
+	default:
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHintf("The requested token type '%s' is not supported.", requestedTokenType(request)))
+	}
+
+	session, ok := request.GetSession().(Session)
+	if !ok {
+		return errorsx.WithStack(fosite.ErrServerError.WithHint("Session must be of type rfc8693.Session."))
+	}
+
+	subject, err := c.validateToken(ctx, client, form.Get("subject_token"), form.Get("subject_token_type"))
+	if err != nil {
+		return err
+	}
+
+	var actor *ExchangedToken
+	if form.Get("actor_token") != "" {
+		actor, err = c.validateToken(ctx, client, form.Get("actor_token"), form.Get("actor_token_type"))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := c.grantScopes(request, subject); err != nil {
+		return err
+	}
+
+	if err := c.grantAudience(request); err != nil {
+		return err
+	}
+
+	if c.ScopeValidator != nil {
+		if err := c.ScopeValidator.ValidateTokenExchange(ctx, request, subject, actor); err != nil {
+			return err
+		}
+	}
+
+	session.SetSubject(subject.Subject)
+	if actor != nil {
+		// The "act" claim of the subject token identifies the prior actors of the delegation chain, the new actor
+		// becomes the current one, see https://tools.ietf.org/html/rfc8693#section-4.1
+		act := map[string]interface{}{"sub": actor.Subject}
+		if subject.ActorClaim != nil {
+			act["act"] = subject.ActorClaim
+		}
+		session.SetActorClaim(act)
+	} else {
+		session.SetActorClaim(nil)
+	}
+
+	session.SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(fosite.GetEffectiveLifespan(request.GetClient(), grantTypeTokenExchange, fosite.AccessToken, c.AccessTokenLifespan)).Round(time.Second))
+	if refreshTokenLifespan := fosite.GetEffectiveLifespan(request.GetClient(), grantTypeTokenExchange, fosite.RefreshToken, c.RefreshTokenLifespan); refreshTokenLifespan > -1 {
+		session.SetExpiresAt(fosite.RefreshToken, time.Now().UTC().Add(refreshTokenLifespan).Round(time.Second))
+	}
+
+	return nil
+}
+
+// PopulateTokenEndpointResponse implements https://tools.ietf.org/html/rfc8693#section-2.2
+func (c *Handler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	if !c.CanHandleTokenEndpointRequest(requester) {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	tokenType := requestedTokenType(requester)
+	switch tokenType {
+	case AccessTokenType:
+		token, signature, err := c.AccessTokenStrategy.GenerateAccessToken(ctx, requester)
+		if err != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		} else if err := c.Storage.CreateAccessTokenSession(ctx, signature, requester.Sanitize([]string{})); err != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		} else if err := c.IssuanceHooks.AccessTokenIssued(ctx, requester, signature); err != nil {
+			return err
+		}
+
+		responder.SetAccessToken(token)
+		responder.SetTokenType("bearer")
+		responder.SetExpiresIn(getExpiresIn(requester, fosite.AccessToken, c.AccessTokenLifespan, time.Now().UTC()))
+	case RefreshTokenType:
+		token, signature, err := c.RefreshTokenStrategy.GenerateRefreshToken(ctx, requester)
+		if err != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		} else if err := c.Storage.CreateRefreshTokenSession(ctx, signature, requester.Sanitize([]string{})); err != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		} else if err := c.IssuanceHooks.RefreshTokenIssued(ctx, requester, signature); err != nil {
+			return err
+		}
+
+		// The issued token is not an access token, see https://tools.ietf.org/html/rfc8693#section-2.2.1
+		responder.SetAccessToken(token)
+		responder.SetTokenType("N_A")
+		if c.RefreshTokenLifespan > -1 {
+			responder.SetExpiresIn(getExpiresIn(requester, fosite.RefreshToken, c.RefreshTokenLifespan, time.Now().UTC()))
+		}
+	case IDTokenType:
+		token, err := c.IDTokenStrategy.GenerateIDToken(ctx, requester)
+		if err != nil {
+			return errorsx.WithStack(fosite.ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+
+		responder.SetAccessToken(token)
+		responder.SetTokenType("N_A")
+	default:
+		return errorsx.WithStack(fosite.ErrInvalidRequest.WithHintf("The requested token type '%s' is not supported.", tokenType))
+	}
+
+	responder.SetScopes(requester.GetGrantedScopes())
+	responder.SetExtra("issued_token_type", tokenType)
+	return nil
+}
+
+func (c *Handler) CanSkipClientAuth(requester fosite.AccessRequester) bool {
+	return false
+}
+
+func (c *Handler) CanHandleTokenEndpointRequest(requester fosite.AccessRequester) bool {
+	// grant_type REQUIRED.
+	// Value MUST be set to "urn:ietf:params:oauth:grant-type:token-exchange"
+	return requester.GetGrantTypes().ExactOne(grantTypeTokenExchange)
+}
+
+func (c *Handler) validateToken(ctx context.Context, client fosite.Client, token string, tokenType string) (*ExchangedToken, error) {
+	switch tokenType {
+	case AccessTokenType:
+		or, err := c.introspectToken(ctx, token, fosite.AccessToken)
+		if err != nil {
+			return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Unable to validate the access token.").WithWrap(err).WithDebug(err.Error()))
+		}
+		return exchangedTokenFromRequest(token, tokenType, or), nil
+	case RefreshTokenType:
+		or, err := c.introspectToken(ctx, token, fosite.RefreshToken)
+		if err != nil {
+			return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Unable to validate the refresh token.").WithWrap(err).WithDebug(err.Error()))
+		}
+		return exchangedTokenFromRequest(token, tokenType, or), nil
+	case IDTokenType, JWTTokenType:
+		if c.JWTStrategy == nil {
+			return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHintf("The token type '%s' is not supported.", tokenType))
+		}
+
+		t, err := c.JWTStrategy.Decode(ctx, token)
+		if err != nil {
+			return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("Unable to validate the JSON Web Token.").WithWrap(err).WithDebug(err.Error()))
+		}
+
+		claims := map[string]interface{}(t.Claims)
+		sub, _ := claims["sub"].(string)
+		if sub == "" {
+			return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The JSON Web Token does not contain a subject."))
+		} else if iss, _ := claims["iss"].(string); c.Issuer != "" && iss != c.Issuer {
+			return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The JSON Web Token was not issued by this authorization server."))
+		}
+
+		// ID tokens are only exchanged by the OAuth 2.0 Clients they were issued to, see
+		// https://openid.net/specs/openid-connect-core-1_0.html#IDTokenValidation
+		if tokenType == IDTokenType && !t.Claims.VerifyAudience(client.GetID(), true) {
+			return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHint("The ID token was not issued to the OAuth 2.0 Client."))
+		}
+
+		clientID, _ := claims["azp"].(string)
+		if clientID == "" {
+			switch aud := claims["aud"].(type) {
+			case string:
+				clientID = aud
+			case []interface{}:
+				if len(aud) == 1 {
+					clientID, _ = aud[0].(string)
+				}
+			}
+		}
+
+		act, _ := claims["act"].(map[string]interface{})
+		return &ExchangedToken{
+			Token:      token,
+			TokenType:  tokenType,
+			Subject:    sub,
+			ClientID:   clientID,
+			ActorClaim: act,
+			Claims:     claims,
+		}, nil
+	}
+
+	return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHintf("The token type '%s' is not supported.", tokenType))
+}
+
+// introspectToken validates the access or refresh token and returns its stored request.
+func (c *Handler) introspectToken(ctx context.Context, token string, tokenUse fosite.TokenUse) (fosite.Requester, error) {
+	if c.TokenValidator != nil {
+		ar := fosite.NewAccessRequest(NewDefaultSession())
+		use, err := c.TokenValidator.IntrospectToken(ctx, token, tokenUse, ar, []string{})
+		if err != nil {
+			return nil, err
+		} else if use != tokenUse {
+			return nil, errorsx.WithStack(fosite.ErrInvalidRequest.WithHintf("The token is not of type '%s'.", tokenUse))
+		}
+		return ar, nil
+	}
+
+	if tokenUse == fosite.AccessToken {
+		or, err := c.Storage.GetAccessTokenSession(ctx, c.AccessTokenStrategy.AccessTokenSignature(token), NewDefaultSession())
+		if err != nil {
+			return nil, err
+		} else if err := c.AccessTokenStrategy.ValidateAccessToken(ctx, or, token); err != nil {
+			return nil, err
+		}
+		return or, nil
+	}
+
+	or, err := c.Storage.GetRefreshTokenSession(ctx, c.RefreshTokenStrategy.RefreshTokenSignature(token), NewDefaultSession())
+	if err != nil {
+		return nil, err
+	} else if err := c.RefreshTokenStrategy.ValidateRefreshToken(ctx, or, token); err != nil {
+		return nil, err
+	}
+	return or, nil
+}
+
+func (c *Handler) grantScopes(request fosite.AccessRequester, subject *ExchangedToken) error {
+	client := request.GetClient()
+	scopes := request.GetRequestedScopes()
+	if len(scopes) == 0 && subject.Request != nil {
+		// Without an explicit scope the exchanged token inherits the scopes of the subject token the client is
+		// allowed to request.
+		for _, scope := range subject.GrantedScopes {
+			if c.ScopeStrategy(client.GetScopes(), scope) {
+				request.GrantScope(scope)
+			}
+		}
+		return nil
+	}
+
+	for _, scope := range scopes {
+		if !c.ScopeStrategy(client.GetScopes(), scope) {
+			return errorsx.WithStack(fosite.ErrInvalidScope.WithHintf("The OAuth 2.0 Client is not allowed to request scope '%s'.", scope))
+		}
+		if subject.Request != nil && !c.ScopeStrategy(subject.GrantedScopes, scope) {
+			return errorsx.WithStack(fosite.ErrInvalidScope.WithHintf("The requested scope '%s' has not been granted to the subject token.", scope))
+		}
+		request.GrantScope(scope)
+	}
+	return nil
+}
+
+func (c *Handler) grantAudience(request fosite.AccessRequester) error {
+	audience := append(fosite.Arguments{}, request.GetRequestedAudience()...)
+	for _, resource := range fosite.RemoveEmpty(request.GetRequestForm()["resource"]) {
+		if !audience.Has(resource) {
+			audience = append(audience, resource)
+		}
+	}
+
+	if err := c.AudienceMatchingStrategy(request.GetClient().GetAudience(), audience); err != nil {
+		return err
+	}
+
+	for _, aud := range audience {
+		request.GrantAudience(aud)
+	}
+	return nil
+}
+
+func exchangedTokenFromRequest(token string, tokenType string, or fosite.Requester) *ExchangedToken {
+	t := &ExchangedToken{
+		Token:         token,
+		TokenType:     tokenType,
+		Subject:       or.GetSession().GetSubject(),
+		ClientID:      or.GetClient().GetID(),
+		GrantedScopes: or.GetGrantedScopes(),
+		Request:       or,
+	}
+	if s, ok := or.GetSession().(Session); ok {
+		t.ActorClaim = s.GetActorClaim()
+	}
+	return t
+}
+
+func requestedTokenType(requester fosite.AccessRequester) string {
+	if tokenType := requester.GetRequestForm().Get("requested_token_type"); tokenType != "" {
+		return tokenType
+	}
+	return AccessTokenType
+}
+
+func getExpiresIn(r fosite.Requester, key fosite.TokenType, defaultLifespan time.Duration, now time.Time) time.Duration {
+	if r.GetSession().GetExpiresAt(key).IsZero() {
+		return defaultLifespan
+	}
+	return time.Duration(r.GetSession().GetExpiresAt(key).UnixNano() - now.UnixNano())
+}
@@ -0,0 +1,379 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8693
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/handler/openid"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/hmac"
+	"github.com/ory/fosite/token/jwt"
+)
+
+var hmacshaStrategy = oauth2.HMACSHAStrategy{
+	Enigma:               &hmac.HMACStrategy{GlobalSecret: []byte("foobarfoobarfoobarfoobarfoobarfoobarfoobarfoobar")},
+	AccessTokenLifespan:  time.Hour,
+	RefreshTokenLifespan: time.Hour * 24,
+}
+
+var jwtStrategy = &jwt.RS256JWTStrategy{
+	PrivateKey: internal.MustRSAKey(),
+}
+
+type validatorFunc func(ctx context.Context, requester fosite.AccessRequester, subject *ExchangedToken, actor *ExchangedToken) error
+
+func (f validatorFunc) ValidateTokenExchange(ctx context.Context, requester fosite.AccessRequester, subject *ExchangedToken, actor *ExchangedToken) error {
+	return f(ctx, requester, subject, actor)
+}
+
+func TestHandler(t *testing.T) {
+	client := &fosite.DefaultClient{
+		ID:         "foo",
+		GrantTypes: fosite.Arguments{grantTypeTokenExchange},
+		Scopes:     []string{"foo", "bar"},
+		Audience:   []string{"https://www.ory.sh/api"},
+	}
+
+	issueAccessToken := func(t *testing.T, store *storage.MemoryStore, subject string, scopes fosite.Arguments, act map[string]interface{}) string {
+		session := NewDefaultSession()
+		session.SetSubject(subject)
+		session.SetActorClaim(act)
+		session.SetExpiresAt(fosite.AccessToken, time.Now().UTC().Add(time.Hour))
+		or := &fosite.Request{
+			ID:           subject,
+			Client:       &fosite.DefaultClient{ID: "bar"},
+			GrantedScope: scopes,
+			Session:      session,
+			RequestedAt:  time.Now().UTC(),
+		}
+		token, signature, err := hmacshaStrategy.GenerateAccessToken(context.Background(), or)
+		require.NoError(t, err)
+		require.NoError(t, store.CreateAccessTokenSession(context.Background(), signature, or))
+		return token
+	}
+
+	for k, c := range []struct {
+		description string
+		setup       func(t *testing.T, store *storage.MemoryStore, areq *fosite.AccessRequest)
+		validator   TokenExchangeScopeValidator
+		configure   func(h *Handler, store *storage.MemoryStore)
+		expectErr   error
+		check       func(t *testing.T, store *storage.MemoryStore, areq *fosite.AccessRequest, aresp *fosite.AccessResponse)
+	}{
+		{
+			description: "should fail because the grant type is not supported by the handler",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.GrantTypes = fosite.Arguments{"authorization_code"}
+			},
+			expectErr: fosite.ErrUnknownRequest,
+		},
+		{
+			description: "should fail because the client is not allowed to use the grant type",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Client = &fosite.DefaultClient{ID: "foo"}
+			},
+			expectErr: fosite.ErrUnauthorizedClient,
+		},
+		{
+			description: "should fail because the subject token is missing",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Del("subject_token")
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the subject token type is missing",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Del("subject_token_type")
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the actor token type is missing",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Set("actor_token", "foo.bar")
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the requested token type is not supported",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Set("requested_token_type", "urn:ietf:params:oauth:token-type:saml2")
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the subject token is unknown",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Set("subject_token", "foo.bar")
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the requested scope was not granted to the subject token",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.RequestedScope = fosite.Arguments{"bar"}
+			},
+			expectErr: fosite.ErrInvalidScope,
+		},
+		{
+			description: "should fail because the requested audience is not allowed",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Set("resource", "https://www.ory.sh/not-api")
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the policy hook rejects the exchange",
+			validator: validatorFunc(func(_ context.Context, _ fosite.AccessRequester, _ *ExchangedToken, actor *ExchangedToken) error {
+				if actor == nil {
+					return errors.WithStack(fosite.ErrAccessDenied)
+				}
+				return nil
+			}),
+			expectErr: fosite.ErrAccessDenied,
+		},
+		{
+			description: "should pass and issue an access token for the subject",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Set("resource", "https://www.ory.sh/api")
+			},
+			check: func(t *testing.T, store *storage.MemoryStore, areq *fosite.AccessRequest, aresp *fosite.AccessResponse) {
+				assert.Equal(t, "bearer", aresp.TokenType)
+				assert.Equal(t, AccessTokenType, aresp.GetExtra("issued_token_type"))
+				assert.Equal(t, "foo", aresp.GetExtra("scope"))
+				assert.Equal(t, fosite.Arguments{"https://www.ory.sh/api"}, areq.GetGrantedAudience())
+
+				or, err := store.GetAccessTokenSession(context.Background(), hmacshaStrategy.AccessTokenSignature(aresp.AccessToken), nil)
+				require.NoError(t, err)
+				assert.Equal(t, "peter", or.GetSession().GetSubject())
+				assert.Nil(t, or.GetSession().(Session).GetActorClaim())
+			},
+		},
+		{
+			description: "should pass and build the delegation chain",
+			setup: func(t *testing.T, store *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Set("subject_token", issueAccessToken(t, store, "alice", fosite.Arguments{"foo"}, map[string]interface{}{"sub": "prior-service"}))
+				areq.Form.Set("actor_token", issueAccessToken(t, store, "service", fosite.Arguments{}, nil))
+				areq.Form.Set("actor_token_type", AccessTokenType)
+			},
+			check: func(t *testing.T, store *storage.MemoryStore, areq *fosite.AccessRequest, aresp *fosite.AccessResponse) {
+				or, err := store.GetAccessTokenSession(context.Background(), hmacshaStrategy.AccessTokenSignature(aresp.AccessToken), nil)
+				require.NoError(t, err)
+				assert.Equal(t, "alice", or.GetSession().GetSubject())
+				assert.Equal(t, map[string]interface{}{
+					"sub": "service",
+					"act": map[string]interface{}{"sub": "prior-service"},
+				}, or.GetSession().(Session).GetActorClaim())
+			},
+		},
+		{
+			description: "should fail because the session of the subject token has ended",
+			configure: func(h *Handler, store *storage.MemoryStore) {
+				h.TokenValidator = &oauth2.CoreValidator{
+					CoreStrategy:  &hmacshaStrategy,
+					CoreStorage:   store,
+					ScopeStrategy: fosite.HierarchicScopeStrategy,
+					SessionValidator: func(context.Context, fosite.Requester) error {
+						return errors.WithStack(fosite.ErrInactiveToken)
+					},
+				}
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because an access token is sent as refresh token",
+			configure: func(h *Handler, store *storage.MemoryStore) {
+				h.TokenValidator = &oauth2.CoreValidator{
+					CoreStrategy:  &hmacshaStrategy,
+					CoreStorage:   store,
+					ScopeStrategy: fosite.HierarchicScopeStrategy,
+				}
+			},
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Set("subject_token_type", RefreshTokenType)
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should pass and validate the subject token using the token validator",
+			configure: func(h *Handler, store *storage.MemoryStore) {
+				h.TokenValidator = &oauth2.CoreValidator{
+					CoreStrategy:  &hmacshaStrategy,
+					CoreStorage:   store,
+					ScopeStrategy: fosite.HierarchicScopeStrategy,
+				}
+			},
+			check: func(t *testing.T, store *storage.MemoryStore, areq *fosite.AccessRequest, aresp *fosite.AccessResponse) {
+				or, err := store.GetAccessTokenSession(context.Background(), hmacshaStrategy.AccessTokenSignature(aresp.AccessToken), nil)
+				require.NoError(t, err)
+				assert.Equal(t, "peter", or.GetSession().GetSubject())
+			},
+		},
+		{
+			description: "should fail because the client is not allowed to use refresh tokens",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Form.Set("requested_token_type", RefreshTokenType)
+			},
+			expectErr: fosite.ErrUnauthorizedClient,
+		},
+		{
+			description: "should pass and issue a refresh token",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				areq.Client = &fosite.DefaultClient{
+					ID:         "foo",
+					GrantTypes: fosite.Arguments{grantTypeTokenExchange, "refresh_token"},
+					Scopes:     []string{"foo", "bar"},
+				}
+				areq.Form.Set("requested_token_type", RefreshTokenType)
+			},
+			check: func(t *testing.T, store *storage.MemoryStore, areq *fosite.AccessRequest, aresp *fosite.AccessResponse) {
+				assert.Equal(t, "N_A", aresp.TokenType)
+				assert.Equal(t, RefreshTokenType, aresp.GetExtra("issued_token_type"))
+
+				_, err := store.GetRefreshTokenSession(context.Background(), hmacshaStrategy.RefreshTokenSignature(aresp.AccessToken), nil)
+				require.NoError(t, err)
+			},
+		},
+		{
+			description: "should pass and exchange an id token for an id token",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				token, _, err := jwtStrategy.Generate(context.Background(), jwt.MapClaims{
+					"iss": "https://www.ory.sh/",
+					"sub": "peter",
+					"aud": "foo",
+					"exp": time.Now().Add(time.Hour).Unix(),
+				}, &jwt.Headers{})
+				require.NoError(t, err)
+				areq.Form.Set("subject_token", token)
+				areq.Form.Set("subject_token_type", IDTokenType)
+				areq.Form.Set("requested_token_type", IDTokenType)
+			},
+			validator: validatorFunc(func(_ context.Context, _ fosite.AccessRequester, subject *ExchangedToken, _ *ExchangedToken) error {
+				if subject.ClientID != "foo" {
+					return errors.WithStack(fosite.ErrAccessDenied)
+				}
+				return nil
+			}),
+			check: func(t *testing.T, store *storage.MemoryStore, areq *fosite.AccessRequest, aresp *fosite.AccessResponse) {
+				assert.Equal(t, "N_A", aresp.TokenType)
+				assert.Equal(t, IDTokenType, aresp.GetExtra("issued_token_type"))
+
+				token, err := jwtStrategy.Decode(context.Background(), aresp.AccessToken)
+				require.NoError(t, err)
+				assert.Equal(t, "peter", token.Claims["sub"])
+			},
+		},
+		{
+			description: "should fail because the id token was issued to another client",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				token, _, err := jwtStrategy.Generate(context.Background(), jwt.MapClaims{
+					"iss": "https://www.ory.sh/",
+					"sub": "peter",
+					"aud": "bar",
+					"exp": time.Now().Add(time.Hour).Unix(),
+				}, &jwt.Headers{})
+				require.NoError(t, err)
+				areq.Form.Set("subject_token", token)
+				areq.Form.Set("subject_token_type", IDTokenType)
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+		{
+			description: "should fail because the id token was issued by another issuer",
+			setup: func(t *testing.T, _ *storage.MemoryStore, areq *fosite.AccessRequest) {
+				token, _, err := jwtStrategy.Generate(context.Background(), jwt.MapClaims{
+					"iss": "https://evil.example.com/",
+					"sub": "peter",
+					"aud": "foo",
+					"exp": time.Now().Add(time.Hour).Unix(),
+				}, &jwt.Headers{})
+				require.NoError(t, err)
+				areq.Form.Set("subject_token", token)
+				areq.Form.Set("subject_token_type", IDTokenType)
+			},
+			expectErr: fosite.ErrInvalidRequest,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			h := &Handler{
+				Storage:              store,
+				AccessTokenStrategy:  &hmacshaStrategy,
+				RefreshTokenStrategy: &hmacshaStrategy,
+				IDTokenStrategy: &openid.DefaultStrategy{
+					JWTStrategy: jwtStrategy,
+					Expiry:      time.Hour,
+				},
+				JWTStrategy:              jwtStrategy,
+				Issuer:                   "https://www.ory.sh/",
+				ScopeStrategy:            fosite.HierarchicScopeStrategy,
+				AudienceMatchingStrategy: fosite.DefaultAudienceMatchingStrategy,
+				ScopeValidator:           c.validator,
+				AccessTokenLifespan:      time.Hour,
+				RefreshTokenLifespan:     time.Hour * 24,
+			}
+
+			areq := &fosite.AccessRequest{
+				GrantTypes: fosite.Arguments{grantTypeTokenExchange},
+				Request: fosite.Request{
+					Client: client,
+					Form: url.Values{
+						"subject_token":      {issueAccessToken(t, store, "peter", fosite.Arguments{"foo"}, nil)},
+						"subject_token_type": {AccessTokenType},
+					},
+					Session:     NewDefaultSession(),
+					RequestedAt: time.Now().UTC(),
+				},
+			}
+			if c.setup != nil {
+				c.setup(t, store, areq)
+			}
+			if c.configure != nil {
+				c.configure(h, store)
+			}
+
+			err := h.HandleTokenEndpointRequest(context.Background(), areq)
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			aresp := fosite.NewAccessResponse()
+			require.NoError(t, h.PopulateTokenEndpointResponse(context.Background(), areq, aresp))
+			c.check(t, store, areq, aresp)
+		})
+	}
+}
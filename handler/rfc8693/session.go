@@ -0,0 +1,90 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8693
+
+import (
+	"github.com/mohae/deepcopy"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/openid"
+)
+
+// Session must be implemented by the session if RFC8693 is to be supported.
+type Session interface {
+	fosite.Session
+
+	// SetSubject sets the session's subject.
+	SetSubject(subject string)
+
+	// GetActorClaim returns the "act" claim of the session, which identifies the acting party to whom authority
+	// has been delegated. It returns nil if no delegation took place.
+	GetActorClaim() map[string]interface{}
+
+	// SetActorClaim sets the "act" claim of the session.
+	SetActorClaim(act map[string]interface{})
+}
+
+// DefaultSession is a default implementation of the Session interface. It is also an OpenID Connect session so
+// that ID tokens can be issued as part of the token exchange.
+type DefaultSession struct {
+	*openid.DefaultSession
+	ActorClaim map[string]interface{} `json:"act,omitempty"`
+}
+
+func NewDefaultSession() *DefaultSession {
+	return &DefaultSession{
+		DefaultSession: openid.NewDefaultSession(),
+	}
+}
+
+// SetSubject sets the subject of the session and of the ID token claims.
+func (s *DefaultSession) SetSubject(subject string) {
+	s.DefaultSession.SetSubject(subject)
+	s.IDTokenClaims().Subject = subject
+}
+
+func (s *DefaultSession) GetActorClaim() map[string]interface{} {
+	return s.ActorClaim
+}
+
+// SetActorClaim sets the "act" claim of the session and adds it to the ID token claims.
+func (s *DefaultSession) SetActorClaim(act map[string]interface{}) {
+	s.ActorClaim = act
+
+	claims := s.IDTokenClaims()
+	if act == nil {
+		delete(claims.Extra, "act")
+		return
+	}
+	if claims.Extra == nil {
+		claims.Extra = map[string]interface{}{}
+	}
+	claims.Extra["act"] = act
+}
+
+func (s *DefaultSession) Clone() fosite.Session {
+	if s == nil {
+		return nil
+	}
+
+	return deepcopy.Copy(s).(fosite.Session)
+}
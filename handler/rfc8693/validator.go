@@ -0,0 +1,64 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc8693
+
+import (
+	"context"
+
+	"github.com/ory/fosite"
+)
+
+// ExchangedToken holds the information extracted from a validated subject or actor token.
+type ExchangedToken struct {
+	// Token is the raw token as sent by the client.
+	Token string
+
+	// TokenType is the token type identifier as sent by the client, for example
+	// "urn:ietf:params:oauth:token-type:access_token".
+	TokenType string
+
+	// Subject is the subject the token was issued for.
+	Subject string
+
+	// ClientID is the ID of the OAuth 2.0 Client the token was issued to, if known.
+	ClientID string
+
+	// GrantedScopes are the scopes the token was granted. Empty for ID tokens and JWTs.
+	GrantedScopes fosite.Arguments
+
+	// ActorClaim is the "act" claim of the token, if any.
+	ActorClaim map[string]interface{}
+
+	// Request is the stored request of access and refresh tokens. It is nil for ID tokens and JWTs.
+	Request fosite.Requester
+
+	// Claims are the claims of ID tokens and JWTs. It is nil for access and refresh tokens.
+	Claims map[string]interface{}
+}
+
+// TokenExchangeScopeValidator is a policy hook which allows integrators to restrict which OAuth 2.0 Clients may
+// impersonate a subject or act on behalf of it. The actor is nil if no actor token was sent, which means that the
+// client asks for impersonation. Returning an error aborts the token exchange, the error should usually be
+// fosite.ErrAccessDenied or fosite.ErrInvalidScope.
+type TokenExchangeScopeValidator interface {
+	ValidateTokenExchange(ctx context.Context, requester fosite.AccessRequester, subject *ExchangedToken, actor *ExchangedToken) error
+}
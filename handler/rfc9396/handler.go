@@ -0,0 +1,152 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc9396
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ory/x/errorsx"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+	"github.com/ory/fosite/token/jwt"
+)
+
+// ClaimName is the name of the claim and the token response parameter holding the granted authorization details.
+const ClaimName = "authorization_details"
+
+// AuthorizationDetailsHandler stores the authorization details which were requested at the authorization endpoint
+// in the session and returns the granted authorization details in the token response, as defined in
+// https://tools.ietf.org/html/rfc9396. Because fosite stores the session with the tokens, the details are part
+// of JWT access tokens and the introspection response as well.
+//
+// Authorization details requested at the token endpoint replace the details of the session. They must be a subset of
+// the authorization details of the grant, so they are rejected if the grant carries none. The handler never handles
+// a grant on its own. It must be registered after the handlers of the grants the client uses, because these replace
+// the session of the access request.
+type AuthorizationDetailsHandler struct{}
+
+func (c *AuthorizationDetailsHandler) HandleAuthorizeEndpointRequest(ctx context.Context, ar fosite.AuthorizeRequester, resp fosite.AuthorizeResponder) error {
+	requester, ok := ar.(fosite.AuthorizationDetailsRequester)
+	if !ok || len(requester.GetAuthorizationDetails()) == 0 {
+		return nil
+	}
+
+	return SetAuthorizationDetails(ar.GetSession(), requester.GetAuthorizationDetails())
+}
+
+func (c *AuthorizationDetailsHandler) HandleTokenEndpointRequest(ctx context.Context, request fosite.AccessRequester) error {
+	requester, ok := request.(fosite.AuthorizationDetailsRequester)
+	if !ok || len(requester.GetAuthorizationDetails()) == 0 {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	requested := requester.GetAuthorizationDetails()
+	granted := GetAuthorizationDetails(request.GetSession())
+	for _, detail := range requested {
+		if !contains(granted, detail) {
+			return errorsx.WithStack(fosite.ErrInvalidAuthorizationDetails.WithHintf("The authorization details of type '%s' have not been granted.", detail.Type))
+		}
+	}
+
+	if err := SetAuthorizationDetails(request.GetSession(), requested); err != nil {
+		return err
+	}
+
+	// The request is not handled by this handler, the grant handlers take care of it.
+	return errorsx.WithStack(fosite.ErrUnknownRequest)
+}
+
+func (c *AuthorizationDetailsHandler) PopulateTokenEndpointResponse(ctx context.Context, requester fosite.AccessRequester, responder fosite.AccessResponder) error {
+	details := GetAuthorizationDetails(requester.GetSession())
+	if len(details) == 0 {
+		return errorsx.WithStack(fosite.ErrUnknownRequest)
+	}
+
+	responder.SetExtra(ClaimName, details)
+	return nil
+}
+
+func (c *AuthorizationDetailsHandler) CanSkipClientAuth(requester fosite.AccessRequester) bool {
+	return true
+}
+
+func (c *AuthorizationDetailsHandler) CanHandleTokenEndpointRequest(requester fosite.AccessRequester) bool {
+	return true
+}
+
+// SetAuthorizationDetails stores the granted authorization details in the session.
+func SetAuthorizationDetails(session fosite.Session, details []fosite.AuthorizationDetail) error {
+	switch s := session.(type) {
+	case oauth2.JWTSessionContainer:
+		claims, ok := s.GetJWTClaims().(*jwt.JWTClaims)
+		if !ok {
+			return errorsx.WithStack(fosite.ErrServerError.WithDebug("Unable to store the authorization details because the JWT claims are not of type *jwt.JWTClaims."))
+		}
+		claims.Add(ClaimName, details)
+	case fosite.ExtraClaimsSession:
+		s.GetExtraClaims()[ClaimName] = details
+	default:
+		return errorsx.WithStack(fosite.ErrServerError.WithDebug("Unable to store the authorization details because the session does not support extra claims."))
+	}
+	return nil
+}
+
+// GetAuthorizationDetails returns the granted authorization details stored in the session.
+func GetAuthorizationDetails(session fosite.Session) []fosite.AuthorizationDetail {
+	s, ok := session.(fosite.ExtraClaimsSession)
+	if !ok {
+		return nil
+	}
+
+	switch details := s.GetExtraClaims()[ClaimName].(type) {
+	case nil:
+		return nil
+	case []fosite.AuthorizationDetail:
+		return details
+	default:
+		// Sessions which have been persisted and loaded again contain the plain JSON values.
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return nil
+		}
+		var result []fosite.AuthorizationDetail
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil
+		}
+		return result
+	}
+}
+
+func contains(haystack []fosite.AuthorizationDetail, needle fosite.AuthorizationDetail) bool {
+	n, err := json.Marshal(needle)
+	if err != nil {
+		return false
+	}
+	for _, h := range haystack {
+		if raw, err := json.Marshal(h); err == nil && string(raw) == string(n) {
+			return true
+		}
+	}
+	return false
+}
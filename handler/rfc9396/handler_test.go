@@ -0,0 +1,94 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package rfc9396
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/handler/oauth2"
+)
+
+func TestAuthorizationDetailsHandler(t *testing.T) {
+	h := &AuthorizationDetailsHandler{}
+	payment := fosite.AuthorizationDetail{Type: "payment", Actions: []string{"initiate"}, Extra: map[string]interface{}{"amount": "10"}}
+	account := fosite.AuthorizationDetail{Type: "account", Locations: []string{"https://example.com/accounts"}}
+
+	for k, session := range []fosite.Session{
+		&fosite.DefaultSession{},
+		&oauth2.JWTSession{},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			ar := fosite.NewAuthorizeRequest()
+			ar.SetSession(session)
+			require.NoError(t, h.HandleAuthorizeEndpointRequest(context.Background(), ar, fosite.NewAuthorizeResponse()))
+			assert.Empty(t, GetAuthorizationDetails(session))
+
+			ar.SetAuthorizationDetails([]fosite.AuthorizationDetail{payment, account})
+			require.NoError(t, h.HandleAuthorizeEndpointRequest(context.Background(), ar, fosite.NewAuthorizeResponse()))
+			assert.Equal(t, []fosite.AuthorizationDetail{payment, account}, GetAuthorizationDetails(session))
+
+			// The code exchange restores the session of the authorization request.
+			areq := fosite.NewAccessRequest(session)
+			require.True(t, h.CanHandleTokenEndpointRequest(areq))
+			require.True(t, h.CanSkipClientAuth(areq))
+			require.EqualError(t, h.HandleTokenEndpointRequest(context.Background(), areq), fosite.ErrUnknownRequest.Error())
+
+			resp := fosite.NewAccessResponse()
+			require.NoError(t, h.PopulateTokenEndpointResponse(context.Background(), areq, resp))
+			assert.Equal(t, []fosite.AuthorizationDetail{payment, account}, resp.GetExtra(ClaimName))
+
+			areq.SetAuthorizationDetails([]fosite.AuthorizationDetail{{Type: "payment"}})
+			require.EqualError(t, h.HandleTokenEndpointRequest(context.Background(), areq), fosite.ErrInvalidAuthorizationDetails.Error())
+
+			areq.SetAuthorizationDetails([]fosite.AuthorizationDetail{account})
+			require.EqualError(t, h.HandleTokenEndpointRequest(context.Background(), areq), fosite.ErrUnknownRequest.Error())
+			assert.Equal(t, []fosite.AuthorizationDetail{account}, GetAuthorizationDetails(session))
+		})
+	}
+
+	t.Run("case=rejects details requested at the token endpoint if none were granted", func(t *testing.T) {
+		areq := fosite.NewAccessRequest(&fosite.DefaultSession{})
+		resp := fosite.NewAccessResponse()
+		require.EqualError(t, h.PopulateTokenEndpointResponse(context.Background(), areq, resp), fosite.ErrUnknownRequest.Error())
+		assert.Nil(t, resp.GetExtra(ClaimName))
+
+		areq.SetAuthorizationDetails([]fosite.AuthorizationDetail{payment})
+		require.EqualError(t, h.HandleTokenEndpointRequest(context.Background(), areq), fosite.ErrInvalidAuthorizationDetails.Error())
+		assert.Empty(t, GetAuthorizationDetails(areq.GetSession()))
+		require.EqualError(t, h.PopulateTokenEndpointResponse(context.Background(), areq, resp), fosite.ErrUnknownRequest.Error())
+	})
+
+	t.Run("case=reads persisted sessions", func(t *testing.T) {
+		raw, err := json.Marshal(&fosite.DefaultSession{Extra: map[string]interface{}{ClaimName: []fosite.AuthorizationDetail{payment}}})
+		require.NoError(t, err)
+		var session fosite.DefaultSession
+		require.NoError(t, json.Unmarshal(raw, &session))
+		assert.Equal(t, []fosite.AuthorizationDetail{payment}, GetAuthorizationDetails(&session))
+	})
+}
@@ -21,7 +21,11 @@
 
 package fosite
 
-import "context"
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+)
 
 // Hasher defines how a oauth2-compatible hasher should look like.
 type Hasher interface {
@@ -32,3 +36,16 @@ type Hasher interface {
 	// Hash creates a hash from data or returns an error.
 	Hash(ctx context.Context, data []byte) ([]byte, error)
 }
+
+// ClientSecretHasher is the Hasher used for client secrets. Clients only expose the hashed secret via
+// Client.GetHashedSecret and ClientWithSecretRotation.GetRotatedHashes, so implementations must be able to verify a
+// secret against its hash without knowing the plaintext and should compare in constant time. BCrypt is the default.
+type ClientSecretHasher = Hasher
+
+// SecretsEqual compares two plaintext secrets in constant time. Unlike subtle.ConstantTimeCompare, which returns
+// early if the lengths differ, it compares the SHA-256 digests of the secrets, so that the time it takes does not
+// reveal how long the expected secret is or how far the secrets match.
+func SecretsEqual(given, expected []byte) bool {
+	g, e := sha256.Sum256(given), sha256.Sum256(expected)
+	return subtle.ConstantTimeCompare(g[:], e[:]) == 1
+}
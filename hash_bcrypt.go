@@ -33,14 +33,16 @@ const DefaultBCryptWorkFactor = 12
 
 // BCrypt implements the Hasher interface by using BCrypt.
 type BCrypt struct {
+	// WorkFactor is the bcrypt cost of new hashes. Defaults to DefaultBCryptWorkFactor.
 	WorkFactor int
 }
 
 func (b *BCrypt) Hash(ctx context.Context, data []byte) ([]byte, error) {
-	if b.WorkFactor == 0 {
-		b.WorkFactor = DefaultBCryptWorkFactor
+	workFactor := b.WorkFactor
+	if workFactor == 0 {
+		workFactor = DefaultBCryptWorkFactor
 	}
-	s, err := bcrypt.GenerateFromPassword(data, b.WorkFactor)
+	s, err := bcrypt.GenerateFromPassword(data, workFactor)
 	if err != nil {
 		return nil, errorsx.WithStack(err)
 	}
@@ -125,3 +125,10 @@ func TestDefaultWorkFactor(t *testing.T) {
 		t.Errorf("got cost factor %d", cost)
 	}
 }
+
+func TestDefaultWorkFactorIsNotPersisted(t *testing.T) {
+	b := &BCrypt{}
+	_, err := b.Hash(context.TODO(), []byte("secrets"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, b.WorkFactor)
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsEqual(t *testing.T) {
+	for k, c := range []struct {
+		given    string
+		expected string
+		equal    bool
+	}{
+		{given: "foobar", expected: "foobar", equal: true},
+		{given: "", expected: "", equal: true},
+		{given: "foobaz", expected: "foobar"},
+		{given: "foo", expected: "foobar"},
+		{given: "foobarfoobar", expected: "foobar"},
+		{given: "", expected: "foobar"},
+		{given: "foobar", expected: ""},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			assert.Equal(t, c.equal, SecretsEqual([]byte(c.given), []byte(c.expected)))
+		})
+	}
+}
+
+type countingHasher struct {
+	compared int
+}
+
+func (h *countingHasher) Compare(_ context.Context, hash, data []byte) error {
+	h.compared++
+	if !bytes.Equal(hash, data) {
+		return errors.New("mismatch")
+	}
+	return nil
+}
+
+func (h *countingHasher) Hash(_ context.Context, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func TestCheckClientSecretComparesAllHashes(t *testing.T) {
+	client := &DefaultClient{Secret: []byte("current"), RotatedSecrets: [][]byte{[]byte("rotated-1"), []byte("rotated-2")}}
+
+	for _, secret := range []string{"current", "rotated-1", "rotated-2", "unknown"} {
+		t.Run("secret="+secret, func(t *testing.T) {
+			h := new(countingHasher)
+			err := (&Fosite{Hasher: h}).checkClientSecret(context.Background(), client, []byte(secret))
+			assert.Equal(t, secret != "unknown", err == nil, "%+v", err)
+			assert.Equal(t, 3, h.compared)
+		})
+	}
+}
+
+// BenchmarkSecretsEqual documents that the comparison takes about the same time whether the given secret matches, differs
+// in its first byte or has a different length than the expected secret.
+func BenchmarkSecretsEqual(b *testing.B) {
+	expected := bytes.Repeat([]byte("a"), 32)
+	for name, given := range map[string][]byte{
+		"equal":           bytes.Repeat([]byte("a"), 32),
+		"first-byte-diff": append([]byte("b"), bytes.Repeat([]byte("a"), 31)...),
+		"last-byte-diff":  append(bytes.Repeat([]byte("a"), 31), 'b'),
+		"shorter":         bytes.Repeat([]byte("a"), 31),
+		"empty":           {},
+	} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				SecretsEqual(given, expected)
+			}
+		})
+	}
+}
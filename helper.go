@@ -24,6 +24,8 @@ package fosite
 import (
 	"fmt"
 	"strings"
+
+	"github.com/ory/x/stringslice"
 )
 
 // StringInSlice returns true if needle exists in haystack
@@ -46,6 +48,25 @@ func RemoveEmpty(args []string) (ret []string) {
 	return
 }
 
+// ParseScope splits the value of a scope parameter at any of the given delimiters, dropping empty and duplicate
+// scopes. Without delimiters, scopes are separated by spaces as defined in https://tools.ietf.org/html/rfc6749#section-3.3.
+func ParseScope(scope string, delimiters ...string) Arguments {
+	if len(delimiters) == 0 {
+		delimiters = []string{" "}
+	}
+
+	var ret Arguments
+	for _, v := range RemoveEmpty(strings.FieldsFunc(scope, func(r rune) bool {
+		return stringslice.Has(delimiters, string(r))
+	})) {
+		// Scopes are case-sensitive, so only exact duplicates are dropped.
+		if !stringslice.Has(ret, v) {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
 // EscapeJSONString does a poor man's JSON encoding. Useful when we do not want to use full JSON encoding
 // because we just had an error doing the JSON encoding. The characters that MUST be escaped: quotation mark,
 // reverse solidus, and the control characters (U+0000 through U+001F).
@@ -47,6 +47,26 @@ func TestStringInSlice(t *testing.T) {
 	}
 }
 
+func TestParseScope(t *testing.T) {
+	for k, c := range []struct {
+		scope      string
+		delimiters []string
+		expected   Arguments
+	}{
+		{scope: "", expected: nil},
+		{scope: "foo bar", expected: Arguments{"foo", "bar"}},
+		{scope: "  foo   bar ", expected: Arguments{"foo", "bar"}},
+		{scope: "foo,bar", expected: Arguments{"foo,bar"}},
+		{scope: "foo bar foo", expected: Arguments{"foo", "bar"}},
+		{scope: "foo Foo FOO foo", expected: Arguments{"foo", "Foo", "FOO"}},
+		{scope: "foo,bar baz", delimiters: []string{" ", ","}, expected: Arguments{"foo", "bar", "baz"}},
+		{scope: "foo, bar,,foo", delimiters: []string{" ", ","}, expected: Arguments{"foo", "bar"}},
+		{scope: "foo bar,baz", delimiters: []string{","}, expected: Arguments{"foo bar", "baz"}},
+	} {
+		assert.Equal(t, c.expected, ParseScope(c.scope, c.delimiters...), "%d", k)
+	}
+}
+
 func TestEscapeJSONString(t *testing.T) {
 	for _, str := range []string{"", "foobar", `foo"bar`, `foo\bar`, "foo\n\tbar"} {
 		escaped := EscapeJSONString(str)
@@ -22,10 +22,17 @@
 package integration_test
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -173,3 +180,295 @@ func runAuthorizeCodeGrantDupeCodeTest(t *testing.T, strategy interface{}) {
 	require.NoError(t, err)
 	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
 }
+
+type recordingAuditLogger struct {
+	sync.Mutex
+	events map[string][]*fosite.AuditEvent
+}
+
+func (l *recordingAuditLogger) record(kind string, event *fosite.AuditEvent) {
+	l.Lock()
+	defer l.Unlock()
+	l.events[kind] = append(l.events[kind], event)
+}
+
+func (l *recordingAuditLogger) AuthorizeSucceeded(_ context.Context, event *fosite.AuditEvent) {
+	l.record("authorize_succeeded", event)
+}
+
+func (l *recordingAuditLogger) AuthorizeFailed(_ context.Context, event *fosite.AuditEvent) {
+	l.record("authorize_failed", event)
+}
+
+func (l *recordingAuditLogger) TokenSucceeded(_ context.Context, event *fosite.AuditEvent) {
+	l.record("token_succeeded", event)
+}
+
+func (l *recordingAuditLogger) TokenFailed(_ context.Context, event *fosite.AuditEvent) {
+	l.record("token_failed", event)
+}
+
+func (l *recordingAuditLogger) Introspected(_ context.Context, event *fosite.AuditEvent) {
+	l.record("introspected", event)
+}
+
+func (l *recordingAuditLogger) Revoked(_ context.Context, event *fosite.AuditEvent) {
+	l.record("revoked", event)
+}
+
+func TestAuthorizeCodeFlowAuditLogger(t *testing.T) {
+	logger := &recordingAuditLogger{events: map[string][]*fosite.AuditEvent{}}
+	f := compose.Compose(&compose.Config{AuditLogger: logger}, fositeStore, hmacStrategy, nil, compose.OAuth2AuthorizeExplicitFactory, compose.OAuth2TokenIntrospectionFactory)
+	ts := mockServer(t, f, &fosite.DefaultSession{})
+	defer ts.Close()
+
+	oauthClient := newOAuth2Client(ts)
+	fositeStore.Clients["my-client"].(*fosite.DefaultClient).RedirectURIs[0] = ts.URL + "/callback"
+
+	resp, err := http.Get(oauthClient.AuthCodeURL("12345678901234567890"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	token, err := oauthClient.Exchange(goauth.NoContext, resp.Request.URL.Query().Get("code"))
+	require.NoError(t, err)
+	require.NotEmpty(t, token.AccessToken)
+
+	_, err = oauthClient.Exchange(goauth.NoContext, resp.Request.URL.Query().Get("code"))
+	require.Error(t, err)
+
+	logger.Lock()
+	defer logger.Unlock()
+
+	assert.Empty(t, logger.events["authorize_failed"])
+	require.Len(t, logger.events["authorize_succeeded"], 1)
+	authorize := logger.events["authorize_succeeded"][0]
+	assert.Equal(t, "my-client", authorize.ClientID)
+	assert.EqualValues(t, fosite.Arguments{"code"}, authorize.ResponseTypes)
+	assert.EqualValues(t, fosite.Arguments{"fosite"}, authorize.RequestedScopes)
+	assert.EqualValues(t, fosite.Arguments{"fosite"}, authorize.GrantedScopes)
+	assert.Empty(t, authorize.ErrorCode)
+
+	require.Len(t, logger.events["token_succeeded"], 1)
+	exchange := logger.events["token_succeeded"][0]
+	assert.Equal(t, "my-client", exchange.ClientID)
+	assert.EqualValues(t, fosite.Arguments{"authorization_code"}, exchange.GrantTypes)
+	assert.EqualValues(t, fosite.Arguments{"fosite"}, exchange.GrantedScopes)
+	assert.Empty(t, exchange.ErrorCode)
+	assert.NoError(t, exchange.Error)
+
+	require.Len(t, logger.events["token_failed"], 1)
+	replay := logger.events["token_failed"][0]
+	assert.Equal(t, "my-client", replay.ClientID)
+	assert.EqualValues(t, fosite.Arguments{"authorization_code"}, replay.GrantTypes)
+	assert.Equal(t, fosite.ErrInvalidGrant.ErrorField, replay.ErrorCode)
+}
+
+type recordingObserver struct {
+	sync.Mutex
+	labels    map[string][]fosite.ObserverLabels
+	durations map[string][]time.Duration
+}
+
+func (o *recordingObserver) record(kind string, labels fosite.ObserverLabels, duration time.Duration) {
+	o.Lock()
+	defer o.Unlock()
+	o.labels[kind] = append(o.labels[kind], labels)
+	o.durations[kind] = append(o.durations[kind], duration)
+}
+
+func (o *recordingObserver) TokenIssued(_ context.Context, labels fosite.ObserverLabels) {
+	o.record("token_issued", labels, 0)
+}
+
+func (o *recordingObserver) RequestFailed(_ context.Context, labels fosite.ObserverLabels) {
+	o.record("request_failed", labels, 0)
+}
+
+func (o *recordingObserver) AuthorizeRequestObserved(_ context.Context, labels fosite.ObserverLabels, duration time.Duration) {
+	o.record("authorize_request", labels, duration)
+}
+
+func (o *recordingObserver) IntrospectionObserved(_ context.Context, labels fosite.ObserverLabels, duration time.Duration) {
+	o.record("introspection", labels, duration)
+}
+
+func TestAuthorizeCodeFlowObserver(t *testing.T) {
+	observer := &recordingObserver{labels: map[string][]fosite.ObserverLabels{}, durations: map[string][]time.Duration{}}
+	f := compose.Compose(&compose.Config{Observer: observer}, fositeStore, hmacStrategy, nil, compose.OAuth2AuthorizeExplicitFactory, compose.OAuth2TokenIntrospectionFactory)
+	ts := mockServer(t, f, &fosite.DefaultSession{})
+	defer ts.Close()
+
+	oauthClient := newOAuth2Client(ts)
+	fositeStore.Clients["my-client"].(*fosite.DefaultClient).RedirectURIs[0] = ts.URL + "/callback"
+
+	resp, err := http.Get(oauthClient.AuthCodeURL("12345678901234567890"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	token, err := oauthClient.Exchange(goauth.NoContext, resp.Request.URL.Query().Get("code"))
+	require.NoError(t, err)
+	require.NotEmpty(t, token.AccessToken)
+
+	req, err := http.NewRequest("POST", ts.URL+"/introspect", strings.NewReader(url.Values{"token": {token.AccessToken}}.Encode()))
+	require.NoError(t, err)
+	req.SetBasicAuth("my-client", "foobar")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Replaying the code fails and revokes the tokens issued for it.
+	_, err = oauthClient.Exchange(goauth.NoContext, resp.Request.URL.Query().Get("code"))
+	require.Error(t, err)
+
+	observer.Lock()
+	defer observer.Unlock()
+
+	assert.Equal(t, []fosite.ObserverLabels{{
+		Endpoint:     fosite.ObserverEndpointAuthorize,
+		ClientID:     "my-client",
+		ResponseType: "code",
+	}}, observer.labels["authorize_request"])
+	assert.True(t, observer.durations["authorize_request"][0] > 0)
+
+	assert.Equal(t, []fosite.ObserverLabels{{
+		Endpoint:  fosite.ObserverEndpointToken,
+		ClientID:  "my-client",
+		GrantType: "authorization_code",
+	}}, observer.labels["token_issued"])
+
+	assert.Equal(t, []fosite.ObserverLabels{{
+		Endpoint:  fosite.ObserverEndpointToken,
+		ClientID:  "my-client",
+		GrantType: "authorization_code",
+		ErrorCode: fosite.ErrInvalidGrant.ErrorField,
+	}}, observer.labels["request_failed"])
+
+	assert.Equal(t, []fosite.ObserverLabels{{
+		Endpoint: fosite.ObserverEndpointIntrospection,
+		ClientID: "my-client",
+		TokenUse: fosite.AccessToken,
+		Active:   true,
+	}}, observer.labels["introspection"])
+	assert.True(t, observer.durations["introspection"][0] > 0)
+}
+
+type tracerSpanKey struct{}
+
+type recordedSpan struct {
+	event      string
+	operation  string
+	attributes map[string]string
+}
+
+type recordingTracer struct {
+	sync.Mutex
+	spans []recordedSpan
+}
+
+func (tr *recordingTracer) StartSpan(ctx context.Context, operation string) context.Context {
+	tr.Lock()
+	defer tr.Unlock()
+	tr.spans = append(tr.spans, recordedSpan{event: "start", operation: operation})
+	return context.WithValue(ctx, tracerSpanKey{}, operation)
+}
+
+func (tr *recordingTracer) EndSpan(ctx context.Context, operation string, attributes map[string]string, err error) {
+	tr.Lock()
+	defer tr.Unlock()
+	event := "end"
+	if ctx.Value(tracerSpanKey{}) != operation {
+		event = "end without the context of the span"
+	}
+	tr.spans = append(tr.spans, recordedSpan{event: event, operation: operation, attributes: attributes})
+}
+
+func TestAuthorizeCodeFlowTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	f := compose.Compose(&compose.Config{Tracer: tracer}, fositeStore, hmacStrategy, nil, compose.OAuth2AuthorizeExplicitFactory, compose.OAuth2TokenIntrospectionFactory)
+	ts := mockServer(t, f, &fosite.DefaultSession{})
+	defer ts.Close()
+
+	oauthClient := newOAuth2Client(ts)
+	fositeStore.Clients["my-client"].(*fosite.DefaultClient).RedirectURIs[0] = ts.URL + "/callback"
+
+	resp, err := http.Get(oauthClient.AuthCodeURL("12345678901234567890"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	token, err := oauthClient.Exchange(goauth.NoContext, resp.Request.URL.Query().Get("code"))
+	require.NoError(t, err)
+	require.NotEmpty(t, token.AccessToken)
+
+	_, err = oauthClient.Exchange(goauth.NoContext, resp.Request.URL.Query().Get("code"))
+	require.Error(t, err)
+
+	tracer.Lock()
+	defer tracer.Unlock()
+
+	authorize := map[string]string{"client_id": "my-client", "response_type": "code"}
+	exchange := map[string]string{"client_id": "my-client", "grant_type": "authorization_code"}
+	assert.Equal(t, []recordedSpan{
+		{event: "start", operation: fosite.TraceOperationNewAuthorizeRequest},
+		{event: "end", operation: fosite.TraceOperationNewAuthorizeRequest, attributes: authorize},
+		{event: "start", operation: fosite.TraceOperationNewAuthorizeResponse},
+		{event: "end", operation: fosite.TraceOperationNewAuthorizeResponse, attributes: authorize},
+		{event: "start", operation: fosite.TraceOperationNewAccessRequest},
+		{event: "end", operation: fosite.TraceOperationNewAccessRequest, attributes: exchange},
+		{event: "start", operation: fosite.TraceOperationNewAccessResponse},
+		{event: "end", operation: fosite.TraceOperationNewAccessResponse, attributes: exchange},
+		{event: "start", operation: fosite.TraceOperationNewAccessRequest},
+		{event: "end", operation: fosite.TraceOperationNewAccessRequest, attributes: map[string]string{
+			"client_id":  "my-client",
+			"grant_type": "authorization_code",
+			"error_code": fosite.ErrInvalidGrant.ErrorField,
+		}},
+	}, tracer.spans)
+}
+
+func TestAuthorizeCodeFlowCodeReuseGracePeriod(t *testing.T) {
+	f := compose.Compose(&compose.Config{CodeReuseGracePeriod: time.Minute}, fositeStore, hmacStrategy, nil, compose.OAuth2AuthorizeExplicitFactory, compose.OAuth2PKCEFactory, compose.OAuth2TokenIntrospectionFactory)
+	ts := mockServer(t, f, &fosite.DefaultSession{})
+	defer ts.Close()
+
+	oauthClient := newOAuth2Client(ts)
+	fositeStore.Clients["my-client"].(*fosite.DefaultClient).RedirectURIs[0] = ts.URL + "/callback"
+
+	verifier := "Nt3Aib4sLk6xeH9BjCgGduaWPpiRZX-7u3UBUznES2e"
+	hash := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(hash[:])
+
+	resp, err := http.Get(oauthClient.AuthCodeURL("12345678901234567890", goauth.SetAuthURLParam("code_challenge", challenge), goauth.SetAuthURLParam("code_challenge_method", "S256")))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	code := resp.Request.URL.Query().Get("code")
+
+	token, err := oauthClient.Exchange(goauth.NoContext, code, goauth.SetAuthURLParam("code_verifier", verifier))
+	require.NoError(t, err)
+	require.NotEmpty(t, token.AccessToken)
+
+	info := func() int {
+		req, err := http.NewRequest("GET", ts.URL+"/info", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	// A retry of the token request is answered with the tokens issued before.
+	retried, err := oauthClient.Exchange(goauth.NoContext, code, goauth.SetAuthURLParam("code_verifier", verifier))
+	require.NoError(t, err)
+	assert.Equal(t, token.AccessToken, retried.AccessToken)
+	assert.Equal(t, token.RefreshToken, retried.RefreshToken)
+	assert.Equal(t, http.StatusOK, info())
+
+	// A replay with different parameters revokes the tokens.
+	_, err = oauthClient.Exchange(goauth.NoContext, code, goauth.SetAuthURLParam("code_verifier", verifier+"-replayed"))
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, info())
+
+	// Once the tokens have been revoked, retries are answered with an error as well.
+	_, err = oauthClient.Exchange(goauth.NoContext, code, goauth.SetAuthURLParam("code_verifier", verifier))
+	require.Error(t, err)
+}
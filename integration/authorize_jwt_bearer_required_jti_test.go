@@ -86,7 +86,7 @@ func (s *authorizeJWTBearerRequiredJtiSuite) assertSuccessResponse(t *testing.T,
 	assert.Nil(t, err)
 	assert.NotNil(t, token)
 
-	assert.Equal(t, token.TokenType, "bearer")
+	assert.Equal(t, token.TokenType, "Bearer")
 	assert.Empty(t, token.RefreshToken)
 	assert.NotEmpty(t, token.ExpiresIn)
 	assert.NotEmpty(t, token.AccessToken)
@@ -402,7 +402,7 @@ func (s *authorizeJWTBearerSuite) assertSuccessResponse(t *testing.T, token *cli
 	assert.Nil(t, err)
 	assert.NotNil(t, token)
 
-	assert.Equal(t, token.TokenType, "bearer")
+	assert.Equal(t, token.TokenType, "Bearer")
 	assert.Empty(t, token.RefreshToken)
 	assert.NotEmpty(t, token.ExpiresIn)
 	assert.NotEmpty(t, token.AccessToken)
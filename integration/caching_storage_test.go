@@ -0,0 +1,83 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package integration_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	goauth "golang.org/x/oauth2"
+
+	"github.com/ory/fosite"
+	"github.com/ory/fosite/compose"
+	"github.com/ory/fosite/storage"
+)
+
+func TestCachingStorage(t *testing.T) {
+	store := storage.NewCachingStorage(fositeStore, time.Minute)
+	store.AccessTokenTTL = time.Minute
+
+	f := compose.Compose(
+		new(compose.Config),
+		store,
+		hmacStrategy,
+		nil,
+		compose.OAuth2AuthorizeExplicitFactory,
+		compose.OAuth2ClientCredentialsGrantFactory,
+		compose.OAuth2RefreshTokenGrantFactory,
+		compose.OAuth2TokenIntrospectionFactory,
+		compose.OAuth2TokenRevocationFactory,
+	)
+	ts := mockServer(t, f, &fosite.DefaultSession{})
+	defer ts.Close()
+
+	oauthClient := newOAuth2AppClient(ts)
+	token, err := oauthClient.Token(goauth.NoContext)
+	require.NoError(t, err)
+
+	// the first introspection caches the access token session
+	for i := 0; i < 2; i++ {
+		hres, _, errs := gorequest.New().Get(ts.URL+"/info").
+			Set("Authorization", "bearer "+token.AccessToken).
+			End()
+		require.Len(t, errs, 0)
+		assert.Equal(t, http.StatusOK, hres.StatusCode)
+	}
+
+	resp, _, errs := gorequest.New().Post(ts.URL+"/revoke").
+		SetBasicAuth(oauthClient.ClientID, oauthClient.ClientSecret).
+		Type("form").
+		SendStruct(map[string]string{"token": token.AccessToken}).End()
+	require.Len(t, errs, 0)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// revoking the access token through the provider has invalidated its cache entry
+	hres, _, errs := gorequest.New().Get(ts.URL+"/info").
+		Set("Authorization", "bearer "+token.AccessToken).
+		End()
+	require.Len(t, errs, 0)
+	assert.Equal(t, http.StatusUnauthorized, hres.StatusCode)
+}
@@ -91,7 +91,7 @@ func authEndpointHandler(t *testing.T, oauth2 fosite.OAuth2Provider, session fos
 		if err != nil {
 			t.Logf("Access request failed because: %+v", err)
 			t.Logf("Request: %+v", ar)
-			oauth2.WriteAuthorizeError(rw, ar, err)
+			oauth2.WriteAuthorizeError(ctx, rw, ar, err)
 			return
 		}
 
@@ -118,11 +118,11 @@ func authEndpointHandler(t *testing.T, oauth2 fosite.OAuth2Provider, session fos
 		if err != nil {
 			t.Logf("Access request failed because: %+v", err)
 			t.Logf("Request: %+v", ar)
-			oauth2.WriteAuthorizeError(rw, ar, err)
+			oauth2.WriteAuthorizeError(ctx, rw, ar, err)
 			return
 		}
 
-		oauth2.WriteAuthorizeResponse(rw, ar, response)
+		oauth2.WriteAuthorizeResponse(ctx, rw, ar, response)
 	}
 }
 
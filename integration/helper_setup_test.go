@@ -115,6 +115,7 @@ var fositeStore = &storage.MemoryStore{
 	IDSessions:             map[string]fosite.Requester{},
 	AccessTokenRequestIDs:  map[string]string{},
 	RefreshTokenRequestIDs: map[string]string{},
+	AuthorizeCodeExchanges: map[string]*fosite.AuthorizeCodeExchange{},
 }
 
 type defaultSession struct {
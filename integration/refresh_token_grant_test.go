@@ -48,6 +48,8 @@ type introspectionResponse struct {
 	Audience  []string `json:"aud,omitempty"`
 	ExpiresAt int64    `json:"exp,omitempty"`
 	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
 	Subject   string   `json:"sub,omitempty"`
 	Username  string   `json:"username,omitempty"`
 }
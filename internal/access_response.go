@@ -64,6 +64,34 @@ func (mr *MockAccessResponderMockRecorder) GetExtra(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExtra", reflect.TypeOf((*MockAccessResponder)(nil).GetExtra), arg0)
 }
 
+// GetRequestedScopes mocks base method
+func (m *MockAccessResponder) GetRequestedScopes() fosite.Arguments {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRequestedScopes")
+	ret0, _ := ret[0].(fosite.Arguments)
+	return ret0
+}
+
+// GetRequestedScopes indicates an expected call of GetRequestedScopes
+func (mr *MockAccessResponderMockRecorder) GetRequestedScopes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestedScopes", reflect.TypeOf((*MockAccessResponder)(nil).GetRequestedScopes))
+}
+
+// GetScopes mocks base method
+func (m *MockAccessResponder) GetScopes() fosite.Arguments {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScopes")
+	ret0, _ := ret[0].(fosite.Arguments)
+	return ret0
+}
+
+// GetScopes indicates an expected call of GetScopes
+func (mr *MockAccessResponderMockRecorder) GetScopes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScopes", reflect.TypeOf((*MockAccessResponder)(nil).GetScopes))
+}
+
 // GetTokenType mocks base method
 func (m *MockAccessResponder) GetTokenType() string {
 	m.ctrl.T.Helper()
@@ -114,6 +142,18 @@ func (mr *MockAccessResponderMockRecorder) SetExtra(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExtra", reflect.TypeOf((*MockAccessResponder)(nil).SetExtra), arg0, arg1)
 }
 
+// SetRequestedScopes mocks base method
+func (m *MockAccessResponder) SetRequestedScopes(arg0 fosite.Arguments) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRequestedScopes", arg0)
+}
+
+// SetRequestedScopes indicates an expected call of SetRequestedScopes
+func (mr *MockAccessResponderMockRecorder) SetRequestedScopes(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRequestedScopes", reflect.TypeOf((*MockAccessResponder)(nil).SetRequestedScopes), arg0)
+}
+
 // SetScopes mocks base method
 func (m *MockAccessResponder) SetScopes(arg0 fosite.Arguments) {
 	m.ctrl.T.Helper()
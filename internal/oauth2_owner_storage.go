@@ -7,6 +7,7 @@ package internal
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 
@@ -135,3 +136,31 @@ func (mr *MockResourceOwnerPasswordCredentialsGrantStorageMockRecorder) GetRefre
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshTokenSession", reflect.TypeOf((*MockResourceOwnerPasswordCredentialsGrantStorage)(nil).GetRefreshTokenSession), arg0, arg1, arg2)
 }
+
+// RevokeRefreshTokenFamily mocks base method
+func (m *MockResourceOwnerPasswordCredentialsGrantStorage) RevokeRefreshTokenFamily(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenFamily", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenFamily indicates an expected call of RevokeRefreshTokenFamily
+func (mr *MockResourceOwnerPasswordCredentialsGrantStorageMockRecorder) RevokeRefreshTokenFamily(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenFamily", reflect.TypeOf((*MockResourceOwnerPasswordCredentialsGrantStorage)(nil).RevokeRefreshTokenFamily), arg0, arg1)
+}
+
+// RevokeRefreshTokenMaybeGracePeriod mocks base method
+func (m *MockResourceOwnerPasswordCredentialsGrantStorage) RevokeRefreshTokenMaybeGracePeriod(arg0 context.Context, arg1, arg2 string, arg3 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenMaybeGracePeriod", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenMaybeGracePeriod indicates an expected call of RevokeRefreshTokenMaybeGracePeriod
+func (mr *MockResourceOwnerPasswordCredentialsGrantStorageMockRecorder) RevokeRefreshTokenMaybeGracePeriod(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenMaybeGracePeriod", reflect.TypeOf((*MockResourceOwnerPasswordCredentialsGrantStorage)(nil).RevokeRefreshTokenMaybeGracePeriod), arg0, arg1, arg2, arg3)
+}
@@ -7,6 +7,7 @@ package internal
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 
@@ -164,3 +165,31 @@ func (mr *MockCoreStorageMockRecorder) InvalidateAuthorizeCodeSession(arg0, arg1
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateAuthorizeCodeSession", reflect.TypeOf((*MockCoreStorage)(nil).InvalidateAuthorizeCodeSession), arg0, arg1)
 }
+
+// RevokeRefreshTokenFamily mocks base method
+func (m *MockCoreStorage) RevokeRefreshTokenFamily(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenFamily", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenFamily indicates an expected call of RevokeRefreshTokenFamily
+func (mr *MockCoreStorageMockRecorder) RevokeRefreshTokenFamily(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenFamily", reflect.TypeOf((*MockCoreStorage)(nil).RevokeRefreshTokenFamily), arg0, arg1)
+}
+
+// RevokeRefreshTokenMaybeGracePeriod mocks base method
+func (m *MockCoreStorage) RevokeRefreshTokenMaybeGracePeriod(arg0 context.Context, arg1, arg2 string, arg3 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenMaybeGracePeriod", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenMaybeGracePeriod indicates an expected call of RevokeRefreshTokenMaybeGracePeriod
+func (mr *MockCoreStorageMockRecorder) RevokeRefreshTokenMaybeGracePeriod(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenMaybeGracePeriod", reflect.TypeOf((*MockCoreStorage)(nil).RevokeRefreshTokenMaybeGracePeriod), arg0, arg1, arg2, arg3)
+}
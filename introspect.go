@@ -60,7 +60,11 @@ func (f *Fosite) IntrospectToken(ctx context.Context, token string, tokenUse Tok
 
 	ar := NewAccessRequest(session)
 	for _, validator := range f.TokenIntrospectionHandlers {
-		tu, err := validator.IntrospectToken(ctx, token, tokenUse, ar, scopes)
+		var tu TokenUse
+		err := f.withStorageTimeout(ctx, func(ctx context.Context) (err error) {
+			tu, err = validator.IntrospectToken(ctx, token, tokenUse, ar, scopes)
+			return err
+		})
 		if err == nil {
 			found = true
 			foundTokenUse = tu
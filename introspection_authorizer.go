@@ -0,0 +1,53 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+
+	"github.com/ory/x/errorsx"
+	"github.com/pkg/errors"
+)
+
+// IntrospectionAuthorizer decides whether the authenticated caller of the introspection endpoint may introspect a
+// token, for example only if the caller is a resource server in the token's audience. The caller is the request of the
+// access token the caller authenticated with, or a request carrying the client which authenticated using HTTP basic
+// authorization and the scopes registered for it. Errors which are not an *RFC6749Error are returned as ErrAccessDenied, which WriteIntrospectionError
+// writes as an inactive token.
+type IntrospectionAuthorizer func(ctx context.Context, caller Requester, introspected AccessRequester, tokenUse TokenUse) error
+
+// authorizeIntrospection calls IntrospectionAuthorizer, if set. Otherwise any authenticated caller may introspect
+// any token.
+func (f *Fosite) authorizeIntrospection(ctx context.Context, caller Requester, introspected AccessRequester, tokenUse TokenUse) error {
+	if f.IntrospectionAuthorizer == nil {
+		return nil
+	}
+
+	if err := f.IntrospectionAuthorizer(ctx, caller, introspected, tokenUse); err != nil {
+		var rfcErr *RFC6749Error
+		if errors.As(err, &rfcErr) {
+			return errorsx.WithStack(rfcErr)
+		}
+		return errorsx.WithStack(ErrAccessDenied.WithHint("The caller is not allowed to introspect the token.").WithWrap(err).WithDebug(err.Error()))
+	}
+	return nil
+}
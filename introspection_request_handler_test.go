@@ -103,6 +103,218 @@ func TestIntrospectionResponse(t *testing.T) {
 	assert.Equal(t, r.Active, r.IsActive())
 }
 
+func TestNewIntrospectionRequestAuthorizer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	validator := internal.NewMockTokenIntrospector(ctrl)
+	defer ctrl.Finish()
+
+	f := compose.ComposeAllEnabled(new(compose.Config), storage.NewExampleStore(), []byte{}, nil).(*Fosite)
+	f.TokenIntrospectionHandlers = TokenIntrospectionHandlers{validator}
+
+	basicAuthRequest := func() *http.Request {
+		return &http.Request{
+			Method: "POST",
+			Header: http.Header{
+				//Basic Authorization with username=my-client and password=foobar
+				"Authorization": []string{"Basic bXktY2xpZW50OmZvb2Jhcg=="},
+			},
+			PostForm: url.Values{"token": []string{"introspect-token"}},
+		}
+	}
+
+	for k, c := range []struct {
+		description string
+		authorizer  IntrospectionAuthorizer
+		req         func() *http.Request
+		setup       func()
+		expectErr   error
+	}{
+		{
+			description: "should pass because the caller is in the audience of the token",
+			authorizer: func(_ context.Context, caller Requester, introspected AccessRequester, tokenUse TokenUse) error {
+				assert.Equal(t, AccessToken, tokenUse)
+				if !introspected.GetGrantedAudience().Has(caller.GetClient().GetID()) {
+					return errors.New("not in audience")
+				}
+				return nil
+			},
+			req: basicAuthRequest,
+			setup: func() {
+				validator.EXPECT().IntrospectToken(gomock.Any(), "introspect-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ string, _ TokenUse, ar AccessRequester, _ []string) (TokenUse, error) {
+						ar.GrantAudience("my-client")
+						return AccessToken, nil
+					})
+			},
+		},
+		{
+			description: "should fail with access_denied because the caller is not in the audience of the token",
+			authorizer: func(_ context.Context, caller Requester, introspected AccessRequester, _ TokenUse) error {
+				if !introspected.GetGrantedAudience().Has(caller.GetClient().GetID()) {
+					return errors.New("not in audience")
+				}
+				return nil
+			},
+			req: basicAuthRequest,
+			setup: func() {
+				validator.EXPECT().IntrospectToken(gomock.Any(), "introspect-token", gomock.Any(), gomock.Any(), gomock.Any()).Return(AccessToken, nil)
+			},
+			expectErr: ErrAccessDenied,
+		},
+		{
+			description: "should pass through errors of the authorizer",
+			authorizer: func(context.Context, Requester, AccessRequester, TokenUse) error {
+				return ErrRequestForbidden
+			},
+			req: basicAuthRequest,
+			setup: func() {
+				validator.EXPECT().IntrospectToken(gomock.Any(), "introspect-token", gomock.Any(), gomock.Any(), gomock.Any()).Return(AccessToken, nil)
+			},
+			expectErr: ErrRequestForbidden,
+		},
+		{
+			description: "should pass the request of the bearer token as the caller",
+			authorizer: func(_ context.Context, caller Requester, _ AccessRequester, _ TokenUse) error {
+				if !caller.GetGrantedScopes().Has("introspect") {
+					return errors.New("missing scope")
+				}
+				return nil
+			},
+			req: func() *http.Request {
+				return &http.Request{
+					Method:   "POST",
+					Header:   http.Header{"Authorization": []string{"bearer some-token"}},
+					PostForm: url.Values{"token": []string{"introspect-token"}},
+				}
+			},
+			setup: func() {
+				validator.EXPECT().IntrospectToken(gomock.Any(), "some-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ string, _ TokenUse, ar AccessRequester, _ []string) (TokenUse, error) {
+						ar.GrantScope("introspect")
+						return AccessToken, nil
+					})
+				validator.EXPECT().IntrospectToken(gomock.Any(), "introspect-token", gomock.Any(), gomock.Any(), gomock.Any()).Return(AccessToken, nil)
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			c.setup()
+			f.IntrospectionAuthorizer = c.authorizer
+			res, err := f.NewIntrospectionRequest(context.TODO(), c.req(), &DefaultSession{})
+			if c.expectErr != nil {
+				require.EqualError(t, err, c.expectErr.Error())
+				assert.False(t, res.IsActive())
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, res.IsActive())
+		})
+	}
+}
+
+func TestNewIntrospectionRequestVisibleScopes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	validator := internal.NewMockTokenIntrospector(ctrl)
+	defer ctrl.Finish()
+
+	introspect := func(tu TokenUse, scopes ...string) func(context.Context, string, TokenUse, AccessRequester, []string) (TokenUse, error) {
+		return func(_ context.Context, _ string, _ TokenUse, ar AccessRequester, _ []string) (TokenUse, error) {
+			for _, scope := range scopes {
+				ar.GrantScope(scope)
+			}
+			return tu, nil
+		}
+	}
+	bearerRequest := func() *http.Request {
+		return &http.Request{
+			Method:   "POST",
+			Header:   http.Header{"Authorization": []string{"bearer some-token"}},
+			PostForm: url.Values{"token": []string{"introspect-token"}},
+		}
+	}
+
+	for k, c := range []struct {
+		description   string
+		restrict      []TokenUse
+		filter        IntrospectionScopeFilter
+		req           func() *http.Request
+		setup         func()
+		expectVisible Arguments
+	}{
+		{
+			description: "should disclose all scopes by default",
+			req:         bearerRequest,
+			setup: func() {
+				validator.EXPECT().IntrospectToken(gomock.Any(), "some-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(introspect(AccessToken, "photos"))
+				validator.EXPECT().IntrospectToken(gomock.Any(), "introspect-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(introspect(AccessToken, "photos", "email"))
+			},
+		},
+		{
+			description: "should only disclose the scopes of the bearer token",
+			restrict:    []TokenUse{AccessToken},
+			req:         bearerRequest,
+			setup: func() {
+				validator.EXPECT().IntrospectToken(gomock.Any(), "some-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(introspect(AccessToken, "photos"))
+				validator.EXPECT().IntrospectToken(gomock.Any(), "introspect-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(introspect(AccessToken, "photos", "email"))
+			},
+			expectVisible: Arguments{"photos"},
+		},
+		{
+			description: "should disclose all scopes because only refresh tokens are restricted",
+			restrict:    []TokenUse{RefreshToken},
+			req:         bearerRequest,
+			setup: func() {
+				validator.EXPECT().IntrospectToken(gomock.Any(), "some-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(introspect(AccessToken, "photos"))
+				validator.EXPECT().IntrospectToken(gomock.Any(), "introspect-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(introspect(AccessToken, "photos", "email"))
+			},
+		},
+		{
+			description: "should only disclose the scopes registered for the client",
+			restrict:    []TokenUse{AccessToken},
+			req: func() *http.Request {
+				return &http.Request{
+					Method: "POST",
+					Header: http.Header{
+						//Basic Authorization with username=my-client and password=foobar
+						"Authorization": []string{"Basic bXktY2xpZW50OmZvb2Jhcg=="},
+					},
+					PostForm: url.Values{"token": []string{"introspect-token"}},
+				}
+			},
+			setup: func() {
+				validator.EXPECT().IntrospectToken(gomock.Any(), "introspect-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(introspect(AccessToken, "photos", "email", "offline"))
+			},
+			expectVisible: Arguments{"photos", "offline"},
+		},
+		{
+			description: "should disclose the scopes returned by the filter",
+			restrict:    []TokenUse{AccessToken},
+			filter: func(context.Context, Requester, AccessRequester, TokenUse) Arguments {
+				return nil
+			},
+			req: bearerRequest,
+			setup: func() {
+				validator.EXPECT().IntrospectToken(gomock.Any(), "some-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(introspect(AccessToken, "photos"))
+				validator.EXPECT().IntrospectToken(gomock.Any(), "introspect-token", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(introspect(AccessToken, "photos", "email"))
+			},
+			expectVisible: Arguments{},
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			f := compose.ComposeAllEnabled(&compose.Config{
+				RestrictIntrospectionScopes: c.restrict,
+				IntrospectionScopeFilter:    c.filter,
+			}, storage.NewExampleStore(), []byte{}, nil).(*Fosite)
+			f.TokenIntrospectionHandlers = TokenIntrospectionHandlers{validator}
+
+			c.setup()
+			res, err := f.NewIntrospectionRequest(context.TODO(), c.req(), &DefaultSession{})
+			require.NoError(t, err)
+			assert.Equal(t, c.expectVisible, res.(*IntrospectionResponse).GetVisibleScopes())
+		})
+	}
+}
+
 func TestNewIntrospectionRequest(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	validator := internal.NewMockTokenIntrospector(ctrl)
@@ -23,8 +23,10 @@ package fosite
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -208,33 +210,38 @@ func (f *Fosite) WriteIntrospectionResponse(rw http.ResponseWriter, r Introspect
 		"active": true,
 	}
 
-	extraClaimsSession, ok := r.GetAccessRequester().GetSession().(ExtraClaimsSession)
-	if ok {
-		extraClaims := extraClaimsSession.GetExtraClaims()
-		if extraClaims != nil {
-			for name, value := range extraClaims {
-				switch name {
-				// We do not allow these to be set through extra claims.
-				case "exp", "client_id", "scope", "iat", "sub", "aud", "username":
-					continue
-				default:
-					response[name] = value
-				}
-			}
-		}
+	// Extra claims of the response take precedence over the extra claims of the session.
+	if extraClaimsSession, ok := r.GetAccessRequester().GetSession().(ExtraClaimsSession); ok {
+		mergeIntrospectionExtraClaims(response, extraClaimsSession.GetExtraClaims(), reservedIntrospectionClaims)
+	}
+	if er, ok := r.(IntrospectionResponderWithExtraClaims); ok {
+		mergeIntrospectionExtraClaims(response, er.GetExtraClaims(), reservedIntrospectionClaims, tokenIntrospectionClaims)
 	}
 
-	if !r.GetAccessRequester().GetSession().GetExpiresAt(AccessToken).IsZero() {
-		response["exp"] = r.GetAccessRequester().GetSession().GetExpiresAt(AccessToken).Unix()
+	if !r.GetAccessRequester().GetSession().GetExpiresAt(introspectedTokenUse(r)).IsZero() {
+		response["exp"] = r.GetAccessRequester().GetSession().GetExpiresAt(introspectedTokenUse(r)).Unix()
 	}
 	if r.GetAccessRequester().GetClient().GetID() != "" {
 		response["client_id"] = r.GetAccessRequester().GetClient().GetID()
 	}
-	if len(r.GetAccessRequester().GetGrantedScopes()) > 0 {
-		response["scope"] = strings.Join(r.GetAccessRequester().GetGrantedScopes(), " ")
+	scopes := r.GetAccessRequester().GetGrantedScopes()
+	if vr, ok := r.(IntrospectionResponderWithVisibleScopes); ok && vr.GetVisibleScopes() != nil {
+		scopes = vr.GetVisibleScopes()
+	}
+	if len(scopes) > 0 {
+		response["scope"] = strings.Join(scopes, " ")
 	}
 	if !r.GetAccessRequester().GetRequestedAt().IsZero() {
 		response["iat"] = r.GetAccessRequester().GetRequestedAt().Unix()
+		// The token can be used from the moment it was issued, unless the session says otherwise.
+		if _, ok := response["nbf"]; !ok {
+			response["nbf"] = response["iat"]
+		}
+	}
+	if r.GetAccessTokenType() != "" {
+		response["token_type"] = r.GetAccessTokenType()
+	} else if r.GetTokenUse() == RefreshToken {
+		response["token_type"] = string(RefreshToken)
 	}
 	if r.GetAccessRequester().GetSession().GetSubject() != "" {
 		response["sub"] = r.GetAccessRequester().GetSession().GetSubject()
@@ -247,7 +254,77 @@ func (f *Fosite) WriteIntrospectionResponse(rw http.ResponseWriter, r Introspect
 	}
 
 	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
-	rw.Header().Set("Cache-Control", "no-store")
-	rw.Header().Set("Pragma", "no-cache")
+	if maxAge := f.introspectionCacheMaxAge(r); maxAge > 0 {
+		rw.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", maxAge))
+	} else {
+		rw.Header().Set("Cache-Control", "no-store")
+		rw.Header().Set("Pragma", "no-cache")
+	}
 	_ = json.NewEncoder(rw).Encode(response)
 }
+
+// reservedIntrospectionClaims are the claims of introspection responses which can not be set through extra claims.
+var reservedIntrospectionClaims = map[string]bool{
+	"active":     true,
+	"exp":        true,
+	"client_id":  true,
+	"scope":      true,
+	"iat":        true,
+	"sub":        true,
+	"aud":        true,
+	"username":   true,
+	"token_type": true,
+}
+
+// tokenIntrospectionClaims describe the token itself, for example its confirmation method. They are taken from the
+// session of the token and can not be set through the extra claims of the introspection response.
+var tokenIntrospectionClaims = map[string]bool{
+	"cnf": true,
+	"iss": true,
+	"nbf": true,
+	"jti": true,
+}
+
+// mergeIntrospectionExtraClaims adds the extra claims to the introspection response, skipping reserved claims.
+func mergeIntrospectionExtraClaims(response map[string]interface{}, extraClaims map[string]interface{}, reserved ...map[string]bool) {
+	for name, value := range extraClaims {
+		if isReservedIntrospectionClaim(name, reserved) {
+			continue
+		}
+		response[name] = value
+	}
+}
+
+func isReservedIntrospectionClaim(name string, reserved []map[string]bool) bool {
+	for _, claims := range reserved {
+		if claims[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// introspectionCacheMaxAge returns for how many seconds the introspection response of an active token may be cached.
+// It is limited by IntrospectionCacheMaxAge and the remaining lifetime of the token.
+func (f *Fosite) introspectionCacheMaxAge(r IntrospectionResponder) int64 {
+	maxAge := f.IntrospectionCacheMaxAge
+	if maxAge <= 0 {
+		return 0
+	}
+
+	if exp := r.GetAccessRequester().GetSession().GetExpiresAt(introspectedTokenUse(r)); !exp.IsZero() {
+		if remaining := time.Until(exp); remaining < maxAge {
+			maxAge = remaining
+		}
+	}
+	return int64(maxAge / time.Second)
+}
+
+// introspectedTokenUse returns the type of the introspected token, assuming an access token if the introspection
+// strategy did not tell.
+func introspectedTokenUse(r IntrospectionResponder) TokenUse {
+	if r.GetTokenUse() == "" {
+		return AccessToken
+	}
+	return r.GetTokenUse()
+}
@@ -23,6 +23,7 @@ package fosite_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -186,3 +187,196 @@ func TestWriteIntrospectionResponseBody(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteIntrospectionResponseCacheControl(t *testing.T) {
+	for _, c := range []struct {
+		description  string
+		maxAge       time.Duration
+		expiresIn    time.Duration
+		cacheControl string
+	}{
+		{
+			description:  "should not allow caching by default",
+			expiresIn:    time.Hour,
+			cacheControl: "no-store",
+		},
+		{
+			description:  "should allow caching for the configured duration",
+			maxAge:       time.Minute,
+			expiresIn:    time.Hour,
+			cacheControl: "max-age=60",
+		},
+		{
+			description:  "should not allow caching beyond the token expiry",
+			maxAge:       time.Hour,
+			expiresIn:    time.Minute - time.Millisecond,
+			cacheControl: "max-age=59",
+		},
+		{
+			description:  "should not allow caching for tokens about to expire",
+			maxAge:       time.Hour,
+			expiresIn:    time.Millisecond * 500,
+			cacheControl: "no-store",
+		},
+	} {
+		t.Run(c.description, func(t *testing.T) {
+			f := &Fosite{IntrospectionCacheMaxAge: c.maxAge}
+			rw := httptest.NewRecorder()
+			sess := &DefaultSession{}
+			sess.SetExpiresAt(AccessToken, time.Now().Add(c.expiresIn))
+			f.WriteIntrospectionResponse(rw, &IntrospectionResponse{
+				Active:          true,
+				TokenUse:        AccessToken,
+				AccessRequester: NewAccessRequest(sess),
+			})
+			assert.Equal(t, c.cacheControl, rw.Header().Get("Cache-Control"))
+			if c.cacheControl == "no-store" {
+				assert.Equal(t, "no-cache", rw.Header().Get("Pragma"))
+			} else {
+				assert.Empty(t, rw.Header().Get("Pragma"))
+			}
+		})
+	}
+
+	t.Run("should not add headers or claims for inactive tokens", func(t *testing.T) {
+		f := &Fosite{IntrospectionCacheMaxAge: time.Hour}
+		rw := httptest.NewRecorder()
+		f.WriteIntrospectionResponse(rw, &IntrospectionResponse{Active: false})
+		assert.Empty(t, rw.Header().Get("Cache-Control"))
+		assert.JSONEq(t, `{"active":false}`, rw.Body.String())
+	})
+}
+
+func TestWriteIntrospectionResponseClaims(t *testing.T) {
+	f := new(Fosite)
+	rw := httptest.NewRecorder()
+
+	sess := &DefaultSession{Subject: "peter"}
+	sess.GetExtraClaims()["jti"] = "token-id"
+	sess.GetExtraClaims()["token_type"] = "invalid"
+	ar := NewAccessRequest(sess)
+	ar.Client = &DefaultClient{ID: "foo"}
+	f.WriteIntrospectionResponse(rw, &IntrospectionResponse{
+		Active:          true,
+		TokenUse:        AccessToken,
+		AccessTokenType: BearerAccessToken,
+		AccessRequester: ar,
+	})
+
+	var params struct {
+		JTI       string `json:"jti"`
+		TokenType string `json:"token_type"`
+		Iat       int64  `json:"iat"`
+		Nbf       int64  `json:"nbf"`
+		ClientID  string `json:"client_id"`
+	}
+	require.NoError(t, json.NewDecoder(rw.Body).Decode(&params))
+	assert.Equal(t, "token-id", params.JTI)
+	assert.Equal(t, BearerAccessToken, params.TokenType)
+	assert.Equal(t, ar.RequestedAt.Unix(), params.Iat)
+	assert.Equal(t, params.Iat, params.Nbf)
+	assert.Equal(t, "foo", params.ClientID)
+}
+
+func TestWriteIntrospectionResponseExtraClaims(t *testing.T) {
+	f := new(Fosite)
+	rw := httptest.NewRecorder()
+
+	sess := &DefaultSession{Subject: "peter"}
+	sess.GetExtraClaims()["tenant"] = "session-tenant"
+	sess.GetExtraClaims()["active"] = false
+	sess.GetExtraClaims()["department"] = "engineering"
+	sess.GetExtraClaims()["cnf"] = map[string]interface{}{"jkt": "session-thumbprint"}
+	sess.GetExtraClaims()["jti"] = "token-id"
+	ar := NewAccessRequest(sess)
+	ar.Client = &DefaultClient{ID: "foo"}
+	ar.GrantScope("read")
+
+	resp := (&IntrospectionResponse{
+		Active:          true,
+		TokenUse:        AccessToken,
+		AccessTokenType: BearerAccessToken,
+		AccessRequester: ar,
+	}).WithExtraClaims(map[string]interface{}{
+		"tenant": "acme",
+		"roles":  []string{"admin", "auditor"},
+		"active": false,
+		"sub":    "mallory",
+		"cnf":    map[string]interface{}{"jkt": "injected-thumbprint"},
+		"iss":    "https://evil.example.com",
+		"nbf":    0,
+		"jti":    "injected-id",
+	})
+	f.WriteIntrospectionResponse(rw, resp)
+
+	var params map[string]interface{}
+	require.NoError(t, json.NewDecoder(rw.Body).Decode(&params))
+	assert.Equal(t, true, params["active"])
+	assert.Equal(t, "peter", params["sub"])
+	assert.Equal(t, "foo", params["client_id"])
+	assert.Equal(t, "read", params["scope"])
+	assert.Equal(t, "acme", params["tenant"])
+	assert.Equal(t, "engineering", params["department"])
+	assert.Equal(t, []interface{}{"admin", "auditor"}, params["roles"])
+	assert.Equal(t, map[string]interface{}{"jkt": "session-thumbprint"}, params["cnf"])
+	assert.Equal(t, "token-id", params["jti"])
+	assert.Equal(t, params["iat"], params["nbf"])
+	assert.NotContains(t, params, "iss")
+}
+
+func TestWriteIntrospectionResponseVisibleScopes(t *testing.T) {
+	for k, c := range []struct {
+		visible     Arguments
+		expectScope interface{}
+	}{
+		{visible: nil, expectScope: "read write"},
+		{visible: Arguments{"read"}, expectScope: "read"},
+		{visible: Arguments{}, expectScope: nil},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			ar := NewAccessRequest(&DefaultSession{})
+			ar.GrantScope("read")
+			ar.GrantScope("write")
+
+			rw := httptest.NewRecorder()
+			new(Fosite).WriteIntrospectionResponse(rw, &IntrospectionResponse{
+				Active:          true,
+				AccessRequester: ar,
+				VisibleScopes:   c.visible,
+			})
+
+			var params map[string]interface{}
+			require.NoError(t, json.NewDecoder(rw.Body).Decode(&params))
+			assert.Equal(t, c.expectScope, params["scope"])
+		})
+	}
+}
+
+func TestWriteIntrospectionResponseRefreshToken(t *testing.T) {
+	f := new(Fosite)
+	rw := httptest.NewRecorder()
+
+	sess := &DefaultSession{}
+	sess.SetExpiresAt(AccessToken, time.Now().Add(time.Hour).Round(time.Second))
+	sess.SetExpiresAt(RefreshToken, time.Now().Add(time.Hour*24).Round(time.Second))
+	ar := NewAccessRequest(sess)
+	ar.GrantScope("offline")
+	ar.GrantAudience("https://api.example.com")
+	f.WriteIntrospectionResponse(rw, &IntrospectionResponse{
+		Active:          true,
+		TokenUse:        RefreshToken,
+		AccessRequester: ar,
+	})
+
+	var params struct {
+		TokenType string   `json:"token_type"`
+		Exp       int64    `json:"exp"`
+		Scope     string   `json:"scope"`
+		Audience  []string `json:"aud"`
+	}
+	require.NoError(t, json.NewDecoder(rw.Body).Decode(&params))
+	assert.Equal(t, string(RefreshToken), params.TokenType)
+	assert.Equal(t, sess.GetExpiresAt(RefreshToken).Unix(), params.Exp)
+	assert.Equal(t, "offline", params.Scope)
+	assert.Equal(t, []string{"https://api.example.com"}, params.Audience)
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import "context"
+
+// IntrospectionScopeFilter returns the scopes of the introspected token the caller of the introspection endpoint may
+// see. The caller is the same as for IntrospectionAuthorizer.
+type IntrospectionScopeFilter func(ctx context.Context, caller Requester, introspected AccessRequester, tokenUse TokenUse) Arguments
+
+// IntrospectionResponderWithVisibleScopes is implemented by introspection responses which only disclose a subset of
+// the scopes granted to the introspected token.
+type IntrospectionResponderWithVisibleScopes interface {
+	// GetVisibleScopes returns the scopes to disclose, or nil if all granted scopes are disclosed.
+	GetVisibleScopes() Arguments
+}
+
+// visibleIntrospectionScopes returns the scopes of the introspected token disclosed to the caller, or nil if scopes of
+// the token type are not restricted, see RestrictIntrospectionScopes. Unless IntrospectionScopeFilter is set, the
+// caller sees the granted scopes matched by its own scopes using ScopeStrategy.
+func (f *Fosite) visibleIntrospectionScopes(ctx context.Context, caller Requester, introspected AccessRequester, tokenUse TokenUse) Arguments {
+	var restricted bool
+	for _, tu := range f.RestrictIntrospectionScopes {
+		if tu == tokenUse {
+			restricted = true
+		}
+	}
+	if !restricted {
+		return nil
+	}
+
+	if f.IntrospectionScopeFilter != nil {
+		if visible := f.IntrospectionScopeFilter(ctx, caller, introspected, tokenUse); visible != nil {
+			return visible
+		}
+		return Arguments{}
+	}
+
+	visible := Arguments{}
+	for _, scope := range introspected.GetGrantedScopes() {
+		if f.ScopeStrategy(caller.GetGrantedScopes(), scope) {
+			visible = append(visible, scope)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,32 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"github.com/ory/fosite/token/jwt"
+)
+
+// isJWTSigningAlgorithmAllowed returns false if AllowedJWTSigningAlgorithms is set and does not contain the signing
+// algorithm of the token. The "none" algorithm is only allowed if it is listed explicitly.
+func (f *Fosite) isJWTSigningAlgorithmAllowed(t *jwt.Token) bool {
+	return len(f.AllowedJWTSigningAlgorithms) == 0 || jwt.IsSigningAlgorithmAllowed(t.Method, f.AllowedJWTSigningAlgorithms)
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+
+	"github.com/ory/x/errorsx"
+	"github.com/pkg/errors"
+)
+
+// LoginHintRequester is implemented by requests which carry the login_hint parameter, see
+// https://openid.net/specs/openid-connect-core-1_0.html#AuthRequest
+type LoginHintRequester interface {
+	// GetLoginHint returns the hint about the login identifier the end-user might use, or an empty string.
+	GetLoginHint() string
+}
+
+// LoginHintValidator validates the format of the login_hint of an authorize request, for example that it is an email
+// address. Errors which are not an *RFC6749Error are returned as ErrInvalidRequest.
+type LoginHintValidator func(ctx context.Context, loginHint string, request AuthorizeRequester) error
+
+// GetLoginHint returns the login_hint of the request, or an empty string if it carries none.
+func GetLoginHint(requester Requester) string {
+	if r, ok := requester.(LoginHintRequester); ok {
+		return r.GetLoginHint()
+	}
+	return ""
+}
+
+// validateLoginHint stores the login_hint on the request and validates it using LoginHintValidator, if set.
+func (f *Fosite) validateLoginHint(ctx context.Context, request *AuthorizeRequest) error {
+	request.LoginHint = request.Form.Get("login_hint")
+	if request.LoginHint == "" || f.LoginHintValidator == nil {
+		return nil
+	}
+
+	if err := f.LoginHintValidator(ctx, request.LoginHint, request); err != nil {
+		var rfcErr *RFC6749Error
+		if errors.As(err, &rfcErr) {
+			return errorsx.WithStack(rfcErr)
+		}
+		return errorsx.WithStack(ErrInvalidRequest.WithHint("Request parameter 'login_hint' is malformed.").WithWrap(err).WithDebug(err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestLoginHint(t *testing.T) {
+	validator := func(_ context.Context, loginHint string, _ AuthorizeRequester) error {
+		if !strings.Contains(loginHint, "@") {
+			return errors.New("the login hint is not an email address")
+		}
+		return nil
+	}
+
+	for k, c := range []struct {
+		validator LoginHintValidator
+		loginHint string
+		expectErr error
+	}{
+		{loginHint: ""},
+		{loginHint: "peter"},
+		{validator: validator, loginHint: "peter@example.org"},
+		{validator: validator, loginHint: ""},
+		{validator: validator, loginHint: "peter", expectErr: ErrInvalidRequest},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			f := &Fosite{Store: storage.NewExampleStore(), ScopeStrategy: ExactScopeStrategy, AudienceMatchingStrategy: DefaultAudienceMatchingStrategy, LoginHintValidator: c.validator}
+			r := httptest.NewRequest(http.MethodGet, "/auth?"+url.Values{
+				"client_id":     {"my-client"},
+				"redirect_uri":  {"http://localhost:3846/callback"},
+				"response_type": {"code"},
+				"state":         {"strong-state"},
+				"login_hint":    {c.loginHint},
+			}.Encode(), nil)
+
+			ar, err := f.NewAuthorizeRequest(context.Background(), r)
+			if c.expectErr != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, c.expectErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.loginHint, ar.(*AuthorizeRequest).LoginHint)
+			assert.Equal(t, c.loginHint, GetLoginHint(ar))
+		})
+	}
+}
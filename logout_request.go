@@ -0,0 +1,183 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/ory/x/errorsx"
+	"github.com/pkg/errors"
+
+	"github.com/ory/fosite/token/jwt"
+)
+
+// LogoutRequest is a validated OpenID Connect RP-Initiated Logout request as defined in
+// https://openid.net/specs/openid-connect-rpinitiated-1_0.html#RPLogout
+type LogoutRequest struct {
+	// Client is the client which initiated the logout. It is nil if the request contains neither a client_id nor an
+	// id_token_hint.
+	Client Client
+
+	// IDTokenHint is the decoded id_token_hint, or nil if the parameter was not set.
+	IDTokenHint *jwt.Token
+
+	// Subject and SessionID are the "sub" and "sid" claims of the id_token_hint.
+	Subject   string
+	SessionID string
+
+	// PostLogoutRedirectURI is the validated post_logout_redirect_uri, or nil if the parameter was not set.
+	PostLogoutRedirectURI *url.URL
+
+	State string
+	Form  url.Values
+}
+
+// LogoutResponse is the response to an OpenID Connect RP-Initiated Logout request.
+type LogoutResponse struct {
+	// RedirectURI is the post_logout_redirect_uri including the state. It is nil if the client did not ask to be
+	// redirected, in which case the authorization server renders its own page to the end-user.
+	RedirectURI *url.URL
+}
+
+// NewRPInitiatedLogoutRequest validates an OpenID Connect RP-Initiated Logout request as defined in
+// https://openid.net/specs/openid-connect-rpinitiated-1_0.html#RPLogout
+//
+// The id_token_hint is decoded using IDTokenHintStrategy, expired ID Tokens are accepted. The post_logout_redirect_uri
+// must exactly match one of the URIs registered by the client. Logging out the end-user is left to the caller.
+func (f *Fosite) NewRPInitiatedLogoutRequest(ctx context.Context, r *http.Request) (*LogoutRequest, error) {
+	if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithWrap(err).WithDebug(err.Error()))
+	}
+
+	request := &LogoutRequest{
+		State: r.Form.Get("state"),
+		Form:  r.Form,
+	}
+
+	clientID := r.Form.Get("client_id")
+	if hint := r.Form.Get("id_token_hint"); hint != "" {
+		if f.IDTokenHintStrategy == nil {
+			return nil, errorsx.WithStack(ErrMisconfiguration.WithDebug("The authorization server is unable to decode the id_token_hint because no IDTokenHintStrategy is configured."))
+		}
+
+		token, err := f.IDTokenHintStrategy.Decode(ctx, hint)
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Errors == jwt.ValidationErrorExpired {
+			// Expired ID Tokens are allowed as values to id_token_hint
+		} else if err != nil {
+			return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("Unable to decode the id token from the 'id_token_hint' parameter.").WithWrap(err).WithDebug(err.Error()))
+		}
+
+		request.IDTokenHint = token
+		request.Subject, _ = token.Claims["sub"].(string)
+		request.SessionID, _ = token.Claims["sid"].(string)
+		if request.Subject == "" {
+			return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("The id token from the 'id_token_hint' parameter does not have a subject."))
+		} else if f.Issuer != "" && !token.Claims.VerifyIssuer(f.Issuer, true) {
+			return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("The id token from the 'id_token_hint' parameter was not issued by this authorization server."))
+		}
+
+		if clientID == "" {
+			clientID = idTokenHintClientID(token.Claims)
+		} else if !token.Claims.VerifyAudience(clientID, true) {
+			return nil, errorsx.WithStack(ErrInvalidRequest.WithHintf("The id token from the 'id_token_hint' parameter was not issued to the client '%s'.", clientID))
+		}
+	}
+
+	if clientID != "" {
+		client, err := f.getClient(ctx, clientID)
+		if errors.Is(err, ErrTemporarilyUnavailable) {
+			return nil, err
+		} else if err != nil {
+			return nil, errorsx.WithStack(ErrInvalidClient.WithHint("The requested OAuth 2.0 Client does not exist.").WithWrap(err).WithDebug(err.Error()))
+		}
+		request.Client = client
+	}
+
+	rawRedirectURI := r.Form.Get("post_logout_redirect_uri")
+	if rawRedirectURI == "" {
+		return request, nil
+	} else if request.Client == nil {
+		return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("Parameter 'post_logout_redirect_uri' requires the 'client_id' or 'id_token_hint' parameter to be set."))
+	}
+
+	var registered []string
+	if lc, ok := request.Client.(RPInitiatedLogoutClient); ok {
+		registered = lc.GetPostLogoutRedirectURIs()
+	}
+
+	if !StringInSlice(rawRedirectURI, registered) {
+		return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("The 'post_logout_redirect_uri' parameter does not match any of the OAuth 2.0 Client's pre-registered post logout redirect URIs."))
+	}
+
+	redirectURI, err := url.Parse(rawRedirectURI)
+	if err != nil {
+		return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("Unable to parse the 'post_logout_redirect_uri' parameter.").WithWrap(err).WithDebug(err.Error()))
+	}
+	request.PostLogoutRedirectURI = redirectURI
+
+	return request, nil
+}
+
+// NewRPInitiatedLogoutResponse returns the response to a validated OpenID Connect RP-Initiated Logout request. It
+// should be called once the end-user was logged out.
+func (f *Fosite) NewRPInitiatedLogoutResponse(ctx context.Context, request *LogoutRequest) *LogoutResponse {
+	response := &LogoutResponse{}
+	if request.PostLogoutRedirectURI == nil {
+		return response
+	}
+
+	redirectURI := *request.PostLogoutRedirectURI
+	if request.State != "" {
+		query := redirectURI.Query()
+		query.Set("state", request.State)
+		redirectURI.RawQuery = query.Encode()
+	}
+	response.RedirectURI = &redirectURI
+
+	return response
+}
+
+// idTokenHintClientID returns the client the id token was issued to, which is the authorized party or the single
+// audience of the token.
+func idTokenHintClientID(claims jwt.MapClaims) string {
+	if azp, _ := claims["azp"].(string); azp != "" {
+		return azp
+	}
+
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud
+	case []interface{}:
+		if len(aud) == 1 {
+			s, _ := aud[0].(string)
+			return s
+		}
+	case []string:
+		if len(aud) == 1 {
+			return aud[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/jwt"
+)
+
+func TestNewRPInitiatedLogoutRequest(t *testing.T) {
+	signer := &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()}
+	other := &jwt.RS256JWTStrategy{PrivateKey: internal.MustRSAKey()}
+
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultOpenIDConnectClient{
+		DefaultClient:          &DefaultClient{ID: "foo"},
+		PostLogoutRedirectURIs: []string{"https://foo.example.com/logged-out"},
+	}
+	store.Clients["bar"] = &DefaultClient{ID: "bar"}
+	f := &Fosite{Store: store, IDTokenHintStrategy: signer}
+
+	idToken := func(s jwt.JWTStrategy, claims jwt.MapClaims) string {
+		token, _, err := s.Generate(context.Background(), claims, jwt.NewHeaders())
+		require.NoError(t, err)
+		return token
+	}
+	valid := idToken(signer, jwt.MapClaims{"sub": "peter", "sid": "session", "aud": []string{"foo"}, "exp": time.Now().Add(time.Hour).Unix()})
+
+	for k, c := range []struct {
+		d         string
+		form      url.Values
+		expectErr error
+		check     func(t *testing.T, r *LogoutRequest)
+	}{
+		{
+			d:    "should pass without parameters",
+			form: url.Values{},
+			check: func(t *testing.T, r *LogoutRequest) {
+				assert.Nil(t, r.Client)
+				assert.Nil(t, r.PostLogoutRedirectURI)
+			},
+		},
+		{
+			d:    "should expose the subject and session of the id_token_hint",
+			form: url.Values{"id_token_hint": {valid}, "post_logout_redirect_uri": {"https://foo.example.com/logged-out"}, "state": {"some-state"}},
+			check: func(t *testing.T, r *LogoutRequest) {
+				assert.Equal(t, "foo", r.Client.GetID())
+				assert.Equal(t, "peter", r.Subject)
+				assert.Equal(t, "session", r.SessionID)
+				assert.Equal(t, "https://foo.example.com/logged-out", r.PostLogoutRedirectURI.String())
+				assert.Equal(t, "some-state", r.State)
+			},
+		},
+		{
+			d:    "should accept expired id tokens",
+			form: url.Values{"id_token_hint": {idToken(signer, jwt.MapClaims{"sub": "peter", "aud": "foo", "exp": time.Now().Add(-time.Hour).Unix()})}},
+			check: func(t *testing.T, r *LogoutRequest) {
+				assert.Equal(t, "foo", r.Client.GetID())
+				assert.Equal(t, "peter", r.Subject)
+			},
+		},
+		{
+			d:         "should fail because the id_token_hint was signed by another key",
+			form:      url.Values{"id_token_hint": {idToken(other, jwt.MapClaims{"sub": "peter", "aud": "foo"})}},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d:         "should fail because the id_token_hint has no subject",
+			form:      url.Values{"id_token_hint": {idToken(signer, jwt.MapClaims{"aud": "foo"})}},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d:         "should fail because the id_token_hint was issued to another client",
+			form:      url.Values{"id_token_hint": {valid}, "client_id": {"bar"}},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d:         "should fail because the client does not exist",
+			form:      url.Values{"client_id": {"baz"}},
+			expectErr: ErrInvalidClient,
+		},
+		{
+			d:         "should fail because the post_logout_redirect_uri requires a client",
+			form:      url.Values{"post_logout_redirect_uri": {"https://foo.example.com/logged-out"}},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d:         "should fail because the post_logout_redirect_uri is not registered",
+			form:      url.Values{"client_id": {"foo"}, "post_logout_redirect_uri": {"https://foo.example.com/logged-out/other"}},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d:         "should fail because the client registered no post_logout_redirect_uris",
+			form:      url.Values{"client_id": {"bar"}, "post_logout_redirect_uri": {"https://foo.example.com/logged-out"}},
+			expectErr: ErrInvalidRequest,
+		},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.d), func(t *testing.T) {
+			r := &http.Request{Method: "GET", Header: http.Header{}, URL: &url.URL{RawQuery: c.form.Encode()}}
+			lr, err := f.NewRPInitiatedLogoutRequest(context.Background(), r)
+			if c.expectErr != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, c.expectErr), "%+v", err)
+				return
+			}
+			require.NoError(t, err)
+			c.check(t, lr)
+		})
+	}
+
+	t.Run("case=should fail because the id_token_hint was issued by another issuer", func(t *testing.T) {
+		f := &Fosite{Store: store, IDTokenHintStrategy: signer, Issuer: "https://auth.example.com"}
+		for iss, valid := range map[string]bool{"https://auth.example.com": true, "https://other.example.com": false} {
+			hint := idToken(signer, jwt.MapClaims{"iss": iss, "sub": "peter", "aud": "foo"})
+			r := &http.Request{Method: "GET", Header: http.Header{}, URL: &url.URL{RawQuery: url.Values{"id_token_hint": {hint}}.Encode()}}
+			_, err := f.NewRPInitiatedLogoutRequest(context.Background(), r)
+			if valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidRequest), "%+v", err)
+			}
+		}
+	})
+
+	t.Run("case=should fail without an IDTokenHintStrategy", func(t *testing.T) {
+		r := &http.Request{Method: "GET", Header: http.Header{}, URL: &url.URL{RawQuery: url.Values{"id_token_hint": {valid}}.Encode()}}
+		_, err := (&Fosite{Store: store}).NewRPInitiatedLogoutRequest(context.Background(), r)
+		assert.True(t, errors.Is(err, ErrMisconfiguration), "%+v", err)
+	})
+}
+
+func TestNewRPInitiatedLogoutResponse(t *testing.T) {
+	f := &Fosite{}
+
+	assert.Nil(t, f.NewRPInitiatedLogoutResponse(context.Background(), &LogoutRequest{}).RedirectURI)
+
+	redirectURI, _ := url.Parse("https://foo.example.com/logged-out?foo=bar")
+	resp := f.NewRPInitiatedLogoutResponse(context.Background(), &LogoutRequest{PostLogoutRedirectURI: redirectURI, State: "some-state"})
+	assert.Equal(t, "https://foo.example.com/logged-out?foo=bar&state=some-state", resp.RedirectURI.String())
+	assert.Equal(t, "https://foo.example.com/logged-out?foo=bar", redirectURI.String())
+}
@@ -37,6 +37,9 @@ const (
 	RefreshToken  TokenType = "refresh_token"
 	AuthorizeCode TokenType = "authorize_code"
 	IDToken       TokenType = "id_token"
+	DeviceCode    TokenType = "device_code"
+	UserCode      TokenType = "user_code"
+	AuthReqID     TokenType = "auth_req_id"
 
 	BearerAccessToken string = "bearer"
 )
@@ -89,7 +92,7 @@ type OAuth2Provider interface {
 	//   fragment component.
 	// * https://tools.ietf.org/html/rfc6749#section-4.1.2.1 (everything)
 	// * https://tools.ietf.org/html/rfc6749#section-3.1.2.2 (everything MUST be implemented)
-	WriteAuthorizeError(rw http.ResponseWriter, requester AuthorizeRequester, err error)
+	WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, requester AuthorizeRequester, err error)
 
 	// WriteAuthorizeResponse persists the AuthorizeSession in the store and redirects the user agent to the provided
 	// redirect url or returns an error if storage failed.
@@ -103,7 +106,7 @@ type OAuth2Provider interface {
 	//   authorization server during the client registration process or when
 	//   making the authorization request.
 	// * https://tools.ietf.org/html/rfc6749#section-3.1.2.2 (everything MUST be implemented)
-	WriteAuthorizeResponse(rw http.ResponseWriter, requester AuthorizeRequester, responder AuthorizeResponder)
+	WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, requester AuthorizeRequester, responder AuthorizeResponder)
 
 	// NewAccessRequest creates a new access request object and validates
 	// various parameters.
@@ -145,6 +148,14 @@ type OAuth2Provider interface {
 	// https://tools.ietf.org/html/rfc7009#section-2.2
 	WriteRevocationResponse(rw http.ResponseWriter, err error)
 
+	// RevokeTokensBySubject revokes the access and refresh tokens of all clients issued to the subject, for example
+	// after the account of the resource owner has been compromised, and returns their number.
+	RevokeTokensBySubject(ctx context.Context, subject string) (int, error)
+
+	// RevokeTokensByClient revokes the access tokens, refresh tokens and authorize codes issued to the client, for
+	// example when it is decommissioned, and returns their number.
+	RevokeTokensByClient(ctx context.Context, clientID string) (int, error)
+
 	// IntrospectToken returns token metadata, if the token is valid. Tokens generated by the authorization endpoint,
 	// such as the authorization code, can not be introspected.
 	IntrospectToken(ctx context.Context, token string, tokenUse TokenUse, session Session, scope ...string) (TokenUse, AccessRequester, error)
@@ -160,6 +171,96 @@ type OAuth2Provider interface {
 	// WriteIntrospectionResponse responds with token metadata discovered by token introspection as defined in
 	// https://tools.ietf.org/search/rfc7662#section-2.2
 	WriteIntrospectionResponse(rw http.ResponseWriter, r IntrospectionResponder)
+
+	// NewBackchannelAuthenticationRequest creates a new backchannel authentication request object and validates
+	// various parameters.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#auth_request
+	NewBackchannelAuthenticationRequest(ctx context.Context, req *http.Request) (BackchannelAuthenticationRequester, error)
+
+	// NewBackchannelAuthenticationResponse iterates through all backchannel authentication endpoint handlers and
+	// returns their result. It returns an error if none of the handlers issued a backchannel authentication request ID.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#successful_authentication_request_acknowdlegment
+	NewBackchannelAuthenticationResponse(ctx context.Context, requester BackchannelAuthenticationRequester, session Session) (BackchannelAuthenticationResponder, error)
+
+	// WriteBackchannelAuthenticationError writes a backchannel authentication request error response.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#auth_error_response
+	WriteBackchannelAuthenticationError(rw http.ResponseWriter, requester BackchannelAuthenticationRequester, err error)
+
+	// WriteBackchannelAuthenticationResponse writes the backchannel authentication response.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://openid.net/specs/openid-client-initiated-backchannel-authentication-core-1_0.html#successful_authentication_request_acknowdlegment
+	WriteBackchannelAuthenticationResponse(rw http.ResponseWriter, requester BackchannelAuthenticationRequester, responder BackchannelAuthenticationResponder)
+
+	// NewRPInitiatedLogoutRequest validates an OpenID Connect RP-Initiated Logout request.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://openid.net/specs/openid-connect-rpinitiated-1_0.html#RPLogout
+	NewRPInitiatedLogoutRequest(ctx context.Context, r *http.Request) (*LogoutRequest, error)
+
+	// NewRPInitiatedLogoutResponse returns the response to a validated OpenID Connect RP-Initiated Logout request.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://openid.net/specs/openid-connect-rpinitiated-1_0.html#RedirectionAfterLogout
+	NewRPInitiatedLogoutResponse(ctx context.Context, request *LogoutRequest) *LogoutResponse
+
+	// NewPushedAuthorizeRequest creates a new pushed authorization request object and validates it as an
+	// authorization request made by the authenticated client.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://tools.ietf.org/html/rfc9126#section-2.1 (everything)
+	NewPushedAuthorizeRequest(ctx context.Context, req *http.Request) (AuthorizeRequester, error)
+
+	// NewPushedAuthorizeResponse iterates through all pushed authorization endpoint handlers and returns their
+	// result. It returns an error if none of the handlers stored the request.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://tools.ietf.org/html/rfc9126#section-2.2 (everything)
+	NewPushedAuthorizeResponse(ctx context.Context, requester AuthorizeRequester, session Session) (PushedAuthorizeResponder, error)
+
+	// WritePushedAuthorizeError writes a pushed authorization request error response.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://tools.ietf.org/html/rfc9126#section-2.3 (everything)
+	WritePushedAuthorizeError(rw http.ResponseWriter, requester AuthorizeRequester, err error)
+
+	// WritePushedAuthorizeResponse writes the pushed authorization response.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://tools.ietf.org/html/rfc9126#section-2.2 (everything)
+	WritePushedAuthorizeResponse(rw http.ResponseWriter, requester AuthorizeRequester, responder PushedAuthorizeResponder)
+
+	// NewDeviceRequest creates a new device authorization request object and validates various parameters.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://tools.ietf.org/html/rfc8628#section-3.1 (everything)
+	NewDeviceRequest(ctx context.Context, req *http.Request) (DeviceRequester, error)
+
+	// NewDeviceResponse iterates through all device endpoint handlers and returns their result. It returns an error
+	// if none of the handlers issued a device and user code.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://tools.ietf.org/html/rfc8628#section-3.2 (everything)
+	NewDeviceResponse(ctx context.Context, requester DeviceRequester, session Session) (DeviceResponder, error)
+
+	// WriteDeviceError writes a device authorization request error response.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://tools.ietf.org/html/rfc8628#section-3.2
+	// * https://tools.ietf.org/html/rfc6749#section-5.2 (everything)
+	WriteDeviceError(rw http.ResponseWriter, requester DeviceRequester, err error)
+
+	// WriteDeviceResponse writes the device authorization response.
+	//
+	// The following specs must be considered in any implementation of this method:
+	// * https://tools.ietf.org/html/rfc8628#section-3.2 (everything)
+	WriteDeviceResponse(rw http.ResponseWriter, requester DeviceRequester, responder DeviceResponder)
 }
 
 // IntrospectionResponder is the response object that will be returned when token introspection was successful,
@@ -280,6 +381,16 @@ type AuthorizeRequester interface {
 	Requester
 }
 
+// DeviceRequester is a device authorization endpoint's request context.
+type DeviceRequester interface {
+	Requester
+}
+
+// BackchannelAuthenticationRequester is a backchannel authentication endpoint's request context.
+type BackchannelAuthenticationRequester interface {
+	Requester
+}
+
 // AccessResponder is a token endpoint's response.
 type AccessResponder interface {
 	// SetExtra sets a key value pair for the access response.
@@ -290,8 +401,18 @@ type AccessResponder interface {
 
 	SetExpiresIn(time.Duration)
 
+	// SetScopes sets the granted scopes, which are returned in the "scope" parameter of the response.
 	SetScopes(scopes Arguments)
 
+	// GetScopes returns the granted scopes.
+	GetScopes() Arguments
+
+	// SetRequestedScopes sets the scopes the client requested, before scopes were dropped by the authorization server.
+	SetRequestedScopes(scopes Arguments)
+
+	// GetRequestedScopes returns the scopes the client requested.
+	GetRequestedScopes() Arguments
+
 	// SetAccessToken sets the responses mandatory access token.
 	SetAccessToken(token string)
 
@@ -325,3 +446,87 @@ type AuthorizeResponder interface {
 	// AddParameter adds key value pair to the response
 	AddParameter(key, value string)
 }
+
+// DeviceResponder is a device authorization endpoint's response.
+type DeviceResponder interface {
+	// GetDeviceCode returns the response's device verification code.
+	GetDeviceCode() string
+
+	// SetDeviceCode sets the response's mandatory device verification code.
+	SetDeviceCode(code string)
+
+	// GetUserCode returns the response's end-user verification code.
+	GetUserCode() string
+
+	// SetUserCode sets the response's mandatory end-user verification code.
+	SetUserCode(code string)
+
+	// GetVerificationURI returns the end-user verification URI on the authorization server.
+	GetVerificationURI() string
+
+	// SetVerificationURI sets the response's mandatory end-user verification URI.
+	SetVerificationURI(uri string)
+
+	// GetVerificationURIComplete returns the verification URI which includes the user code.
+	GetVerificationURIComplete() string
+
+	// SetVerificationURIComplete sets the verification URI which includes the user code.
+	SetVerificationURIComplete(uri string)
+
+	// GetExpiresIn returns the lifetime of the device and user code in seconds.
+	GetExpiresIn() int64
+
+	// SetExpiresIn sets the lifetime of the device and user code.
+	SetExpiresIn(expiresIn time.Duration)
+
+	// GetInterval returns the minimum amount of time in seconds that the client should wait between polling requests.
+	GetInterval() int64
+
+	// SetInterval sets the minimum amount of time that the client should wait between polling requests.
+	SetInterval(interval time.Duration)
+
+	// ToMap converts the response to a map.
+	ToMap() map[string]interface{}
+}
+
+// BackchannelAuthenticationResponder is a backchannel authentication endpoint's response.
+type BackchannelAuthenticationResponder interface {
+	// GetAuthReqID returns the response's backchannel authentication request ID.
+	GetAuthReqID() string
+
+	// SetAuthReqID sets the response's mandatory backchannel authentication request ID.
+	SetAuthReqID(id string)
+
+	// GetExpiresIn returns the lifetime of the backchannel authentication request ID in seconds.
+	GetExpiresIn() int64
+
+	// SetExpiresIn sets the lifetime of the backchannel authentication request ID.
+	SetExpiresIn(expiresIn time.Duration)
+
+	// GetInterval returns the minimum amount of time in seconds that the client should wait between polling requests.
+	GetInterval() int64
+
+	// SetInterval sets the minimum amount of time that the client should wait between polling requests.
+	SetInterval(interval time.Duration)
+
+	// ToMap converts the response to a map.
+	ToMap() map[string]interface{}
+}
+
+// PushedAuthorizeResponder is a pushed authorization endpoint's response.
+type PushedAuthorizeResponder interface {
+	// GetRequestURI returns the request_uri the client passes to the authorization endpoint.
+	GetRequestURI() string
+
+	// SetRequestURI sets the request_uri the client passes to the authorization endpoint.
+	SetRequestURI(requestURI string)
+
+	// GetExpiresIn returns the lifetime of the request_uri in seconds.
+	GetExpiresIn() int64
+
+	// SetExpiresIn sets the lifetime of the request_uri.
+	SetExpiresIn(expiresIn time.Duration)
+
+	// ToMap converts the response to a map.
+	ToMap() map[string]interface{}
+}
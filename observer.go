@@ -0,0 +1,121 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Observer receives measurements of the requests handled by fosite, for example to expose them as Prometheus counters
+// and histograms, without coupling fosite to a metrics library. The callbacks are invoked synchronously while the
+// request is handled and must not block. They can not alter the response; a panicking callback is recovered and
+// ignored.
+type Observer interface {
+	// TokenIssued is invoked for every access response which was created, labeled with the grant type and client.
+	TokenIssued(ctx context.Context, labels ObserverLabels)
+
+	// RequestFailed is invoked for every failed authorize, token, introspection and revocation request, labeled with
+	// the endpoint and the OAuth 2.0 error code.
+	RequestFailed(ctx context.Context, labels ObserverLabels)
+
+	// AuthorizeRequestObserved is invoked with the time it took NewAuthorizeRequest to validate an authorize request,
+	// regardless of its outcome.
+	AuthorizeRequestObserved(ctx context.Context, labels ObserverLabels, duration time.Duration)
+
+	// IntrospectionObserved is invoked with the time it took NewIntrospectionRequest to handle a token introspection
+	// request, regardless of its outcome.
+	IntrospectionObserved(ctx context.Context, labels ObserverLabels, duration time.Duration)
+}
+
+// ObserverEndpoint is the endpoint a measurement was taken at.
+type ObserverEndpoint string
+
+const (
+	ObserverEndpointAuthorize     ObserverEndpoint = "authorize"
+	ObserverEndpointToken         ObserverEndpoint = "token"
+	ObserverEndpointIntrospection ObserverEndpoint = "introspection"
+	ObserverEndpointRevocation    ObserverEndpoint = "revocation"
+)
+
+// ObserverLabels describe a measurement. Labels which do not apply to the endpoint are empty. Note that ClientID has
+// an unbounded number of values, which integrators may want to drop from their metrics.
+type ObserverLabels struct {
+	Endpoint ObserverEndpoint
+
+	// ClientID is the ID of the client which made the request. For introspection requests it is the ID of the client
+	// the introspected token was issued to.
+	ClientID string
+
+	// GrantType is the grant type of token requests.
+	GrantType string
+
+	// ResponseType is the space delimited response type of authorize requests, for example "code id_token".
+	ResponseType string
+
+	// TokenUse is the type of the introspected token, and Active whether it was active.
+	TokenUse TokenUse
+	Active   bool
+
+	// ErrorCode is the OAuth 2.0 error code of failed requests, for example "invalid_grant".
+	ErrorCode string
+}
+
+// NoopObserver is an Observer which discards all measurements.
+type NoopObserver struct{}
+
+func (*NoopObserver) TokenIssued(context.Context, ObserverLabels)                             {}
+func (*NoopObserver) RequestFailed(context.Context, ObserverLabels)                           {}
+func (*NoopObserver) AuthorizeRequestObserved(context.Context, ObserverLabels, time.Duration) {}
+func (*NoopObserver) IntrospectionObserved(context.Context, ObserverLabels, time.Duration)    {}
+
+// newObserverLabels derives the labels of a measurement at endpoint from the audit event of the request. The token
+// type and whether it is active are only labels of introspection requests.
+func newObserverLabels(endpoint ObserverEndpoint, event *AuditEvent) ObserverLabels {
+	labels := ObserverLabels{
+		Endpoint:     endpoint,
+		ClientID:     event.ClientID,
+		GrantType:    strings.Join(event.GrantTypes, " "),
+		ResponseType: strings.Join(event.ResponseTypes, " "),
+		ErrorCode:    event.ErrorCode,
+	}
+	if endpoint == ObserverEndpointIntrospection {
+		labels.TokenUse = TokenUse(event.TokenType)
+		labels.Active = event.Active
+	}
+	return labels
+}
+
+func (f *Fosite) observe(fn func(o Observer)) {
+	if f.Observer == nil {
+		return
+	}
+	recoverHook(func() { fn(f.Observer) })
+}
+
+// observeFailure reports the request to the Observer if it failed.
+func observeFailure(ctx context.Context, o Observer, labels ObserverLabels) {
+	if labels.ErrorCode != "" {
+		o.RequestFailed(ctx, labels)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ory/x/errorsx"
+	"github.com/ory/x/stringslice"
+	"github.com/pkg/errors"
+)
+
+// PushedAuthorizeRequestURIPrefix is the prefix of the request_uri values returned by the pushed authorization
+// request endpoint, see https://tools.ietf.org/html/rfc9126#section-2.2
+const PushedAuthorizeRequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+// clientAuthenticationParameters are the parameters a client authenticates with at the pushed authorization request
+// endpoint. They are not part of the authorization request and are never stored with it.
+var clientAuthenticationParameters = []string{"client_secret", "client_assertion", "client_assertion_type"}
+
+// withoutClientAuthenticationParameters returns a copy of the form without clientAuthenticationParameters.
+func withoutClientAuthenticationParameters(form url.Values) url.Values {
+	result := url.Values{}
+	for k, v := range form {
+		if stringslice.Has(clientAuthenticationParameters, k) {
+			continue
+		}
+		result[k] = append([]string{}, v...)
+	}
+	return result
+}
+
+// NewPushedAuthorizeRequest parses and validates a pushed authorization request as defined in
+// https://tools.ietf.org/html/rfc9126#section-2.1. The client authenticates in the same manner as when making
+// requests to the token endpoint, and the request is validated like a request to the authorization endpoint.
+func (f *Fosite) NewPushedAuthorizeRequest(ctx context.Context, r *http.Request) (AuthorizeRequester, error) {
+	request := NewAuthorizeRequest()
+
+	ctx = context.WithValue(ctx, RequestContextKey, r)
+	ctx = context.WithValue(ctx, AuthorizeRequestContextKey, request)
+
+	if r.Method != "POST" {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHintf("HTTP method is '%s', expected 'POST'.", r.Method))
+	} else if err := f.parseMultipartForm(r); err != nil && err != http.ErrNotMultipart {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("Unable to parse HTTP body, make sure to send a properly formatted form request body.").WithWrap(err).WithDebug(err.Error()))
+	} else if len(r.PostForm) == 0 {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("The POST body can not be empty."))
+	} else if err := f.validateParameterLengths(r.Form); err != nil {
+		return request, err
+	}
+	request.Form = r.Form
+
+	// Save state to the request to be returned in error conditions
+	request.State = request.Form.Get("state")
+
+	// The request_uri parameter MUST NOT be provided, see https://tools.ietf.org/html/rfc9126#section-2.1
+	if len(request.Form.Get("request_uri")) > 0 {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("The 'request_uri' parameter must not be used with pushed authorization requests."))
+	}
+
+	ctx, err := f.extractRequestContext(ctx, r, &request.Request)
+	if err != nil {
+		return request, err
+	}
+
+	client, err := f.AuthenticateClient(ctx, r, r.PostForm)
+	if err != nil {
+		return request, err
+	} else if id := request.Form.Get("client_id"); len(id) > 0 && id != client.GetID() {
+		return request, errorsx.WithStack(ErrInvalidRequest.WithHint("The 'client_id' parameter does not match the authenticated OAuth 2.0 Client."))
+	}
+	request.Client = client
+	request.Form = withoutClientAuthenticationParameters(request.Form)
+
+	if err := f.validateAuthorizeRequest(ctx, r, request); err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+// authorizeRequestFromPAR replaces the parameters of the authorize request with the ones of the pushed authorization
+// request it references, if any. It returns true if the request referenced a pushed authorization request.
+func (f *Fosite) authorizeRequestFromPAR(ctx context.Context, request *AuthorizeRequest) (bool, error) {
+	requestURI := request.Form.Get("request_uri")
+	if !strings.HasPrefix(requestURI, PushedAuthorizeRequestURIPrefix) {
+		return false, nil
+	}
+
+	storage, ok := f.Store.(PARStorage)
+	if !ok {
+		return false, errorsx.WithStack(ErrRequestURINotSupported.WithHint("The authorization server does not support pushed authorization requests."))
+	}
+
+	pushed, err := storage.GetPARSession(ctx, requestURI)
+	if errors.Is(err, ErrNotFound) {
+		return false, errorsx.WithStack(ErrInvalidRequestURI.WithHint("The 'request_uri' is unknown, expired or has already been used.").WithWrap(err).WithDebug(err.Error()))
+	} else if err != nil {
+		return false, errorsx.WithStack(ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	// The ownership is checked before the request_uri is consumed so that other clients can not invalidate it.
+	if pushed.GetRequestedAt().Add(f.GetPushedAuthorizeRequestLifespan()).Before(time.Now().UTC()) {
+		if err := storage.DeletePARSession(ctx, requestURI); err != nil && !errors.Is(err, ErrNotFound) {
+			return false, errorsx.WithStack(ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+		return false, errorsx.WithStack(ErrInvalidRequestURI.WithHint("The 'request_uri' has expired."))
+	} else if pushed.GetClient() == nil || pushed.GetClient().GetID() != request.Client.GetID() {
+		return false, errorsx.WithStack(ErrInvalidRequestURI.WithHint("The 'request_uri' was pushed by a different OAuth 2.0 Client."))
+	}
+
+	// The request_uri is deleted before it is used to ensure that it can be used once only.
+	if err := storage.DeletePARSession(ctx, requestURI); errors.Is(err, ErrNotFound) {
+		return false, errorsx.WithStack(ErrInvalidRequestURI.WithHint("The 'request_uri' is unknown, expired or has already been used.").WithWrap(err).WithDebug(err.Error()))
+	} else if err != nil {
+		return false, errorsx.WithStack(ErrServerError.WithWrap(err).WithDebug(err.Error()))
+	}
+
+	// The front-channel request carries nothing but client_id and request_uri, every other parameter is taken from
+	// the pushed authorization request, see https://tools.ietf.org/html/rfc9126#section-4
+	form := withoutClientAuthenticationParameters(pushed.GetRequestForm())
+	form.Set("client_id", request.Form.Get("client_id"))
+	form.Set("request_uri", requestURI)
+	request.Form = form
+
+	requestedAt := request.RequestedAt
+	request.Merge(pushed)
+	request.RequestedAt = requestedAt
+	request.ResponseTypes = pushed.GetResponseTypes()
+	request.RedirectURI = pushed.GetRedirectURI()
+	request.State = pushed.GetState()
+	request.ResponseMode = pushed.GetResponseMode()
+	request.DefaultResponseMode = pushed.GetDefaultResponseMode()
+	// The login_hint has been validated by LoginHintValidator when it was pushed.
+	request.LoginHint = GetLoginHint(pushed)
+	if ui, ok := pushed.(AuthorizeUIRequester); ok {
+		request.UILocales = ui.GetUILocales()
+		request.ClaimsLocales = ui.GetClaimsLocales()
+		request.Display = ui.GetDisplay()
+	}
+	request.redirectURIMatchingStrategy = f.RedirectURIMatchingStrategy
+	return true, nil
+}
@@ -0,0 +1,325 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/handler/par"
+	"github.com/ory/fosite/storage"
+)
+
+func newPARProvider() (*Fosite, *storage.MemoryStore) {
+	store := storage.NewMemoryStore()
+	store.Clients["foo"] = &DefaultClient{
+		ID:           "foo",
+		Public:       true,
+		RedirectURIs: []string{"https://foo.com/callback"},
+		Scopes:       []string{"fosite"},
+	}
+	store.Clients["bar"] = &DefaultClient{
+		ID:           "bar",
+		Public:       true,
+		RedirectURIs: []string{"https://bar.com/callback"},
+		Scopes:       []string{"fosite"},
+	}
+	store.Clients["qux"] = &DefaultOpenIDConnectClient{
+		DefaultClient: &DefaultClient{
+			ID:           "qux",
+			Public:       true,
+			RedirectURIs: []string{"https://qux.com/callback"},
+			Scopes:       []string{"fosite"},
+		},
+		TokenEndpointAuthMethod:            "none",
+		RequirePushedAuthorizationRequests: true,
+	}
+
+	f := &Fosite{
+		Store:                    store,
+		ScopeStrategy:            ExactScopeStrategy,
+		AudienceMatchingStrategy: DefaultAudienceMatchingStrategy,
+		PushedAuthorizeEndpointHandlers: PushedAuthorizeEndpointHandlers{
+			&par.PushedAuthorizeHandler{Storage: store, RequestURILifespan: time.Minute},
+		},
+	}
+	return f, store
+}
+
+func pushAuthorizeRequest(t *testing.T, f *Fosite, form url.Values) string {
+	r := httptest.NewRequest(http.MethodPost, "/par", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	ar, err := f.NewPushedAuthorizeRequest(context.Background(), r)
+	require.NoError(t, err)
+
+	resp, err := f.NewPushedAuthorizeResponse(context.Background(), ar, new(DefaultSession))
+	require.NoError(t, err)
+
+	rw := httptest.NewRecorder()
+	f.WritePushedAuthorizeResponse(rw, ar, resp)
+	assert.Equal(t, http.StatusCreated, rw.Code)
+	assert.Contains(t, rw.Body.String(), `"expires_in":60`)
+	return resp.GetRequestURI()
+}
+
+func TestNewPushedAuthorizeRequest(t *testing.T) {
+	pushed := url.Values{
+		"client_id":     {"foo"},
+		"redirect_uri":  {"https://foo.com/callback"},
+		"response_type": {"code"},
+		"scope":         {"fosite"},
+		"state":         {"strong-state-value"},
+	}
+
+	for k, c := range []struct {
+		description string
+		method      string
+		form        url.Values
+		expectErr   error
+	}{
+		{description: "should fail because of the method", method: http.MethodGet, form: pushed, expectErr: ErrInvalidRequest},
+		{description: "should fail because of the empty body", method: http.MethodPost, form: url.Values{}, expectErr: ErrInvalidRequest},
+		{description: "should fail because a request_uri is pushed", method: http.MethodPost, form: url.Values{"client_id": {"foo"}, "request_uri": {"https://foo.com/request"}}, expectErr: ErrInvalidRequest},
+		{description: "should fail because the client is unknown", method: http.MethodPost, form: url.Values{"client_id": {"baz"}}, expectErr: ErrInvalidClient},
+		{description: "should fail because the request is invalid", method: http.MethodPost, form: url.Values{"client_id": {"foo"}, "redirect_uri": {"https://evil.com/callback"}}, expectErr: ErrInvalidRequest},
+		{description: "should pass", method: http.MethodPost, form: pushed},
+	} {
+		t.Run(fmt.Sprintf("case=%d/description=%s", k, c.description), func(t *testing.T) {
+			f, _ := newPARProvider()
+			r := httptest.NewRequest(c.method, "/par", strings.NewReader(c.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			ar, err := f.NewPushedAuthorizeRequest(context.Background(), r)
+			if c.expectErr != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, c.expectErr), "%+v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "foo", ar.GetClient().GetID())
+			assert.Equal(t, "https://foo.com/callback", ar.GetRedirectURI().String())
+		})
+	}
+}
+
+func TestAuthorizeRequestWithPushedAuthorizeRequest(t *testing.T) {
+	pushed := url.Values{
+		"client_id":     {"foo"},
+		"redirect_uri":  {"https://foo.com/callback"},
+		"response_type": {"code"},
+		"scope":         {"fosite"},
+		"state":         {"strong-state-value"},
+	}
+
+	authorize := func(f *Fosite, form url.Values) (AuthorizeRequester, error) {
+		r := httptest.NewRequest(http.MethodGet, "/auth?"+form.Encode(), nil)
+		return f.NewAuthorizeRequest(context.Background(), r)
+	}
+
+	t.Run("case=uses the pushed parameters once only", func(t *testing.T) {
+		f, _ := newPARProvider()
+		requestURI := pushAuthorizeRequest(t, f, pushed)
+
+		ar, err := authorize(f, url.Values{"client_id": {"foo"}, "request_uri": {requestURI}})
+		require.NoError(t, err)
+		assert.Equal(t, "https://foo.com/callback", ar.GetRedirectURI().String())
+		assert.True(t, ar.IsRedirectURIValid())
+		assert.Equal(t, Arguments{"code"}, ar.GetResponseTypes())
+		assert.Equal(t, Arguments{"fosite"}, ar.GetRequestedScopes())
+		assert.Equal(t, "strong-state-value", ar.GetState())
+		assert.Equal(t, "strong-state-value", ar.GetRequestForm().Get("state"))
+		assert.Equal(t, requestURI, ar.GetRequestForm().Get("request_uri"))
+
+		_, err = authorize(f, url.Values{"client_id": {"foo"}, "request_uri": {requestURI}})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidRequestURI))
+	})
+
+	t.Run("case=ignores front-channel parameters other than client_id and request_uri", func(t *testing.T) {
+		f, _ := newPARProvider()
+		requestURI := pushAuthorizeRequest(t, f, pushed)
+
+		ar, err := authorize(f, url.Values{
+			"client_id":   {"foo"},
+			"request_uri": {requestURI},
+			"nonce":       {"injected-nonce-value"},
+			"prompt":      {"none"},
+			"state":       {"injected-state-value"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "strong-state-value", ar.GetRequestForm().Get("state"))
+		assert.Empty(t, ar.GetRequestForm().Get("nonce"))
+		assert.Empty(t, ar.GetRequestForm().Get("prompt"))
+		assert.Equal(t, "foo", ar.GetRequestForm().Get("client_id"))
+	})
+
+	t.Run("case=uses the pushed login_hint without validating it again", func(t *testing.T) {
+		f, _ := newPARProvider()
+		var validated int
+		f.LoginHintValidator = func(_ context.Context, loginHint string, _ AuthorizeRequester) error {
+			validated++
+			return nil
+		}
+
+		form := url.Values{"login_hint": {"peter@example.com"}}
+		for k, v := range pushed {
+			form[k] = v
+		}
+		requestURI := pushAuthorizeRequest(t, f, form)
+		assert.Equal(t, 1, validated)
+
+		ar, err := authorize(f, url.Values{"client_id": {"foo"}, "request_uri": {requestURI}})
+		require.NoError(t, err)
+		assert.Equal(t, "peter@example.com", GetLoginHint(ar))
+		assert.Equal(t, 1, validated)
+	})
+
+	t.Run("case=uses the pushed ui_locales, claims_locales and display", func(t *testing.T) {
+		f, _ := newPARProvider()
+		form := url.Values{"ui_locales": {"de-CH fr"}, "claims_locales": {"en"}, "display": {"popup"}}
+		for k, v := range pushed {
+			form[k] = v
+		}
+		requestURI := pushAuthorizeRequest(t, f, form)
+
+		ar, err := authorize(f, url.Values{"client_id": {"foo"}, "request_uri": {requestURI}})
+		require.NoError(t, err)
+		ui, ok := ar.(AuthorizeUIRequester)
+		require.True(t, ok)
+		assert.Equal(t, Arguments{"de-CH", "fr"}, ui.GetUILocales())
+		assert.Equal(t, Arguments{"en"}, ui.GetClaimsLocales())
+		assert.Equal(t, DisplayPopup, ui.GetDisplay())
+	})
+
+	t.Run("case=does not store or forward the client authentication parameters", func(t *testing.T) {
+		f, store := newPARProvider()
+		f.Hasher = &BCrypt{WorkFactor: 6}
+		secret, err := f.Hasher.Hash(context.Background(), []byte("foobar"))
+		require.NoError(t, err)
+		store.Clients["confidential"] = &DefaultOpenIDConnectClient{
+			DefaultClient: &DefaultClient{
+				ID:           "confidential",
+				Secret:       secret,
+				RedirectURIs: []string{"https://confidential.com/callback"},
+				Scopes:       []string{"fosite"},
+			},
+			TokenEndpointAuthMethod: "client_secret_post",
+		}
+
+		requestURI := pushAuthorizeRequest(t, f, url.Values{
+			"client_id":     {"confidential"},
+			"client_secret": {"foobar"},
+			"redirect_uri":  {"https://confidential.com/callback"},
+			"response_type": {"code"},
+			"scope":         {"fosite"},
+			"state":         {"strong-state-value"},
+		})
+		assert.Empty(t, store.PARSessions[requestURI].GetRequestForm().Get("client_secret"))
+
+		ar, err := authorize(f, url.Values{"client_id": {"confidential"}, "request_uri": {requestURI}})
+		require.NoError(t, err)
+		assert.NotContains(t, ar.GetRequestForm(), "client_secret")
+		assert.Equal(t, "strong-state-value", ar.GetRequestForm().Get("state"))
+	})
+
+	t.Run("case=fails because the request_uri is unknown", func(t *testing.T) {
+		f, _ := newPARProvider()
+		_, err := authorize(f, url.Values{"client_id": {"foo"}, "request_uri": {PushedAuthorizeRequestURIPrefix + "unknown"}})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidRequestURI))
+	})
+
+	t.Run("case=fails because the request_uri has expired", func(t *testing.T) {
+		f, store := newPARProvider()
+		requestURI := pushAuthorizeRequest(t, f, pushed)
+		store.PARSessions[requestURI].(*AuthorizeRequest).RequestedAt = time.Now().UTC().Add(-time.Minute * 2)
+
+		_, err := authorize(f, url.Values{"client_id": {"foo"}, "request_uri": {requestURI}})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidRequestURI))
+		assert.Empty(t, store.PARSessions)
+	})
+
+	t.Run("case=fails because pushed authorization requests are required", func(t *testing.T) {
+		f, _ := newPARProvider()
+		f.RequirePushedAuthorizationRequests = true
+
+		_, err := authorize(f, pushed)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidRequest))
+
+		requestURI := pushAuthorizeRequest(t, f, pushed)
+		_, err = authorize(f, url.Values{"client_id": {"foo"}, "request_uri": {requestURI}})
+		require.NoError(t, err)
+	})
+
+	t.Run("case=enforces pushed authorization requests for clients requiring them", func(t *testing.T) {
+		f, _ := newPARProvider()
+		qux := url.Values{
+			"client_id":     {"qux"},
+			"redirect_uri":  {"https://qux.com/callback"},
+			"response_type": {"code"},
+			"scope":         {"fosite"},
+			"state":         {"strong-state-value"},
+		}
+
+		_, err := authorize(f, qux)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidRequest))
+		assert.Contains(t, ErrorToRFC6749Error(err).HintField, "Pushed Authorization Requests")
+
+		requestURI := pushAuthorizeRequest(t, f, qux)
+		ar, err := authorize(f, url.Values{"client_id": {"qux"}, "request_uri": {requestURI}})
+		require.NoError(t, err)
+		assert.Equal(t, "https://qux.com/callback", ar.GetRedirectURI().String())
+
+		// Clients which do not require pushed authorization requests may still use the authorization endpoint directly.
+		_, err = authorize(f, pushed)
+		require.NoError(t, err)
+	})
+
+	t.Run("case=fails because the request_uri was pushed by another client", func(t *testing.T) {
+		f, _ := newPARProvider()
+		requestURI := pushAuthorizeRequest(t, f, pushed)
+
+		_, err := authorize(f, url.Values{"client_id": {"bar"}, "request_uri": {requestURI}})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrInvalidRequestURI))
+
+		// The request_uri survives the attempt of the other client.
+		ar, err := authorize(f, url.Values{"client_id": {"foo"}, "request_uri": {requestURI}})
+		require.NoError(t, err)
+		assert.Equal(t, "https://foo.com/callback", ar.GetRedirectURI().String())
+	})
+}
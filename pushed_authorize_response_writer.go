@@ -0,0 +1,74 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ory/x/errorsx"
+)
+
+func (f *Fosite) NewPushedAuthorizeResponse(ctx context.Context, requester AuthorizeRequester, session Session) (PushedAuthorizeResponder, error) {
+	var resp = NewPushedAuthorizeResponse()
+
+	ctx = context.WithValue(ctx, AuthorizeRequestContextKey, requester)
+	ctx = context.WithValue(ctx, PushedAuthorizeResponseContextKey, resp)
+
+	requester.SetSession(session)
+	for _, h := range f.PushedAuthorizeEndpointHandlers {
+		if err := f.withStorageTimeout(ctx, func(ctx context.Context) error {
+			return h.HandlePushedAuthorizeEndpointRequest(ctx, requester, resp)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.GetRequestURI() == "" {
+		return nil, errorsx.WithStack(ErrServerError.WithHint("An internal server occurred while trying to complete the request.").WithDebug("Request URI not set by PushedAuthorizeEndpointHandlers."))
+	}
+
+	return resp, nil
+}
+
+// WritePushedAuthorizeResponse writes the pushed authorization response with status code 201 as defined in
+// https://tools.ietf.org/html/rfc9126#section-2.2
+func (f *Fosite) WritePushedAuthorizeResponse(rw http.ResponseWriter, _ AuthorizeRequester, responder PushedAuthorizeResponder) {
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+
+	js, err := json.Marshal(responder.ToMap())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
+
+	rw.WriteHeader(http.StatusCreated)
+	_, _ = rw.Write(js)
+}
+
+func (f *Fosite) WritePushedAuthorizeError(rw http.ResponseWriter, _ AuthorizeRequester, err error) {
+	f.writeJsonError(rw, err)
+}
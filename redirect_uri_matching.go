@@ -0,0 +1,199 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ory/x/errorsx"
+)
+
+// RedirectURIMatchingStrategy matches a requested redirect URI against the redirect URIs registered by a client. It
+// returns the redirect URI the user agent should be redirected to.
+type RedirectURIMatchingStrategy func(requested string, registered []string) (string, bool)
+
+// RedirectURIMatchingStrategyClient is a client which overrides the globally configured RedirectURIMatchingStrategy.
+type RedirectURIMatchingStrategyClient interface {
+	// GetRedirectURIMatchingStrategy returns the strategy used to match the redirect URIs of this client, or nil to
+	// use the global strategy.
+	GetRedirectURIMatchingStrategy() RedirectURIMatchingStrategy
+}
+
+// ExactRedirectURIMatchingStrategy matches redirect URIs using simple string comparison as required by
+// https://tools.ietf.org/html/rfc6749#section-3.1.2.3
+func ExactRedirectURIMatchingStrategy(requested string, registered []string) (string, bool) {
+	for _, r := range registered {
+		if r == requested {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+// LoopbackIPFlexibleRedirectURIMatchingStrategy matches redirect URIs using simple string comparison, but allows
+// native apps to use any port on a registered loopback IP redirect URI as defined in
+// https://tools.ietf.org/html/rfc8252#section-7.3
+func LoopbackIPFlexibleRedirectURIMatchingStrategy(requested string, registered []string) (string, bool) {
+	return isMatchingRedirectURI(requested, registered)
+}
+
+// QueryPreservingRedirectURIMatchingStrategy matches a requested redirect URI if its scheme, host and path equal the
+// ones of a registered redirect URI, and its query contains all query parameters of the registered redirect URI.
+// Additional query parameters of the requested redirect URI are preserved.
+func QueryPreservingRedirectURIMatchingStrategy(requested string, registered []string) (string, bool) {
+	req, err := url.Parse(requested)
+	if err != nil {
+		return "", false
+	}
+
+	for _, r := range registered {
+		reg, err := url.Parse(r)
+		if err != nil {
+			continue
+		}
+
+		if reg.Scheme == req.Scheme && reg.Host == req.Host && reg.Path == req.Path && containsQuery(req.Query(), reg.Query()) {
+			return requested, true
+		}
+	}
+	return "", false
+}
+
+// UnsafeWildcardHostRedirectURIMatchingStrategy is like ExactRedirectURIMatchingStrategy, but allows registered
+// redirect URIs to use a wildcard as the leftmost label of their host, for example https://*.example.com/callback.
+// The wildcard matches exactly one label. Any host matching the wildcard will receive authorization codes and tokens,
+// which is why this strategy must be chosen explicitly and should only be used with domains fully under your control.
+func UnsafeWildcardHostRedirectURIMatchingStrategy(requested string, registered []string) (string, bool) {
+	req, err := url.Parse(requested)
+	if err != nil {
+		return "", false
+	}
+
+	for _, r := range registered {
+		if r == requested {
+			return r, true
+		}
+
+		reg, err := url.Parse(r)
+		if err != nil || !strings.HasPrefix(reg.Hostname(), "*.") {
+			continue
+		}
+
+		label, domain := splitHostLabel(req.Hostname())
+		if label != "" && label != "*" && "*."+domain == reg.Hostname() &&
+			reg.Scheme == req.Scheme &&
+			reg.Port() == req.Port() &&
+			reg.Path == req.Path &&
+			reg.RawQuery == req.RawQuery {
+			return requested, true
+		}
+	}
+	return "", false
+}
+
+// withLoopbackDynamicPort extends strategy to match loopback redirect URIs regardless of their port. The scheme, host,
+// path and query must still match exactly.
+//
+// https://tools.ietf.org/html/rfc8252#section-7.3
+// The authorization server MUST allow any port to be specified at the
+// time of the request for loopback IP redirect URIs, to accommodate
+// clients that obtain an available ephemeral port from the operating
+// system at the time of the request.
+func withLoopbackDynamicPort(strategy RedirectURIMatchingStrategy) RedirectURIMatchingStrategy {
+	return func(requested string, registered []string) (string, bool) {
+		if redirectTo, ok := strategy(requested, registered); ok {
+			return redirectTo, true
+		}
+
+		req, err := url.Parse(requested)
+		if err != nil || req.Scheme != "http" || !isLoopbackHost(req.Hostname()) {
+			return "", false
+		}
+
+		for _, r := range registered {
+			reg, err := url.Parse(r)
+			if err != nil {
+				continue
+			}
+
+			if reg.Scheme == req.Scheme &&
+				reg.Hostname() == req.Hostname() &&
+				reg.Path == req.Path &&
+				reg.RawQuery == req.RawQuery {
+				return requested, true
+			}
+		}
+		return "", false
+	}
+}
+
+func isLoopbackHost(hostname string) bool {
+	return hostname == "127.0.0.1" || hostname == "::1" || hostname == "localhost"
+}
+
+func containsQuery(haystack, needle url.Values) bool {
+	for k, values := range needle {
+		if len(haystack[k]) != len(values) {
+			return false
+		}
+		for i, v := range values {
+			if haystack[k][i] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func splitHostLabel(host string) (string, string) {
+	parts := strings.SplitN(host, ".", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+func matchRedirectURIWithClientRedirectURIs(rawurl string, client Client, strategy RedirectURIMatchingStrategy) (*url.URL, error) {
+	if c, ok := client.(RedirectURIMatchingStrategyClient); ok && c.GetRedirectURIMatchingStrategy() != nil {
+		strategy = c.GetRedirectURIMatchingStrategy()
+	}
+	if strategy == nil {
+		strategy = LoopbackIPFlexibleRedirectURIMatchingStrategy
+	}
+	if c, ok := client.(NativeAppClient); ok && c.GetAllowLoopbackRedirectWithDynamicPort() {
+		strategy = withLoopbackDynamicPort(strategy)
+	}
+
+	if rawurl == "" && len(client.GetRedirectURIs()) == 1 {
+		if redirectURIFromClient, err := url.Parse(client.GetRedirectURIs()[0]); err == nil && IsValidRedirectURI(redirectURIFromClient) {
+			// If no redirect_uri was given and the client has exactly one valid redirect_uri registered, use that instead
+			return redirectURIFromClient, nil
+		}
+	} else if redirectTo, ok := strategy(rawurl, client.GetRedirectURIs()); rawurl != "" && ok {
+		if parsed, err := url.Parse(redirectTo); err == nil && IsValidRedirectURI(parsed) {
+			return parsed, nil
+		}
+	}
+
+	return nil, errorsx.WithStack(ErrInvalidRequest.WithHint("The 'redirect_uri' parameter does not match any of the OAuth 2.0 Client's pre-registered redirect urls."))
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+type redirectURIMatchingClient struct {
+	*DefaultClient
+	strategy RedirectURIMatchingStrategy
+}
+
+func (c *redirectURIMatchingClient) GetRedirectURIMatchingStrategy() RedirectURIMatchingStrategy {
+	return c.strategy
+}
+
+func TestRedirectURIMatchingStrategies(t *testing.T) {
+	for k, c := range []struct {
+		strategy   RedirectURIMatchingStrategy
+		registered []string
+		requested  string
+		expected   string
+		match      bool
+	}{
+		{strategy: ExactRedirectURIMatchingStrategy, registered: []string{"https://foo.com/cb"}, requested: "https://foo.com/cb", expected: "https://foo.com/cb", match: true},
+		{strategy: ExactRedirectURIMatchingStrategy, registered: []string{"https://foo.com/cb"}, requested: "https://foo.com/cb?a=b"},
+		{strategy: ExactRedirectURIMatchingStrategy, registered: []string{"http://127.0.0.1/cb"}, requested: "http://127.0.0.1:4000/cb"},
+		{strategy: LoopbackIPFlexibleRedirectURIMatchingStrategy, registered: []string{"http://127.0.0.1/cb"}, requested: "http://127.0.0.1:4000/cb", expected: "http://127.0.0.1:4000/cb", match: true},
+		{strategy: LoopbackIPFlexibleRedirectURIMatchingStrategy, registered: []string{"http://127.0.0.1:3000/cb"}, requested: "http://127.0.0.1:4000/cb", expected: "http://127.0.0.1:4000/cb", match: true},
+		{strategy: LoopbackIPFlexibleRedirectURIMatchingStrategy, registered: []string{"http://[::1]/cb"}, requested: "http://[::1]:4000/cb", expected: "http://[::1]:4000/cb", match: true},
+		{strategy: LoopbackIPFlexibleRedirectURIMatchingStrategy, registered: []string{"http://127.0.0.1/cb"}, requested: "http://127.0.0.1:4000/other"},
+		{strategy: LoopbackIPFlexibleRedirectURIMatchingStrategy, registered: []string{"http://foo.com/cb"}, requested: "http://foo.com:4000/cb"},
+		{strategy: QueryPreservingRedirectURIMatchingStrategy, registered: []string{"https://foo.com/cb?tenant=a"}, requested: "https://foo.com/cb?tenant=a&lang=en", expected: "https://foo.com/cb?tenant=a&lang=en", match: true},
+		{strategy: QueryPreservingRedirectURIMatchingStrategy, registered: []string{"https://foo.com/cb"}, requested: "https://foo.com/cb?lang=en", expected: "https://foo.com/cb?lang=en", match: true},
+		{strategy: QueryPreservingRedirectURIMatchingStrategy, registered: []string{"https://foo.com/cb?tenant=a"}, requested: "https://foo.com/cb?tenant=b"},
+		{strategy: QueryPreservingRedirectURIMatchingStrategy, registered: []string{"https://foo.com/cb"}, requested: "https://bar.com/cb?lang=en"},
+		{strategy: UnsafeWildcardHostRedirectURIMatchingStrategy, registered: []string{"https://*.foo.com/cb"}, requested: "https://tenant.foo.com/cb", expected: "https://tenant.foo.com/cb", match: true},
+		{strategy: UnsafeWildcardHostRedirectURIMatchingStrategy, registered: []string{"https://*.foo.com/cb"}, requested: "https://a.tenant.foo.com/cb"},
+		{strategy: UnsafeWildcardHostRedirectURIMatchingStrategy, registered: []string{"https://*.foo.com/cb"}, requested: "https://foo.com/cb"},
+		{strategy: UnsafeWildcardHostRedirectURIMatchingStrategy, registered: []string{"https://*.foo.com/cb"}, requested: "http://tenant.foo.com/cb"},
+		{strategy: UnsafeWildcardHostRedirectURIMatchingStrategy, registered: []string{"https://*.foo.com/cb"}, requested: "https://tenant.evil.com/cb"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			redirectTo, ok := c.strategy(c.requested, c.registered)
+			assert.Equal(t, c.match, ok)
+			assert.Equal(t, c.expected, redirectTo)
+		})
+	}
+}
+
+func TestNewAuthorizeRequestWithRedirectURIMatchingStrategy(t *testing.T) {
+	registered := []string{"http://127.0.0.1/callback", "http://[::1]/callback", "http://localhost/callback", "http://foo.com/callback"}
+	native := &DefaultClient{RedirectURIs: registered, AllowLoopbackRedirectWithDynamicPort: true}
+	for k, c := range []struct {
+		global      RedirectURIMatchingStrategy
+		client      Client
+		redirectURI string
+		expectErr   bool
+	}{
+		{client: &DefaultClient{RedirectURIs: registered}, redirectURI: "http://127.0.0.1:52314/callback"},
+		{client: &DefaultClient{RedirectURIs: registered}, redirectURI: "http://127.0.0.1/callback"},
+		{global: ExactRedirectURIMatchingStrategy, client: &DefaultClient{RedirectURIs: registered}, redirectURI: "http://127.0.0.1:52314/callback", expectErr: true},
+		{global: ExactRedirectURIMatchingStrategy, client: &DefaultClient{RedirectURIs: registered}, redirectURI: "http://127.0.0.1/callback"},
+		{global: LoopbackIPFlexibleRedirectURIMatchingStrategy, client: &DefaultClient{RedirectURIs: registered}, redirectURI: "http://127.0.0.1:52314/callback"},
+		{global: LoopbackIPFlexibleRedirectURIMatchingStrategy, client: &DefaultClient{RedirectURIs: registered}, redirectURI: "http://[::1]:52314/callback"},
+		{client: &redirectURIMatchingClient{DefaultClient: &DefaultClient{RedirectURIs: registered}, strategy: LoopbackIPFlexibleRedirectURIMatchingStrategy}, redirectURI: "http://[::1]:52314/callback"},
+		{global: LoopbackIPFlexibleRedirectURIMatchingStrategy, client: &redirectURIMatchingClient{DefaultClient: &DefaultClient{RedirectURIs: registered}, strategy: ExactRedirectURIMatchingStrategy}, redirectURI: "http://127.0.0.1:52314/callback", expectErr: true},
+		{client: native, redirectURI: "http://127.0.0.1:52314/callback"},
+		{client: native, redirectURI: "http://[::1]:52314/callback"},
+		{client: native, redirectURI: "http://localhost:52314/callback"},
+		{client: native, redirectURI: "http://127.0.0.1/callback"},
+		{client: native, redirectURI: "http://127.0.0.1:52314/other", expectErr: true},
+		{client: native, redirectURI: "https://127.0.0.1:52314/callback", expectErr: true},
+		{client: native, redirectURI: "http://[::1]:52314/callback?foo=bar", expectErr: true},
+		{client: native, redirectURI: "http://foo.com:52314/callback", expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			store.Clients["foo"] = c.client
+			f := &Fosite{Store: store, RedirectURIMatchingStrategy: c.global, ScopeStrategy: ExactScopeStrategy, AudienceMatchingStrategy: DefaultAudienceMatchingStrategy}
+
+			r := httptest.NewRequest(http.MethodGet, "/auth?"+url.Values{
+				"client_id":     {"foo"},
+				"redirect_uri":  {c.redirectURI},
+				"response_type": {"code"},
+				"state":         {"strong-state-value"},
+			}.Encode(), nil)
+
+			ar, err := f.NewAuthorizeRequest(context.Background(), r)
+			if c.expectErr {
+				require.Error(t, err)
+				assert.False(t, ar.IsRedirectURIValid())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.redirectURI, ar.GetRedirectURI().String())
+			assert.True(t, ar.IsRedirectURIValid())
+		})
+	}
+}
+
+func TestFositeMatchRedirectURIWithClientRedirectURIs(t *testing.T) {
+	client := &DefaultClient{RedirectURIs: []string{"http://127.0.0.1/callback"}}
+
+	_, err := MatchRedirectURIWithClientRedirectURIs("http://127.0.0.1:52314/callback", client)
+	require.NoError(t, err)
+
+	_, err = (&Fosite{}).MatchRedirectURIWithClientRedirectURIs("http://127.0.0.1:52314/callback", client)
+	require.NoError(t, err)
+
+	_, err = (&Fosite{RedirectURIMatchingStrategy: ExactRedirectURIMatchingStrategy}).MatchRedirectURIWithClientRedirectURIs("http://127.0.0.1:52314/callback", client)
+	require.Error(t, err)
+}
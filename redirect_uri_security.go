@@ -0,0 +1,82 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/url"
+
+	"github.com/ory/x/errorsx"
+)
+
+// DefaultHTTPRedirectURIHosts are the loopback hosts native apps may use with plain HTTP as per
+// https://tools.ietf.org/html/rfc8252#section-7.3
+var DefaultHTTPRedirectURIHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// RedirectURISecurityPolicy requires redirect URIs to use HTTPS. Plain HTTP is only accepted for the hosts in
+// HTTPHosts, and other schemes, such as the private-use URI schemes of native apps, are rejected.
+type RedirectURISecurityPolicy struct {
+	// HTTPHosts are the hosts which may be used with plain HTTP. Defaults to DefaultHTTPRedirectURIHosts.
+	HTTPHosts []string
+
+	// AllowInsecure accepts all redirect URIs. It must only be set for development.
+	AllowInsecure bool
+}
+
+// IsSecure returns true if the redirect URI is allowed by the policy.
+func (p *RedirectURISecurityPolicy) IsSecure(redirectURI *url.URL) bool {
+	if p.AllowInsecure {
+		return true
+	}
+
+	switch redirectURI.Scheme {
+	case "https":
+		return true
+	case "http":
+		hosts := p.HTTPHosts
+		if hosts == nil {
+			hosts = DefaultHTTPRedirectURIHosts
+		}
+		return StringInSlice(redirectURI.Hostname(), hosts)
+	}
+	return false
+}
+
+// ValidateClient returns an error if a redirect URI registered for the client is not allowed by the policy.
+func (p *RedirectURISecurityPolicy) ValidateClient(client Client) error {
+	for _, raw := range client.GetRedirectURIs() {
+		redirectURI, err := url.Parse(raw)
+		if err != nil {
+			return errorsx.WithStack(ErrInvalidRequest.WithHintf("The OAuth 2.0 Client redirect URI '%s' is not a valid URL.", raw).WithWrap(err).WithDebug(err.Error()))
+		} else if !p.IsSecure(redirectURI) {
+			return errorsx.WithStack(ErrInvalidRequest.WithHintf("The OAuth 2.0 Client redirect URI '%s' must use HTTPS, or HTTP for a loopback host.", raw))
+		}
+	}
+
+	return nil
+}
+
+// ValidateRedirectURISecurity returns an error if a redirect URI registered for the client does not use HTTPS, or
+// HTTP for a loopback host. Call it when registering clients to reject insecure redirect URIs early. Use
+// RedirectURISecurityPolicy.ValidateClient to validate it against a custom policy.
+func ValidateRedirectURISecurity(client Client) error {
+	return new(RedirectURISecurityPolicy).ValidateClient(client)
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestRedirectURISecurityPolicy(t *testing.T) {
+	for k, c := range []struct {
+		policy      *RedirectURISecurityPolicy
+		redirectURI string
+		expectErr   bool
+	}{
+		{policy: &RedirectURISecurityPolicy{}, redirectURI: "https://foo.com/cb"},
+		{policy: &RedirectURISecurityPolicy{}, redirectURI: "http://127.0.0.1:4000/cb"},
+		{policy: &RedirectURISecurityPolicy{}, redirectURI: "http://[::1]/cb"},
+		{policy: &RedirectURISecurityPolicy{}, redirectURI: "http://localhost/cb"},
+		{policy: &RedirectURISecurityPolicy{}, redirectURI: "http://foo.com/cb", expectErr: true},
+		{policy: &RedirectURISecurityPolicy{}, redirectURI: "http://app.localhost/cb", expectErr: true},
+		{policy: &RedirectURISecurityPolicy{}, redirectURI: "com.example.app:/cb", expectErr: true},
+		{policy: &RedirectURISecurityPolicy{HTTPHosts: []string{"app.localhost"}}, redirectURI: "http://app.localhost/cb"},
+		{policy: &RedirectURISecurityPolicy{HTTPHosts: []string{"app.localhost"}}, redirectURI: "http://127.0.0.1/cb", expectErr: true},
+		{policy: &RedirectURISecurityPolicy{AllowInsecure: true}, redirectURI: "http://foo.com/cb"},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			redirectURI, err := url.Parse(c.redirectURI)
+			require.NoError(t, err)
+			assert.Equal(t, !c.expectErr, c.policy.IsSecure(redirectURI))
+
+			err = c.policy.ValidateClient(&DefaultClient{RedirectURIs: []string{"https://foo.com/cb", c.redirectURI}})
+			if c.expectErr {
+				assert.EqualError(t, err, ErrInvalidRequest.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateRedirectURISecurity(t *testing.T) {
+	assert.NoError(t, ValidateRedirectURISecurity(&DefaultClient{RedirectURIs: []string{"https://foo.com/cb", "http://127.0.0.1/cb"}}))
+	assert.EqualError(t, ValidateRedirectURISecurity(&DefaultClient{RedirectURIs: []string{"http://foo.com/cb"}}), ErrInvalidRequest.Error())
+}
+
+func TestNewAuthorizeRequestWithRedirectURISecurityPolicy(t *testing.T) {
+	for k, c := range []struct {
+		redirectURI string
+		expectErr   bool
+	}{
+		{redirectURI: "https://foo.com/cb"},
+		{redirectURI: "http://127.0.0.1/cb"},
+		{redirectURI: "http://foo.com/cb", expectErr: true},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			store := storage.NewMemoryStore()
+			store.Clients["foo"] = &DefaultClient{ID: "foo", RedirectURIs: []string{c.redirectURI}}
+			f := &Fosite{
+				Store:                     store,
+				ScopeStrategy:             ExactScopeStrategy,
+				AudienceMatchingStrategy:  DefaultAudienceMatchingStrategy,
+				RedirectURISecurityPolicy: &RedirectURISecurityPolicy{},
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/auth?"+url.Values{
+				"client_id":     {"foo"},
+				"redirect_uri":  {c.redirectURI},
+				"response_type": {"code"},
+				"state":         {"strong-state-value"},
+			}.Encode(), nil)
+
+			ar, err := f.NewAuthorizeRequest(context.Background(), r)
+			if c.expectErr {
+				require.EqualError(t, err, ErrInvalidRequest.Error())
+				assert.Nil(t, ar.GetRedirectURI())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.redirectURI, ar.GetRedirectURI().String())
+		})
+	}
+}
@@ -39,6 +39,9 @@ type Request struct {
 	Session           Session    `json:"session" gorethink:"session"`
 	RequestedAudience Arguments  `json:"requestedAudience"`
 	GrantedAudience   Arguments  `json:"grantedAudience"`
+
+	AuthorizationDetails []AuthorizationDetail `json:"authorizationDetails,omitempty"`
+	RequestContext       RequestContext        `json:"requestContext,omitempty"`
 }
 
 func NewRequest() *Request {
@@ -142,6 +145,22 @@ func (a *Request) GrantScope(scope string) {
 	a.GrantedScope = append(a.GrantedScope, scope)
 }
 
+func (a *Request) GetAuthorizationDetails() []AuthorizationDetail {
+	return a.AuthorizationDetails
+}
+
+func (a *Request) SetAuthorizationDetails(details []AuthorizationDetail) {
+	a.AuthorizationDetails = details
+}
+
+func (a *Request) GetRequestContext() RequestContext {
+	return a.RequestContext
+}
+
+func (a *Request) SetRequestContext(rc RequestContext) {
+	a.RequestContext = rc
+}
+
 func (a *Request) SetSession(session Session) {
 	a.Session = session
 }
@@ -170,6 +189,14 @@ func (a *Request) Merge(request Requester) {
 	a.Client = request.GetClient()
 	a.Session = request.GetSession()
 
+	if r, ok := request.(AuthorizationDetailsRequester); ok && len(r.GetAuthorizationDetails()) > 0 {
+		a.AuthorizationDetails = r.GetAuthorizationDetails()
+	}
+
+	if r, ok := request.(RequestContextRequester); ok && len(r.GetRequestContext()) > 0 {
+		a.RequestContext = r.GetRequestContext()
+	}
+
 	for k, v := range request.GetRequestForm() {
 		a.Form[k] = v
 	}
@@ -0,0 +1,91 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ory/x/errorsx"
+)
+
+// TenantRequestContextKey is the key of the tenant in the RequestContext. Fosite keeps it in sync with the tenant
+// added to the context with WithTenant, so that tokens are bound to the tenant of the client they were issued to.
+const TenantRequestContextKey = "tenant"
+
+// RequestContext holds request-scoped metadata, such as a tenant or trace identifier. It is carried by the request
+// through all handlers and persisted with the request by storage implementations, for example for auditing.
+type RequestContext map[string]string
+
+// Get returns the value of key, or an empty string if the key is not set.
+func (c RequestContext) Get(key string) string {
+	return c[key]
+}
+
+// RequestContextRequester is implemented by requests which carry a RequestContext.
+type RequestContextRequester interface {
+	// GetRequestContext returns the request context of the request.
+	GetRequestContext() RequestContext
+
+	// SetRequestContext sets the request context of the request.
+	SetRequestContext(rc RequestContext)
+}
+
+// RequestContextExtractor extracts the RequestContext from the incoming HTTP request. Errors abort the request.
+type RequestContextExtractor func(ctx context.Context, r *http.Request) (RequestContext, error)
+
+// GetRequestContext returns the request context of the requester, or nil if it carries none.
+func GetRequestContext(requester Requester) RequestContext {
+	if r, ok := requester.(RequestContextRequester); ok {
+		return r.GetRequestContext()
+	}
+	return nil
+}
+
+// GetTenant returns the tenant stored in the request context of the requester, or an empty string if it has none.
+func GetTenant(requester Requester) string {
+	return GetRequestContext(requester).Get(TenantRequestContextKey)
+}
+
+// extractRequestContext populates the request context of the request using RequestContextExtractor, if set. The
+// tenant of the request context is added to the returned context, which client lookups pass to
+// ClientManagerWithContext. A tenant added to the context with WithTenant takes precedence and is stored in the
+// request context instead.
+func (f *Fosite) extractRequestContext(ctx context.Context, r *http.Request, request *Request) (context.Context, error) {
+	if f.RequestContextExtractor != nil {
+		rc, err := f.RequestContextExtractor(ctx, r)
+		if err != nil {
+			return ctx, errorsx.WithStack(err)
+		}
+		request.RequestContext = rc
+	}
+
+	if tenant := TenantFromContext(ctx); tenant != "" {
+		if request.RequestContext == nil {
+			request.RequestContext = RequestContext{}
+		}
+		request.RequestContext[TenantRequestContextKey] = tenant
+	} else if tenant := request.RequestContext.Get(TenantRequestContextKey); tenant != "" {
+		ctx = WithTenant(ctx, tenant)
+	}
+	return ctx, nil
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+func TestRequestContextExtractor(t *testing.T) {
+	extractor := func(ctx context.Context, r *http.Request) (RequestContext, error) {
+		tenant := r.Header.Get("X-Tenant")
+		if tenant == "" {
+			return nil, ErrInvalidRequest.WithHint("The tenant is missing.")
+		}
+		return RequestContext{"tenant": tenant}, nil
+	}
+
+	for k, c := range []struct {
+		extractor RequestContextExtractor
+		tenant    string
+		expectErr error
+		expect    RequestContext
+	}{
+		{tenant: "acme"},
+		{extractor: extractor, tenant: "acme", expect: RequestContext{"tenant": "acme"}},
+		{extractor: extractor, expectErr: ErrInvalidRequest},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			f := &Fosite{Store: storage.NewExampleStore(), RequestContextExtractor: c.extractor}
+			r := httptest.NewRequest(http.MethodGet, "/auth?client_id=unknown", nil)
+			r.Header.Set("X-Tenant", c.tenant)
+
+			// The request fails after the request context is extracted because the client does not exist.
+			ar, err := f.NewAuthorizeRequest(context.Background(), r)
+			require.Error(t, err)
+			if c.expectErr != nil {
+				assert.True(t, errors.Is(err, c.expectErr))
+				assert.Contains(t, ErrorToRFC6749Error(err).HintField, "tenant")
+				return
+			}
+			assert.Equal(t, c.expect, GetRequestContext(ar))
+			assert.Equal(t, c.expect.Get("tenant"), GetRequestContext(ar).Get("tenant"))
+		})
+	}
+}
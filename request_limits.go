@@ -0,0 +1,64 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/ory/x/errorsx"
+)
+
+// DefaultMaxRequestBodySize is the default of Fosite.MaxRequestBodySize.
+const DefaultMaxRequestBodySize int64 = 1 << 20
+
+// DefaultMaxParameterLengths are the default limits of Fosite.MaxParameterLengths. They are generous enough for
+// legitimate requests, including request objects carrying rich authorization requests.
+var DefaultMaxParameterLengths = map[string]int{
+	"scope":        8 << 10,
+	"state":        8 << 10,
+	"redirect_uri": 8 << 10,
+	"request":      512 << 10,
+}
+
+// parseMultipartForm parses the form of the request whose body is limited to MaxRequestBodySize.
+func (f *Fosite) parseMultipartForm(r *http.Request) error {
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(nil, r.Body, f.GetMaxRequestBodySize())
+	}
+	return r.ParseMultipartForm(1 << 20)
+}
+
+// validateParameterLengths checks the parameters of the form against MaxParameterLengths.
+func (f *Fosite) validateParameterLengths(form url.Values) error {
+	for parameter, max := range f.GetMaxParameterLengths() {
+		if max <= 0 {
+			continue
+		}
+		for _, value := range form[parameter] {
+			if len(value) > max {
+				return errorsx.WithStack(ErrInvalidRequest.WithHintf("Request parameter '%s' is %d bytes long, but at most %d bytes are allowed.", parameter, len(value), max))
+			}
+		}
+	}
+	return nil
+}
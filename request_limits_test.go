@@ -0,0 +1,123 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+)
+
+func TestRequestParameterLimits(t *testing.T) {
+	for k, c := range []struct {
+		d         string
+		f         *Fosite
+		r         func() *http.Request
+		token     bool
+		expectErr error
+	}{
+		{
+			d: "should fail because the state of the authorize request is too long",
+			f: &Fosite{},
+			r: func() *http.Request {
+				return &http.Request{Method: "GET", URL: &url.URL{RawQuery: url.Values{"state": {strings.Repeat("a", 8<<10+1)}}.Encode()}}
+			},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d: "should fail because the request object of the authorize request is too long",
+			f: &Fosite{},
+			r: func() *http.Request {
+				form := url.Values{"request": {strings.Repeat("a", 512<<10+1)}}
+				r, _ := http.NewRequest("POST", "/auth", strings.NewReader(form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d: "should fail because the state exceeds the configured limit",
+			f: &Fosite{MaxParameterLengths: map[string]int{"state": 8}},
+			r: func() *http.Request {
+				return &http.Request{Method: "GET", URL: &url.URL{RawQuery: "state=123456789"}}
+			},
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d: "should pass the limits because the check of the state is disabled",
+			f: &Fosite{MaxParameterLengths: map[string]int{"state": 0}, Store: &limitsStore{}},
+			r: func() *http.Request {
+				return &http.Request{Method: "GET", URL: &url.URL{RawQuery: url.Values{"state": {strings.Repeat("a", 8<<10+1)}}.Encode()}}
+			},
+			expectErr: ErrInvalidClient,
+		},
+		{
+			d: "should fail because the body of the token request is too large",
+			f: &Fosite{MaxRequestBodySize: 64},
+			r: func() *http.Request {
+				form := url.Values{"grant_type": {"client_credentials"}, "scope": {strings.Repeat("a ", 64)}}
+				r, _ := http.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			},
+			token:     true,
+			expectErr: ErrInvalidRequest,
+		},
+		{
+			d: "should fail because the scope of the token request is too long",
+			f: &Fosite{MaxParameterLengths: map[string]int{"scope": 16}},
+			r: func() *http.Request {
+				form := url.Values{"grant_type": {"client_credentials"}, "scope": {strings.Repeat("a ", 16)}}
+				r, _ := http.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			},
+			token:     true,
+			expectErr: ErrInvalidRequest,
+		},
+	} {
+		t.Run(c.d, func(t *testing.T) {
+			var err error
+			if c.token {
+				_, err = c.f.NewAccessRequest(context.Background(), c.r(), new(DefaultSession))
+			} else {
+				_, err = c.f.NewAuthorizeRequest(context.Background(), c.r())
+			}
+			require.Error(t, err, "%d", k)
+			assert.True(t, errors.Is(err, c.expectErr), "%+v", err)
+		})
+	}
+}
+
+type limitsStore struct{ Storage }
+
+func (s *limitsStore) GetClient(_ context.Context, _ string) (Client, error) {
+	return nil, ErrNotFound
+}
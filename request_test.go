@@ -62,6 +62,7 @@ func TestMergeRequest(t *testing.T) {
 		GrantedAudience:   []string{"aud-1", "aud-2"},
 		Form:              url.Values{"foo": []string{"fasdf"}},
 		Session:           new(DefaultSession),
+		RequestContext:    RequestContext{"tenant": "acme"},
 	}
 	b := &Request{
 		RequestedAt:    time.Now().UTC(),
@@ -82,6 +83,7 @@ func TestMergeRequest(t *testing.T) {
 	assert.EqualValues(t, a.Form, b.Form)
 	assert.EqualValues(t, a.Session, b.Session)
 	assert.EqualValues(t, a.ID, b.ID)
+	assert.EqualValues(t, a.RequestContext, b.RequestContext)
 }
 
 func TestSanitizeRequest(t *testing.T) {
@@ -1,8 +1,15 @@
 package fosite
 
-import "net/http"
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/url"
+)
 
-// ResponseModeHandler provides a contract for handling custom response modes
+// ResponseModeHandler provides a contract for handling response modes. The built-in query, fragment and form_post
+// response modes are implemented by QueryResponseModeHandler, FragmentResponseModeHandler and
+// FormPostResponseModeHandler. Additional handlers can be registered using Fosite.ResponseModeHandlers.
 type ResponseModeHandler interface {
 	// ResponseModes returns a set of supported response modes handled
 	// by the interface implementation.
@@ -17,14 +24,15 @@ type ResponseModeHandler interface {
 	// Following headers are expected to be set by default:
 	// header.Set("Cache-Control", "no-store")
 	// header.Set("Pragma", "no-cache")
-	WriteAuthorizeResponse(rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder)
+	WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder)
 
-	// WriteAuthorizeError writes error responses
+	// WriteAuthorizeError writes error responses. It is only invoked if the redirect URI of the request is valid,
+	// otherwise the error is written as JSON.
 	//
 	// Following headers are expected to be set by default:
 	// header.Set("Cache-Control", "no-store")
 	// header.Set("Pragma", "no-cache")
-	WriteAuthorizeError(rw http.ResponseWriter, ar AuthorizeRequester, err error)
+	WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, err error)
 }
 
 type ResponseModeTypes []ResponseModeType
@@ -41,7 +49,115 @@ func (rs ResponseModeTypes) Has(item ResponseModeType) bool {
 type DefaultResponseModeHandler struct{}
 
 func (d *DefaultResponseModeHandler) ResponseModes() ResponseModeTypes { return nil }
-func (d *DefaultResponseModeHandler) WriteAuthorizeResponse(rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
+func (d *DefaultResponseModeHandler) WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
 }
-func (d *DefaultResponseModeHandler) WriteAuthorizeError(rw http.ResponseWriter, ar AuthorizeRequester, err error) {
+func (d *DefaultResponseModeHandler) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, err error) {
+}
+
+// QueryResponseModeHandler adds the authorization response parameters to the query of the redirect URI. It is also
+// used if no response mode was requested and the flow did not set a default response mode.
+type QueryResponseModeHandler struct{}
+
+func (h *QueryResponseModeHandler) ResponseModes() ResponseModeTypes {
+	return ResponseModeTypes{ResponseModeQuery, ResponseModeDefault}
+}
+
+func (h *QueryResponseModeHandler) WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
+	redir := ar.GetRedirectURI()
+	q := redir.Query()
+	params := resp.GetParameters()
+	for k := range params {
+		q.Set(k, params.Get(k))
+	}
+	redir.RawQuery = q.Encode()
+	sendRedirect(redir.String(), rw)
+}
+
+func (h *QueryResponseModeHandler) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, err error) {
+	redirectURI := ar.GetRedirectURI()
+
+	// The endpoint URI MUST NOT include a fragment component.
+	redirectURI.Fragment = ""
+
+	errors := authorizeErrorParameters(ar, err)
+	for key, values := range redirectURI.Query() {
+		for _, value := range values {
+			errors.Add(key, value)
+		}
+	}
+	redirectURI.RawQuery = errors.Encode()
+	sendRedirect(redirectURI.String(), rw)
+}
+
+// FragmentResponseModeHandler adds the authorization response parameters to the fragment of the redirect URI.
+type FragmentResponseModeHandler struct{}
+
+func (h *FragmentResponseModeHandler) ResponseModes() ResponseModeTypes {
+	return ResponseModeTypes{ResponseModeFragment}
+}
+
+func (h *FragmentResponseModeHandler) WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
+	redir := ar.GetRedirectURI()
+
+	// The endpoint URI MUST NOT include a fragment component.
+	redir.Fragment = ""
+	URLSetFragment(redir, resp.GetParameters())
+	sendRedirect(redir.String(), rw)
+}
+
+func (h *FragmentResponseModeHandler) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, err error) {
+	redirectURI := ar.GetRedirectURI()
+
+	// The endpoint URI MUST NOT include a fragment component.
+	redirectURI.Fragment = ""
+	sendRedirect(redirectURI.String()+"#"+authorizeErrorParameters(ar, err).Encode(), rw)
+}
+
+// FormPostResponseModeHandler renders the authorization response parameters as an HTML form which is automatically
+// posted to the redirect URI.
+type FormPostResponseModeHandler struct {
+	// Template renders the form. Defaults to fosite.FormPostDefaultTemplate.
+	Template *template.Template
+}
+
+func (h *FormPostResponseModeHandler) ResponseModes() ResponseModeTypes {
+	return ResponseModeTypes{ResponseModeFormPost}
+}
+
+func (h *FormPostResponseModeHandler) template() *template.Template {
+	if h.Template == nil {
+		return FormPostDefaultTemplate
+	}
+	return h.Template
+}
+
+func (h *FormPostResponseModeHandler) WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
+	rw.Header().Set("Content-Type", "text/html;charset=UTF-8")
+	WriteAuthorizeFormPostResponse(ar.GetRedirectURI().String(), resp.GetParameters(), h.template(), rw)
+}
+
+func (h *FormPostResponseModeHandler) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, err error) {
+	redirectURI := ar.GetRedirectURI()
+
+	// The endpoint URI MUST NOT include a fragment component.
+	redirectURI.Fragment = ""
+
+	rw.Header().Set("Content-Type", "text/html;charset=UTF-8")
+	WriteAuthorizeFormPostResponse(redirectURI.String(), authorizeErrorParameters(ar, err), h.template(), rw)
+}
+
+func authorizeErrorParameters(ar AuthorizeRequester, err error) url.Values {
+	errors := ErrorToRFC6749Error(err).ToValues()
+	errors.Set("state", ar.GetState())
+	return errors
+}
+
+// parametersResponder replaces the parameters of an authorization response.
+type parametersResponder struct {
+	AuthorizeResponder
+	parameters url.Values
+}
+
+func (r *parametersResponder) GetParameters() url.Values {
+	return r.parameters
 }
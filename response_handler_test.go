@@ -0,0 +1,133 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ory/fosite/token/jwt"
+)
+
+type staticResponseModeHandler struct {
+	modes ResponseModeTypes
+}
+
+func (h *staticResponseModeHandler) ResponseModes() ResponseModeTypes { return h.modes }
+
+func (h *staticResponseModeHandler) WriteAuthorizeResponse(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, resp AuthorizeResponder) {
+	rw.WriteHeader(http.StatusTeapot)
+}
+
+func (h *staticResponseModeHandler) WriteAuthorizeError(ctx context.Context, rw http.ResponseWriter, ar AuthorizeRequester, err error) {
+	rw.WriteHeader(http.StatusTeapot)
+}
+
+func TestGetResponseModeHandler(t *testing.T) {
+	custom := &staticResponseModeHandler{modes: ResponseModeTypes{"custom", ResponseModeFormPost}}
+
+	for k, c := range []struct {
+		f      *Fosite
+		rm     ResponseModeType
+		expect interface{}
+	}{
+		{f: &Fosite{}, rm: ResponseModeDefault, expect: &QueryResponseModeHandler{}},
+		{f: &Fosite{}, rm: ResponseModeQuery, expect: &QueryResponseModeHandler{}},
+		{f: &Fosite{}, rm: ResponseModeFragment, expect: &FragmentResponseModeHandler{}},
+		{f: &Fosite{}, rm: ResponseModeFormPost, expect: &FormPostResponseModeHandler{}},
+		{f: &Fosite{}, rm: "custom", expect: nil},
+		{f: &Fosite{}, rm: ResponseModeJWT, expect: nil},
+		{f: &Fosite{ResponseModeHandlers: []ResponseModeHandler{custom}}, rm: "custom", expect: custom},
+		{f: &Fosite{ResponseModeHandlers: []ResponseModeHandler{custom}}, rm: ResponseModeFormPost, expect: custom},
+		{f: &Fosite{ResponseModeHandlerExtension: custom}, rm: "custom", expect: custom},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			h := c.f.GetResponseModeHandler(c.rm)
+			if c.expect == nil {
+				assert.Nil(t, h)
+				return
+			}
+			assert.IsType(t, c.expect, h)
+			if c.expect == custom {
+				assert.Equal(t, custom, h)
+			}
+		})
+	}
+
+	t.Run("case=jwt secured response modes require a signer", func(t *testing.T) {
+		f := &Fosite{JWTSecuredAuthorizeResponseModeSigners: map[string]jwt.JWTStrategy{"RS256": &jwt.RS256JWTStrategy{}}}
+		for _, rm := range []ResponseModeType{ResponseModeJWT, ResponseModeQueryJWT, ResponseModeFragmentJWT, ResponseModeFormPostJWT} {
+			assert.IsType(t, &jwtSecuredResponseModeHandler{}, f.GetResponseModeHandler(rm))
+		}
+	})
+}
+
+func TestParseResponseModeRegistry(t *testing.T) {
+	f := &Fosite{ResponseModeHandlers: []ResponseModeHandler{&staticResponseModeHandler{modes: ResponseModeTypes{"custom"}}}}
+
+	for k, c := range []struct {
+		rm        string
+		expectErr error
+	}{
+		{rm: ""},
+		{rm: "query"},
+		{rm: "custom"},
+		{rm: "unknown", expectErr: ErrUnsupportedResponseMode},
+		{rm: "query.jwt", expectErr: ErrUnsupportedResponseMode},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			ar := NewAuthorizeRequest()
+			err := f.ParseResponseMode(&http.Request{Form: url.Values{"response_mode": {c.rm}}}, ar)
+			if c.expectErr != nil {
+				assert.ErrorIs(t, err, c.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.EqualValues(t, c.rm, ar.GetResponseMode())
+		})
+	}
+}
+
+func TestWriteAuthorizeResponseWithRegisteredHandler(t *testing.T) {
+	f := &Fosite{ResponseModeHandlers: []ResponseModeHandler{&staticResponseModeHandler{modes: ResponseModeTypes{"custom"}}}}
+	ar := NewAuthorizeRequest()
+	ar.ResponseMode = "custom"
+	ar.RedirectURI, _ = url.Parse("https://localhost/cb")
+
+	rw := httptest.NewRecorder()
+	f.WriteAuthorizeResponse(context.Background(), rw, ar, NewAuthorizeResponse())
+	assert.Equal(t, http.StatusTeapot, rw.Code)
+	assert.Equal(t, "no-store", rw.Header().Get("Cache-Control"))
+
+	rw = httptest.NewRecorder()
+	ar.ResponseMode = "unknown"
+	f.WriteAuthorizeResponse(context.Background(), rw, ar, NewAuthorizeResponse())
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	assert.Contains(t, rw.Body.String(), "unsupported_response_mode")
+}
@@ -49,9 +49,26 @@ import (
 // * https://tools.ietf.org/html/rfc7009#section-2.2
 // An invalid token type hint value is ignored by the authorization
 // server and does not influence the revocation response.
-func (f *Fosite) NewRevocationRequest(ctx context.Context, r *http.Request) error {
+func (f *Fosite) NewRevocationRequest(ctx context.Context, r *http.Request) (err error) {
+	ctx, endSpan := f.startSpan(ctx, TraceOperationNewRevocationRequest)
 	ctx = context.WithValue(ctx, RequestContextKey, r)
 
+	var client Client
+	defer func() {
+		if !f.hasRequestHooks() {
+			return
+		}
+
+		event := newAuditEvent(nil, err)
+		if client != nil {
+			event.ClientID = client.GetID()
+		}
+		event.TokenType = TokenType(r.PostForm.Get("token_type_hint"))
+		f.audit(func(l AuditLogger) { l.Revoked(ctx, event) })
+		f.observe(func(o Observer) { observeFailure(ctx, o, newObserverLabels(ObserverEndpointRevocation, event)) })
+		endSpan(event)
+	}()
+
 	if r.Method != "POST" {
 		return errorsx.WithStack(ErrInvalidRequest.WithHintf("HTTP method is '%s' but expected 'POST'.", r.Method))
 	} else if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
@@ -60,7 +77,12 @@ func (f *Fosite) NewRevocationRequest(ctx context.Context, r *http.Request) erro
 		return errorsx.WithStack(ErrInvalidRequest.WithHint("The POST body can not be empty."))
 	}
 
-	client, err := f.AuthenticateClient(ctx, r, r.PostForm)
+	// The request context is not kept, but its tenant identifies the client and the tokens it may revoke.
+	if ctx, err = f.extractRequestContext(ctx, r, new(Request)); err != nil {
+		return err
+	}
+
+	client, err = f.AuthenticateClient(ctx, r, r.PostForm)
 	if err != nil {
 		return err
 	}
@@ -70,7 +92,9 @@ func (f *Fosite) NewRevocationRequest(ctx context.Context, r *http.Request) erro
 
 	var found = false
 	for _, loader := range f.RevocationHandlers {
-		if err := loader.RevokeToken(ctx, token, tokenTypeHint, client); err == nil {
+		if err := f.withStorageTimeout(ctx, func(ctx context.Context) error {
+			return loader.RevokeToken(ctx, token, tokenTypeHint, client)
+		}); err == nil {
 			found = true
 		} else if errors.Is(err, ErrUnknownRequest) {
 			// do nothing
@@ -86,6 +110,96 @@ func (f *Fosite) NewRevocationRequest(ctx context.Context, r *http.Request) erro
 	return nil
 }
 
+// RevokeTokensBySubject revokes the tokens of all clients issued to the subject using the revocation handlers which
+// implement SubjectRevocationHandler, and returns the number of revoked tokens. It fails if none of them does.
+func (f *Fosite) RevokeTokensBySubject(ctx context.Context, subject string) (int, error) {
+	if subject == "" {
+		return 0, errorsx.WithStack(ErrInvalidRequest.WithHint("The subject must not be empty."))
+	}
+
+	var found = false
+	var revoked int
+	for _, h := range f.RevocationHandlers {
+		sh, ok := h.(SubjectRevocationHandler)
+		if !ok {
+			continue
+		}
+
+		var n int
+		if err := f.withStorageTimeout(ctx, func(ctx context.Context) (err error) {
+			n, err = sh.RevokeTokensBySubject(ctx, subject)
+			return err
+		}); err == nil {
+			found = true
+		} else if errors.Is(err, ErrUnknownRequest) {
+			// do nothing
+		} else {
+			return revoked + n, err
+		}
+		revoked += n
+	}
+
+	if !found {
+		return 0, errorsx.WithStack(ErrServerError.WithHint("No revocation handler is able to revoke tokens by subject."))
+	}
+
+	return revoked, nil
+}
+
+// RevokeTokensByClient revokes the tokens issued to the client using the revocation handlers which implement
+// ClientRevocationHandler, and returns the number of revoked tokens. It fails if none of them does. If
+// RevokePushedAuthorizeRequestsByClient is set, the pushed authorization requests of the client are deleted and
+// counted as well.
+func (f *Fosite) RevokeTokensByClient(ctx context.Context, clientID string) (int, error) {
+	if clientID == "" {
+		return 0, errorsx.WithStack(ErrInvalidRequest.WithHint("The client ID must not be empty."))
+	}
+
+	var found = false
+	var revoked int
+	for _, h := range f.RevocationHandlers {
+		ch, ok := h.(ClientRevocationHandler)
+		if !ok {
+			continue
+		}
+
+		var n int
+		if err := f.withStorageTimeout(ctx, func(ctx context.Context) (err error) {
+			n, err = ch.RevokeTokensByClient(ctx, clientID)
+			return err
+		}); err == nil {
+			found = true
+		} else if errors.Is(err, ErrUnknownRequest) {
+			// do nothing
+		} else {
+			return revoked + n, err
+		}
+		revoked += n
+	}
+
+	if !found {
+		return 0, errorsx.WithStack(ErrServerError.WithHint("No revocation handler is able to revoke tokens by client."))
+	}
+
+	if f.RevokePushedAuthorizeRequestsByClient {
+		storage, ok := f.Store.(PARClientRevocationStorage)
+		if !ok {
+			return revoked, errorsx.WithStack(ErrServerError.WithHint("The storage is not able to revoke pushed authorization requests by client."))
+		}
+
+		var n int
+		if err := f.withStorageTimeout(ctx, func(ctx context.Context) (err error) {
+			n, err = storage.DeletePARSessionsByClient(ctx, clientID)
+			return err
+		}); err != nil {
+			return revoked + n, errorsx.WithStack(ErrServerError.WithWrap(err).WithDebug(err.Error()))
+		}
+		revoked += n
+	}
+
+	return revoked, nil
+}
+
 // WriteRevocationResponse writes a token revocation response as specified in:
 // https://tools.ietf.org/html/rfc7009#section-2.2
 //
@@ -105,6 +219,7 @@ func (f *Fosite) WriteRevocationResponse(rw http.ResponseWriter, err error) {
 		rw.WriteHeader(http.StatusOK)
 		return
 	}
+	f.runErrorHook(ErrorToRFC6749Error(err))
 
 	if errors.Is(err, ErrInvalidRequest) {
 		rw.Header().Set("Content-Type", "application/json;charset=UTF-8")
@@ -23,18 +23,25 @@ package fosite_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	. "github.com/ory/fosite"
+	"github.com/ory/fosite/compose"
+	"github.com/ory/fosite/handler/oauth2"
 	"github.com/ory/fosite/internal"
+	"github.com/ory/fosite/storage"
+	"github.com/ory/fosite/token/jwt"
 )
 
 func TestNewRevocationRequest(t *testing.T) {
@@ -265,3 +272,149 @@ func TestWriteRevocationResponse(t *testing.T) {
 		assert.Equal(t, tc.expectCode, tc.input.rw.Code)
 	}
 }
+
+func TestRevokeJWTAccessTokenIntrospection(t *testing.T) {
+	key := internal.MustRSAKey()
+	config := &compose.Config{EnableJWTAccessTokenDenylist: true}
+	store := storage.NewExampleStore()
+	strategy := &compose.CommonStrategy{
+		CoreStrategy: compose.NewOAuth2JWTStrategy(key, compose.NewOAuth2HMACStrategy(config, []byte("some-super-cool-secret-that-nobody-knows"), nil)).WithJWTProfile(),
+		JWTStrategy:  &jwt.RS256JWTStrategy{PrivateKey: key},
+	}
+	f := compose.Compose(config, store, strategy, nil, compose.OAuth2JWTProfileAccessTokenFactory, compose.OAuth2TokenRevocationFactory).(*Fosite)
+
+	ar := NewAccessRequest(&oauth2.JWTSession{
+		JWTClaims: &jwt.JWTClaims{Subject: "peter"},
+		ExpiresAt: map[TokenType]time.Time{AccessToken: time.Now().UTC().Add(time.Hour)},
+	})
+	ar.Client = store.Clients["my-client"]
+	token, _, err := strategy.GenerateAccessToken(context.Background(), ar)
+	require.NoError(t, err)
+
+	newRequest := func() *http.Request {
+		return &http.Request{
+			Method: "POST",
+			Header: http.Header{
+				//Basic Authorization with username=my-client and password=foobar
+				"Authorization": []string{"Basic bXktY2xpZW50OmZvb2Jhcg=="},
+			},
+			PostForm: url.Values{"token": []string{token}, "token_type_hint": []string{"access_token"}},
+		}
+	}
+	introspect := func() map[string]interface{} {
+		resp, err := f.NewIntrospectionRequest(context.Background(), newRequest(), &oauth2.JWTSession{})
+		rw := httptest.NewRecorder()
+		if err != nil {
+			f.WriteIntrospectionError(rw, err)
+		} else {
+			f.WriteIntrospectionResponse(rw, resp)
+		}
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(rw.Body).Decode(&body))
+		return body
+	}
+
+	assert.Equal(t, true, introspect()["active"])
+	require.NoError(t, f.NewRevocationRequest(context.Background(), newRequest()))
+	assert.Equal(t, false, introspect()["active"])
+}
+
+func TestRevokeTokensBySubject(t *testing.T) {
+	config := new(compose.Config)
+	store := storage.NewMemoryStore()
+	strategy := compose.NewOAuth2HMACStrategy(config, []byte("some-super-cool-secret-that-nobody-knows"), nil)
+	f := compose.Compose(config, store, strategy, nil, compose.OAuth2TokenRevocationFactory).(*Fosite)
+
+	newRequest := func(clientID, subject string) Requester {
+		r := NewRequest()
+		r.Client = &DefaultClient{ID: clientID}
+		r.Session = &DefaultSession{Subject: subject}
+		return r
+	}
+	for k, r := range []Requester{
+		newRequest("my-client", "peter"),
+		newRequest("my-client", "peter"),
+		newRequest("other-client", "peter"),
+		newRequest("my-client", "alice"),
+	} {
+		require.NoError(t, store.CreateAccessTokenSession(context.Background(), fmt.Sprintf("access-%d", k), r))
+		require.NoError(t, store.CreateRefreshTokenSession(context.Background(), fmt.Sprintf("refresh-%d", k), r))
+	}
+
+	revoked, err := f.RevokeTokensBySubject(context.Background(), "peter")
+	require.NoError(t, err)
+	assert.Equal(t, 6, revoked)
+
+	for _, signature := range []string{"access-0", "access-1", "access-2"} {
+		_, err := store.GetAccessTokenSession(context.Background(), signature, nil)
+		assert.True(t, errors.Is(err, ErrNotFound), signature)
+	}
+	for _, signature := range []string{"refresh-0", "refresh-1", "refresh-2"} {
+		_, err := store.GetRefreshTokenSession(context.Background(), signature, nil)
+		assert.True(t, errors.Is(err, ErrNotFound), signature)
+	}
+	_, err = store.GetAccessTokenSession(context.Background(), "access-3", nil)
+	assert.NoError(t, err)
+	_, err = store.GetRefreshTokenSession(context.Background(), "refresh-3", nil)
+	assert.NoError(t, err)
+
+	revoked, err = f.RevokeTokensBySubject(context.Background(), "peter")
+	require.NoError(t, err)
+	assert.Equal(t, 0, revoked)
+
+	_, err = f.RevokeTokensBySubject(context.Background(), "")
+	assert.EqualError(t, err, ErrInvalidRequest.Error())
+
+	f.RevocationHandlers = nil
+	_, err = f.RevokeTokensBySubject(context.Background(), "alice")
+	assert.EqualError(t, err, ErrServerError.Error())
+}
+
+func TestRevokeTokensByClient(t *testing.T) {
+	config := &compose.Config{RevokePushedAuthorizeRequestsByClient: true}
+	store := storage.NewMemoryStore()
+	strategy := compose.NewOAuth2HMACStrategy(config, []byte("some-super-cool-secret-that-nobody-knows"), nil)
+	f := compose.Compose(config, store, strategy, nil, compose.OAuth2TokenRevocationFactory).(*Fosite)
+
+	ctx := context.Background()
+	for _, clientID := range []string{"my-client", "other-client"} {
+		for k := 0; k < 2; k++ {
+			r := NewAuthorizeRequest()
+			r.Client = &DefaultClient{ID: clientID}
+			r.Session = &DefaultSession{Subject: "peter"}
+			key := fmt.Sprintf("%s-%d", clientID, k)
+			require.NoError(t, store.CreateAccessTokenSession(ctx, key, r))
+			require.NoError(t, store.CreateRefreshTokenSession(ctx, key, r))
+			require.NoError(t, store.CreateAuthorizeCodeSession(ctx, key, r))
+			require.NoError(t, store.CreatePARSession(ctx, key, r))
+		}
+	}
+
+	revoked, err := f.RevokeTokensByClient(ctx, "my-client")
+	require.NoError(t, err)
+	assert.Equal(t, 8, revoked)
+
+	for _, c := range []struct {
+		clientID  string
+		expectErr bool
+	}{
+		{clientID: "my-client", expectErr: true},
+		{clientID: "other-client", expectErr: false},
+	} {
+		for k := 0; k < 2; k++ {
+			key := fmt.Sprintf("%s-%d", c.clientID, k)
+			_, err := store.GetAccessTokenSession(ctx, key, nil)
+			assert.Equal(t, c.expectErr, errors.Is(err, ErrNotFound), key)
+			_, err = store.GetRefreshTokenSession(ctx, key, nil)
+			assert.Equal(t, c.expectErr, errors.Is(err, ErrNotFound), key)
+			_, err = store.GetAuthorizeCodeSession(ctx, key, nil)
+			assert.Equal(t, c.expectErr, errors.Is(err, ErrNotFound), key)
+			_, err = store.GetPARSession(ctx, key)
+			assert.Equal(t, c.expectErr, errors.Is(err, ErrNotFound), key)
+		}
+	}
+
+	_, err = f.RevokeTokensByClient(ctx, "")
+	assert.EqualError(t, err, ErrInvalidRequest.Error())
+}
@@ -21,7 +21,13 @@
 
 package fosite
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ory/x/errorsx"
+)
 
 // ScopeStrategy is a strategy for matching scopes.
 type ScopeStrategy func(haystack []string, needle string) bool
@@ -101,3 +107,59 @@ func WildcardScopeStrategy(matchers []string, needle string) bool {
 
 	return false
 }
+
+// RegexpScopePrefix marks scopes of a client which RegexpScopeStrategy treats as regular expressions, for example
+// "regex:tenant\.[a-z0-9]+\.read".
+const RegexpScopePrefix = "regex:"
+
+var regexpScopeCache sync.Map
+
+// RegexpScopeStrategy matches the needle against the scopes in the haystack. Scopes prefixed with RegexpScopePrefix
+// are regular expressions which have to match the whole needle, all other scopes have to match it exactly. Patterns
+// are compiled once and cached; invalid patterns never match.
+func RegexpScopeStrategy(haystack []string, needle string) bool {
+	for _, this := range haystack {
+		if !strings.HasPrefix(this, RegexpScopePrefix) {
+			if this == needle {
+				return true
+			}
+			continue
+		}
+
+		if re, err := compileScopePattern(this); err == nil && re.MatchString(needle) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateScopePatterns returns an error if a scope of the client is not a valid RegexpScopeStrategy pattern. Call it
+// when registering clients to detect invalid patterns early.
+func ValidateScopePatterns(client Client) error {
+	for _, scope := range client.GetScopes() {
+		if !strings.HasPrefix(scope, RegexpScopePrefix) {
+			continue
+		}
+
+		if _, err := compileScopePattern(scope); err != nil {
+			return errorsx.WithStack(ErrInvalidScope.WithHintf("The OAuth 2.0 Client scope '%s' is not a valid regular expression.", scope).WithWrap(err).WithDebug(err.Error()))
+		}
+	}
+
+	return nil
+}
+
+func compileScopePattern(scope string) (*regexp.Regexp, error) {
+	if re, ok := regexpScopeCache.Load(scope); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile("^(?:" + strings.TrimPrefix(scope, RegexpScopePrefix) + ")$")
+	if err != nil {
+		return nil, err
+	}
+
+	regexpScopeCache.Store(scope, re)
+	return re, nil
+}
@@ -150,3 +150,26 @@ func TestExactScopeStrategy2ScopeStrategy(t *testing.T) {
 
 	assert.False(t, strategy([]string{}, "foo"))
 }
+
+func TestRegexpScopeStrategy(t *testing.T) {
+	var strategy ScopeStrategy = RegexpScopeStrategy
+
+	scopes := []string{`regex:tenant\.[a-z0-9]+\.read`, "openid", "regex:invalid(", "foo.*"}
+	assert.True(t, strategy(scopes, "tenant.acme.read"))
+	assert.True(t, strategy(scopes, "tenant.acme42.read"))
+	assert.True(t, strategy(scopes, "openid"))
+	assert.True(t, strategy(scopes, "foo.*"))
+
+	assert.False(t, strategy(scopes, "tenant.acme.write"))
+	assert.False(t, strategy(scopes, "tenant.acme.read.write"))
+	assert.False(t, strategy(scopes, "prefix.tenant.acme.read"))
+	assert.False(t, strategy(scopes, "tenant..read"))
+	assert.False(t, strategy(scopes, "foo.bar"))
+	assert.False(t, strategy(scopes, "invalid("))
+	assert.False(t, strategy([]string{}, "foo"))
+}
+
+func TestValidateScopePatterns(t *testing.T) {
+	assert.NoError(t, ValidateScopePatterns(&DefaultClient{Scopes: []string{`regex:tenant\.[a-z0-9]+\.read`, "openid", "foo(bar"}}))
+	assert.EqualError(t, ValidateScopePatterns(&DefaultClient{Scopes: []string{"openid", "regex:tenant.(read"}}), ErrInvalidScope.Error())
+}
@@ -56,6 +56,9 @@ type DefaultSession struct {
 	Username  string
 	Subject   string
 	Extra     map[string]interface{}
+
+	GrantedAt         time.Time
+	RefreshChainDepth int
 }
 
 func (s *DefaultSession) SetExpiresAt(key TokenType, exp time.Time) {
@@ -103,6 +106,39 @@ func (s *DefaultSession) Clone() Session {
 	return deepcopy.Copy(s).(Session)
 }
 
+// RefreshChainSession is implemented by sessions which track the refresh token chain they belong to, which allows to
+// cap the absolute lifetime and the length of the chain.
+type RefreshChainSession interface {
+	// GetGrantedAt returns when the authorization grant the refresh token chain started with was issued, or
+	// time.IsZero() if not known.
+	GetGrantedAt() time.Time
+
+	// SetGrantedAt sets when the authorization grant the refresh token chain started with was issued.
+	SetGrantedAt(t time.Time)
+
+	// GetRefreshChainDepth returns how often the refresh token has been refreshed.
+	GetRefreshChainDepth() int
+
+	// SetRefreshChainDepth sets how often the refresh token has been refreshed.
+	SetRefreshChainDepth(depth int)
+}
+
+func (s *DefaultSession) GetGrantedAt() time.Time {
+	return s.GrantedAt
+}
+
+func (s *DefaultSession) SetGrantedAt(t time.Time) {
+	s.GrantedAt = t
+}
+
+func (s *DefaultSession) GetRefreshChainDepth() int {
+	return s.RefreshChainDepth
+}
+
+func (s *DefaultSession) SetRefreshChainDepth(depth int) {
+	s.RefreshChainDepth = depth
+}
+
 // ExtraClaimsSession provides an interface for session to store any extra claims.
 type ExtraClaimsSession interface {
 	// GetExtraClaims returns a map to store extra claims.
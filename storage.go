@@ -21,7 +21,43 @@
 
 package fosite
 
+import "context"
+
 // Storage defines fosite's minimal storage interface.
 type Storage interface {
 	ClientManager
 }
+
+// PARStorage stores pushed authorization requests as defined in https://tools.ietf.org/html/rfc9126
+type PARStorage interface {
+	// CreatePARSession stores the pushed authorization request under its request_uri.
+	CreatePARSession(ctx context.Context, requestURI string, request AuthorizeRequester) error
+
+	// GetPARSession returns the pushed authorization request stored under the request_uri, or ErrNotFound.
+	GetPARSession(ctx context.Context, requestURI string) (AuthorizeRequester, error)
+
+	// DeletePARSession deletes the pushed authorization request stored under the request_uri. It must return
+	// ErrNotFound if the request does not exist (anymore), which ensures that every request_uri is used once only.
+	DeletePARSession(ctx context.Context, requestURI string) error
+}
+
+// PARClientRevocationStorage deletes the pushed authorization requests of a client at once, for example when the
+// client is decommissioned.
+type PARClientRevocationStorage interface {
+	// DeletePARSessionsByClient deletes the pushed authorization requests of the client and returns their number.
+	DeletePARSessionsByClient(ctx context.Context, clientID string) (int, error)
+}
+
+// AuthorizeCodeExchangeStorage stores the responses of authorize code exchanges for retries of token requests. The
+// responses contain the issued tokens in plain text, which is why implementations should encrypt them and must
+// delete them once their grace period has ended.
+type AuthorizeCodeExchangeStorage interface {
+	// CreateAuthorizeCodeExchange stores the exchange under the signature of the authorize code.
+	CreateAuthorizeCodeExchange(ctx context.Context, signature string, exchange *AuthorizeCodeExchange) error
+
+	// GetAuthorizeCodeExchange returns the exchange stored under the signature of the authorize code, or ErrNotFound.
+	GetAuthorizeCodeExchange(ctx context.Context, signature string) (*AuthorizeCodeExchange, error)
+
+	// DeleteAuthorizeCodeExchange deletes the exchange stored under the signature of the authorize code, if any.
+	DeleteAuthorizeCodeExchange(ctx context.Context, signature string) error
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ory/fosite"
+)
+
+// CachedStorage is the storage wrapped by CachingStorage. It consists of the methods of fosite.Storage and of the
+// oauth2.CoreStorage and oauth2.TokenRevocationStorage interfaces, which are required by the core OAuth 2.0 factories
+// of the compose package.
+type CachedStorage interface {
+	fosite.Storage
+
+	CreateAuthorizeCodeSession(ctx context.Context, code string, request fosite.Requester) error
+	GetAuthorizeCodeSession(ctx context.Context, code string, session fosite.Session) (fosite.Requester, error)
+	InvalidateAuthorizeCodeSession(ctx context.Context, code string) error
+
+	CreateAccessTokenSession(ctx context.Context, signature string, request fosite.Requester) error
+	GetAccessTokenSession(ctx context.Context, signature string, session fosite.Session) (fosite.Requester, error)
+	DeleteAccessTokenSession(ctx context.Context, signature string) error
+
+	CreateRefreshTokenSession(ctx context.Context, signature string, request fosite.Requester) error
+	GetRefreshTokenSession(ctx context.Context, signature string, session fosite.Session) (fosite.Requester, error)
+	DeleteRefreshTokenSession(ctx context.Context, signature string) error
+	RevokeRefreshTokenMaybeGracePeriod(ctx context.Context, requestID string, signature string, gracePeriod time.Duration) error
+	RevokeRefreshTokenFamily(ctx context.Context, requestID string) error
+
+	RevokeRefreshToken(ctx context.Context, requestID string) error
+	RevokeAccessToken(ctx context.Context, requestID string) error
+}
+
+// CachingStorage is a read-through cache in front of a storage, which reduces its load on hot paths like client
+// authentication and token introspection. Deleting and revoking access tokens through CachingStorage invalidates
+// their cache entries, changes made to the wrapped storage directly become visible once the entries have expired.
+//
+// CachingStorage forwards the methods of CachedStorage only. Other capabilities of the wrapped storage, for example
+// the PKCE or OpenID Connect storages, are hidden by it, so it can not be passed to factories which require them.
+type CachingStorage struct {
+	CachedStorage
+
+	// ClientTTL sets how long clients are cached. Clients are not cached if it is zero.
+	ClientTTL time.Duration
+
+	// AccessTokenTTL sets how long access token sessions are cached, which should be short as access tokens revoked
+	// elsewhere remain valid until their cache entries expire. Access token sessions are not cached if it is zero.
+	AccessTokenTTL time.Duration
+
+	clients      map[string]cachedClient
+	accessTokens map[string]cachedRequester
+
+	// expired entries are pruned once the caches have grown to these sizes, which keeps pruning amortized O(1)
+	clientsPruneAt      int
+	accessTokensPruneAt int
+
+	clientsMutex      sync.RWMutex
+	accessTokensMutex sync.RWMutex
+
+	now func() time.Time
+}
+
+type cachedClient struct {
+	client    fosite.Client
+	expiresAt time.Time
+}
+
+type cachedRequester struct {
+	requester fosite.Requester
+	expiresAt time.Time
+}
+
+// NewCachingStorage wraps the storage with a cache which keeps clients for the given TTL.
+func NewCachingStorage(storage CachedStorage, clientTTL time.Duration) *CachingStorage {
+	return &CachingStorage{
+		CachedStorage: storage,
+		ClientTTL:     clientTTL,
+		clients:       make(map[string]cachedClient),
+		accessTokens:  make(map[string]cachedRequester),
+		now:           time.Now,
+	}
+}
+
+func (s *CachingStorage) GetClient(ctx context.Context, id string) (fosite.Client, error) {
+	if s.ClientTTL <= 0 {
+		return s.CachedStorage.GetClient(ctx, id)
+	}
+
+	s.clientsMutex.RLock()
+	cached, ok := s.clients[id]
+	s.clientsMutex.RUnlock()
+	if ok && s.currentTime().Before(cached.expiresAt) {
+		return cached.client, nil
+	}
+
+	client, err := s.CachedStorage.GetClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.clientsMutex.Lock()
+	defer s.clientsMutex.Unlock()
+
+	if s.clients == nil {
+		s.clients = make(map[string]cachedClient)
+	}
+	now := s.currentTime()
+	if len(s.clients) >= s.clientsPruneAt {
+		for key, c := range s.clients {
+			if !now.Before(c.expiresAt) {
+				delete(s.clients, key)
+			}
+		}
+		s.clientsPruneAt = nextPruneAt(len(s.clients))
+	}
+	s.clients[id] = cachedClient{client: client, expiresAt: now.Add(s.ClientTTL)}
+	return client, nil
+}
+
+// nextPruneAt returns the cache size at which expired entries are pruned next, given the size after pruning. Doubling
+// it means that the cost of every pruning is covered by the insertions before it.
+func nextPruneAt(size int) int {
+	return 2*size + 64
+}
+
+func (s *CachingStorage) currentTime() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+// InvalidateClient removes the client from the cache, for example after it was updated or deleted.
+func (s *CachingStorage) InvalidateClient(id string) {
+	s.clientsMutex.Lock()
+	defer s.clientsMutex.Unlock()
+
+	delete(s.clients, id)
+}
+
+func (s *CachingStorage) GetAccessTokenSession(ctx context.Context, signature string, session fosite.Session) (fosite.Requester, error) {
+	if s.AccessTokenTTL <= 0 {
+		return s.CachedStorage.GetAccessTokenSession(ctx, signature, session)
+	}
+
+	s.accessTokensMutex.RLock()
+	cached, ok := s.accessTokens[signature]
+	s.accessTokensMutex.RUnlock()
+	if ok && s.currentTime().Before(cached.expiresAt) {
+		return hydrateCachedRequester(cached.requester, session)
+	}
+
+	requester, err := s.CachedStorage.GetAccessTokenSession(ctx, signature, session)
+	if err != nil {
+		return nil, err
+	}
+
+	s.accessTokensMutex.Lock()
+	defer s.accessTokensMutex.Unlock()
+
+	if s.accessTokens == nil {
+		s.accessTokens = make(map[string]cachedRequester)
+	}
+	now := s.currentTime()
+	if len(s.accessTokens) >= s.accessTokensPruneAt {
+		for key, c := range s.accessTokens {
+			if !now.Before(c.expiresAt) {
+				delete(s.accessTokens, key)
+			}
+		}
+		s.accessTokensPruneAt = nextPruneAt(len(s.accessTokens))
+	}
+	s.accessTokens[signature] = cachedRequester{requester: requester, expiresAt: now.Add(s.AccessTokenTTL)}
+	return requester, nil
+}
+
+// hydrateCachedRequester returns a copy of the cached requester. Like the wrapped storage would, it hydrates the
+// session given by the caller, unless it is nil, and uses it as the session of the copy.
+func hydrateCachedRequester(cached fosite.Requester, session fosite.Session) (fosite.Requester, error) {
+	parameters := make([]string, 0, len(cached.GetRequestForm()))
+	for k := range cached.GetRequestForm() {
+		parameters = append(parameters, k)
+	}
+	requester := cached.Sanitize(parameters)
+
+	if session == nil || cached.GetSession() == nil {
+		return requester, nil
+	}
+
+	raw, err := json.Marshal(cached.GetSession())
+	if err != nil {
+		return nil, err
+	} else if err := json.Unmarshal(raw, session); err != nil {
+		return nil, err
+	}
+	requester.SetSession(session)
+	return requester, nil
+}
+
+func (s *CachingStorage) DeleteAccessTokenSession(ctx context.Context, signature string) error {
+	s.accessTokensMutex.Lock()
+	delete(s.accessTokens, signature)
+	s.accessTokensMutex.Unlock()
+
+	return s.CachedStorage.DeleteAccessTokenSession(ctx, signature)
+}
+
+func (s *CachingStorage) RevokeAccessToken(ctx context.Context, requestID string) error {
+	s.accessTokensMutex.Lock()
+	for signature, c := range s.accessTokens {
+		if c.requester.GetID() == requestID {
+			delete(s.accessTokens, signature)
+		}
+	}
+	s.accessTokensMutex.Unlock()
+
+	return s.CachedStorage.RevokeAccessToken(ctx, requestID)
+}
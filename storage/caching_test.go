@@ -0,0 +1,193 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ory/fosite"
+)
+
+type countingStorage struct {
+	*MemoryStore
+	clientLookups      int
+	accessTokenLookups int
+}
+
+func (s *countingStorage) GetClient(ctx context.Context, id string) (fosite.Client, error) {
+	s.clientLookups++
+	return s.MemoryStore.GetClient(ctx, id)
+}
+
+func (s *countingStorage) GetAccessTokenSession(ctx context.Context, signature string, session fosite.Session) (fosite.Requester, error) {
+	s.accessTokenLookups++
+	return s.MemoryStore.GetAccessTokenSession(ctx, signature, session)
+}
+
+func TestCachingStorage_GetClient(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStorage{MemoryStore: NewExampleStore()}
+	s := NewCachingStorage(backend, time.Minute)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.GetClient(ctx, "my-client"); err != nil {
+			t.Fatalf("GetClient() error = %v", err)
+		}
+	}
+	if backend.clientLookups != 1 {
+		t.Errorf("backend was queried %d times, want 1", backend.clientLookups)
+	}
+
+	// unknown clients are not cached
+	for i := 0; i < 2; i++ {
+		if _, err := s.GetClient(ctx, "unknown-client"); !errors.Is(err, fosite.ErrNotFound) {
+			t.Fatalf("GetClient() error = %v, want %v", err, fosite.ErrNotFound)
+		}
+	}
+	if backend.clientLookups != 3 {
+		t.Errorf("backend was queried %d times, want 3", backend.clientLookups)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := s.GetClient(ctx, "my-client"); err != nil {
+		t.Fatalf("GetClient() error = %v", err)
+	}
+	if backend.clientLookups != 4 {
+		t.Errorf("expired client was not looked up again, backend was queried %d times, want 4", backend.clientLookups)
+	}
+
+	s.InvalidateClient("my-client")
+	if _, err := s.GetClient(ctx, "my-client"); err != nil {
+		t.Fatalf("GetClient() error = %v", err)
+	}
+	if backend.clientLookups != 5 {
+		t.Errorf("invalidated client was not looked up again, backend was queried %d times, want 5", backend.clientLookups)
+	}
+}
+
+func TestCachingStorage_GetAccessTokenSession(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStorage{MemoryStore: NewMemoryStore()}
+	s := NewCachingStorage(backend, 0)
+	s.AccessTokenTTL = time.Second * 10
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	for _, signature := range []string{"at-a", "at-b"} {
+		if err := s.CreateAccessTokenSession(ctx, signature, &fosite.Request{ID: "request-" + signature}); err != nil {
+			t.Fatalf("CreateAccessTokenSession() error = %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := s.GetAccessTokenSession(ctx, signature, nil); err != nil {
+				t.Fatalf("GetAccessTokenSession() error = %v", err)
+			}
+		}
+	}
+	if backend.accessTokenLookups != 2 {
+		t.Errorf("backend was queried %d times, want 2", backend.accessTokenLookups)
+	}
+
+	now = now.Add(time.Second * 10)
+	if _, err := s.GetAccessTokenSession(ctx, "at-a", nil); err != nil {
+		t.Fatalf("GetAccessTokenSession() error = %v", err)
+	}
+	if backend.accessTokenLookups != 3 {
+		t.Errorf("expired access token was not looked up again, backend was queried %d times, want 3", backend.accessTokenLookups)
+	}
+
+	if err := s.DeleteAccessTokenSession(ctx, "at-a"); err != nil {
+		t.Fatalf("DeleteAccessTokenSession() error = %v", err)
+	}
+	if _, err := s.GetAccessTokenSession(ctx, "at-a", nil); !errors.Is(err, fosite.ErrNotFound) {
+		t.Errorf("GetAccessTokenSession() error = %v, want %v for deleted access token", err, fosite.ErrNotFound)
+	}
+
+	if err := s.RevokeAccessToken(ctx, "request-at-b"); err != nil {
+		t.Fatalf("RevokeAccessToken() error = %v", err)
+	}
+	if _, err := s.GetAccessTokenSession(ctx, "at-b", nil); !errors.Is(err, fosite.ErrNotFound) {
+		t.Errorf("GetAccessTokenSession() error = %v, want %v for revoked access token", err, fosite.ErrNotFound)
+	}
+}
+
+func TestCachingStorage_GetAccessTokenSessionHydratesSession(t *testing.T) {
+	ctx := context.Background()
+	backend := &countingStorage{MemoryStore: NewMemoryStore()}
+	s := NewCachingStorage(backend, 0)
+	s.AccessTokenTTL = time.Minute
+
+	request := &fosite.Request{ID: "request-at", Session: &fosite.DefaultSession{Subject: "peter"}}
+	if err := s.CreateAccessTokenSession(ctx, "at", request); err != nil {
+		t.Fatalf("CreateAccessTokenSession() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		session := new(fosite.DefaultSession)
+		requester, err := s.GetAccessTokenSession(ctx, "at", session)
+		if err != nil {
+			t.Fatalf("GetAccessTokenSession() error = %v", err)
+		}
+		if i == 0 {
+			// the wrapped memory store ignores the session, which is not the point of this test
+			continue
+		}
+		if session.Subject != "peter" {
+			t.Errorf("session of the caller was not hydrated on a cache hit, subject = %q", session.Subject)
+		}
+		if requester.GetSession() != session {
+			t.Errorf("requester does not use the session of the caller on a cache hit")
+		}
+	}
+	if backend.accessTokenLookups != 1 {
+		t.Errorf("backend was queried %d times, want 1", backend.accessTokenLookups)
+	}
+}
+
+func TestCachingStorage_PrunesAmortized(t *testing.T) {
+	ctx := context.Background()
+	s := NewCachingStorage(&countingStorage{MemoryStore: NewMemoryStore()}, 0)
+	s.AccessTokenTTL = time.Second
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	for i := 0; i < 100; i++ {
+		signature := fmt.Sprintf("at-%d", i)
+		if err := s.CreateAccessTokenSession(ctx, signature, &fosite.Request{ID: signature}); err != nil {
+			t.Fatalf("CreateAccessTokenSession() error = %v", err)
+		}
+		if _, err := s.GetAccessTokenSession(ctx, signature, nil); err != nil {
+			t.Fatalf("GetAccessTokenSession() error = %v", err)
+		}
+		now = now.Add(time.Second)
+	}
+
+	// the 64 expired entries were pruned once the cache had reached that size, the ones added since are kept
+	if got := len(s.accessTokens); got != 100-64 {
+		t.Errorf("cache holds %d entries, want %d", got, 100-64)
+	}
+}
@@ -65,6 +65,34 @@ type MemoryStore struct {
 	RefreshTokenRequestIDs map[string]string
 	// Public keys to check signature in auth grant jwt assertion.
 	IssuerPublicKeys map[string]IssuerPublicKeys
+	DeviceCodes      map[string]StoreDeviceCode
+	// In-memory user code signature to device code signature
+	UserCodes map[string]string
+	// In-memory DPoP proof jti to expiry of its replay window
+	DPoPJTIs map[string]time.Time
+	// In-memory client ID and nonce of authentication requests to expiry
+	UsedNonces map[string]time.Time
+	// In-memory jti of revoked JWT access tokens to their expiry
+	RevokedJTIs map[string]time.Time
+	// In-memory subject to the time its JWT access tokens were revoked
+	RevokedSubjects map[string]time.Time
+
+	// In-memory auth_req_id signature to backchannel authentication request
+	BackchannelAuthRequests map[string]StoreBackchannelAuthRequest
+
+	// In-memory request_uri to pushed authorization request
+	PARSessions map[string]fosite.AuthorizeRequester
+
+	// In-memory authorize code signature to the response of its exchange
+	AuthorizeCodeExchanges map[string]*fosite.AuthorizeCodeExchange
+
+	// MaxEntries caps the number of authorize codes, PKCE and OpenID Connect sessions, access tokens and refresh
+	// tokens each. Once a collection is full, its expired entries are pruned and, if that does not suffice, the entry
+	// requested first is evicted. Eviction is first in, first out rather than least recently used, and scans the
+	// collection on every insert into a full collection. Invalidated authorize codes and inactive refresh tokens are
+	// never evicted, because they are needed to detect the reuse of a code or token, and only leave the store once
+	// expired. Defaults to no limit.
+	MaxEntries int
 
 	clientsMutex                sync.RWMutex
 	authorizeCodesMutex         sync.RWMutex
@@ -77,6 +105,16 @@ type MemoryStore struct {
 	accessTokenRequestIDsMutex  sync.RWMutex
 	refreshTokenRequestIDsMutex sync.RWMutex
 	issuerPublicKeysMutex       sync.RWMutex
+	deviceCodesMutex            sync.RWMutex
+	userCodesMutex              sync.RWMutex
+	dpopJTIsMutex               sync.RWMutex
+	usedNoncesMutex             sync.RWMutex
+	revokedJTIsMutex            sync.RWMutex
+	revokedSubjectsMutex        sync.RWMutex
+
+	backchannelAuthRequestsMutex sync.RWMutex
+	parSessionsMutex             sync.RWMutex
+	authorizeCodeExchangesMutex  sync.RWMutex
 }
 
 func NewMemoryStore() *MemoryStore {
@@ -92,6 +130,16 @@ func NewMemoryStore() *MemoryStore {
 		RefreshTokenRequestIDs: make(map[string]string),
 		BlacklistedJTIs:        make(map[string]time.Time),
 		IssuerPublicKeys:       make(map[string]IssuerPublicKeys),
+		DeviceCodes:            make(map[string]StoreDeviceCode),
+		UserCodes:              make(map[string]string),
+		DPoPJTIs:               make(map[string]time.Time),
+		UsedNonces:             make(map[string]time.Time),
+		RevokedJTIs:            make(map[string]time.Time),
+		RevokedSubjects:        make(map[string]time.Time),
+
+		BackchannelAuthRequests: make(map[string]StoreBackchannelAuthRequest),
+		PARSessions:             make(map[string]fosite.AuthorizeRequester),
+		AuthorizeCodeExchanges:  make(map[string]*fosite.AuthorizeCodeExchange),
 	}
 }
 
@@ -101,6 +149,18 @@ type StoreAuthorizeCode struct {
 }
 
 type StoreRefreshToken struct {
+	active bool
+	// rotatedUntil is the end of the grace period of a rotated refresh token.
+	rotatedUntil time.Time
+	fosite.Requester
+}
+
+type StoreDeviceCode struct {
+	active bool
+	fosite.Requester
+}
+
+type StoreBackchannelAuthRequest struct {
 	active bool
 	fosite.Requester
 }
@@ -143,6 +203,16 @@ func NewExampleStore() *MemoryStore {
 		AccessTokenRequestIDs:  map[string]string{},
 		RefreshTokenRequestIDs: map[string]string{},
 		IssuerPublicKeys:       map[string]IssuerPublicKeys{},
+		DeviceCodes:            map[string]StoreDeviceCode{},
+		UserCodes:              map[string]string{},
+		DPoPJTIs:               map[string]time.Time{},
+		UsedNonces:             map[string]time.Time{},
+		RevokedJTIs:            map[string]time.Time{},
+		RevokedSubjects:        map[string]time.Time{},
+
+		BackchannelAuthRequests: map[string]StoreBackchannelAuthRequest{},
+		PARSessions:             map[string]fosite.AuthorizeRequester{},
+		AuthorizeCodeExchanges:  map[string]*fosite.AuthorizeCodeExchange{},
 	}
 }
 
@@ -150,6 +220,9 @@ func (s *MemoryStore) CreateOpenIDConnectSession(_ context.Context, authorizeCod
 	s.idSessionsMutex.Lock()
 	defer s.idSessionsMutex.Unlock()
 
+	if _, ok := s.IDSessions[authorizeCode]; !ok && s.isFull(len(s.IDSessions)) {
+		s.evictIDSession(time.Now().UTC())
+	}
 	s.IDSessions[authorizeCode] = requester
 	return nil
 }
@@ -218,6 +291,9 @@ func (s *MemoryStore) CreateAuthorizeCodeSession(_ context.Context, code string,
 	s.authorizeCodesMutex.Lock()
 	defer s.authorizeCodesMutex.Unlock()
 
+	if _, ok := s.AuthorizeCodes[code]; !ok && s.isFull(len(s.AuthorizeCodes)) {
+		s.evictAuthorizeCode(time.Now().UTC())
+	}
 	s.AuthorizeCodes[code] = StoreAuthorizeCode{active: true, Requester: req}
 	return nil
 }
@@ -250,10 +326,137 @@ func (s *MemoryStore) InvalidateAuthorizeCodeSession(ctx context.Context, code s
 	return nil
 }
 
+func (s *MemoryStore) CreateDeviceCodeSession(_ context.Context, deviceCodeSignature string, userCodeSignature string, req fosite.Requester) error {
+	// We first lock userCodesMutex and then deviceCodesMutex because this is the same order
+	// locking happens in GetDeviceCodeSessionByUserCode and using the same order prevents deadlocks.
+	s.userCodesMutex.Lock()
+	defer s.userCodesMutex.Unlock()
+	s.deviceCodesMutex.Lock()
+	defer s.deviceCodesMutex.Unlock()
+
+	s.DeviceCodes[deviceCodeSignature] = StoreDeviceCode{active: true, Requester: req}
+	s.UserCodes[userCodeSignature] = deviceCodeSignature
+	return nil
+}
+
+func (s *MemoryStore) GetDeviceCodeSession(_ context.Context, deviceCodeSignature string, _ fosite.Session) (fosite.Requester, error) {
+	s.deviceCodesMutex.RLock()
+	defer s.deviceCodesMutex.RUnlock()
+
+	rel, ok := s.DeviceCodes[deviceCodeSignature]
+	if !ok {
+		return nil, fosite.ErrNotFound
+	}
+	if !rel.active {
+		return rel.Requester, fosite.ErrInvalidatedDeviceCode
+	}
+
+	return rel.Requester, nil
+}
+
+func (s *MemoryStore) GetDeviceCodeSessionByUserCode(_ context.Context, userCodeSignature string, _ fosite.Session) (string, fosite.Requester, error) {
+	s.userCodesMutex.RLock()
+	defer s.userCodesMutex.RUnlock()
+	s.deviceCodesMutex.RLock()
+	defer s.deviceCodesMutex.RUnlock()
+
+	deviceCodeSignature, ok := s.UserCodes[userCodeSignature]
+	if !ok {
+		return "", nil, fosite.ErrNotFound
+	}
+	rel, ok := s.DeviceCodes[deviceCodeSignature]
+	if !ok {
+		return "", nil, fosite.ErrNotFound
+	}
+	if !rel.active {
+		return deviceCodeSignature, rel.Requester, fosite.ErrInvalidatedDeviceCode
+	}
+
+	return deviceCodeSignature, rel.Requester, nil
+}
+
+func (s *MemoryStore) UpdateDeviceCodeSession(_ context.Context, deviceCodeSignature string, req fosite.Requester) error {
+	s.deviceCodesMutex.Lock()
+	defer s.deviceCodesMutex.Unlock()
+
+	rel, ok := s.DeviceCodes[deviceCodeSignature]
+	if !ok {
+		return fosite.ErrNotFound
+	}
+	rel.Requester = req
+	s.DeviceCodes[deviceCodeSignature] = rel
+	return nil
+}
+
+func (s *MemoryStore) InvalidateDeviceCodeSession(_ context.Context, deviceCodeSignature string) error {
+	s.deviceCodesMutex.Lock()
+	defer s.deviceCodesMutex.Unlock()
+
+	rel, ok := s.DeviceCodes[deviceCodeSignature]
+	if !ok {
+		return fosite.ErrNotFound
+	}
+	rel.active = false
+	s.DeviceCodes[deviceCodeSignature] = rel
+	return nil
+}
+
+func (s *MemoryStore) CreateBackchannelAuthRequestSession(_ context.Context, authReqIDSignature string, req fosite.Requester) error {
+	s.backchannelAuthRequestsMutex.Lock()
+	defer s.backchannelAuthRequestsMutex.Unlock()
+
+	s.BackchannelAuthRequests[authReqIDSignature] = StoreBackchannelAuthRequest{active: true, Requester: req}
+	return nil
+}
+
+func (s *MemoryStore) GetBackchannelAuthRequestSession(_ context.Context, authReqIDSignature string, _ fosite.Session) (fosite.Requester, error) {
+	s.backchannelAuthRequestsMutex.RLock()
+	defer s.backchannelAuthRequestsMutex.RUnlock()
+
+	rel, ok := s.BackchannelAuthRequests[authReqIDSignature]
+	if !ok {
+		return nil, fosite.ErrNotFound
+	}
+	if !rel.active {
+		return rel.Requester, fosite.ErrInvalidatedAuthReqID
+	}
+
+	return rel.Requester, nil
+}
+
+func (s *MemoryStore) UpdateBackchannelAuthRequestSession(_ context.Context, authReqIDSignature string, req fosite.Requester) error {
+	s.backchannelAuthRequestsMutex.Lock()
+	defer s.backchannelAuthRequestsMutex.Unlock()
+
+	rel, ok := s.BackchannelAuthRequests[authReqIDSignature]
+	if !ok {
+		return fosite.ErrNotFound
+	}
+	rel.Requester = req
+	s.BackchannelAuthRequests[authReqIDSignature] = rel
+	return nil
+}
+
+func (s *MemoryStore) InvalidateBackchannelAuthRequestSession(_ context.Context, authReqIDSignature string) error {
+	s.backchannelAuthRequestsMutex.Lock()
+	defer s.backchannelAuthRequestsMutex.Unlock()
+
+	rel, ok := s.BackchannelAuthRequests[authReqIDSignature]
+	if !ok {
+		return fosite.ErrNotFound
+	}
+	rel.active = false
+	s.BackchannelAuthRequests[authReqIDSignature] = rel
+	return nil
+}
+
 func (s *MemoryStore) CreatePKCERequestSession(_ context.Context, code string, req fosite.Requester) error {
 	s.pkcesMutex.Lock()
 	defer s.pkcesMutex.Unlock()
 
+	if _, ok := s.PKCES[code]; !ok && s.isFull(len(s.PKCES)) {
+		s.evictPKCERequestSession(time.Now().UTC())
+	}
 	s.PKCES[code] = req
 	return nil
 }
@@ -285,6 +488,9 @@ func (s *MemoryStore) CreateAccessTokenSession(_ context.Context, signature stri
 	s.accessTokensMutex.Lock()
 	defer s.accessTokensMutex.Unlock()
 
+	if _, ok := s.AccessTokens[signature]; !ok && s.isFull(len(s.AccessTokens)) {
+		s.evictAccessToken(time.Now().UTC())
+	}
 	s.AccessTokens[signature] = req
 	s.AccessTokenRequestIDs[req.GetID()] = signature
 	return nil
@@ -317,6 +523,9 @@ func (s *MemoryStore) CreateRefreshTokenSession(_ context.Context, signature str
 	s.refreshTokensMutex.Lock()
 	defer s.refreshTokensMutex.Unlock()
 
+	if _, ok := s.RefreshTokens[signature]; !ok && s.isFull(len(s.RefreshTokens)) {
+		s.evictRefreshToken(time.Now().UTC())
+	}
 	s.RefreshTokens[signature] = StoreRefreshToken{active: true, Requester: req}
 	s.RefreshTokenRequestIDs[req.GetID()] = signature
 	return nil
@@ -330,7 +539,7 @@ func (s *MemoryStore) GetRefreshTokenSession(_ context.Context, signature string
 	if !ok {
 		return nil, fosite.ErrNotFound
 	}
-	if !rel.active {
+	if !rel.active || (!rel.rotatedUntil.IsZero() && time.Now().UTC().After(rel.rotatedUntil)) {
 		return rel, fosite.ErrInactiveToken
 	}
 	return rel, nil
@@ -344,6 +553,118 @@ func (s *MemoryStore) DeleteRefreshTokenSession(_ context.Context, signature str
 	return nil
 }
 
+func (s *MemoryStore) DeleteAccessTokensBySubject(_ context.Context, subject string) (int, error) {
+	s.accessTokenRequestIDsMutex.Lock()
+	defer s.accessTokenRequestIDsMutex.Unlock()
+	s.accessTokensMutex.Lock()
+	defer s.accessTokensMutex.Unlock()
+
+	var deleted int
+	for signature, req := range s.AccessTokens {
+		if !hasSubject(req, subject) {
+			continue
+		}
+		delete(s.AccessTokens, signature)
+		if s.AccessTokenRequestIDs[req.GetID()] == signature {
+			delete(s.AccessTokenRequestIDs, req.GetID())
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (s *MemoryStore) DeleteRefreshTokensBySubject(_ context.Context, subject string) (int, error) {
+	s.refreshTokenRequestIDsMutex.Lock()
+	defer s.refreshTokenRequestIDsMutex.Unlock()
+	s.refreshTokensMutex.Lock()
+	defer s.refreshTokensMutex.Unlock()
+
+	var deleted int
+	for signature, rel := range s.RefreshTokens {
+		if !hasSubject(rel.Requester, subject) {
+			continue
+		}
+		delete(s.RefreshTokens, signature)
+		if s.RefreshTokenRequestIDs[rel.GetID()] == signature {
+			delete(s.RefreshTokenRequestIDs, rel.GetID())
+		}
+		// Refresh tokens that were already revoked are not counted again.
+		if rel.active {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func hasSubject(req fosite.Requester, subject string) bool {
+	return req.GetSession() != nil && req.GetSession().GetSubject() == subject
+}
+
+func (s *MemoryStore) DeleteAccessTokensByClient(_ context.Context, clientID string) (int, error) {
+	s.accessTokenRequestIDsMutex.Lock()
+	defer s.accessTokenRequestIDsMutex.Unlock()
+	s.accessTokensMutex.Lock()
+	defer s.accessTokensMutex.Unlock()
+
+	var deleted int
+	for signature, req := range s.AccessTokens {
+		if !hasClient(req, clientID) {
+			continue
+		}
+		delete(s.AccessTokens, signature)
+		if s.AccessTokenRequestIDs[req.GetID()] == signature {
+			delete(s.AccessTokenRequestIDs, req.GetID())
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (s *MemoryStore) DeleteRefreshTokensByClient(_ context.Context, clientID string) (int, error) {
+	s.refreshTokenRequestIDsMutex.Lock()
+	defer s.refreshTokenRequestIDsMutex.Unlock()
+	s.refreshTokensMutex.Lock()
+	defer s.refreshTokensMutex.Unlock()
+
+	var deleted int
+	for signature, rel := range s.RefreshTokens {
+		if !hasClient(rel.Requester, clientID) {
+			continue
+		}
+		delete(s.RefreshTokens, signature)
+		if s.RefreshTokenRequestIDs[rel.GetID()] == signature {
+			delete(s.RefreshTokenRequestIDs, rel.GetID())
+		}
+		// Refresh tokens that were already revoked are not counted again.
+		if rel.active {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *MemoryStore) DeleteAuthorizeCodesByClient(_ context.Context, clientID string) (int, error) {
+	s.authorizeCodesMutex.Lock()
+	defer s.authorizeCodesMutex.Unlock()
+
+	var deleted int
+	for code, rel := range s.AuthorizeCodes {
+		if !hasClient(rel.Requester, clientID) {
+			continue
+		}
+		delete(s.AuthorizeCodes, code)
+		// Authorize codes that were already used are not counted again.
+		if rel.active {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func hasClient(req fosite.Requester, clientID string) bool {
+	return req.GetClient() != nil && req.GetClient().GetID() == clientID
+}
+
 func (s *MemoryStore) Authenticate(_ context.Context, name string, secret string) error {
 	s.usersMutex.RLock()
 	defer s.usersMutex.RUnlock()
@@ -352,15 +673,17 @@ func (s *MemoryStore) Authenticate(_ context.Context, name string, secret string
 	if !ok {
 		return fosite.ErrNotFound
 	}
-	if rel.Password != secret {
+	if !fosite.SecretsEqual([]byte(secret), []byte(rel.Password)) {
 		return fosite.ErrNotFound.WithDebug("Invalid credentials")
 	}
 	return nil
 }
 
 func (s *MemoryStore) RevokeRefreshToken(ctx context.Context, requestID string) error {
-	s.refreshTokenRequestIDsMutex.Lock()
-	defer s.refreshTokenRequestIDsMutex.Unlock()
+	s.refreshTokenRequestIDsMutex.RLock()
+	defer s.refreshTokenRequestIDsMutex.RUnlock()
+	s.refreshTokensMutex.Lock()
+	defer s.refreshTokensMutex.Unlock()
 
 	if signature, exists := s.RefreshTokenRequestIDs[requestID]; exists {
 		rel, ok := s.RefreshTokens[signature]
@@ -373,14 +696,53 @@ func (s *MemoryStore) RevokeRefreshToken(ctx context.Context, requestID string)
 	return nil
 }
 
+func (s *MemoryStore) RevokeRefreshTokenMaybeGracePeriod(ctx context.Context, requestID string, signature string, gracePeriod time.Duration) error {
+	s.refreshTokensMutex.Lock()
+	defer s.refreshTokensMutex.Unlock()
+
+	rel, ok := s.RefreshTokens[signature]
+	if !ok {
+		return fosite.ErrNotFound
+	}
+
+	for sig, token := range s.RefreshTokens {
+		if sig != signature && token.GetID() == requestID {
+			token.active = false
+			s.RefreshTokens[sig] = token
+		}
+	}
+
+	// A token which is used again within its grace period keeps its original deadline.
+	if rel.rotatedUntil.IsZero() {
+		rel.rotatedUntil = time.Now().UTC().Add(gracePeriod)
+		s.RefreshTokens[signature] = rel
+	}
+	return nil
+}
+
+func (s *MemoryStore) RevokeRefreshTokenFamily(ctx context.Context, requestID string) error {
+	s.refreshTokensMutex.Lock()
+	defer s.refreshTokensMutex.Unlock()
+
+	for sig, token := range s.RefreshTokens {
+		if token.GetID() == requestID {
+			token.active = false
+			s.RefreshTokens[sig] = token
+		}
+	}
+	return nil
+}
+
 func (s *MemoryStore) RevokeAccessToken(ctx context.Context, requestID string) error {
-	s.accessTokenRequestIDsMutex.RLock()
-	defer s.accessTokenRequestIDsMutex.RUnlock()
+	s.accessTokenRequestIDsMutex.Lock()
+	defer s.accessTokenRequestIDsMutex.Unlock()
+	s.accessTokensMutex.Lock()
+	defer s.accessTokensMutex.Unlock()
 
 	if signature, exists := s.AccessTokenRequestIDs[requestID]; exists {
-		if err := s.DeleteAccessTokenSession(ctx, signature); err != nil {
-			return err
-		}
+		s.deleteAccessToken(signature)
+		// The request ID is released even if its access token was deleted separately.
+		delete(s.AccessTokenRequestIDs, requestID)
 	}
 	return nil
 }
@@ -448,3 +810,373 @@ func (s *MemoryStore) IsJWTUsed(ctx context.Context, jti string) (bool, error) {
 func (s *MemoryStore) MarkJWTUsedForTime(ctx context.Context, jti string, exp time.Time) error {
 	return s.SetClientAssertionJWT(ctx, jti, exp)
 }
+
+func (s *MemoryStore) IsDPoPJTIUsed(_ context.Context, jti string) (bool, error) {
+	s.dpopJTIsMutex.RLock()
+	defer s.dpopJTIsMutex.RUnlock()
+
+	exp, exists := s.DPoPJTIs[jti]
+	return exists && exp.After(time.Now()), nil
+}
+
+func (s *MemoryStore) MarkDPoPJTIUsedForTime(_ context.Context, jti string, exp time.Time) error {
+	s.dpopJTIsMutex.Lock()
+	defer s.dpopJTIsMutex.Unlock()
+
+	// delete expired jtis
+	for j, e := range s.DPoPJTIs {
+		if e.Before(time.Now()) {
+			delete(s.DPoPJTIs, j)
+		}
+	}
+
+	if e, exists := s.DPoPJTIs[jti]; exists && e.After(time.Now()) {
+		return fosite.ErrJTIKnown
+	}
+
+	s.DPoPJTIs[jti] = exp
+	return nil
+}
+
+func (s *MemoryStore) MarkNonceUsedForTime(_ context.Context, clientID, nonce string, exp time.Time) (bool, error) {
+	s.usedNoncesMutex.Lock()
+	defer s.usedNoncesMutex.Unlock()
+
+	// delete expired nonces
+	for n, e := range s.UsedNonces {
+		if e.Before(time.Now()) {
+			delete(s.UsedNonces, n)
+		}
+	}
+
+	key := clientID + "\x00" + nonce
+	if _, exists := s.UsedNonces[key]; exists {
+		return true, nil
+	}
+
+	s.UsedNonces[key] = exp
+	return false, nil
+}
+
+func (s *MemoryStore) RevokeJTI(_ context.Context, jti string, exp time.Time) error {
+	s.revokedJTIsMutex.Lock()
+	defer s.revokedJTIsMutex.Unlock()
+
+	// delete expired jtis
+	for j, e := range s.RevokedJTIs {
+		if !e.IsZero() && e.Before(time.Now()) {
+			delete(s.RevokedJTIs, j)
+		}
+	}
+
+	s.RevokedJTIs[jti] = exp
+	return nil
+}
+
+func (s *MemoryStore) IsJTIRevoked(_ context.Context, jti string) (bool, error) {
+	s.revokedJTIsMutex.RLock()
+	defer s.revokedJTIsMutex.RUnlock()
+
+	exp, exists := s.RevokedJTIs[jti]
+	return exists && (exp.IsZero() || exp.After(time.Now())), nil
+}
+
+func (s *MemoryStore) RevokeSubject(_ context.Context, subject string, revokedAt time.Time) error {
+	s.revokedSubjectsMutex.Lock()
+	defer s.revokedSubjectsMutex.Unlock()
+
+	s.RevokedSubjects[subject] = revokedAt
+	return nil
+}
+
+func (s *MemoryStore) GetSubjectRevokedAt(_ context.Context, subject string) (time.Time, error) {
+	s.revokedSubjectsMutex.RLock()
+	defer s.revokedSubjectsMutex.RUnlock()
+
+	return s.RevokedSubjects[subject], nil
+}
+
+func (s *MemoryStore) CreatePARSession(_ context.Context, requestURI string, request fosite.AuthorizeRequester) error {
+	s.parSessionsMutex.Lock()
+	defer s.parSessionsMutex.Unlock()
+
+	s.PARSessions[requestURI] = request
+	return nil
+}
+
+func (s *MemoryStore) GetPARSession(_ context.Context, requestURI string) (fosite.AuthorizeRequester, error) {
+	s.parSessionsMutex.RLock()
+	defer s.parSessionsMutex.RUnlock()
+
+	r, ok := s.PARSessions[requestURI]
+	if !ok {
+		return nil, fosite.ErrNotFound
+	}
+	return r, nil
+}
+
+func (s *MemoryStore) DeletePARSession(_ context.Context, requestURI string) error {
+	s.parSessionsMutex.Lock()
+	defer s.parSessionsMutex.Unlock()
+
+	if _, ok := s.PARSessions[requestURI]; !ok {
+		return fosite.ErrNotFound
+	}
+	delete(s.PARSessions, requestURI)
+	return nil
+}
+
+func (s *MemoryStore) DeletePARSessionsByClient(_ context.Context, clientID string) (int, error) {
+	s.parSessionsMutex.Lock()
+	defer s.parSessionsMutex.Unlock()
+
+	var deleted int
+	for requestURI, req := range s.PARSessions {
+		if hasClient(req, clientID) {
+			delete(s.PARSessions, requestURI)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *MemoryStore) CreateAuthorizeCodeExchange(_ context.Context, signature string, exchange *fosite.AuthorizeCodeExchange) error {
+	s.authorizeCodeExchangesMutex.Lock()
+	defer s.authorizeCodeExchangesMutex.Unlock()
+
+	s.AuthorizeCodeExchanges[signature] = exchange
+	return nil
+}
+
+func (s *MemoryStore) GetAuthorizeCodeExchange(_ context.Context, signature string) (*fosite.AuthorizeCodeExchange, error) {
+	s.authorizeCodeExchangesMutex.RLock()
+	defer s.authorizeCodeExchangesMutex.RUnlock()
+
+	exchange, ok := s.AuthorizeCodeExchanges[signature]
+	if !ok || time.Now().UTC().After(exchange.ExpiresAt) {
+		return nil, fosite.ErrNotFound
+	}
+	return exchange, nil
+}
+
+func (s *MemoryStore) DeleteAuthorizeCodeExchange(_ context.Context, signature string) error {
+	s.authorizeCodeExchangesMutex.Lock()
+	defer s.authorizeCodeExchangesMutex.Unlock()
+
+	delete(s.AuthorizeCodeExchanges, signature)
+	return nil
+}
+
+// PruneExpired removes the expired authorize codes, PKCE and OpenID Connect sessions, access and refresh tokens as
+// well as the expired jti and nonce entries, and returns their number. Entries without an expiry are kept.
+func (s *MemoryStore) PruneExpired(_ context.Context) (int, error) {
+	now := time.Now().UTC()
+	var n int
+
+	s.authorizeCodesMutex.Lock()
+	n += s.pruneAuthorizeCodes(now)
+	s.authorizeCodesMutex.Unlock()
+
+	s.pkcesMutex.Lock()
+	n += pruneRequesters(s.PKCES, fosite.AuthorizeCode, now)
+	s.pkcesMutex.Unlock()
+
+	s.idSessionsMutex.Lock()
+	n += pruneRequesters(s.IDSessions, fosite.AuthorizeCode, now)
+	s.idSessionsMutex.Unlock()
+
+	s.accessTokenRequestIDsMutex.Lock()
+	s.accessTokensMutex.Lock()
+	n += s.pruneAccessTokens(now)
+	s.accessTokensMutex.Unlock()
+	s.accessTokenRequestIDsMutex.Unlock()
+
+	s.refreshTokenRequestIDsMutex.Lock()
+	s.refreshTokensMutex.Lock()
+	n += s.pruneRefreshTokens(now)
+	s.refreshTokensMutex.Unlock()
+	s.refreshTokenRequestIDsMutex.Unlock()
+
+	s.blacklistedJTIsMutex.Lock()
+	n += pruneTimes(s.BlacklistedJTIs, now)
+	s.blacklistedJTIsMutex.Unlock()
+
+	s.dpopJTIsMutex.Lock()
+	n += pruneTimes(s.DPoPJTIs, now)
+	s.dpopJTIsMutex.Unlock()
+
+	s.usedNoncesMutex.Lock()
+	n += pruneTimes(s.UsedNonces, now)
+	s.usedNoncesMutex.Unlock()
+
+	s.revokedJTIsMutex.Lock()
+	n += pruneTimes(s.RevokedJTIs, now)
+	s.revokedJTIsMutex.Unlock()
+
+	return n, nil
+}
+
+func (s *MemoryStore) isFull(n int) bool {
+	return s.MaxEntries > 0 && n >= s.MaxEntries
+}
+
+func (s *MemoryStore) evictAuthorizeCode(now time.Time) {
+	if s.pruneAuthorizeCodes(now) > 0 {
+		return
+	}
+
+	var c evictionCandidate
+	for code, rel := range s.AuthorizeCodes {
+		if rel.active {
+			c.consider(code, rel.Requester)
+		}
+	}
+	delete(s.AuthorizeCodes, c.key)
+}
+
+func (s *MemoryStore) evictPKCERequestSession(now time.Time) {
+	if pruneRequesters(s.PKCES, fosite.AuthorizeCode, now) > 0 {
+		return
+	}
+
+	var c evictionCandidate
+	for code, req := range s.PKCES {
+		c.consider(code, req)
+	}
+	delete(s.PKCES, c.key)
+}
+
+func (s *MemoryStore) evictIDSession(now time.Time) {
+	if pruneRequesters(s.IDSessions, fosite.AuthorizeCode, now) > 0 {
+		return
+	}
+
+	var c evictionCandidate
+	for code, req := range s.IDSessions {
+		c.consider(code, req)
+	}
+	delete(s.IDSessions, c.key)
+}
+
+// evictAccessToken must be called with accessTokenRequestIDsMutex and accessTokensMutex locked.
+func (s *MemoryStore) evictAccessToken(now time.Time) {
+	if s.pruneAccessTokens(now) > 0 {
+		return
+	}
+
+	var c evictionCandidate
+	for signature, req := range s.AccessTokens {
+		c.consider(signature, req)
+	}
+	s.deleteAccessToken(c.key)
+}
+
+// evictRefreshToken must be called with refreshTokenRequestIDsMutex and refreshTokensMutex locked.
+func (s *MemoryStore) evictRefreshToken(now time.Time) {
+	if s.pruneRefreshTokens(now) > 0 {
+		return
+	}
+
+	var c evictionCandidate
+	for signature, rel := range s.RefreshTokens {
+		if rel.active {
+			c.consider(signature, rel.Requester)
+		}
+	}
+	s.deleteRefreshToken(c.key)
+}
+
+func (s *MemoryStore) pruneAuthorizeCodes(now time.Time) int {
+	var n int
+	for code, rel := range s.AuthorizeCodes {
+		if isExpired(rel.Requester, fosite.AuthorizeCode, now) {
+			delete(s.AuthorizeCodes, code)
+			n++
+		}
+	}
+	return n
+}
+
+func (s *MemoryStore) pruneAccessTokens(now time.Time) int {
+	var n int
+	for signature, req := range s.AccessTokens {
+		if isExpired(req, fosite.AccessToken, now) {
+			s.deleteAccessToken(signature)
+			n++
+		}
+	}
+	return n
+}
+
+func (s *MemoryStore) pruneRefreshTokens(now time.Time) int {
+	var n int
+	for signature, rel := range s.RefreshTokens {
+		if isExpired(rel.Requester, fosite.RefreshToken, now) {
+			s.deleteRefreshToken(signature)
+			n++
+		}
+	}
+	return n
+}
+
+// deleteAccessToken deletes the access token and its request ID, unless the request ID already refers to another
+// access token.
+func (s *MemoryStore) deleteAccessToken(signature string) {
+	if req, ok := s.AccessTokens[signature]; ok && s.AccessTokenRequestIDs[req.GetID()] == signature {
+		delete(s.AccessTokenRequestIDs, req.GetID())
+	}
+	delete(s.AccessTokens, signature)
+}
+
+// deleteRefreshToken deletes the refresh token and its request ID, unless the request ID already refers to another
+// refresh token.
+func (s *MemoryStore) deleteRefreshToken(signature string) {
+	if rel, ok := s.RefreshTokens[signature]; ok && s.RefreshTokenRequestIDs[rel.GetID()] == signature {
+		delete(s.RefreshTokenRequestIDs, rel.GetID())
+	}
+	delete(s.RefreshTokens, signature)
+}
+
+func pruneRequesters(requesters map[string]fosite.Requester, tokenType fosite.TokenType, now time.Time) int {
+	var n int
+	for key, req := range requesters {
+		if isExpired(req, tokenType, now) {
+			delete(requesters, key)
+			n++
+		}
+	}
+	return n
+}
+
+// pruneTimes removes the entries which expired before now. Entries with a zero expiry never expire.
+func pruneTimes(expiries map[string]time.Time, now time.Time) int {
+	var n int
+	for key, exp := range expiries {
+		if !exp.IsZero() && exp.Before(now) {
+			delete(expiries, key)
+			n++
+		}
+	}
+	return n
+}
+
+func isExpired(req fosite.Requester, tokenType fosite.TokenType, now time.Time) bool {
+	if req == nil || req.GetSession() == nil {
+		return false
+	}
+	exp := req.GetSession().GetExpiresAt(tokenType)
+	return !exp.IsZero() && exp.Before(now)
+}
+
+// evictionCandidate keeps track of the entry requested first while iterating over a collection.
+type evictionCandidate struct {
+	key         string
+	requestedAt time.Time
+}
+
+func (c *evictionCandidate) consider(key string, req fosite.Requester) {
+	if c.key == "" || req.GetRequestedAt().Before(c.requestedAt) {
+		c.key = key
+		c.requestedAt = req.GetRequestedAt()
+	}
+}
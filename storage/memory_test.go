@@ -3,9 +3,11 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/ory/fosite"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestMemoryStore_Authenticate(t *testing.T) {
@@ -54,3 +56,337 @@ func TestMemoryStore_Authenticate(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryStore_ClientAssertionJWT(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	if err := s.ClientAssertionJWTValid(ctx, "jti-a"); err != nil {
+		t.Fatalf("ClientAssertionJWTValid() error = %v, want nil for unknown jti", err)
+	}
+	if err := s.SetClientAssertionJWT(ctx, "jti-a", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("SetClientAssertionJWT() error = %v", err)
+	}
+	if err := s.ClientAssertionJWTValid(ctx, "jti-a"); !errors.Is(err, fosite.ErrJTIKnown) {
+		t.Errorf("ClientAssertionJWTValid() error = %v, want %v", err, fosite.ErrJTIKnown)
+	}
+	if err := s.SetClientAssertionJWT(ctx, "jti-a", time.Now().Add(time.Hour)); !errors.Is(err, fosite.ErrJTIKnown) {
+		t.Errorf("SetClientAssertionJWT() error = %v, want %v", err, fosite.ErrJTIKnown)
+	}
+
+	// expired jtis may be used again and are removed once another jti is stored
+	s.BlacklistedJTIs["jti-b"] = time.Now().Add(-time.Minute)
+	if err := s.ClientAssertionJWTValid(ctx, "jti-b"); err != nil {
+		t.Errorf("ClientAssertionJWTValid() error = %v, want nil for expired jti", err)
+	}
+	if err := s.SetClientAssertionJWT(ctx, "jti-c", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("SetClientAssertionJWT() error = %v", err)
+	}
+	if _, ok := s.BlacklistedJTIs["jti-b"]; ok {
+		t.Errorf("expired jti %q has not been cleaned up", "jti-b")
+	}
+}
+
+func TestMemoryStore_MarkNonceUsedForTime(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	if used, err := s.MarkNonceUsedForTime(ctx, "client-a", "nonce-a", time.Now().Add(time.Hour)); err != nil || used {
+		t.Fatalf("MarkNonceUsedForTime() = %v, %v, want false, nil for unknown nonce", used, err)
+	}
+	if used, err := s.MarkNonceUsedForTime(ctx, "client-a", "nonce-a", time.Now().Add(time.Hour)); err != nil || !used {
+		t.Errorf("MarkNonceUsedForTime() = %v, %v, want true, nil for used nonce", used, err)
+	}
+	if used, err := s.MarkNonceUsedForTime(ctx, "client-b", "nonce-a", time.Now().Add(time.Hour)); err != nil || used {
+		t.Errorf("MarkNonceUsedForTime() = %v, %v, want false, nil for nonce of another client", used, err)
+	}
+
+	// expired nonces may be used again
+	s.UsedNonces["client-a\x00nonce-b"] = time.Now().Add(-time.Minute)
+	if used, err := s.MarkNonceUsedForTime(ctx, "client-a", "nonce-b", time.Now().Add(time.Hour)); err != nil || used {
+		t.Errorf("MarkNonceUsedForTime() = %v, %v, want false, nil for expired nonce", used, err)
+	}
+}
+
+func TestMemoryStore_PruneExpired(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	newRequest := func(id string, tokenType fosite.TokenType, exp time.Time) fosite.Requester {
+		return &fosite.Request{ID: id, RequestedAt: time.Now().UTC(), Session: &fosite.DefaultSession{
+			ExpiresAt: map[fosite.TokenType]time.Time{tokenType: exp},
+		}}
+	}
+	expired, valid := time.Now().UTC().Add(-time.Minute), time.Now().UTC().Add(time.Hour)
+
+	for _, err := range []error{
+		s.CreateAuthorizeCodeSession(ctx, "code-expired", newRequest("a", fosite.AuthorizeCode, expired)),
+		s.CreateAuthorizeCodeSession(ctx, "code-valid", newRequest("b", fosite.AuthorizeCode, valid)),
+		s.CreatePKCERequestSession(ctx, "code-expired", newRequest("a", fosite.AuthorizeCode, expired)),
+		s.CreateOpenIDConnectSession(ctx, "code-expired", newRequest("a", fosite.AuthorizeCode, expired)),
+		s.CreateAccessTokenSession(ctx, "at-expired", newRequest("c", fosite.AccessToken, expired)),
+		s.CreateAccessTokenSession(ctx, "at-valid", newRequest("d", fosite.AccessToken, valid)),
+		s.CreateRefreshTokenSession(ctx, "rt-expired", newRequest("c", fosite.RefreshToken, expired)),
+		s.CreateRefreshTokenSession(ctx, "rt-forever", newRequest("d", fosite.RefreshToken, time.Time{})),
+		s.RevokeJTI(ctx, "jti-forever", time.Time{}),
+		s.RevokeJTI(ctx, "jti-expired", expired),
+	} {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	s.DPoPJTIs["jti-expired"] = expired
+	s.UsedNonces["client\x00nonce-expired"] = expired
+
+	n, err := s.PruneExpired(ctx)
+	if err != nil {
+		t.Fatalf("PruneExpired() error = %v", err)
+	}
+	if n != 8 {
+		t.Errorf("PruneExpired() = %d, want 8", n)
+	}
+
+	if _, err := s.GetAuthorizeCodeSession(ctx, "code-valid", nil); err != nil {
+		t.Errorf("valid authorize code has been pruned: %v", err)
+	}
+	if _, err := s.GetAccessTokenSession(ctx, "at-valid", nil); err != nil {
+		t.Errorf("valid access token has been pruned: %v", err)
+	}
+	if _, err := s.GetRefreshTokenSession(ctx, "rt-forever", nil); err != nil {
+		t.Errorf("refresh token without expiry has been pruned: %v", err)
+	}
+	if revoked, _ := s.IsJTIRevoked(ctx, "jti-forever"); !revoked {
+		t.Error("jti revoked forever has been pruned")
+	}
+	if len(s.AuthorizeCodes) != 1 || len(s.PKCES) != 0 || len(s.IDSessions) != 0 || len(s.AccessTokens) != 1 ||
+		len(s.RefreshTokens) != 1 || len(s.RevokedJTIs) != 1 || len(s.DPoPJTIs) != 0 || len(s.UsedNonces) != 0 {
+		t.Error("expired entries have not been pruned")
+	}
+	if _, ok := s.AccessTokenRequestIDs["c"]; ok {
+		t.Error("request ID of expired access token has not been pruned")
+	}
+	if _, ok := s.RefreshTokenRequestIDs["c"]; ok {
+		t.Error("request ID of expired refresh token has not been pruned")
+	}
+}
+
+func TestMemoryStore_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	s.MaxEntries = 3
+
+	now := time.Now().UTC()
+	for i := 0; i < 10; i++ {
+		req := &fosite.Request{ID: fmt.Sprintf("request-%d", i), RequestedAt: now.Add(time.Duration(i) * time.Second), Session: &fosite.DefaultSession{}}
+		if err := s.CreateAccessTokenSession(ctx, fmt.Sprintf("at-%d", i), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := s.CreateAuthorizeCodeSession(ctx, fmt.Sprintf("code-%d", i), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(s.AccessTokens) != 3 || len(s.AccessTokenRequestIDs) != 3 || len(s.AuthorizeCodes) != 3 {
+		t.Fatalf("collections hold %d access tokens, %d request IDs and %d authorize codes, want 3 each", len(s.AccessTokens), len(s.AccessTokenRequestIDs), len(s.AuthorizeCodes))
+	}
+	for _, signature := range []string{"at-7", "at-8", "at-9"} {
+		if _, err := s.GetAccessTokenSession(ctx, signature, nil); err != nil {
+			t.Errorf("latest access token %q has been evicted: %v", signature, err)
+		}
+	}
+	if _, err := s.GetAccessTokenSession(ctx, "at-0", nil); !errors.Is(err, fosite.ErrNotFound) {
+		t.Errorf("oldest access token has not been evicted: %v", err)
+	}
+
+	// expired entries are evicted before the oldest ones
+	s.AccessTokens["at-7"].GetSession().SetExpiresAt(fosite.AccessToken, now.Add(-time.Minute))
+	if err := s.CreateAccessTokenSession(ctx, "at-10", &fosite.Request{ID: "request-10", RequestedAt: now.Add(time.Minute), Session: &fosite.DefaultSession{}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := s.AccessTokens["at-7"]; ok {
+		t.Error("expired access token has not been evicted")
+	}
+	if len(s.AccessTokens) != 3 {
+		t.Errorf("collection holds %d access tokens, want 3", len(s.AccessTokens))
+	}
+}
+
+func TestMemoryStore_MaxEntriesKeepsInactiveEntries(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	s.MaxEntries = 2
+
+	now := time.Now().UTC()
+	for i := 0; i < 3; i++ {
+		req := &fosite.Request{ID: fmt.Sprintf("request-%d", i), RequestedAt: now.Add(time.Duration(i) * time.Second), Session: &fosite.DefaultSession{}}
+		if err := s.CreateAuthorizeCodeSession(ctx, fmt.Sprintf("code-%d", i), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := s.CreateRefreshTokenSession(ctx, fmt.Sprintf("rt-%d", i), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if i == 0 {
+			if err := s.InvalidateAuthorizeCodeSession(ctx, "code-0"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := s.RevokeRefreshToken(ctx, "request-0"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	if _, err := s.GetAuthorizeCodeSession(ctx, "code-0", nil); !errors.Is(err, fosite.ErrInvalidatedAuthorizeCode) {
+		t.Errorf("GetAuthorizeCodeSession() of the invalidated code error = %v, want %v", err, fosite.ErrInvalidatedAuthorizeCode)
+	}
+	if _, err := s.GetRefreshTokenSession(ctx, "rt-0", nil); !errors.Is(err, fosite.ErrInactiveToken) {
+		t.Errorf("GetRefreshTokenSession() of the revoked token error = %v, want %v", err, fosite.ErrInactiveToken)
+	}
+	if _, err := s.GetAuthorizeCodeSession(ctx, "code-1", nil); !errors.Is(err, fosite.ErrNotFound) {
+		t.Errorf("oldest active authorize code has not been evicted: %v", err)
+	}
+	if _, err := s.GetRefreshTokenSession(ctx, "rt-1", nil); !errors.Is(err, fosite.ErrNotFound) {
+		t.Errorf("oldest active refresh token has not been evicted: %v", err)
+	}
+	if _, err := s.GetAuthorizeCodeSession(ctx, "code-2", nil); err != nil {
+		t.Errorf("latest authorize code has been evicted: %v", err)
+	}
+	if _, err := s.GetRefreshTokenSession(ctx, "rt-2", nil); err != nil {
+		t.Errorf("latest refresh token has been evicted: %v", err)
+	}
+}
+
+func TestMemoryStore_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	s.MaxEntries = 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := fmt.Sprintf("request-%d-%d", i, j)
+				req := &fosite.Request{ID: id, RequestedAt: time.Now().UTC(), Session: &fosite.DefaultSession{Subject: "peter"}}
+				_ = s.CreateAccessTokenSession(ctx, "at-"+id, req)
+				_ = s.CreateRefreshTokenSession(ctx, "rt-"+id, req)
+				_, _ = s.GetAccessTokenSession(ctx, "at-"+id, nil)
+				_, _ = s.GetRefreshTokenSession(ctx, "rt-"+id, nil)
+				_ = s.RevokeRefreshToken(ctx, id)
+				_ = s.RevokeAccessToken(ctx, id)
+				_, _ = s.MarkNonceUsedForTime(ctx, "client", id, time.Now().Add(time.Minute))
+				_ = s.RevokeJTI(ctx, id, time.Now().Add(time.Minute))
+				_, _ = s.IsJTIRevoked(ctx, id)
+				if j%25 == 0 {
+					_, _ = s.PruneExpired(ctx)
+					_, _ = s.DeleteAccessTokensBySubject(ctx, "peter")
+				}
+			}
+		}(i)
+	}
+	// introspection running alongside token issuance sees each token either completely or not at all
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := fmt.Sprintf("request-%d-%d", i, j)
+				if req, err := s.GetAccessTokenSession(ctx, "at-"+id, nil); err == nil && req.GetID() != id {
+					t.Errorf("GetAccessTokenSession() returned request %s, want %s", req.GetID(), id)
+				} else if err != nil && !errors.Is(err, fosite.ErrNotFound) {
+					t.Errorf("GetAccessTokenSession() error = %v, want %v", err, fosite.ErrNotFound)
+				}
+				if req, err := s.GetRefreshTokenSession(ctx, "rt-"+id, nil); err == nil && req.GetID() != id {
+					t.Errorf("GetRefreshTokenSession() returned request %s, want %s", req.GetID(), id)
+				} else if err != nil && !errors.Is(err, fosite.ErrNotFound) && !errors.Is(err, fosite.ErrInactiveToken) {
+					t.Errorf("GetRefreshTokenSession() error = %v, want %v or %v", err, fosite.ErrNotFound, fosite.ErrInactiveToken)
+				}
+				if _, err := s.IsJTIRevoked(ctx, id); err != nil {
+					t.Errorf("IsJTIRevoked() error = %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// revoked refresh tokens are kept for reuse detection, so only the active ones are capped
+	var active int
+	for _, rel := range s.RefreshTokens {
+		if rel.active {
+			active++
+		}
+	}
+	if len(s.AccessTokens) > s.MaxEntries || active > s.MaxEntries {
+		t.Errorf("collections hold %d access and %d active refresh tokens, want at most %d", len(s.AccessTokens), active, s.MaxEntries)
+	}
+}
+
+// lockingWith runs f while holding mu, if set. Benchmarks use it to compare the per-map locks of MemoryStore with a
+// baseline which serializes all calls on a single lock.
+func lockingWith(mu *sync.Mutex, f func()) {
+	if mu != nil {
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	f()
+}
+
+func BenchmarkMemoryStore_IssueAndIntrospectParallel(b *testing.B) {
+	for name, mu := range map[string]*sync.Mutex{"locks=per-map": nil, "locks=single-baseline": new(sync.Mutex)} {
+		b.Run(name, func(b *testing.B) {
+			ctx := context.Background()
+			s := NewMemoryStore()
+
+			var n int64
+			var counter sync.Mutex
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					counter.Lock()
+					n++
+					signature := fmt.Sprintf("at-%d", n)
+					counter.Unlock()
+
+					req := &fosite.Request{ID: signature, RequestedAt: time.Now().UTC(), Session: &fosite.DefaultSession{}}
+					var err error
+					lockingWith(mu, func() { err = s.CreateAccessTokenSession(ctx, signature, req) })
+					if err != nil {
+						b.Fatal(err)
+					}
+					lockingWith(mu, func() { _, err = s.GetAccessTokenSession(ctx, signature, nil) })
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkMemoryStore_IntrospectParallel(b *testing.B) {
+	for name, mu := range map[string]*sync.Mutex{"locks=per-map": nil, "locks=single-baseline": new(sync.Mutex)} {
+		b.Run(name, func(b *testing.B) {
+			ctx := context.Background()
+			s := NewMemoryStore()
+			for i := 0; i < 1000; i++ {
+				signature := fmt.Sprintf("at-%d", i)
+				_ = s.CreateAccessTokenSession(ctx, signature, &fosite.Request{ID: signature, Session: &fosite.DefaultSession{}})
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				var i int
+				for pb.Next() {
+					var err error
+					lockingWith(mu, func() { _, err = s.GetAccessTokenSession(ctx, fmt.Sprintf("at-%d", i%1000), nil) })
+					if err != nil {
+						b.Fatal(err)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+
+	"github.com/ory/x/errorsx"
+	"github.com/pkg/errors"
+)
+
+// withStorageTimeout calls fn with a context which expires after StorageTimeout, which bounds the time a handler or a
+// client lookup may spend waiting for the storage. Storage implementations must respect the deadline of the context.
+// If fn fails after the deadline was exceeded, ErrTemporarilyUnavailable is returned instead of the error of fn.
+func (f *Fosite) withStorageTimeout(ctx context.Context, fn func(ctx context.Context) error) error {
+	if f.StorageTimeout <= 0 {
+		return fn(ctx)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, f.StorageTimeout)
+	defer cancel()
+
+	err := fn(timeoutCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		return errorsx.WithStack(ErrTemporarilyUnavailable.WithHint("The storage did not respond in time.").WithWrap(err).WithDebug(err.Error()))
+	}
+	return err
+}
+
+// getClient looks up the client within StorageTimeout.
+func (f *Fosite) getClient(ctx context.Context, id string) (client Client, err error) {
+	err = f.withStorageTimeout(ctx, func(ctx context.Context) error {
+		client, err = GetClientWithContext(ctx, f.Store, id)
+		return err
+	})
+	return client, err
+}
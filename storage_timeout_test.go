@@ -0,0 +1,114 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/ory/fosite"
+	"github.com/ory/fosite/storage"
+)
+
+// blockingClientStore blocks client lookups until their context is done.
+type blockingClientStore struct {
+	*storage.MemoryStore
+}
+
+func (s *blockingClientStore) GetClient(ctx context.Context, id string) (Client, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// blockingTokenHandler blocks until its context is done.
+type blockingTokenHandler struct{}
+
+func (h *blockingTokenHandler) PopulateTokenEndpointResponse(ctx context.Context, requester AccessRequester, responder AccessResponder) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (h *blockingTokenHandler) HandleTokenEndpointRequest(ctx context.Context, requester AccessRequester) error {
+	return nil
+}
+
+func (h *blockingTokenHandler) CanSkipClientAuth(requester AccessRequester) bool {
+	return true
+}
+
+func (h *blockingTokenHandler) CanHandleTokenEndpointRequest(requester AccessRequester) bool {
+	return true
+}
+
+func TestStorageTimeout(t *testing.T) {
+	f := &Fosite{
+		Store:                 &blockingClientStore{MemoryStore: storage.NewMemoryStore()},
+		StorageTimeout:        50 * time.Millisecond,
+		TokenEndpointHandlers: TokenEndpointHandlers{&blockingTokenHandler{}},
+	}
+
+	t.Run("case=client lookup", func(t *testing.T) {
+		r := &http.Request{
+			Method: "POST",
+			Header: http.Header{},
+			PostForm: url.Values{
+				"grant_type": {"client_credentials"},
+				"client_id":  {"foo"},
+			},
+		}
+
+		start := time.Now()
+		_, err := f.AuthenticateClient(context.Background(), r, r.PostForm)
+		require.Error(t, err)
+		assert.EqualError(t, err, ErrTemporarilyUnavailable.Error())
+		assert.Equal(t, http.StatusServiceUnavailable, ErrorToRFC6749Error(err).StatusCode())
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+
+	t.Run("case=token endpoint handler", func(t *testing.T) {
+		ar := NewAccessRequest(new(DefaultSession))
+		ar.GrantTypes = Arguments{"client_credentials"}
+
+		start := time.Now()
+		_, err := f.NewAccessResponse(context.Background(), ar)
+		require.Error(t, err)
+		assert.EqualError(t, err, ErrTemporarilyUnavailable.Error())
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	})
+
+	t.Run("case=no timeout if the deadline is not exceeded", func(t *testing.T) {
+		store := storage.NewMemoryStore()
+		store.Clients["foo"] = &DefaultClient{ID: "foo", Public: true}
+		f := &Fosite{Store: store, StorageTimeout: time.Second}
+
+		r := &http.Request{Method: "POST", Header: http.Header{}, PostForm: url.Values{"client_id": {"foo"}}}
+		c, err := f.AuthenticateClient(context.Background(), r, r.PostForm)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", c.GetID())
+	})
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package hmac
+
+import (
+	"encoding/base64"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TokenEncoding converts the random message and the signature of a token from and to their textual representation.
+// The encoded text must not contain a dot, which separates the two parts of a token.
+type TokenEncoding interface {
+	EncodeToString(src []byte) string
+	DecodeString(s string) ([]byte, error)
+}
+
+var (
+	// Base64URLEncoding is the default TokenEncoding: unpadded base64 with the URL safe alphabet.
+	Base64URLEncoding TokenEncoding = base64.URLEncoding.WithPadding(base64.NoPadding)
+
+	// Base62Encoding encodes tokens with digits and latin letters only, which keeps them in one piece when
+	// double-clicked or wrapped by text editors.
+	Base62Encoding TokenEncoding = newBaseXEncoding("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+
+	// Base58Encoding encodes tokens with the bitcoin alphabet, which additionally leaves out the easily confused
+	// characters 0, O, I and l.
+	Base58Encoding TokenEncoding = newBaseXEncoding("123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
+)
+
+// baseXEncoding interprets the data as a big-endian number and writes it down in the base of the alphabet's length.
+// Each leading zero byte is written as the first character of the alphabet so that the data length is preserved.
+type baseXEncoding struct {
+	alphabet string
+	base     *big.Int
+}
+
+func newBaseXEncoding(alphabet string) *baseXEncoding {
+	return &baseXEncoding{alphabet: alphabet, base: big.NewInt(int64(len(alphabet)))}
+}
+
+func (e *baseXEncoding) EncodeToString(src []byte) string {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == 0 {
+		zeros++
+	}
+
+	n := new(big.Int).SetBytes(src)
+	digit := new(big.Int)
+	out := make([]byte, 0, len(src)*138/100+1)
+	for n.Sign() > 0 {
+		n.DivMod(n, e.base, digit)
+		out = append(out, e.alphabet[digit.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		out = append(out, e.alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func (e *baseXEncoding) DecodeString(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == e.alphabet[0] {
+		zeros++
+	}
+
+	n := new(big.Int)
+	digit := new(big.Int)
+	for i := 0; i < len(s); i++ {
+		d := strings.IndexByte(e.alphabet, s[i])
+		if d < 0 {
+			return nil, errors.Errorf("illegal character %q at input byte %d", s[i], i)
+		}
+		n.Mul(n, e.base)
+		n.Add(n, digit.SetInt64(int64(d)))
+	}
+
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package hmac
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenEncodingRoundTrip(t *testing.T) {
+	for name, encoding := range map[string]TokenEncoding{
+		"base64url": Base64URLEncoding,
+		"base62":    Base62Encoding,
+		"base58":    Base58Encoding,
+	} {
+		t.Run("encoding="+name, func(t *testing.T) {
+			for k, data := range [][]byte{
+				{},
+				{0},
+				{0, 0, 1},
+				{0, 255, 0},
+				{255, 255, 255, 255},
+				[]byte("foo.bar"),
+			} {
+				t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+					decoded, err := encoding.DecodeString(encoding.EncodeToString(data))
+					require.NoError(t, err)
+					assert.Equal(t, data, decoded)
+				})
+			}
+
+			for i := 0; i < 20; i++ {
+				data, err := RandomBytes(32)
+				require.NoError(t, err)
+				decoded, err := encoding.DecodeString(encoding.EncodeToString(data))
+				require.NoError(t, err)
+				assert.Equal(t, data, decoded)
+			}
+		})
+	}
+}
+
+func TestTokenEncodingAlphabet(t *testing.T) {
+	data, err := RandomBytes(64)
+	require.NoError(t, err)
+
+	assert.Regexp(t, regexp.MustCompile("^[0-9A-Za-z]+$"), Base62Encoding.EncodeToString(data))
+	assert.Regexp(t, regexp.MustCompile("^[1-9A-HJ-NP-Za-km-z]+$"), Base58Encoding.EncodeToString(data))
+
+	_, err = Base62Encoding.DecodeString("abc-def")
+	assert.EqualError(t, err, `illegal character '-' at input byte 3`)
+	_, err = Base58Encoding.DecodeString("0OIl")
+	assert.Error(t, err)
+}
+
+func TestGenerateWithEncoding(t *testing.T) {
+	secret := []byte("1234567890123456789012345678901234567890")
+	for name, encoding := range map[string]TokenEncoding{
+		"base62": Base62Encoding,
+		"base58": Base58Encoding,
+	} {
+		t.Run("encoding="+name, func(t *testing.T) {
+			cg := HMACStrategy{GlobalSecret: secret, Encoding: encoding, TokenPrefix: "ory_at_"}
+			token, signature, err := cg.Generate()
+			require.NoError(t, err)
+			assert.Regexp(t, regexp.MustCompile("^ory_at_[0-9A-Za-z]+\\.[0-9A-Za-z]+$"), token)
+			assert.Equal(t, signature, cg.Signature(token))
+			require.NoError(t, cg.Validate(token))
+
+			assert.Error(t, (&HMACStrategy{GlobalSecret: secret, TokenPrefix: "ory_at_"}).Validate(token))
+		})
+	}
+}
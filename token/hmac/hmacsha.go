@@ -19,15 +19,16 @@
  *
  */
 
-// Package hmac is the default implementation for generating and validating challenges. It uses SHA-512/256 to
-// generate and validate challenges.
+// Package hmac is the default implementation for generating and validating challenges. It uses SHA-512/256 by
+// default to generate and validate challenges.
 
 package hmac
 
 import (
+	"crypto"
 	"crypto/hmac"
-	"crypto/sha512"
-	"encoding/base64"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
 	"fmt"
 	"strings"
 	"sync"
@@ -41,25 +42,64 @@ import (
 
 // HMACStrategy is responsible for generating and validating challenges.
 type HMACStrategy struct {
-	TokenEntropy         int
+	// TokenEntropy is the number of random bytes in the "message" part of a token. It defaults to DefaultTokenEntropy
+	// and must not be lower than MinimumTokenEntropy. Every additional 3 bytes add 4 characters to the token, which
+	// consists of the encoded message, a dot and the encoded signature (43 characters with the default encoding).
+	TokenEntropy int
+
 	GlobalSecret         []byte
 	RotatedGlobalSecrets [][]byte
+
+	// Hash is the hash function of the HMAC signing tokens. Defaults to crypto.SHA512_256. Tokens signed with one
+	// hash function do not validate with another one, so changing it invalidates all live tokens.
+	Hash crypto.Hash
+
+	// Encoding converts the message and the signature of tokens to text. Defaults to Base64URLEncoding, see
+	// Base62Encoding and Base58Encoding for alternatives. Changing it invalidates all live tokens.
+	Encoding TokenEncoding
+
+	// TokenPrefix is prepended to tokens created by Generate, for example "ory_at_", which makes them recognizable
+	// for secret scanning tools. Validate rejects tokens which do not carry this prefix.
+	TokenPrefix string
+
+	// AllowUnprefixedTokens makes Validate accept tokens without prefix, for example tokens which have been issued
+	// before a prefix was configured.
+	AllowUnprefixedTokens bool
+
 	sync.Mutex
 }
 
 const (
-	// key should be at least 256 bit long, making it
-	minimumEntropy = 32
+	// DefaultTokenEntropy is the number of random bytes in a token if no TokenEntropy is set.
+	DefaultTokenEntropy = 32
+
+	// MinimumTokenEntropy is the lowest allowed TokenEntropy, which makes tokens carry 128 bit of randomness.
+	MinimumTokenEntropy = 16
 
 	// the secrets (client and global) should each have at least 16 characters making it harder to guess them
 	minimumSecretLength = 32
 )
 
-var b64 = base64.URLEncoding.WithPadding(base64.NoPadding)
+// NewHMACStrategy returns a HMACStrategy signing tokens with the given secret. A token entropy of zero selects
+// DefaultTokenEntropy. It returns an error if the secret is too short or the token entropy too low.
+func NewHMACStrategy(globalSecret []byte, tokenEntropy int) (*HMACStrategy, error) {
+	c := &HMACStrategy{GlobalSecret: globalSecret, TokenEntropy: tokenEntropy}
+	if len(c.GlobalSecret) < minimumSecretLength {
+		return nil, errors.Errorf("secret for signing HMAC-SHA512/256 is expected to be 32 byte long, got %d byte", len(c.GlobalSecret))
+	} else if _, err := c.tokenEntropy(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
 
 // Generate generates a token and a matching signature or returns an error.
 // This method implements rfc6819 Section 5.1.4.2.2: Use High Entropy for Secrets.
 func (c *HMACStrategy) Generate() (string, string, error) {
+	return c.GenerateWithPrefix(c.TokenPrefix)
+}
+
+// GenerateWithPrefix works like Generate but prepends the given prefix instead of TokenPrefix to the token.
+func (c *HMACStrategy) GenerateWithPrefix(prefix string) (string, string, error) {
 	c.Lock()
 	defer c.Unlock()
 
@@ -70,8 +110,14 @@ func (c *HMACStrategy) Generate() (string, string, error) {
 	var signingKey [32]byte
 	copy(signingKey[:], c.GlobalSecret)
 
-	if c.TokenEntropy < minimumEntropy {
-		c.TokenEntropy = minimumEntropy
+	entropy, err := c.tokenEntropy()
+	if err != nil {
+		return "", "", err
+	}
+
+	hash, err := c.hash()
+	if err != nil {
+		return "", "", err
 	}
 
 	// When creating secrets not intended for usage by human users (e.g.,
@@ -81,20 +127,31 @@ func (c *HMACStrategy) Generate() (string, string, error) {
 	// constructed from a cryptographically strong random or pseudo-random
 	// number sequence (see [RFC4086] for best current practice) generated
 	// by the authorization server.
-	tokenKey, err := RandomBytes(c.TokenEntropy)
+	tokenKey, err := RandomBytes(entropy)
 	if err != nil {
 		return "", "", errorsx.WithStack(err)
 	}
 
-	signature := generateHMAC(tokenKey, &signingKey)
+	signature := generateHMAC(hash, tokenKey, &signingKey)
 
-	encodedSignature := b64.EncodeToString(signature)
-	encodedToken := fmt.Sprintf("%s.%s", b64.EncodeToString(tokenKey), encodedSignature)
+	encoding := c.encoding()
+	encodedSignature := encoding.EncodeToString(signature)
+	encodedToken := fmt.Sprintf("%s%s.%s", prefix, encoding.EncodeToString(tokenKey), encodedSignature)
 	return encodedToken, encodedSignature, nil
 }
 
 // Validate validates a token and returns its signature or an error if the token is not valid.
 func (c *HMACStrategy) Validate(token string) (err error) {
+	return c.ValidateWithPrefix(c.TokenPrefix, token)
+}
+
+// ValidateWithPrefix works like Validate but expects the token to carry the given prefix instead of TokenPrefix.
+func (c *HMACStrategy) ValidateWithPrefix(prefix string, token string) (err error) {
+	token, err = c.trimPrefix(prefix, token)
+	if err != nil {
+		return err
+	}
+
 	var keys [][]byte
 
 	if len(c.GlobalSecret) > 0 {
@@ -129,6 +186,11 @@ func (c *HMACStrategy) validate(secret []byte, token string) error {
 	var signingKey [32]byte
 	copy(signingKey[:], secret)
 
+	hash, err := c.hash()
+	if err != nil {
+		return err
+	}
+
 	split := strings.Split(token, ".")
 	if len(split) != 2 {
 		return errorsx.WithStack(fosite.ErrInvalidTokenFormat)
@@ -140,17 +202,18 @@ func (c *HMACStrategy) validate(secret []byte, token string) error {
 		return errorsx.WithStack(fosite.ErrInvalidTokenFormat)
 	}
 
-	decodedTokenSignature, err := b64.DecodeString(tokenSignature)
+	encoding := c.encoding()
+	decodedTokenSignature, err := encoding.DecodeString(tokenSignature)
 	if err != nil {
 		return errorsx.WithStack(err)
 	}
 
-	decodedTokenKey, err := b64.DecodeString(tokenKey)
+	decodedTokenKey, err := encoding.DecodeString(tokenKey)
 	if err != nil {
 		return errorsx.WithStack(err)
 	}
 
-	expectedMAC := generateHMAC(decodedTokenKey, &signingKey)
+	expectedMAC := generateHMAC(hash, decodedTokenKey, &signingKey)
 	if !hmac.Equal(expectedMAC, decodedTokenSignature) {
 		// Hash is invalid
 		return errorsx.WithStack(fosite.ErrTokenSignatureMismatch)
@@ -159,7 +222,31 @@ func (c *HMACStrategy) validate(secret []byte, token string) error {
 	return nil
 }
 
+// GenerateHMACForString returns the HMAC-SHA512/256 signature of the given text using the global secret. It can be
+// used to derive a storage key for values which are not issued in the token format, for example user codes.
+func (c *HMACStrategy) GenerateHMACForString(text string) (string, error) {
+	if len(c.GlobalSecret) < minimumSecretLength {
+		return "", errors.Errorf("secret for signing HMAC-SHA512/256 is expected to be 32 byte long, got %d byte", len(c.GlobalSecret))
+	}
+
+	var signingKey [32]byte
+	copy(signingKey[:], c.GlobalSecret)
+
+	hash, err := c.hash()
+	if err != nil {
+		return "", err
+	}
+
+	return c.encoding().EncodeToString(generateHMAC(hash, []byte(text), &signingKey)), nil
+}
+
 func (c *HMACStrategy) Signature(token string) string {
+	return c.SignatureWithPrefix(c.TokenPrefix, token)
+}
+
+// SignatureWithPrefix works like Signature but strips the given prefix instead of TokenPrefix from the token.
+func (c *HMACStrategy) SignatureWithPrefix(prefix string, token string) string {
+	token = strings.TrimPrefix(token, prefix)
 	split := strings.Split(token, ".")
 
 	if len(split) != 2 {
@@ -169,9 +256,44 @@ func (c *HMACStrategy) Signature(token string) string {
 	return split[1]
 }
 
-func generateHMAC(data []byte, key *[32]byte) []byte {
-	h := hmac.New(sha512.New512_256, key[:])
-	// sha512.digest.Write() always returns nil for err, the panic should never happen
+func (c *HMACStrategy) tokenEntropy() (int, error) {
+	if c.TokenEntropy == 0 {
+		return DefaultTokenEntropy, nil
+	} else if c.TokenEntropy < MinimumTokenEntropy {
+		return 0, errors.Errorf("token entropy for HMAC-SHA512/256 tokens is expected to be at least %d byte, got %d byte", MinimumTokenEntropy, c.TokenEntropy)
+	}
+	return c.TokenEntropy, nil
+}
+
+func (c *HMACStrategy) trimPrefix(prefix string, token string) (string, error) {
+	if prefix == "" || strings.HasPrefix(token, prefix) {
+		return strings.TrimPrefix(token, prefix), nil
+	} else if c.AllowUnprefixedTokens {
+		return token, nil
+	}
+
+	return "", errorsx.WithStack(fosite.ErrInvalidTokenFormat.WithDebugf("Expected the token to be prefixed with '%s'.", prefix))
+}
+
+func (c *HMACStrategy) hash() (crypto.Hash, error) {
+	if c.Hash == 0 {
+		return crypto.SHA512_256, nil
+	} else if !c.Hash.Available() {
+		return 0, errors.Errorf("hash function %s for signing HMAC tokens is not available", c.Hash)
+	}
+	return c.Hash, nil
+}
+
+func (c *HMACStrategy) encoding() TokenEncoding {
+	if c.Encoding == nil {
+		return Base64URLEncoding
+	}
+	return c.Encoding
+}
+
+func generateHMAC(hash crypto.Hash, data []byte, key *[32]byte) []byte {
+	h := hmac.New(hash.New, key[:])
+	// The Write() method of hash functions always returns nil for err, the panic should never happen
 	_, err := h.Write(data)
 	if err != nil {
 		panic(err)
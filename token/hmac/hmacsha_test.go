@@ -22,6 +22,9 @@
 package hmac
 
 import (
+	"crypto"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/ory/fosite"
@@ -38,18 +41,55 @@ func TestGenerateFailsWithShortCredentials(t *testing.T) {
 	require.Empty(t, signature)
 }
 
+func TestGenerateFailsWithLowEntropy(t *testing.T) {
+	cg := HMACStrategy{GlobalSecret: []byte("1234567890123456789012345678901234567890"), TokenEntropy: 15}
+	challenge, signature, err := cg.Generate()
+	require.EqualError(t, err, "token entropy for HMAC-SHA512/256 tokens is expected to be at least 16 byte, got 15 byte")
+	require.Empty(t, challenge)
+	require.Empty(t, signature)
+}
+
+func TestNewHMACStrategy(t *testing.T) {
+	secret := []byte("1234567890123456789012345678901234567890")
+
+	cg, err := NewHMACStrategy(secret, 0)
+	require.NoError(t, err)
+	token, _, err := cg.Generate()
+	require.NoError(t, err)
+	require.NoError(t, cg.Validate(token))
+
+	_, err = NewHMACStrategy(secret, 8)
+	require.EqualError(t, err, "token entropy for HMAC-SHA512/256 tokens is expected to be at least 16 byte, got 8 byte")
+
+	_, err = NewHMACStrategy([]byte("foo"), 32)
+	require.EqualError(t, err, "secret for signing HMAC-SHA512/256 is expected to be 32 byte long, got 3 byte")
+}
+
 func TestGenerate(t *testing.T) {
 	for _, c := range []struct {
 		globalSecret []byte
 		tokenEntropy int
+		tokenLength  int
 	}{
+		{
+			globalSecret: []byte("1234567890123456789012345678901234567890"),
+			tokenEntropy: 0,
+			tokenLength:  43 + 1 + 43,
+		},
+		{
+			globalSecret: []byte("1234567890123456789012345678901234567890"),
+			tokenEntropy: 16,
+			tokenLength:  22 + 1 + 43,
+		},
 		{
 			globalSecret: []byte("1234567890123456789012345678901234567890"),
 			tokenEntropy: 32,
+			tokenLength:  43 + 1 + 43,
 		},
 		{
 			globalSecret: []byte("1234567890123456789012345678901234567890"),
 			tokenEntropy: 64,
+			tokenLength:  86 + 1 + 43,
 		},
 	} {
 		cg := HMACStrategy{
@@ -61,6 +101,7 @@ func TestGenerate(t *testing.T) {
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 		require.NotEmpty(t, signature)
+		assert.Len(t, token, c.tokenLength)
 		t.Logf("Token: %s\n Signature: %s", token, signature)
 
 		err = cg.Validate(token)
@@ -131,3 +172,81 @@ func TestValidateWithRotatedKeyInvalid(t *testing.T) {
 
 	require.EqualError(t, new(HMACStrategy).Validate(token), "a secret for signing HMAC-SHA512/256 is expected to be defined, but none were")
 }
+
+func TestGenerateWithPrefix(t *testing.T) {
+	cg := HMACStrategy{
+		GlobalSecret: []byte("1234567890123456789012345678901234567890"),
+		TokenPrefix:  "ory_at_",
+	}
+
+	token, signature, err := cg.Generate()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, "ory_at_"))
+	assert.Equal(t, signature, cg.Signature(token))
+	require.NoError(t, cg.Validate(token))
+
+	unprefixed := strings.TrimPrefix(token, "ory_at_")
+	require.EqualError(t, cg.Validate(unprefixed), fosite.ErrInvalidTokenFormat.Error())
+	require.EqualError(t, cg.ValidateWithPrefix("ory_rt_", token), fosite.ErrInvalidTokenFormat.Error())
+
+	refreshToken, refreshSignature, err := cg.GenerateWithPrefix("ory_rt_")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(refreshToken, "ory_rt_"))
+	assert.Equal(t, refreshSignature, cg.SignatureWithPrefix("ory_rt_", refreshToken))
+	require.NoError(t, cg.ValidateWithPrefix("ory_rt_", refreshToken))
+	require.Error(t, cg.Validate(refreshToken))
+
+	cg.AllowUnprefixedTokens = true
+	require.NoError(t, cg.Validate(unprefixed))
+	assert.Equal(t, signature, cg.Signature(unprefixed))
+	require.Error(t, cg.Validate(refreshToken))
+}
+
+func TestGenerateHMACForString(t *testing.T) {
+	cg := HMACStrategy{GlobalSecret: []byte("1234567890123456789012345678901234567890")}
+
+	first, err := cg.GenerateHMACForString("BCDFGHJK")
+	require.NoError(t, err)
+	second, err := cg.GenerateHMACForString("BCDFGHJK")
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	other, err := cg.GenerateHMACForString("BCDFGHJL")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+
+	cg.GlobalSecret = []byte("foo")
+	_, err = cg.GenerateHMACForString("BCDFGHJK")
+	require.Error(t, err)
+}
+
+func TestHash(t *testing.T) {
+	secret := []byte("1234567890123456789012345678901234567890")
+	for k, c := range []struct {
+		hash            crypto.Hash
+		signatureLength int
+	}{
+		{hash: 0, signatureLength: 43},
+		{hash: crypto.SHA512_256, signatureLength: 43},
+		{hash: crypto.SHA256, signatureLength: 43},
+		{hash: crypto.SHA512, signatureLength: 86},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			cg := HMACStrategy{GlobalSecret: secret, Hash: c.hash}
+			token, signature, err := cg.Generate()
+			require.NoError(t, err)
+			assert.Len(t, signature, c.signatureLength)
+			require.NoError(t, cg.Validate(token))
+
+			for _, other := range []crypto.Hash{crypto.SHA512_256, crypto.SHA256, crypto.SHA512} {
+				if other == c.hash || (c.hash == 0 && other == crypto.SHA512_256) {
+					continue
+				}
+				require.EqualError(t, (&HMACStrategy{GlobalSecret: secret, Hash: other}).Validate(token), fosite.ErrTokenSignatureMismatch.Error())
+			}
+		})
+	}
+
+	_, _, err := (&HMACStrategy{GlobalSecret: secret, Hash: crypto.MD4}).Generate()
+	require.EqualError(t, err, "hash function MD4 for signing HMAC tokens is not available")
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// IsSigningAlgorithmAllowed returns true if a token signed with alg may be verified given the allowlist of
+// algorithms. An empty allowlist allows all algorithms except "none", which is only allowed if it is listed
+// explicitly.
+func IsSigningAlgorithmAllowed(alg jose.SignatureAlgorithm, allowed []jose.SignatureAlgorithm) bool {
+	if len(allowed) == 0 {
+		return alg != SigningMethodNone
+	}
+
+	for _, a := range allowed {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// checkVerificationKey makes sure that the type of the verification key matches the family of the signing algorithm
+// of the token. This rules out algorithm confusion attacks such as accepting a HS256 token whose HMAC secret is the
+// public key of an RSA key pair. HMAC secrets which look like PEM encoded keys are rejected for the same reason.
+func checkVerificationKey(alg jose.SignatureAlgorithm, key interface{}) error {
+	if jwk, ok := key.(*jose.JSONWebKey); ok {
+		key = jwk.Key
+	}
+
+	var ok bool
+	switch alg {
+	case jose.HS256, jose.HS384, jose.HS512:
+		var secret []byte
+		secret, ok = key.([]byte)
+		ok = ok && !bytes.HasPrefix(bytes.TrimSpace(secret), []byte("-----BEGIN"))
+	case jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512:
+		_, ok = key.(*rsa.PublicKey)
+	case jose.ES256, jose.ES384, jose.ES512:
+		_, ok = key.(*ecdsa.PublicKey)
+	case jose.EdDSA:
+		_, ok = key.(ed25519.PublicKey)
+	default:
+		// Unknown algorithms are rejected when the signature is verified.
+		return nil
+	}
+
+	if !ok {
+		return &ValidationError{Errors: ValidationErrorSignatureInvalid, text: fmt.Sprintf("verification key of type %T can not be used with signing algorithm %s", key, alg)}
+	}
+	return nil
+}
@@ -42,6 +42,8 @@ type IDTokenClaims struct {
 	AuthenticationContextClassReference string
 	AuthenticationMethodsReferences     []string
 	CodeHash                            string
+	StateHash                           string
+	SessionID                           string
 	Extra                               map[string]interface{}
 }
 
@@ -109,6 +111,12 @@ func (c *IDTokenClaims) ToMap() map[string]interface{} {
 		delete(ret, "c_hash")
 	}
 
+	if len(c.StateHash) > 0 {
+		ret["s_hash"] = c.StateHash
+	} else {
+		delete(ret, "s_hash")
+	}
+
 	if !c.AuthTime.IsZero() {
 		ret["auth_time"] = c.AuthTime.Unix()
 	} else {
@@ -127,6 +135,12 @@ func (c *IDTokenClaims) ToMap() map[string]interface{} {
 		delete(ret, "amr")
 	}
 
+	if len(c.SessionID) > 0 {
+		ret["sid"] = c.SessionID
+	} else {
+		delete(ret, "sid")
+	}
+
 	return ret
 
 }
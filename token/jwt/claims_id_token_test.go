@@ -51,6 +51,7 @@ func TestIDTokenClaimsToMap(t *testing.T) {
 		RequestedAt:                         time.Now().UTC(),
 		AccessTokenHash:                     "foobar",
 		CodeHash:                            "barfoo",
+		StateHash:                           "bazbar",
 		AuthenticationContextClassReference: "acr",
 		AuthenticationMethodsReferences:     []string{"amr"},
 		Extra: map[string]interface{}{
@@ -70,6 +71,7 @@ func TestIDTokenClaimsToMap(t *testing.T) {
 		"baz":       idTokenClaims.Extra["baz"],
 		"at_hash":   idTokenClaims.AccessTokenHash,
 		"c_hash":    idTokenClaims.CodeHash,
+		"s_hash":    idTokenClaims.StateHash,
 		"auth_time": idTokenClaims.AuthTime.Unix(),
 		"acr":       idTokenClaims.AuthenticationContextClassReference,
 		"amr":       idTokenClaims.AuthenticationMethodsReferences,
@@ -88,6 +90,7 @@ func TestIDTokenClaimsToMap(t *testing.T) {
 		"baz":       idTokenClaims.Extra["baz"],
 		"at_hash":   idTokenClaims.AccessTokenHash,
 		"c_hash":    idTokenClaims.CodeHash,
+		"s_hash":    idTokenClaims.StateHash,
 		"auth_time": idTokenClaims.AuthTime.Unix(),
 		"acr":       idTokenClaims.AuthenticationContextClassReference,
 		"amr":       idTokenClaims.AuthenticationMethodsReferences,
@@ -72,6 +72,9 @@ type JWTClaims struct {
 	Scope      []string
 	Extra      map[string]interface{}
 	ScopeField JWTScopeFieldEnum
+
+	// AuthTime is the time when the end-user authenticated.
+	AuthTime time.Time
 }
 
 func (c *JWTClaims) With(expiry time.Time, scope, audience []string) JWTClaimsContainer {
@@ -143,6 +146,12 @@ func (c *JWTClaims) ToMap() map[string]interface{} {
 		delete(ret, "exp")
 	}
 
+	if !c.AuthTime.IsZero() {
+		ret["auth_time"] = c.AuthTime.Unix()
+	} else {
+		delete(ret, "auth_time")
+	}
+
 	if c.Scope != nil {
 		// ScopeField default (when value is JWTScopeFieldUnset) is the list for backwards compatibility with old versions of fosite.
 		if c.ScopeField == JWTScopeFieldUnset || c.ScopeField == JWTScopeFieldList || c.ScopeField == JWTScopeFieldBoth {
@@ -188,6 +197,8 @@ func (c *JWTClaims) FromMap(m map[string]interface{}) {
 			c.NotBefore = toTime(v, c.NotBefore)
 		case "exp":
 			c.ExpiresAt = toTime(v, c.ExpiresAt)
+		case "auth_time":
+			c.AuthTime = toTime(v, c.AuthTime)
 		case "scp":
 			switch s := v.(type) {
 			case []string:
@@ -0,0 +1,126 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// SigningKey is a private key which signs tokens.
+type SigningKey struct {
+	// KeyID is the "kid" of the key. Defaults to the JWK thumbprint of the public key as defined in
+	// https://tools.ietf.org/html/rfc7638.
+	KeyID string
+
+	// Algorithm is the JWS algorithm the key signs tokens with. Defaults to RS256 for RSA keys, to ES256, ES384 or
+	// ES512 for ECDSA keys depending on the curve and to EdDSA for Ed25519 keys.
+	Algorithm jose.SignatureAlgorithm
+
+	// Key is a *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey.
+	Key crypto.Signer
+
+	// Active marks the key currently used to sign tokens. The active key is listed first in the JSON Web Key Set.
+	Active bool
+}
+
+// PublicJWKSet returns the JSON Web Key Set containing the public keys of the signing keys, which is typically served
+// at /.well-known/jwks.json. Each key contains its "kid", "alg" and "use" set to "sig". The active key is listed first
+// and the other keys, which are still accepted during a key rotation, follow in the given order.
+func PublicJWKSet(keys ...SigningKey) (*jose.JSONWebKeySet, error) {
+	set := &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	var inactive []jose.JSONWebKey
+	for _, key := range keys {
+		jwk, err := PublicJWK(key)
+		if err != nil {
+			return nil, err
+		}
+		if key.Active {
+			set.Keys = append(set.Keys, jwk)
+		} else {
+			inactive = append(inactive, jwk)
+		}
+	}
+	set.Keys = append(set.Keys, inactive...)
+	return set, nil
+}
+
+// PublicJWK returns the JSON Web Key containing the public key of the signing key.
+func PublicJWK(key SigningKey) (jose.JSONWebKey, error) {
+	if key.Key == nil {
+		return jose.JSONWebKey{}, errors.New("the signing key must not be nil")
+	}
+
+	alg := key.Algorithm
+	if alg == "" {
+		var err error
+		if alg, err = defaultSigningAlgorithm(key.Key); err != nil {
+			return jose.JSONWebKey{}, err
+		}
+	}
+
+	jwk := jose.JSONWebKey{
+		Key:       key.Key.Public(),
+		KeyID:     key.KeyID,
+		Algorithm: string(alg),
+		Use:       "sig",
+	}
+	if !jwk.Valid() {
+		return jose.JSONWebKey{}, errors.Errorf("unsupported signing key type %T", key.Key)
+	}
+
+	if jwk.KeyID == "" {
+		thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return jose.JSONWebKey{}, errors.WithStack(err)
+		}
+		jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+	}
+	return jwk, nil
+}
+
+func defaultSigningAlgorithm(key crypto.Signer) (jose.SignatureAlgorithm, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jose.RS256, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jose.ES256, nil
+		case elliptic.P384():
+			return jose.ES384, nil
+		case elliptic.P521():
+			return jose.ES512, nil
+		}
+		return "", errors.Errorf("unsupported elliptic curve %s", k.Curve.Params().Name)
+	case ed25519.PrivateKey:
+		return jose.EdDSA, nil
+	}
+	return "", errors.Errorf("unsupported signing key type %T", key)
+}
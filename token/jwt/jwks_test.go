@@ -0,0 +1,80 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"crypto"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func TestPublicJWKSet(t *testing.T) {
+	rsaKey := MustRSAKey()
+	ecdsaKey := MustECDSAKey()
+	ed25519Key := MustEd25519Key()
+
+	set, err := PublicJWKSet(
+		SigningKey{KeyID: "rsa", Key: rsaKey},
+		SigningKey{KeyID: "ecdsa", Key: ecdsaKey, Active: true},
+		SigningKey{Key: ed25519Key},
+		SigningKey{KeyID: "pss", Key: rsaKey, Algorithm: jose.PS256},
+	)
+	require.NoError(t, err)
+
+	// The JSON Web Key Set must round-trip to the original public keys.
+	raw, err := json.Marshal(set)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), `"d"`)
+
+	var decoded jose.JSONWebKeySet
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	require.Len(t, decoded.Keys, 4)
+
+	for k, c := range []struct {
+		kid string
+		alg string
+		key crypto.PublicKey
+	}{
+		{kid: "ecdsa", alg: "ES256", key: &ecdsaKey.PublicKey},
+		{kid: "rsa", alg: "RS256", key: &rsaKey.PublicKey},
+		{alg: "EdDSA", key: ed25519Key.Public()},
+		{kid: "pss", alg: "PS256", key: &rsaKey.PublicKey},
+	} {
+		jwk := decoded.Keys[k]
+		if c.kid != "" {
+			assert.Equal(t, c.kid, jwk.KeyID, "%d", k)
+		} else {
+			assert.NotEmpty(t, jwk.KeyID, "%d", k)
+		}
+		assert.Equal(t, c.alg, jwk.Algorithm, "%d", k)
+		assert.Equal(t, "sig", jwk.Use, "%d", k)
+		assert.True(t, jwk.IsPublic(), "%d", k)
+		assert.Equal(t, c.key, jwk.Key, "%d", k)
+	}
+
+	_, err = PublicJWKSet(SigningKey{})
+	assert.Error(t, err)
+}
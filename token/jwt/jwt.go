@@ -28,9 +28,14 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
 	"strings"
+	"time"
 
 	"github.com/ory/x/errorsx"
 	"gopkg.in/square/go-jose.v2"
@@ -47,11 +52,28 @@ type JWTStrategy interface {
 	GetSigningMethodLength() int
 }
 
+// SigningAlgorithmProvider is implemented by JWT strategies which sign tokens using a fixed algorithm.
+type SigningAlgorithmProvider interface {
+	// GetSigningAlgorithm returns the JWS alg algorithm the strategy signs tokens with.
+	GetSigningAlgorithm() string
+}
+
 var SHA256HashSize = crypto.SHA256.Size()
 
-// RS256JWTStrategy is responsible for generating and validating JWT challenges
+var SHA384HashSize = crypto.SHA384.Size()
+
+var SHA512HashSize = crypto.SHA512.Size()
+
+// RS256JWTStrategy is responsible for generating and validating JWT challenges. It only accepts tokens signed with RS256,
+// so tokens signed with another algorithm, such as HS256 using the public key as HMAC secret, are rejected.
 type RS256JWTStrategy struct {
 	PrivateKey *rsa.PrivateKey
+
+	// ClockSkew is tolerated when Validate and Decode check the time based claims. Defaults to zero.
+	ClockSkew time.Duration
+
+	// AllowedAlgorithms restricts the algorithms Validate and Decode accept, see IsSigningAlgorithmAllowed.
+	AllowedAlgorithms []jose.SignatureAlgorithm
 }
 
 // Generate generates a new authorize code or returns an error. set secret
@@ -61,12 +83,12 @@ func (j *RS256JWTStrategy) Generate(ctx context.Context, claims MapClaims, heade
 
 // Validate validates a token and returns its signature or an error if the token is not valid.
 func (j *RS256JWTStrategy) Validate(ctx context.Context, token string) (string, error) {
-	return validateToken(token, &j.PrivateKey.PublicKey)
+	return validateToken(token, &j.PrivateKey.PublicKey, jose.RS256, j.ClockSkew, j.AllowedAlgorithms)
 }
 
 // Decode will decode a JWT token
 func (j *RS256JWTStrategy) Decode(ctx context.Context, token string) (*Token, error) {
-	return decodeToken(token, &j.PrivateKey.PublicKey)
+	return decodeToken(token, &j.PrivateKey.PublicKey, jose.RS256, j.ClockSkew, j.AllowedAlgorithms)
 }
 
 // GetSignature will return the signature of a token
@@ -84,9 +106,20 @@ func (j *RS256JWTStrategy) GetSigningMethodLength() int {
 	return SHA256HashSize
 }
 
+// GetSigningAlgorithm returns the JWS alg algorithm the strategy signs tokens with
+func (j *RS256JWTStrategy) GetSigningAlgorithm() string {
+	return string(jose.RS256)
+}
+
 // ES256JWTStrategy is responsible for generating and validating JWT challenges
 type ES256JWTStrategy struct {
 	PrivateKey *ecdsa.PrivateKey
+
+	// ClockSkew is tolerated when Validate and Decode check the time based claims. Defaults to zero.
+	ClockSkew time.Duration
+
+	// AllowedAlgorithms restricts the algorithms Validate and Decode accept, see IsSigningAlgorithmAllowed.
+	AllowedAlgorithms []jose.SignatureAlgorithm
 }
 
 // Generate generates a new authorize code or returns an error. set secret
@@ -96,12 +129,12 @@ func (j *ES256JWTStrategy) Generate(ctx context.Context, claims MapClaims, heade
 
 // Validate validates a token and returns its signature or an error if the token is not valid.
 func (j *ES256JWTStrategy) Validate(ctx context.Context, token string) (string, error) {
-	return validateToken(token, &j.PrivateKey.PublicKey)
+	return validateToken(token, &j.PrivateKey.PublicKey, jose.ES256, j.ClockSkew, j.AllowedAlgorithms)
 }
 
 // Decode will decode a JWT token
 func (j *ES256JWTStrategy) Decode(ctx context.Context, token string) (*Token, error) {
-	return decodeToken(token, &j.PrivateKey.PublicKey)
+	return decodeToken(token, &j.PrivateKey.PublicKey, jose.ES256, j.ClockSkew, j.AllowedAlgorithms)
 }
 
 // GetSignature will return the signature of a token
@@ -119,6 +152,126 @@ func (j *ES256JWTStrategy) GetSigningMethodLength() int {
 	return SHA256HashSize
 }
 
+// GetSigningAlgorithm returns the JWS alg algorithm the strategy signs tokens with
+func (j *ES256JWTStrategy) GetSigningAlgorithm() string {
+	return string(jose.ES256)
+}
+
+// Ed25519JWTStrategy is responsible for generating and validating JWT challenges using EdDSA with Ed25519 keys
+type Ed25519JWTStrategy struct {
+	PrivateKey ed25519.PrivateKey
+
+	// ClockSkew is tolerated when Validate and Decode check the time based claims. Defaults to zero.
+	ClockSkew time.Duration
+
+	// AllowedAlgorithms restricts the algorithms Validate and Decode accept, see IsSigningAlgorithmAllowed.
+	AllowedAlgorithms []jose.SignatureAlgorithm
+}
+
+// Generate generates a new authorize code or returns an error. set secret
+func (j *Ed25519JWTStrategy) Generate(ctx context.Context, claims MapClaims, header Mapper) (string, string, error) {
+	return generateToken(claims, header, jose.EdDSA, j.PrivateKey)
+}
+
+// Validate validates a token and returns its signature or an error if the token is not valid.
+func (j *Ed25519JWTStrategy) Validate(ctx context.Context, token string) (string, error) {
+	return validateToken(token, j.PrivateKey.Public(), jose.EdDSA, j.ClockSkew, j.AllowedAlgorithms)
+}
+
+// Decode will decode a JWT token
+func (j *Ed25519JWTStrategy) Decode(ctx context.Context, token string) (*Token, error) {
+	return decodeToken(token, j.PrivateKey.Public(), jose.EdDSA, j.ClockSkew, j.AllowedAlgorithms)
+}
+
+// GetSignature will return the signature of a token
+func (j *Ed25519JWTStrategy) GetSignature(ctx context.Context, token string) (string, error) {
+	return getTokenSignature(token)
+}
+
+// Hash will return a given hash based on the byte input or an error upon fail. Ed25519 uses SHA-512 internally,
+// which is why it is used for hashes like at_hash as well.
+func (j *Ed25519JWTStrategy) Hash(ctx context.Context, in []byte) ([]byte, error) {
+	return hashSHA512(in)
+}
+
+// GetSigningMethodLength will return the length of the signing method
+func (j *Ed25519JWTStrategy) GetSigningMethodLength() int {
+	return SHA512HashSize
+}
+
+// GetSigningAlgorithm returns the JWS alg algorithm the strategy signs tokens with
+func (j *Ed25519JWTStrategy) GetSigningAlgorithm() string {
+	return string(jose.EdDSA)
+}
+
+// RSAPSSJWTStrategy is responsible for generating and validating JWT challenges using RSA-PSS
+type RSAPSSJWTStrategy struct {
+	PrivateKey *rsa.PrivateKey
+
+	// Algorithm is one of PS256, PS384 and PS512. Defaults to PS256.
+	Algorithm jose.SignatureAlgorithm
+
+	// ClockSkew is tolerated when Validate and Decode check the time based claims. Defaults to zero.
+	ClockSkew time.Duration
+
+	// AllowedAlgorithms restricts the algorithms Validate and Decode accept, see IsSigningAlgorithmAllowed.
+	AllowedAlgorithms []jose.SignatureAlgorithm
+}
+
+func (j *RSAPSSJWTStrategy) getAlgorithm() jose.SignatureAlgorithm {
+	if j.Algorithm == "" {
+		return jose.PS256
+	}
+	return j.Algorithm
+}
+
+// Generate generates a new authorize code or returns an error. set secret
+func (j *RSAPSSJWTStrategy) Generate(ctx context.Context, claims MapClaims, header Mapper) (string, string, error) {
+	return generateToken(claims, header, j.getAlgorithm(), j.PrivateKey)
+}
+
+// Validate validates a token and returns its signature or an error if the token is not valid.
+func (j *RSAPSSJWTStrategy) Validate(ctx context.Context, token string) (string, error) {
+	return validateToken(token, &j.PrivateKey.PublicKey, j.getAlgorithm(), j.ClockSkew, j.AllowedAlgorithms)
+}
+
+// Decode will decode a JWT token
+func (j *RSAPSSJWTStrategy) Decode(ctx context.Context, token string) (*Token, error) {
+	return decodeToken(token, &j.PrivateKey.PublicKey, j.getAlgorithm(), j.ClockSkew, j.AllowedAlgorithms)
+}
+
+// GetSignature will return the signature of a token
+func (j *RSAPSSJWTStrategy) GetSignature(ctx context.Context, token string) (string, error) {
+	return getTokenSignature(token)
+}
+
+// Hash will return a given hash based on the byte input or an error upon fail
+func (j *RSAPSSJWTStrategy) Hash(ctx context.Context, in []byte) ([]byte, error) {
+	switch j.getAlgorithm() {
+	case jose.PS384:
+		return hashWith(sha512.New384(), in)
+	case jose.PS512:
+		return hashSHA512(in)
+	}
+	return hashSHA256(in)
+}
+
+// GetSigningMethodLength will return the length of the signing method
+func (j *RSAPSSJWTStrategy) GetSigningMethodLength() int {
+	switch j.getAlgorithm() {
+	case jose.PS384:
+		return SHA384HashSize
+	case jose.PS512:
+		return SHA512HashSize
+	}
+	return SHA256HashSize
+}
+
+// GetSigningAlgorithm returns the JWS alg algorithm the strategy signs tokens with
+func (j *RSAPSSJWTStrategy) GetSigningAlgorithm() string {
+	return string(j.getAlgorithm())
+}
+
 func generateToken(claims MapClaims, header Mapper, signingMethod jose.SignatureAlgorithm, privateKey interface{}) (rawToken string, sig string, err error) {
 	if header == nil || claims == nil {
 		err = errors.New("Either claims or header is nil.")
@@ -137,13 +290,23 @@ func generateToken(claims MapClaims, header Mapper, signingMethod jose.Signature
 	return
 }
 
-func decodeToken(token string, verificationKey interface{}) (*Token, error) {
-	keyFunc := func(*Token) (interface{}, error) { return verificationKey, nil }
-	return ParseWithClaims(token, MapClaims{}, keyFunc)
+func decodeToken(token string, verificationKey interface{}, alg jose.SignatureAlgorithm, skew time.Duration, allowed []jose.SignatureAlgorithm) (*Token, error) {
+	keyFunc := func(t *Token) (interface{}, error) {
+		if !IsSigningAlgorithmAllowed(t.Method, allowed) {
+			return nil, &ValidationError{Errors: ValidationErrorUnverifiable, text: fmt.Sprintf("signing algorithm %s is not allowed", t.Method)}
+		}
+		// The same key may be used with different algorithms, for example RS256 and PS256, which is why the
+		// algorithm has to be checked as well.
+		if t.Method != alg {
+			return nil, &ValidationError{Errors: ValidationErrorUnverifiable, text: fmt.Sprintf("expected token to be signed using %s but got %s", alg, t.Method)}
+		}
+		return verificationKey, nil
+	}
+	return ParseWithClaimsAndClockSkew(token, MapClaims{}, skew, keyFunc)
 }
 
-func validateToken(tokenStr string, verificationKey interface{}) (string, error) {
-	_, err := decodeToken(tokenStr, verificationKey)
+func validateToken(tokenStr string, verificationKey interface{}, alg jose.SignatureAlgorithm, skew time.Duration, allowed []jose.SignatureAlgorithm) (string, error) {
+	_, err := decodeToken(tokenStr, verificationKey, alg, skew, allowed)
 	if err != nil {
 		return "", err
 	}
@@ -159,12 +322,19 @@ func getTokenSignature(token string) (string, error) {
 }
 
 func hashSHA256(in []byte) ([]byte, error) {
-	hash := sha256.New()
-	_, err := hash.Write(in)
+	return hashWith(sha256.New(), in)
+}
+
+func hashSHA512(in []byte) ([]byte, error) {
+	return hashWith(sha512.New(), in)
+}
+
+func hashWith(h hash.Hash, in []byte) ([]byte, error) {
+	_, err := h.Write(in)
 	if err != nil {
 		return []byte{}, errorsx.WithStack(err)
 	}
-	return hash.Sum([]byte{}), nil
+	return h.Sum([]byte{}), nil
 }
 
 func assign(a, b map[string]interface{}) map[string]interface{} {
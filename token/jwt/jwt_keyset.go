@@ -0,0 +1,243 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha512"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// KeySetJWTStrategy signs tokens with the active key of a set of keys identified by their "kid", which makes it
+// possible to rotate signing keys. Tokens are validated against any key of the set, selected by the "kid" header of
+// the token. Tokens without a "kid" header are validated against the active key.
+//
+// Keys can be added and activated while the strategy is in use. To rotate keys, add the new key, publish it using
+// PublicJWKSet(strategy.SigningKeys()...) and activate it once relying parties had the chance to fetch it. The
+// previous key should be removed only after all tokens signed by it have expired.
+type KeySetJWTStrategy struct {
+	// ClockSkew is tolerated when Validate and Decode check the time based claims. Defaults to zero.
+	ClockSkew time.Duration
+
+	// AllowedAlgorithms restricts the algorithms Validate and Decode accept, see IsSigningAlgorithmAllowed.
+	AllowedAlgorithms []jose.SignatureAlgorithm
+
+	mu     sync.RWMutex
+	keys   []SigningKey
+	active string
+}
+
+// AddKey adds a *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey to the key set. The JWS algorithm is derived
+// from the key type, see SigningKey. The first key added becomes the active key.
+func (j *KeySetJWTStrategy) AddKey(kid string, key crypto.Signer) error {
+	return j.AddSigningKey(SigningKey{KeyID: kid, Key: key})
+}
+
+// AddSigningKey adds a signing key to the key set, which makes it possible to choose the JWS algorithm of the key. The
+// first key added becomes the active key, unless another key is marked as active.
+func (j *KeySetJWTStrategy) AddSigningKey(key SigningKey) error {
+	if key.KeyID == "" {
+		return errors.New("the key id must not be empty")
+	}
+	if key.Key == nil {
+		return errors.New("the signing key must not be nil")
+	}
+	if key.Algorithm == "" {
+		alg, err := defaultSigningAlgorithm(key.Key)
+		if err != nil {
+			return err
+		}
+		key.Algorithm = alg
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, k := range j.keys {
+		if k.KeyID == key.KeyID {
+			return errors.Errorf("a key with id %s already exists", key.KeyID)
+		}
+	}
+	j.keys = append(j.keys, key)
+	if j.active == "" || key.Active {
+		j.active = key.KeyID
+	}
+	return nil
+}
+
+// SetActiveKey makes the key with the given kid the key new tokens are signed with.
+func (j *KeySetJWTStrategy) SetActiveKey(kid string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, ok := j.findKey(kid); !ok {
+		return errors.Errorf("a key with id %s does not exist", kid)
+	}
+	j.active = kid
+	return nil
+}
+
+// RemoveKey removes the key with the given kid from the key set. Tokens signed by it are not valid anymore. The active
+// key can not be removed.
+func (j *KeySetJWTStrategy) RemoveKey(kid string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if kid == j.active {
+		return errors.Errorf("the active key %s can not be removed", kid)
+	}
+	for i, k := range j.keys {
+		if k.KeyID == kid {
+			j.keys = append(j.keys[:i:i], j.keys[i+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("a key with id %s does not exist", kid)
+}
+
+// SigningKeys returns all keys of the key set with the active key marked as such.
+func (j *KeySetJWTStrategy) SigningKeys() []SigningKey {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	keys := make([]SigningKey, len(j.keys))
+	for i, k := range j.keys {
+		k.Active = k.KeyID == j.active
+		keys[i] = k
+	}
+	return keys
+}
+
+func (j *KeySetJWTStrategy) findKey(kid string) (SigningKey, bool) {
+	for _, k := range j.keys {
+		if k.KeyID == kid {
+			return k, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func (j *KeySetJWTStrategy) activeKey() (SigningKey, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	key, ok := j.findKey(j.active)
+	if !ok {
+		return SigningKey{}, errors.New("the key set does not contain any keys")
+	}
+	return key, nil
+}
+
+// Generate signs the token with the active key and sets its "kid" header.
+func (j *KeySetJWTStrategy) Generate(ctx context.Context, claims MapClaims, header Mapper) (string, string, error) {
+	key, err := j.activeKey()
+	if err != nil {
+		return "", "", err
+	}
+	headers := NewHeaders()
+	if header != nil {
+		for k, v := range header.ToMap() {
+			headers.Add(k, v)
+		}
+	}
+	headers.Add("kid", key.KeyID)
+	return generateToken(claims, headers, key.Algorithm, key.Key)
+}
+
+// Validate validates a token and returns its signature or an error if the token is not valid.
+func (j *KeySetJWTStrategy) Validate(ctx context.Context, token string) (string, error) {
+	if _, err := j.Decode(ctx, token); err != nil {
+		return "", err
+	}
+	return getTokenSignature(token)
+}
+
+// Decode will decode a JWT token and verify it using the key identified by its "kid" header
+func (j *KeySetJWTStrategy) Decode(ctx context.Context, token string) (*Token, error) {
+	return ParseWithClaimsAndClockSkew(token, MapClaims{}, j.ClockSkew, func(t *Token) (interface{}, error) {
+		if !IsSigningAlgorithmAllowed(t.Method, j.AllowedAlgorithms) {
+			return nil, &ValidationError{Errors: ValidationErrorUnverifiable, text: fmt.Sprintf("signing algorithm %s is not allowed", t.Method)}
+		}
+
+		kid, _ := t.Header["kid"].(string)
+
+		j.mu.RLock()
+		if kid == "" {
+			kid = j.active
+		}
+		key, ok := j.findKey(kid)
+		j.mu.RUnlock()
+
+		if !ok {
+			return nil, &ValidationError{Errors: ValidationErrorUnverifiable, text: fmt.Sprintf("unknown key id %s", kid)}
+		}
+		if t.Method != key.Algorithm {
+			return nil, &ValidationError{Errors: ValidationErrorUnverifiable, text: fmt.Sprintf("expected token to be signed using %s but got %s", key.Algorithm, t.Method)}
+		}
+		return key.Key.Public(), nil
+	})
+}
+
+// GetSignature will return the signature of a token
+func (j *KeySetJWTStrategy) GetSignature(ctx context.Context, token string) (string, error) {
+	return getTokenSignature(token)
+}
+
+// Hash will return a given hash based on the byte input or an error upon fail. The hash function is the one of the
+// algorithm of the active key.
+func (j *KeySetJWTStrategy) Hash(ctx context.Context, in []byte) ([]byte, error) {
+	switch j.GetSigningMethodLength() {
+	case SHA384HashSize:
+		return hashWith(sha512.New384(), in)
+	case SHA512HashSize:
+		return hashSHA512(in)
+	}
+	return hashSHA256(in)
+}
+
+// GetSigningMethodLength will return the length of the signing method of the active key
+func (j *KeySetJWTStrategy) GetSigningMethodLength() int {
+	alg := j.GetSigningAlgorithm()
+	switch {
+	case alg == string(jose.EdDSA), strings.HasSuffix(alg, "512"):
+		return SHA512HashSize
+	case strings.HasSuffix(alg, "384"):
+		return SHA384HashSize
+	}
+	return SHA256HashSize
+}
+
+// GetSigningAlgorithm returns the JWS alg algorithm the active key signs tokens with
+func (j *KeySetJWTStrategy) GetSigningAlgorithm() string {
+	key, err := j.activeKey()
+	if err != nil {
+		return ""
+	}
+	return string(key.Algorithm)
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySetJWTStrategy(t *testing.T) {
+	ctx := context.Background()
+	strategy := new(KeySetJWTStrategy)
+
+	_, _, err := strategy.Generate(ctx, MapClaims{"sub": "foo"}, NewHeaders())
+	require.Error(t, err, "an empty key set can not sign tokens")
+
+	require.NoError(t, strategy.AddKey("first", MustRSAKey()))
+	require.NoError(t, strategy.AddKey("second", MustECDSAKey()))
+	require.Error(t, strategy.AddKey("second", MustEd25519Key()))
+	require.Error(t, strategy.SetActiveKey("unknown"))
+	assert.Equal(t, "RS256", strategy.GetSigningAlgorithm())
+
+	first, _, err := strategy.Generate(ctx, MapClaims{"sub": "foo"}, &Headers{Extra: map[string]interface{}{"kid": "ignored"}})
+	require.NoError(t, err)
+	decoded, err := strategy.Decode(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, "first", decoded.Header["kid"])
+	assert.Equal(t, "RS256", decoded.Header["alg"])
+
+	require.NoError(t, strategy.SetActiveKey("second"))
+	assert.Equal(t, "ES256", strategy.GetSigningAlgorithm())
+
+	second, _, err := strategy.Generate(ctx, MapClaims{"sub": "foo"}, NewHeaders())
+	require.NoError(t, err)
+	decoded, err = strategy.Decode(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, "second", decoded.Header["kid"])
+	assert.Equal(t, "ES256", decoded.Header["alg"])
+
+	withoutHeader, _, err := strategy.Generate(ctx, MapClaims{"sub": "foo"}, nil)
+	require.NoError(t, err)
+	decoded, err = strategy.Decode(ctx, withoutHeader)
+	require.NoError(t, err)
+	assert.Equal(t, "second", decoded.Header["kid"])
+
+	// Tokens signed by the previously active key remain valid.
+	_, err = strategy.Validate(ctx, first)
+	require.NoError(t, err)
+
+	set, err := PublicJWKSet(strategy.SigningKeys()...)
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "second", set.Keys[0].KeyID)
+	assert.Equal(t, "first", set.Keys[1].KeyID)
+
+	require.Error(t, strategy.RemoveKey("second"), "the active key can not be removed")
+	require.NoError(t, strategy.RemoveKey("first"))
+	_, err = strategy.Validate(ctx, first)
+	require.Error(t, err)
+	_, err = strategy.Validate(ctx, second)
+	require.NoError(t, err)
+
+	// Tokens signed by keys outside of the key set are rejected, even if they claim a known kid.
+	other := &ES256JWTStrategy{PrivateKey: MustECDSAKey()}
+	forged, _, err := other.Generate(ctx, MapClaims{"sub": "foo"}, &Headers{Extra: map[string]interface{}{"kid": "second"}})
+	require.NoError(t, err)
+	_, err = strategy.Validate(ctx, forged)
+	require.Error(t, err)
+}
@@ -23,6 +23,8 @@ package jwt
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"strings"
 	"testing"
@@ -30,6 +32,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
 )
 
 var header = &Headers{
@@ -55,6 +58,19 @@ func TestHash(t *testing.T) {
 				PrivateKey: MustECDSAKey(),
 			},
 		},
+		{
+			d: "Ed25519JWTStrategy",
+			strategy: &Ed25519JWTStrategy{
+				PrivateKey: MustEd25519Key(),
+			},
+		},
+		{
+			d: "RSAPSSJWTStrategy",
+			strategy: &RSAPSSJWTStrategy{
+				PrivateKey: MustRSAKey(),
+				Algorithm:  jose.PS384,
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d/strategy=%s", k, tc.d), func(t *testing.T) {
 			in := []byte("foo")
@@ -116,6 +132,24 @@ func TestGenerateJWT(t *testing.T) {
 				strategy.(*ES256JWTStrategy).PrivateKey = MustECDSAKey()
 			},
 		},
+		{
+			d: "Ed25519JWTStrategy",
+			strategy: &Ed25519JWTStrategy{
+				PrivateKey: MustEd25519Key(),
+			},
+			resetKey: func(strategy JWTStrategy) {
+				strategy.(*Ed25519JWTStrategy).PrivateKey = MustEd25519Key()
+			},
+		},
+		{
+			d: "RSAPSSJWTStrategy",
+			strategy: &RSAPSSJWTStrategy{
+				PrivateKey: MustRSAKey(),
+			},
+			resetKey: func(strategy JWTStrategy) {
+				strategy.(*RSAPSSJWTStrategy).PrivateKey = MustRSAKey()
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d/strategy=%s", k, tc.d), func(t *testing.T) {
 			claims := &JWTClaims{
@@ -183,6 +217,19 @@ func TestValidateSignatureRejectsJWT(t *testing.T) {
 				PrivateKey: MustECDSAKey(),
 			},
 		},
+		{
+			d: "Ed25519JWTStrategy",
+			strategy: &Ed25519JWTStrategy{
+				PrivateKey: MustEd25519Key(),
+			},
+		},
+		{
+			d: "RSAPSSJWTStrategy",
+			strategy: &RSAPSSJWTStrategy{
+				PrivateKey: MustRSAKey(),
+				Algorithm:  jose.PS384,
+			},
+		},
 	} {
 		t.Run(fmt.Sprintf("case=%d/strategy=%s", k, tc.d), func(t *testing.T) {
 			for k, c := range []string{
@@ -199,3 +246,128 @@ func TestValidateSignatureRejectsJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateRejectsOtherAlgorithmWithSameKey(t *testing.T) {
+	key := MustRSAKey()
+	rs256 := &RS256JWTStrategy{PrivateKey: key}
+	ps256 := &RSAPSSJWTStrategy{PrivateKey: key}
+	ps384 := &RSAPSSJWTStrategy{PrivateKey: key, Algorithm: jose.PS384}
+
+	claims := &JWTClaims{ExpiresAt: time.Now().UTC().Add(time.Hour)}
+	for k, tc := range []struct {
+		generator JWTStrategy
+		validator JWTStrategy
+		valid     bool
+	}{
+		{generator: rs256, validator: rs256, valid: true},
+		{generator: ps256, validator: ps256, valid: true},
+		{generator: ps384, validator: ps384, valid: true},
+		{generator: rs256, validator: ps256},
+		{generator: ps256, validator: rs256},
+		{generator: ps256, validator: ps384},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			token, _, err := tc.generator.Generate(context.TODO(), claims.ToMapClaims(), header)
+			require.NoError(t, err)
+
+			_, err = tc.validator.Validate(context.TODO(), token)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateWithClockSkew(t *testing.T) {
+	key := MustRSAKey()
+	strict := &RS256JWTStrategy{PrivateKey: key}
+	tolerant := &RS256JWTStrategy{PrivateKey: key, ClockSkew: time.Minute}
+
+	for k, claims := range []*JWTClaims{
+		{ExpiresAt: time.Now().UTC().Add(-30 * time.Second)},
+		{ExpiresAt: time.Now().UTC().Add(time.Hour), IssuedAt: time.Now().UTC().Add(30 * time.Second)},
+		{ExpiresAt: time.Now().UTC().Add(time.Hour), NotBefore: time.Now().UTC().Add(30 * time.Second)},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			token, _, err := strict.Generate(context.TODO(), claims.ToMapClaims(), header)
+			require.NoError(t, err)
+
+			_, err = strict.Validate(context.TODO(), token)
+			require.Error(t, err)
+
+			_, err = tolerant.Validate(context.TODO(), token)
+			require.NoError(t, err)
+			_, err = tolerant.Decode(context.TODO(), token)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateRejectsDisallowedAlgorithm(t *testing.T) {
+	key := MustRSAKey()
+	keySet := &KeySetJWTStrategy{AllowedAlgorithms: []jose.SignatureAlgorithm{jose.ES256}}
+	require.NoError(t, keySet.AddKey("rsa", key))
+
+	claims := &JWTClaims{ExpiresAt: time.Now().UTC().Add(time.Hour)}
+	for k, tc := range []struct {
+		generator JWTStrategy
+		validator JWTStrategy
+		valid     bool
+	}{
+		{generator: &RS256JWTStrategy{PrivateKey: key}, validator: &RS256JWTStrategy{PrivateKey: key, AllowedAlgorithms: []jose.SignatureAlgorithm{jose.RS256}}, valid: true},
+		{generator: &RS256JWTStrategy{PrivateKey: key}, validator: &RS256JWTStrategy{PrivateKey: key, AllowedAlgorithms: []jose.SignatureAlgorithm{jose.PS256}}},
+		{generator: &RSAPSSJWTStrategy{PrivateKey: key}, validator: &RSAPSSJWTStrategy{PrivateKey: key, AllowedAlgorithms: []jose.SignatureAlgorithm{jose.RS256}}},
+		{generator: keySet, validator: keySet},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			token, _, err := tc.generator.Generate(context.TODO(), claims.ToMapClaims(), header)
+			require.NoError(t, err)
+
+			_, err = tc.validator.Validate(context.TODO(), token)
+			if tc.valid {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "is not allowed")
+		})
+	}
+}
+
+func TestIsSigningAlgorithmAllowed(t *testing.T) {
+	assert.True(t, IsSigningAlgorithmAllowed(jose.RS256, nil))
+	assert.False(t, IsSigningAlgorithmAllowed(SigningMethodNone, nil))
+	assert.True(t, IsSigningAlgorithmAllowed(SigningMethodNone, []jose.SignatureAlgorithm{SigningMethodNone}))
+	assert.True(t, IsSigningAlgorithmAllowed(jose.ES256, []jose.SignatureAlgorithm{jose.RS256, jose.ES256}))
+	assert.False(t, IsSigningAlgorithmAllowed(jose.HS256, []jose.SignatureAlgorithm{jose.RS256, jose.ES256}))
+}
+
+func TestValidateRejectsHMACSignedWithRSAPublicKey(t *testing.T) {
+	key := MustRSAKey()
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	// The attacker signs the token with HS256 using the public key of the server as HMAC secret.
+	claims := &JWTClaims{ExpiresAt: time.Now().UTC().Add(time.Hour)}
+	token, err := NewWithClaims(jose.HS256, claims.ToMapClaims()).SignedString(publicKeyPEM)
+	require.NoError(t, err)
+
+	_, err = (&RS256JWTStrategy{PrivateKey: key}).Validate(context.TODO(), token)
+	require.Error(t, err)
+
+	for k, verificationKey := range []interface{}{&key.PublicKey, key.PublicKey, publicKeyPEM, &jose.JSONWebKey{Key: &key.PublicKey}} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			_, err := Parse(token, func(*Token) (interface{}, error) {
+				return verificationKey, nil
+			})
+			require.Error(t, err)
+
+			var ve *ValidationError
+			require.ErrorAs(t, err, &ve)
+			assert.NotZero(t, ve.Errors&ValidationErrorSignatureInvalid)
+		})
+	}
+}
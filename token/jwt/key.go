@@ -0,0 +1,64 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// ParseEd25519PrivateKeyFromPEM parses a PEM encoded PKCS #8 Ed25519 private key.
+func ParseEd25519PrivateKeyFromPEM(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("unable to decode PEM block containing the private key")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("expected an Ed25519 private key but got %T", key)
+	}
+	return privateKey, nil
+}
+
+// ParseEd25519PrivateKeyFromJWK parses a JSON Web Key of type OKP containing an Ed25519 private key.
+func ParseEd25519PrivateKeyFromJWK(data []byte) (ed25519.PrivateKey, error) {
+	var key jose.JSONWebKey
+	if err := key.UnmarshalJSON(data); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	privateKey, ok := key.Key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("expected an Ed25519 private key but got %T", key.Key)
+	}
+	return privateKey, nil
+}
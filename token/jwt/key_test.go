@@ -25,9 +25,17 @@ package jwt
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
 )
 
 func MustRSAKey() *rsa.PrivateKey {
@@ -46,3 +54,46 @@ func MustECDSAKey() *ecdsa.PrivateKey {
 	}
 	return key
 }
+
+func MustEd25519Key() ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func TestParseEd25519PrivateKey(t *testing.T) {
+	key := MustEd25519Key()
+
+	t.Run("format=pem", func(t *testing.T) {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+
+		parsed, err := ParseEd25519PrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		require.NoError(t, err)
+		assert.Equal(t, key, parsed)
+
+		der, err = x509.MarshalPKCS8PrivateKey(MustECDSAKey())
+		require.NoError(t, err)
+		_, err = ParseEd25519PrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		require.Error(t, err)
+
+		_, err = ParseEd25519PrivateKeyFromPEM([]byte("foo"))
+		require.Error(t, err)
+	})
+
+	t.Run("format=jwk", func(t *testing.T) {
+		data, err := (&jose.JSONWebKey{Key: key, KeyID: "foo"}).MarshalJSON()
+		require.NoError(t, err)
+
+		parsed, err := ParseEd25519PrivateKeyFromJWK(data)
+		require.NoError(t, err)
+		assert.Equal(t, key, parsed)
+
+		data, err = (&jose.JSONWebKey{Key: key.Public(), KeyID: "foo"}).MarshalJSON()
+		require.NoError(t, err)
+		_, err = ParseEd25519PrivateKeyFromJWK(data)
+		require.Error(t, err)
+	})
+}
@@ -100,24 +100,31 @@ func (m MapClaims) toInt64(claim string) (int64, bool) {
 }
 
 // Validates time based claims "exp, iat, nbf".
-// There is no accounting for clock skew.
+// There is no accounting for clock skew, see ValidWithClockSkew.
 // As well, if any of the above claims are not in the token, it will still
 // be considered a valid claim.
 func (m MapClaims) Valid() error {
+	return m.ValidWithClockSkew(0)
+}
+
+// ValidWithClockSkew validates the time based claims "exp, iat, nbf" like Valid but tolerates clocks which differ by
+// up to skew: tokens are accepted for skew after they expired and skew before they are issued or become valid.
+func (m MapClaims) ValidWithClockSkew(skew time.Duration) error {
 	vErr := new(ValidationError)
 	now := TimeFunc().Unix()
+	leeway := int64(skew / time.Second)
 
-	if !m.VerifyExpiresAt(now, false) {
+	if !m.VerifyExpiresAt(now-leeway, false) {
 		vErr.Inner = errors.New("Token is expired")
 		vErr.Errors |= ValidationErrorExpired
 	}
 
-	if !m.VerifyIssuedAt(now, false) {
+	if !m.VerifyIssuedAt(now+leeway, false) {
 		vErr.Inner = errors.New("Token used before issued")
 		vErr.Errors |= ValidationErrorIssuedAt
 	}
 
-	if !m.VerifyNotBefore(now, false) {
+	if !m.VerifyNotBefore(now+leeway, false) {
 		vErr.Inner = errors.New("Token is not valid yet")
 		vErr.Errors |= ValidationErrorNotValidYet
 	}
@@ -1,6 +1,10 @@
 package jwt
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+	"time"
+)
 
 // Test taken from taken from [here](https://raw.githubusercontent.com/form3tech-oss/jwt-go/master/map_claims_test.go).
 func Test_mapClaims_list_aud(t *testing.T) {
@@ -93,3 +97,31 @@ func Test_mapClaims_string_aud_no_claim_not_required(t *testing.T) {
 		t.Fatalf("Failed to verify claims, wanted: %v got %v", want, got)
 	}
 }
+
+func Test_mapClaims_valid_with_clock_skew(t *testing.T) {
+	now := time.Now()
+	for k, tc := range []struct {
+		claims MapClaims
+		skew   time.Duration
+		valid  bool
+	}{
+		{claims: MapClaims{"exp": now.Add(-30 * time.Second).Unix()}, skew: 0, valid: false},
+		{claims: MapClaims{"exp": now.Add(-30 * time.Second).Unix()}, skew: time.Minute, valid: true},
+		{claims: MapClaims{"exp": now.Add(-2 * time.Minute).Unix()}, skew: time.Minute, valid: false},
+		{claims: MapClaims{"nbf": now.Add(30 * time.Second).Unix()}, skew: 0, valid: false},
+		{claims: MapClaims{"nbf": now.Add(30 * time.Second).Unix()}, skew: time.Minute, valid: true},
+		{claims: MapClaims{"nbf": now.Add(2 * time.Minute).Unix()}, skew: time.Minute, valid: false},
+		{claims: MapClaims{"iat": now.Add(30 * time.Second).Unix()}, skew: 0, valid: false},
+		{claims: MapClaims{"iat": now.Add(30 * time.Second).Unix()}, skew: time.Minute, valid: true},
+		{claims: MapClaims{"iat": now.Add(2 * time.Minute).Unix()}, skew: time.Minute, valid: false},
+	} {
+		t.Run(fmt.Sprintf("case=%d", k), func(t *testing.T) {
+			err := tc.claims.ValidWithClockSkew(tc.skew)
+			if tc.valid && err != nil {
+				t.Fatalf("Expected claims to be valid, got %v", err)
+			} else if !tc.valid && err == nil {
+				t.Fatal("Expected claims to be invalid")
+			}
+		})
+	}
+}
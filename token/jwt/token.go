@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/ory/x/errorsx"
 	"gopkg.in/square/go-jose.v2"
@@ -154,8 +155,16 @@ func Parse(tokenString string, keyFunc Keyfunc) (*Token, error) {
 
 // Parse, validate, and return a token.
 // keyFunc will receive the parsed token and should return the key for validating.
+// The key must be of the type the signing algorithm of the token requires, for example
+// a *rsa.PublicKey for RS256 and a []byte for HS256, otherwise the token is rejected.
 // If everything is kosher, err will be nil
 func ParseWithClaims(rawToken string, claims MapClaims, keyFunc Keyfunc) (*Token, error) {
+	return ParseWithClaimsAndClockSkew(rawToken, claims, 0, keyFunc)
+}
+
+// ParseWithClaimsAndClockSkew works like ParseWithClaims but tolerates the given clock skew when validating the time
+// based claims, see MapClaims.ValidWithClockSkew.
+func ParseWithClaimsAndClockSkew(rawToken string, claims MapClaims, skew time.Duration, keyFunc Keyfunc) (*Token, error) {
 	// Parse the token.
 	parsedToken, err := jwt.ParseSigned(rawToken)
 	if err != nil {
@@ -206,6 +215,9 @@ func ParseWithClaims(rawToken string, claims MapClaims, keyFunc Keyfunc) (*Token
 	_, validNoneKey := verificationKey.(*unsafeNoneMagicConstant)
 	isSignedToken := !(token.Method == SigningMethodNone && validNoneKey)
 	if isSignedToken {
+		if err := checkVerificationKey(token.Method, verificationKey); err != nil {
+			return token, err
+		}
 		if err := parsedToken.Claims(verificationKey, &claims); err != nil {
 			return token, &ValidationError{Errors: ValidationErrorSignatureInvalid, text: err.Error()}
 		}
@@ -214,7 +226,7 @@ func ParseWithClaims(rawToken string, claims MapClaims, keyFunc Keyfunc) (*Token
 	// Validate claims
 	// This validation is performed to be backwards compatible
 	// with jwt-go library behavior
-	if err := claims.Valid(); err != nil {
+	if err := claims.ValidWithClockSkew(skew); err != nil {
 		if e, ok := err.(*ValidationError); !ok {
 			err = &ValidationError{Inner: e, Errors: ValidationErrorClaimsInvalid}
 		}
@@ -229,7 +241,8 @@ func ParseWithClaims(rawToken string, claims MapClaims, keyFunc Keyfunc) (*Token
 // if underline value of v is not a pointer
 // it creates a pointer of it and returns it
 func pointer(v interface{}) interface{} {
-	if reflect.ValueOf(v).Kind() != reflect.Ptr {
+	// Keys which are slices, like ed25519.PublicKey, are passed by value.
+	if kind := reflect.ValueOf(v).Kind(); kind != reflect.Ptr && kind != reflect.Slice {
 		value := reflect.New(reflect.ValueOf(v).Type())
 		value.Elem().Set(reflect.ValueOf(v))
 		return value.Interface()
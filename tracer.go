@@ -0,0 +1,79 @@
+/*
+ * Copyright © 2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ *
+ */
+
+package fosite
+
+import (
+	"context"
+)
+
+// Tracer is notified at the boundaries of the operations which handle authorize, token, introspection and revocation
+// requests, for example to wrap them in OpenTelemetry spans without coupling fosite to a tracing library.
+type Tracer interface {
+	// StartSpan is invoked when the operation starts. The returned context is used for the rest of the operation,
+	// including the calls to handlers and the storage, so that it can carry the span.
+	StartSpan(ctx context.Context, operation string) context.Context
+
+	// EndSpan is invoked with the context returned by StartSpan when the operation has finished. The attributes are
+	// those known by then, for example "client_id", "grant_type", "response_type" and, if the operation failed,
+	// "error_code".
+	EndSpan(ctx context.Context, operation string, attributes map[string]string, err error)
+}
+
+// The operations passed to the Tracer.
+const (
+	TraceOperationNewAuthorizeRequest     = "fosite.NewAuthorizeRequest"
+	TraceOperationNewAuthorizeResponse    = "fosite.NewAuthorizeResponse"
+	TraceOperationNewAccessRequest        = "fosite.NewAccessRequest"
+	TraceOperationNewAccessResponse       = "fosite.NewAccessResponse"
+	TraceOperationNewIntrospectionRequest = "fosite.NewIntrospectionRequest"
+	TraceOperationNewRevocationRequest    = "fosite.NewRevocationRequest"
+)
+
+// startSpan starts a span for the operation if a Tracer is set. The returned function ends it with the audit event
+// of the operation.
+func (f *Fosite) startSpan(ctx context.Context, operation string) (context.Context, func(event *AuditEvent)) {
+	if f.Tracer == nil {
+		return ctx, func(*AuditEvent) {}
+	}
+
+	ctx = f.Tracer.StartSpan(ctx, operation)
+	return ctx, func(event *AuditEvent) {
+		f.Tracer.EndSpan(ctx, operation, newTraceAttributes(event), event.Error)
+	}
+}
+
+func newTraceAttributes(event *AuditEvent) map[string]string {
+	labels := newObserverLabels("", event)
+
+	attributes := map[string]string{}
+	for key, value := range map[string]string{
+		"client_id":     labels.ClientID,
+		"grant_type":    labels.GrantType,
+		"response_type": labels.ResponseType,
+		"error_code":    labels.ErrorCode,
+	} {
+		if value != "" {
+			attributes[key] = value
+		}
+	}
+	return attributes
+}